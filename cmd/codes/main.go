@@ -7,12 +7,16 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
+	"codes/internal/agent"
 	"codes/internal/commands"
 	"codes/internal/output"
+	"codes/internal/trace"
 	"codes/internal/tui"
 )
 
 var jsonFlag bool
+var chaosFlag string
+var traceFlag bool
 
 var rootCmd = &cobra.Command{
 	Use:   "codes",
@@ -22,14 +26,26 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonFlag, "json", false, "Output in JSON format")
+	// Fault injection for robustness testing; see agent.ChaosEnv
+	rootCmd.PersistentFlags().StringVar(&chaosFlag, "chaos", "", "Inject storage/messaging faults, e.g. disk_slow,msg_drop=0.5")
+	rootCmd.PersistentFlags().MarkHidden("chaos")
+	rootCmd.PersistentFlags().BoolVar(&traceFlag, "verbose", false, "Print timing spans (config, SSH, subprocesses, file scans) to stderr")
+	rootCmd.PersistentFlags().BoolVar(&traceFlag, "trace", false, "Same as --verbose")
 
 	rootCmd.AddCommand(commands.InitCmd)
 	rootCmd.AddCommand(commands.UpdateCmd)
+	rootCmd.AddCommand(commands.InstallCmd)
 	rootCmd.AddCommand(commands.VersionCmd)
+	rootCmd.AddCommand(commands.MaintenanceCmd)
+	rootCmd.AddCommand(commands.AuditCmd)
+	rootCmd.AddCommand(commands.McpCmd)
 	rootCmd.AddCommand(commands.DoctorCmd)
+	rootCmd.AddCommand(commands.SelftestCmd)
+	rootCmd.AddCommand(commands.UninstallCmd)
 	rootCmd.AddCommand(commands.StartCmd)
 	rootCmd.AddCommand(commands.ProfileCmd)
 	rootCmd.AddCommand(commands.ProjectCmd)
+	rootCmd.AddCommand(commands.ImportCmd)
 	rootCmd.AddCommand(commands.ConfigCmd)
 	rootCmd.AddCommand(commands.CompletionCmd)
 	rootCmd.AddCommand(commands.ServeCmd)
@@ -50,7 +66,7 @@ func init() {
 
 		// If --json flag, output project list in JSON
 		if jsonFlag {
-			commands.RunProjectList()
+			commands.RunProjectList(false, "")
 			return
 		}
 
@@ -72,12 +88,25 @@ func init() {
 }
 
 func main() {
+	// Time the whole command; the span is printed once Execute returns
+	endCommand := func() {}
+
 	// Propagate --json flag before execution
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		output.JSONMode = jsonFlag
+		// Exported so agent daemons started from here inherit it
+		if chaosFlag != "" {
+			os.Setenv(agent.ChaosEnv, chaosFlag)
+		}
+		if traceFlag {
+			trace.Enable()
+		}
+		endCommand = trace.Start("command", "cmd", cmd.CommandPath())
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	endCommand()
+	if err != nil {
 		os.Exit(1)
 	}
 }
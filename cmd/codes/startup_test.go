@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// BenchmarkVersionStartup measures the cold start of a simple command: every
+// run is a new process, so it covers loading the binary and running every
+// linked package's initializers. Compare with GODEBUG=inittrace=1 to see
+// which packages the time goes to.
+//
+//	go test -run '^$' -bench VersionStartup ./cmd/codes
+func BenchmarkVersionStartup(b *testing.B) {
+	bin := filepath.Join(b.TempDir(), "codes")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		b.Fatalf("go build: %v\n%s", err, out)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := exec.Command(bin, "version").Run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Activity event types besides the message types they are derived from.
+const (
+	ActivityMessage       = "message"
+	ActivityTaskCreated   = "task_created"
+	ActivityTaskStarted   = "task_started"
+	ActivityTaskCompleted = "task_completed"
+	ActivityTaskFailed    = "task_failed"
+)
+
+// ActivityEvent is one entry of a team's activity timeline.
+type ActivityEvent struct {
+	Time    time.Time
+	Type    string // ActivityMessage, a message type such as "progress", or a task lifecycle type
+	Agent   string // the sender, or the task's owner
+	To      string // recipient of a direct message
+	Summary string // message content, or "Task #N created: subject"
+	TaskID  int
+}
+
+// activityType maps a message type to its timeline type.
+func activityType(t MessageType) string {
+	switch t {
+	case MsgTaskCompleted:
+		return ActivityTaskCompleted
+	case MsgTaskFailed:
+		return ActivityTaskFailed
+	case MsgProgress:
+		return "progress"
+	case MsgHelpRequest:
+		return "help_request"
+	case MsgDiscovery:
+		return "discovery"
+	case MsgPlan:
+		return "plan"
+	}
+	return ActivityMessage
+}
+
+// TeamActivity returns a team's messages and task lifecycle events (created,
+// started, completed, failed) as one timeline, newest first.
+func TeamActivity(teamName string) ([]ActivityEvent, error) {
+	if _, err := GetTeam(teamName); err != nil {
+		return nil, err
+	}
+
+	var events []ActivityEvent
+	if msgs, err := GetAllTeamMessages(teamName, 0); err == nil {
+		for _, msg := range msgs {
+			events = append(events, ActivityEvent{
+				Time:    msg.CreatedAt,
+				Type:    activityType(msg.Type),
+				Agent:   msg.From,
+				To:      msg.To,
+				Summary: msg.Content,
+				TaskID:  msg.TaskID,
+			})
+		}
+	}
+
+	if tasks, err := ListTasks(teamName, "", ""); err == nil {
+		for _, t := range tasks {
+			event := func(at time.Time, typ, verb string) {
+				events = append(events, ActivityEvent{
+					Time:    at,
+					Type:    typ,
+					Agent:   t.Owner,
+					Summary: fmt.Sprintf("Task #%d %s: %s", t.ID, verb, t.Subject),
+					TaskID:  t.ID,
+				})
+			}
+			event(t.CreatedAt, ActivityTaskCreated, "created")
+			if t.StartedAt != nil {
+				event(*t.StartedAt, ActivityTaskStarted, "started")
+			}
+			if t.CompletedAt != nil && t.Status == TaskCompleted {
+				event(*t.CompletedAt, ActivityTaskCompleted, "completed")
+			}
+			if t.CompletedAt != nil && t.Status == TaskFailed {
+				event(*t.CompletedAt, ActivityTaskFailed, "failed")
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+	return events, nil
+}
@@ -46,6 +46,10 @@ type RunConfig struct {
 	AllowedTools []string // Allowed tools
 	MaxTurns     int      // Max agentic turns
 	PermMode     string   // Permission mode
+
+	// Host process priority (0 = unchanged), from config.AgentLimits
+	Nice        int // CPU niceness (Unix)
+	IONiceClass int // I/O scheduling class (Linux)
 }
 
 // RunResult holds the output from a CLI adapter execution.
@@ -9,6 +9,9 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"codes/internal/config"
+	"codes/internal/trace"
 )
 
 // ClaudeAdapter implements CLIAdapter for the Claude CLI tool.
@@ -25,8 +28,7 @@ func (a *ClaudeAdapter) Name() string {
 
 // Available checks if the claude CLI is installed and executable.
 func (a *ClaudeAdapter) Available() bool {
-	_, err := exec.LookPath("claude")
-	return err == nil
+	return config.ClaudeAvailable()
 }
 
 // Capabilities returns the full feature set of Claude CLI.
@@ -51,8 +53,8 @@ func (a *ClaudeAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, err
 		}
 	}
 
-	args := a.buildArgs(cfg)
-	cmd := exec.CommandContext(ctx, "claude", args...)
+	name, args := priorityCommand("claude", a.buildArgs(cfg), config.AgentLimits{Nice: cfg.Nice, IONiceClass: cfg.IONiceClass})
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = cfg.WorkDir
 
 	// Set up environment
@@ -68,7 +70,9 @@ func (a *ClaudeAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, err
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	end := trace.Start("exec", "cmd", "claude", "dir", cfg.WorkDir)
 	err := cmd.Run()
+	end()
 
 	// Parse JSON output
 	result := &RunResult{}
@@ -141,12 +145,42 @@ func (a *ClaudeAdapter) buildArgs(cfg RunConfig) []string {
 // claudeJSONOutput represents the JSON output from claude CLI.
 // This matches the format returned by `claude --output-format json`.
 type claudeJSONOutput struct {
-	Type      string  `json:"type"`
-	Result    string  `json:"result"`
-	Cost      float64 `json:"cost_usd"`
-	Duration  float64 `json:"duration_secs"`
-	SessionID string  `json:"session_id"`
-	IsError   bool    `json:"is_error"`
+	Type      string       `json:"type"`
+	Result    string       `json:"result"`
+	Cost      float64      `json:"cost_usd"`
+	TotalCost float64      `json:"total_cost_usd"` // newer CLIs report cost here
+	Usage     *claudeUsage `json:"usage"`
+	Duration  float64      `json:"duration_secs"`
+	SessionID string       `json:"session_id"`
+	IsError   bool         `json:"is_error"`
+}
+
+// claudeUsage is the token usage block of a claude result message.
+type claudeUsage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	CacheReadTokens  int `json:"cache_read_input_tokens"`
+	CacheWriteTokens int `json:"cache_creation_input_tokens"`
+}
+
+// costInfo returns the reported cost and token usage, or nil if the CLI
+// reported neither.
+func (out *claudeJSONOutput) costInfo() *CostInfo {
+	cost := out.TotalCost
+	if cost == 0 {
+		cost = out.Cost
+	}
+	if cost == 0 && out.Usage == nil {
+		return nil
+	}
+	info := &CostInfo{TotalCostUSD: cost}
+	if u := out.Usage; u != nil {
+		info.InputTokens = u.InputTokens
+		info.OutputTokens = u.OutputTokens
+		info.CacheReadTokens = u.CacheReadTokens
+		info.CacheWriteTokens = u.CacheWriteTokens
+	}
+	return info
 }
 
 // parseOutput parses the JSON output from claude CLI.
@@ -158,9 +192,7 @@ func (a *ClaudeAdapter) parseOutput(data []byte, result *RunResult) error {
 	if err := json.Unmarshal(data, &out); err == nil {
 		result.Result = out.Result
 		result.SessionID = out.SessionID
-		if out.Cost > 0 {
-			result.Cost = &CostInfo{TotalCostUSD: out.Cost}
-		}
+		result.Cost = out.costInfo()
 		if out.Duration > 0 {
 			result.Duration = time.Duration(out.Duration * float64(time.Second))
 		}
@@ -187,9 +219,7 @@ func (a *ClaudeAdapter) parseOutput(data []byte, result *RunResult) error {
 		if out.Type == "result" || out.Result != "" {
 			result.Result = out.Result
 			result.SessionID = out.SessionID
-			if out.Cost > 0 {
-				result.Cost = &CostInfo{TotalCostUSD: out.Cost}
-			}
+			result.Cost = out.costInfo()
 			if out.Duration > 0 {
 				result.Duration = time.Duration(out.Duration * float64(time.Second))
 			}
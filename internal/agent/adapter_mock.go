@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// MockAdapter implements CLIAdapter without running any CLI. It answers every
+// prompt instantly by echoing its first line, so the task pipeline can be
+// exercised (e.g. by `codes selftest`) without the claude CLI or an API key.
+type MockAdapter struct{}
+
+func init() {
+	RegisterAdapter("mock", &MockAdapter{})
+}
+
+// Name returns the adapter identifier.
+func (a *MockAdapter) Name() string {
+	return "mock"
+}
+
+// Available always reports true; there is nothing to install.
+func (a *MockAdapter) Available() bool {
+	return true
+}
+
+// Capabilities reports no optional features.
+func (a *MockAdapter) Capabilities() AdapterCapabilities {
+	return AdapterCapabilities{}
+}
+
+// Run returns "mock: <first line of the prompt>" unless ctx is already done.
+func (a *MockAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, error) {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(cfg.Prompt), "\n")
+	return &RunResult{
+		Result:   "mock: " + line,
+		Duration: time.Since(start),
+	}, nil
+}
@@ -56,12 +56,13 @@ func DefaultAdapter() CLIAdapter {
 		return adapter
 	}
 
-	// Fall back to any available adapter
+	// Fall back to any available adapter; the mock adapter does no real
+	// work, so it is only ever used when asked for by name
 	mu.RLock()
 	defer mu.RUnlock()
 
-	for _, adapter := range adapters {
-		if adapter.Available() {
+	for name, adapter := range adapters {
+		if name != "mock" && adapter.Available() {
 			return adapter
 		}
 	}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"testing"
 )
 
@@ -135,3 +136,44 @@ func TestTaskAdapterField(t *testing.T) {
 		t.Errorf("expected empty adapter, got %q", task2.Adapter)
 	}
 }
+
+func TestClaudeAdapterParsesUsage(t *testing.T) {
+	a := &ClaudeAdapter{}
+	out := `{"type":"result","result":"done","session_id":"s1","total_cost_usd":0.42,` +
+		`"usage":{"input_tokens":10,"output_tokens":20,"cache_read_input_tokens":30,"cache_creation_input_tokens":40}}`
+
+	var result RunResult
+	if err := a.parseOutput([]byte(out), &result); err != nil {
+		t.Fatal(err)
+	}
+	want := CostInfo{InputTokens: 10, OutputTokens: 20, CacheReadTokens: 30, CacheWriteTokens: 40, TotalCostUSD: 0.42}
+	if result.Cost == nil || *result.Cost != want {
+		t.Errorf("Cost = %+v, want %+v", result.Cost, want)
+	}
+
+	// Older CLIs only report cost_usd
+	result = RunResult{}
+	if err := a.parseOutput([]byte(`{"result":"done","cost_usd":0.1}`), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Cost == nil || result.Cost.TotalCostUSD != 0.1 {
+		t.Errorf("Cost = %+v, want 0.1 USD", result.Cost)
+	}
+}
+
+func TestMockAdapter(t *testing.T) {
+	adapter, err := GetAdapter("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := adapter.Run(context.Background(), RunConfig{Prompt: "ping\nmore detail"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Result != "mock: ping" {
+		t.Errorf("Result = %q, want %q", result.Result, "mock: ping")
+	}
+	if d := DefaultAdapter(); d != nil && d.Name() == "mock" {
+		t.Error("mock adapter must never be the default")
+	}
+}
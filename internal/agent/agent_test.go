@@ -1,13 +1,26 @@
 package agent
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
+
+	"codes/internal/config"
+	"codes/internal/update"
+	"codes/pkg/schemas"
 )
 
 // setupTestDir creates a temporary teams directory and overrides teamsBaseDir.
@@ -255,6 +268,53 @@ func TestTaskBlocking(t *testing.T) {
 	}
 }
 
+func TestCreateTasksBatch(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("batch-team", "", "")
+	existing, _ := CreateTask("batch-team", "Existing", "", "", nil, "", "", "")
+
+	tasks, err := CreateTasks("batch-team", []TaskSpec{
+		{Subject: "Schema", BlockedBy: []int{existing.ID}},
+		{Subject: "API", Owner: "w1", DependsOn: []int{1}},
+		{Subject: "UI", DependsOn: []int{1, 2}, Priority: PriorityHigh, Artifacts: []string{"dist/*"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	if len(tasks) != 3 || tasks[0].ID != existing.ID+1 || tasks[2].ID != existing.ID+3 {
+		t.Fatalf("unexpected IDs: %+v", tasks)
+	}
+	if tasks[1].Status != TaskAssigned || tasks[1].Owner != "w1" {
+		t.Errorf("owned task should be assigned: %+v", tasks[1])
+	}
+	got, _ := GetTask("batch-team", tasks[2].ID)
+	if fmt.Sprint(got.BlockedBy) != fmt.Sprint([]int{tasks[0].ID, tasks[1].ID}) || got.Priority != PriorityHigh || len(got.Artifacts) != 1 {
+		t.Errorf("dependencies not resolved: %+v", got)
+	}
+	if fmt.Sprint(tasks[0].BlockedBy) != fmt.Sprint([]int{existing.ID}) {
+		t.Errorf("existing blocker lost: %v", tasks[0].BlockedBy)
+	}
+
+	// Invalid batches create nothing
+	for _, specs := range [][]TaskSpec{
+		{{Subject: "A"}, {Subject: "B", DependsOn: []int{2}}},
+		{{Subject: "A"}, {Subject: ""}},
+		{{Subject: "A", BlockedBy: []int{999}}},
+		{{Subject: "A", Priority: "urgent"}},
+		nil,
+	} {
+		if _, err := CreateTasks("batch-team", specs); err == nil {
+			t.Errorf("expected error for %+v", specs)
+		}
+	}
+	all, _ := ListTasks("batch-team", "", "")
+	if len(all) != 4 {
+		t.Errorf("expected 4 tasks after rejected batches, got %d", len(all))
+	}
+}
+
 func TestMessages(t *testing.T) {
 	cleanup := setupTestDir(t)
 	defer cleanup()
@@ -468,6 +528,182 @@ func TestIsAgentAlive(t *testing.T) {
 	}
 }
 
+func TestHeartbeatLiveness(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("beat-team", "", "")
+
+	running := func(name string, pid int, host string) {
+		SaveAgentState(&AgentState{Name: name, Team: "beat-team", PID: pid, Host: host, Status: AgentRunning})
+	}
+	beat := func(name string, at time.Time, pid int, host string) {
+		writeJSON(agentHeartbeatPath("beat-team", name), heartbeat{At: at, PID: pid, Host: host})
+	}
+
+	// Daemon on another host: its PID means nothing here, a fresh beat is enough
+	running("remote", 999999, "build-box-2")
+	beat("remote", time.Now(), 999999, "build-box-2")
+	if !IsAgentAlive("beat-team", "remote") {
+		t.Error("remote agent with a fresh heartbeat should be alive")
+	}
+
+	// Remote daemon without a heartbeat can't be checked → not alive
+	running("remote-silent", 999999, "build-box-2")
+	if IsAgentAlive("beat-team", "remote-silent") {
+		t.Error("remote agent without a heartbeat should not be alive")
+	}
+
+	// Stale beat: the PID is alive (reused), but the daemon is gone
+	running("stale", os.Getpid(), "")
+	beat("stale", time.Now().Add(-2*heartbeatTimeout), os.Getpid(), localHost())
+	if IsAgentAlive("beat-team", "stale") {
+		t.Error("agent with a stale heartbeat should not be alive")
+	}
+	if state, _ := GetAgentState("beat-team", "stale"); state == nil || state.Status != AgentStopped {
+		t.Errorf("stale agent state = %+v, want stopped", state)
+	}
+
+	// Fresh beat from this host, but the process has crashed
+	running("crashed", 999999, localHost())
+	beat("crashed", time.Now(), 999999, localHost())
+	if IsAgentAlive("beat-team", "crashed") {
+		t.Error("local agent with a dead PID should not be alive despite a fresh heartbeat")
+	}
+
+	// HealthCheck and StaleStateCleanup don't trust a reused PID either
+	running("reused", os.Getpid(), "")
+	beat("reused", time.Now().Add(-2*heartbeatTimeout), os.Getpid(), localHost())
+	if err := HealthCheck("beat-team", "reused"); err == nil {
+		t.Error("HealthCheck should fail for an agent with a stale heartbeat")
+	}
+	running("reused", os.Getpid(), "")
+	running("fresh", os.Getpid(), localHost())
+	beat("fresh", time.Now(), os.Getpid(), localHost())
+	if err := StaleStateCleanup(); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := GetAgentState("beat-team", "reused"); state == nil || state.Status != AgentStopped || state.PID != 0 {
+		t.Errorf("reused-PID agent state after cleanup = %+v, want stopped", state)
+	}
+	if state, _ := GetAgentState("beat-team", "fresh"); state == nil || state.Status != AgentRunning {
+		t.Errorf("live agent state after cleanup = %+v, want running", state)
+	}
+
+	// runHeartbeat writes the file and removes it when stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runHeartbeat(ctx, "beat-team", "live")
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for readHeartbeat("beat-team", "live") == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	hb := readHeartbeat("beat-team", "live")
+	if hb == nil || hb.PID != os.Getpid() || time.Since(hb.At) > heartbeatTimeout {
+		t.Fatalf("heartbeat = %+v", hb)
+	}
+	cancel()
+	<-done
+	if readHeartbeat("beat-team", "live") != nil {
+		t.Error("heartbeat file should be removed when the daemon stops")
+	}
+}
+
+func TestAgentVersionSkew(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	origVersion := Version
+	defer func() { Version = origVersion }()
+
+	CreateTeam("skew-team", "", "")
+	AddMember("skew-team", TeamMember{Name: "old"})
+	AddMember("skew-team", TeamMember{Name: "new"})
+
+	// A daemon from before the upgrade, still running
+	SaveAgentState(&AgentState{
+		Name:    "old",
+		Team:    "skew-team",
+		PID:     os.Getpid(),
+		Status:  AgentIdle,
+		Version: "v1.4.0",
+	})
+
+	Version = "v1.5.0"
+	if skew := AgentVersionSkew("skew-team", "old"); skew == nil || skew.Skew != update.SkewMinor {
+		t.Errorf("AgentVersionSkew = %v, want minor skew", skew)
+	}
+	if skew := AgentVersionSkew("skew-team", "new"); skew != nil {
+		t.Errorf("AgentVersionSkew for stopped agent = %v, want nil", skew)
+	}
+
+	Version = "v2.0.0"
+	if _, err := StartAgent("skew-team", "new"); err == nil || !strings.Contains(err.Error(), "incompatible") {
+		t.Errorf("StartAgent with incompatible daemon running: err = %v", err)
+	}
+}
+
+func TestAgentLogs(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("log-team", "", "")
+	AddMember("log-team", TeamMember{Name: "worker"})
+
+	// Never started: no lines, no error
+	lines, err := ReadAgentLog("log-team", "worker", 10, "")
+	if err != nil || len(lines) != 0 {
+		t.Fatalf("ReadAgentLog before start = %v, %v", lines, err)
+	}
+	if _, err := ReadAgentLog("log-team", "ghost", 10, ""); err == nil {
+		t.Error("ReadAgentLog should fail for unknown agent")
+	}
+
+	w, err := openAgentLog("log-team", "worker")
+	if err != nil {
+		t.Fatalf("openAgentLog: %v", err)
+	}
+	logger := log.New(w, "[agent:worker] ", 0)
+	for i := 1; i <= 5; i++ {
+		logger.Printf("task #%d: done", i)
+	}
+	logger.Printf("task #6: error: claude exited 1")
+	w.Close()
+
+	lines, _ = ReadAgentLog("log-team", "worker", 3, "")
+	if len(lines) != 3 || lines[2] != "[agent:worker] task #6: error: claude exited 1" {
+		t.Errorf("tail = %q", lines)
+	}
+	lines, _ = ReadAgentLog("log-team", "worker", 0, `error|#1\b`)
+	if len(lines) != 2 {
+		t.Errorf("grep = %q", lines)
+	}
+	if _, err := ReadAgentLog("log-team", "worker", 10, "("); err == nil {
+		t.Error("expected error for invalid grep pattern")
+	}
+
+	// Rotation keeps the previous file and still serves its lines
+	w, _ = openAgentLog("log-team", "worker")
+	w.size = maxAgentLogSize
+	log.New(w, "", 0).Printf("after rotation")
+	w.Close()
+	if _, err := os.Stat(AgentLogPath("log-team", "worker") + ".1"); err != nil {
+		t.Errorf("expected rotated log: %v", err)
+	}
+	lines, _ = ReadAgentLog("log-team", "worker", 2, "")
+	if len(lines) != 2 || lines[0] != "[agent:worker] task #6: error: claude exited 1" || lines[1] != "after rotation" {
+		t.Errorf("tail across rotation = %q", lines)
+	}
+
+	RemoveMember("log-team", "worker")
+	if _, err := os.Stat(AgentLogPath("log-team", "worker")); !os.IsNotExist(err) {
+		t.Error("RemoveMember should delete the agent log")
+	}
+}
+
 func TestTaskDefaultPriority(t *testing.T) {
 	cleanup := setupTestDir(t)
 	defer cleanup()
@@ -584,6 +820,124 @@ func TestRedirectTaskCannotRedirectCompleted(t *testing.T) {
 	}
 }
 
+func TestFollowUpTask(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("followup-team", "", "")
+
+	task, _ := CreateTask("followup-team", "Add login", "build the login page", "worker1", nil, PriorityHigh, "", "/tmp/work")
+	if _, err := FollowUpTask("followup-team", task.ID, "also add logout", ""); err == nil {
+		t.Error("FollowUpTask on pending task should fail")
+	}
+
+	AssignTask("followup-team", task.ID, "worker1")
+	CompleteTask("followup-team", task.ID, "done")
+	if _, err := FollowUpTask("followup-team", task.ID, "also add logout", ""); err == nil {
+		t.Error("FollowUpTask without a session should fail")
+	}
+
+	UpdateTask("followup-team", task.ID, func(t *Task) error {
+		t.SessionID = "sess-123"
+		return nil
+	})
+	if _, err := FollowUpTask("followup-team", task.ID, "", ""); err == nil {
+		t.Error("FollowUpTask without instructions should fail")
+	}
+
+	next, err := FollowUpTask("followup-team", task.ID, "also add logout", "")
+	if err != nil {
+		t.Fatalf("FollowUpTask: %v", err)
+	}
+	if next.ID == task.ID || next.FollowUpOf != task.ID {
+		t.Errorf("follow-up id = %d, followUpOf = %d", next.ID, next.FollowUpOf)
+	}
+	if next.Subject != "Follow-up: Add login" || next.Description != "also add logout" {
+		t.Errorf("follow-up subject = %q, description = %q", next.Subject, next.Description)
+	}
+	if next.SessionID != "sess-123" || next.Owner != "worker1" || next.WorkDir != "/tmp/work" || next.Priority != PriorityHigh {
+		t.Errorf("follow-up did not inherit the original task: %+v", next)
+	}
+
+	named, err := FollowUpTask("followup-team", task.ID, "write tests", "Login tests")
+	if err != nil || named.Subject != "Login tests" {
+		t.Errorf("FollowUpTask with subject = %+v, %v", named, err)
+	}
+}
+
+func TestTaskPlacement(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("place-team", "", "")
+	AddMember("place-team", TeamMember{Name: "a", Skills: []string{"go"}})
+	AddMember("place-team", TeamMember{Name: "b", Skills: []string{"Go", "frontend"}})
+	AddMember("place-team", TeamMember{Name: "c", Skills: []string{"frontend"}})
+	for _, name := range []string{"a", "b"} {
+		SaveAgentState(&AgentState{Name: name, Team: "place-team", Status: AgentIdle, PID: os.Getpid()})
+	}
+
+	// Without a strategy, unassigned tasks stay pending.
+	task, _ := CreateTask("place-team", "left alone", "", "", nil, "", "", "")
+	if task.Owner != "" || task.Status != TaskPending {
+		t.Errorf("no strategy: owner = %q, status = %s", task.Owner, task.Status)
+	}
+
+	if err := SetAssignStrategy("place-team", "fastest"); err == nil {
+		t.Error("SetAssignStrategy accepted an unknown strategy")
+	}
+	SetAssignStrategy("place-team", AssignLeastLoaded)
+	// "a" has no work yet, "b" has one assigned task.
+	CreateTask("place-team", "busy", "", "b", nil, "", "", "")
+	task, _ = CreateTask("place-team", "least loaded", "", "", nil, "", "", "")
+	if task.Owner != "a" || task.Status != TaskAssigned {
+		t.Errorf("least_loaded: owner = %q, status = %s", task.Owner, task.Status)
+	}
+	tasks, _ := CreateTasks("place-team", []TaskSpec{{Subject: "x"}, {Subject: "y"}, {Subject: "z", Owner: "c"}})
+	if tasks[0].Owner != "a" && tasks[0].Owner != "b" || tasks[0].Owner == tasks[1].Owner || tasks[2].Owner != "c" {
+		t.Errorf("least_loaded batch owners = %q, %q, %q", tasks[0].Owner, tasks[1].Owner, tasks[2].Owner)
+	}
+
+	// Skills are matched case-insensitively; "c" is not running.
+	tasks, _ = CreateTasks("place-team", []TaskSpec{
+		{Subject: "ui", Skills: []string{"frontend"}},
+		{Subject: "rust", Skills: []string{"rust"}},
+	})
+	if tasks[0].Owner != "b" || tasks[1].Owner != "" || tasks[1].Status != TaskPending {
+		t.Errorf("skills: owners = %q, %q", tasks[0].Owner, tasks[1].Owner)
+	}
+
+	SetAssignStrategy("place-team", AssignRoundRobin)
+	tasks, _ = CreateTasks("place-team", []TaskSpec{{Subject: "r1"}, {Subject: "r2"}, {Subject: "r3"}})
+	if tasks[0].Owner == tasks[1].Owner || tasks[0].Owner != tasks[2].Owner {
+		t.Errorf("round_robin owners = %q, %q, %q", tasks[0].Owner, tasks[1].Owner, tasks[2].Owner)
+	}
+
+	SetAssignStrategy("place-team", AssignRandom)
+	task, _ = CreateTask("place-team", "random", "", "", nil, "", "", "")
+	if task.Owner != "a" && task.Owner != "b" {
+		t.Errorf("random: owner = %q", task.Owner)
+	}
+
+	plan, err := PlanTask("place-team", TaskSpec{Subject: "planned"})
+	if err != nil || plan.Task.Owner == "" {
+		t.Errorf("PlanTask with strategy = %+v, %v", plan, err)
+	}
+	plan, _ = PlanTask("place-team", TaskSpec{Subject: "nobody", Skills: []string{"cobol"}})
+	if plan.Task.Owner != "" || len(plan.Warnings) == 0 {
+		t.Errorf("PlanTask with unknown skill = %+v", plan)
+	}
+
+	// Auto-claim skips tasks the agent lacks the skills for.
+	CreateTeam("claim-team", "", "")
+	CreateTasks("claim-team", []TaskSpec{{Subject: "needs rust", Skills: []string{"rust"}}, {Subject: "anyone"}})
+	d := &Daemon{TeamName: "claim-team", AgentName: "a", Skills: []string{"go"}, logger: newTestLogger()}
+	next, err := d.findNextTask(true)
+	if err != nil || next == nil || next.Subject != "anyone" {
+		t.Errorf("findNextTask = %+v, %v; want the task without skills", next, err)
+	}
+}
+
 func TestCheckTaskCancellation(t *testing.T) {
 	cleanup := setupTestDir(t)
 	defer cleanup()
@@ -751,6 +1105,241 @@ func TestSendCallbackUnreachable(t *testing.T) {
 	d.sendCallback("http://127.0.0.1:1", taskNotification{Team: "cb-team", TaskID: 1, Status: "completed"})
 }
 
+func TestParseSummary(t *testing.T) {
+	s, err := parseSummary("Here you go:\n```json\n{\"changes\": \" Added retries \", \"files\": [\"client.go\"], \"followUps\": [\"tune backoff\"]}\n```")
+	if err != nil {
+		t.Fatalf("parseSummary: %v", err)
+	}
+	if s.Changes != "Added retries" || len(s.Files) != 1 || len(s.FollowUps) != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+	want := "Added retries\nFiles: client.go\nFollow-ups:\n- tune backoff"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	for _, reply := range []string{"no json here", `{"files": ["a.go"]}`, `{"changes": 3}`} {
+		if _, err := parseSummary(reply); err == nil {
+			t.Errorf("parseSummary(%q) succeeded, want error", reply)
+		}
+	}
+}
+
+func TestSummarizeResultSkips(t *testing.T) {
+	long := strings.Repeat("x", summaryThreshold+1)
+	task := &Task{ID: 1, Subject: "s"}
+	tests := []struct {
+		name, adapter, model, result string
+	}{
+		{"short result", "claude", "haiku", "done"},
+		{"summaries off", "claude", "", long},
+		{"no model selection", "mock", "haiku", long},
+	}
+	for _, tt := range tests {
+		s, err := SummarizeResult(context.Background(), tt.adapter, tt.model, task, tt.result)
+		if s != nil || err != nil {
+			t.Errorf("%s: SummarizeResult = %+v, %v; want nil, nil", tt.name, s, err)
+		}
+	}
+
+	if got := resultDigest(long, nil); len(got) != summaryThreshold {
+		t.Errorf("resultDigest without summary: len %d, want %d", len(got), summaryThreshold)
+	}
+	if got := resultDigest(long, &TaskSummary{Changes: "did it"}); got != "did it" {
+		t.Errorf("resultDigest with summary = %q", got)
+	}
+}
+
+func TestHandleTaskResultStoresSummary(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("HOME", t.TempDir())
+
+	CreateTeam("summary-team", "", "")
+	task, _ := CreateTask("summary-team", "Refactor", "", "worker", nil, "", "", "")
+	UpdateTask("summary-team", task.ID, func(t *Task) error {
+		t.Status = TaskRunning
+		return nil
+	})
+
+	d := &Daemon{TeamName: "summary-team", AgentName: "worker", logger: newTestLogger()}
+	state := &AgentState{Name: "worker", Team: "summary-team", Status: AgentRunning}
+	summary := &TaskSummary{Changes: "Split the parser", Files: []string{"parser.go"}, FollowUps: []string{"add fuzz tests"}}
+	long := strings.Repeat("details ", 200)
+	d.handleTaskResult(taskResult{task: task, result: &ClaudeResult{Result: long}, summary: summary}, state)
+
+	updated, _ := GetTask("summary-team", task.ID)
+	if updated.Status != TaskCompleted || updated.Result != long {
+		t.Errorf("task = %s with %d-char result, want completed with the full result", updated.Status, len(updated.Result))
+	}
+	if updated.Summary == nil || updated.Summary.Changes != "Split the parser" {
+		t.Errorf("Summary = %+v", updated.Summary)
+	}
+
+	msgs, _ := GetMessagesByType("summary-team", "lead", MsgTaskCompleted, false)
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Content, summary.String()) {
+		t.Errorf("completion report = %+v, want it to carry the summary", msgs)
+	}
+
+	path, _ := NotificationPath("summary-team", task.ID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read notification: %v", err)
+	}
+	var n taskNotification
+	json.Unmarshal(data, &n)
+	if n.Summary == nil || n.Summary.Changes != "Split the parser" {
+		t.Errorf("notification summary = %+v", n.Summary)
+	}
+	if err := schemas.Validate(schemas.TaskNotification, data); err != nil {
+		t.Errorf("notification %s: %v", data, err)
+	}
+}
+
+func TestNotificationsMatchSchema(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("HOME", t.TempDir()) // notification files and webhook config
+
+	var callbacks [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		callbacks = append(callbacks, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	CreateTeam("schema-team", "", "")
+	d := &Daemon{
+		TeamName:  "schema-team",
+		AgentName: "worker",
+		logger:    newTestLogger(),
+	}
+	for _, tc := range []struct{ status, detail string }{
+		{"completed", "all done"},
+		{"failed", "exit status 1"},
+		{"cancelled", ""},
+	} {
+		task, _ := CreateTask("schema-team", "Task "+tc.status, "", "worker", nil, "", "", "")
+		task.CallbackURL = srv.URL
+		d.writeNotification(task, tc.status, tc.detail)
+
+		path, _ := NotificationPath("schema-team", task.ID)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: read notification: %v", tc.status, err)
+		}
+		if err := schemas.Validate(schemas.TaskNotification, data); err != nil {
+			t.Errorf("%s notification %s: %v", tc.status, data, err)
+		}
+	}
+
+	if len(callbacks) != 3 {
+		t.Fatalf("got %d callbacks, want 3", len(callbacks))
+	}
+	for _, body := range callbacks {
+		if err := schemas.Validate(schemas.TaskNotification, body); err != nil {
+			t.Errorf("callback %s: %v", body, err)
+		}
+	}
+}
+
+func TestGitChanges(t *testing.T) {
+	dir := t.TempDir()
+	if gitHead(dir) != "" || gitChanges(dir, "") != nil {
+		t.Fatal("changes reported outside a git repository")
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "work")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0644)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	base := gitHead(dir)
+	if base == "" {
+		t.Fatal("gitHead found no commit")
+	}
+	if c := gitChanges(dir, base); c == nil || c.FilesChanged != 0 || c.Branch != "work" {
+		t.Errorf("no changes: %+v", c)
+	}
+
+	// One committed, one modified (and later committed again), one untracked
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c"), 0644)
+	git("add", "c.txt")
+	git("commit", "-q", "-m", "add c")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a2"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("n"), 0644)
+	if c := gitChanges(dir, base); c == nil || c.FilesChanged != 3 || c.Branch != "work" {
+		t.Errorf("changes = %+v, want 3 files on work", c)
+	}
+}
+
+func TestHookPayloadStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported on Windows")
+	}
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("HOME", t.TempDir())
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { config.ConfigPath = origPath }()
+
+	out := filepath.Join(t.TempDir(), "payload.json")
+	script := filepath.Join(t.TempDir(), "hook.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\ncat > "+out+"\n"), 0755)
+	config.SaveConfig(&config.Config{Hooks: map[string]string{"on_task_completed": script}})
+
+	CreateTeam("stats-team", "", "")
+	task, _ := CreateTask("stats-team", "Refactor", "", "worker", nil, "", "", "")
+	started := time.Now().Add(-90 * time.Second)
+	UpdateTask("stats-team", task.ID, func(t *Task) error {
+		t.Status = TaskRunning
+		t.StartedAt = &started
+		return nil
+	})
+	task.StartedAt = &started
+
+	d := &Daemon{TeamName: "stats-team", AgentName: "worker", logger: newTestLogger()}
+	state := &AgentState{Name: "worker", Team: "stats-team", Status: AgentRunning}
+	d.handleTaskResult(taskResult{
+		task:    task,
+		result:  &ClaudeResult{Result: "done", Cost: &CostInfo{TotalCostUSD: 0.42}},
+		changes: &TaskChanges{Branch: "feature", FilesChanged: 5},
+	}, state)
+
+	if stored, _ := GetTask("stats-team", task.ID); stored.Changes == nil || stored.Changes.FilesChanged != 5 {
+		t.Errorf("stored changes = %+v", stored.Changes)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	if err := schemas.Validate(schemas.Hook, data); err != nil {
+		t.Errorf("payload %s: %v", data, err)
+	}
+	var payload struct {
+		DurationSeconds float64 `json:"durationSeconds"`
+		CostUSD         float64 `json:"costUsd"`
+		FilesChanged    *int    `json:"filesChanged"`
+		Branch          string  `json:"branch"`
+	}
+	json.Unmarshal(data, &payload)
+	if payload.DurationSeconds < 90 || payload.CostUSD != 0.42 || payload.FilesChanged == nil || *payload.FilesChanged != 5 || payload.Branch != "feature" {
+		t.Errorf("payload = %s", data)
+	}
+}
+
 func TestTaskCallbackURLPersisted(t *testing.T) {
 	cleanup := setupTestDir(t)
 	defer cleanup()
@@ -782,3 +1371,1406 @@ func TestTaskCallbackURLPersisted(t *testing.T) {
 		t.Errorf("Loaded CallbackURL = %q, want %q", loaded.CallbackURL, "https://example.com/callback")
 	}
 }
+
+func TestCollectArtifacts(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("artifacts", "", "")
+	task, _ := CreateTask("artifacts", "Generate report", "", "", nil, "", "", "")
+	task, err := SetTaskArtifacts("artifacts", task.ID, []string{"out/*.pdf", "summary.txt", "missing.csv"})
+	if err != nil {
+		t.Fatalf("SetTaskArtifacts: %v", err)
+	}
+
+	workDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workDir, "out"), 0755)
+	os.WriteFile(filepath.Join(workDir, "out", "report.pdf"), []byte("pdf"), 0644)
+	os.WriteFile(filepath.Join(workDir, "out", "appendix.pdf"), []byte("appendix"), 0644)
+	os.WriteFile(filepath.Join(workDir, "summary.txt"), []byte("summary"), 0644)
+
+	files, err := CollectArtifacts("artifacts", task, workDir)
+	if err == nil {
+		t.Error("expected error for unmatched pattern")
+	}
+	if len(files) != 3 {
+		t.Fatalf("collected %v, want 3 files", files)
+	}
+
+	listed, err := ListArtifacts("artifacts", task.ID)
+	if err != nil {
+		t.Fatalf("ListArtifacts: %v", err)
+	}
+	want := []string{"appendix.pdf", "report.pdf", "summary.txt"}
+	if len(listed) != len(want) {
+		t.Fatalf("ListArtifacts = %v, want %v", listed, want)
+	}
+	for i := range want {
+		if listed[i] != want[i] {
+			t.Errorf("ListArtifacts[%d] = %q, want %q", i, listed[i], want[i])
+		}
+	}
+
+	// Collected artifacts must not be mistaken for task files
+	tasks, _ := ListTasks("artifacts", "", "")
+	if len(tasks) != 1 {
+		t.Errorf("ListTasks returned %d tasks, want 1", len(tasks))
+	}
+
+	path, err := ArtifactPath("artifacts", task.ID, "summary.txt")
+	if err != nil {
+		t.Fatalf("ArtifactPath: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "summary" {
+		t.Errorf("artifact content = %q, want %q", data, "summary")
+	}
+
+	for _, bad := range []string{"../1.json", "..", "a/b", ""} {
+		if _, err := ArtifactPath("artifacts", task.ID, bad); err == nil {
+			t.Errorf("ArtifactPath(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCollectArtifacts_OutsideWorkDir(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	os.WriteFile(secret, []byte("secret"), 0644)
+	workDir := filepath.Join(t.TempDir(), "work")
+	os.MkdirAll(workDir, 0755)
+	os.Symlink(secret, filepath.Join(workDir, "link.txt"))
+
+	CreateTeam("escape", "", "")
+	task, _ := CreateTask("escape", "Leak", "", "", nil, "", "", "")
+
+	task, _ = SetTaskArtifacts("escape", task.ID, []string{secret})
+	if _, err := CollectArtifacts("escape", task, workDir); err == nil {
+		t.Error("absolute pattern should be rejected")
+	}
+
+	rel, _ := filepath.Rel(workDir, secret)
+	task, _ = SetTaskArtifacts("escape", task.ID, []string{rel, "link.txt"})
+	files, _ := CollectArtifacts("escape", task, workDir)
+	if len(files) != 0 {
+		t.Errorf("collected %v from outside the work dir", files)
+	}
+}
+
+func TestPinnedContext(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("pinned", "", "")
+	task, _ := CreateTask("pinned", "Implement the API", "", "", nil, "", "", "")
+	if got, err := PinnedContext(task, t.TempDir()); got != "" || err != nil {
+		t.Errorf("no pinned files: %q, %v", got, err)
+	}
+
+	workDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workDir, "api"), 0755)
+	os.WriteFile(filepath.Join(workDir, "SPEC.md"), []byte("# Spec\nReturn 42."), 0644)
+	os.WriteFile(filepath.Join(workDir, "api", "interface.go"), []byte("type Answerer interface{ Answer() int }\n"), 0644)
+	task, err := SetTaskContextFiles("pinned", task.ID, []string{"SPEC.md", "api/interface.go"})
+	if err != nil {
+		t.Fatalf("SetTaskContextFiles: %v", err)
+	}
+	if got, _ := GetTask("pinned", task.ID); len(got.ContextFiles) != 2 {
+		t.Fatalf("stored context files = %v", got.ContextFiles)
+	}
+
+	got, err := PinnedContext(task, workDir)
+	if err != nil {
+		t.Fatalf("PinnedContext: %v", err)
+	}
+	for _, want := range []string{"<file path=\"SPEC.md\">\n# Spec\nReturn 42.\n</file>", "Answer() int }\n</file>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("PinnedContext missing %q:\n%s", want, got)
+		}
+	}
+
+	// Content is read when the task starts, not when it is created
+	os.WriteFile(filepath.Join(workDir, "SPEC.md"), []byte("Return 43."), 0644)
+	if got, _ := PinnedContext(task, workDir); !strings.Contains(got, "Return 43.") {
+		t.Errorf("PinnedContext did not pick up the change:\n%s", got)
+	}
+
+	task.ContextFiles = []string{"missing.md"}
+	if _, err := PinnedContext(task, workDir); err == nil || !strings.Contains(err.Error(), "missing.md") {
+		t.Errorf("missing file: err = %v", err)
+	}
+	if w := PinnedContextWarnings(task, workDir); len(w) != 1 {
+		t.Errorf("PinnedContextWarnings = %v, want one", w)
+	}
+
+	os.WriteFile(filepath.Join(workDir, "big.txt"), []byte(strings.Repeat("x", maxPinnedContextBytes+1)), 0644)
+	task.ContextFiles = []string{"big.txt"}
+	if _, err := PinnedContext(task, workDir); err == nil {
+		t.Error("expected an error for oversized context")
+	}
+
+	// Nothing outside the work dir can be pinned
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(secret, []byte("secret"), 0644)
+	os.Symlink(secret, filepath.Join(workDir, "link.txt"))
+	rel, _ := filepath.Rel(workDir, secret)
+	for _, name := range []string{secret, rel, "link.txt", "~/.ssh/id_rsa"} {
+		task.ContextFiles = []string{name}
+		if got, err := PinnedContext(task, workDir); err == nil || strings.Contains(got, "secret") {
+			t.Errorf("PinnedContext(%q) = %q, %v; want an error", name, got, err)
+		}
+	}
+}
+
+func TestParsePlanSteps(t *testing.T) {
+	got := ParsePlanSteps("1. Read the spec\n 2) Write the handler\n\n- add tests\n* 10. update docs\nship it")
+	want := []string{"Read the spec", "Write the handler", "add tests", "update docs", "ship it"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("ParsePlanSteps = %q, want %q", got, want)
+	}
+	if got := ParsePlanSteps("2026 plan: v1.2 release"); len(got) != 1 || got[0] != "2026 plan: v1.2 release" {
+		t.Errorf("ParsePlanSteps stripped a non-marker: %q", got)
+	}
+}
+
+func TestPostTaskPlan(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("plans", "", "")
+	task, _ := CreateTask("plans", "Add login", "", "w1", nil, "", "", "")
+
+	if _, err := PostTaskPlan("plans", "w1", task.ID, "1. do it"); err == nil {
+		t.Error("plan accepted for a task that is not running")
+	}
+	UpdateTask("plans", task.ID, func(t *Task) error {
+		t.Status = TaskRunning
+		return nil
+	})
+	if _, err := PostTaskPlan("plans", "w2", task.ID, "1. do it"); err == nil {
+		t.Error("plan accepted from an agent that does not own the task")
+	}
+	if _, err := PostTaskPlan("plans", "w1", task.ID, "\n - \n"); err == nil {
+		t.Error("empty plan accepted")
+	}
+
+	if _, err := PostTaskPlan("plans", "w1", task.ID, "1. Add the form\n2. Wire up the API"); err != nil {
+		t.Fatalf("PostTaskPlan: %v", err)
+	}
+	got, _ := GetTask("plans", task.ID)
+	if got.Plan == nil || len(got.Plan.Steps) != 2 || got.Plan.Agent != "w1" || got.Plan.PostedAt.IsZero() {
+		t.Fatalf("task plan = %+v", got.Plan)
+	}
+
+	msgs, _ := GetAllTeamMessages("plans", 0)
+	if len(msgs) != 1 || msgs[0].Type != MsgPlan || msgs[0].TaskID != task.ID || msgs[0].To != "" ||
+		!strings.Contains(msgs[0].Content, "2. Wire up the API") {
+		t.Errorf("plan message = %+v", msgs)
+	}
+}
+
+func TestUniqueArtifactName(t *testing.T) {
+	used := map[string]bool{"report.pdf": true, "report-2.pdf": true}
+	if got := uniqueArtifactName("report.pdf", used); got != "report-3.pdf" {
+		t.Errorf("uniqueArtifactName = %q, want %q", got, "report-3.pdf")
+	}
+	if got := uniqueArtifactName("new.txt", used); got != "new.txt" {
+		t.Errorf("uniqueArtifactName = %q, want %q", got, "new.txt")
+	}
+}
+
+func TestWritePrometheusMetrics(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("metrics", "", "")
+	AddMember("metrics", TeamMember{Name: "worker"})
+
+	done, _ := CreateTask("metrics", "Done", "", "worker", nil, "", "", "")
+	UpdateTask("metrics", done.ID, func(t *Task) error {
+		start := t.CreatedAt.Add(-90 * time.Second)
+		end := t.CreatedAt
+		t.Status = TaskCompleted
+		t.StartedAt = &start
+		t.CompletedAt = &end
+		return nil
+	})
+	CreateTask("metrics", "Waiting", "", "", nil, "", "", "")
+
+	SaveAgentState(&AgentState{
+		Name:     "worker",
+		Team:     "metrics",
+		PID:      os.Getpid(),
+		Status:   AgentIdle,
+		Counters: &AgentCounters{ClaudeFailures: 2, NotificationErrors: 1},
+	})
+
+	var buf bytes.Buffer
+	if err := WritePrometheusMetrics(&buf); err != nil {
+		t.Fatalf("WritePrometheusMetrics: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`codes_tasks{team="metrics",status="pending"} 1`,
+		`codes_tasks{team="metrics",status="completed"} 1`,
+		`codes_task_duration_seconds_bucket{team="metrics",le="60"} 0`,
+		`codes_task_duration_seconds_bucket{team="metrics",le="300"} 1`,
+		`codes_task_duration_seconds_count{team="metrics"} 1`,
+		`codes_agent_up{team="metrics",agent="worker"} 1`,
+		`codes_claude_failures_total{team="metrics",agent="worker"} 2`,
+		`codes_notification_errors_total{team="metrics",agent="worker"} 1`,
+		"# TYPE codes_task_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestIsProcessAlive(t *testing.T) {
+	if !isProcessAlive(os.Getpid()) {
+		t.Error("current process should be reported alive")
+	}
+
+	// A process that has exited and been reaped must be reported dead.
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	p, err := os.StartProcess(exe, []string{exe, "-test.run=^$"}, &os.ProcAttr{})
+	if err != nil {
+		t.Skip(err)
+	}
+	pid := p.Pid
+	p.Wait()
+	if isProcessAlive(pid) {
+		t.Errorf("exited pid %d reported alive", pid)
+	}
+}
+
+func TestListenStopEventWithoutSignal(t *testing.T) {
+	stop, closeStop := listenStopEvent("team", "agent")
+	defer closeStop()
+	select {
+	case <-stop:
+		t.Fatal("stop event fired without being signalled")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTaskStateMachine(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("fsm-team", "", "")
+	task, _ := CreateTask("fsm-team", "Work", "", "worker1", nil, "", "", "")
+
+	setStatus := func(s TaskStatus) (*Task, error) {
+		return UpdateTask("fsm-team", task.ID, func(t *Task) error {
+			t.Status = s
+			return nil
+		})
+	}
+
+	running, err := setStatus(TaskRunning)
+	if err != nil {
+		t.Fatalf("assigned → running: %v", err)
+	}
+	if running.StartedAt == nil {
+		t.Error("StartedAt should be set on transition to running")
+	}
+
+	// running → pending is not allowed
+	_, err = setStatus(TaskPending)
+	var invalid *InvalidTransitionError
+	if !errors.As(err, &invalid) || invalid.From != TaskRunning || invalid.To != TaskPending {
+		t.Fatalf("running → pending: expected InvalidTransitionError, got %v", err)
+	}
+	if got, _ := GetTask("fsm-team", task.ID); got.Status != TaskRunning {
+		t.Errorf("rejected transition must not be persisted, status = %s", got.Status)
+	}
+
+	// Unknown statuses are rejected
+	if _, err := setStatus("bogus"); !errors.As(err, &invalid) {
+		t.Errorf("unknown status: expected InvalidTransitionError, got %v", err)
+	}
+
+	done, err := setStatus(TaskCompleted)
+	if err != nil {
+		t.Fatalf("running → completed: %v", err)
+	}
+	if done.CompletedAt == nil {
+		t.Error("CompletedAt should be set on terminal transition")
+	}
+
+	// completed is final
+	if _, err := setStatus(TaskRunning); err == nil {
+		t.Error("completed → running should be rejected")
+	}
+
+	want := []TaskStatus{TaskAssigned, TaskRunning, TaskCompleted}
+	if len(done.History) != len(want) {
+		t.Fatalf("History = %+v, want %d entries", done.History, len(want))
+	}
+	for i, s := range want {
+		if done.History[i].To != s || done.History[i].At.IsZero() {
+			t.Errorf("History[%d] = %+v, want to=%s with timestamp", i, done.History[i], s)
+		}
+	}
+	if done.History[2].From != TaskRunning {
+		t.Errorf("History[2].From = %s, want %s", done.History[2].From, TaskRunning)
+	}
+}
+
+func TestTaskRetryAfterFailure(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("retry-team", "", "")
+	task, _ := CreateTask("retry-team", "Flaky", "", "worker1", nil, "", "", "")
+	FailTask("retry-team", task.ID, "boom")
+
+	requeued, err := UpdateTask("retry-team", task.ID, func(t *Task) error {
+		t.Status = TaskPending
+		t.Owner = ""
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed → pending: %v", err)
+	}
+	if requeued.CompletedAt != nil || requeued.StartedAt != nil {
+		t.Error("re-queued task should clear run timestamps")
+	}
+}
+
+func TestRecoverOrphanedTasks(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("HOME", t.TempDir()) // keep the failure notification out of ~/.codes
+
+	CreateTeam("orphan-team", "", "")
+	task, _ := CreateTask("orphan-team", "Interrupted", "", "worker1", nil, "", "", "")
+	UpdateTask("orphan-team", task.ID, func(t *Task) error {
+		t.Status = TaskRunning
+		return nil
+	})
+
+	d := &Daemon{
+		TeamName:  "orphan-team",
+		AgentName: "worker1",
+		logger:    newTestLogger(),
+	}
+	d.recoverOrphanedTasks()
+
+	got, _ := GetTask("orphan-team", task.ID)
+	if got.Status != TaskFailed {
+		t.Errorf("orphaned task status = %s, want %s", got.Status, TaskFailed)
+	}
+}
+
+func TestAcquireExecSlot(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	if _, ok := acquireExecSlot(0); !ok {
+		t.Fatal("unlimited acquire should always succeed")
+	}
+
+	release1, ok := acquireExecSlot(2)
+	if !ok {
+		t.Fatal("first slot should be free")
+	}
+	release2, ok := acquireExecSlot(2)
+	if !ok {
+		t.Fatal("second slot should be free")
+	}
+	if _, ok := acquireExecSlot(2); ok {
+		t.Fatal("third acquire should fail while both slots are held")
+	}
+
+	release1()
+	release3, ok := acquireExecSlot(2)
+	if !ok {
+		t.Fatal("slot should be free after release")
+	}
+	release2()
+	release3()
+}
+
+func TestPriorityCommand(t *testing.T) {
+	name, args := priorityCommand("claude", []string{"-p", "hi"}, config.AgentLimits{})
+	if name != "claude" || strings.Join(args, " ") != "-p hi" {
+		t.Errorf("zero limits changed command: %s %v", name, args)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	name, args = priorityCommand("claude", []string{"-p", "hi"}, config.AgentLimits{Nice: 10})
+	got := name + " " + strings.Join(args, " ")
+	if !strings.HasSuffix(got, "nice -n 10 claude -p hi") {
+		t.Errorf("priorityCommand = %q", got)
+	}
+}
+
+func TestResolvePollSettings(t *testing.T) {
+	base, max := resolvePollSettings(nil, nil)
+	if base != defaultPollInterval || max != defaultMaxPollInterval {
+		t.Errorf("defaults = %v/%v", base, max)
+	}
+
+	base, max = resolvePollSettings(&PollSettings{Interval: 10, MaxInterval: 300}, &PollSettings{Interval: 1})
+	if base != time.Second || max != 300*time.Second {
+		t.Errorf("member override = %v/%v, want 1s/5m0s", base, max)
+	}
+
+	// Ceiling below the base interval is raised to it (backoff disabled)
+	base, max = resolvePollSettings(&PollSettings{Interval: 120}, nil)
+	if base != 120*time.Second || max != 120*time.Second {
+		t.Errorf("clamped = %v/%v", base, max)
+	}
+}
+
+func TestPollBackoff(t *testing.T) {
+	now := time.Now()
+	b := newPollBackoff(3*time.Second, 20*time.Second, now)
+
+	if d := b.next(false, now.Add(time.Minute)); d != 3*time.Second {
+		t.Errorf("short idle should keep base interval, got %v", d)
+	}
+
+	idle := now.Add(idleBackoffAfter)
+	want := []time.Duration{6 * time.Second, 12 * time.Second, 20 * time.Second, 20 * time.Second}
+	for i, w := range want {
+		if d := b.next(false, idle); d != w {
+			t.Errorf("idle poll %d = %v, want %v", i, d, w)
+		}
+	}
+
+	if d := b.next(true, idle); d != 3*time.Second {
+		t.Errorf("busy should reset to base, got %v", d)
+	}
+	if d := b.next(false, idle.Add(time.Second)); d != 3*time.Second {
+		t.Errorf("idle timer should restart after work, got %v", d)
+	}
+}
+
+func TestWatchTeamChanges(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	if _, err := CreateTeam("watch-team", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	wake, stop := watchTeamChanges("watch-team", newTestLogger())
+	defer stop()
+	if wake == nil {
+		t.Skip("file watching not supported here")
+	}
+
+	if _, err := CreateTask("watch-team", "wake up", "", "", nil, PriorityNormal, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-wake:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected wake-up after task creation")
+	}
+}
+
+func TestSetPollSettings(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("poll-team", "", "")
+	AddMember("poll-team", TeamMember{Name: "w1"})
+
+	if err := SetPollSettings("poll-team", "", &PollSettings{Interval: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPollSettings("poll-team", "w1", &PollSettings{MaxInterval: 600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPollSettings("poll-team", "ghost", &PollSettings{}); err == nil {
+		t.Error("expected error for unknown member")
+	}
+
+	d, err := NewDaemon("poll-team", "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.pollInterval != 5*time.Second || d.maxPollInterval != 600*time.Second {
+		t.Errorf("daemon intervals = %v/%v", d.pollInterval, d.maxPollInterval)
+	}
+}
+
+func TestWatchTeams(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := WatchTeams(ctx)
+	if err != nil {
+		t.Skipf("file watching not supported here: %v", err)
+	}
+
+	// A team created after the watch starts is picked up.
+	if _, err := CreateTeam("new-team", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForTeamEvent(t, changes, "new-team")
+
+	// Drain the burst from team creation, then change a task.
+	for quiet := false; !quiet; {
+		select {
+		case <-changes:
+		case <-time.After(200 * time.Millisecond):
+			quiet = true
+		}
+	}
+	if _, err := CreateTask("new-team", "watched", "", "", nil, PriorityNormal, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForTeamEvent(t, changes, "new-team")
+
+	cancel()
+	for range changes {
+	}
+}
+
+// waitForTeamEvent waits until team appears on changes.
+func waitForTeamEvent(t *testing.T, changes <-chan string, team string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-changes:
+			if got == team {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no change event for team %q", team)
+		}
+	}
+}
+
+func TestDaemonWaitsForClaudeCLI(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("cli-team", "", "")
+	AddMember("cli-team", TeamMember{Name: "w1"})
+	task, err := CreateTask("cli-team", "queued", "", "", nil, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDaemon("cli-team", "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &AgentState{Name: "w1", Team: "cli-team", Status: AgentIdle}
+
+	t.Setenv("PATH", t.TempDir())
+	if busy, stop := d.poll(context.Background(), state); busy || stop {
+		t.Errorf("poll() = %v, %v; want idle while the CLI is missing", busy, stop)
+	}
+	if !strings.Contains(state.Activity, "claude CLI") {
+		t.Errorf("activity = %q, want a claude CLI hint", state.Activity)
+	}
+	got, _ := GetTask("cli-team", task.ID)
+	if got.Status != TaskPending {
+		t.Errorf("task status = %s, want it left pending", got.Status)
+	}
+
+	// Tasks for other adapters do not need the CLI
+	mock, err := CreateTasks("cli-team", []TaskSpec{{Subject: "mocked", Owner: "w1", Adapter: "mock"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := d.findNextTask(false)
+	if err != nil || next == nil || next.ID != mock[0].ID {
+		t.Errorf("findNextTask(false) = %+v, %v; want the mock task", next, err)
+	}
+}
+
+func TestGetUsageReport(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("usage-team", "", "")
+	AddMember("usage-team", TeamMember{Name: "w1"})
+	AddMember("usage-team", TeamMember{Name: "w2"})
+
+	now := time.Now()
+	finish := func(owner string, completed time.Time, cost *CostInfo) {
+		t.Helper()
+		task, err := CreateTask("usage-team", "work", "", owner, nil, "", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		UpdateTask("usage-team", task.ID, func(tk *Task) error {
+			tk.Status = TaskCompleted
+			tk.StartedAt = &completed
+			tk.CompletedAt = &completed
+			tk.Cost = cost
+			return nil
+		})
+	}
+	finish("w1", now.Add(-time.Hour), &CostInfo{InputTokens: 100, OutputTokens: 10, TotalCostUSD: 1.5})
+	finish("w1", now.Add(-2*time.Hour), &CostInfo{InputTokens: 50, TotalCostUSD: 0.5})
+	finish("w2", now.Add(-30*time.Minute), nil)
+	finish("w2", now.Add(-48*time.Hour), &CostInfo{TotalCostUSD: 9})
+	CreateTask("usage-team", "never ran", "", "w2", nil, "", "", "")
+
+	report, err := GetUsageReport("usage-team", now.Add(-12*time.Hour), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total.Tasks != 3 || report.Total.Untracked != 1 || report.Total.CostUSD != 2 || report.Total.InputTokens != 150 {
+		t.Errorf("total = %+v", report.Total)
+	}
+	if len(report.Teams) != 1 || len(report.Teams[0].Agents) != 2 {
+		t.Fatalf("teams = %+v", report.Teams)
+	}
+	if a := report.Teams[0].Agents[0]; a.Agent != "w1" || a.Tasks != 2 || a.CostUSD != 2 {
+		t.Errorf("first agent = %+v, want w1 with 2 tasks costing 2", a)
+	}
+
+	// No window counts everything that ran
+	report, _ = GetUsageReport("", time.Time{}, time.Time{})
+	if report.Total.Tasks != 4 || report.Total.CostUSD != 11 {
+		t.Errorf("all-time total = %+v", report.Total)
+	}
+
+	if _, err := GetUsageReport("ghost", time.Time{}, time.Time{}); err == nil {
+		t.Error("expected error for unknown team")
+	}
+}
+
+func TestParseChaos(t *testing.T) {
+	c, err := parseChaos("disk_slow, msg_drop=0.5,disk_fail=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chaosConfig{diskSlow: 200 * time.Millisecond, diskFail: 1, msgDrop: 0.5}
+	if c != want {
+		t.Errorf("parseChaos = %+v, want %+v", c, want)
+	}
+
+	c, err = parseChaos("msg_slow=10ms,bogus,disk_fail=2")
+	if err == nil || !strings.Contains(err.Error(), "bogus") || !strings.Contains(err.Error(), "disk_fail=2") {
+		t.Errorf("expected bad entries reported, got %v", err)
+	}
+	if c.msgSlow != 10*time.Millisecond || c.diskFail != 0 {
+		t.Errorf("valid entries should still apply: %+v", c)
+	}
+
+	if c, err := parseChaos(""); err != nil || c.enabled() {
+		t.Errorf("empty spec = %+v, %v; want disabled", c, err)
+	}
+}
+
+func TestChaosInjection(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	CreateTeam("chaos-team", "", "")
+
+	orig := chaosSettings
+	defer func() { chaosSettings = orig }()
+
+	chaosSettings = func() chaosConfig { return chaosConfig{msgDrop: 1} }
+	if _, err := SendMessage("chaos-team", "lead", "w1", "hello"); err != nil {
+		t.Fatalf("dropped message should look sent: %v", err)
+	}
+	if msgs, _ := GetMessages("chaos-team", "w1", false); len(msgs) != 0 {
+		t.Errorf("expected message to be dropped, got %d", len(msgs))
+	}
+
+	chaosSettings = func() chaosConfig { return chaosConfig{diskFail: 1} }
+	if _, err := CreateTask("chaos-team", "doomed", "", "", nil, "", "", ""); !errors.Is(err, errChaos) {
+		t.Errorf("CreateTask error = %v, want injected failure", err)
+	}
+}
+
+func TestPlanTask(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	workDir := t.TempDir()
+	CreateTeam("plan-team", "", workDir)
+	AddMember("plan-team", TeamMember{Name: "w1"})
+	dep, _ := CreateTask("plan-team", "first", "", "", nil, "", "", "")
+
+	plan, err := PlanTask("plan-team", TaskSpec{Subject: "second", Owner: "w1", BlockedBy: []int{dep.ID}, Priority: PriorityHigh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Task.ID != dep.ID+1 || plan.Task.Status != TaskAssigned || plan.Task.Priority != PriorityHigh {
+		t.Errorf("planned task = %+v", plan.Task)
+	}
+	if plan.WorkDir != workDir || !plan.Blocked {
+		t.Errorf("plan = workDir %q blocked %v, want %q and blocked", plan.WorkDir, plan.Blocked, workDir)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "not running") {
+		t.Errorf("warnings = %v, want the owner not running", plan.Warnings)
+	}
+	if tasks, _ := ListTasks("plan-team", "", ""); len(tasks) != 1 {
+		t.Errorf("dry run wrote tasks: have %d", len(tasks))
+	}
+
+	plan, _ = PlanTask("plan-team", TaskSpec{Subject: "x", Owner: "ghost", WorkDir: filepath.Join(workDir, "missing")})
+	if len(plan.Warnings) != 2 {
+		t.Errorf("warnings = %v, want unknown owner and missing workdir", plan.Warnings)
+	}
+
+	if _, err := PlanTask("plan-team", TaskSpec{Subject: "x", BlockedBy: []int{99}}); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("unknown blockedBy: err = %v", err)
+	}
+	if _, err := PlanTask("plan-team", TaskSpec{Subject: "x", Priority: "urgent"}); err == nil {
+		t.Error("expected invalid priority to be rejected")
+	}
+	if _, err := PlanTask("ghost", TaskSpec{Subject: "x"}); !errors.Is(err, ErrTeamNotFound) {
+		t.Errorf("unknown team: err = %v", err)
+	}
+}
+
+func TestPlanRedirectAndStartAll(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	CreateTeam("plan-team", "", t.TempDir())
+	AddMember("plan-team", TeamMember{Name: "w1"})
+	AddMember("plan-team", TeamMember{Name: "w2"})
+	task, _ := CreateTask("plan-team", "old", "", "w1", nil, PriorityLow, "", "")
+	UpdateTask("plan-team", task.ID, func(t *Task) error { t.Status = TaskRunning; return nil })
+
+	plan, err := PlanRedirect("plan-team", task.ID, "new instructions", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.StopsRun || plan.Cancel.ID != task.ID {
+		t.Errorf("redirect plan = %+v", plan)
+	}
+	if c := plan.Create.Task; c.Subject != "old" || c.Owner != "w1" || c.Priority != PriorityLow || c.Description != "new instructions" {
+		t.Errorf("replacement = %+v", c)
+	}
+	if got, _ := GetTask("plan-team", task.ID); got.Status != TaskRunning {
+		t.Errorf("dry run changed the task to %s", got.Status)
+	}
+
+	CancelTask("plan-team", task.ID)
+	var te *InvalidTransitionError
+	if _, err := PlanRedirect("plan-team", task.ID, "again", ""); !errors.As(err, &te) {
+		t.Errorf("redirect of cancelled task: err = %v", err)
+	}
+
+	SaveAgentState(&AgentState{Name: "w2", Team: "plan-team", Status: AgentIdle, PID: os.Getpid()})
+	starts, err := PlanStartAll("plan-team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(starts) != 2 || !starts[0].Start || starts[1].Start || starts[1].PID != os.Getpid() {
+		t.Errorf("start plan = %+v, want w1 started and w2 already running", starts)
+	}
+}
+
+func TestTeamTemplates(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("review", "Code review", "/tmp/review")
+	AddMember("review", TeamMember{Name: "lead", Type: "leader", Model: "opus"})
+	AddMember("review", TeamMember{Name: "w1", Role: "reviewer"})
+	SetPollSettings("review", "", &PollSettings{Interval: 5})
+
+	tmpl, err := SaveTeamTemplate("review", "standard-review", false)
+	if err != nil {
+		t.Fatalf("SaveTeamTemplate: %v", err)
+	}
+	if tmpl.Source != "review" || len(tmpl.Members) != 2 || tmpl.Poll == nil || tmpl.Poll.Interval != 5 {
+		t.Errorf("template = %+v", tmpl)
+	}
+	if _, err := SaveTeamTemplate("review", "standard-review", false); !errors.Is(err, ErrTemplateExists) {
+		t.Errorf("saving twice: err = %v, want ErrTemplateExists", err)
+	}
+	AddMember("review", TeamMember{Name: "w2"})
+	if tmpl, err := SaveTeamTemplate("review", "standard-review", true); err != nil || len(tmpl.Members) != 3 {
+		t.Errorf("overwrite: %+v, %v", tmpl, err)
+	}
+	for _, name := range []string{"", "../x", ".hidden", "a/b"} {
+		if _, err := SaveTeamTemplate("review", name, false); err == nil {
+			t.Errorf("SaveTeamTemplate(%q) succeeded, want error", name)
+		}
+	}
+
+	// Templates don't show up as teams
+	if teams, _ := ListTeams(); len(teams) != 1 {
+		t.Errorf("ListTeams = %v, want only review", teams)
+	}
+	if list, err := ListTeamTemplates(); err != nil || len(list) != 1 || list[0].Name != "standard-review" {
+		t.Errorf("ListTeamTemplates = %v, %v", list, err)
+	}
+
+	cfg, err := InstantiateTeamTemplate("standard-review", "review-2", TeamFromTemplate{WorkDir: "/tmp/other"})
+	if err != nil {
+		t.Fatalf("InstantiateTeamTemplate: %v", err)
+	}
+	if cfg.Description != "Code review" || cfg.WorkDir != "/tmp/other" || len(cfg.Members) != 3 || cfg.Poll == nil {
+		t.Errorf("instantiated = %+v", cfg)
+	}
+	if loaded, _ := GetTeam("review-2"); loaded == nil || len(loaded.Members) != 3 || loaded.Members[0].Model != "opus" {
+		t.Errorf("stored team = %+v", loaded)
+	}
+	if _, err := InstantiateTeamTemplate("standard-review", "review-2", TeamFromTemplate{}); !errors.Is(err, ErrTeamExists) {
+		t.Errorf("instantiate onto existing team: err = %v, want ErrTeamExists", err)
+	}
+	if _, err := InstantiateTeamTemplate("missing", "review-3", TeamFromTemplate{}); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("missing template: err = %v, want ErrTemplateNotFound", err)
+	}
+
+	if err := DeleteTeamTemplate("standard-review"); err != nil {
+		t.Fatalf("DeleteTeamTemplate: %v", err)
+	}
+	if _, err := GetTeamTemplate("standard-review"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("after delete: err = %v, want ErrTemplateNotFound", err)
+	}
+}
+
+func TestSleepInhibitor(t *testing.T) {
+	if args := sleepInhibitorArgs("darwin", 42, "x"); strings.Join(args, " ") != "caffeinate -i -s -w 42" {
+		t.Errorf("darwin: %v", args)
+	}
+	if args := sleepInhibitorArgs("linux", 42, "task #1"); args[0] != "systemd-inhibit" || !strings.Contains(strings.Join(args, " "), "--why=task #1") || args[len(args)-3] != "--pid=42" {
+		t.Errorf("linux: %v", args)
+	}
+	if args := sleepInhibitorArgs("windows", 42, "x"); args != nil {
+		t.Errorf("windows: %v", args)
+	}
+
+	if runtime.GOOS != "linux" {
+		t.Skip("fake inhibitor is a shell script named systemd-inhibit")
+	}
+	// A fake systemd-inhibit that records its arguments and holds on.
+	bin := t.TempDir()
+	marker := filepath.Join(bin, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + marker + "\nexec sleep 60\n"
+	if err := os.WriteFile(filepath.Join(bin, "systemd-inhibit"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	d := &Daemon{TeamName: "t", AgentName: "a", logger: newTestLogger()}
+	release := d.inhibitSleep("agent t/a is running task #1")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(marker); err == nil && strings.Contains(string(data), "--pid="+fmt.Sprint(os.Getpid())) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("inhibitor was not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		release()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("release did not stop the inhibitor")
+	}
+
+	// Without an inhibitor on PATH it is a no-op.
+	t.Setenv("PATH", t.TempDir())
+	d.inhibitSleep("x")()
+}
+
+func TestPowerPolicy(t *testing.T) {
+	on, pct := parsePmsetBatt("Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1234)\t42%; discharging; 3:10 remaining present: true\n")
+	if !on || pct != 42 {
+		t.Errorf("pmset batt on battery = %v, %d", on, pct)
+	}
+	if on, pct := parsePmsetBatt("Now drawing from 'AC Power'\n -InternalBattery-0 (id=1234)\t80%; charging\n"); on || pct != 80 {
+		t.Errorf("pmset batt on AC = %v, %d", on, pct)
+	}
+	for out, want := range map[string]bool{
+		"Note: No thermal warning level has been recorded\nNote: No performance warning level has been recorded\n": false,
+		"CPU Power notify\n\tCPU_Scheduler_Limit \t= 100\n\tCPU_Available_CPUs \t= 8\n\tCPU_Speed_Limit \t= 100\n": false,
+		"CPU Power notify\n\tCPU_Speed_Limit \t= 72\n":                                                             true,
+		"Thermal warning level set to 1.\n":                                                                        true,
+	} {
+		if got := parsePmsetTherm(out); got != want {
+			t.Errorf("parsePmsetTherm(%q) = %v, want %v", out, got, want)
+		}
+	}
+
+	// A fake /sys/class with a discharging battery and a zone past its
+	// passive trip point.
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("power_supply/AC/type", "Mains")
+	write("power_supply/AC/online", "0")
+	write("power_supply/BAT0/type", "Battery")
+	write("power_supply/BAT0/status", "Discharging")
+	write("power_supply/BAT0/capacity", "15")
+	write("thermal/thermal_zone0/temp", "91000")
+	write("thermal/thermal_zone0/trip_point_0_type", "passive")
+	write("thermal/thermal_zone0/trip_point_0_temp", "90000")
+	write("thermal/thermal_zone0/trip_point_1_type", "critical")
+	write("thermal/thermal_zone0/trip_point_1_temp", "105000")
+	if st := readSysfsPower(root); !st.OnBattery || st.Battery != 15 || !st.Thermal {
+		t.Errorf("sysfs status = %+v", st)
+	}
+	write("power_supply/BAT0/status", "Charging")
+	write("thermal/thermal_zone0/temp", "60000")
+	if st := readSysfsPower(root); st.OnBattery || st.Thermal {
+		t.Errorf("sysfs status after plugging in = %+v", st)
+	}
+
+	for _, tc := range []struct {
+		policy config.PowerPolicy
+		status powerStatus
+		paused bool
+	}{
+		{config.PowerPolicy{}, powerStatus{OnBattery: true, Battery: 5, Thermal: true}, false},
+		{config.PowerPolicy{MinBattery: 20}, powerStatus{OnBattery: true, Battery: 15}, true},
+		{config.PowerPolicy{MinBattery: 20}, powerStatus{OnBattery: true, Battery: 50}, false},
+		{config.PowerPolicy{MinBattery: 20}, powerStatus{OnBattery: false, Battery: 15}, false},
+		{config.PowerPolicy{MinBattery: 20}, powerStatus{OnBattery: true, Battery: -1}, false},
+		{config.PowerPolicy{MinBattery: 100}, powerStatus{OnBattery: true, Battery: 100}, true},
+		{config.PowerPolicy{PauseOnThermal: true}, powerStatus{Battery: -1, Thermal: true}, true},
+	} {
+		if got := powerPauseReason(tc.policy, tc.status) != ""; got != tc.paused {
+			t.Errorf("policy %+v, status %+v: paused = %v, want %v", tc.policy, tc.status, got, tc.paused)
+		}
+	}
+}
+
+func TestDaemonPowerPause(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { config.ConfigPath = origPath }()
+	if err := config.SaveConfig(&config.Config{PowerPolicy: &config.PowerPolicy{MinBattery: 20}}); err != nil {
+		t.Fatal(err)
+	}
+
+	status := powerStatus{OnBattery: true, Battery: 10}
+	origRead := readPowerStatus
+	readPowerStatus = func() powerStatus { return status }
+	defer func() { readPowerStatus = origRead }()
+
+	if _, err := CreateTeam("power-team", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{TeamName: "power-team", AgentName: "a", logger: newTestLogger()}
+	state := &AgentState{Name: "a", Team: "power-team"}
+
+	if !d.powerPaused(state) {
+		t.Fatal("not paused on battery at 10%")
+	}
+	if state.PausedReason != "on battery at 10% (below 20%)" || !strings.HasPrefix(state.Activity, "paused: ") {
+		t.Errorf("state = %+v", state)
+	}
+
+	// Within the check interval the cached decision holds.
+	status = powerStatus{Battery: 10}
+	if !d.powerPaused(state) {
+		t.Error("re-read power state before powerCheckInterval elapsed")
+	}
+
+	d.powerCheckedAt = time.Time{}
+	if d.powerPaused(state) {
+		t.Fatal("still paused on AC power")
+	}
+	if state.PausedReason != "" || state.Activity != "" {
+		t.Errorf("state after resume = %+v", state)
+	}
+}
+
+func TestArchiveTasksAndCompactMessages(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("archive-team", "", "")
+	done, _ := CreateTask("archive-team", "Dropped", "", "", nil, "", "", "")
+	open, _ := CreateTask("archive-team", "Open", "", "", nil, "", "", "")
+	UpdateTask("archive-team", done.ID, func(t *Task) error {
+		t.Status = TaskCancelled
+		return nil
+	})
+
+	// Everything counts as old with a cutoff in the future
+	cutoff := time.Now().Add(time.Hour)
+	n, err := ArchiveTasks("archive-team", cutoff)
+	if err != nil || n != 1 {
+		t.Fatalf("ArchiveTasks = %d, %v; want 1", n, err)
+	}
+	tasks, _ := ListTasks("archive-team", "", "")
+	if len(tasks) != 1 || tasks[0].ID != open.ID {
+		t.Errorf("active tasks = %+v, want only %d", tasks, open.ID)
+	}
+	if got, err := GetTask("archive-team", done.ID); err != nil || got.Status != TaskCancelled {
+		t.Errorf("GetTask on archived task = %+v, %v", got, err)
+	}
+	if next, _ := CreateTask("archive-team", "Next", "", "", nil, "", "", ""); next.ID <= done.ID || next.ID == open.ID {
+		t.Errorf("new task ID %d reuses an existing one", next.ID)
+	}
+
+	read, _ := SendMessage("archive-team", "lead", "worker", "old news")
+	SendMessage("archive-team", "lead", "worker", "unread")
+	MarkRead("archive-team", read.ID)
+	n, err = CompactMessages("archive-team", cutoff)
+	if err != nil || n != 1 {
+		t.Fatalf("CompactMessages = %d, %v; want 1", n, err)
+	}
+	msgs, _ := GetMessages("archive-team", "worker", false)
+	if len(msgs) != 1 || msgs[0].Content != "unread" {
+		t.Errorf("messages after compaction = %+v", msgs)
+	}
+	data, err := os.ReadFile(messageArchivePath("archive-team"))
+	if err != nil || !strings.Contains(string(data), "old news") {
+		t.Errorf("archive = %q, %v", data, err)
+	}
+}
+
+func TestPruneNotifications(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	path, _ := NotificationPath("prune-team", 1)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("{}"), 0644)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(path, old, old)
+	fresh, _ := NotificationPath("prune-team", 2)
+	os.WriteFile(fresh, []byte("{}"), 0644)
+
+	n, err := PruneNotifications(time.Now().Add(-24 * time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("PruneNotifications = %d, %v; want 1", n, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("old notification still exists")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("fresh notification removed: %v", err)
+	}
+}
+
+func TestCheckAgentStartable(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("start-team", "", "")
+	AddMember("start-team", TeamMember{Name: "worker"})
+
+	if err := CheckAgentStartable("start-team", "ghost"); !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("unknown agent: got %v, want ErrAgentNotFound", err)
+	}
+	if err := CheckAgentStartable("start-team", "worker"); err != nil {
+		t.Errorf("stopped agent: unexpected error %v", err)
+	}
+
+	SaveAgentState(&AgentState{Name: "worker", Team: "start-team", PID: os.Getpid(), Status: AgentIdle})
+	if err := CheckAgentStartable("start-team", "worker"); !errors.Is(err, ErrAgentRunning) {
+		t.Errorf("running agent: got %v, want ErrAgentRunning", err)
+	}
+}
+
+func TestWorkDirPolicy(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { config.ConfigPath = origPath }()
+
+	allowed := t.TempDir()
+	config.SaveConfig(&config.Config{})
+	if err := config.SetWorkDirPolicy(config.WorkDirPolicy{AllowedRoots: []string{allowed}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CreateTeam("policy-root", "", "/"); !errors.Is(err, config.ErrWorkDirNotAllowed) {
+		t.Errorf("CreateTeam(/) = %v, want ErrWorkDirNotAllowed", err)
+	}
+	if _, err := GetTeam("policy-root"); err == nil {
+		t.Error("team created despite the policy")
+	}
+
+	if _, err := CreateTeam("policy-team", "", allowed); err != nil {
+		t.Fatal(err)
+	}
+	// A task's own work dir overrides the team's, so it is checked too
+	if _, err := CreateTask("policy-team", "Read keys", "", "", nil, "", "", "/etc"); !errors.Is(err, config.ErrWorkDirNotAllowed) {
+		t.Errorf("CreateTask(/etc) = %v, want ErrWorkDirNotAllowed", err)
+	}
+	if _, err := CreateTask("policy-team", "Build", "", "", nil, "", "", filepath.Join(allowed, "app")); err != nil {
+		t.Errorf("CreateTask inside the root: %v", err)
+	}
+	_, err := CreateTasks("policy-team", []TaskSpec{{Subject: "ok"}, {Subject: "escape", WorkDir: "/"}})
+	if !errors.Is(err, config.ErrWorkDirNotAllowed) {
+		t.Errorf("CreateTasks = %v, want ErrWorkDirNotAllowed", err)
+	}
+	if tasks, _ := ListTasks("policy-team", "", ""); len(tasks) != 1 {
+		t.Errorf("got %d tasks, want 1", len(tasks))
+	}
+
+	// Agents of a team created before the policy may not start
+	team, _ := GetTeam("policy-team")
+	team.WorkDir = "/"
+	writeJSON(teamConfigPath("policy-team"), team)
+	AddMember("policy-team", TeamMember{Name: "worker"})
+	if err := CheckAgentStartable("policy-team", "worker"); !errors.Is(err, config.ErrWorkDirNotAllowed) {
+		t.Errorf("CheckAgentStartable = %v, want ErrWorkDirNotAllowed", err)
+	}
+}
+
+func TestIdlePolicy(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(t.TempDir(), "config.json") // no hooks
+	defer func() { config.ConfigPath = origPath }()
+	origInterval := idleCheckInterval
+	idleCheckInterval = 0
+	defer func() { idleCheckInterval = origInterval }()
+
+	CreateTeam("idle-team", "", "")
+	AddMember("idle-team", TeamMember{Name: "lead"})
+	AddMember("idle-team", TeamMember{Name: "worker"})
+	if err := SetIdlePolicy("idle-team", &IdlePolicy{StopAfter: 0}); err == nil {
+		t.Error("expected stopAfter 0 to be rejected")
+	}
+	if err := SetIdlePolicy("idle-team", &IdlePolicy{StopAfter: 30, Archive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	task, _ := CreateTask("idle-team", "Work", "", "", nil, "", "", "")
+	if _, idle, _ := TeamIdleSince("idle-team"); idle {
+		t.Error("team with a pending task reported idle")
+	}
+	if _, err := CancelTask("idle-team", task.ID); err != nil {
+		t.Fatal(err)
+	}
+	since, idle, err := TeamIdleSince("idle-team")
+	if err != nil || !idle || time.Since(since) > time.Minute {
+		t.Fatalf("TeamIdleSince = %v, %v, %v", since, idle, err)
+	}
+
+	// Both agents "running" in this process; the lead acts for the team
+	longAgo := time.Now().Add(-time.Hour)
+	for _, name := range []string{"lead", "worker"} {
+		SaveAgentState(&AgentState{Name: name, Team: "idle-team", PID: os.Getpid(), Status: AgentIdle, StartedAt: longAgo})
+	}
+	lead := &Daemon{TeamName: "idle-team", AgentName: "lead", logger: newTestLogger()}
+	worker := &Daemon{TeamName: "idle-team", AgentName: "worker", logger: newTestLogger()}
+	state := &AgentState{StartedAt: longAgo}
+
+	// Not idle long enough yet
+	if lead.stopIfTeamIdle(state) {
+		t.Error("stopped before 30 minutes without work")
+	}
+
+	// Age the team and task as if it finished an hour ago
+	cfg, _ := GetTeam("idle-team")
+	cfg.CreatedAt = longAgo
+	writeJSON(teamConfigPath("idle-team"), cfg)
+	tk, _ := GetTask("idle-team", task.ID)
+	tk.UpdatedAt, tk.CompletedAt = longAgo, &longAgo
+	writeJSON(taskPath("idle-team", task.ID), tk)
+
+	if worker.stopIfTeamIdle(state) {
+		t.Error("worker acted although lead, earlier in the roster, is running")
+	}
+	// A recently started agent gets the full period
+	if lead.stopIfTeamIdle(&AgentState{StartedAt: time.Now()}) {
+		t.Error("stopped right after the agent started")
+	}
+	if !lead.stopIfTeamIdle(state) {
+		t.Fatal("lead did not stop the idle team")
+	}
+	msgs, _ := GetMessages("idle-team", "worker", true)
+	stopped := false
+	for _, m := range msgs {
+		stopped = stopped || m.Content == "__stop__"
+	}
+	if !stopped {
+		t.Error("worker was not told to stop")
+	}
+	if tasks, _ := ListTasks("idle-team", "", ""); len(tasks) != 0 {
+		t.Errorf("%d tasks left, want the finished one archived", len(tasks))
+	}
+}
+
+func TestHumanTask(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("HOME", t.TempDir()) // the answer's notification file
+
+	CreateTeam("ask-team", "", "")
+	if err := AddMember("ask-team", TeamMember{Name: HumanOwner}); err == nil {
+		t.Error("expected the human owner name to be reserved")
+	}
+	for _, spec := range []TaskSpec{
+		{Subject: "Q", Choices: []string{"a"}},
+		{Subject: "Q", Type: "robot"},
+		{Subject: "Q", Type: TaskTypeHuman, Owner: "worker"},
+		{Subject: "Q", Type: TaskTypeHuman, Choices: []string{" "}},
+	} {
+		if _, err := CreateTasks("ask-team", []TaskSpec{spec}); err == nil {
+			t.Errorf("expected %+v to be rejected", spec)
+		}
+	}
+
+	tasks, err := CreateTasks("ask-team", []TaskSpec{
+		{Subject: "Build release"},
+		{Subject: "Ship to production?", Type: TaskTypeHuman, Choices: []string{"yes", "no"}, DependsOn: []int{1}},
+		{Subject: "Deploy", DependsOn: []int{2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	build, question, deploy := tasks[0], tasks[1], tasks[2]
+	if question.Owner != HumanOwner || question.Status != TaskAssigned {
+		t.Fatalf("human task = owner %q, status %s", question.Owner, question.Status)
+	}
+
+	// No agent takes the question
+	d := &Daemon{TeamName: "ask-team", AgentName: "worker", logger: newTestLogger()}
+	if next, _ := d.findNextTask(true); next == nil || next.ID != build.ID {
+		t.Fatalf("findNextTask = %+v, want the build task", next)
+	}
+
+	if asked, _ := AskQuestions("ask-team", "worker"); len(asked) != 0 {
+		t.Errorf("asked %d questions while blocked", len(asked))
+	}
+	if _, err := AnswerTask("ask-team", question.ID, "yes", ""); err == nil {
+		t.Error("expected a blocked question to be unanswerable")
+	}
+	if _, err := CompleteTask("ask-team", build.ID, "built"); err != nil {
+		t.Fatal(err)
+	}
+
+	asked, err := AskQuestions("ask-team", "worker")
+	if err != nil || len(asked) != 1 || asked[0].AskedAt == nil {
+		t.Fatalf("AskQuestions = %+v, %v", asked, err)
+	}
+	if again, _ := AskQuestions("ask-team", "worker"); len(again) != 0 {
+		t.Error("question asked twice")
+	}
+	inbox, _ := GetMessagesByType("ask-team", HumanOwner, MsgQuestion, true)
+	if len(inbox) != 1 || inbox[0].TaskID != question.ID || !strings.Contains(inbox[0].Content, "Choices: yes, no") {
+		t.Fatalf("inbox = %+v", inbox)
+	}
+	if open, _ := OpenQuestions("ask-team"); len(open) != 1 {
+		t.Errorf("OpenQuestions = %d tasks, want 1", len(open))
+	}
+
+	if _, err := AnswerTask("ask-team", question.ID, "maybe", ""); err == nil {
+		t.Error("expected an answer outside the choices to be rejected")
+	}
+	if _, err := AnswerTask("ask-team", build.ID, "yes", ""); err == nil {
+		t.Error("expected an agent task to be unanswerable")
+	}
+	answered, err := AnswerTask("ask-team", question.ID, "No", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answered.Status != TaskCompleted || answered.Result != "no" || answered.AnsweredBy != "alice" {
+		t.Errorf("answered task = %+v", answered)
+	}
+	if inbox, _ := GetMessagesByType("ask-team", HumanOwner, MsgQuestion, true); len(inbox) != 0 {
+		t.Error("question still unread after the answer")
+	}
+	path, _ := NotificationPath("ask-team", question.ID)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("no notification written for the answer: %v", err)
+	}
+
+	// The dependent task runs with the answer in its prompt
+	deploy, _ = GetTask("ask-team", deploy.ID)
+	if blocked, _ := IsTaskBlocked("ask-team", deploy); blocked {
+		t.Error("dependent task still blocked after the answer")
+	}
+	if got := humanAnswers("ask-team", deploy); !strings.Contains(got, "Ship to production?") || !strings.Contains(got, "Answer: no") {
+		t.Errorf("humanAnswers = %q", got)
+	}
+}
+
+func TestTeamActivity(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	if _, err := TeamActivity("no-such-team"); err == nil {
+		t.Error("expected an error for a missing team")
+	}
+
+	CreateTeam("activity-team", "", "")
+	AddMember("activity-team", TeamMember{Name: "w1"})
+	task, _ := CreateTask("activity-team", "build", "", "w1", nil, "", "", "")
+	started := time.Now().Add(time.Second)
+	completed := started.Add(time.Second)
+	UpdateTask("activity-team", task.ID, func(tk *Task) error {
+		tk.Status = TaskCompleted
+		tk.StartedAt = &started
+		tk.CompletedAt = &completed
+		return nil
+	})
+	SendMessage("activity-team", "lead", "w1", "please hurry")
+
+	events, err := TeamActivity("activity-team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("events = %+v, want 4", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Time.After(events[i-1].Time) {
+			t.Fatalf("events not newest first: %+v", events)
+		}
+	}
+	if e := events[0]; e.Type != ActivityTaskCompleted || e.TaskID != task.ID || e.Agent != "w1" {
+		t.Errorf("newest event = %+v, want the completion", e)
+	}
+	var msg *ActivityEvent
+	for i := range events {
+		if events[i].Type == ActivityMessage {
+			msg = &events[i]
+		}
+	}
+	if msg == nil || msg.Agent != "lead" || msg.To != "w1" || msg.Summary != "please hurry" {
+		t.Errorf("message event = %+v", msg)
+	}
+}
+
+func TestCreateTasks_Labels(t *testing.T) {
+	cleanup := setupTestDir(t)
+	defer cleanup()
+
+	CreateTeam("label-team", "", "")
+	tasks, err := CreateTasks("label-team", []TaskSpec{{Subject: "fix login", Labels: []string{"bug", "frontend"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetTask("label-team", tasks[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Labels) != 2 || got.Labels[0] != "bug" || got.Labels[1] != "frontend" {
+		t.Errorf("labels = %v, want [bug frontend]", got.Labels)
+	}
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// AgentPlan is the plan an agent posts when it starts work on a task, so the
+// orchestrator can catch a wrong approach before the work is done.
+type AgentPlan struct {
+	Steps    []string  `json:"steps"`
+	Agent    string    `json:"agent"`
+	PostedAt time.Time `json:"postedAt"`
+}
+
+// ParsePlanSteps splits a plan into its steps: one per non-empty line, with
+// list markers ("-", "*", "1.", "2)") removed.
+func ParsePlanSteps(text string) []string {
+	var steps []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*• ")
+		if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" {
+			line = line[i+1:]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			steps = append(steps, line)
+		}
+	}
+	return steps
+}
+
+// PostTaskPlan records agentName's plan for a running task and broadcasts it
+// to the team as a MsgPlan message. Posting again replaces the plan.
+func PostTaskPlan(teamName, agentName string, taskID int, text string) (*Task, error) {
+	steps := ParsePlanSteps(text)
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("plan has no steps")
+	}
+
+	task, err := UpdateTask(teamName, taskID, func(t *Task) error {
+		if t.Status != TaskRunning {
+			return fmt.Errorf("task %d is %s: a plan can only be posted while it runs", taskID, t.Status)
+		}
+		if t.Owner != "" && t.Owner != agentName {
+			return fmt.Errorf("task %d belongs to %s, not %s", taskID, t.Owner, agentName)
+		}
+		t.Plan = &AgentPlan{Steps: steps, Agent: agentName, PostedAt: time.Now()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan for task #%d %s:", task.ID, task.Subject)
+	for i, s := range steps {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, s)
+	}
+	if _, err := SendTypedMessage(teamName, MsgPlan, agentName, "", b.String(), task.ID); err != nil {
+		return task, fmt.Errorf("broadcast plan: %w", err)
+	}
+	return task, nil
+}
+
+// planInstructions asks the agent running task to post its plan with
+// `codes agent task plan` before starting.
+func (d *Daemon) planInstructions(task *Task) string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "codes"
+	}
+	return fmt.Sprintf("Before you change anything, post your plan for this task (a few short steps, one per line) by running:\n"+
+		"%q agent task plan %q %d --from %q \"1. first step\n2. second step\"\n"+
+		"Then carry out the plan. If you change course substantially, post the new plan the same way.",
+		exe, d.TeamName, task.ID, d.AgentName)
+}
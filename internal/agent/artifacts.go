@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CollectArtifacts resolves the task's declared artifact paths/globs against
+// workDir and copies every matching regular file into tasks/{id}/artifacts/.
+// Patterns must be relative, and files that resolve outside workDir, through
+// .. or a symlink, are not collected.
+// Files are stored flat by base name; collisions get a numeric suffix.
+// Returns the stored file names. Patterns that match nothing are reported
+// in the returned error but do not stop the remaining copies.
+func CollectArtifacts(teamName string, task *Task, workDir string) ([]string, error) {
+	if len(task.Artifacts) == 0 {
+		return nil, nil
+	}
+
+	dir := taskArtifactsDir(teamName, task.ID)
+	if err := ensureDir(dir); err != nil {
+		return nil, fmt.Errorf("create artifacts dir: %w", err)
+	}
+
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve work dir: %w", err)
+	}
+
+	var stored []string
+	var missing []string
+	used := make(map[string]bool)
+
+	for _, pattern := range task.Artifacts {
+		if filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "~") {
+			return stored, fmt.Errorf("artifact pattern %q must be relative to the work dir", pattern)
+		}
+		pattern = filepath.Join(workDir, pattern)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return stored, fmt.Errorf("invalid artifact pattern %q: %w", pattern, err)
+		}
+
+		copied := 0
+		for _, src := range matches {
+			// Neither .. nor a symlink may reach outside the work dir
+			src, err := filepath.EvalSymlinks(src)
+			if err != nil || !inDir(src, root) {
+				continue
+			}
+			info, err := os.Stat(src)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			name := uniqueArtifactName(filepath.Base(src), used)
+			if err := copyFile(src, filepath.Join(dir, name)); err != nil {
+				return stored, fmt.Errorf("copy artifact %s: %w", src, err)
+			}
+			used[name] = true
+			stored = append(stored, name)
+			copied++
+		}
+		if copied == 0 {
+			missing = append(missing, pattern)
+		}
+	}
+
+	if len(missing) > 0 {
+		return stored, fmt.Errorf("no files matched artifact patterns: %s", strings.Join(missing, ", "))
+	}
+	return stored, nil
+}
+
+// inDir reports whether path is dir or below it. Both must be clean.
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// ListArtifacts returns the names of artifact files stored for a task.
+func ListArtifacts(teamName string, taskID int) ([]string, error) {
+	entries, err := os.ReadDir(taskArtifactsDir(teamName, taskID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ArtifactPath returns the on-disk path of a stored artifact. The name must be
+// a plain file name (no path separators) so callers cannot escape the task's
+// artifacts directory.
+func ArtifactPath(teamName string, taskID int, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	path := filepath.Join(taskArtifactsDir(teamName, taskID), name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("artifact %q not found for task %d", name, taskID)
+	}
+	return path, nil
+}
+
+// uniqueArtifactName returns name, or name with a "-N" suffix before the
+// extension if it's already taken.
+func uniqueArtifactName(name string, used map[string]bool) string {
+	if !used[name] {
+		return name
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", stem, i, ext)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// copyFile copies a regular file from src to dst, overwriting dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosEnv enables fault injection in the storage and messaging layers, for
+// checking that retries, watchdogs and recovery paths really work. It holds a
+// comma-separated list of faults, each optionally with a value:
+//
+//	disk_slow[=max]   delay every task/state read and write by up to max (default 200ms)
+//	disk_fail[=rate]  fail that fraction of writes (default 0.1)
+//	msg_slow[=max]    delay every message send by up to max (default 500ms)
+//	msg_drop[=rate]   silently lose that fraction of messages (default 0.2)
+//
+// e.g. CODES_CHAOS=disk_slow,msg_drop=0.5. Agent daemons inherit it from the
+// command that starts them. Never set it for real work.
+const ChaosEnv = "CODES_CHAOS"
+
+// errChaos marks a failure injected by chaos mode.
+var errChaos = errors.New("chaos: injected failure")
+
+// chaosConfig is the parsed form of ChaosEnv. Zero values disable a fault.
+type chaosConfig struct {
+	diskSlow time.Duration
+	diskFail float64
+	msgSlow  time.Duration
+	msgDrop  float64
+}
+
+func (c chaosConfig) enabled() bool {
+	return c != chaosConfig{}
+}
+
+// chaosSettings reads ChaosEnv once per process. It is a variable so tests
+// can inject faults without touching the environment.
+var chaosSettings = sync.OnceValue(func() chaosConfig {
+	spec := os.Getenv(ChaosEnv)
+	c, err := parseChaos(spec)
+	if err != nil {
+		log.Printf("[chaos] %v", err)
+	}
+	if c.enabled() {
+		log.Printf("[chaos] fault injection active: %s", spec)
+	}
+	return c
+})
+
+// parseChaos parses a ChaosEnv value. Unknown or malformed entries are
+// reported in the error and skipped; the rest still apply.
+func parseChaos(spec string) (chaosConfig, error) {
+	var c chaosConfig
+	var bad []string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(entry, "=")
+		var err error
+		switch name {
+		case "disk_slow":
+			c.diskSlow, err = chaosDuration(value, hasValue, 200*time.Millisecond)
+		case "disk_fail":
+			c.diskFail, err = chaosRate(value, hasValue, 0.1)
+		case "msg_slow":
+			c.msgSlow, err = chaosDuration(value, hasValue, 500*time.Millisecond)
+		case "msg_drop":
+			c.msgDrop, err = chaosRate(value, hasValue, 0.2)
+		default:
+			err = fmt.Errorf("unknown fault")
+		}
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("%s (%v)", entry, err))
+		}
+	}
+	if len(bad) > 0 {
+		return c, fmt.Errorf("ignoring %s entries: %s", ChaosEnv, strings.Join(bad, ", "))
+	}
+	return c, nil
+}
+
+func chaosDuration(value string, hasValue bool, def time.Duration) (time.Duration, error) {
+	if !hasValue {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("want a duration such as 300ms")
+	}
+	return d, nil
+}
+
+func chaosRate(value string, hasValue bool, def float64) (float64, error) {
+	if !hasValue {
+		return def, nil
+	}
+	r, err := strconv.ParseFloat(value, 64)
+	if err != nil || r < 0 || r > 1 {
+		return 0, fmt.Errorf("want a rate between 0 and 1")
+	}
+	return r, nil
+}
+
+// chaosSleep waits a random time up to max.
+func chaosSleep(max time.Duration) {
+	if max > 0 {
+		time.Sleep(rand.N(max))
+	}
+}
+
+// chaosDiskRead applies injected storage read latency.
+func chaosDiskRead() {
+	chaosSleep(chaosSettings().diskSlow)
+}
+
+// chaosDiskWrite applies injected storage write latency and failures.
+func chaosDiskWrite(path string) error {
+	c := chaosSettings()
+	chaosSleep(c.diskSlow)
+	if c.diskFail > 0 && rand.Float64() < c.diskFail {
+		return fmt.Errorf("write %s: %w", path, errChaos)
+	}
+	return nil
+}
+
+// chaosDropMessage applies injected message latency and reports whether the
+// message should be lost.
+func chaosDropMessage() bool {
+	c := chaosSettings()
+	chaosSleep(c.msgSlow)
+	return c.msgDrop > 0 && rand.Float64() < c.msgDrop
+}
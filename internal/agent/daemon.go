@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -15,6 +16,10 @@ import (
 	"time"
 )
 
+// Version is the codes version of this binary. Daemons record it in their
+// state so a newer CLI can spot daemons left running from before an upgrade.
+var Version = "dev"
+
 // Daemon manages the poll loop for an agent, executing assigned tasks
 // and responding to messages from the team lead or other agents.
 type Daemon struct {
@@ -22,23 +27,36 @@ type Daemon struct {
 	AgentName string
 	Role      string
 	Model     string
+	Skills    []string
 	WorkDir   string
 
-	pollInterval time.Duration
-	logger       *log.Logger
+	pollInterval    time.Duration // base poll interval while active
+	maxPollInterval time.Duration // ceiling for idle backoff
+	logger          *log.Logger
 	msgSessionID string // established message session ID (set after first response)
 
 	// Async task execution state
 	taskCancel  context.CancelFunc // cancels the currently running task's context
 	taskDone    chan taskResult     // receives result when async task completes
 	runningTask int                // ID of the currently running task (0 = none)
+
+	counters AgentCounters // cumulative failure counters, persisted with agent state
+
+	limits      config.AgentLimits // host-wide execution limits, read at startup
+	slotWaiting bool               // true while queued behind the concurrency limit
+	cliWaiting  bool               // true while the claude CLI is missing
+
+	powerCheckedAt time.Time // last power policy check, see powerPaused
+	idleCheckedAt  time.Time // last idle policy check, see stopIfTeamIdle
 }
 
 // taskResult carries the outcome of an asynchronous task execution.
 type taskResult struct {
-	task   *Task
-	result *ClaudeResult
-	err    error
+	task    *Task
+	result  *ClaudeResult
+	summary *TaskSummary // digest of a long successful result, if one was made
+	changes *TaskChanges // git changes in the work dir, if it is a repository
+	err     error
 }
 
 // NewDaemon creates a new agent daemon.
@@ -56,7 +74,7 @@ func NewDaemon(teamName, agentName string) (*Daemon, error) {
 		}
 	}
 	if member == nil {
-		return nil, fmt.Errorf("agent %q not found in team %q", agentName, teamName)
+		return nil, newError(ErrAgentNotFound, map[string]any{"team": teamName, "agent": agentName}, "agent %q not found in team %q", agentName, teamName)
 	}
 
 	workDir := cfg.WorkDir
@@ -64,14 +82,19 @@ func NewDaemon(teamName, agentName string) (*Daemon, error) {
 		workDir, _ = os.Getwd()
 	}
 
+	pollInterval, maxPollInterval := resolvePollSettings(cfg.Poll, member.Poll)
+
 	return &Daemon{
-		TeamName:     teamName,
-		AgentName:    agentName,
-		Role:         member.Role,
-		Model:        member.Model,
-		WorkDir:      workDir,
-		pollInterval: 3 * time.Second,
-		logger:       log.New(os.Stderr, fmt.Sprintf("[agent:%s] ", agentName), log.LstdFlags),
+		TeamName:        teamName,
+		AgentName:       agentName,
+		Role:            member.Role,
+		Model:           member.Model,
+		Skills:          member.Skills,
+		WorkDir:         workDir,
+		pollInterval:    pollInterval,
+		maxPollInterval: maxPollInterval,
+		logger:          log.New(os.Stderr, fmt.Sprintf("[agent:%s] ", agentName), log.LstdFlags),
+		limits:          config.GetAgentLimits(),
 	}, nil
 }
 
@@ -112,86 +135,188 @@ func (d *Daemon) buildSystemPromptWithContext(projectName, workDir string) strin
 // Run starts the daemon poll loop. It blocks until the context is cancelled
 // or a stop message is received.
 //
-// The loop has three responsibilities each tick:
-//   1. Check for __stop__ signal
-//   2. Process incoming chat messages (respond via Claude, reply to sender)
-//   3. Pick up and execute the next assigned task
+// The loop wakes on file changes in the team's tasks/messages directories or,
+// failing that, on a timer that backs off while idle. Each wake-up it:
+//   1. Checks for __stop__ signal
+//   2. Processes incoming chat messages (respond via Claude, reply to sender)
+//   3. Picks up and executes the next assigned task
 func (d *Daemon) Run(ctx context.Context) error {
+	// Detached daemons have no one reading stderr, so keep a copy on disk
+	if logFile, err := openAgentLog(d.TeamName, d.AgentName); err != nil {
+		d.logger.Printf("cannot open log file, logging to stderr only: %v", err)
+	} else {
+		d.logger.SetOutput(io.MultiWriter(os.Stderr, logFile))
+		defer logFile.Close()
+	}
+
+	// Refuse to start where the policy forbids, even if spawned directly
+	if err := config.CheckWorkDir(d.WorkDir); err != nil {
+		d.logger.Printf("cannot start: %v", err)
+		return err
+	}
+
+	// Carry counters over from the previous run so they stay cumulative
+	if prev, err := GetAgentState(d.TeamName, d.AgentName); err == nil && prev != nil && prev.Counters != nil {
+		d.counters = *prev.Counters
+	}
+
 	// Record agent state with a persistent session ID for message conversations
 	state := &AgentState{
 		Name:      d.AgentName,
 		Team:      d.TeamName,
 		PID:       os.Getpid(),
+		Host:      localHost(),
 		Status:    AgentIdle,
 		SessionID: generateID(),
 		StartedAt: time.Now(),
+		Version:   Version,
 	}
+	counters := d.counters
+	state.Counters = &counters
 	if err := SaveAgentState(state); err != nil {
 		return fmt.Errorf("save agent state: %w", err)
 	}
 
 	d.logger.Printf("started (pid=%d, team=%s, session=%s)", state.PID, d.TeamName, state.SessionID)
+	go d.runAgentHook("on_agent_started", "")
+
+	beatCtx, stopBeat := context.WithCancel(context.Background())
+	beatDone := make(chan struct{})
+	go func() {
+		runHeartbeat(beatCtx, d.TeamName, d.AgentName)
+		close(beatDone)
+	}()
+
+	d.recoverOrphanedTasks()
 
 	// Announce availability to the team
 	BroadcastMessage(d.TeamName, d.AgentName, fmt.Sprintf("Agent %s is online and ready for tasks.", d.AgentName))
 
+	stopReason := "stop_requested"
 	defer func() {
+		stopBeat()
+		<-beatDone
 		state.Status = AgentStopped
+		counters := d.counters
+		state.Counters = &counters
 		SaveAgentState(state)
 		BroadcastMessage(d.TeamName, d.AgentName, fmt.Sprintf("Agent %s is going offline.", d.AgentName))
 		d.logger.Println("stopped")
+		d.runAgentHook("on_agent_stopped", stopReason)
 	}()
 
-	ticker := time.NewTicker(d.pollInterval)
-	defer ticker.Stop()
+	// Platform stop event (Windows named event; nil channel elsewhere)
+	stopEvent, closeStopEvent := listenStopEvent(d.TeamName, d.AgentName)
+	defer closeStopEvent()
+
+	// Wake immediately when tasks or messages change on disk; the timer
+	// below is only a fallback and backs off while the team is idle.
+	wake, closeWatcher := watchTeamChanges(d.TeamName, d.logger)
+	defer closeWatcher()
+
+	backoff := newPollBackoff(d.pollInterval, d.maxPollInterval, time.Now())
+	timer := time.NewTimer(d.pollInterval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
+			stopReason = "signal"
 			d.cancelRunningTask()
 			d.drainRunningTask(state)
 			return ctx.Err()
-		case <-ticker.C:
-			// 1. Check for stop signal
-			if d.shouldStop() {
-				d.logger.Println("received stop signal")
-				d.cancelRunningTask()
-				d.drainRunningTask(state)
-				return nil
-			}
+		case <-stopEvent:
+			d.logger.Println("received stop event")
+			d.cancelRunningTask()
+			d.drainRunningTask(state)
+			return nil
+		case <-wake:
+			backoff.reset(time.Now())
+		case <-timer.C:
+		}
 
-			// 2. Check if async task has completed
-			if d.taskDone != nil {
-				select {
-				case res := <-d.taskDone:
-					d.handleTaskResult(res, state)
-					d.taskDone = nil
-					d.taskCancel = nil
-					d.runningTask = 0
-				default:
-					// Task still running, check for external cancellation
-					d.checkTaskCancellation()
-				}
-			}
+		busy, stop := d.poll(ctx, state)
+		if stop {
+			return nil
+		}
+		if !busy && d.stopIfTeamIdle(state) {
+			stopReason = "idle"
+			return nil
+		}
+		timer.Reset(backoff.next(busy, time.Now()))
+	}
+}
 
-			// 3. Process incoming chat messages (only when no task is running)
-			if d.taskDone == nil {
-				d.processMessages(ctx, state)
-			}
+// poll runs one iteration of the daemon loop. busy reports whether the agent
+// has work in flight (a running task, handled messages, or tasks queued behind
+// the concurrency limit) so the caller can keep polling at the base interval.
+func (d *Daemon) poll(ctx context.Context, state *AgentState) (busy, stop bool) {
+	// 1. Check for stop signal
+	if d.shouldStop() {
+		d.logger.Println("received stop signal")
+		d.cancelRunningTask()
+		d.drainRunningTask(state)
+		return false, true
+	}
 
-			// 4. Find and start next task (only when no task is running)
-			if d.taskDone == nil {
-				task, err := d.findNextTask()
-				if err != nil {
-					d.logger.Printf("error finding task: %v", err)
-					continue
-				}
-				if task != nil {
-					d.startTaskAsync(ctx, task, state)
-				}
+	// 2. Check if async task has completed
+	if d.taskDone != nil {
+		select {
+		case res := <-d.taskDone:
+			d.handleTaskResult(res, state)
+			d.taskDone = nil
+			d.taskCancel = nil
+			d.runningTask = 0
+			busy = true
+		default:
+			// Task still running, check for external cancellation
+			d.checkTaskCancellation()
+		}
+	}
+
+	// Without the claude CLI, messages and claude tasks stay queued on disk
+	// until it is installed; the daemon keeps polling for it. Tasks for
+	// other adapters still run.
+	cliOK := d.taskDone != nil || d.cliReady(state)
+
+	// 3. Process incoming chat messages (only when no task is running)
+	if d.taskDone == nil && cliOK {
+		if release, ok := d.acquireSlot(state); ok {
+			if d.processMessages(ctx, state) {
+				busy = true
 			}
+			release()
 		}
 	}
+
+	// 4. Post the questions of human tasks that became ready
+	d.askQuestions()
+
+	// 5. Find and start next task (only when no task is running).
+	//    While the host is at its concurrency limit, work stays
+	//    queued on disk and is picked up on a later poll, as it is while
+	//    the power policy pauses this machine.
+	if d.taskDone == nil {
+		if d.powerPaused(state) {
+			return busy, false
+		}
+		release, ok := d.acquireSlot(state)
+		if !ok {
+			return true, false
+		}
+		task, err := d.findNextTask(cliOK)
+		if err != nil {
+			release()
+			d.logger.Printf("error finding task: %v", err)
+			return busy, false
+		}
+		if task == nil {
+			release()
+			return busy, false
+		}
+		d.startTaskAsync(ctx, task, state, release)
+	}
+	return true, false
 }
 
 // shouldStop checks if there's a stop message for this agent.
@@ -210,13 +335,16 @@ func (d *Daemon) shouldStop() bool {
 }
 
 // processMessages handles incoming chat messages by feeding them to Claude
-// and sending the response back to the sender.
-func (d *Daemon) processMessages(ctx context.Context, state *AgentState) {
+// and sending the response back to the sender. It reports whether any
+// direct message was handled.
+func (d *Daemon) processMessages(ctx context.Context, state *AgentState) bool {
 	msgs, err := GetMessages(d.TeamName, d.AgentName, true)
 	if err != nil {
-		return
+		return false
 	}
 
+	handled := false
+
 	for _, msg := range msgs {
 		// Skip system messages (already handled by shouldStop)
 		if msg.Content == "__stop__" {
@@ -232,8 +360,8 @@ func (d *Daemon) processMessages(ctx context.Context, state *AgentState) {
 			MarkRead(d.TeamName, msg.ID)
 			continue
 		}
-		// Skip informational messages (progress updates and discoveries are notification-only)
-		if msg.Type == MsgProgress || msg.Type == MsgDiscovery {
+		// Skip informational messages (progress updates, discoveries and plans are notification-only)
+		if msg.Type == MsgProgress || msg.Type == MsgDiscovery || msg.Type == MsgPlan {
 			MarkRead(d.TeamName, msg.ID)
 			continue
 		}
@@ -247,6 +375,7 @@ func (d *Daemon) processMessages(ctx context.Context, state *AgentState) {
 
 		d.logger.Printf("message from %s: %s", msg.From, truncate(msg.Content, 80))
 		MarkRead(d.TeamName, msg.ID)
+		handled = true
 
 		d.updateActivity(state, fmt.Sprintf("processing message from %s", msg.From))
 
@@ -262,6 +391,8 @@ func (d *Daemon) processMessages(ctx context.Context, state *AgentState) {
 			Model:        d.Model,
 			SystemPrompt: d.buildSystemPrompt(),
 			PermMode:     "dangerously-skip-permissions",
+			Nice:         d.limits.Nice,
+			IONiceClass:  d.limits.IONiceClass,
 		}
 		// Resume existing message session if one was established
 		if d.msgSessionID != "" {
@@ -272,6 +403,7 @@ func (d *Daemon) processMessages(ctx context.Context, state *AgentState) {
 		// Use default adapter (claude) for message handling
 		result, err := RunClaude(ctx, opts)
 		if err != nil {
+			d.counters.ClaudeFailures++
 			d.logger.Printf("error responding to message: %v", err)
 			SendMessage(d.TeamName, d.AgentName, msg.From,
 				fmt.Sprintf("[error] Failed to process your message: %v", err))
@@ -295,11 +427,12 @@ func (d *Daemon) processMessages(ctx context.Context, state *AgentState) {
 		SendMessage(d.TeamName, d.AgentName, msg.From, response)
 		d.logger.Printf("replied to %s", msg.From)
 	}
+	return handled
 }
 
 // findNextTask finds the next task for this agent. It first looks for tasks
 // explicitly assigned to this agent, then auto-claims unassigned pending tasks.
-func (d *Daemon) findNextTask() (*Task, error) {
+func (d *Daemon) findNextTask(cliOK bool) (*Task, error) {
 	// 1. Check for tasks explicitly assigned to this agent
 	tasks, err := ListTasks(d.TeamName, TaskAssigned, d.AgentName)
 	if err != nil {
@@ -307,6 +440,9 @@ func (d *Daemon) findNextTask() (*Task, error) {
 	}
 
 	for _, task := range tasks {
+		if task.Type == TaskTypeHuman || (!cliOK && taskNeedsClaude(task)) {
+			continue
+		}
 		blocked, err := IsTaskBlocked(d.TeamName, task)
 		if err != nil {
 			continue
@@ -323,9 +459,12 @@ func (d *Daemon) findNextTask() (*Task, error) {
 	}
 
 	for _, task := range pending {
-		if task.Owner != "" {
+		if task.Owner != "" || task.Type == TaskTypeHuman || (!cliOK && taskNeedsClaude(task)) {
 			continue
 		}
+		if !hasSkills(d.Skills, task.Skills) {
+			continue // left for an agent with the required skills
+		}
 		blocked, err := IsTaskBlocked(d.TeamName, task)
 		if err != nil {
 			continue
@@ -355,20 +494,64 @@ func (d *Daemon) findNextTask() (*Task, error) {
 	return nil, nil
 }
 
+// acquireSlot claims a host-wide execution slot for a Claude invocation,
+// honouring AgentLimits.MaxConcurrent. When none is free it records that the
+// agent is waiting and returns false.
+func (d *Daemon) acquireSlot(state *AgentState) (func(), bool) {
+	release, ok := acquireExecSlot(d.limits.MaxConcurrent)
+	if !ok {
+		if !d.slotWaiting {
+			d.logger.Printf("host at max concurrency (%d), queueing work", d.limits.MaxConcurrent)
+			d.updateActivity(state, "waiting for a free execution slot")
+		}
+		d.slotWaiting = true
+		return nil, false
+	}
+	d.slotWaiting = false
+	return release, true
+}
+
+// cliReady reports whether the claude CLI is installed, recording a waiting
+// activity (once) while it is missing.
+func (d *Daemon) cliReady(state *AgentState) bool {
+	if !config.ClaudeAvailable() {
+		if !d.cliWaiting {
+			d.logger.Printf("%v; queueing work until it appears", config.ErrClaudeNotFound)
+			d.updateActivity(state, "waiting for the claude CLI (run `codes install`)")
+		}
+		d.cliWaiting = true
+		return false
+	}
+	if d.cliWaiting {
+		d.logger.Println("claude CLI found, resuming work")
+		d.updateActivity(state, "")
+	}
+	d.cliWaiting = false
+	return true
+}
+
+// taskNeedsClaude reports whether a task runs through the claude CLI.
+func taskNeedsClaude(task *Task) bool {
+	return task.Adapter == "" || task.Adapter == "claude"
+}
+
 // startTaskAsync launches a task in a background goroutine. The main loop
 // continues ticking and can detect external cancellation while the task runs.
-func (d *Daemon) startTaskAsync(ctx context.Context, task *Task, state *AgentState) {
+// release frees the task's execution slot once the subprocess has exited.
+func (d *Daemon) startTaskAsync(ctx context.Context, task *Task, state *AgentState, release func()) {
 	// Transition to running
+	now := time.Now()
 	_, err := UpdateTask(d.TeamName, task.ID, func(t *Task) error {
 		t.Status = TaskRunning
-		now := time.Now()
 		t.StartedAt = &now
 		return nil
 	})
 	if err != nil {
+		release()
 		d.logger.Printf("error updating task %d to running: %v", task.ID, err)
 		return
 	}
+	task.StartedAt = &now // for the durations in the reports
 
 	taskCtx, cancel := context.WithCancel(ctx)
 	d.taskCancel = cancel
@@ -381,11 +564,32 @@ func (d *Daemon) startTaskAsync(ctx context.Context, task *Task, state *AgentSta
 	state.CurrentTaskSubject = task.Subject
 	d.updateActivity(state, fmt.Sprintf("executing task #%d: %s", task.ID, task.Subject))
 
-	d.logger.Printf("executing task %d: %s", task.ID, task.Subject)
+	if task.RequestID != "" {
+		d.logger.Printf("executing task %d: %s (request %s)", task.ID, task.Subject, task.RequestID)
+	} else {
+		d.logger.Printf("executing task %d: %s", task.ID, task.Subject)
+	}
+	if task.Adapter != "mock" {
+		go d.runTaskStartedHook(task)
+	}
+
+	wake := func() {}
+	if config.GetPreventSleep() {
+		wake = d.inhibitSleep(fmt.Sprintf("agent %s/%s is running task #%d", d.TeamName, d.AgentName, task.ID))
+	}
 
 	go func() {
+		workDir := TaskWorkDir(d.TeamName, task)
+		base := gitHead(workDir)
 		result, err := d.runTask(taskCtx, task)
-		d.taskDone <- taskResult{task: task, result: result, err: err}
+		var summary *TaskSummary
+		if err == nil && result != nil && !result.IsError {
+			summary = d.summarizeResult(taskCtx, task, result.Result)
+		}
+		changes := gitChanges(workDir, base)
+		wake()
+		release()
+		d.taskDone <- taskResult{task: task, result: result, summary: summary, changes: changes, err: err}
 	}()
 }
 
@@ -394,26 +598,35 @@ func (d *Daemon) startTaskAsync(ctx context.Context, task *Task, state *AgentSta
 func (d *Daemon) runTask(ctx context.Context, task *Task) (*ClaudeResult, error) {
 	// Build prompt
 	prompt := task.Subject
-	if task.Description != "" {
+	if task.FollowUpOf != 0 {
+		// The resumed session already knows the task; send just the new instructions
+		prompt = task.Description
+	} else if task.Description != "" {
 		prompt = fmt.Sprintf("%s\n\n%s", task.Subject, task.Description)
 	}
 
-	// Resolve task-specific working directory:
-	//   1. Explicit task.WorkDir takes highest precedence
-	//   2. task.Project resolves via config.GetProjectPath()
-	//   3. Fall back to daemon's default WorkDir
-	taskWorkDir := d.WorkDir
-	taskProject := ""
-	if task.WorkDir != "" {
-		taskWorkDir = task.WorkDir
-	} else if task.Project != "" {
-		if projectPath, ok := config.GetProjectPath(task.Project); ok {
-			taskWorkDir = projectPath
-			taskProject = task.Project
-			d.logger.Printf("task %d: project %q → %s", task.ID, task.Project, projectPath)
-		} else {
-			d.logger.Printf("warning: project %q not found in config, using default workdir", task.Project)
-		}
+	taskWorkDir, taskProject := d.resolveTaskWorkDir(task)
+	// The policy may have changed, or the project moved, since the task was created
+	if err := config.CheckWorkDir(taskWorkDir); err != nil {
+		return nil, err
+	}
+
+	pinned, err := PinnedContext(task, taskWorkDir)
+	if err != nil {
+		return nil, err
+	}
+	if pinned != "" {
+		prompt += "\n\n" + pinned
+	}
+	if answers := humanAnswers(d.TeamName, task); answers != "" {
+		prompt += "\n\n" + answers
+	}
+
+	prompt += "\n\n" + d.planInstructions(task)
+
+	if len(task.Artifacts) > 0 {
+		prompt += fmt.Sprintf("\n\nWhen done, make sure these output files exist (relative to the working directory): %s",
+			strings.Join(task.Artifacts, ", "))
 	}
 
 	opts := RunOptions{
@@ -422,6 +635,8 @@ func (d *Daemon) runTask(ctx context.Context, task *Task) (*ClaudeResult, error)
 		Model:        d.Model,
 		SystemPrompt: d.buildSystemPromptWithContext(taskProject, taskWorkDir),
 		PermMode:     "dangerously-skip-permissions",
+		Nice:         d.limits.Nice,
+		IONiceClass:  d.limits.IONiceClass,
 	}
 	// Resume existing task session if available (for retries/continuations)
 	if task.SessionID != "" {
@@ -438,6 +653,57 @@ func (d *Daemon) runTask(ctx context.Context, task *Task) (*ClaudeResult, error)
 	return RunWithAdapter(ctx, adapterName, opts)
 }
 
+// summarizeResult digests a long task result with the configured summary
+// model. A failed summary is logged and the result is reported truncated.
+func (d *Daemon) summarizeResult(ctx context.Context, task *Task, result string) *TaskSummary {
+	summary, err := SummarizeResult(ctx, task.Adapter, config.GetSummaryModel(), task, result)
+	if err != nil {
+		d.logger.Printf("task %d: %v", task.ID, err)
+	}
+	return summary
+}
+
+// resolveTaskWorkDir determines where a task runs:
+//  1. Explicit task.WorkDir takes highest precedence
+//  2. task.Project resolves via config.GetProjectPath()
+//  3. Fall back to daemon's default WorkDir
+//
+// The returned project name is empty unless the project was resolved.
+func (d *Daemon) resolveTaskWorkDir(task *Task) (string, string) {
+	if task.WorkDir != "" {
+		return task.WorkDir, ""
+	}
+	if task.Project != "" {
+		if projectPath, ok := config.GetProjectPath(task.Project); ok {
+			d.logger.Printf("task %d: project %q → %s", task.ID, task.Project, projectPath)
+			return projectPath, task.Project
+		}
+		d.logger.Printf("warning: project %q not found in config, using default workdir", task.Project)
+	}
+	return d.WorkDir, ""
+}
+
+// collectArtifacts copies the task's declared artifacts into the task's
+// artifacts directory and records the stored file names on the task.
+func (d *Daemon) collectArtifacts(task *Task) {
+	if len(task.Artifacts) == 0 {
+		return
+	}
+	workDir, _ := d.resolveTaskWorkDir(task)
+	files, err := CollectArtifacts(d.TeamName, task, workDir)
+	if err != nil {
+		d.logger.Printf("task %d: artifacts: %v", task.ID, err)
+	}
+	if len(files) == 0 {
+		return
+	}
+	UpdateTask(d.TeamName, task.ID, func(t *Task) error {
+		t.ArtifactFiles = files
+		return nil
+	})
+	d.logger.Printf("task %d: collected %d artifact(s)", task.ID, len(files))
+}
+
 // checkTaskCancellation polls the task file to detect external cancellation
 // (e.g. via MCP task_update setting status to cancelled).
 func (d *Daemon) checkTaskCancellation() {
@@ -454,6 +720,25 @@ func (d *Daemon) checkTaskCancellation() {
 	}
 }
 
+// recoverOrphanedTasks fails tasks this agent left in running state, e.g.
+// after a crash. A new daemon cannot resume the previous Claude subprocess,
+// so the task is failed instead of staying stuck in running forever.
+func (d *Daemon) recoverOrphanedTasks() {
+	tasks, err := ListTasks(d.TeamName, TaskRunning, d.AgentName)
+	if err != nil {
+		return
+	}
+	const reason = "agent restarted while task was running"
+	for _, t := range tasks {
+		if _, err := FailTask(d.TeamName, t.ID, reason); err != nil {
+			d.logger.Printf("error recovering task %d: %v", t.ID, err)
+			continue
+		}
+		d.logger.Printf("task %d was left running by a previous instance; marked failed", t.ID)
+		d.reportTaskFailed(t, reason)
+	}
+}
+
 // cancelRunningTask cancels the currently running task's context, if any.
 func (d *Daemon) cancelRunningTask() {
 	if d.taskCancel != nil {
@@ -492,6 +777,28 @@ func (d *Daemon) drainRunningTask(state *AgentState) {
 func (d *Daemon) handleTaskResult(res taskResult, state *AgentState) {
 	// Re-read task status from disk — it may have been cancelled externally
 	currentTask, _ := GetTask(d.TeamName, res.task.ID)
+
+	// Record usage and changes whatever the outcome; a failed run still
+	// costs money and may have touched files
+	var cost *CostInfo
+	if res.result != nil {
+		cost = res.result.Cost
+	}
+	if cost != nil || res.changes != nil {
+		UpdateTask(d.TeamName, res.task.ID, func(t *Task) error {
+			if cost != nil {
+				t.Cost = cost
+			}
+			t.Changes = res.changes
+			return nil
+		})
+		// For the reports below
+		if cost != nil {
+			res.task.Cost = cost
+		}
+		res.task.Changes = res.changes
+	}
+
 	if currentTask != nil && currentTask.Status == TaskCancelled {
 		// Task was cancelled — save partial result if available
 		if res.result != nil && res.result.Result != "" {
@@ -503,10 +810,12 @@ func (d *Daemon) handleTaskResult(res taskResult, state *AgentState) {
 		d.reportTaskCancelled(res.task)
 	} else if res.err != nil {
 		errMsg := res.err.Error()
+		d.counters.ClaudeFailures++
 		d.logger.Printf("error executing task %d: %v", res.task.ID, errMsg)
 		FailTask(d.TeamName, res.task.ID, errMsg)
 		d.reportTaskFailed(res.task, errMsg)
 	} else if res.result != nil && res.result.IsError {
+		d.counters.ClaudeFailures++
 		d.logger.Printf("task %d failed: %s", res.task.ID, res.result.Error)
 		FailTask(d.TeamName, res.task.ID, res.result.Error)
 		d.reportTaskFailed(res.task, res.result.Error)
@@ -519,10 +828,20 @@ func (d *Daemon) handleTaskResult(res taskResult, state *AgentState) {
 				return nil
 			})
 		}
+		if currentTask != nil {
+			d.collectArtifacts(currentTask)
+		}
 		result := ""
 		if res.result != nil {
 			result = res.result.Result
 		}
+		if res.summary != nil {
+			UpdateTask(d.TeamName, res.task.ID, func(t *Task) error {
+				t.Summary = res.summary
+				return nil
+			})
+			res.task.Summary = res.summary // for the reports below
+		}
 		CompleteTask(d.TeamName, res.task.ID, result)
 		d.reportTaskCompleted(res.task, result)
 	}
@@ -536,7 +855,7 @@ func (d *Daemon) handleTaskResult(res taskResult, state *AgentState) {
 
 // reportTaskCompleted broadcasts a task completion report to the team.
 func (d *Daemon) reportTaskCompleted(task *Task, result string) {
-	summary := truncate(result, 500)
+	summary := resultDigest(result, task.Summary)
 	content := fmt.Sprintf("Task #%d completed: %s\n\nResult: %s", task.ID, task.Subject, summary)
 
 	// Send to all (broadcast) so leader and other agents can see
@@ -566,30 +885,19 @@ func (d *Daemon) reportTaskCancelled(task *Task) {
 
 // taskNotification is the JSON structure written to ~/.codes/notifications/.
 type taskNotification struct {
-	Team      string `json:"team"`
-	TaskID    int    `json:"taskId"`
-	Subject   string `json:"subject"`
-	Status    string `json:"status"`
-	Agent     string `json:"agent"`
-	Result    string `json:"result,omitempty"`
-	Error     string `json:"error,omitempty"`
-	Timestamp string `json:"timestamp"`
+	Team      string       `json:"team"`
+	TaskID    int          `json:"taskId"`
+	Subject   string       `json:"subject"`
+	Status    string       `json:"status"`
+	Agent     string       `json:"agent"`
+	Result    string       `json:"result,omitempty"`
+	Summary   *TaskSummary `json:"summary,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Timestamp string       `json:"timestamp"`
 }
 
 // writeNotification writes a notification file for a completed or failed task.
 func (d *Daemon) writeNotification(task *Task, status, detail string) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		d.logger.Printf("notification: cannot get home dir: %v", err)
-		return
-	}
-
-	dir := filepath.Join(home, ".codes", "notifications")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		d.logger.Printf("notification: cannot create dir: %v", err)
-		return
-	}
-
 	n := taskNotification{
 		Team:      d.TeamName,
 		TaskID:    task.ID,
@@ -599,20 +907,14 @@ func (d *Daemon) writeNotification(task *Task, status, detail string) {
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 	if status == "completed" {
-		n.Result = truncate(detail, 500)
+		n.Result = truncate(detail, summaryThreshold)
+		n.Summary = task.Summary
 	} else {
 		n.Error = detail
 	}
 
-	data, err := json.MarshalIndent(n, "", "  ")
-	if err != nil {
-		d.logger.Printf("notification: marshal error: %v", err)
-		return
-	}
-
-	// Use __ separator to avoid ambiguity when team name contains hyphens
-	filename := filepath.Join(dir, fmt.Sprintf("%s__%d.json", d.TeamName, task.ID))
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := writeNotificationFile(n); err != nil {
+		d.counters.NotificationErrors++
 		d.logger.Printf("notification: write error: %v", err)
 	}
 
@@ -623,9 +925,16 @@ func (d *Daemon) writeNotification(task *Task, status, detail string) {
 		Message: fmt.Sprintf("[%s] #%d %s", d.TeamName, task.ID, task.Subject),
 		Sound:   status == "completed",
 	}); err != nil {
+		d.counters.NotificationErrors++
 		d.logger.Printf("notification: desktop notify error: %v", err)
 	}
 
+	// Mock tasks (codes selftest) do no real work; keep them off the
+	// user's webhooks, hooks and callbacks
+	if task.Adapter == "mock" {
+		return
+	}
+
 	// Send webhook notifications (if configured)
 	d.sendWebhookNotifications(status, task)
 
@@ -638,6 +947,23 @@ func (d *Daemon) writeNotification(task *Task, status, detail string) {
 	}
 }
 
+// writeNotificationFile writes n to ~/.codes/notifications, where the MCP
+// monitor and GET /notifications pick it up.
+func writeNotificationFile(n taskNotification) error {
+	filename, err := NotificationPath(n.Team, n.TaskID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
 // sendCallback POSTs the task notification payload to the caller-provided
 // callback URL. It is best-effort: errors are logged but never fatal.
 func (d *Daemon) sendCallback(url string, n taskNotification) {
@@ -650,12 +976,14 @@ func (d *Daemon) sendCallback(url string, n taskNotification) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
+		d.counters.NotificationErrors++
 		d.logger.Printf("callback: POST %s error: %v", url, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
+		d.counters.NotificationErrors++
 		d.logger.Printf("callback: POST %s returned status %d", url, resp.StatusCode)
 	}
 }
@@ -671,6 +999,8 @@ func truncate(s string, maxLen int) string {
 
 // updateActivity updates the agent state's activity description and persists it.
 func (d *Daemon) updateActivity(state *AgentState, activity string) {
+	counters := d.counters
+	state.Counters = &counters
 	state.Activity = activity
 	state.UpdatedAt = time.Now()
 	SaveAgentState(state)
@@ -691,10 +1021,49 @@ func (d *Daemon) sendWebhookNotifications(status string, task *Task) {
 		eventType = "task_cancelled"
 	}
 
+	message := fmt.Sprintf("[%s] #%d %s", d.TeamName, task.ID, task.Subject)
+	// Every key is always set, so custom templates never render "<no value>"
+	data := map[string]any{
+		"Team":            d.TeamName,
+		"TaskID":          task.ID,
+		"Subject":         task.Subject,
+		"Status":          status,
+		"Agent":           d.AgentName,
+		"Duration":        "",
+		"DurationSeconds": 0.0,
+		"CostUSD":         0.0,
+		"FilesChanged":    0,
+		"Branch":          "",
+	}
+	var stats []string
+	if task.StartedAt != nil {
+		duration := time.Since(*task.StartedAt).Round(time.Second)
+		stats = append(stats, duration.String())
+		data["Duration"] = duration.String()
+		data["DurationSeconds"] = duration.Seconds()
+	}
+	if task.Cost != nil && task.Cost.TotalCostUSD > 0 {
+		stats = append(stats, fmt.Sprintf("$%.2f", task.Cost.TotalCostUSD))
+		data["CostUSD"] = task.Cost.TotalCostUSD
+	}
+	if task.Changes != nil {
+		files := fmt.Sprintf("%d files changed", task.Changes.FilesChanged)
+		if task.Changes.Branch != "" {
+			files += " on " + task.Changes.Branch
+		}
+		stats = append(stats, files)
+		data["FilesChanged"] = task.Changes.FilesChanged
+		data["Branch"] = task.Changes.Branch
+	}
+	if len(stats) > 0 {
+		message += " (" + strings.Join(stats, ", ") + ")"
+	}
+
 	notification := notify.Notification{
 		Title:   fmt.Sprintf("codes: Task %s", status),
-		Message: fmt.Sprintf("[%s] #%d %s", d.TeamName, task.ID, task.Subject),
+		Message: message,
 		Sound:   false, // webhooks don't use sound
+		Data:    data,
 	}
 
 	for _, webhook := range webhooks {
@@ -715,6 +1084,7 @@ func (d *Daemon) sendWebhookNotifications(status string, task *Task) {
 		// Send notification
 		notifier := notify.NewWebhookNotifier(webhook.URL, webhook.Format, webhook.Extra)
 		if err := notifier.Send(notification); err != nil {
+			d.counters.NotificationErrors++
 			d.logger.Printf("webhook notification error (%s): %v", webhook.URL, err)
 		}
 	}
@@ -736,6 +1106,7 @@ func (d *Daemon) executeHook(status string, task *Task, detail string) {
 	}
 
 	payload := notify.HookPayload{
+		Event:     event,
 		Team:      d.TeamName,
 		TaskID:    task.ID,
 		Subject:   task.Subject,
@@ -748,9 +1119,59 @@ func (d *Daemon) executeHook(status string, task *Task, detail string) {
 	} else {
 		payload.Error = detail
 	}
+	if task.StartedAt != nil {
+		payload.DurationSeconds = time.Since(*task.StartedAt).Round(time.Millisecond).Seconds()
+	}
+	if task.Cost != nil {
+		payload.CostUSD = task.Cost.TotalCostUSD
+	}
+	if task.Changes != nil {
+		payload.FilesChanged = &task.Changes.FilesChanged
+		payload.Branch = task.Changes.Branch
+	}
 
 	runner := notify.NewHookRunner(scriptPath)
 	if err := runner.Execute(payload); err != nil {
+		d.counters.NotificationErrors++
+		d.logger.Printf("hook execution error (%s): %v", event, err)
+	}
+}
+
+// runTaskStartedHook runs the on_task_started hook for task, which has just
+// been set running.
+func (d *Daemon) runTaskStartedHook(task *Task) {
+	err := notify.RunEventHook(notify.HookEvent{
+		Event: "on_task_started",
+		Task: &notify.HookTask{
+			Team:     d.TeamName,
+			ID:       task.ID,
+			Subject:  task.Subject,
+			Agent:    d.AgentName,
+			Priority: string(task.Priority),
+			Project:  task.Project,
+			WorkDir:  task.WorkDir,
+		},
+	})
+	if err != nil {
+		d.logger.Printf("hook execution error (on_task_started): %v", err)
+	}
+}
+
+// runAgentHook runs the on_agent_started or on_agent_stopped hook for this
+// daemon.
+func (d *Daemon) runAgentHook(event, reason string) {
+	err := notify.RunEventHook(notify.HookEvent{
+		Event: event,
+		Agent: &notify.HookAgent{
+			Team:   d.TeamName,
+			Name:   d.AgentName,
+			Role:   d.Role,
+			Model:  d.Model,
+			PID:    os.Getpid(),
+			Reason: reason,
+		},
+	})
+	if err != nil {
 		d.logger.Printf("hook execution error (%s): %v", event, err)
 	}
 }
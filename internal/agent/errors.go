@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Conditions callers need to tell apart. Errors from this package wrap them,
+// so check with errors.Is; the error text still names the team, task or
+// agent involved. Invalid status changes are reported as
+// *InvalidTransitionError instead.
+var (
+	ErrTeamNotFound     = errors.New("team not found")
+	ErrTeamExists       = errors.New("team already exists")
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrAgentNotFound    = errors.New("agent not found")
+	ErrAgentExists      = errors.New("agent already exists")
+	ErrAgentRunning     = errors.New("agent already running")
+	ErrAgentNotRunning  = errors.New("agent not running")
+	ErrTemplateNotFound = errors.New("team template not found")
+	ErrTemplateExists   = errors.New("team template already exists")
+)
+
+// detailedError is an error that matches kind with errors.Is and carries the
+// identifiers it is about.
+type detailedError struct {
+	kind    error
+	msg     string
+	details map[string]any
+}
+
+func (e *detailedError) Error() string { return e.msg }
+func (e *detailedError) Unwrap() error { return e.kind }
+
+// newError formats an error of the given kind.
+func newError(kind error, details map[string]any, format string, args ...any) error {
+	return &detailedError{kind: kind, msg: fmt.Sprintf(format, args...), details: details}
+}
+
+// ErrorDetails returns the identifiers (team, taskId, agent, ...) attached to
+// an error from this package, or nil.
+func ErrorDetails(err error) map[string]any {
+	var de *detailedError
+	if errors.As(err, &de) {
+		return de.details
+	}
+	var te *InvalidTransitionError
+	if errors.As(err, &te) {
+		return map[string]any{"taskId": te.TaskID, "from": string(te.From), "to": string(te.To)}
+	}
+	return nil
+}
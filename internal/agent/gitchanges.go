@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitChangesTimeout bounds each git call made around a task run; the
+// numbers are a nicety for hooks and must never hold up a report.
+const gitChangesTimeout = 10 * time.Second
+
+// TaskChanges describes what a task run changed in its working directory's
+// git repository.
+type TaskChanges struct {
+	Branch       string `json:"branch,omitempty"` // branch checked out when the task finished; empty when detached
+	FilesChanged int    `json:"filesChanged"`     // files committed, modified or added since the task started
+}
+
+// gitHead returns the commit checked out in dir, or "" when dir is not in a
+// git repository (or the repository has no commits yet).
+func gitHead(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	out, err := gitOutput(dir, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// gitChanges compares dir against base, the commit gitHead returned before
+// the task ran. Committed, staged, unstaged and untracked files all count,
+// each once. It returns nil when base is empty or git fails.
+func gitChanges(dir, base string) *TaskChanges {
+	if base == "" {
+		return nil
+	}
+	diff, err := gitOutput(dir, "diff", "--name-only", base)
+	if err != nil {
+		return nil
+	}
+	untracked, err := gitOutput(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil
+	}
+	files := make(map[string]bool)
+	for _, name := range strings.Split(diff+"\n"+untracked, "\n") {
+		if name != "" {
+			files[name] = true
+		}
+	}
+
+	changes := &TaskChanges{FilesChanged: len(files)}
+	if branch, err := gitOutput(dir, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		changes.Branch = strings.TrimSpace(branch)
+	}
+	return changes
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitChangesTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Liveness: a running daemon rewrites agents/{name}.heartbeat every
+// heartbeatInterval from its own goroutine, so the beat keeps going while
+// the main loop is busy in a long Claude call. A beat older than
+// heartbeatTimeout means the daemon is gone, whatever its PID now belongs to.
+
+const (
+	heartbeatInterval = 10 * time.Second
+	heartbeatTimeout  = 3 * heartbeatInterval
+)
+
+// heartbeat is the content of a heartbeat file.
+type heartbeat struct {
+	At   time.Time `json:"at"`
+	PID  int       `json:"pid"`
+	Host string    `json:"host,omitempty"`
+}
+
+func agentHeartbeatPath(teamName, agentName string) string {
+	return filepath.Join(agentsDir(teamName), agentName+".heartbeat")
+}
+
+// runHeartbeat writes the agent's heartbeat until ctx is done, then removes
+// it so the agent reads as stopped right away.
+func runHeartbeat(ctx context.Context, teamName, agentName string) {
+	path := agentHeartbeatPath(teamName, agentName)
+	hb := heartbeat{PID: os.Getpid(), Host: localHost()}
+	beat := func() {
+		hb.At = time.Now()
+		writeJSON(path, hb)
+	}
+	beat()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			os.Remove(path)
+			return
+		case <-ticker.C:
+			beat()
+		}
+	}
+}
+
+// readHeartbeat returns the agent's last heartbeat, or nil if it has none
+// (not running, or a daemon from before heartbeats).
+func readHeartbeat(teamName, agentName string) *heartbeat {
+	var hb heartbeat
+	if err := readJSON(agentHeartbeatPath(teamName, agentName), &hb); err != nil {
+		return nil
+	}
+	return &hb
+}
+
+// localHost is this machine's host name, used to tell whether a recorded PID
+// can be checked here.
+func localHost() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+// daemonAlive decides whether the daemon recorded in state is running. A
+// fresh heartbeat is the primary signal; on the daemon's own host a dead PID
+// overrides it, so a crash is noticed before the beat goes stale. Without a
+// heartbeat only the PID is checked.
+func daemonAlive(state *AgentState, hb *heartbeat) bool {
+	if hb == nil {
+		return isLocal(state.Host) && state.PID > 0 && isProcessAlive(state.PID)
+	}
+	if time.Since(hb.At) > heartbeatTimeout {
+		return false
+	}
+	if isLocal(hb.Host) && hb.PID > 0 {
+		return isProcessAlive(hb.PID)
+	}
+	return true
+}
+
+// isLocal reports whether host is this machine. Records without a host
+// predate it and were always local.
+func isLocal(host string) bool {
+	return host == "" || host == localHost()
+}
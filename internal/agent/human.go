@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"codes/internal/notify"
+)
+
+// Human tasks: a task of type TaskTypeHuman is a decision point in a
+// pipeline. No agent runs it. It is assigned to HumanOwner from the start,
+// and once its blockedBy tasks are completed a daemon posts its subject as a
+// question: a message to HumanOwner (the human's inbox) and a desktop
+// notification. AnswerTask completes it with the answer as its result;
+// tasks blocked by it then start and find the answer in their prompt.
+
+// HumanOwner owns every human task and receives its question. No agent may
+// take the name.
+const HumanOwner = "human"
+
+var errAlreadyAsked = errors.New("question already asked")
+
+// checkTaskType validates the type-specific fields of a task spec.
+func checkTaskType(spec TaskSpec) error {
+	switch spec.Type {
+	case "":
+		if len(spec.Choices) > 0 {
+			return fmt.Errorf("choices are only allowed on human tasks")
+		}
+	case TaskTypeHuman:
+		if spec.Owner != "" && spec.Owner != HumanOwner {
+			return fmt.Errorf("human tasks cannot be assigned to an agent")
+		}
+		if len(spec.Skills) > 0 || spec.Adapter != "" || spec.SessionID != "" {
+			return fmt.Errorf("human tasks are not run by an agent: skills, adapter and session are not allowed")
+		}
+		for _, c := range spec.Choices {
+			if strings.TrimSpace(c) == "" {
+				return fmt.Errorf("choices must not be empty")
+			}
+		}
+	default:
+		return fmt.Errorf("invalid task type %q (must be %q or empty)", spec.Type, TaskTypeHuman)
+	}
+	return nil
+}
+
+// OpenQuestions returns the team's human tasks that can be answered now: not
+// answered or cancelled yet, and no longer blocked.
+func OpenQuestions(teamName string) ([]*Task, error) {
+	tasks, err := ListTasks(teamName, TaskAssigned, HumanOwner)
+	if err != nil {
+		return nil, err
+	}
+	var open []*Task
+	for _, t := range tasks {
+		if t.Type != TaskTypeHuman {
+			continue
+		}
+		if blocked, err := IsTaskBlocked(teamName, t); err != nil || blocked {
+			continue
+		}
+		open = append(open, t)
+	}
+	return open, nil
+}
+
+// AskQuestions posts the question of each open human task that has not been
+// asked yet as a message from "from" to HumanOwner, and returns those tasks.
+// Each question is asked once, however many daemons call it.
+func AskQuestions(teamName, from string) ([]*Task, error) {
+	open, err := OpenQuestions(teamName)
+	if err != nil {
+		return nil, err
+	}
+	var asked []*Task
+	for _, t := range open {
+		if t.AskedAt != nil {
+			continue
+		}
+		t, err := UpdateTask(teamName, t.ID, func(t *Task) error {
+			if t.AskedAt != nil || t.Status != TaskAssigned {
+				return errAlreadyAsked
+			}
+			now := time.Now()
+			t.AskedAt = &now
+			return nil
+		})
+		if err != nil {
+			continue // asked by another daemon, or answered meanwhile
+		}
+		if _, err := SendTaskReport(teamName, from, HumanOwner, MsgQuestion, t.ID, QuestionText(t)); err != nil {
+			return asked, fmt.Errorf("post question %d: %w", t.ID, err)
+		}
+		asked = append(asked, t)
+	}
+	return asked, nil
+}
+
+// QuestionText renders a human task as the question shown to the human.
+func QuestionText(t *Task) string {
+	text := t.Subject
+	if t.Description != "" {
+		text += "\n\n" + t.Description
+	}
+	if len(t.Choices) > 0 {
+		text += "\n\nChoices: " + strings.Join(t.Choices, ", ")
+	}
+	return text
+}
+
+// AnswerTask completes an open human task with answer as its result. When the
+// task has choices, the answer must be one of them (case does not matter)
+// and is stored as the choice is written. by names who answered and defaults
+// to HumanOwner.
+func AnswerTask(teamName string, taskID int, answer, by string) (*Task, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, fmt.Errorf("answer is required")
+	}
+	if by == "" {
+		by = HumanOwner
+	}
+	task, err := GetTask(teamName, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Type != TaskTypeHuman {
+		return nil, fmt.Errorf("task %d is not a human task", taskID)
+	}
+	if len(task.Choices) > 0 {
+		i := slices.IndexFunc(task.Choices, func(c string) bool { return strings.EqualFold(c, answer) })
+		if i < 0 {
+			return nil, fmt.Errorf("answer %q is not one of the choices: %s", answer, strings.Join(task.Choices, ", "))
+		}
+		answer = task.Choices[i]
+	}
+	blocked, err := IsTaskBlocked(teamName, task)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, fmt.Errorf("task %d cannot be answered yet: it is blocked by tasks %v", taskID, task.BlockedBy)
+	}
+
+	task, err = UpdateTask(teamName, taskID, func(t *Task) error {
+		if t.Status != TaskAssigned {
+			return &InvalidTransitionError{TaskID: taskID, From: t.Status, To: TaskCompleted}
+		}
+		t.Status = TaskCompleted
+		t.Result = answer
+		t.AnsweredBy = by
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Take the question out of the inbox
+	if msgs, err := GetMessagesByType(teamName, HumanOwner, MsgQuestion, true); err == nil {
+		for _, m := range msgs {
+			if m.TaskID == taskID {
+				MarkRead(teamName, m.ID)
+			}
+		}
+	}
+	// Reported like a finished agent task, for the MCP monitor and
+	// GET /notifications
+	writeNotificationFile(taskNotification{
+		Team:      teamName,
+		TaskID:    task.ID,
+		Subject:   task.Subject,
+		Status:    string(TaskCompleted),
+		Agent:     by,
+		Result:    answer,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	})
+	return task, nil
+}
+
+// humanAnswers returns the answered human tasks t is blocked by as a prompt
+// section, or "" when there are none.
+func humanAnswers(teamName string, t *Task) string {
+	var lines []string
+	for _, id := range t.BlockedBy {
+		dep, err := GetTask(teamName, id)
+		if err != nil || dep.Type != TaskTypeHuman || dep.Status != TaskCompleted {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s\n  Answer: %s", dep.Subject, dep.Result))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "The human answered these questions before this task:\n" + strings.Join(lines, "\n")
+}
+
+// askQuestions posts the questions of human tasks that became ready and
+// tells the user on the desktop.
+func (d *Daemon) askQuestions() {
+	asked, err := AskQuestions(d.TeamName, d.AgentName)
+	if err != nil {
+		d.logger.Printf("ask questions: %v", err)
+	}
+	for _, t := range asked {
+		d.logger.Printf("asked the human: task %d: %s", t.ID, t.Subject)
+		if err := notify.NewDesktopNotifier().Send(notify.Notification{
+			Title:   "codes: Question for you",
+			Message: fmt.Sprintf("[%s] #%d %s", d.TeamName, t.ID, t.Subject),
+			Sound:   true,
+		}); err != nil {
+			d.counters.NotificationErrors++
+			d.logger.Printf("notification: desktop notify error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"codes/internal/notify"
+)
+
+// Idle policy: with TeamConfig.Idle set, a team whose tasks are all finished
+// stops itself after StopAfter minutes instead of polling forever. One daemon
+// acts for the team, the first running member in roster order: it archives
+// the finished tasks if asked to, tells the other agents to stop, reports it
+// with a team message, a desktop notification and the on_team_idle hook, and
+// exits. Starting an agent again resets the clock for it.
+
+// idleCheckInterval limits how often a daemon reads the team's tasks for the
+// idle policy; tests shorten it.
+var idleCheckInterval = time.Minute
+
+// SetIdlePolicy sets the team's idle policy. A nil policy turns it off.
+// Running daemons read it on their next check.
+func SetIdlePolicy(teamName string, p *IdlePolicy) error {
+	if p != nil && p.StopAfter <= 0 {
+		return fmt.Errorf("idle stopAfter must be at least 1 minute")
+	}
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return err
+	}
+	cfg.Idle = p
+	return writeJSON(teamConfigPath(teamName), cfg)
+}
+
+// TeamIdleSince reports whether the team has no pending, assigned or running
+// task and, if so, since when: the last change to one of its tasks, or the
+// team's creation when it has none.
+func TeamIdleSince(teamName string) (time.Time, bool, error) {
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	tasks, err := ListTasks(teamName, "", "")
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	since := cfg.CreatedAt
+	for _, t := range tasks {
+		switch t.Status {
+		case TaskPending, TaskAssigned, TaskRunning:
+			return time.Time{}, false, nil
+		}
+		if t.UpdatedAt.After(since) {
+			since = t.UpdatedAt
+		}
+		if t.CompletedAt != nil && t.CompletedAt.After(since) {
+			since = *t.CompletedAt
+		}
+	}
+	return since, true, nil
+}
+
+// stopIfTeamIdle applies the team's idle policy and reports whether this
+// daemon should exit. Only the acting daemon returns true; the others are
+// sent __stop__ by it.
+func (d *Daemon) stopIfTeamIdle(state *AgentState) bool {
+	now := time.Now()
+	if d.taskDone != nil || now.Sub(d.idleCheckedAt) < idleCheckInterval {
+		return false
+	}
+	d.idleCheckedAt = now
+
+	cfg, err := GetTeam(d.TeamName)
+	if err != nil || cfg.Idle == nil || cfg.Idle.StopAfter <= 0 {
+		return false
+	}
+	since, idle, err := TeamIdleSince(d.TeamName)
+	if err != nil || !idle {
+		return false
+	}
+	// An agent started on a team that was already idle gets the full period
+	if state.StartedAt.After(since) {
+		since = state.StartedAt
+	}
+	idleFor := now.Sub(since)
+	if idleFor < time.Duration(cfg.Idle.StopAfter)*time.Minute {
+		return false
+	}
+
+	var others []string
+	seenSelf := false
+	for _, m := range cfg.Members {
+		if m.Name == d.AgentName {
+			seenSelf = true
+			continue
+		}
+		if !IsAgentAlive(d.TeamName, m.Name) {
+			continue
+		}
+		if !seenSelf {
+			return false // an earlier running member acts for the team
+		}
+		others = append(others, m.Name)
+	}
+
+	archived := 0
+	if cfg.Idle.Archive {
+		if archived, err = ArchiveTasks(d.TeamName, now); err != nil {
+			d.logger.Printf("idle: archive tasks: %v", err)
+		}
+	}
+	for _, name := range others {
+		if err := StopAgent(d.TeamName, name); err != nil {
+			d.logger.Printf("idle: stop %s: %v", name, err)
+		}
+	}
+
+	idleFor = idleFor.Round(time.Minute)
+	summary := fmt.Sprintf("Team %s has had no pending or running tasks for %s; stopping all agents", d.TeamName, idleFor)
+	if archived > 0 {
+		summary += fmt.Sprintf(" and archiving %d finished tasks", archived)
+	}
+	d.logger.Println(summary)
+	BroadcastMessage(d.TeamName, d.AgentName, summary+".")
+
+	if err := notify.NewDesktopNotifier().Send(notify.Notification{
+		Title:   "codes: Team idle",
+		Message: fmt.Sprintf("[%s] stopped %s after %s without work", d.TeamName, strings.Join(append([]string{d.AgentName}, others...), ", "), idleFor),
+	}); err != nil {
+		d.counters.NotificationErrors++
+		d.logger.Printf("notification: desktop notify error: %v", err)
+	}
+	err = notify.RunEventHook(notify.HookEvent{
+		Event: "on_team_idle",
+		Team: &notify.HookTeam{
+			Name:          cfg.Name,
+			Description:   cfg.Description,
+			WorkDir:       cfg.WorkDir,
+			IdleMinutes:   int(idleFor / time.Minute),
+			StoppedAgents: append([]string{d.AgentName}, others...),
+			ArchivedTasks: archived,
+		},
+	})
+	if err != nil {
+		d.logger.Printf("hook execution error (on_team_idle): %v", err)
+	}
+	return true
+}
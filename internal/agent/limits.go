@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"codes/internal/config"
+	"codes/internal/filelock"
+)
+
+// acquireExecSlot claims one of max host-wide execution slots without
+// blocking. Slots are shared by every daemon on the host, across teams, so
+// a remote's limits cap that whole machine rather than one remote entry; a
+// held lock marks a busy slot and is released by the OS if its daemon
+// crashes. It returns a release func and true on success, or false if all
+// slots are busy. max <= 0 means unlimited.
+func acquireExecSlot(max int) (func(), bool) {
+	if max <= 0 {
+		return func() {}, true
+	}
+	dir := execSlotsDir()
+	if err := ensureDir(dir); err != nil {
+		// Fail open: a broken slots dir should not stop all work
+		return func() {}, true
+	}
+	for i := 0; i < max; i++ {
+		fl := filelock.New(filepath.Join(dir, fmt.Sprintf("slot-%d.lock", i)))
+		if ok, err := fl.TryLock(); err == nil && ok {
+			return func() { fl.Unlock() }, true
+		}
+	}
+	return nil, false
+}
+
+// priorityCommand wraps a command with nice/ionice according to limits.
+// Wrappers that are unsupported on this platform or not installed are skipped.
+func priorityCommand(name string, args []string, limits config.AgentLimits) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return name, args
+	}
+	if limits.IONiceClass > 0 && runtime.GOOS == "linux" {
+		if _, err := exec.LookPath("ionice"); err == nil {
+			args = append([]string{"-c", strconv.Itoa(limits.IONiceClass), name}, args...)
+			name = "ionice"
+		}
+	}
+	if limits.Nice != 0 {
+		if _, err := exec.LookPath("nice"); err == nil {
+			args = append([]string{"-n", strconv.Itoa(limits.Nice), name}, args...)
+			name = "nice"
+		}
+	}
+	return name, args
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxAgentLogSize is the size at which a daemon log is rotated to <name>.log.1.
+// Only one rotated file is kept, so a log never takes more than twice this.
+const maxAgentLogSize = 5 << 20
+
+// AgentLogPath returns the path to an agent daemon's log file.
+func AgentLogPath(teamName, agentName string) string {
+	return filepath.Join(agentsDir(teamName), agentName+".log")
+}
+
+// agentLogWriter appends to an agent's log file, rotating it once it grows
+// past maxAgentLogSize. It is not safe for concurrent use on its own; the
+// daemon's log.Logger serializes writes.
+type agentLogWriter struct {
+	path string
+	f    *os.File
+	size int64
+}
+
+// openAgentLog opens an agent's log file for appending.
+func openAgentLog(teamName, agentName string) (*agentLogWriter, error) {
+	w := &agentLogWriter{path: AgentLogPath(teamName, agentName)}
+	if err := ensureDir(filepath.Dir(w.path)); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *agentLogWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+func (w *agentLogWriter) Write(p []byte) (int, error) {
+	if w.size+int64(len(p)) > maxAgentLogSize && w.size > 0 {
+		w.f.Close()
+		os.Rename(w.path, w.path+".1")
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *agentLogWriter) Close() error {
+	return w.f.Close()
+}
+
+// ReadAgentLog returns the last n lines of an agent's daemon log, including
+// the rotated file when the current one is short. If grep is set, only lines
+// matching that regular expression are kept. n <= 0 returns every line.
+// An agent that has never run returns no lines and no error.
+func ReadAgentLog(teamName, agentName string, n int, grep string) ([]string, error) {
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, m := range cfg.Members {
+		if m.Name == agentName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, newError(ErrAgentNotFound, map[string]any{"team": teamName, "agent": agentName}, "agent %q not found in team %q", agentName, teamName)
+	}
+
+	var pattern *regexp.Regexp
+	if grep != "" {
+		if pattern, err = regexp.Compile(grep); err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+
+	path := AgentLogPath(teamName, agentName)
+	var data []byte
+	for _, p := range []string{path + ".1", path} {
+		b, err := os.ReadFile(p)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(string(bytes.TrimRight(data, "\n")), "\n") {
+		if line == "" || (pattern != nil && !pattern.MatchString(line)) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
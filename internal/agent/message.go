@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -51,6 +52,11 @@ func sendTypedMessage(teamName string, msgType MessageType, from, to, content st
 		CreatedAt: now,
 	}
 
+	// A dropped message looks sent to the caller but never arrives
+	if chaosDropMessage() {
+		return msg, nil
+	}
+
 	path := filepath.Join(dir, id+".json")
 	if err := writeJSON(path, msg); err != nil {
 		return nil, fmt.Errorf("write message: %w", err)
@@ -174,3 +180,63 @@ func GetAllTeamMessages(teamName string, limit int) ([]*Message, error) {
 
 	return messages, nil
 }
+
+// messageArchivePath returns the JSON Lines file CompactMessages appends to.
+func messageArchivePath(teamName string) string {
+	return filepath.Join(messagesDir(teamName), "archive.jsonl")
+}
+
+// CompactMessages moves read messages created before the given time out of
+// the message directory into messages/archive.jsonl, one JSON object per
+// line, oldest first. Unread messages are kept so no inbox loses anything.
+// Returns the number of messages compacted.
+func CompactMessages(teamName string, before time.Time) (int, error) {
+	dir := messagesDir(teamName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var old []*Message
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		var msg Message
+		if err := readJSON(filepath.Join(dir, e.Name()), &msg); err != nil {
+			continue
+		}
+		if msg.Read && msg.CreatedAt.Before(before) {
+			old = append(old, &msg)
+		}
+	}
+	if len(old) == 0 {
+		return 0, nil
+	}
+	sort.Slice(old, func(i, j int) bool {
+		return old[i].CreatedAt.Before(old[j].CreatedAt)
+	})
+
+	f, err := os.OpenFile(messageArchivePath(teamName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(f)
+	for _, msg := range old {
+		if err := enc.Encode(msg); err != nil {
+			f.Close()
+			return 0, fmt.Errorf("write archive: %w", err)
+		}
+	}
+	// Only delete the files once the archive is safely written
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("write archive: %w", err)
+	}
+	for _, msg := range old {
+		os.Remove(filepath.Join(dir, msg.ID+".json"))
+	}
+	return len(old), nil
+}
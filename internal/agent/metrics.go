@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// AgentCounters are cumulative per-agent counters. Daemons run as separate
+// processes, so counters are persisted in AgentState and read back by the
+// metrics exporter rather than kept in memory.
+type AgentCounters struct {
+	ClaudeFailures     int64 `json:"claudeFailures,omitempty"`     // Claude subprocess errors (task or message)
+	NotificationErrors int64 `json:"notificationErrors,omitempty"` // failed desktop/webhook/hook/callback deliveries
+}
+
+// taskDurationBuckets are the histogram upper bounds (seconds) for task run time.
+var taskDurationBuckets = []float64{30, 60, 300, 600, 1800, 3600, 7200}
+
+// taskStatuses is the fixed reporting order for the tasks-by-status gauge.
+var taskStatuses = []TaskStatus{TaskPending, TaskAssigned, TaskRunning, TaskCompleted, TaskFailed, TaskCancelled}
+
+// teamMetrics is a point-in-time snapshot of one team, built from disk state.
+type teamMetrics struct {
+	name          string
+	tasksByStatus map[TaskStatus]int
+	durations     []float64 // seconds, for tasks that ran to a terminal state
+	agents        []agentMetrics
+}
+
+type agentMetrics struct {
+	name     string
+	up       bool
+	restarts int
+	counters AgentCounters
+}
+
+// collectTeamMetrics builds metric snapshots for all teams.
+func collectTeamMetrics() ([]teamMetrics, error) {
+	names, err := ListTeams()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var out []teamMetrics
+	for _, name := range names {
+		cfg, err := GetTeam(name)
+		if err != nil {
+			continue
+		}
+		tm := teamMetrics{name: name, tasksByStatus: make(map[TaskStatus]int)}
+
+		tasks, _ := ListTasks(name, "", "")
+		for _, t := range tasks {
+			tm.tasksByStatus[t.Status]++
+			if t.StartedAt != nil && t.CompletedAt != nil && t.Status != TaskRunning {
+				tm.durations = append(tm.durations, t.CompletedAt.Sub(*t.StartedAt).Seconds())
+			}
+		}
+
+		for _, m := range cfg.Members {
+			am := agentMetrics{name: m.Name}
+			if state, err := GetAgentState(name, m.Name); err == nil && state != nil {
+				am.up = state.Status != AgentStopped && daemonAlive(state, readHeartbeat(name, m.Name))
+				am.restarts = state.RestartCount
+				if state.Counters != nil {
+					am.counters = *state.Counters
+				}
+			}
+			tm.agents = append(tm.agents, am)
+		}
+
+		out = append(out, tm)
+	}
+	return out, nil
+}
+
+// WritePrometheusMetrics writes agent subsystem metrics for all teams in the
+// Prometheus text exposition format (version 0.0.4).
+func WritePrometheusMetrics(w io.Writer) error {
+	teams, err := collectTeamMetrics()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	writeMetricHeader(&b, "codes_tasks", "gauge", "Number of tasks by team and status.")
+	for _, t := range teams {
+		for _, st := range taskStatuses {
+			fmt.Fprintf(&b, "codes_tasks{team=%q,status=%q} %d\n", escapeLabel(t.name), st, t.tasksByStatus[st])
+		}
+	}
+
+	writeMetricHeader(&b, "codes_task_duration_seconds", "histogram", "Run time of finished tasks, from start to completion.")
+	for _, t := range teams {
+		team := escapeLabel(t.name)
+		var sum float64
+		for _, d := range t.durations {
+			sum += d
+		}
+		for _, le := range taskDurationBuckets {
+			n := 0
+			for _, d := range t.durations {
+				if d <= le {
+					n++
+				}
+			}
+			fmt.Fprintf(&b, "codes_task_duration_seconds_bucket{team=%q,le=\"%g\"} %d\n", team, le, n)
+		}
+		fmt.Fprintf(&b, "codes_task_duration_seconds_bucket{team=%q,le=\"+Inf\"} %d\n", team, len(t.durations))
+		fmt.Fprintf(&b, "codes_task_duration_seconds_sum{team=%q} %g\n", team, sum)
+		fmt.Fprintf(&b, "codes_task_duration_seconds_count{team=%q} %d\n", team, len(t.durations))
+	}
+
+	writeMetricHeader(&b, "codes_agent_up", "gauge", "Whether the agent daemon process is running (1) or not (0).")
+	for _, t := range teams {
+		for _, a := range t.agents {
+			up := 0
+			if a.up {
+				up = 1
+			}
+			fmt.Fprintf(&b, "codes_agent_up{team=%q,agent=%q} %d\n", escapeLabel(t.name), escapeLabel(a.name), up)
+		}
+	}
+
+	writeMetricHeader(&b, "codes_agent_restarts_total", "counter", "Number of times the supervisor restarted the agent daemon.")
+	for _, t := range teams {
+		for _, a := range t.agents {
+			fmt.Fprintf(&b, "codes_agent_restarts_total{team=%q,agent=%q} %d\n", escapeLabel(t.name), escapeLabel(a.name), a.restarts)
+		}
+	}
+
+	writeMetricHeader(&b, "codes_claude_failures_total", "counter", "Number of failed Claude subprocess invocations.")
+	for _, t := range teams {
+		for _, a := range t.agents {
+			fmt.Fprintf(&b, "codes_claude_failures_total{team=%q,agent=%q} %d\n", escapeLabel(t.name), escapeLabel(a.name), a.counters.ClaudeFailures)
+		}
+	}
+
+	writeMetricHeader(&b, "codes_notification_errors_total", "counter", "Number of failed notification deliveries (desktop, webhook, hook, callback).")
+	for _, t := range teams {
+		for _, a := range t.agents {
+			fmt.Fprintf(&b, "codes_notification_errors_total{team=%q,agent=%q} %d\n", escapeLabel(t.name), escapeLabel(a.name), a.counters.NotificationErrors)
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func writeMetricHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// escapeLabel prepares a label value for use with %q. Go's %q already escapes
+// backslashes, quotes, and newlines the way the exposition format expects,
+// but would turn other control characters into \x escapes, so strip those.
+func escapeLabel(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPinnedContextBytes caps the total size of a task's pinned context
+// files. The prompt is passed to the CLI as a single argument, which Linux
+// limits to 128 KiB.
+const maxPinnedContextBytes = 96 << 10
+
+// PinnedContext reads the task's pinned context files, which are relative to
+// workDir and must stay inside it, and returns them formatted for the prompt.
+// Any file that cannot be read, is not UTF-8 text or would take the total
+// over maxPinnedContextBytes is an error: the task should not run without the
+// exact content it was pinned to.
+func PinnedContext(task *Task, workDir string) (string, error) {
+	if len(task.ContextFiles) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("The following files are pinned to this task. This is their content as of the start of the task:\n")
+	total := 0
+	for _, name := range task.ContextFiles {
+		path, err := pinnedPath(workDir, name)
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("pinned context file %s: %w", name, err)
+		}
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("pinned context file %s is not a regular file", name)
+		}
+		if total+int(info.Size()) > maxPinnedContextBytes {
+			return "", fmt.Errorf("pinned context files exceed %d KiB", maxPinnedContextBytes>>10)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("pinned context file %s: %w", name, err)
+		}
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("pinned context file %s is not text", name)
+		}
+		// The file may have grown since the stat
+		total += len(data)
+		if total > maxPinnedContextBytes {
+			return "", fmt.Errorf("pinned context files exceed %d KiB", maxPinnedContextBytes>>10)
+		}
+		fmt.Fprintf(&b, "\n<file path=%q>\n%s", name, data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		b.WriteString("</file>\n")
+	}
+	return b.String(), nil
+}
+
+// PinnedContextWarnings reports the pinned context files of task that could
+// not be read from workDir now, for dry runs. A file may legitimately be
+// missing at creation time if a blocking task produces it.
+func PinnedContextWarnings(task *Task, workDir string) []string {
+	var warnings []string
+	for _, name := range task.ContextFiles {
+		path, err := pinnedPath(workDir, name)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			warnings = append(warnings, fmt.Sprintf("pinned context file %s does not exist: the task would fail unless it is created first", name))
+		}
+	}
+	return warnings
+}
+
+// pinnedPath resolves a pinned context file against workDir. Absolute paths,
+// and paths that lead outside workDir through .. or a symlink, are refused,
+// so pinning cannot expose files the task's work dir policy keeps out of
+// reach.
+func pinnedPath(workDir, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "~") {
+		return "", fmt.Errorf("pinned context file %s must be relative to the work dir", name)
+	}
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return "", fmt.Errorf("resolve work dir: %w", err)
+	}
+	path := filepath.Join(root, name)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if !inDir(path, root) {
+		return "", fmt.Errorf("pinned context file %s is outside the work dir", name)
+	}
+	return path, nil
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// ParseAssignStrategy validates a strategy name. "" and "off" return the
+// empty strategy, which leaves unassigned tasks to auto-claim.
+func ParseAssignStrategy(s string) (AssignStrategy, error) {
+	switch AssignStrategy(s) {
+	case "", "off":
+		return "", nil
+	case AssignRoundRobin, AssignLeastLoaded, AssignRandom:
+		return AssignStrategy(s), nil
+	}
+	return "", fmt.Errorf("invalid assignment strategy %q (valid: %s, %s, %s, off)", s, AssignRoundRobin, AssignLeastLoaded, AssignRandom)
+}
+
+// SetAssignStrategy sets how the team places tasks created without an
+// owner. The empty strategy turns placement off.
+func SetAssignStrategy(teamName string, strategy AssignStrategy) error {
+	if _, err := ParseAssignStrategy(string(strategy)); err != nil {
+		return err
+	}
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return err
+	}
+	cfg.Assignment = strategy
+	return writeJSON(teamConfigPath(teamName), cfg)
+}
+
+// placeOwners fills in an owner for each spec without one, following the
+// team's assignment strategy. Candidates are running agents that have every
+// skill the spec asks for. Specs with no candidate, or in teams without a
+// strategy, stay unassigned for auto-claim. firstID is the ID the first spec
+// will get.
+func placeOwners(teamName string, specs []TaskSpec, firstID int) {
+	cfg, err := GetTeam(teamName)
+	if err != nil || cfg.Assignment == "" {
+		return
+	}
+
+	var alive []TeamMember
+	for _, m := range cfg.Members {
+		if IsAgentAlive(teamName, m.Name) {
+			alive = append(alive, m)
+		}
+	}
+	if len(alive) == 0 {
+		return
+	}
+
+	var load map[string]int
+	if cfg.Assignment == AssignLeastLoaded {
+		load = agentLoad(teamName)
+	}
+
+	for i := range specs {
+		if specs[i].Owner != "" || specs[i].Type == TaskTypeHuman {
+			continue
+		}
+		var candidates []TeamMember
+		for _, m := range alive {
+			if hasSkills(m.Skills, specs[i].Skills) {
+				candidates = append(candidates, m)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		var owner string
+		switch cfg.Assignment {
+		case AssignRoundRobin:
+			owner = candidates[(firstID+i)%len(candidates)].Name
+		case AssignLeastLoaded:
+			owner = candidates[0].Name
+			for _, m := range candidates[1:] {
+				if load[m.Name] < load[owner] {
+					owner = m.Name
+				}
+			}
+		case AssignRandom:
+			owner = candidates[rand.IntN(len(candidates))].Name
+		}
+		specs[i].Owner = owner
+		if load != nil {
+			load[owner]++
+		}
+	}
+}
+
+// agentLoad counts the assigned and running tasks of each agent.
+func agentLoad(teamName string) map[string]int {
+	load := make(map[string]int)
+	tasks, _ := ListTasks(teamName, "", "")
+	for _, t := range tasks {
+		if t.Owner != "" && (t.Status == TaskAssigned || t.Status == TaskRunning) {
+			load[t.Owner]++
+		}
+	}
+	return load
+}
+
+// hasSkills reports whether have includes every skill in want, ignoring case.
+func hasSkills(have, want []string) bool {
+	for _, s := range want {
+		if !slices.ContainsFunc(have, func(h string) bool { return strings.EqualFold(h, s) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"codes/internal/config"
+)
+
+// Dry-run planning: the Plan functions validate a change the way the real
+// call would and describe its outcome, without writing anything.
+
+// TaskPlan describes the task CreateTask would create.
+type TaskPlan struct {
+	Task     *Task    `json:"task"`               // as it would be written; the ID is the next free one
+	WorkDir  string   `json:"workDir,omitempty"`  // where it would run; empty means the daemon's own directory
+	Blocked  bool     `json:"blocked"`            // waits for unfinished blockedBy tasks
+	Warnings []string `json:"warnings,omitempty"` // valid, but probably not what the caller intends
+}
+
+// PlanTask validates spec for a new task in teamName and describes the task
+// that would be created, including where and when it would run.
+func PlanTask(teamName string, spec TaskSpec) (*TaskPlan, error) {
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	if spec.Priority != "" && spec.Priority != PriorityHigh && spec.Priority != PriorityNormal && spec.Priority != PriorityLow {
+		return nil, fmt.Errorf("invalid priority %q", spec.Priority)
+	}
+	if err := checkTaskType(spec); err != nil {
+		return nil, err
+	}
+
+	plan := &TaskPlan{}
+	for _, id := range spec.BlockedBy {
+		dep, err := GetTask(teamName, id)
+		if err != nil {
+			return nil, fmt.Errorf("blockedBy: %w", err)
+		}
+		switch dep.Status {
+		case TaskCompleted:
+		case TaskFailed, TaskCancelled:
+			plan.Blocked = true
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("blocked by task %d, which is %s: this task will not run unless it is retried", id, dep.Status))
+		default:
+			plan.Blocked = true
+		}
+	}
+
+	id, err := nextTaskID(teamName)
+	if err != nil {
+		return nil, fmt.Errorf("next task ID: %w", err)
+	}
+	specs := []TaskSpec{spec}
+	placeOwners(teamName, specs, id)
+	plan.Task = newTask(id, specs[0], time.Now())
+	plan.WorkDir = TaskWorkDir(teamName, plan.Task)
+
+	if spec.Project != "" && spec.WorkDir == "" {
+		if _, ok := config.GetProjectPath(spec.Project); !ok {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("project %q is not registered: the task would run in the team's working directory", spec.Project))
+		}
+	}
+	if plan.WorkDir != "" {
+		if info, err := os.Stat(plan.WorkDir); err != nil || !info.IsDir() {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("working directory %s does not exist: the task would fail", plan.WorkDir))
+		}
+	}
+
+	plan.Warnings = append(plan.Warnings, PinnedContextWarnings(plan.Task, plan.WorkDir)...)
+
+	if len(spec.Skills) > 0 && !slices.ContainsFunc(cfg.Members, func(m TeamMember) bool { return hasSkills(m.Skills, spec.Skills) }) {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("no member has skills %v: no agent would pick the task up", spec.Skills))
+	}
+	if spec.Type == TaskTypeHuman {
+		// Answered by the human; no agent picks it up
+	} else if spec.Owner != "" {
+		if !isMember(cfg, spec.Owner) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%q is not a member of team %q: no agent would pick the task up", spec.Owner, teamName))
+		} else if !IsAgentAlive(teamName, spec.Owner) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("agent %q is not running: the task would wait until it starts", spec.Owner))
+		}
+	} else if !anyAgentAlive(teamName, cfg) {
+		plan.Warnings = append(plan.Warnings, "unassigned and no agent is running: the task would wait until one starts")
+	}
+	return plan, nil
+}
+
+// RedirectPlan describes what RedirectTask would do.
+type RedirectPlan struct {
+	Cancel   *Task     `json:"cancel"`   // the task that would be cancelled, as it is now
+	StopsRun bool      `json:"stopsRun"` // its agent is working on it and would be interrupted
+	Create   *TaskPlan `json:"create"`   // the replacement task
+}
+
+// PlanRedirect validates a RedirectTask call and describes its outcome.
+func PlanRedirect(teamName string, taskID int, newInstructions, newSubject string) (*RedirectPlan, error) {
+	old, err := GetTask(teamName, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if old.Status == TaskCompleted || old.Status == TaskCancelled {
+		return nil, fmt.Errorf("cancel task %d: %w", taskID, &InvalidTransitionError{TaskID: taskID, From: old.Status, To: TaskCancelled})
+	}
+
+	subject := newSubject
+	if subject == "" {
+		subject = old.Subject
+	}
+	create, err := PlanTask(teamName, TaskSpec{
+		Subject:     subject,
+		Description: newInstructions,
+		Owner:       old.Owner,
+		Priority:    old.Priority,
+		Project:     old.Project,
+		WorkDir:     old.WorkDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create redirect task: %w", err)
+	}
+	return &RedirectPlan{
+		Cancel:   old,
+		StopsRun: old.Status == TaskRunning,
+		Create:   create,
+	}, nil
+}
+
+// AgentStartPlan describes what StartAllAgents would do for one member.
+type AgentStartPlan struct {
+	Name   string `json:"name"`
+	Start  bool   `json:"start"`            // a daemon would be started
+	PID    int    `json:"pid,omitempty"`    // the daemon already running
+	Reason string `json:"reason,omitempty"` // why no daemon would be started
+}
+
+// PlanStartAll describes what StartAllAgents would do for each member.
+func PlanStartAll(teamName string) ([]AgentStartPlan, error) {
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return nil, err
+	}
+	incompatible := checkTeamCompatible(teamName)
+
+	plans := make([]AgentStartPlan, 0, len(cfg.Members))
+	for _, m := range cfg.Members {
+		p := AgentStartPlan{Name: m.Name}
+		switch {
+		case IsAgentAlive(teamName, m.Name):
+			if state, _ := GetAgentState(teamName, m.Name); state != nil {
+				p.PID = state.PID
+			}
+			p.Reason = "already running"
+		case incompatible != nil:
+			p.Reason = incompatible.Error()
+		default:
+			p.Start = true
+		}
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
+
+// anyAgentAlive reports whether any member of the team has a live daemon.
+func anyAgentAlive(teamName string, cfg *TeamConfig) bool {
+	for _, m := range cfg.Members {
+		if IsAgentAlive(teamName, m.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMember reports whether the team has a member with the given name.
+func isMember(cfg *TeamConfig, name string) bool {
+	for _, m := range cfg.Members {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"log"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultPollInterval    = 3 * time.Second
+	defaultMaxPollInterval = 60 * time.Second
+
+	// idleBackoffAfter is how long an agent must be idle before its poll
+	// interval starts growing, so short lulls between tasks stay responsive.
+	idleBackoffAfter = 5 * time.Minute
+)
+
+// resolvePollSettings merges member overrides over team defaults over the
+// built-in defaults. The ceiling is never below the base interval.
+func resolvePollSettings(team, member *PollSettings) (base, max time.Duration) {
+	base, max = defaultPollInterval, defaultMaxPollInterval
+	for _, p := range []*PollSettings{team, member} {
+		if p == nil {
+			continue
+		}
+		if p.Interval > 0 {
+			base = time.Duration(p.Interval) * time.Second
+		}
+		if p.MaxInterval > 0 {
+			max = time.Duration(p.MaxInterval) * time.Second
+		}
+	}
+	if max < base {
+		max = base
+	}
+	return base, max
+}
+
+// pollBackoff computes the delay until the next poll. It stays at the base
+// interval while the agent is busy, then doubles per idle poll up to max once
+// the agent has been idle for idleBackoffAfter.
+type pollBackoff struct {
+	base, max time.Duration
+	current   time.Duration
+	idleSince time.Time
+}
+
+func newPollBackoff(base, max time.Duration, now time.Time) *pollBackoff {
+	return &pollBackoff{base: base, max: max, current: base, idleSince: now}
+}
+
+// next returns the delay before the next poll given whether the last poll
+// found work.
+func (b *pollBackoff) next(busy bool, now time.Time) time.Duration {
+	if busy {
+		b.reset(now)
+		return b.current
+	}
+	if now.Sub(b.idleSince) >= idleBackoffAfter && b.current < b.max {
+		b.current *= 2
+		if b.current > b.max {
+			b.current = b.max
+		}
+	}
+	return b.current
+}
+
+// reset drops back to the base interval, e.g. after a file change wake-up.
+func (b *pollBackoff) reset(now time.Time) {
+	b.current = b.base
+	b.idleSince = now
+}
+
+// watchTeamChanges signals on the returned channel whenever a file is
+// created, written or renamed in the team's tasks or messages directory.
+// Bursts are coalesced into a single wake-up. If the watcher cannot be
+// set up, a nil channel is returned and the daemon relies on polling alone.
+func watchTeamChanges(teamName string, logger *log.Logger) (<-chan struct{}, func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Printf("file watcher unavailable, polling only: %v", err)
+		return nil, func() {}
+	}
+	for _, dir := range []string{tasksDir(teamName), messagesDir(teamName)} {
+		if err := ensureDir(dir); err == nil {
+			err = watcher.Add(dir)
+		}
+		if err != nil {
+			logger.Printf("cannot watch %s, polling only: %v", dir, err)
+			watcher.Close()
+			return nil, func() {}
+		}
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Rename) {
+					continue
+				}
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return wake, func() { watcher.Close() }
+}
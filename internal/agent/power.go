@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codes/internal/config"
+)
+
+// Power policy: with config.PowerPolicy set, a daemon checks the machine's
+// battery and thermal state before picking up a task and leaves tasks queued
+// while the policy says to pause. It records why in AgentState.PausedReason,
+// which team_status surfaces as a warning, and resumes on its own once the
+// machine is plugged in or cools down.
+
+// powerCheckInterval limits how often the power state is read; pmset is an
+// exec on macOS.
+const powerCheckInterval = 30 * time.Second
+
+// powerStatus is a snapshot of the machine's power state.
+type powerStatus struct {
+	OnBattery bool
+	Battery   int  // charge percentage, -1 if unknown
+	Thermal   bool // the OS reports thermal throttling
+}
+
+// readPowerStatus reads the current power state. Platforms without support
+// report AC power and no thermal pressure, so the policy never pauses there.
+var readPowerStatus = func() powerStatus {
+	switch runtime.GOOS {
+	case "darwin":
+		st := powerStatus{Battery: -1}
+		if out, err := exec.Command("pmset", "-g", "batt").Output(); err == nil {
+			st.OnBattery, st.Battery = parsePmsetBatt(string(out))
+		}
+		if out, err := exec.Command("pmset", "-g", "therm").Output(); err == nil {
+			st.Thermal = parsePmsetTherm(string(out))
+		}
+		return st
+	case "linux":
+		return readSysfsPower("/sys/class")
+	}
+	return powerStatus{Battery: -1}
+}
+
+var percentRe = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`(\d+)%`)
+})
+
+// parsePmsetBatt parses `pmset -g batt`:
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=1234)	42%; discharging; 3:10 remaining present: true
+func parsePmsetBatt(out string) (onBattery bool, percent int) {
+	percent = -1
+	onBattery = strings.Contains(out, "'Battery Power'")
+	if m := percentRe().FindStringSubmatch(out); m != nil {
+		percent, _ = strconv.Atoi(m[1])
+	}
+	return onBattery, percent
+}
+
+var pmsetLevelRe = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(CPU_Speed_Limit\s*=\s*|warning level set to\s*)(\d+)`)
+})
+
+// parsePmsetTherm parses `pmset -g therm`. Intel Macs report
+// CPU_Speed_Limit below 100 while throttled; Apple silicon reports a
+// non-zero thermal or performance warning level.
+func parsePmsetTherm(out string) bool {
+	for _, m := range pmsetLevelRe().FindAllStringSubmatch(out, -1) {
+		n, _ := strconv.Atoi(m[2])
+		if strings.HasPrefix(m[1], "CPU_Speed_Limit") {
+			if n < 100 {
+				return true
+			}
+		} else if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// readSysfsPower reads power_supply and thermal_zone devices under root
+// (/sys/class). The machine is on battery when a battery is discharging, and
+// under thermal pressure when a zone has reached a passive (throttling) or
+// hot trip point.
+func readSysfsPower(root string) powerStatus {
+	st := powerStatus{Battery: -1}
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return strings.TrimSpace(string(data))
+	}
+
+	supplies, _ := filepath.Glob(filepath.Join(root, "power_supply", "*"))
+	total, count := 0, 0
+	for _, dir := range supplies {
+		if read(filepath.Join(dir, "type")) != "Battery" {
+			continue
+		}
+		if read(filepath.Join(dir, "status")) == "Discharging" {
+			st.OnBattery = true
+		}
+		if n, err := strconv.Atoi(read(filepath.Join(dir, "capacity"))); err == nil {
+			total += n
+			count++
+		}
+	}
+	if count > 0 {
+		st.Battery = total / count
+	}
+
+	zones, _ := filepath.Glob(filepath.Join(root, "thermal", "thermal_zone*"))
+	for _, dir := range zones {
+		temp, err := strconv.Atoi(read(filepath.Join(dir, "temp")))
+		if err != nil {
+			continue
+		}
+		types, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+		for _, typePath := range types {
+			kind := read(typePath)
+			if kind != "passive" && kind != "hot" {
+				continue
+			}
+			trip, err := strconv.Atoi(read(strings.TrimSuffix(typePath, "_type") + "_temp"))
+			if err == nil && trip > 0 && temp >= trip {
+				st.Thermal = true
+			}
+		}
+	}
+	return st
+}
+
+// powerPauseReason returns why the policy pauses task pickup for st, or ""
+// when agents may work.
+func powerPauseReason(p config.PowerPolicy, st powerStatus) string {
+	if p.PauseOnThermal && st.Thermal {
+		return "machine is under thermal pressure"
+	}
+	if p.MinBattery > 0 && st.OnBattery {
+		switch {
+		case p.MinBattery >= 100:
+			return "running on battery"
+		case st.Battery >= 0 && st.Battery < p.MinBattery:
+			return fmt.Sprintf("on battery at %d%% (below %d%%)", st.Battery, p.MinBattery)
+		}
+	}
+	return ""
+}
+
+// powerPaused reports whether the power policy holds new tasks back,
+// re-reading the power state at most every powerCheckInterval. Changes are
+// logged and recorded in the agent's state.
+func (d *Daemon) powerPaused(state *AgentState) bool {
+	if time.Since(d.powerCheckedAt) < powerCheckInterval {
+		return state.PausedReason != ""
+	}
+	d.powerCheckedAt = time.Now()
+
+	reason := ""
+	if p := config.GetPowerPolicy(); p != (config.PowerPolicy{}) {
+		reason = powerPauseReason(p, readPowerStatus())
+	}
+	if reason == state.PausedReason {
+		return reason != ""
+	}
+	state.PausedReason = reason
+	if reason != "" {
+		d.logger.Printf("pausing task pickup: %s", reason)
+		d.updateActivity(state, "paused: "+reason)
+	} else {
+		d.logger.Println("power policy satisfied, resuming task pickup")
+		d.updateActivity(state, "")
+	}
+	return reason != ""
+}
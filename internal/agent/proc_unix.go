@@ -17,6 +17,13 @@ func setSysProcAttr(cmd *exec.Cmd) {
 	}
 }
 
+// setDaemonSysProcAttr configures a spawned agent daemon so it outlives the
+// command that started it. A separate process group keeps terminal Ctrl+C
+// from reaching the daemon.
+func setDaemonSysProcAttr(cmd *exec.Cmd) {
+	setSysProcAttr(cmd)
+}
+
 // isProcessAlive checks if a process with the given PID is still running.
 func isProcessAlive(pid int) bool {
 	p, err := os.FindProcess(pid)
@@ -26,3 +33,18 @@ func isProcessAlive(pid int) bool {
 	err = p.Signal(syscall.Signal(0))
 	return err == nil
 }
+
+// listenStopEvent is a no-op on Unix: daemons receive SIGTERM, which
+// `codes agent daemon` already turns into context cancellation.
+func listenStopEvent(teamName, agentName string) (<-chan struct{}, func()) {
+	return nil, func() {}
+}
+
+// requestStop asks a daemon process to shut down gracefully via SIGTERM.
+func requestStop(pid int, teamName, agentName string) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(syscall.SIGTERM)
+}
@@ -3,11 +3,29 @@
 package agent
 
 import (
+	"fmt"
 	"os/exec"
+	"strings"
 	"syscall"
 	"unsafe"
 )
 
+var (
+	modkernel32            = syscall.NewLazyDLL("kernel32.dll")
+	procOpenProcess        = modkernel32.NewProc("OpenProcess")
+	procGetExitCodeProcess = modkernel32.NewProc("GetExitCodeProcess")
+	procCreateEventW       = modkernel32.NewProc("CreateEventW")
+	procOpenEventW         = modkernel32.NewProc("OpenEventW")
+	procSetEvent           = modkernel32.NewProc("SetEvent")
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+	detachedProcess                = 0x00000008
+	eventModifyState               = 0x0002
+)
+
 // setSysProcAttr configures platform-specific process attributes.
 // On Windows, we use CREATE_NEW_PROCESS_GROUP so the child process
 // can be terminated without affecting the parent.
@@ -17,29 +35,90 @@ func setSysProcAttr(cmd *exec.Cmd) {
 	}
 }
 
+// setDaemonSysProcAttr configures a spawned agent daemon so it outlives the
+// command that started it. DETACHED_PROCESS drops the console, so closing the
+// terminal window does not deliver CTRL_CLOSE_EVENT to the daemon.
+func setDaemonSysProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess,
+	}
+}
+
 // isProcessAlive checks if a process with the given PID is still running.
 func isProcessAlive(pid int) bool {
-	const PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
-	const STILL_ACTIVE = 259
-
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	openProcess := kernel32.NewProc("OpenProcess")
-
-	handle, _, _ := openProcess.Call(
-		PROCESS_QUERY_LIMITED_INFORMATION,
+	handle, _, err := procOpenProcess.Call(
+		processQueryLimitedInformation,
 		0,
 		uintptr(pid),
 	)
 	if handle == 0 {
-		return false
+		// The process exists but runs as another user or elevated.
+		return err == syscall.ERROR_ACCESS_DENIED
 	}
 	defer syscall.CloseHandle(syscall.Handle(handle))
 
 	var exitCode uint32
-	getExitCodeProcess := kernel32.NewProc("GetExitCodeProcess")
-	ret, _, _ := getExitCodeProcess.Call(uintptr(handle), uintptr(unsafe.Pointer(&exitCode)))
+	ret, _, _ := procGetExitCodeProcess.Call(uintptr(handle), uintptr(unsafe.Pointer(&exitCode)))
 	if ret == 0 {
 		return false
 	}
-	return exitCode == STILL_ACTIVE
+	return exitCode == stillActive
+}
+
+// stopEventName returns the per-agent named event used for graceful stop.
+// Backslashes are not allowed in kernel object names after the namespace.
+func stopEventName(teamName, agentName string) string {
+	r := strings.NewReplacer(`\`, "_", "/", "_")
+	return `Local\codes-agent-stop-` + r.Replace(teamName) + "-" + r.Replace(agentName)
+}
+
+// listenStopEvent creates the agent's named stop event and returns a channel
+// that is closed once another process signals it. Detached daemons have no
+// console, so this replaces SIGTERM/Ctrl+C for graceful shutdown.
+func listenStopEvent(teamName, agentName string) (<-chan struct{}, func()) {
+	name, err := syscall.UTF16PtrFromString(stopEventName(teamName, agentName))
+	if err != nil {
+		return nil, func() {}
+	}
+	h, _, _ := procCreateEventW.Call(0, 0, 0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return nil, func() {}
+	}
+
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer syscall.CloseHandle(syscall.Handle(h))
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			ev, _ := syscall.WaitForSingleObject(syscall.Handle(h), 500)
+			if ev == syscall.WAIT_OBJECT_0 {
+				close(stopped)
+				return
+			}
+		}
+	}()
+	return stopped, func() { close(done) }
+}
+
+// requestStop asks a daemon process to shut down gracefully by signalling
+// its named stop event.
+func requestStop(pid int, teamName, agentName string) error {
+	name, err := syscall.UTF16PtrFromString(stopEventName(teamName, agentName))
+	if err != nil {
+		return err
+	}
+	h, _, err := procOpenEventW.Call(eventModifyState, 0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return fmt.Errorf("open stop event for pid %d: %w", pid, err)
+	}
+	defer syscall.CloseHandle(syscall.Handle(h))
+	if ret, _, err := procSetEvent.Call(h); ret == 0 {
+		return fmt.Errorf("signal stop event for pid %d: %w", pid, err)
+	}
+	return nil
 }
@@ -24,6 +24,8 @@ func RunClaude(ctx context.Context, opts RunOptions) (*ClaudeResult, error) {
 		AllowedTools: opts.AllowedTools,
 		MaxTurns:     opts.MaxTurns,
 		PermMode:     opts.PermMode,
+		Nice:         opts.Nice,
+		IONiceClass:  opts.IONiceClass,
 	}
 
 	result, err := adapter.Run(ctx, cfg)
@@ -41,6 +43,7 @@ func RunClaude(ctx context.Context, opts RunOptions) (*ClaudeResult, error) {
 
 	if result.Cost != nil {
 		claudeResult.CostUSD = result.Cost.TotalCostUSD
+		claudeResult.Cost = result.Cost
 	}
 
 	if result.Duration > 0 {
@@ -70,6 +73,8 @@ func RunWithAdapter(ctx context.Context, adapterName string, opts RunOptions) (*
 		AllowedTools: opts.AllowedTools,
 		MaxTurns:     opts.MaxTurns,
 		PermMode:     opts.PermMode,
+		Nice:         opts.Nice,
+		IONiceClass:  opts.IONiceClass,
 		Timeout:      30 * time.Minute, // Default timeout
 	}
 
@@ -88,6 +93,7 @@ func RunWithAdapter(ctx context.Context, adapterName string, opts RunOptions) (*
 
 	if result.Cost != nil {
 		claudeResult.CostUSD = result.Cost.TotalCostUSD
+		claudeResult.Cost = result.Cost
 	}
 
 	if result.Duration > 0 {
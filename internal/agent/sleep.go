@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// Sleep inhibition: with preventSleep on, a daemon holds an inhibitor for as
+// long as it runs a task, so the machine stays awake while any agent of any
+// team is busy and may sleep again once they are all idle. The inhibitor is a
+// child process watching the daemon's PID, so it also goes away if the
+// daemon is killed.
+
+// sleepInhibitorArgs returns the command that keeps the machine awake until
+// pid exits, or nil if the platform has no supported inhibitor.
+func sleepInhibitorArgs(goos string, pid int, why string) []string {
+	switch goos {
+	case "darwin":
+		// -i: no idle sleep, -s: no system sleep on AC power
+		return []string{"caffeinate", "-i", "-s", "-w", strconv.Itoa(pid)}
+	case "linux":
+		return []string{"systemd-inhibit", "--what=idle:sleep", "--who=codes", "--why=" + why, "--mode=block",
+			"tail", "--pid=" + strconv.Itoa(pid), "-f", "/dev/null"}
+	}
+	return nil
+}
+
+// inhibitSleep keeps the machine awake until the returned release func is
+// called. Where no inhibitor is available it logs why and does nothing.
+func (d *Daemon) inhibitSleep(why string) (release func()) {
+	args := sleepInhibitorArgs(runtime.GOOS, os.Getpid(), why)
+	if args == nil {
+		d.logger.Printf("preventSleep: not supported on %s", runtime.GOOS)
+		return func() {}
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		d.logger.Printf("preventSleep: %s not found, the machine may sleep during the task", args[0])
+		return func() {}
+	}
+	cmd := exec.Command(path, args[1:]...)
+	if err := cmd.Start(); err != nil {
+		d.logger.Printf("preventSleep: start %s: %v", args[0], err)
+		return func() {}
+	}
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // teamsBaseDirFunc returns the base directory for all teams (~/.codes/teams/).
@@ -21,6 +24,21 @@ func teamDir(teamName string) string {
 	return filepath.Join(teamsBaseDirFunc(), teamName)
 }
 
+// execSlotsDir returns the directory of host-wide execution slot lock files.
+func execSlotsDir() string {
+	return filepath.Join(teamsBaseDirFunc(), ".slots")
+}
+
+// templatesDir returns the directory of saved team templates.
+func templatesDir() string {
+	return filepath.Join(teamsBaseDirFunc(), ".templates")
+}
+
+// templatePath returns the path to a saved team template.
+func templatePath(name string) string {
+	return filepath.Join(templatesDir(), name+".json")
+}
+
 // teamConfigPath returns the path to the team config file.
 func teamConfigPath(teamName string) string {
 	return filepath.Join(teamDir(teamName), "config.json")
@@ -36,11 +54,26 @@ func taskPath(teamName string, taskID int) string {
 	return filepath.Join(tasksDir(teamName), fmt.Sprintf("%d.json", taskID))
 }
 
+// archivedTasksDir returns the directory tasks are moved to by ArchiveTasks.
+func archivedTasksDir(teamName string) string {
+	return filepath.Join(tasksDir(teamName), "archive")
+}
+
+// archivedTaskPath returns the path to an archived task file.
+func archivedTaskPath(teamName string, taskID int) string {
+	return filepath.Join(archivedTasksDir(teamName), fmt.Sprintf("%d.json", taskID))
+}
+
 // taskLockPath returns the path to a task's lock file.
 func taskLockPath(teamName string, taskID int) string {
 	return filepath.Join(tasksDir(teamName), fmt.Sprintf("%d.json.lock", taskID))
 }
 
+// taskArtifactsDir returns the directory holding a task's collected artifacts.
+func taskArtifactsDir(teamName string, taskID int) string {
+	return filepath.Join(tasksDir(teamName), strconv.Itoa(taskID), "artifacts")
+}
+
 // messagesDir returns the messages directory for a team.
 func messagesDir(teamName string) string {
 	return filepath.Join(teamDir(teamName), "messages")
@@ -56,6 +89,60 @@ func agentStatePath(teamName, agentName string) string {
 	return filepath.Join(agentsDir(teamName), agentName+".json")
 }
 
+// NotificationPath returns where a daemon writes the completion notification
+// for a task, ~/.codes/notifications/{team}__{id}.json. The MCP monitor and
+// `codes selftest` watch this directory.
+func NotificationPath(teamName string, taskID int) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	// Use __ separator to avoid ambiguity when team name contains hyphens
+	return filepath.Join(home, ".codes", "notifications", fmt.Sprintf("%s__%d.json", teamName, taskID)), nil
+}
+
+// NotificationsDir returns the directory of task notifications,
+// ~/.codes/notifications.
+func NotificationsDir() (string, error) {
+	path, err := NotificationPath("", 0)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
+// PruneNotifications deletes notification files last written before the
+// given time. The MCP monitor removes the ones it delivers; these are left
+// over from daemons that finished while no monitor was running.
+func PruneNotifications(before time.Time) (int, error) {
+	dir, err := NotificationsDir()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	pruned := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if os.Remove(filepath.Join(dir, e.Name())) == nil {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
 // ensureDir creates a directory (and parents) if it doesn't exist.
 func ensureDir(dir string) error {
 	return os.MkdirAll(dir, 0755)
@@ -73,6 +160,10 @@ func writeJSON(path string, v any) error {
 		return fmt.Errorf("marshal: %w", err)
 	}
 
+	if err := chaosDiskWrite(path); err != nil {
+		return err
+	}
+
 	tmp := path + ".tmp"
 	if err := os.WriteFile(tmp, data, 0644); err != nil {
 		return fmt.Errorf("write tmp: %w", err)
@@ -87,6 +178,7 @@ func writeJSON(path string, v any) error {
 
 // readJSON reads and unmarshals a JSON file into v.
 func readJSON(path string, v any) error {
+	chaosDiskRead()
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -94,26 +186,28 @@ func readJSON(path string, v any) error {
 	return json.Unmarshal(data, v)
 }
 
-// nextTaskID scans the tasks directory and returns the next available ID.
+// nextTaskID scans the tasks directory and the archive and returns the next
+// available ID, so archived IDs are never reused.
 func nextTaskID(teamName string) (int, error) {
-	dir := tasksDir(teamName)
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return 1, nil
-		}
-		return 0, err
-	}
-
 	maxID := 0
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
+	for _, dir := range []string{tasksDir(teamName), archivedTasksDir(teamName)} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, err
 		}
-		var id int
-		if _, err := fmt.Sscanf(e.Name(), "%d.json", &id); err == nil {
-			if id > maxID {
-				maxID = id
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			var id int
+			if _, err := fmt.Sscanf(e.Name(), "%d.json", &id); err == nil {
+				if id > maxID {
+					maxID = id
+				}
 			}
 		}
 	}
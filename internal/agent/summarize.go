@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// summaryThreshold is the result length above which a result is summarized.
+// Shorter results are passed around as they are.
+const summaryThreshold = 500
+
+// summaryTimeout bounds the summarization run, so a slow model never holds
+// up the completion report for long.
+const summaryTimeout = 2 * time.Minute
+
+const summaryPrompt = `Summarize the following report of a finished coding task for the rest of the team.
+Reply with only a JSON object, no prose and no code fence:
+{"changes": "<what was done, at most two sentences>", "files": ["<paths created or modified>"], "followUps": ["<open issues or suggested next steps>"]}
+Use empty lists when there is nothing to report.
+
+Task: %s
+
+Report:
+%s`
+
+// SummarizeResult asks model, through the named adapter, for a structured
+// summary of a task result. It returns nil without running anything when the
+// result is short, model is empty, or the adapter cannot select a model.
+func SummarizeResult(ctx context.Context, adapterName, model string, task *Task, result string) (*TaskSummary, error) {
+	if len(result) <= summaryThreshold || model == "" {
+		return nil, nil
+	}
+	if adapterName == "" {
+		adapterName = "claude"
+	}
+	adapter, err := GetAdapter(adapterName)
+	if err != nil {
+		return nil, err
+	}
+	if !adapter.Capabilities().ModelSelection {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+	out, err := RunWithAdapter(ctx, adapterName, RunOptions{
+		Prompt:   fmt.Sprintf(summaryPrompt, task.Subject, result),
+		WorkDir:  os.TempDir(), // the summary needs no project context
+		Model:    model,
+		MaxTurns: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summarize: %w", err)
+	}
+	if out.IsError {
+		return nil, fmt.Errorf("summarize: %s", out.Error)
+	}
+	return parseSummary(out.Result)
+}
+
+// parseSummary extracts the JSON summary from the model's reply, tolerating
+// surrounding prose or a code fence.
+func parseSummary(reply string) (*TaskSummary, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("summarize: no JSON object in reply")
+	}
+	var s TaskSummary
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &s); err != nil {
+		return nil, fmt.Errorf("summarize: %w", err)
+	}
+	s.Changes = strings.TrimSpace(s.Changes)
+	if s.Changes == "" {
+		return nil, fmt.Errorf("summarize: reply has no changes")
+	}
+	return &s, nil
+}
+
+// String renders the summary as plain text for messages and notifications.
+func (s *TaskSummary) String() string {
+	var b strings.Builder
+	b.WriteString(s.Changes)
+	if len(s.Files) > 0 {
+		b.WriteString("\nFiles: ")
+		b.WriteString(strings.Join(s.Files, ", "))
+	}
+	if len(s.FollowUps) > 0 {
+		b.WriteString("\nFollow-ups:")
+		for _, f := range s.FollowUps {
+			b.WriteString("\n- ")
+			b.WriteString(f)
+		}
+	}
+	return b.String()
+}
+
+// resultDigest is the text reported for a completed task: the summary when
+// there is one, otherwise the result truncated to summaryThreshold.
+func resultDigest(result string, summary *TaskSummary) string {
+	if summary != nil {
+		return summary.String()
+	}
+	return truncate(result, summaryThreshold)
+}
@@ -21,6 +21,10 @@ type SupervisorConfig struct {
 	CrashWindow   time.Duration // time window to consider crashes as consecutive (default: 5m)
 }
 
+// stopGracePeriod is how long a cancelled daemon may take to drain before
+// the supervisor kills it.
+const stopGracePeriod = 30 * time.Second
+
 // Supervisor manages the lifecycle of an agent daemon, automatically restarting
 // it on crashes with exponential backoff.
 type Supervisor struct {
@@ -124,9 +128,15 @@ func (s *Supervisor) startDaemon(ctx context.Context) error {
 		return fmt.Errorf("cannot find executable: %w", err)
 	}
 
-	cmd := exec.CommandContext(ctx, exe, "agent", "run", s.cfg.TeamName, s.cfg.AgentName)
+	cmd := exec.CommandContext(ctx, exe, "agent", "daemon", s.cfg.TeamName, s.cfg.AgentName)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	// On cancellation, ask the daemon to stop gracefully (SIGTERM or the
+	// Windows stop event) so it can drain its running task before exiting.
+	cmd.Cancel = func() error {
+		return requestStop(cmd.Process.Pid, s.cfg.TeamName, s.cfg.AgentName)
+	}
+	cmd.WaitDelay = stopGracePeriod
 
 	// Mark state as supervised before starting
 	state, _ := GetAgentState(s.cfg.TeamName, s.cfg.AgentName)
@@ -199,9 +209,9 @@ func (s *Supervisor) cleanupStalePID() error {
 		return nil
 	}
 
-	// Check if process is alive
-	if isProcessAlive(state.PID) {
-		return nil // process still running, no cleanup needed
+	// A reused PID can look alive; the heartbeat tells
+	if daemonAlive(state, readHeartbeat(s.cfg.TeamName, s.cfg.AgentName)) {
+		return nil // daemon still running, no cleanup needed
 	}
 
 	s.logger.Printf("detected stale PID %d, cleaning up", state.PID)
@@ -229,9 +239,8 @@ func HealthCheck(teamName, agentName string) error {
 		return fmt.Errorf("no PID recorded")
 	}
 
-	// Check if process is alive
-	alive := isProcessAlive(state.PID)
-	if !alive {
+	if !daemonAlive(state, readHeartbeat(teamName, agentName)) {
+		pid := state.PID
 		// Process died unexpectedly (not under supervisor)
 		if !state.Supervised {
 			now := time.Now()
@@ -246,7 +255,7 @@ func HealthCheck(teamName, agentName string) error {
 			return fmt.Errorf("failed to update state: %w", err)
 		}
 
-		return fmt.Errorf("process %d is not running", state.PID)
+		return fmt.Errorf("process %d is not running", pid)
 	}
 
 	return nil
@@ -271,14 +280,15 @@ func GetAgentUptime(teamName, agentName string) (time.Duration, error) {
 
 // HealthStatus holds structured health status for an agent.
 type HealthStatus struct {
-	Alive        bool          `json:"alive"`
-	PID          int           `json:"pid"`
-	Status       AgentStatus   `json:"status"`
-	Uptime       time.Duration `json:"uptime"`
-	RestartCount int           `json:"restartCount"`
-	LastCrash    *time.Time    `json:"lastCrash,omitempty"`
-	Supervised   bool          `json:"supervised"`
-	Error        string        `json:"error,omitempty"`
+	Alive         bool          `json:"alive"`
+	PID           int           `json:"pid"`
+	Status        AgentStatus   `json:"status"`
+	Uptime        time.Duration `json:"uptime"`
+	RestartCount  int           `json:"restartCount"`
+	LastCrash     *time.Time    `json:"lastCrash,omitempty"`
+	LastHeartbeat *time.Time    `json:"lastHeartbeat,omitempty"`
+	Supervised    bool          `json:"supervised"`
+	Error         string        `json:"error,omitempty"`
 }
 
 // GetAgentHealthStatus returns the health status of an agent.
@@ -302,10 +312,11 @@ func GetAgentHealthStatus(teamName, agentName string) (*HealthStatus, error) {
 		Supervised:   state.Supervised,
 	}
 
-	// Check if process is alive
-	if state.PID > 0 {
-		status.Alive = isProcessAlive(state.PID)
+	hb := readHeartbeat(teamName, agentName)
+	if hb != nil {
+		status.LastHeartbeat = &hb.At
 	}
+	status.Alive = daemonAlive(state, hb)
 
 	// Calculate uptime if running
 	if status.Alive && state.Status != AgentStopped {
@@ -323,18 +334,12 @@ func GetAgentHealthStatus(teamName, agentName string) (*HealthStatus, error) {
 // StaleStateCleanup scans all agent states and cleans up stale PIDs.
 // This should be called periodically (e.g., on startup or via cron).
 func StaleStateCleanup() error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("cannot get home dir: %w", err)
-	}
-
-	agentDir := filepath.Join(home, ".codes", "agent")
-	teams, err := os.ReadDir(agentDir)
+	teams, err := os.ReadDir(teamsBaseDirFunc())
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil // no agents directory
+			return nil // no teams directory
 		}
-		return fmt.Errorf("cannot read agent dir: %w", err)
+		return fmt.Errorf("cannot read teams dir: %w", err)
 	}
 
 	cleanedCount := 0
@@ -344,9 +349,7 @@ func StaleStateCleanup() error {
 		}
 
 		teamName := team.Name()
-		agentsPath := filepath.Join(agentDir, teamName, "agents")
-
-		agents, err := os.ReadDir(agentsPath)
+		agents, err := os.ReadDir(agentsDir(teamName))
 		if err != nil {
 			continue
 		}
@@ -363,7 +366,7 @@ func StaleStateCleanup() error {
 				continue
 			}
 
-			if state.PID > 0 && !isProcessAlive(state.PID) {
+			if state.PID > 0 && !daemonAlive(state, readHeartbeat(teamName, agentName)) {
 				state.PID = 0
 				state.Status = AgentStopped
 				state.CurrentTask = 0
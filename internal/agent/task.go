@@ -1,15 +1,24 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
+
+	"codes/internal/config"
+	"codes/internal/filelock"
 )
 
-// CreateTask creates a new task in a team.
+// CreateTask creates a new task in a team. Without an owner, the team's
+// assignment strategy may pick one (see placeOwners).
 func CreateTask(teamName, subject, description, owner string, blockedBy []int, priority TaskPriority, project, workDir string) (*Task, error) {
+	if err := checkTaskWorkDir(teamName, project, workDir); err != nil {
+		return nil, err
+	}
 	if err := ensureDir(tasksDir(teamName)); err != nil {
 		return nil, err
 	}
@@ -19,44 +28,215 @@ func CreateTask(teamName, subject, description, owner string, blockedBy []int, p
 		return nil, fmt.Errorf("next task ID: %w", err)
 	}
 
-	now := time.Now()
+	specs := []TaskSpec{{
+		Subject:     subject,
+		Description: description,
+		Owner:       owner,
+		BlockedBy:   blockedBy,
+		Priority:    priority,
+		Project:     project,
+		WorkDir:     workDir,
+	}}
+	placeOwners(teamName, specs, id)
+	task := newTask(id, specs[0], time.Now())
+
+	if err := writeJSON(taskPath(teamName, id), task); err != nil {
+		return nil, fmt.Errorf("write task: %w", err)
+	}
+
+	return task, nil
+}
+
+// newTask builds a new pending (or assigned, when spec has an owner) task.
+// Human tasks are assigned to HumanOwner from the start.
+func newTask(id int, spec TaskSpec, now time.Time) *Task {
+	if spec.Type == TaskTypeHuman {
+		spec.Owner = HumanOwner
+	}
 	status := TaskPending
-	if owner != "" {
+	if spec.Owner != "" {
 		status = TaskAssigned
 	}
 
+	priority := spec.Priority
 	if priority == "" {
 		priority = PriorityNormal
 	}
 
-	task := &Task{
-		ID:          id,
-		Subject:     subject,
-		Description: description,
-		Status:      status,
-		Priority:    priority,
-		Owner:       owner,
-		Project:     project,
-		WorkDir:     workDir,
-		BlockedBy:   blockedBy,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+	return &Task{
+		ID:           id,
+		Subject:      spec.Subject,
+		Description:  spec.Description,
+		Status:       status,
+		Priority:     priority,
+		Owner:        spec.Owner,
+		Project:      spec.Project,
+		WorkDir:      spec.WorkDir,
+		BlockedBy:    spec.BlockedBy,
+		Skills:       spec.Skills,
+		Labels:       spec.Labels,
+		Type:         spec.Type,
+		Choices:      spec.Choices,
+		Artifacts:    spec.Artifacts,
+		ContextFiles: spec.ContextFiles,
+		Adapter:      spec.Adapter,
+		SessionID:    spec.SessionID,
+		FollowUpOf:   spec.FollowUpOf,
+		History:      []TaskTransition{{To: status, At: now}},
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
+}
 
-	if err := writeJSON(taskPath(teamName, id), task); err != nil {
-		return nil, fmt.Errorf("write task: %w", err)
+// TaskSpec describes one task for CreateTasks.
+type TaskSpec struct {
+	Subject      string
+	Description  string
+	Owner        string
+	BlockedBy    []int // IDs of existing tasks
+	DependsOn    []int // 1-based positions of earlier specs in the same batch
+	Priority     TaskPriority
+	Project      string
+	WorkDir      string
+	Skills       []string // skills the owner must have; see placeOwners
+	Labels       []string
+	Artifacts    []string
+	ContextFiles []string // files whose contents are inlined into the prompt
+	Adapter      string   // CLI adapter to run with (default: claude)
+	SessionID    string   // Claude session to resume
+	FollowUpOf   int      // completed task the session comes from
+	Type         TaskType // TaskTypeHuman for a question to the human
+	Choices      []string // allowed answers to a human task
+}
+
+// CreateTasks creates a batch of tasks all-or-nothing. DependsOn entries
+// refer to earlier specs in the batch and become BlockedBy on the created
+// tasks. Tasks are returned in spec order.
+func CreateTasks(teamName string, specs []TaskSpec) ([]*Task, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no tasks given")
+	}
+	for i, spec := range specs {
+		if spec.Subject == "" {
+			return nil, fmt.Errorf("task %d: subject is required", i+1)
+		}
+		if spec.Priority != "" && spec.Priority != PriorityHigh && spec.Priority != PriorityNormal && spec.Priority != PriorityLow {
+			return nil, fmt.Errorf("task %d: invalid priority %q", i+1, spec.Priority)
+		}
+		if err := checkTaskType(spec); err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		for _, dep := range spec.DependsOn {
+			if dep < 1 || dep > i {
+				return nil, fmt.Errorf("task %d: dependsOn %d must refer to an earlier task in the batch (1-%d)", i+1, dep, i)
+			}
+		}
+		for _, id := range spec.BlockedBy {
+			if _, err := GetTask(teamName, id); err != nil {
+				return nil, fmt.Errorf("task %d: blockedBy: %w", i+1, err)
+			}
+		}
+		if err := checkTaskWorkDir(teamName, spec.Project, spec.WorkDir); err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+	}
+	if err := ensureDir(tasksDir(teamName)); err != nil {
+		return nil, err
 	}
 
-	return task, nil
+	first, err := nextTaskID(teamName)
+	if err != nil {
+		return nil, fmt.Errorf("next task ID: %w", err)
+	}
+
+	specs = slices.Clone(specs)
+	placeOwners(teamName, specs, first)
+
+	now := time.Now()
+	tasks := make([]*Task, len(specs))
+	for i, spec := range specs {
+		blockedBy := append([]int(nil), spec.BlockedBy...)
+		for _, dep := range spec.DependsOn {
+			blockedBy = append(blockedBy, first+dep-1)
+		}
+		spec.BlockedBy = blockedBy
+		tasks[i] = newTask(first+i, spec, now)
+	}
+
+	// Stage every task before publishing any, so a failed write leaves
+	// nothing behind. Staged names are hidden from ListTasks but still
+	// reserve their IDs against concurrent CreateTask calls.
+	staged := make([]string, 0, len(tasks))
+	discard := func() {
+		for _, p := range staged {
+			os.Remove(p)
+		}
+	}
+	for _, task := range tasks {
+		data, err := json.MarshalIndent(task, "", "  ")
+		if err != nil {
+			discard()
+			return nil, fmt.Errorf("marshal task: %w", err)
+		}
+		p := taskPath(teamName, task.ID) + ".batch"
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			discard()
+			return nil, fmt.Errorf("write task: %w", err)
+		}
+		staged = append(staged, p)
+	}
+
+	// Publish in order: a task's batch dependencies are always visible
+	// before it is.
+	for i, task := range tasks {
+		if err := os.Rename(staged[i], taskPath(teamName, task.ID)); err != nil {
+			for _, published := range tasks[:i] {
+				os.Remove(taskPath(teamName, published.ID))
+			}
+			staged = staged[i:]
+			discard()
+			return nil, fmt.Errorf("publish task %d: %w", task.ID, err)
+		}
+	}
+
+	return tasks, nil
+}
+
+// TaskWorkDir returns the directory a task runs in, resolved the way the
+// daemon does: the task's WorkDir, then its registered project's path, then
+// the team's WorkDir. It returns "" when none of these is set.
+func TaskWorkDir(teamName string, task *Task) string {
+	if task.WorkDir != "" {
+		return task.WorkDir
+	}
+	if task.Project != "" {
+		if path, ok := config.GetProjectPath(task.Project); ok {
+			return path
+		}
+	}
+	if cfg, err := GetTeam(teamName); err == nil {
+		return cfg.WorkDir
+	}
+	return ""
+}
+
+// checkTaskWorkDir checks the directory a new task would run in against the
+// work dir policy.
+func checkTaskWorkDir(teamName, project, workDir string) error {
+	return config.CheckWorkDir(TaskWorkDir(teamName, &Task{Project: project, WorkDir: workDir}))
 }
 
-// GetTask loads a single task by ID.
+// GetTask loads a single task by ID. Archived tasks are found too, so
+// dependencies on them still resolve.
 func GetTask(teamName string, taskID int) (*Task, error) {
 	var task Task
-	path := taskPath(teamName, taskID)
-	if err := readJSON(path, &task); err != nil {
+	err := readJSON(taskPath(teamName, taskID), &task)
+	if os.IsNotExist(err) {
+		err = readJSON(archivedTaskPath(teamName, taskID), &task)
+	}
+	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("task %d not found in team %q", taskID, teamName)
+			return nil, newError(ErrTaskNotFound, map[string]any{"team": teamName, "taskId": taskID}, "task %d not found in team %q", taskID, teamName)
 		}
 		return nil, err
 	}
@@ -105,7 +285,7 @@ func ListTasks(teamName string, statusFilter TaskStatus, ownerFilter string) ([]
 
 	// Sort by priority (high > normal > low), then by ID (ascending)
 	sort.Slice(tasks, func(i, j int) bool {
-		pi, pj := priorityRank(tasks[i].Priority), priorityRank(tasks[j].Priority)
+		pi, pj := PriorityRank(tasks[i].Priority), PriorityRank(tasks[j].Priority)
 		if pi != pj {
 			return pi < pj
 		}
@@ -115,8 +295,8 @@ func ListTasks(teamName string, statusFilter TaskStatus, ownerFilter string) ([]
 	return tasks, nil
 }
 
-// priorityRank returns a sort rank for a priority level (lower = higher priority).
-func priorityRank(p TaskPriority) int {
+// PriorityRank returns a sort rank for a priority level (lower = higher priority).
+func PriorityRank(p TaskPriority) int {
 	switch p {
 	case PriorityHigh:
 		return 0
@@ -128,13 +308,16 @@ func priorityRank(p TaskPriority) int {
 }
 
 // UpdateTask modifies a task with file locking for safe concurrent access.
+// Status changes made by updateFn are checked against the task state machine
+// and recorded in the task's history; an invalid transition aborts the update
+// and leaves the task on disk untouched.
 func UpdateTask(teamName string, taskID int, updateFn func(*Task) error) (*Task, error) {
 	lockPath := taskLockPath(teamName, taskID)
 	if err := ensureDir(tasksDir(teamName)); err != nil {
 		return nil, err
 	}
 
-	fl := NewFileLock(lockPath)
+	fl := filelock.New(lockPath)
 	if err := fl.Lock(); err != nil {
 		return nil, fmt.Errorf("lock task %d: %w", taskID, err)
 	}
@@ -145,19 +328,55 @@ func UpdateTask(teamName string, taskID int, updateFn func(*Task) error) (*Task,
 		return nil, err
 	}
 
+	prevStatus := task.Status
 	if err := updateFn(task); err != nil {
 		return nil, err
 	}
 
-	task.UpdatedAt = time.Now()
+	now := time.Now()
+	if task.Status != prevStatus {
+		if err := applyTransition(task, prevStatus, now); err != nil {
+			return nil, err
+		}
+	}
+	task.UpdatedAt = now
 
 	if err := writeJSON(taskPath(teamName, taskID), task); err != nil {
 		return nil, fmt.Errorf("write task: %w", err)
 	}
+	// Changing an archived task brings it back
+	os.Remove(archivedTaskPath(teamName, taskID))
 
 	return task, nil
 }
 
+// SetTaskArtifacts declares the output artifact paths/globs for a task.
+// The daemon copies matching files into the task's artifacts directory on completion.
+func SetTaskArtifacts(teamName string, taskID int, patterns []string) (*Task, error) {
+	return UpdateTask(teamName, taskID, func(t *Task) error {
+		t.Artifacts = patterns
+		return nil
+	})
+}
+
+// SetTaskContextFiles pins files to a task. The daemon inlines their contents
+// into the prompt when the task starts.
+func SetTaskContextFiles(teamName string, taskID int, paths []string) (*Task, error) {
+	return UpdateTask(teamName, taskID, func(t *Task) error {
+		t.ContextFiles = paths
+		return nil
+	})
+}
+
+// SetTaskRequestID records the HTTP request that created or redirected a
+// task, so the daemon's log lines for it can be matched to the API log.
+func SetTaskRequestID(teamName string, taskID int, requestID string) (*Task, error) {
+	return UpdateTask(teamName, taskID, func(t *Task) error {
+		t.RequestID = requestID
+		return nil
+	})
+}
+
 // AssignTask assigns a task to an agent.
 func AssignTask(teamName string, taskID int, owner string) (*Task, error) {
 	return UpdateTask(teamName, taskID, func(t *Task) error {
@@ -174,7 +393,7 @@ func AssignTask(teamName string, taskID int, owner string) (*Task, error) {
 func CompleteTask(teamName string, taskID int, result string) (*Task, error) {
 	return UpdateTask(teamName, taskID, func(t *Task) error {
 		if t.Status != TaskRunning && t.Status != TaskAssigned {
-			return fmt.Errorf("cannot complete task %d: status is %s", taskID, t.Status)
+			return &InvalidTransitionError{TaskID: taskID, From: t.Status, To: TaskCompleted}
 		}
 		t.Status = TaskCompleted
 		t.Result = result
@@ -188,7 +407,7 @@ func CompleteTask(teamName string, taskID int, result string) (*Task, error) {
 func FailTask(teamName string, taskID int, errMsg string) (*Task, error) {
 	return UpdateTask(teamName, taskID, func(t *Task) error {
 		if t.Status != TaskRunning && t.Status != TaskAssigned {
-			return fmt.Errorf("cannot fail task %d: status is %s", taskID, t.Status)
+			return &InvalidTransitionError{TaskID: taskID, From: t.Status, To: TaskFailed}
 		}
 		t.Status = TaskFailed
 		t.Error = errMsg
@@ -202,7 +421,7 @@ func FailTask(teamName string, taskID int, errMsg string) (*Task, error) {
 func CancelTask(teamName string, taskID int) (*Task, error) {
 	return UpdateTask(teamName, taskID, func(t *Task) error {
 		if t.Status == TaskCompleted || t.Status == TaskCancelled {
-			return fmt.Errorf("cannot cancel task %d: status is %s", taskID, t.Status)
+			return &InvalidTransitionError{TaskID: taskID, From: t.Status, To: TaskCancelled}
 		}
 		t.Status = TaskCancelled
 		now := time.Now()
@@ -233,6 +452,97 @@ func RedirectTask(teamName string, taskID int, newInstructions string, newSubjec
 	return newTask, nil
 }
 
+// FollowUpTask creates a task that resumes a completed task's Claude session
+// with further instructions, so the agent keeps the context of the earlier
+// work instead of starting over. The new task inherits the original's owner,
+// priority, project, working directory and adapter; subject defaults to
+// "Follow-up: <original subject>".
+func FollowUpTask(teamName string, taskID int, instructions, subject string) (*Task, error) {
+	if instructions == "" {
+		return nil, fmt.Errorf("instructions are required")
+	}
+	old, err := GetTask(teamName, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if old.Status != TaskCompleted {
+		return nil, fmt.Errorf("task %d is %s: only completed tasks can be followed up", taskID, old.Status)
+	}
+	if old.SessionID == "" {
+		return nil, fmt.Errorf("task %d has no session to resume", taskID)
+	}
+
+	if subject == "" {
+		subject = "Follow-up: " + old.Subject
+	}
+	tasks, err := CreateTasks(teamName, []TaskSpec{{
+		Subject:     subject,
+		Description: instructions,
+		Owner:       old.Owner,
+		Priority:    old.Priority,
+		Project:     old.Project,
+		WorkDir:     old.WorkDir,
+		Adapter:     old.Adapter,
+		SessionID:   old.SessionID,
+		FollowUpOf:  old.ID,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("create follow-up task: %w", err)
+	}
+	return tasks[0], nil
+}
+
+// ArchiveTasks moves tasks that finished (completed, failed or cancelled)
+// before the given time out of the task list into tasks/archive/. They no
+// longer show up in ListTasks but GetTask still finds them. Returns the
+// number of tasks archived.
+func ArchiveTasks(teamName string, before time.Time) (int, error) {
+	tasks, err := ListTasks(teamName, "", "")
+	if err != nil {
+		return 0, err
+	}
+
+	archived := 0
+	for _, t := range tasks {
+		if !t.Status.IsTerminal() || !t.UpdatedAt.Before(before) {
+			continue
+		}
+		ok, err := archiveTask(teamName, t.ID, before)
+		if err != nil {
+			return archived, fmt.Errorf("archive task %d: %w", t.ID, err)
+		}
+		if ok {
+			archived++
+		}
+	}
+	return archived, nil
+}
+
+// archiveTask moves one task file to the archive under the task's lock,
+// unless it changed since it was listed.
+func archiveTask(teamName string, taskID int, before time.Time) (bool, error) {
+	fl := filelock.New(taskLockPath(teamName, taskID))
+	if err := fl.Lock(); err != nil {
+		return false, err
+	}
+	defer fl.Unlock()
+
+	var task Task
+	if err := readJSON(taskPath(teamName, taskID), &task); err != nil {
+		return false, err
+	}
+	if !task.Status.IsTerminal() || !task.UpdatedAt.Before(before) {
+		return false, nil
+	}
+	if err := ensureDir(archivedTasksDir(teamName)); err != nil {
+		return false, err
+	}
+	if err := os.Rename(taskPath(teamName, taskID), archivedTaskPath(teamName, taskID)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // IsTaskBlocked checks if a task's dependencies are all completed.
 func IsTaskBlocked(teamName string, task *Task) (bool, error) {
 	if len(task.BlockedBy) == 0 {
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+)
+
+// TaskTransition records a single status change in a task's history.
+type TaskTransition struct {
+	From TaskStatus `json:"from,omitempty"` // empty for the initial status at creation
+	To   TaskStatus `json:"to"`
+	At   time.Time  `json:"at"`
+}
+
+// taskTransitions lists the statuses each status may move to. Completed and
+// cancelled are final; failed tasks may be re-queued or assigned for a retry.
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	TaskPending:   {TaskAssigned, TaskCancelled},
+	TaskAssigned:  {TaskPending, TaskRunning, TaskCompleted, TaskFailed, TaskCancelled},
+	TaskRunning:   {TaskCompleted, TaskFailed, TaskCancelled},
+	TaskFailed:    {TaskPending, TaskAssigned, TaskCancelled},
+	TaskCompleted: nil,
+	TaskCancelled: nil,
+}
+
+// InvalidTransitionError is returned when a task status change is not
+// allowed by the task state machine.
+type InvalidTransitionError struct {
+	TaskID int
+	From   TaskStatus
+	To     TaskStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if !IsValidTaskStatus(e.To) {
+		return fmt.Sprintf("task %d: unknown status %q", e.TaskID, e.To)
+	}
+	return fmt.Sprintf("task %d: invalid status transition %s → %s", e.TaskID, e.From, e.To)
+}
+
+// IsValidTaskStatus reports whether s is one of the known task statuses.
+func IsValidTaskStatus(s TaskStatus) bool {
+	_, ok := taskTransitions[s]
+	return ok
+}
+
+// CanTransition reports whether a task may move from one status to another.
+func CanTransition(from, to TaskStatus) bool {
+	for _, s := range taskTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether a status ends a task's run.
+func (s TaskStatus) IsTerminal() bool {
+	return s == TaskCompleted || s == TaskFailed || s == TaskCancelled
+}
+
+// applyTransition validates that t may move from status from to its current
+// t.Status, appends the change to t.History, and maintains the run
+// timestamps. UpdateTask calls it for every status change, so no mutation
+// path can bypass the state machine.
+func applyTransition(t *Task, from TaskStatus, now time.Time) error {
+	to := t.Status
+	if !CanTransition(from, to) {
+		return &InvalidTransitionError{TaskID: t.ID, From: from, To: to}
+	}
+
+	switch {
+	case to == TaskRunning:
+		if t.StartedAt == nil {
+			t.StartedAt = &now
+		}
+	case to.IsTerminal():
+		if t.CompletedAt == nil {
+			t.CompletedAt = &now
+		}
+	case from.IsTerminal():
+		// Re-queued after failure: the previous run's timing no longer applies
+		t.StartedAt = nil
+		t.CompletedAt = nil
+	}
+
+	t.History = append(t.History, TaskTransition{From: from, To: to, At: now})
+	return nil
+}
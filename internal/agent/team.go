@@ -2,16 +2,25 @@ package agent
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"time"
+
+	"codes/internal/config"
+	"codes/internal/notify"
+	"codes/internal/trace"
+	"codes/internal/update"
 )
 
 // CreateTeam creates a new team workspace with the given configuration.
 func CreateTeam(name, description, workDir string) (*TeamConfig, error) {
 	dir := teamDir(name)
 	if _, err := os.Stat(dir); err == nil {
-		return nil, fmt.Errorf("team %q already exists", name)
+		return nil, newError(ErrTeamExists, map[string]any{"team": name}, "team %q already exists", name)
+	}
+	if err := config.CheckWorkDir(workDir); err != nil {
+		return nil, err
 	}
 
 	// Create directory structure
@@ -39,6 +48,14 @@ func CreateTeam(name, description, workDir string) (*TeamConfig, error) {
 		return nil, fmt.Errorf("write config: %w", err)
 	}
 
+	// The team exists either way; a failing hook is only worth a log line
+	if err := notify.RunEventHook(notify.HookEvent{
+		Event: "on_team_created",
+		Team:  &notify.HookTeam{Name: name, Description: description, WorkDir: workDir},
+	}); err != nil {
+		log.Printf("hook execution error (on_team_created): %v", err)
+	}
+
 	return cfg, nil
 }
 
@@ -47,7 +64,7 @@ func GetTeam(name string) (*TeamConfig, error) {
 	var cfg TeamConfig
 	if err := readJSON(teamConfigPath(name), &cfg); err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("team %q not found", name)
+			return nil, newError(ErrTeamNotFound, map[string]any{"team": name}, "team %q not found", name)
 		}
 		return nil, err
 	}
@@ -81,7 +98,7 @@ func ListTeams() ([]string, error) {
 func DeleteTeam(name string) error {
 	dir := teamDir(name)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return fmt.Errorf("team %q not found", name)
+		return newError(ErrTeamNotFound, map[string]any{"team": name}, "team %q not found", name)
 	}
 	return os.RemoveAll(dir)
 }
@@ -93,9 +110,12 @@ func AddMember(teamName string, member TeamMember) error {
 		return err
 	}
 
+	if member.Name == HumanOwner {
+		return fmt.Errorf("%q is reserved for human tasks", HumanOwner)
+	}
 	for _, m := range cfg.Members {
 		if m.Name == member.Name {
-			return fmt.Errorf("member %q already exists in team %q", member.Name, teamName)
+			return newError(ErrAgentExists, map[string]any{"team": teamName, "agent": member.Name}, "member %q already exists in team %q", member.Name, teamName)
 		}
 	}
 
@@ -103,6 +123,29 @@ func AddMember(teamName string, member TeamMember) error {
 	return writeJSON(teamConfigPath(teamName), cfg)
 }
 
+// SetPollSettings updates poll settings for a member, or the team-wide
+// default when memberName is empty. A nil poll clears the override.
+// Running daemons pick up the change on their next start.
+func SetPollSettings(teamName, memberName string, poll *PollSettings) error {
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return err
+	}
+
+	if memberName == "" {
+		cfg.Poll = poll
+		return writeJSON(teamConfigPath(teamName), cfg)
+	}
+
+	for i := range cfg.Members {
+		if cfg.Members[i].Name == memberName {
+			cfg.Members[i].Poll = poll
+			return writeJSON(teamConfigPath(teamName), cfg)
+		}
+	}
+	return newError(ErrAgentNotFound, map[string]any{"team": teamName, "agent": memberName}, "member %q not found in team %q", memberName, teamName)
+}
+
 // RemoveMember removes an agent from the team.
 func RemoveMember(teamName, memberName string) error {
 	cfg, err := GetTeam(teamName)
@@ -121,13 +164,16 @@ func RemoveMember(teamName, memberName string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("member %q not found in team %q", memberName, teamName)
+		return newError(ErrAgentNotFound, map[string]any{"team": teamName, "agent": memberName}, "member %q not found in team %q", memberName, teamName)
 	}
 
 	cfg.Members = members
 
-	// Remove agent state file if exists
+	// Remove agent state and logs if they exist
 	os.Remove(agentStatePath(teamName, memberName))
+	os.Remove(agentHeartbeatPath(teamName, memberName))
+	os.Remove(AgentLogPath(teamName, memberName))
+	os.Remove(AgentLogPath(teamName, memberName) + ".1")
 
 	return writeJSON(teamConfigPath(teamName), cfg)
 }
@@ -150,8 +196,9 @@ func SaveAgentState(state *AgentState) error {
 	return writeJSON(agentStatePath(state.Team, state.Name), state)
 }
 
-// IsAgentAlive checks if an agent's daemon process is still running.
-// If the process is dead but the recorded status is not AgentStopped,
+// IsAgentAlive checks if an agent's daemon is still running, by its
+// heartbeat and, on the same host, its PID (see daemonAlive).
+// If the daemon is gone but the recorded status is not AgentStopped,
 // the state is updated to AgentStopped automatically.
 func IsAgentAlive(teamName, agentName string) bool {
 	state, err := GetAgentState(teamName, agentName)
@@ -159,11 +206,7 @@ func IsAgentAlive(teamName, agentName string) bool {
 		return false
 	}
 
-	if state.PID <= 0 {
-		return false
-	}
-
-	alive := isProcessAlive(state.PID)
+	alive := daemonAlive(state, readHeartbeat(teamName, agentName))
 	if !alive && state.Status != AgentStopped {
 		state.Status = AgentStopped
 		state.CurrentTask = 0
@@ -172,6 +215,36 @@ func IsAgentAlive(teamName, agentName string) bool {
 	return alive
 }
 
+// AgentVersionSkew reports whether a running agent daemon was built from a
+// different codes version than this binary. Returns nil when the agent is not
+// running or the versions match.
+func AgentVersionSkew(teamName, agentName string) *update.SkewError {
+	if !IsAgentAlive(teamName, agentName) {
+		return nil
+	}
+	state, _ := GetAgentState(teamName, agentName)
+	if state == nil {
+		return nil
+	}
+	return update.CheckSkew(fmt.Sprintf("agent %s/%s", teamName, agentName), Version, state.Version)
+}
+
+// checkTeamCompatible refuses to add a daemon to a team whose running
+// daemons were built from an incompatible major version, since they share
+// the team's task and message files.
+func checkTeamCompatible(teamName string) error {
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return err
+	}
+	for _, m := range cfg.Members {
+		if skew := AgentVersionSkew(teamName, m.Name); skew != nil && skew.Skew == update.SkewMajor {
+			return skew
+		}
+	}
+	return nil
+}
+
 // AgentStartResult holds the result of starting a single agent.
 type AgentStartResult struct {
 	Name    string `json:"name"`
@@ -180,12 +253,17 @@ type AgentStartResult struct {
 	Error   string `json:"error,omitempty"`
 }
 
-// StartAgent spawns an agent daemon as an independent subprocess.
-// Returns the PID of the spawned process.
-func StartAgent(teamName, agentName string) (int, error) {
-	// Verify the agent exists
-	if _, err := NewDaemon(teamName, agentName); err != nil {
-		return 0, err
+// CheckAgentStartable returns why agentName cannot be started: it does not
+// exist, its work dir is not allowed by policy, it is already running, or the
+// team runs agents of an incompatible version. Used by StartAgent and by `codes agent run --foreground`.
+func CheckAgentStartable(teamName, agentName string) error {
+	// Verify the agent exists and may run where it would
+	d, err := NewDaemon(teamName, agentName)
+	if err != nil {
+		return err
+	}
+	if err := config.CheckWorkDir(d.WorkDir); err != nil {
+		return err
 	}
 
 	// Check if already running
@@ -195,7 +273,17 @@ func StartAgent(teamName, agentName string) (int, error) {
 		if state != nil {
 			pid = state.PID
 		}
-		return 0, fmt.Errorf("agent %q is already running (pid %d)", agentName, pid)
+		return newError(ErrAgentRunning, map[string]any{"team": teamName, "agent": agentName, "pid": pid}, "agent %q is already running (pid %d)", agentName, pid)
+	}
+
+	return checkTeamCompatible(teamName)
+}
+
+// StartAgent spawns an agent daemon as an independent subprocess.
+// Returns the PID of the spawned process.
+func StartAgent(teamName, agentName string) (int, error) {
+	if err := CheckAgentStartable(teamName, agentName); err != nil {
+		return 0, err
 	}
 
 	exe, err := os.Executable()
@@ -203,10 +291,14 @@ func StartAgent(teamName, agentName string) (int, error) {
 		return 0, fmt.Errorf("cannot find executable: %w", err)
 	}
 
-	cmd := exec.Command(exe, "agent", "run", teamName, agentName)
+	cmd := exec.Command(exe, "agent", "daemon", teamName, agentName)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Start(); err != nil {
+	setDaemonSysProcAttr(cmd)
+	end := trace.Start("exec.start", "cmd", "agent daemon", "agent", agentName)
+	err = cmd.Start()
+	end()
+	if err != nil {
 		return 0, fmt.Errorf("failed to start agent: %w", err)
 	}
 
@@ -215,6 +307,20 @@ func StartAgent(teamName, agentName string) (int, error) {
 	return pid, nil
 }
 
+// StopAgent asks a running agent daemon to shut down after its current step.
+// It does not wait for the daemon to exit.
+func StopAgent(teamName, agentName string) error {
+	// Verify the agent exists
+	if _, err := NewDaemon(teamName, agentName); err != nil {
+		return err
+	}
+	if !IsAgentAlive(teamName, agentName) {
+		return newError(ErrAgentNotRunning, map[string]any{"team": teamName, "agent": agentName}, "agent %q is not running", agentName)
+	}
+	_, err := SendMessage(teamName, "__system__", agentName, "__stop__")
+	return err
+}
+
 // StartAllAgents spawns all agents in a team, skipping those already running.
 func StartAllAgents(teamName string) ([]AgentStartResult, error) {
 	cfg, err := GetTeam(teamName)
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TeamTemplate is a reusable team blueprint: the member roster and team
+// defaults, without tasks, messages or agent state. Templates are stored
+// under ~/.codes/teams/.templates/{name}.json.
+type TeamTemplate struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"` // given to teams created from it
+	WorkDir     string         `json:"workDir,omitempty"`     // default working directory
+	Members     []TeamMember   `json:"members"`
+	Poll        *PollSettings  `json:"poll,omitempty"`
+	Assignment  AssignStrategy `json:"assignment,omitempty"`
+	Idle        *IdlePolicy    `json:"idle,omitempty"`
+	Source      string         `json:"source,omitempty"` // team it was captured from
+	CreatedAt   time.Time      `json:"createdAt"`
+}
+
+// TeamFromTemplate overrides template defaults for a new team. Empty fields
+// keep the template's value.
+type TeamFromTemplate struct {
+	Description string
+	WorkDir     string
+}
+
+// SaveTeamTemplate captures teamName's roster and defaults as the template
+// name. An existing template is only replaced when overwrite is set.
+func SaveTeamTemplate(teamName, name string, overwrite bool) (*TeamTemplate, error) {
+	if err := validateTemplateName(name); err != nil {
+		return nil, err
+	}
+	cfg, err := GetTeam(teamName)
+	if err != nil {
+		return nil, err
+	}
+	if !overwrite {
+		if _, err := os.Stat(templatePath(name)); err == nil {
+			return nil, newError(ErrTemplateExists, map[string]any{"template": name}, "team template %q already exists", name)
+		}
+	}
+
+	tmpl := &TeamTemplate{
+		Name:        name,
+		Description: cfg.Description,
+		WorkDir:     cfg.WorkDir,
+		Members:     cfg.Members,
+		Poll:        cfg.Poll,
+		Assignment:  cfg.Assignment,
+		Idle:        cfg.Idle,
+		Source:      teamName,
+		CreatedAt:   time.Now(),
+	}
+	if err := writeJSON(templatePath(name), tmpl); err != nil {
+		return nil, fmt.Errorf("write template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// GetTeamTemplate loads a saved team template.
+func GetTeamTemplate(name string) (*TeamTemplate, error) {
+	if err := validateTemplateName(name); err != nil {
+		return nil, err
+	}
+	var tmpl TeamTemplate
+	if err := readJSON(templatePath(name), &tmpl); err != nil {
+		if os.IsNotExist(err) {
+			return nil, newError(ErrTemplateNotFound, map[string]any{"template": name}, "team template %q not found", name)
+		}
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// ListTeamTemplates returns all saved team templates, sorted by name.
+func ListTeamTemplates() ([]*TeamTemplate, error) {
+	entries, err := os.ReadDir(templatesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var templates []*TeamTemplate
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok {
+			continue
+		}
+		tmpl, err := GetTeamTemplate(name)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// DeleteTeamTemplate removes a saved team template.
+func DeleteTeamTemplate(name string) error {
+	if _, err := GetTeamTemplate(name); err != nil {
+		return err
+	}
+	return os.Remove(templatePath(name))
+}
+
+// InstantiateTeamTemplate creates teamName from the template name with the
+// template's members and defaults. The new team has no tasks or messages and
+// its agents are not started.
+func InstantiateTeamTemplate(name, teamName string, opts TeamFromTemplate) (*TeamConfig, error) {
+	tmpl, err := GetTeamTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	description := tmpl.Description
+	if opts.Description != "" {
+		description = opts.Description
+	}
+	workDir := tmpl.WorkDir
+	if opts.WorkDir != "" {
+		workDir = opts.WorkDir
+	}
+
+	cfg, err := CreateTeam(teamName, description, workDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Members = append([]TeamMember{}, tmpl.Members...)
+	cfg.Poll = tmpl.Poll
+	cfg.Assignment = tmpl.Assignment
+	cfg.Idle = tmpl.Idle
+	if err := writeJSON(teamConfigPath(teamName), cfg); err != nil {
+		DeleteTeam(teamName)
+		return nil, fmt.Errorf("write config: %w", err)
+	}
+	return cfg, nil
+}
+
+// validateTemplateName rejects names that can't be used as a file name.
+func validateTemplateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid template name %q", name)
+	}
+	return nil
+}
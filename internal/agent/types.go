@@ -26,19 +26,48 @@ const (
 
 // TeamConfig holds the configuration for a team of agents.
 type TeamConfig struct {
-	Name        string       `json:"name"`
-	Description string       `json:"description,omitempty"`
-	WorkDir     string       `json:"workDir,omitempty"`
-	Members     []TeamMember `json:"members"`
-	CreatedAt   time.Time    `json:"createdAt"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	WorkDir     string         `json:"workDir,omitempty"`
+	Members     []TeamMember   `json:"members"`
+	Poll        *PollSettings  `json:"poll,omitempty"`       // team-wide default, overridable per member
+	Assignment  AssignStrategy `json:"assignment,omitempty"` // placement of unassigned tasks; empty leaves them to auto-claim
+	Idle        *IdlePolicy    `json:"idle,omitempty"`       // stop the agents of a team left without work, see idle.go
+	CreatedAt   time.Time      `json:"createdAt"`
+}
+
+// IdlePolicy stops every agent of a team once it has had no pending,
+// assigned or running task for StopAfter minutes.
+type IdlePolicy struct {
+	StopAfter int  `json:"stopAfter"`         // minutes without work
+	Archive   bool `json:"archive,omitempty"` // also archive the team's finished tasks
 }
 
 // TeamMember represents a registered agent in a team.
 type TeamMember struct {
-	Name  string `json:"name"`
-	Role  string `json:"role,omitempty"`
-	Model string `json:"model,omitempty"`
-	Type  string `json:"type,omitempty"` // e.g. "worker", "leader"
+	Name   string        `json:"name"`
+	Role   string        `json:"role,omitempty"`
+	Model  string        `json:"model,omitempty"`
+	Type   string        `json:"type,omitempty"`   // e.g. "worker", "leader"
+	Skills []string      `json:"skills,omitempty"` // matched against Task.Skills
+	Poll   *PollSettings `json:"poll,omitempty"`
+}
+
+// AssignStrategy selects how CreateTask picks an owner for a task created
+// without one.
+type AssignStrategy string
+
+const (
+	AssignRoundRobin  AssignStrategy = "round_robin"  // rotate through eligible agents by task ID
+	AssignLeastLoaded AssignStrategy = "least_loaded" // fewest assigned and running tasks, ties by member order
+	AssignRandom      AssignStrategy = "random"
+)
+
+// PollSettings tunes how often an agent daemon checks for work. Zero fields
+// fall back to the team setting, then to the built-in defaults.
+type PollSettings struct {
+	Interval    int `json:"interval,omitempty"`    // seconds between polls while active
+	MaxInterval int `json:"maxInterval,omitempty"` // idle backoff ceiling in seconds; equal to Interval disables backoff
 }
 
 // TaskPriority represents the urgency of a task.
@@ -50,26 +79,58 @@ const (
 	PriorityLow    TaskPriority = "low"
 )
 
+// TaskType distinguishes work for an agent from a question for the human.
+type TaskType string
+
+// TaskTypeHuman tasks are not run by an agent: they ask the human a question
+// and complete with the answer as their result (see human.go). The empty type
+// is an ordinary agent task.
+const TaskTypeHuman TaskType = "human"
+
 // Task represents a unit of work assigned to an agent.
 type Task struct {
-	ID          int          `json:"id"`
-	Subject     string       `json:"subject"`
-	Description string       `json:"description,omitempty"`
-	Status      TaskStatus   `json:"status"`
-	Priority    TaskPriority `json:"priority,omitempty"`
-	Owner       string       `json:"owner,omitempty"`
-	Project     string       `json:"project,omitempty"`  // registered project name for WorkDir resolution
-	WorkDir     string       `json:"workDir,omitempty"`  // explicit working directory (overrides project)
-	BlockedBy   []int        `json:"blockedBy,omitempty"`
-	SessionID   string       `json:"sessionId,omitempty"`
-	Adapter     string       `json:"adapter,omitempty"`   // CLI adapter to use (default: "claude")
-	CallbackURL string       `json:"callbackUrl,omitempty"` // URL to POST result when task completes/fails
-	Result      string       `json:"result,omitempty"`
-	Error       string       `json:"error,omitempty"`
-	CreatedAt   time.Time    `json:"createdAt"`
-	UpdatedAt   time.Time    `json:"updatedAt"`
-	StartedAt   *time.Time   `json:"startedAt,omitempty"`
-	CompletedAt *time.Time   `json:"completedAt,omitempty"`
+	ID            int              `json:"id"`
+	Subject       string           `json:"subject"`
+	Description   string           `json:"description,omitempty"`
+	Status        TaskStatus       `json:"status"`
+	Priority      TaskPriority     `json:"priority,omitempty"`
+	Owner         string           `json:"owner,omitempty"`
+	Project       string           `json:"project,omitempty"` // registered project name for WorkDir resolution
+	WorkDir       string           `json:"workDir,omitempty"` // explicit working directory (overrides project)
+	BlockedBy     []int            `json:"blockedBy,omitempty"`
+	Skills        []string         `json:"skills,omitempty"` // skills the owner must have
+	Labels        []string         `json:"labels,omitempty"` // free-form tags for people, e.g. "bug", "frontend"
+	Type          TaskType         `json:"type,omitempty"`
+	Choices       []string         `json:"choices,omitempty"` // allowed answers to a human task; any answer when empty
+	SessionID     string           `json:"sessionId,omitempty"`
+	FollowUpOf    int              `json:"followUpOf,omitempty"`    // completed task whose session this one resumes
+	Adapter       string           `json:"adapter,omitempty"`       // CLI adapter to use (default: "claude")
+	CallbackURL   string           `json:"callbackUrl,omitempty"`   // URL to POST result when task completes/fails
+	RequestID     string           `json:"requestId,omitempty"`     // X-Request-ID of the HTTP request that created or last redirected it
+	Artifacts     []string         `json:"artifacts,omitempty"`     // output paths or globs (relative to workdir) collected on completion
+	ContextFiles  []string         `json:"contextFiles,omitempty"`  // files (relative to workdir) whose contents are inlined into the prompt at start
+	Plan          *AgentPlan       `json:"plan,omitempty"`          // plan the agent posted when it started
+	ArtifactFiles []string         `json:"artifactFiles,omitempty"` // file names collected into tasks/{id}/artifacts/
+	Result        string           `json:"result,omitempty"`
+	AnsweredBy    string           `json:"answeredBy,omitempty"` // who answered a human task
+	Summary       *TaskSummary     `json:"summary,omitempty"` // structured digest of a long result
+	Error         string           `json:"error,omitempty"`
+	History       []TaskTransition `json:"history,omitempty"` // status changes, oldest first
+	CreatedAt     time.Time        `json:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
+	StartedAt     *time.Time       `json:"startedAt,omitempty"`
+	AskedAt       *time.Time       `json:"askedAt,omitempty"` // when a human task's question was posted
+	CompletedAt   *time.Time       `json:"completedAt,omitempty"`
+	Cost          *CostInfo        `json:"cost,omitempty"`    // token usage and cost of the run, when reported
+	Changes       *TaskChanges     `json:"changes,omitempty"` // git changes made by the run, when the work dir is a repository
+}
+
+// TaskSummary is a short, structured digest of a task result, written by a
+// cheap model when the result is too long to pass around whole.
+type TaskSummary struct {
+	Changes   string   `json:"changes"`             // what was done, in a sentence or two
+	Files     []string `json:"files,omitempty"`     // files created or modified
+	FollowUps []string `json:"followUps,omitempty"` // open issues or suggested next steps
 }
 
 // MessageType distinguishes different kinds of messages.
@@ -83,6 +144,8 @@ const (
 	MsgProgress      MessageType = "progress"        // intermediate progress update
 	MsgHelpRequest   MessageType = "help_request"    // request for help
 	MsgDiscovery     MessageType = "discovery"       // share a finding/discovery
+	MsgPlan          MessageType = "plan"            // agent's plan for the task it just started
+	MsgQuestion      MessageType = "question"        // a human task waiting for an answer
 )
 
 // Message represents a message between agents.
@@ -99,19 +162,23 @@ type Message struct {
 
 // AgentState represents the on-disk state of a running agent daemon.
 type AgentState struct {
-	Name               string      `json:"name"`
-	Team               string      `json:"team"`
-	PID                int         `json:"pid"`
-	Status             AgentStatus `json:"status"`
-	CurrentTask        int         `json:"currentTask,omitempty"`
-	CurrentTaskSubject string      `json:"currentTaskSubject,omitempty"` // cached subject of current task
-	Activity           string      `json:"activity,omitempty"`          // human-readable activity description
-	SessionID          string      `json:"sessionId,omitempty"`         // persistent Claude session for message handling
-	StartedAt          time.Time   `json:"startedAt"`
-	UpdatedAt          time.Time   `json:"updatedAt"`
-	RestartCount       int         `json:"restartCount,omitempty"` // number of times daemon has been restarted
-	LastCrash          *time.Time  `json:"lastCrash,omitempty"`    // timestamp of last crash/unexpected exit
-	Supervised         bool        `json:"supervised,omitempty"`   // whether running under supervisor
+	Name               string         `json:"name"`
+	Team               string         `json:"team"`
+	PID                int            `json:"pid"`
+	Host               string         `json:"host,omitempty"` // machine the daemon runs on; PIDs are only checked there
+	Status             AgentStatus    `json:"status"`
+	CurrentTask        int            `json:"currentTask,omitempty"`
+	CurrentTaskSubject string         `json:"currentTaskSubject,omitempty"` // cached subject of current task
+	Activity           string         `json:"activity,omitempty"`           // human-readable activity description
+	SessionID          string         `json:"sessionId,omitempty"`          // persistent Claude session for message handling
+	StartedAt          time.Time      `json:"startedAt"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+	RestartCount       int            `json:"restartCount,omitempty"` // number of times daemon has been restarted
+	LastCrash          *time.Time     `json:"lastCrash,omitempty"`    // timestamp of last crash/unexpected exit
+	Supervised         bool           `json:"supervised,omitempty"`   // whether running under supervisor
+	Counters           *AgentCounters `json:"counters,omitempty"`     // cumulative failure counters exported as metrics
+	Version            string         `json:"version,omitempty"`      // codes version the daemon was built from
+	PausedReason       string         `json:"pausedReason,omitempty"` // why the power policy holds new tasks back
 }
 
 
@@ -121,6 +188,7 @@ type ClaudeResult struct {
 	Error     string `json:"error,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
 	CostUSD   float64 `json:"cost_usd,omitempty"`
+	Cost      *CostInfo `json:"cost,omitempty"` // token usage, when the CLI reports it
 	Duration  float64 `json:"duration_secs,omitempty"`
 	IsError   bool   `json:"is_error,omitempty"`
 }
@@ -137,4 +205,6 @@ type RunOptions struct {
 	MaxTurns     int
 	PermMode     string // e.g. "dangerously-skip-permissions"
 	Env          map[string]string
+	Nice         int // CPU niceness for the subprocess (0 = unchanged)
+	IONiceClass  int // I/O scheduling class for the subprocess (0 = unchanged)
 }
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"sort"
+	"time"
+)
+
+// UsageTotals sums token usage and cost over a set of finished tasks.
+type UsageTotals struct {
+	Tasks            int     `json:"tasks"`
+	Untracked        int     `json:"untracked,omitempty"` // finished tasks with no usage reported (e.g. killed or non-claude adapters)
+	InputTokens      int     `json:"inputTokens"`
+	OutputTokens     int     `json:"outputTokens"`
+	CacheReadTokens  int     `json:"cacheReadTokens"`
+	CacheWriteTokens int     `json:"cacheWriteTokens"`
+	CostUSD          float64 `json:"costUSD"`
+}
+
+func (u *UsageTotals) add(t *Task) {
+	u.Tasks++
+	if t.Cost == nil {
+		u.Untracked++
+		return
+	}
+	u.InputTokens += t.Cost.InputTokens
+	u.OutputTokens += t.Cost.OutputTokens
+	u.CacheReadTokens += t.Cost.CacheReadTokens
+	u.CacheWriteTokens += t.Cost.CacheWriteTokens
+	u.CostUSD += t.Cost.TotalCostUSD
+}
+
+// AgentUsage is one agent's share of a team's usage.
+type AgentUsage struct {
+	Agent string `json:"agent"`
+	UsageTotals
+}
+
+// TeamUsage is a team's usage with a per-agent breakdown, most expensive first.
+type TeamUsage struct {
+	Team string `json:"team"`
+	UsageTotals
+	Agents []AgentUsage `json:"agents"`
+}
+
+// UsageReport aggregates usage across teams for a time window.
+type UsageReport struct {
+	From  *time.Time  `json:"from,omitempty"`
+	To    *time.Time  `json:"to,omitempty"`
+	Total UsageTotals `json:"total"`
+	Teams []TeamUsage `json:"teams"`
+}
+
+// GetUsageReport sums the recorded usage of tasks that finished between from
+// and to. A zero from or to leaves that end of the window open. If teamName
+// is empty every team is included. Only agent task runs are counted; replies
+// to chat messages are not recorded per task.
+func GetUsageReport(teamName string, from, to time.Time) (*UsageReport, error) {
+	teams := []string{teamName}
+	if teamName == "" {
+		var err error
+		if teams, err = ListTeams(); err != nil {
+			return nil, err
+		}
+	} else if _, err := GetTeam(teamName); err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{Teams: []TeamUsage{}}
+	if !from.IsZero() {
+		report.From = &from
+	}
+	if !to.IsZero() {
+		report.To = &to
+	}
+
+	for _, name := range teams {
+		tasks, err := ListTasks(name, "", "")
+		if err != nil {
+			return nil, err
+		}
+		team := TeamUsage{Team: name, Agents: []AgentUsage{}}
+		byAgent := make(map[string]*AgentUsage)
+		for _, t := range tasks {
+			if t.CompletedAt == nil || t.StartedAt == nil {
+				continue // never ran
+			}
+			if (!from.IsZero() && t.CompletedAt.Before(from)) || (!to.IsZero() && t.CompletedAt.After(to)) {
+				continue
+			}
+			team.add(t)
+			report.Total.add(t)
+			a, ok := byAgent[t.Owner]
+			if !ok {
+				a = &AgentUsage{Agent: t.Owner}
+				byAgent[t.Owner] = a
+			}
+			a.add(t)
+		}
+		if team.Tasks == 0 {
+			continue
+		}
+		for _, a := range byAgent {
+			team.Agents = append(team.Agents, *a)
+		}
+		sort.Slice(team.Agents, func(i, j int) bool {
+			if team.Agents[i].CostUSD != team.Agents[j].CostUSD {
+				return team.Agents[i].CostUSD > team.Agents[j].CostUSD
+			}
+			return team.Agents[i].Agent < team.Agents[j].Agent
+		})
+		report.Teams = append(report.Teams, team)
+	}
+
+	sort.Slice(report.Teams, func(i, j int) bool {
+		if report.Teams[i].CostUSD != report.Teams[j].CostUSD {
+			return report.Teams[i].CostUSD > report.Teams[j].CostUSD
+		}
+		return report.Teams[i].Team < report.Teams[j].Team
+	})
+	return report, nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchTeams reports the name of a team whenever its config, tasks, messages
+// or agent state change on disk. Teams created after the call are picked up
+// automatically. Sends never block: if the consumer falls behind, events are
+// dropped, so consumers should rescan rather than rely on every name.
+// The channel is closed when ctx is done.
+func WatchTeams(ctx context.Context) (<-chan string, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	base := teamsBaseDirFunc()
+	if err := ensureDir(base); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	if err := watcher.Add(base); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	// Subdirectories may not exist yet for a team that is being created;
+	// their Create events in the team directory add them later.
+	addTeam := func(team string) {
+		watcher.Add(teamDir(team))
+		for _, dir := range []string{tasksDir(team), messagesDir(team), agentsDir(team)} {
+			watcher.Add(dir)
+		}
+	}
+	teams, _ := ListTeams()
+	for _, team := range teams {
+		addTeam(team)
+	}
+
+	out := make(chan string, 64)
+	go func() {
+		defer close(out)
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				rel, err := filepath.Rel(base, ev.Name)
+				if err != nil || strings.HasPrefix(rel, "..") {
+					continue
+				}
+				parts := strings.Split(filepath.ToSlash(rel), "/")
+				team := parts[0]
+				if team == "" || strings.HasPrefix(team, ".") {
+					continue
+				}
+				// Daemon log and heartbeat writes are not state changes
+				if strings.HasSuffix(ev.Name, ".log") || strings.HasSuffix(ev.Name, ".log.1") || strings.Contains(ev.Name, ".heartbeat") {
+					continue
+				}
+				if ev.Has(fsnotify.Create) && len(parts) <= 2 {
+					if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+						if len(parts) == 1 {
+							addTeam(team)
+						} else {
+							watcher.Add(ev.Name)
+						}
+					}
+				}
+				select {
+				case out <- team:
+				default:
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
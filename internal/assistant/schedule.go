@@ -0,0 +1,37 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"codes/internal/agent"
+	"codes/internal/assistant/scheduler"
+)
+
+// RunSchedule does what a schedule does when it fires: a schedule with a
+// Team creates that team's task, any other sends its message to the
+// assistant session. It records the run and returns a one-line outcome: the
+// task created or the assistant's reply. Used by the scheduler in
+// codes serve and by "run now" in the TUI.
+func RunSchedule(ctx context.Context, sc *scheduler.Schedule) (string, error) {
+	if err := scheduler.MarkRun(sc.ID, time.Now()); err != nil {
+		return "", err
+	}
+	if sc.Team != "" {
+		subject, description := sc.Subject, sc.Message
+		if subject == "" {
+			subject, description = sc.Message, ""
+		}
+		task, err := agent.CreateTask(sc.Team, subject, description, sc.Owner, nil, "", "", "")
+		if err != nil {
+			return "", fmt.Errorf("create task in team %s: %w", sc.Team, err)
+		}
+		return fmt.Sprintf("created task %d in team %s", task.ID, sc.Team), nil
+	}
+	result, err := Run(ctx, RunOptions{SessionID: sc.SessionID, Message: sc.Message})
+	if err != nil {
+		return "", err
+	}
+	return result.Reply, nil
+}
@@ -2,15 +2,21 @@ package scheduler
 
 import (
 	"log"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
-// TriggerFunc is called when a schedule fires.
-// sessionID identifies the assistant conversation; message is forwarded to it.
-type TriggerFunc func(sessionID, message string)
+// watchInterval is how often the running scheduler checks the schedules
+// file for changes made by other processes (the TUI, a stdio MCP server).
+const watchInterval = 5 * time.Second
+
+// TriggerFunc is called when a schedule fires. It is up to the caller to
+// forward the message to the assistant session or, for schedules with a Team,
+// to create the agent task.
+type TriggerFunc func(sc *Schedule)
 
 // Scheduler manages both one-shot and periodic scheduled tasks.
 type Scheduler struct {
@@ -19,6 +25,7 @@ type Scheduler struct {
 	mu     sync.Mutex
 	cron   *cron.Cron    // drives TypePeriodic schedules
 	timers []*time.Timer // drives TypeOnce schedules
+	loaded time.Time     // modification time of the schedules file when last loaded
 	done   chan struct{}
 }
 
@@ -41,10 +48,45 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 	s.cron.Start()
+	go s.watch()
 	log.Printf("[scheduler] started")
 	return nil
 }
 
+// watch reloads the schedules whenever the file changes on disk, until Stop.
+func (s *Scheduler) watch() {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.cron != nil && schedulesModTime() != s.loaded {
+				if err := s.reloadLocked(); err != nil {
+					log.Printf("[scheduler] reload error: %v", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// schedulesModTime returns the schedules file's modification time, or the
+// zero time if it does not exist.
+func schedulesModTime() time.Time {
+	path, err := schedulesPath()
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
 // Stop cancels all pending timers and shuts down the cron runner.
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
@@ -78,7 +120,11 @@ func (s *Scheduler) Stop() {
 func (s *Scheduler) Reload() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.reloadLocked()
+}
 
+// reloadLocked does the work of Reload. Must be called with s.mu held.
+func (s *Scheduler) reloadLocked() error {
 	// Cancel existing one-shot timers.
 	for _, t := range s.timers {
 		t.Stop()
@@ -102,6 +148,7 @@ func (s *Scheduler) Reload() error {
 
 // loadLocked registers all enabled schedules. Must be called with s.mu held.
 func (s *Scheduler) loadLocked() error {
+	s.loaded = schedulesModTime()
 	schedules, err := LoadSchedules()
 	if err != nil {
 		return err
@@ -139,14 +186,10 @@ func (s *Scheduler) registerOnce(sc *Schedule, now time.Time) {
 		return
 	}
 
-	// Capture loop variables for the closure.
 	id := sc.ID
-	sessionID := sc.SessionID
-	message := sc.Message
-
 	t := time.AfterFunc(delay, func() {
 		log.Printf("[scheduler] once schedule id=%s fired", id)
-		s.trigger(sessionID, message)
+		s.trigger(sc)
 		// Remove the schedule after firing — it's a one-shot.
 		if err := RemoveSchedule(id); err != nil {
 			log.Printf("[scheduler] failed to remove once schedule id=%s: %v", id, err)
@@ -158,7 +201,7 @@ func (s *Scheduler) registerOnce(sc *Schedule, now time.Time) {
 
 // fireOnce triggers a past-due one-shot schedule and removes it from disk.
 func (s *Scheduler) fireOnce(sc *Schedule) {
-	s.trigger(sc.SessionID, sc.Message)
+	s.trigger(sc)
 	if err := RemoveSchedule(sc.ID); err != nil {
 		log.Printf("[scheduler] failed to remove once schedule id=%s: %v", sc.ID, err)
 	}
@@ -173,12 +216,9 @@ func (s *Scheduler) registerPeriodic(sc *Schedule) {
 	}
 
 	id := sc.ID
-	sessionID := sc.SessionID
-	message := sc.Message
-
 	_, err := s.cron.AddFunc(sc.Cron, func() {
 		log.Printf("[scheduler] periodic schedule id=%s fired", id)
-		s.trigger(sessionID, message)
+		s.trigger(sc)
 	})
 	if err != nil {
 		log.Printf("[scheduler] failed to register cron for schedule id=%s expr=%q: %v", id, sc.Cron, err)
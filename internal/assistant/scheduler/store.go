@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/robfig/cron/v3"
 )
 
 // ScheduleType distinguishes one-shot vs recurring schedules.
@@ -25,6 +27,13 @@ type Schedule struct {
 	Message   string       `json:"message"`    // sent to assistant when triggered
 	SessionID string       `json:"session_id"` // which assistant session receives the trigger
 
+	// Agent work: when Team is set, firing creates a task in that team
+	// (Subject, or else Message, as its subject; Message as its description)
+	// instead of messaging the assistant.
+	Team    string `json:"team,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Owner   string `json:"owner,omitempty"` // agent to assign the task to
+
 	// TypeOnce: trigger at this absolute time.
 	At *time.Time `json:"at,omitempty"`
 
@@ -37,6 +46,27 @@ type Schedule struct {
 	Enabled   bool       `json:"enabled"`
 }
 
+// Validate checks that the schedule can be registered: a message, and an
+// 'at' time or a valid cron expression matching its type.
+func (s *Schedule) Validate() error {
+	if s.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	switch s.Type {
+	case TypeOnce:
+		if s.At == nil {
+			return fmt.Errorf("once schedule needs an 'at' time")
+		}
+	case TypePeriodic:
+		if _, err := cron.ParseStandard(s.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression %q: %w", s.Cron, err)
+		}
+	default:
+		return fmt.Errorf("unknown schedule type %q", s.Type)
+	}
+	return nil
+}
+
 // schedulesPath returns the path to the schedules file (~/.codes/assistant/schedules.json).
 func schedulesPath() (string, error) {
 	home, err := os.UserHomeDir()
@@ -125,6 +155,60 @@ func RemoveSchedule(id string) error {
 	return SaveSchedules(filtered)
 }
 
+// updateSchedule applies fn to the schedule with the given ID on disk and
+// returns the result.
+func updateSchedule(id string, fn func(s *Schedule)) (*Schedule, error) {
+	schedules, err := LoadSchedules()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schedules {
+		if s.ID == id {
+			fn(s)
+			return s, SaveSchedules(schedules)
+		}
+	}
+	return nil, fmt.Errorf("schedule %q not found", id)
+}
+
+// SetEnabled enables or disables the schedule with the given ID. Disabled
+// schedules are kept but never fire.
+func SetEnabled(id string, enabled bool) (*Schedule, error) {
+	return updateSchedule(id, func(s *Schedule) { s.Enabled = enabled })
+}
+
+// MarkRun records that the schedule with the given ID fired at t.
+func MarkRun(id string, t time.Time) error {
+	_, err := updateSchedule(id, func(s *Schedule) { s.LastRunAt = &t })
+	return err
+}
+
+// Next returns when the schedule fires next after now, if it is enabled and
+// will fire again. A past-due one-shot schedule fires as soon as the
+// scheduler loads it, so its next run is now.
+func (s *Schedule) Next(now time.Time) (time.Time, bool) {
+	if !s.Enabled {
+		return time.Time{}, false
+	}
+	switch s.Type {
+	case TypeOnce:
+		if s.At == nil {
+			return time.Time{}, false
+		}
+		if s.At.Before(now) {
+			return now, true
+		}
+		return *s.At, true
+	case TypePeriodic:
+		sched, err := cron.ParseStandard(s.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return sched.Next(now), true
+	}
+	return time.Time{}, false
+}
+
 // ListSchedules is an alias for LoadSchedules provided for callers that
 // want explicit list semantics.
 func ListSchedules() ([]*Schedule, error) {
@@ -53,7 +53,7 @@ func buildTools() ([]anthropic.BetaTool, error) {
 		"list_projects",
 		"List all registered projects with their paths.",
 		func(ctx context.Context, _ listProjectsInput) (anthropic.BetaToolResultBlockParamContentUnion, error) {
-			projects, err := config.ListProjects()
+			projects, err := config.ListActiveProjects()
 			if err != nil {
 				return toolText("error: " + err.Error()), nil
 			}
@@ -0,0 +1,167 @@
+// Package audit keeps an append-only record of the operations that change
+// state through `codes serve` and the MCP server: who did what and when, a
+// digest of the parameters and the outcome. Entries are JSON lines in
+// ~/.codes/audit.jsonl, read back by GET /audit and `codes audit`.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Sources of entries.
+const (
+	SourceHTTP = "http"
+	SourceMCP  = "mcp"
+)
+
+// Results of an operation.
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+// Entry is one audited operation.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source"`              // SourceHTTP or SourceMCP
+	Actor     string    `json:"actor"`               // token name or hash (HTTP), client name (MCP), or "anonymous"
+	Action    string    `json:"action"`              // "POST /teams/x/tasks" or the MCP tool name
+	Params    string    `json:"params,omitempty"`    // digest of the request body or tool arguments
+	Result    string    `json:"result"`              // ResultOK or ResultError
+	Status    int       `json:"status,omitempty"`    // HTTP status
+	Error     string    `json:"error,omitempty"`     // MCP tool error
+	RequestID string    `json:"requestId,omitempty"` // X-Request-ID of the HTTP request
+}
+
+// Digest returns a short SHA-256 digest of data, or "" for no data. Entries
+// keep only the digest, so secrets in parameters never reach the log but two
+// identical requests can still be matched.
+func Digest(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// pathFunc returns the audit file path; tests override it.
+var pathFunc = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codes", "audit.jsonl"), nil
+}
+
+var mu sync.Mutex
+
+// Record appends e to the audit file, setting its time if unset. Each entry
+// is written with a single append, so entries from several processes (the
+// server and stdio MCP servers) do not interleave.
+func Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Actor == "" {
+		e.Actor = "anonymous"
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path, err := pathFunc()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ParseSince accepts a duration before now ("24h") or an RFC 3339 time, as
+// taken by GET /audit?since= and `codes audit --since`.
+func ParseSince(v string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be a duration (24h) or an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// Filter selects entries for Query. Zero fields match everything.
+type Filter struct {
+	Since  time.Time
+	Source string
+	Actor  string
+	Limit  int // newest entries to return; <= 0 returns all
+}
+
+func (f Filter) match(e Entry) bool {
+	return (f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Source == "" || e.Source == f.Source) &&
+		(f.Actor == "" || e.Actor == f.Actor)
+}
+
+// Query returns the entries matching f, newest first. A missing file has no
+// entries; lines that do not parse are skipped.
+func Query(f Filter) ([]Entry, error) {
+	path, err := pathFunc()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || !f.match(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if f.Limit > 0 && len(entries) > 2*f.Limit {
+			entries = entries[len(entries)-f.Limit:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[len(entries)-f.Limit:]
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
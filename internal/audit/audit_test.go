@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func useTempFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	orig := pathFunc
+	pathFunc = func() (string, error) { return path, nil }
+	t.Cleanup(func() { pathFunc = orig })
+	return path
+}
+
+func TestRecordAndQuery(t *testing.T) {
+	path := useTempFile(t)
+
+	if entries, err := Query(Filter{}); err != nil || len(entries) != 0 {
+		t.Fatalf("empty log: %v, %v", entries, err)
+	}
+
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []Entry{
+		{Source: SourceHTTP, Actor: "ci", Action: "POST /teams/a/tasks", Result: ResultOK, Status: 201},
+		{Source: SourceMCP, Actor: "claude-code", Action: "task_cancel", Result: ResultError, Error: "task not found"},
+		{Source: SourceHTTP, Action: "POST /feishu/webhook", Result: ResultOK, Status: 200},
+	} {
+		e.Time = base.Add(time.Duration(i) * time.Hour)
+		if err := Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("audit file mode %v, want 0600", info.Mode().Perm())
+	}
+
+	all, _ := Query(Filter{})
+	if len(all) != 3 || all[0].Action != "POST /feishu/webhook" {
+		t.Fatalf("Query() = %+v, want 3 entries newest first", all)
+	}
+	if all[0].Actor != "anonymous" {
+		t.Errorf("entry without actor recorded as %q", all[0].Actor)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"source", Filter{Source: SourceHTTP}, 2},
+		{"actor", Filter{Actor: "ci"}, 1},
+		{"since", Filter{Since: base.Add(90 * time.Minute)}, 1},
+		{"limit", Filter{Limit: 2}, 2},
+	} {
+		got, err := Query(tc.filter)
+		if err != nil || len(got) != tc.want {
+			t.Errorf("%s: got %d entries (%v), want %d", tc.name, len(got), err, tc.want)
+		}
+	}
+
+	// A damaged line does not hide the rest
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("{not json\n")
+	f.Close()
+	Record(Entry{Source: SourceMCP, Action: "team_delete", Result: ResultOK})
+	if got, _ := Query(Filter{}); len(got) != 4 {
+		t.Errorf("after damaged line: %d entries, want 4", len(got))
+	}
+}
+
+func TestDigest(t *testing.T) {
+	if Digest(nil) != "" {
+		t.Error("digest of no data should be empty")
+	}
+	d := Digest([]byte(`{"token":"secret"}`))
+	if !strings.HasPrefix(d, "sha256:") || len(d) != len("sha256:")+16 || strings.Contains(d, "secret") {
+		t.Errorf("Digest = %q", d)
+	}
+	if d != Digest([]byte(`{"token":"secret"}`)) {
+		t.Error("digest is not stable")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	if got, _ := ParseSince("2h", now); !got.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("2h = %v", got)
+	}
+	if got, _ := ParseSince("2026-10-01T00:00:00Z", now); got.Day() != 1 {
+		t.Errorf("RFC 3339 = %v", got)
+	}
+	if _, err := ParseSince("last week", now); err == nil {
+		t.Error("expected an error")
+	}
+}
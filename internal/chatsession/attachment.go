@@ -0,0 +1,141 @@
+package chatsession
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attachments: files sent with a user message, such as a screenshot of a
+// bug, are written to the session's directory under the temp dir so Claude
+// can open them with its tools, and images are also put in the message
+// itself as image blocks. The transcript keeps their names and paths, not
+// their contents. The directory is removed when the session is closed.
+
+const (
+	maxAttachments     = 10
+	maxAttachmentBytes = 10 << 20 // decoded size of one attachment
+	// MaxAttachmentsBytes caps the decoded size of a message's attachments
+	// together.
+	MaxAttachmentsBytes = 20 << 20
+)
+
+// MaxMessageBodyBytes is the largest request body a message needs with its
+// attachments at the limit: base64 makes them a third larger, plus room for
+// the text. The HTTP API accepts this much on the message route, whatever
+// its general body limit.
+const MaxMessageBodyBytes = MaxAttachmentsBytes/3*4 + 1<<20
+
+// ErrInvalidAttachment is returned for attachments that cannot be accepted:
+// bad base64, too large or too many.
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// Attachment is a file sent along with a user message.
+type Attachment struct {
+	Name      string `json:"name"`                 // file name, e.g. "screenshot.png"
+	MediaType string `json:"media_type,omitempty"` // e.g. "image/png"; guessed from the name or content when empty
+	Data      string `json:"data"`                 // base64-encoded content
+}
+
+// savedAttachment is how an attachment is recorded in the transcript.
+type savedAttachment struct {
+	Name      string `json:"name"`
+	MediaType string `json:"media_type"`
+	Path      string `json:"path"`
+	Size      int    `json:"size"`
+}
+
+// imageTypes are the image formats Claude accepts as image blocks.
+var imageTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true}
+
+// AttachmentDir returns the directory a session's attachments are written to.
+func AttachmentDir(id string) string {
+	return filepath.Join(os.TempDir(), "codes-sessions", id)
+}
+
+// saveAttachments decodes and writes attachments to the session's directory.
+// It returns text with a list of the files appended, the image blocks to
+// send Claude after it and what to record in the transcript. Caller must
+// hold s.mu.
+func (s *ChatSession) saveAttachments(text string, attachments []Attachment) (string, []contentBlock, []savedAttachment, error) {
+	if len(attachments) > maxAttachments {
+		return "", nil, nil, fmt.Errorf("%w: at most %d attachments per message", ErrInvalidAttachment, maxAttachments)
+	}
+	type decoded struct {
+		Attachment
+		data []byte
+	}
+	var files []decoded
+	total := 0
+	for i, a := range attachments {
+		name := filepath.Base(strings.TrimSpace(a.Name))
+		if name == "." || name == string(filepath.Separator) || name == "" {
+			name = fmt.Sprintf("attachment-%d", i+1)
+		}
+		data, err := base64.StdEncoding.DecodeString(a.Data)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("%w: %s is not valid base64", ErrInvalidAttachment, name)
+		}
+		if len(data) == 0 || len(data) > maxAttachmentBytes {
+			return "", nil, nil, fmt.Errorf("%w: %s must be between 1 byte and %d MiB", ErrInvalidAttachment, name, maxAttachmentBytes>>20)
+		}
+		if total += len(data); total > MaxAttachmentsBytes {
+			return "", nil, nil, fmt.Errorf("%w: at most %d MiB of attachments per message", ErrInvalidAttachment, MaxAttachmentsBytes>>20)
+		}
+		mediaType := a.MediaType
+		if mediaType == "" {
+			mediaType = mime.TypeByExtension(filepath.Ext(name))
+		}
+		if mediaType == "" {
+			mediaType = http.DetectContentType(data)
+		}
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		a.Name, a.MediaType = name, strings.TrimSpace(mediaType)
+		files = append(files, decoded{a, data})
+	}
+
+	dir := AttachmentDir(s.ID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", nil, nil, err
+	}
+	if s.attachmentCount == 0 {
+		// A restored session carries on after the files it already has
+		entries, _ := os.ReadDir(dir)
+		s.attachmentCount = len(entries)
+	}
+	var blocks []contentBlock
+	var saved []savedAttachment
+	var lines []string
+	for _, f := range files {
+		// Numbered so files of the same name from different messages are kept
+		path := filepath.Join(dir, fmt.Sprintf("%d-%s", s.attachmentCount+len(saved)+1, f.Name))
+		if err := os.WriteFile(path, f.data, 0600); err != nil {
+			return "", nil, nil, err
+		}
+		saved = append(saved, savedAttachment{Name: f.Name, MediaType: f.MediaType, Path: path, Size: len(f.data)})
+		lines = append(lines, fmt.Sprintf("- %s (%s, %d bytes)", path, f.MediaType, len(f.data)))
+		if imageTypes[f.MediaType] {
+			blocks = append(blocks, contentBlock{
+				Type:   "image",
+				Source: &imageSource{Type: "base64", MediaType: f.MediaType, Data: base64.StdEncoding.EncodeToString(f.data)},
+			})
+		}
+	}
+	s.attachmentCount += len(saved)
+
+	note := "Attached files:\n" + strings.Join(lines, "\n")
+	if text != "" {
+		note = text + "\n\n" + note
+	}
+	return note, blocks, saved, nil
+}
+
+// removeAttachments deletes the session's attachment directory.
+func (s *ChatSession) removeAttachments() {
+	os.RemoveAll(AttachmentDir(s.ID))
+}
@@ -6,12 +6,19 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+
+	"codes/internal/config"
+	"codes/internal/trace"
 )
 
 // spawnClaude starts a Claude CLI subprocess in stream-json mode.
 // If resumeSessionID is non-empty, the session is resumed.
 // Returns stdin writer, stdout reader, the command, and any error.
 func spawnClaude(projectPath, model, resumeSessionID string) (io.WriteCloser, io.ReadCloser, *exec.Cmd, error) {
+	if !config.ClaudeAvailable() {
+		return nil, nil, nil, config.ErrClaudeNotFound
+	}
+
 	args := []string{
 		"--output-format", "stream-json",
 		"--input-format", "stream-json",
@@ -53,7 +60,10 @@ func spawnClaude(projectPath, model, resumeSessionID string) (io.WriteCloser, io
 	// Discard stderr to avoid blocking.
 	cmd.Stderr = io.Discard
 
-	if err := cmd.Start(); err != nil {
+	end := trace.Start("exec.start", "cmd", "claude", "dir", projectPath)
+	err = cmd.Start()
+	end()
+	if err != nil {
 		stdin.Close()
 		stdout.Close()
 		return nil, nil, nil, fmt.Errorf("start claude: %w", err)
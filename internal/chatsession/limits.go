@@ -0,0 +1,70 @@
+package chatsession
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Limits: a session created with MaxCostUSD or MaxTurns refuses messages
+// once it has reached them, so a client left running, or anyone who gets
+// hold of an exposed endpoint, cannot keep spending. Cost is only known
+// when a turn finishes, so the turn that crosses MaxCostUSD completes and
+// the next message is refused.
+
+// ErrCodeLimitExceeded is the error code sent for a refused message, over
+// HTTP and WebSocket.
+const ErrCodeLimitExceeded = "limit_exceeded"
+
+// ErrLimitExceeded is wrapped by the *LimitError SendMessage returns for a
+// session past one of its limits.
+var ErrLimitExceeded = errors.New("session limit exceeded")
+
+// LimitError says which limit a session has reached.
+type LimitError struct {
+	Limit string  // "max_cost_usd" or "max_turns"
+	Max   float64 // the limit
+	Used  float64 // cost or turns so far
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == "max_turns" {
+		return fmt.Sprintf("session limit exceeded: %d of max_turns %d used", int(e.Used), int(e.Max))
+	}
+	return fmt.Sprintf("session limit exceeded: $%.4f spent of max_cost_usd $%.4f", e.Used, e.Max)
+}
+
+func (e *LimitError) Unwrap() error { return ErrLimitExceeded }
+
+// SetLimits sets the session's cost and turn limits; zero means no limit.
+func (s *ChatSession) SetLimits(maxCostUSD float64, maxTurns int) {
+	s.mu.Lock()
+	s.MaxCostUSD = maxCostUSD
+	s.MaxTurns = maxTurns
+	s.mu.Unlock()
+	s.saveInfo()
+}
+
+// InheritLimits gives a session resumed from prev (POST /sessions/{id}/resume)
+// prev's limits and what it has used of them, since it continues the same
+// conversation.
+func (s *ChatSession) InheritLimits(prev SessionInfo) {
+	s.mu.Lock()
+	s.MaxCostUSD, s.MaxTurns = prev.MaxCostUSD, prev.MaxTurns
+	s.CostUSD += prev.CostUSD
+	s.costBase += prev.CostUSD
+	s.TurnCount += prev.TurnCount
+	s.mu.Unlock()
+	s.saveInfo()
+}
+
+// checkLimitsLocked returns a *LimitError if another turn would go past the
+// session's limits. Caller must hold s.mu.
+func (s *ChatSession) checkLimitsLocked() error {
+	if s.MaxTurns > 0 && s.TurnCount >= s.MaxTurns {
+		return &LimitError{Limit: "max_turns", Max: float64(s.MaxTurns), Used: float64(s.TurnCount)}
+	}
+	if s.MaxCostUSD > 0 && s.CostUSD >= s.MaxCostUSD {
+		return &LimitError{Limit: "max_cost_usd", Max: s.MaxCostUSD, Used: s.CostUSD}
+	}
+	return nil
+}
@@ -2,10 +2,17 @@ package chatsession
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
+	"codes/internal/config"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -25,6 +32,9 @@ func (m *SessionManager) Create(projectName, projectPath, model string) (*ChatSe
 	if projectPath == "" {
 		return nil, fmt.Errorf("projectPath is required")
 	}
+	if err := config.CheckWorkDir(projectPath); err != nil {
+		return nil, err
+	}
 
 	id := generateID()
 
@@ -36,12 +46,13 @@ func (m *SessionManager) Create(projectName, projectPath, model string) (*ChatSe
 		Status:       StatusCreating,
 		CreatedAt:    time.Now(),
 		LastActiveAt: time.Now(),
-		clients:      make(map[*websocket.Conn]bool),
+		clients:      make(map[*websocket.Conn]*wsClient),
 	}
 
 	m.mu.Lock()
 	m.sessions[id] = session
 	m.mu.Unlock()
+	session.saveInfo()
 
 	return session, nil
 }
@@ -121,6 +132,8 @@ func (s *ChatSession) Snapshot() SessionInfo {
 		LastActiveAt:    s.LastActiveAt,
 		CostUSD:         s.CostUSD,
 		TurnCount:       s.TurnCount,
+		MaxCostUSD:      s.MaxCostUSD,
+		MaxTurns:        s.MaxTurns,
 		ClientCount:     len(s.clients),
 	}
 }
@@ -137,25 +150,129 @@ type SessionInfo struct {
 	LastActiveAt    time.Time     `json:"lastActiveAt"`
 	CostUSD         float64       `json:"costUsd"`
 	TurnCount       int           `json:"turnCount"`
+	MaxCostUSD      float64       `json:"maxCostUsd,omitempty"`
+	MaxTurns        int           `json:"maxTurns,omitempty"`
 	ClientCount     int           `json:"clientCount"`
 }
 
-// CloseAll shuts down every session. Used during server shutdown.
+// CloseAll shuts down every session. Used during server shutdown: the
+// sessions stay saved as they were, so Restore resumes them on the next
+// start.
 func (m *SessionManager) CloseAll() {
 	m.mu.Lock()
-	ids := make([]string, 0, len(m.sessions))
-	for id := range m.sessions {
-		ids = append(ids, id)
+	sessions := make([]*ChatSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
 	}
+	m.sessions = make(map[string]*ChatSession)
 	m.mu.Unlock()
 
 	var wg sync.WaitGroup
-	for _, id := range ids {
+	for _, s := range sessions {
 		wg.Add(1)
-		go func(sid string) {
+		go func(s *ChatSession) {
 			defer wg.Done()
-			m.Delete(sid)
-		}(id)
+			s.close(false)
+		}(s)
 	}
 	wg.Wait()
 }
+
+// Restore adds the sessions saved in TranscriptDir that were not closed,
+// such as those of a server that stopped or crashed, as ready sessions
+// without a Claude process. The next message resumes the conversation the
+// same way as a new turn does (SendMessage respawns Claude with --resume),
+// and reconnecting clients get the transcript replayed. It returns how many
+// sessions it restored.
+func (m *SessionManager) Restore() (int, error) {
+	saved, err := ListSaved()
+	if err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, info := range saved {
+		if info.Status == StatusClosed {
+			continue
+		}
+		if _, ok := m.Get(info.ID); ok {
+			continue
+		}
+		if err := config.CheckWorkDir(info.ProjectPath); err != nil {
+			log.Printf("[chatsession] not restoring session %s: %v", info.ID, err)
+			continue
+		}
+		s := &ChatSession{
+			ID:              info.ID,
+			ProjectName:     info.ProjectName,
+			ProjectPath:     info.ProjectPath,
+			Model:           info.Model,
+			ClaudeSessionID: info.ClaudeSessionID,
+			Status:          StatusReady,
+			CreatedAt:       info.CreatedAt,
+			LastActiveAt:    info.LastActiveAt,
+			CostUSD:         info.CostUSD,
+			TurnCount:       info.TurnCount,
+			MaxCostUSD:      info.MaxCostUSD,
+			MaxTurns:        info.MaxTurns,
+			clients:         make(map[*websocket.Conn]*wsClient),
+		}
+		if entries, err := readTranscript(info.ID); err == nil {
+			for _, e := range entries {
+				s.messages = append(s.messages, e.Event)
+			}
+		}
+		m.mu.Lock()
+		m.sessions[s.ID] = s
+		m.mu.Unlock()
+		restored++
+	}
+	return restored, nil
+}
+
+// ListSaved returns the sessions saved in TranscriptDir, closed ones
+// included, most recently active first. It reads the files only, so it also
+// works outside codes serve; the status of a session is as last saved.
+func ListSaved() ([]SessionInfo, error) {
+	dir, err := TranscriptDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var saved []SessionInfo
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var info SessionInfo
+		if json.Unmarshal(data, &info) != nil || info.ID == "" {
+			continue
+		}
+		saved = append(saved, info)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].LastActiveAt.After(saved[j].LastActiveAt) })
+	return saved, nil
+}
+
+// forgetIdle removes a session that has no Claude process running from the
+// registry, without closing it, and reports whether it did (or the session
+// was not there).
+func (m *SessionManager) forgetIdle(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return true
+	}
+	s.mu.Lock()
+	running := s.process != nil
+	s.mu.Unlock()
+	if running {
+		return false
+	}
+	delete(m.sessions, id)
+	return true
+}
@@ -0,0 +1,99 @@
+package chatsession
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// DefaultIdleTTL is how long a session may go without activity (a message,
+// or output from Claude) before the reaper closes it, unless sessionIdleTTL
+// says otherwise.
+const DefaultIdleTTL = 24 * time.Hour
+
+// maxReapInterval bounds how late the reaper notices an expired session.
+const maxReapInterval = time.Minute
+
+// ParseIdleTTL reads the sessionIdleTTL setting: a duration such as "2h",
+// "0" or "off" to keep idle sessions forever, or "" for DefaultIdleTTL.
+func ParseIdleTTL(s string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return DefaultIdleTTL, nil
+	case "0", "off":
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid session idle TTL %q: want a duration such as 2h, or off", s)
+	}
+	return d, nil
+}
+
+// RunReaper closes sessions idle for longer than ttl until ctx is done.
+// It checks every ttl/4, at least once a minute.
+func (m *SessionManager) RunReaper(ctx context.Context, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(ttl/4, maxReapInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, id := range m.ReapIdle(ttl) {
+				log.Printf("[chatsession] session %s expired after %s idle", id, ttl)
+			}
+		}
+	}
+}
+
+// ReapIdle removes the sessions that have been idle for longer than ttl and
+// expires them: a turn still running is interrupted, connected clients get a
+// session_expired message and the Claude process is stopped. The sessions
+// are saved as closed, so their transcripts stay readable. It returns the
+// IDs of the expired sessions.
+func (m *SessionManager) ReapIdle(ttl time.Duration) []string {
+	cutoff := time.Now().Add(-ttl)
+	var expired []*ChatSession
+	m.mu.Lock()
+	for id, s := range m.sessions {
+		s.mu.Lock()
+		idle := s.LastActiveAt.Before(cutoff)
+		s.mu.Unlock()
+		if idle {
+			delete(m.sessions, id)
+			expired = append(expired, s)
+		}
+	}
+	m.mu.Unlock()
+
+	ids := make([]string, 0, len(expired))
+	for _, s := range expired {
+		s.expire(ttl)
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// expire interrupts a running turn, tells the clients and closes the
+// session.
+func (s *ChatSession) expire(ttl time.Duration) {
+	s.mu.Lock()
+	busy := s.Status == StatusBusy && s.stdin != nil
+	s.mu.Unlock()
+	if busy {
+		if err := s.Interrupt(); err != nil {
+			log.Printf("[chatsession] interrupt expired session %s: %v", s.ID, err)
+		}
+	}
+	s.broadcast(wsOutgoing{
+		Type:    "session_expired",
+		Message: fmt.Sprintf("session closed after %s without activity", ttl),
+	})
+	s.Close()
+}
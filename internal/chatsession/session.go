@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"codes/internal/notify"
 )
 
 // Start spawns a Claude subprocess. If firstMessage is non-empty it is sent
@@ -35,19 +37,21 @@ func (s *ChatSession) Start(firstMessage string) error {
 	s.stdin = stdin
 	s.stdout = stdout
 	s.done = make(chan struct{})
+	s.costBase = s.CostUSD
 	s.Status = StatusReady
 	s.LastActiveAt = time.Now()
 	s.mu.Unlock()
 
 	// Start the read pump in background (reads Claude stdout, broadcasts to clients).
 	go s.readPump()
+	go s.runStartedHook()
 
 	// Send the first user message only if provided.
 	if firstMessage != "" {
 		// Store for replay so reconnecting clients see the initial question.
 		if evt, err := json.Marshal(map[string]string{"type": "user", "content": firstMessage}); err == nil {
 			s.mu.Lock()
-			s.messages = append(s.messages, evt)
+			s.recordLocked(evt)
 			s.mu.Unlock()
 		}
 		if err := s.writeUserMessage(firstMessage); err != nil {
@@ -56,6 +60,7 @@ func (s *ChatSession) Start(firstMessage string) error {
 		}
 		s.mu.Lock()
 		s.Status = StatusBusy
+		s.TurnCount++
 		s.mu.Unlock()
 	}
 
@@ -84,22 +89,52 @@ func (s *ChatSession) Resume(claudeSessionID string) error {
 	s.stdin = stdin
 	s.stdout = stdout
 	s.done = make(chan struct{})
+	s.costBase = s.CostUSD
 	s.ClaudeSessionID = claudeSessionID
 	s.Status = StatusReady
 	s.LastActiveAt = time.Now()
 	s.mu.Unlock()
 
 	go s.readPump()
+	go s.runStartedHook()
 	return nil
 }
 
-// SendMessage writes a user message to the Claude stdin for multi-turn conversation.
-func (s *ChatSession) SendMessage(content string) error {
+// runStartedHook runs the on_session_started hook once Claude is running.
+func (s *ChatSession) runStartedHook() {
+	err := notify.RunEventHook(notify.HookEvent{
+		Event:   "on_session_started",
+		Session: &notify.HookSession{ID: s.ID, Kind: "chat", Project: s.ProjectName, Path: s.ProjectPath},
+	})
+	if err != nil {
+		log.Printf("[chatsession] hook execution error (on_session_started): %v", err)
+	}
+}
+
+// SendMessage writes a user message to the Claude stdin for multi-turn
+// conversation. Attachments are saved for Claude to read (see
+// saveAttachments); errors wrapping ErrInvalidAttachment mean the message
+// was not sent because of them, and a *LimitError that the session has
+// reached its limits.
+func (s *ChatSession) SendMessage(content string, attachments ...Attachment) error {
 	s.mu.Lock()
 	if s.Status == StatusClosed {
 		s.mu.Unlock()
 		return fmt.Errorf("session %s is closed", s.ID)
 	}
+	if err := s.checkLimitsLocked(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	text, blocks := content, []contentBlock(nil)
+	var saved []savedAttachment
+	if len(attachments) > 0 {
+		var err error
+		if text, blocks, saved, err = s.saveAttachments(content, attachments); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
 	needsRespawn := s.stdin == nil
 	claudeSessionID := s.ClaudeSessionID
 	s.mu.Unlock()
@@ -113,8 +148,8 @@ func (s *ChatSession) SendMessage(content string) error {
 
 	s.mu.Lock()
 	// Store user message for replay so reconnecting clients see the full conversation.
-	if evt, err := json.Marshal(map[string]string{"type": "user", "content": content}); err == nil {
-		s.messages = append(s.messages, evt)
+	if evt, err := json.Marshal(userEvent{Type: "user", Content: content, Attachments: saved}); err == nil {
+		s.recordLocked(evt)
 	}
 	s.Status = StatusBusy
 	s.TurnCount++
@@ -123,7 +158,7 @@ func (s *ChatSession) SendMessage(content string) error {
 
 	s.broadcastStatus(StatusBusy)
 
-	if err := s.writeUserMessage(content); err != nil {
+	if err := s.writeUserMessage(text, blocks...); err != nil {
 		return fmt.Errorf("write message: %w", err)
 	}
 	return nil
@@ -142,6 +177,7 @@ func (s *ChatSession) respawn(claudeSessionID string) error {
 	s.stdin = stdin
 	s.stdout = stdout
 	s.done = make(chan struct{})
+	s.costBase = s.CostUSD
 	s.mu.Unlock()
 
 	go s.readPump()
@@ -200,13 +236,25 @@ func (s *ChatSession) RespondPermission(requestID string, allow bool, updatedInp
 	return s.writeJSON(resp)
 }
 
-// Close terminates the Claude subprocess and cleans up.
+// Close terminates the Claude subprocess and cleans up. The session is
+// saved as closed, so it is not restored after a restart.
 func (s *ChatSession) Close() error {
+	return s.close(true)
+}
+
+// close ends the session. With final unset (server shutdown) it is saved as
+// it was, so Restore brings it back on the next start.
+func (s *ChatSession) close(final bool) error {
 	s.mu.Lock()
 	if s.Status == StatusClosed {
 		s.mu.Unlock()
 		return nil
 	}
+	if !final {
+		s.mu.Unlock()
+		s.saveInfo()
+		s.mu.Lock()
+	}
 	s.Status = StatusClosed
 	process := s.process
 	stdin := s.stdin
@@ -223,13 +271,18 @@ func (s *ChatSession) Close() error {
 		process.Wait()
 	}
 
+	if final {
+		s.saveInfo()
+		s.removeAttachments()
+	}
+
 	// Notify all connected clients.
 	s.broadcastStatus(StatusClosed)
 
 	// Close all client connections.
 	s.mu.Lock()
-	for conn := range s.clients {
-		conn.WriteMessage(websocket.CloseMessage,
+	for conn, c := range s.clients {
+		c.write(websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session closed"))
 		conn.Close()
 	}
@@ -241,19 +294,25 @@ func (s *ChatSession) Close() error {
 
 // AddClient registers a WebSocket connection to receive events.
 func (s *ChatSession) AddClient(conn *websocket.Conn) {
+	s.addClient(&wsClient{conn: conn})
+}
+
+// addClient registers c and replays the cached messages to it. Further
+// writes to c must go through c.write.
+func (s *ChatSession) addClient(c *wsClient) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.clients == nil {
-		s.clients = make(map[*websocket.Conn]bool)
+		s.clients = make(map[*websocket.Conn]*wsClient)
 	}
-	s.clients[conn] = true
+	s.clients[c.conn] = c
 
 	// Replay cached messages so the client can catch up.
 	for _, msg := range s.messages {
 		out := wsOutgoing{Type: "claude_event", Event: msg}
 		if data, err := json.Marshal(out); err == nil {
-			conn.WriteMessage(websocket.TextMessage, data)
+			c.write(websocket.TextMessage, data)
 		}
 	}
 }
@@ -279,8 +338,9 @@ func (s *ChatSession) Done() <-chan struct{} {
 
 // --- internal helpers ---
 
-// writeUserMessage encodes a user message and writes it to Claude's stdin.
-func (s *ChatSession) writeUserMessage(content string) error {
+// writeUserMessage encodes a user message, followed by any image blocks, and
+// writes it to Claude's stdin.
+func (s *ChatSession) writeUserMessage(content string, images ...contentBlock) error {
 	msg := stdinUserMessage{
 		Type: "user",
 		Message: stdinMsgContent{
@@ -288,6 +348,9 @@ func (s *ChatSession) writeUserMessage(content string) error {
 			Content: content,
 		},
 	}
+	if len(images) > 0 {
+		msg.Message.Content = append([]contentBlock{{Type: "text", Text: content}}, images...)
+	}
 	return s.writeJSON(msg)
 }
 
@@ -332,12 +395,12 @@ func (s *ChatSession) readPump() {
 			continue
 		}
 
-		// Cache the raw event.
+		// Cache and record the raw event.
 		raw := make(json.RawMessage, len(line))
 		copy(raw, line)
 
 		s.mu.Lock()
-		s.messages = append(s.messages, raw)
+		s.recordLocked(raw)
 		s.LastActiveAt = time.Now()
 		s.mu.Unlock()
 
@@ -378,26 +441,34 @@ func (s *ChatSession) readPump() {
 // processEvent inspects a raw Claude event for metadata (session_id, result type, cost).
 func (s *ChatSession) processEvent(raw json.RawMessage) {
 	var event struct {
-		Type      string  `json:"type"`
-		SessionID string  `json:"session_id"`
-		CostUSD   float64 `json:"cost_usd"`
+		Type         string  `json:"type"`
+		SessionID    string  `json:"session_id"`
+		CostUSD      float64 `json:"cost_usd"`
+		TotalCostUSD float64 `json:"total_cost_usd"` // newer Claude versions
 	}
 	if err := json.Unmarshal(raw, &event); err != nil {
 		return
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
+	newID := event.SessionID != "" && event.SessionID != s.ClaudeSessionID
 	if event.SessionID != "" {
 		s.ClaudeSessionID = event.SessionID
 	}
-	if event.CostUSD > 0 {
-		s.CostUSD = event.CostUSD
+	// Claude reports the cost of its process so far; earlier processes of
+	// the session are in costBase
+	if cost := max(event.CostUSD, event.TotalCostUSD); cost > 0 {
+		s.CostUSD = s.costBase + cost
 	}
 	if event.Type == "result" {
 		s.Status = StatusReady
 	}
+	s.mu.Unlock()
+
+	// Saved as soon as the conversation can be resumed, and after each turn
+	if newID || event.Type == "result" {
+		s.saveInfo()
+	}
 }
 
 // broadcast sends a message to all connected WebSocket clients.
@@ -409,22 +480,22 @@ func (s *ChatSession) broadcast(msg wsOutgoing) {
 	}
 
 	s.mu.Lock()
-	clients := make([]*websocket.Conn, 0, len(s.clients))
-	for c := range s.clients {
+	clients := make([]*wsClient, 0, len(s.clients))
+	for _, c := range s.clients {
 		clients = append(clients, c)
 	}
 	s.mu.Unlock()
 
 	var wg sync.WaitGroup
-	for _, conn := range clients {
+	for _, client := range clients {
 		wg.Add(1)
-		go func(c *websocket.Conn) {
+		go func(c *wsClient) {
 			defer wg.Done()
-			if err := c.WriteMessage(websocket.TextMessage, data); err != nil {
+			if err := c.write(websocket.TextMessage, data); err != nil {
 				log.Printf("[chatsession] write to client error: %v", err)
-				s.RemoveClient(c)
+				s.RemoveClient(c.conn)
 			}
-		}(conn)
+		}(client)
 	}
 	wg.Wait()
 }
@@ -0,0 +1,310 @@
+package chatsession
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Transcripts: every event of a session, the user's messages and Claude's
+// stream-json output, is appended to ~/.codes/sessions/<id>.jsonl as it
+// happens, and the session's metadata is kept next to it in <id>.json. They
+// outlive the session and the server, so past turns can be read back with
+// LoadTranscript and exported with TranscriptMarkdown.
+
+// ErrTranscriptNotFound is returned for a session that has no transcript.
+var ErrTranscriptNotFound = errors.New("session transcript not found")
+
+// TranscriptEntry is one recorded session event.
+type TranscriptEntry struct {
+	Seq   int             `json:"seq"` // 1 for the session's first event
+	Time  time.Time       `json:"time"`
+	Event json.RawMessage `json:"event"` // user message or raw Claude stream-json event
+}
+
+// TranscriptDir returns ~/.codes/sessions.
+func TranscriptDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codes", "sessions"), nil
+}
+
+// transcriptPath returns the path of a session's file with the given
+// extension, refusing IDs that could leave the directory.
+func transcriptPath(id, ext string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid session ID %q", id)
+	}
+	dir, err := TranscriptDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+ext), nil
+}
+
+// recordLocked caches an event for reconnecting clients and appends it to
+// the transcript. Caller must hold s.mu.
+func (s *ChatSession) recordLocked(event json.RawMessage) {
+	s.messages = append(s.messages, event)
+	entry := TranscriptEntry{Seq: len(s.messages), Time: time.Now(), Event: event}
+	if err := appendTranscript(s.ID, entry); err != nil {
+		log.Printf("[chatsession] transcript for session %s: %v", s.ID, err)
+	}
+}
+
+func appendTranscript(id string, entry TranscriptEntry) error {
+	path, err := transcriptPath(id, ".jsonl")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// saveInfo writes the session's metadata next to its transcript. It is
+// called when the session is created, after each turn and when it closes.
+func (s *ChatSession) saveInfo() {
+	info := s.Snapshot()
+	info.ClientCount = 0
+	path, err := transcriptPath(info.ID, ".json")
+	if err == nil {
+		err = writeInfo(path, info)
+	}
+	if err != nil {
+		log.Printf("[chatsession] save session %s: %v", info.ID, err)
+	}
+}
+
+func writeInfo(path string, info SessionInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadTranscript returns a session's metadata and recorded events, oldest
+// first. A live session in DefaultManager is described as it is now; one
+// that is gone (closed, or from before a restart) as it was last saved, with
+// the closed status.
+func LoadTranscript(id string) (SessionInfo, []TranscriptEntry, error) {
+	var info SessionInfo
+	if s, ok := DefaultManager.Get(id); ok {
+		info = s.Snapshot()
+	} else {
+		path, err := transcriptPath(id, ".json")
+		if err != nil {
+			return info, nil, err
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return info, nil, ErrTranscriptNotFound
+		} else if err != nil {
+			return info, nil, err
+		}
+		if err := json.Unmarshal(data, &info); err != nil {
+			return info, nil, fmt.Errorf("invalid session file %s: %w", path, err)
+		}
+		info.Status = StatusClosed
+	}
+
+	entries, err := readTranscript(id)
+	return info, entries, err
+}
+
+// readTranscript returns the events recorded for a session, none if it has
+// no transcript yet.
+func readTranscript(id string) ([]TranscriptEntry, error) {
+	path, err := transcriptPath(id, ".jsonl")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []TranscriptEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+	for scanner.Scan() {
+		var e TranscriptEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e) // a torn last line is skipped
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// PruneTranscripts deletes the transcripts of sessions that were last active
+// before the given time and have no Claude process running, dropping them
+// from DefaultManager if they were restored, and returns how many it
+// deleted.
+func PruneTranscripts(before time.Time) (int, error) {
+	dir, err := TranscriptDir()
+	if err != nil {
+		return 0, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var info SessionInfo
+		if json.Unmarshal(data, &info) != nil || !info.LastActiveAt.Before(before) {
+			continue
+		}
+		if !DefaultManager.forgetIdle(strings.TrimSuffix(filepath.Base(path), ".json")) {
+			continue
+		}
+		os.Remove(strings.TrimSuffix(path, ".json") + ".jsonl")
+		if err := os.Remove(path); err == nil {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// transcriptEvent holds the parts of a recorded event that transcripts show.
+type transcriptEvent struct {
+	Type        string            `json:"type"`
+	Content     any               `json:"content"`
+	Attachments []savedAttachment `json:"attachments"`
+	Message     struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	} `json:"message"`
+	TotalCostUSD *float64 `json:"total_cost_usd"`
+}
+
+// EventLines summarizes a recorded event as log lines for a live view, such
+// as the TUI's log pane: "you: ...", "claude: ...", "tool: ..." and the cost
+// of a finished turn. Events with nothing to show give no lines.
+func EventLines(event json.RawMessage) []string {
+	var evt transcriptEvent
+	if json.Unmarshal(event, &evt) != nil {
+		return nil
+	}
+	var lines []string
+	switch evt.Type {
+	case "user":
+		if text, ok := evt.Content.(string); ok {
+			lines = append(lines, "you: "+text)
+			for _, a := range evt.Attachments {
+				lines = append(lines, fmt.Sprintf("attachment: %s (%s, %d bytes)", a.Name, a.MediaType, a.Size))
+			}
+		}
+	case "assistant":
+		for _, block := range evt.Message.Content {
+			switch block.Type {
+			case "text":
+				lines = append(lines, "claude: "+block.Text)
+			case "tool_use":
+				lines = append(lines, "tool: "+block.Name+" "+compactJSON(block.Input))
+			}
+		}
+	case "result":
+		if evt.TotalCostUSD != nil {
+			lines = append(lines, fmt.Sprintf("turn finished: $%.4f", *evt.TotalCostUSD))
+		}
+	}
+	return lines
+}
+
+// TranscriptMarkdown renders a transcript for reading: the user's messages,
+// Claude's replies and tool calls, and the cost of each turn.
+func TranscriptMarkdown(info SessionInfo, entries []TranscriptEntry) string {
+	var b strings.Builder
+	title := info.ProjectName
+	if title == "" {
+		title = info.ProjectPath
+	}
+	fmt.Fprintf(&b, "# Session %s: %s\n\n", info.ID, title)
+	fmt.Fprintf(&b, "- Project: %s\n", info.ProjectPath)
+	if info.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", info.Model)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", info.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Turns: %d, cost $%.4f\n", info.TurnCount, info.CostUSD)
+
+	for _, e := range entries {
+		var evt transcriptEvent
+		if json.Unmarshal(e.Event, &evt) != nil {
+			continue
+		}
+		switch evt.Type {
+		case "user":
+			// Our own record of what was sent; Claude's user events carry
+			// tool results instead
+			if text, ok := evt.Content.(string); ok {
+				fmt.Fprintf(&b, "\n## User (%s)\n\n%s\n", e.Time.Format("15:04:05"), text)
+				for _, a := range evt.Attachments {
+					fmt.Fprintf(&b, "\n> Attachment: `%s` (%s, %d bytes)\n", a.Name, a.MediaType, a.Size)
+				}
+			}
+		case "assistant":
+			for _, block := range evt.Message.Content {
+				switch block.Type {
+				case "text":
+					fmt.Fprintf(&b, "\n## Claude\n\n%s\n", block.Text)
+				case "tool_use":
+					fmt.Fprintf(&b, "\n> Tool: `%s` %s\n", block.Name, compactJSON(block.Input))
+				}
+			}
+		case "result":
+			if evt.TotalCostUSD != nil {
+				fmt.Fprintf(&b, "\n_Turn finished: $%.4f_\n", *evt.TotalCostUSD)
+			}
+		}
+	}
+	return b.String()
+}
+
+func compactJSON(raw json.RawMessage) string {
+	var v any
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return "{}"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
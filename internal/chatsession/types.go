@@ -32,16 +32,36 @@ type ChatSession struct {
 	LastActiveAt    time.Time     `json:"lastActiveAt"`
 	CostUSD         float64       `json:"costUsd"`
 	TurnCount       int           `json:"turnCount"`
+	MaxCostUSD      float64       `json:"maxCostUsd,omitempty"` // 0 = no limit, see SetLimits
+	MaxTurns        int           `json:"maxTurns,omitempty"`   // 0 = no limit
 
 	mu       sync.Mutex
 	process  *exec.Cmd
 	stdin    io.WriteCloser
 	stdout   io.ReadCloser
-	clients  map[*websocket.Conn]bool
-	messages []json.RawMessage // Cached messages for reconnection replay
+	clients  map[*websocket.Conn]*wsClient
+	messages []json.RawMessage // Cached messages for reconnection replay, also in the transcript
+	attachmentCount int        // attachments saved so far, numbers their files
+	costBase float64           // CostUSD before the current Claude process
 	done     chan struct{}      // Closed when readPump exits
 }
 
+// wsClient is a connected WebSocket client. gorilla/websocket allows one
+// writer per connection, and broadcasts, replies to the client's own
+// messages and the handshake's status write come from different
+// goroutines, so every write goes through write.
+type wsClient struct {
+	conn *websocket.Conn
+	mu   sync.Mutex // serializes writes to conn
+}
+
+// write sends one message to the client.
+func (c *wsClient) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
 // SessionManager is a thread-safe registry of active chat sessions.
 type SessionManager struct {
 	sessions map[string]*ChatSession
@@ -58,7 +78,28 @@ type stdinUserMessage struct {
 
 type stdinMsgContent struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"` // string, or []contentBlock with attachments
+}
+
+// contentBlock is a part of a user message with attachments.
+type contentBlock struct {
+	Type   string       `json:"type"` // text, image
+	Text   string       `json:"text,omitempty"`
+	Source *imageSource `json:"source,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"` // base64
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// userEvent records a user message in the session's events, for replay and
+// the transcript.
+type userEvent struct {
+	Type        string            `json:"type"` // always "user"
+	Content     string            `json:"content"`
+	Attachments []savedAttachment `json:"attachments,omitempty"`
 }
 
 // stdinControlRequest sends a control signal (e.g., interrupt) to Claude.
@@ -96,6 +137,7 @@ type stdinControlResponseBehavior struct {
 type wsIncoming struct {
 	Type         string          `json:"type"`                    // user_message, interrupt, permission_response
 	Content      string          `json:"content,omitempty"`       // For user_message
+	Attachments  []Attachment    `json:"attachments,omitempty"`   // For user_message
 	RequestID    string          `json:"request_id,omitempty"`    // For permission_response
 	Allow        bool            `json:"allow,omitempty"`         // For permission_response
 	UpdatedInput json.RawMessage `json:"updated_input,omitempty"` // For permission_response
@@ -103,8 +145,9 @@ type wsIncoming struct {
 
 // wsOutgoing represents a message sent to WebSocket clients.
 type wsOutgoing struct {
-	Type    string          `json:"type"`              // claude_event, session_status, error
+	Type    string          `json:"type"`              // claude_event, session_status, session_expired, error
 	Event   json.RawMessage `json:"event,omitempty"`   // Raw Claude stream-json event
 	Status  SessionStatus   `json:"status,omitempty"`  // For session_status
-	Message string          `json:"message,omitempty"` // For error
+	Message string          `json:"message,omitempty"` // For error and session_expired
+	Code    string          `json:"code,omitempty"`    // For error: machine-readable reason, e.g. limit_exceeded
 }
@@ -2,6 +2,7 @@ package chatsession
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
@@ -12,6 +13,9 @@ import (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  4096,
 	WriteBufferSize: 4096,
+	// Browsers send the token as a second subprotocol (see the HTTP
+	// server's authMiddleware); agreeing to "codes" completes the handshake.
+	Subprotocols: []string{"codes"},
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins; auth is handled at the HTTP layer.
 	},
@@ -19,14 +23,17 @@ var upgrader = websocket.Upgrader{
 
 // HandleWebSocket upgrades an HTTP connection and bridges it to the ChatSession.
 // It registers the client, reads incoming messages, and forwards them to Claude.
-func HandleWebSocket(session *ChatSession, w http.ResponseWriter, r *http.Request) {
+// With readOnly the client only watches: its messages are answered with an
+// error.
+func HandleWebSocket(session *ChatSession, w http.ResponseWriter, r *http.Request, readOnly bool) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("[chatsession] websocket upgrade error: %v", err)
 		return
 	}
 
-	session.AddClient(conn)
+	client := &wsClient{conn: conn}
+	session.addClient(client)
 
 	// Send current status immediately.
 	statusMsg := wsOutgoing{
@@ -34,7 +41,7 @@ func HandleWebSocket(session *ChatSession, w http.ResponseWriter, r *http.Reques
 		Status: session.Snapshot().Status,
 	}
 	if data, err := json.Marshal(statusMsg); err == nil {
-		conn.WriteMessage(websocket.TextMessage, data)
+		client.write(websocket.TextMessage, data)
 	}
 
 	// Read messages from the client until disconnect.
@@ -56,55 +63,69 @@ func HandleWebSocket(session *ChatSession, w http.ResponseWriter, r *http.Reques
 				return
 			}
 
-			handleClientMessage(session, conn, raw)
+			if readOnly {
+				sendWSError(client, "server is read-only: messages are not accepted")
+				continue
+			}
+			handleClientMessage(session, client, raw)
 		}
 	}()
 }
 
 // handleClientMessage processes a single incoming WebSocket message.
-func handleClientMessage(session *ChatSession, conn *websocket.Conn, raw []byte) {
+func handleClientMessage(session *ChatSession, client *wsClient, raw []byte) {
 	var msg wsIncoming
 	if err := json.Unmarshal(raw, &msg); err != nil {
-		sendWSError(conn, "invalid JSON: "+err.Error())
+		sendWSError(client, "invalid JSON: "+err.Error())
 		return
 	}
 
 	switch msg.Type {
 	case "user_message":
-		if msg.Content == "" {
-			sendWSError(conn, "content is required for user_message")
+		if msg.Content == "" && len(msg.Attachments) == 0 {
+			sendWSError(client, "content or attachments are required for user_message")
 			return
 		}
-		if err := session.SendMessage(msg.Content); err != nil {
-			sendWSError(conn, "send message failed: "+err.Error())
+		if err := session.SendMessage(msg.Content, msg.Attachments...); err != nil {
+			if errors.Is(err, ErrLimitExceeded) {
+				sendWSErrorCode(client, err.Error(), ErrCodeLimitExceeded)
+				return
+			}
+			sendWSError(client, "send message failed: "+err.Error())
 		}
 
 	case "interrupt":
 		if err := session.Interrupt(); err != nil {
-			sendWSError(conn, "interrupt failed: "+err.Error())
+			sendWSError(client, "interrupt failed: "+err.Error())
 		}
 
 	case "permission_response":
 		if msg.RequestID == "" {
-			sendWSError(conn, "request_id is required for permission_response")
+			sendWSError(client, "request_id is required for permission_response")
 			return
 		}
 		if err := session.RespondPermission(msg.RequestID, msg.Allow, msg.UpdatedInput); err != nil {
-			sendWSError(conn, "permission response failed: "+err.Error())
+			sendWSError(client, "permission response failed: "+err.Error())
 		}
 
 	default:
-		sendWSError(conn, "unknown message type: "+msg.Type)
+		sendWSError(client, "unknown message type: "+msg.Type)
 	}
 }
 
 // sendWSError sends an error message to a single WebSocket client.
-func sendWSError(conn *websocket.Conn, message string) {
+func sendWSError(client *wsClient, message string) {
+	sendWSErrorCode(client, message, "")
+}
+
+// sendWSErrorCode sends an error message with a machine-readable code.
+func sendWSErrorCode(client *wsClient, message, code string) {
 	out := wsOutgoing{
 		Type:    "error",
 		Message: message,
+		Code:    code,
 	}
 	if data, err := json.Marshal(out); err == nil {
-		conn.WriteMessage(websocket.TextMessage, data)
+		client.write(websocket.TextMessage, data)
 	}
 }
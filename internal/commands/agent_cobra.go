@@ -2,6 +2,8 @@ package commands
 
 import (
 	"github.com/spf13/cobra"
+
+	"codes/internal/agent"
 )
 
 // AgentCmd is the parent command for agent/team management.
@@ -67,7 +69,8 @@ var agentAddCmd = &cobra.Command{
 		role, _ := cmd.Flags().GetString("role")
 		model, _ := cmd.Flags().GetString("model")
 		agentType, _ := cmd.Flags().GetString("type")
-		RunAgentAdd(args[0], args[1], role, model, agentType)
+		skills, _ := cmd.Flags().GetStringSlice("skills")
+		RunAgentAdd(args[0], args[1], role, model, agentType, skills)
 	},
 }
 
@@ -80,6 +83,59 @@ var agentRemoveCmd = &cobra.Command{
 	},
 }
 
+var agentPollCmd = &cobra.Command{
+	Use:   "poll <team> [name]",
+	Short: "Show or set agent poll interval and idle backoff",
+	Long:  "Agents wake immediately when tasks or messages change and otherwise poll on a timer that backs off while idle. Without a name, settings apply to the whole team; members can override them.",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		clear, _ := cmd.Flags().GetBool("clear")
+		var poll *agent.PollSettings
+		if cmd.Flags().Changed("interval") || cmd.Flags().Changed("max-interval") {
+			poll = &agent.PollSettings{}
+			poll.Interval, _ = cmd.Flags().GetInt("interval")
+			poll.MaxInterval, _ = cmd.Flags().GetInt("max-interval")
+		}
+		RunAgentPoll(args[0], name, poll, clear)
+	},
+}
+
+var agentAssignmentCmd = &cobra.Command{
+	Use:   "assignment <team> [round_robin|least_loaded|random|off]",
+	Short: "Show or set how unassigned tasks are placed",
+	Long:  "With a strategy set, tasks created without an owner are assigned right away to a running agent that has the task's skills. Without one (off, the default), they stay pending until an agent auto-claims them.",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		strategy := ""
+		if len(args) == 2 {
+			strategy = args[1]
+		}
+		RunAgentAssignment(args[0], strategy, len(args) == 2)
+	},
+}
+
+var agentIdleCmd = &cobra.Command{
+	Use:   "idle <team> [minutes|off]",
+	Short: "Show or set when an idle team stops its agents",
+	Long:  "With an idle policy, once the team has had no pending, assigned or running task for the given number of minutes, its agents stop themselves and report it with a team message, a desktop notification and the on_team_idle hook. With --archive the team's finished tasks are archived too.",
+	Example: `  codes agent idle myteam 30
+  codes agent idle myteam 120 --archive
+  codes agent idle myteam off`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setting := ""
+		if len(args) == 2 {
+			setting = args[1]
+		}
+		archive, _ := cmd.Flags().GetBool("archive")
+		RunAgentIdle(args[0], setting, archive, len(args) == 2)
+	},
+}
+
 var agentStartCmd = &cobra.Command{
 	Use:   "start <team> <name>",
 	Short: "Start an agent daemon",
@@ -99,7 +155,23 @@ var agentStopCmd = &cobra.Command{
 }
 
 var agentRunCmd = &cobra.Command{
-	Use:    "run <team> <name>",
+	Use:   "run <team> <name>",
+	Short: "Run an agent attached to the terminal",
+	Long:  "With --foreground, runs the agent's daemon loop in this terminal with its log printed live, for debugging a misbehaving agent. Ctrl+C stops it the way `codes agent stop` does; a second Ctrl+C exits at once.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		foreground, _ := cmd.Flags().GetBool("foreground")
+		if !foreground {
+			// Daemons spawned by earlier versions are started this way
+			RunAgentDaemon(args[0], args[1])
+			return
+		}
+		RunAgentForeground(args[0], args[1])
+	},
+}
+
+var agentDaemonCmd = &cobra.Command{
+	Use:    "daemon <team> <name>",
 	Short:  "Run agent daemon (internal)",
 	Hidden: true,
 	Args:   cobra.ExactArgs(2),
@@ -127,7 +199,8 @@ var agentTaskCreateCmd = &cobra.Command{
 		priority, _ := cmd.Flags().GetString("priority")
 		project, _ := cmd.Flags().GetString("project")
 		workDir, _ := cmd.Flags().GetString("work-dir")
-		RunAgentTaskCreate(args[0], args[1], desc, assign, blockedBy, priority, project, workDir)
+		contextFiles, _ := cmd.Flags().GetStringSlice("context-file")
+		RunAgentTaskCreate(args[0], args[1], desc, assign, blockedBy, priority, project, workDir, contextFiles)
 	},
 }
 
@@ -160,6 +233,67 @@ var agentTaskCancelCmd = &cobra.Command{
 	},
 }
 
+var agentTaskFollowupCmd = &cobra.Command{
+	Use:   "followup <team> <task-id> <instructions>",
+	Short: "Follow up on a completed task in its Claude session",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		subject, _ := cmd.Flags().GetString("subject")
+		RunAgentTaskFollowup(args[0], args[1], args[2], subject)
+	},
+}
+
+var agentTaskPlanCmd = &cobra.Command{
+	Use:   "plan <team> <task-id> <plan>",
+	Short: "Post the plan for a running task (used by agents)",
+	Long: `Record an agent's plan for the task it is running and broadcast it to the
+team as a plan message. Put one step per line; list markers are removed.
+The plan shows in team_status so the orchestrator can redirect a task
+before the work is done.`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		RunAgentTaskPlan(args[0], args[1], from, args[2])
+	},
+}
+
+var agentTaskAskCmd = &cobra.Command{
+	Use:   "ask <team> <question>",
+	Short: "Create a task that asks the human a question",
+	Long: `Create a human task: instead of running Claude, it posts the question to
+the human (a message to "human" and a desktop notification) once its
+--blocked-by tasks are done, and waits for an answer. Tasks blocked by it
+start after it is answered and get the answer in their prompt.
+
+Answer with: codes agent task answer <team> <task-id> <answer>`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		desc, _ := cmd.Flags().GetString("description")
+		blockedBy, _ := cmd.Flags().GetIntSlice("blocked-by")
+		choices, _ := cmd.Flags().GetStringSlice("choices")
+		priority, _ := cmd.Flags().GetString("priority")
+		RunAgentTaskAsk(args[0], args[1], desc, blockedBy, choices, priority)
+	},
+}
+
+var agentTaskAnswerCmd = &cobra.Command{
+	Use:   "answer <team> <task-id> <answer>",
+	Short: "Answer a human task",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		RunAgentTaskAnswer(args[0], args[1], args[2])
+	},
+}
+
+var agentTaskQuestionsCmd = &cobra.Command{
+	Use:   "questions <team>",
+	Short: "List human tasks waiting for an answer",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		RunAgentTaskQuestions(args[0])
+	},
+}
+
 // -- Message subcommands --
 
 var agentMessageCmd = &cobra.Command{
@@ -235,6 +369,10 @@ func init() {
 	agentAddCmd.Flags().String("role", "", "Agent role description")
 	agentAddCmd.Flags().String("model", "", "Claude model to use (e.g. sonnet, opus)")
 	agentAddCmd.Flags().String("type", "worker", "Agent type (worker, leader)")
+	agentAddCmd.Flags().StringSlice("skills", nil, "Agent skills, matched against task skills (e.g. go,frontend)")
+	agentPollCmd.Flags().Int("interval", 0, "Seconds between polls while active (default 3)")
+	agentPollCmd.Flags().Int("max-interval", 0, "Maximum seconds between polls when idle (default 60)")
+	agentPollCmd.Flags().Bool("clear", false, "Remove the override and inherit defaults")
 
 	// Task commands
 	agentTaskCreateCmd.Flags().StringP("description", "d", "", "Task description")
@@ -243,9 +381,18 @@ func init() {
 	agentTaskCreateCmd.Flags().String("priority", "normal", "Task priority: high, normal, or low")
 	agentTaskCreateCmd.Flags().StringP("project", "p", "", "Project name to execute in (registered via codes project add)")
 	agentTaskCreateCmd.Flags().String("work-dir", "", "Explicit working directory (overrides project)")
+	agentTaskCreateCmd.Flags().StringSlice("context-file", nil, "File (relative to the working directory) whose contents are inlined into the prompt when the task starts (repeatable)")
 	agentTaskListCmd.Flags().String("status", "", "Filter by status")
 	agentTaskListCmd.Flags().String("owner", "", "Filter by owner")
-	agentTaskCmd.AddCommand(agentTaskCreateCmd, agentTaskListCmd, agentTaskGetCmd, agentTaskCancelCmd)
+	agentTaskFollowupCmd.Flags().String("subject", "", "Subject of the follow-up task (default: Follow-up: <original subject>)")
+	agentTaskPlanCmd.Flags().String("from", "", "Agent running the task")
+	agentTaskPlanCmd.MarkFlagRequired("from")
+	agentTaskAskCmd.Flags().StringP("description", "d", "", "Context for the question")
+	agentTaskAskCmd.Flags().IntSlice("blocked-by", nil, "Task IDs to wait for before asking")
+	agentTaskAskCmd.Flags().StringSlice("choices", nil, "Allowed answers (default: any answer)")
+	agentTaskAskCmd.Flags().String("priority", "normal", "Task priority: high, normal, or low")
+	agentTaskCmd.AddCommand(agentTaskCreateCmd, agentTaskListCmd, agentTaskGetCmd, agentTaskCancelCmd, agentTaskFollowupCmd, agentTaskPlanCmd,
+		agentTaskAskCmd, agentTaskAnswerCmd, agentTaskQuestionsCmd)
 
 	// Message commands
 	agentMessageSendCmd.Flags().String("from", "", "Sender agent name")
@@ -255,6 +402,12 @@ func init() {
 	agentMessageListCmd.MarkFlagRequired("agent")
 	agentMessageCmd.AddCommand(agentMessageSendCmd, agentMessageListCmd)
 
+	// Idle flags
+	agentIdleCmd.Flags().Bool("archive", false, "Also archive the team's finished tasks when it stops")
+
+	// Run flags
+	agentRunCmd.Flags().Bool("foreground", false, "Run attached to the terminal with live logs")
+
 	// Status flags
 	agentStatusCmd.Flags().BoolP("watch", "w", false, "Auto-refresh every 3 seconds")
 
@@ -262,11 +415,15 @@ func init() {
 	AgentCmd.AddCommand(agentTeamCmd)
 	AgentCmd.AddCommand(agentAddCmd)
 	AgentCmd.AddCommand(agentRemoveCmd)
+	AgentCmd.AddCommand(agentPollCmd)
+	AgentCmd.AddCommand(agentAssignmentCmd)
+	AgentCmd.AddCommand(agentIdleCmd)
 	AgentCmd.AddCommand(agentStartCmd)
 	AgentCmd.AddCommand(agentStopCmd)
 	AgentCmd.AddCommand(agentStartAllCmd)
 	AgentCmd.AddCommand(agentStopAllCmd)
 	AgentCmd.AddCommand(agentRunCmd)
+	AgentCmd.AddCommand(agentDaemonCmd)
 	AgentCmd.AddCommand(agentTaskCmd)
 	AgentCmd.AddCommand(agentMessageCmd)
 	AgentCmd.AddCommand(agentStatusCmd)
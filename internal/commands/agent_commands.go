@@ -7,11 +7,14 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 
 	"codes/internal/agent"
 	"codes/internal/output"
+	"codes/internal/trace"
 	"codes/internal/ui"
+	"codes/internal/update"
 )
 
 // -- Team commands --
@@ -93,6 +96,16 @@ func RunAgentTeamInfo(name string) {
 	if cfg.WorkDir != "" {
 		fmt.Printf("WorkDir: %s\n", cfg.WorkDir)
 	}
+	if cfg.Assignment != "" {
+		fmt.Printf("Assignment: %s\n", cfg.Assignment)
+	}
+	if cfg.Idle != nil {
+		fmt.Printf("Idle stop: after %d minutes", cfg.Idle.StopAfter)
+		if cfg.Idle.Archive {
+			fmt.Print(", archiving finished tasks")
+		}
+		fmt.Println()
+	}
 	fmt.Printf("Created: %s\n", cfg.CreatedAt.Format("2006-01-02 15:04:05"))
 	fmt.Printf("Members (%d):\n", len(cfg.Members))
 	for _, m := range cfg.Members {
@@ -103,6 +116,9 @@ func RunAgentTeamInfo(name string) {
 		if m.Model != "" {
 			fmt.Printf(" [%s]", m.Model)
 		}
+		if len(m.Skills) > 0 {
+			fmt.Printf(" skills: %s", strings.Join(m.Skills, ", "))
+		}
 
 		// Show live status
 		state, _ := agent.GetAgentState(name, m.Name)
@@ -118,12 +134,13 @@ func RunAgentTeamInfo(name string) {
 
 // -- Agent member commands --
 
-func RunAgentAdd(teamName, agentName, role, model, agentType string) {
+func RunAgentAdd(teamName, agentName, role, model, agentType string, skills []string) {
 	member := agent.TeamMember{
-		Name:  agentName,
-		Role:  role,
-		Model: model,
-		Type:  agentType,
+		Name:   agentName,
+		Role:   role,
+		Model:  model,
+		Type:   agentType,
+		Skills: skills,
 	}
 
 	if err := agent.AddMember(teamName, member); err != nil {
@@ -138,6 +155,134 @@ func RunAgentAdd(teamName, agentName, role, model, agentType string) {
 	ui.ShowSuccess("Agent %q added to team %q", agentName, teamName)
 }
 
+// RunAgentPoll shows or updates poll settings for an agent, or the team-wide
+// default when agentName is empty.
+func RunAgentPoll(teamName, agentName string, poll *agent.PollSettings, clear bool) {
+	cfg, err := agent.GetTeam(teamName)
+	if err != nil {
+		ui.ShowError("Failed to get team", err)
+		return
+	}
+
+	if clear || poll != nil {
+		if poll != nil && (poll.Interval < 0 || poll.MaxInterval < 0) {
+			ui.ShowError("Invalid poll settings", fmt.Errorf("intervals must be >= 0"))
+			return
+		}
+		if clear {
+			poll = nil
+		}
+		if err := agent.SetPollSettings(teamName, agentName, poll); err != nil {
+			ui.ShowError("Failed to save poll settings", err)
+			return
+		}
+		if cfg, err = agent.GetTeam(teamName); err != nil {
+			ui.ShowError("Failed to get team", err)
+			return
+		}
+	}
+
+	current := cfg.Poll
+	if agentName != "" {
+		current = nil
+		for _, m := range cfg.Members {
+			if m.Name == agentName {
+				current = m.Poll
+			}
+		}
+	}
+
+	if output.JSONMode {
+		printJSON(current)
+		return
+	}
+
+	target := fmt.Sprintf("team %q", teamName)
+	if agentName != "" {
+		target = fmt.Sprintf("agent %q", agentName)
+	}
+	if current == nil {
+		ui.ShowInfo("No poll settings for %s (using defaults)", target)
+	} else {
+		ui.ShowInfo("Poll settings for %s: interval=%ds maxInterval=%ds (0 = inherit)", target, current.Interval, current.MaxInterval)
+	}
+	if clear || poll != nil {
+		ui.ShowInfo("Restart running agents to apply")
+	}
+}
+
+// RunAgentAssignment shows the team's assignment strategy, or sets it when
+// set is true.
+func RunAgentAssignment(teamName, strategy string, set bool) {
+	if set {
+		s, err := agent.ParseAssignStrategy(strategy)
+		if err != nil {
+			ui.ShowError("Invalid assignment strategy", err)
+			return
+		}
+		if err := agent.SetAssignStrategy(teamName, s); err != nil {
+			ui.ShowError("Failed to save assignment strategy", err)
+			return
+		}
+	}
+
+	cfg, err := agent.GetTeam(teamName)
+	if err != nil {
+		ui.ShowError("Failed to get team", err)
+		return
+	}
+
+	if output.JSONMode {
+		printJSON(map[string]string{"assignment": string(cfg.Assignment)})
+		return
+	}
+	if cfg.Assignment == "" {
+		ui.ShowInfo("Team %q: unassigned tasks are left for agents to auto-claim", teamName)
+	} else {
+		ui.ShowInfo("Team %q: unassigned tasks are assigned %s", teamName, cfg.Assignment)
+	}
+}
+
+// RunAgentIdle shows the team's idle policy, or sets it when set is true:
+// setting is a number of minutes or "off".
+func RunAgentIdle(teamName, setting string, archive, set bool) {
+	if set {
+		var policy *agent.IdlePolicy
+		if setting != "off" {
+			minutes, err := strconv.Atoi(setting)
+			if err != nil || minutes <= 0 {
+				ui.ShowError("Invalid idle setting", fmt.Errorf("expected a number of minutes or off, got %q", setting))
+				return
+			}
+			policy = &agent.IdlePolicy{StopAfter: minutes, Archive: archive}
+		}
+		if err := agent.SetIdlePolicy(teamName, policy); err != nil {
+			ui.ShowError("Failed to save idle policy", err)
+			return
+		}
+	}
+
+	cfg, err := agent.GetTeam(teamName)
+	if err != nil {
+		ui.ShowError("Failed to get team", err)
+		return
+	}
+
+	if output.JSONMode {
+		printJSON(map[string]*agent.IdlePolicy{"idle": cfg.Idle})
+		return
+	}
+	if cfg.Idle == nil {
+		ui.ShowInfo("Team %q: agents keep running while idle", teamName)
+		return
+	}
+	msg := fmt.Sprintf("Team %q: agents stop after %d minutes without pending or running tasks", teamName, cfg.Idle.StopAfter)
+	if cfg.Idle.Archive {
+		msg += ", archiving finished tasks"
+	}
+	ui.ShowInfo("%s", msg)
+}
+
 func RunAgentRemove(teamName, agentName string) {
 	if err := agent.RemoveMember(teamName, agentName); err != nil {
 		ui.ShowError("Failed to remove agent", err)
@@ -152,30 +297,17 @@ func RunAgentRemove(teamName, agentName string) {
 }
 
 func RunAgentStart(teamName, agentName string) {
-	// Find our own binary
-	exe, err := os.Executable()
+	pid, err := agent.StartAgent(teamName, agentName)
 	if err != nil {
-		ui.ShowError("Cannot find executable", err)
-		return
-	}
-
-	cmd := exec.Command(exe, "agent", "run", teamName, agentName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
 		ui.ShowError("Failed to start agent", err)
 		return
 	}
 
 	if output.JSONMode {
-		printJSON(map[string]any{"started": true, "pid": cmd.Process.Pid})
+		printJSON(map[string]any{"started": true, "pid": pid})
 		return
 	}
-	ui.ShowSuccess("Agent %q started (pid %d)", agentName, cmd.Process.Pid)
-
-	// Detach
-	cmd.Process.Release()
+	ui.ShowSuccess("Agent %q started (pid %d)", agentName, pid)
 }
 
 func RunAgentStop(teamName, agentName string) {
@@ -193,6 +325,43 @@ func RunAgentStop(teamName, agentName string) {
 	ui.ShowSuccess("Stop signal sent to agent %q", agentName)
 }
 
+// RunAgentForeground runs an agent's daemon loop in this process for
+// debugging: the log goes to the terminal as well as the log file, the
+// first Ctrl+C stops the agent the way `codes agent stop` does (a running
+// task is cancelled and marked failed) and a second one exits at once.
+func RunAgentForeground(teamName, agentName string) {
+	if err := agent.CheckAgentStartable(teamName, agentName); err != nil {
+		ui.ShowError("Cannot run agent", err)
+		return
+	}
+	d, err := agent.NewDaemon(teamName, agentName)
+	if err != nil {
+		ui.ShowError("Cannot run agent", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 2)
+	notifySignals(sigCh)
+	go func() {
+		<-sigCh
+		ui.ShowWarning("Stopping agent %q (Ctrl+C again to exit immediately)...", agentName)
+		cancel()
+		<-sigCh
+		os.Exit(130)
+	}()
+
+	ui.ShowInfo("Running agent %q of team %q in the foreground (pid %d)", agentName, teamName, os.Getpid())
+	ui.ShowInfo("Log: %s", agent.AgentLogPath(teamName, agentName))
+	if err := d.Run(ctx); err != nil && err != context.Canceled {
+		ui.ShowError("Agent stopped", err)
+		return
+	}
+	ui.ShowSuccess("Agent %q stopped", agentName)
+}
+
 func RunAgentDaemon(teamName, agentName string) {
 	d, err := agent.NewDaemon(teamName, agentName)
 	if err != nil {
@@ -219,12 +388,22 @@ func RunAgentDaemon(teamName, agentName string) {
 
 // -- Task commands --
 
-func RunAgentTaskCreate(teamName, subject, description, assign string, blockedBy []int, priority, project, workDir string) {
-	task, err := agent.CreateTask(teamName, subject, description, assign, blockedBy, agent.TaskPriority(priority), project, workDir)
+func RunAgentTaskCreate(teamName, subject, description, assign string, blockedBy []int, priority, project, workDir string, contextFiles []string) {
+	tasks, err := agent.CreateTasks(teamName, []agent.TaskSpec{{
+		Subject:      subject,
+		Description:  description,
+		Owner:        assign,
+		BlockedBy:    blockedBy,
+		Priority:     agent.TaskPriority(priority),
+		Project:      project,
+		WorkDir:      workDir,
+		ContextFiles: contextFiles,
+	}})
 	if err != nil {
 		ui.ShowError("Failed to create task", err)
 		return
 	}
+	task := tasks[0]
 
 	if output.JSONMode {
 		printJSON(task)
@@ -237,6 +416,9 @@ func RunAgentTaskCreate(teamName, subject, description, assign string, blockedBy
 	if task.Project != "" {
 		fmt.Printf("  Project: %s\n", task.Project)
 	}
+	if len(task.ContextFiles) > 0 {
+		fmt.Printf("  Pinned: %s\n", strings.Join(task.ContextFiles, ", "))
+	}
 }
 
 func RunAgentTaskList(teamName, statusFilter, ownerFilter string) {
@@ -286,6 +468,12 @@ func RunAgentTaskGet(teamName, taskIDStr string) {
 
 	fmt.Printf("Task #%d: %s\n", task.ID, task.Subject)
 	fmt.Printf("  Status: %s\n", task.Status)
+	if task.Type != "" {
+		fmt.Printf("  Type: %s\n", task.Type)
+	}
+	if len(task.Choices) > 0 {
+		fmt.Printf("  Choices: %s\n", strings.Join(task.Choices, ", "))
+	}
 	if task.Owner != "" {
 		fmt.Printf("  Owner: %s\n", task.Owner)
 	}
@@ -298,9 +486,15 @@ func RunAgentTaskGet(teamName, taskIDStr string) {
 	if task.SessionID != "" {
 		fmt.Printf("  Session: %s\n", task.SessionID)
 	}
+	if task.FollowUpOf != 0 {
+		fmt.Printf("  Follow-up of: #%d\n", task.FollowUpOf)
+	}
 	if task.Result != "" {
 		fmt.Printf("  Result: %s\n", task.Result)
 	}
+	if task.AnsweredBy != "" {
+		fmt.Printf("  Answered by: %s\n", task.AnsweredBy)
+	}
 	if task.Error != "" {
 		fmt.Printf("  Error: %s\n", task.Error)
 	}
@@ -330,6 +524,119 @@ func RunAgentTaskCancel(teamName, taskIDStr string) {
 	ui.ShowSuccess("Task #%d cancelled", task.ID)
 }
 
+func RunAgentTaskFollowup(teamName, taskIDStr, instructions, subject string) {
+	taskID, err := strconv.Atoi(taskIDStr)
+	if err != nil {
+		ui.ShowError("Invalid task ID", fmt.Errorf("%s is not a number", taskIDStr))
+		return
+	}
+
+	task, err := agent.FollowUpTask(teamName, taskID, instructions, subject)
+	if err != nil {
+		ui.ShowError("Failed to follow up task", err)
+		return
+	}
+
+	if output.JSONMode {
+		printJSON(task)
+		return
+	}
+	ui.ShowSuccess("Task #%d created: %s (follow-up of #%d)", task.ID, task.Subject, taskID)
+}
+
+func RunAgentTaskPlan(teamName, taskIDStr, from, plan string) {
+	taskID, err := strconv.Atoi(taskIDStr)
+	if err != nil {
+		ui.ShowError("Invalid task ID", fmt.Errorf("%s is not a number", taskIDStr))
+		return
+	}
+
+	task, err := agent.PostTaskPlan(teamName, from, taskID, plan)
+	if err != nil {
+		ui.ShowError("Failed to post plan", err)
+		return
+	}
+
+	if output.JSONMode {
+		printJSON(task.Plan)
+		return
+	}
+	ui.ShowSuccess("Plan for task #%d posted (%d steps)", task.ID, len(task.Plan.Steps))
+}
+
+func RunAgentTaskAsk(teamName, question, description string, blockedBy []int, choices []string, priority string) {
+	tasks, err := agent.CreateTasks(teamName, []agent.TaskSpec{{
+		Subject:     question,
+		Description: description,
+		BlockedBy:   blockedBy,
+		Priority:    agent.TaskPriority(priority),
+		Type:        agent.TaskTypeHuman,
+		Choices:     choices,
+	}})
+	if err != nil {
+		ui.ShowError("Failed to create task", err)
+		return
+	}
+	task := tasks[0]
+
+	if output.JSONMode {
+		printJSON(task)
+		return
+	}
+	ui.ShowSuccess("Task #%d created: %s", task.ID, task.Subject)
+	if len(blockedBy) > 0 {
+		ui.ShowInfo("The question is asked once tasks %v are completed", blockedBy)
+	}
+	ui.ShowInfo("Answer with: codes agent task answer %s %d <answer>", teamName, task.ID)
+}
+
+func RunAgentTaskAnswer(teamName, taskIDStr, answer string) {
+	taskID, err := strconv.Atoi(taskIDStr)
+	if err != nil {
+		ui.ShowError("Invalid task ID", fmt.Errorf("%s is not a number", taskIDStr))
+		return
+	}
+
+	task, err := agent.AnswerTask(teamName, taskID, answer, "")
+	if err != nil {
+		ui.ShowError("Failed to answer task", err)
+		return
+	}
+
+	if output.JSONMode {
+		printJSON(task)
+		return
+	}
+	ui.ShowSuccess("Task #%d answered: %s", task.ID, task.Result)
+}
+
+func RunAgentTaskQuestions(teamName string) {
+	tasks, err := agent.OpenQuestions(teamName)
+	if err != nil {
+		ui.ShowError("Failed to list questions", err)
+		return
+	}
+
+	if output.JSONMode {
+		printJSON(map[string]any{"tasks": tasks})
+		return
+	}
+
+	if len(tasks) == 0 {
+		fmt.Println("No questions waiting for an answer")
+		return
+	}
+	for _, t := range tasks {
+		fmt.Printf("  #%d %s\n", t.ID, t.Subject)
+		if t.Description != "" {
+			fmt.Printf("      %s\n", t.Description)
+		}
+		if len(t.Choices) > 0 {
+			fmt.Printf("      Choices: %s\n", strings.Join(t.Choices, ", "))
+		}
+	}
+}
+
 // -- Message commands --
 
 func RunAgentMessageSend(teamName, from, to, content string) {
@@ -430,6 +737,11 @@ func RunAgentStatus(teamName string) {
 		}
 		fmt.Printf("  %-15s %s\n", m.Name, status)
 	}
+	for _, m := range cfg.Members {
+		if skew := agent.AgentVersionSkew(teamName, m.Name); skew != nil {
+			ui.ShowWarning("%v", skew)
+		}
+	}
 
 	// Task summary
 	counts := map[agent.TaskStatus]int{}
@@ -496,6 +808,17 @@ func RunAgentStartAll(teamName string) {
 		return
 	}
 
+	for _, m := range cfg.Members {
+		if skew := agent.AgentVersionSkew(teamName, m.Name); skew != nil && skew.Skew == update.SkewMajor {
+			if output.JSONMode {
+				output.PrintError(skew)
+				return
+			}
+			ui.ShowError("Cannot start agents", skew)
+			return
+		}
+	}
+
 	exe, err := os.Executable()
 	if err != nil {
 		ui.ShowError("Cannot find executable", err)
@@ -525,11 +848,14 @@ func RunAgentStartAll(teamName string) {
 			continue
 		}
 
-		cmd := exec.Command(exe, "agent", "run", teamName, m.Name)
+		cmd := exec.Command(exe, "agent", "daemon", teamName, m.Name)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
-		if err := cmd.Start(); err != nil {
+		end := trace.Start("exec.start", "cmd", "agent daemon", "agent", m.Name)
+		err := cmd.Start()
+		end()
+		if err != nil {
 			r.Error = err.Error()
 			results = append(results, r)
 			if !output.JSONMode {
@@ -8,8 +8,11 @@ import (
 	"strings"
 
 	anthropic "github.com/anthropics/anthropic-sdk-go"
+	"golang.org/x/term"
 
 	"codes/internal/assistant"
+	"codes/internal/config"
+	"codes/internal/markdown"
 	"codes/internal/output"
 	"codes/internal/ui"
 )
@@ -36,7 +39,7 @@ func RunAssistantOnce(message, sessionID, model string) error {
 		return nil
 	}
 
-	fmt.Println(result.Reply)
+	fmt.Println(renderReply(result.Reply))
 	return nil
 }
 
@@ -68,11 +71,27 @@ func RunAssistantREPL(sessionID, model string) error {
 			continue
 		}
 
-		fmt.Printf("\n%s\n\n", result.Reply)
+		fmt.Printf("\n%s\n\n", renderReply(result.Reply))
 	}
 	return nil
 }
 
+// renderReply renders an assistant reply as Markdown when stdout is a
+// terminal, wrapped to its width (or the configured markdown width).
+// Piped output keeps the raw text.
+func renderReply(reply string) string {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return reply
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		width = 0
+	}
+	cfg, _ := config.LoadConfig()
+	return markdown.Render(reply, markdown.FromConfig(cfg, width))
+}
+
 // RunAssistantClear deletes the session history.
 func RunAssistantClear(sessionID string) error {
 	if err := assistant.ClearSession(sessionID); err != nil {
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"codes/internal/audit"
+	"codes/internal/output"
+	"codes/internal/ui"
+)
+
+// RunAudit prints the audit log entries matching the filters, newest first.
+func RunAudit(since, source, actor string, limit int) {
+	filter := audit.Filter{Source: source, Actor: actor, Limit: limit}
+	if since != "" {
+		t, err := audit.ParseSince(since, time.Now())
+		if err != nil {
+			ui.ShowError("Invalid --since", err)
+			return
+		}
+		filter.Since = t
+	}
+	entries, err := audit.Query(filter)
+	if err != nil {
+		ui.ShowError("Failed to read the audit log", err)
+		return
+	}
+	if output.JSONMode {
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		output.Print(entries, nil)
+		return
+	}
+	if len(entries) == 0 {
+		ui.ShowInfo("No audit entries")
+		return
+	}
+	for _, e := range entries {
+		result := e.Result
+		if e.Status != 0 {
+			result = fmt.Sprintf("%d", e.Status)
+		}
+		fmt.Printf("%s  %-4s %-20s %-6s %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Source, e.Actor, result, e.Action)
+		if e.Error != "" {
+			fmt.Printf("    error: %s\n", e.Error)
+		}
+	}
+}
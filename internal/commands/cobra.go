@@ -3,12 +3,13 @@ package commands
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"codes/internal/config"
+	mcpserver "codes/internal/mcp"
 	"codes/internal/ui"
 )
 
@@ -49,9 +50,10 @@ var AddCmd = &cobra.Command{
 var SelectCmd = &cobra.Command{
 	Use:   "select",
 	Short: "Select Claude configuration",
-	Long:  "Interactively select which Claude configuration to use",
+	Long:  "Interactively select which Claude configuration to use.\n\nWith --session-only the choice applies to the Claude session started now\n(through its environment) and the saved default is left unchanged.",
 	Run: func(cmd *cobra.Command, args []string) {
-		RunSelect()
+		sessionOnly, _ := cmd.Flags().GetBool("session-only")
+		RunSelect(sessionOnly)
 	},
 }
 
@@ -59,14 +61,21 @@ var SelectCmd = &cobra.Command{
 var TestCmd = &cobra.Command{
 	Use:               "test [config-name]",
 	Short:             "Test API configuration",
-	Long:              "Test API connectivity for all configurations or a specific one",
+	Long:              "Test API connectivity for all configurations or a specific one. All configurations are tested concurrently; the command exits non-zero if the default profile fails.",
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: completeProfileNames,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunTest(args)
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		RunTest(args, concurrency, sortBy)
 	},
 }
 
+func init() {
+	TestCmd.Flags().IntP("concurrency", "j", 4, "Number of profiles to test at once")
+	TestCmd.Flags().String("sort", "name", "Sort the summary by name, latency or status")
+}
+
 // ProfileListCmd represents the profile list command
 var ProfileListCmd = &cobra.Command{
 	Use:   "list",
@@ -93,9 +102,15 @@ var ProfileRemoveCmd = &cobra.Command{
 var UpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update codes to the latest version",
-	Long:  "Check for and install the latest version of codes CLI",
+	Long: `Check for and install the latest version of codes CLI. The release notes
+between the installed and the latest version are shown first, with breaking
+changes highlighted, and the update is confirmed interactively. Major version
+upgrades are only installed with --yes.
+
+Applied updates are listed by 'codes version --history'.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunSelfUpdate()
+		yes, _ := cmd.Flags().GetBool("yes")
+		RunSelfUpdate(yes)
 	},
 }
 
@@ -116,16 +131,144 @@ var ClaudeUpdateCmd = &cobra.Command{
 	},
 }
 
+// InstallCmd installs the Claude CLI.
+var InstallCmd = &cobra.Command{
+	Use:   "install [version]",
+	Short: "Install the Claude CLI",
+	Long:  "Install the Claude CLI (npm @anthropic-ai/claude-code), latest by default. Running agents that are waiting for it resume on their next poll.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version := "latest"
+		if len(args) > 0 {
+			version = args[0]
+		}
+		ui.ShowLoading("Installing Claude %s...", version)
+		InstallClaude(version)
+	},
+}
+
+// MaintenanceCmd runs the nightly maintenance on demand.
+var MaintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Prune, compact and archive old state now",
+	Long: `Run the maintenance codes serve does every night at 3am:
+
+  • delete task notifications older than 7 days that no MCP client picked up
+  • move read team messages older than 30 days into messages/archive.jsonl
+  • archive tasks that finished more than 30 days ago (tasks/archive/)
+  • refresh the cached status of remote hosts
+  • check ~/.codes/config.json for broken references
+
+Run it from cron or a systemd timer when codes serve is not running. The TUI
+shows a summary of the last run on its next launch.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunMaintenance()
+	},
+}
+
+// AuditCmd shows the audit log of state-changing API requests and MCP calls.
+var AuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show who changed what through the HTTP API and MCP",
+	Long: `Show the audit log (~/.codes/audit.jsonl), newest first: every request
+codes serve handled that was not a GET, and every MCP tool call that is not
+read-only, with who made it, a digest of its parameters and the outcome.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetString("since")
+		source, _ := cmd.Flags().GetString("source")
+		actor, _ := cmd.Flags().GetString("actor")
+		limit, _ := cmd.Flags().GetInt("limit")
+		RunAudit(since, source, actor, limit)
+	},
+}
+
+func init() {
+	AuditCmd.Flags().String("since", "", "Only entries after this duration ago (24h) or RFC 3339 time")
+	AuditCmd.Flags().String("source", "", "Only entries from http or mcp")
+	AuditCmd.Flags().String("actor", "", "Only entries by this token name or hash, or MCP client")
+	AuditCmd.Flags().IntP("limit", "n", 50, "Number of entries to show (0 for all)")
+}
+
+// McpCmd is the parent command for inspecting the MCP server.
+var McpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Inspect the codes MCP server",
+}
+
+// McpHistoryCmd shows recent MCP tool calls.
+var McpHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent MCP tool calls with their arguments and results",
+	Long: `Show the last 200 MCP tool calls (~/.codes/mcp-history.json), oldest first:
+when, which client, the tool, how long it took and whether it failed. With
+--full the arguments (secrets redacted) and output are printed too. The
+history is saved when codes serve or the stdio MCP server shuts down.
+
+--export writes the selected calls to a file, e.g. to attach to a bug report;
+--import merges such a file into the local history.`,
+	Example: `  codes mcp history --errors --full
+  codes mcp history --tool task_create -n 5
+  codes mcp history --export history.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tool, _ := cmd.Flags().GetString("tool")
+		errorsOnly, _ := cmd.Flags().GetBool("errors")
+		limit, _ := cmd.Flags().GetInt("limit")
+		full, _ := cmd.Flags().GetBool("full")
+		exportPath, _ := cmd.Flags().GetString("export")
+		importPath, _ := cmd.Flags().GetString("import")
+		RunMcpHistory(tool, errorsOnly, limit, full, exportPath, importPath)
+	},
+}
+
+func init() {
+	McpHistoryCmd.Flags().String("tool", "", "Only calls of this tool")
+	McpHistoryCmd.Flags().Bool("errors", false, "Only calls that failed")
+	McpHistoryCmd.Flags().IntP("limit", "n", 50, "Number of most recent calls to show (0 for all)")
+	McpHistoryCmd.Flags().Bool("full", false, "Also print arguments and output")
+	McpHistoryCmd.Flags().String("export", "", "Write the selected calls to this file instead of printing them")
+	McpHistoryCmd.Flags().String("import", "", "Merge the calls in this exported file into the local history first")
+	McpCmd.AddCommand(McpHistoryCmd)
+}
+
 // VersionCmd represents the version command
 var VersionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show codes version",
-	Long:  "Show the version of codes CLI",
+	Long:  "Show the version of codes CLI, or with --history the updates applied to it",
+	Run: func(cmd *cobra.Command, args []string) {
+		history, _ := cmd.Flags().GetBool("history")
+		RunVersion(history)
+	},
+}
+
+// UninstallCmd removes codes from the system.
+var UninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Uninstall codes",
+	Long: `Remove the codes binary, shell completions, service units and the "codes"
+MCP server registration from Claude Code's user config. A summary of what will
+be removed is shown before anything is deleted.
+
+With --purge, all state under ~/.codes (config, teams, sessions, stats) is
+deleted as well. Purge refuses to run while agent daemons are still running.`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunVersion()
+		purge, _ := cmd.Flags().GetBool("purge")
+		yes, _ := cmd.Flags().GetBool("yes")
+		RunUninstall(purge, yes)
 	},
 }
 
+func init() {
+	UpdateCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt; required for major upgrades")
+	VersionCmd.Flags().Bool("history", false, "List the updates applied to this installation")
+	UninstallCmd.Flags().Bool("purge", false, "Also delete all state under ~/.codes")
+	UninstallCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+}
+
 // DoctorCmd represents the doctor command
 var DoctorCmd = &cobra.Command{
 	Use:   "doctor",
@@ -136,13 +279,31 @@ var DoctorCmd = &cobra.Command{
 	},
 }
 
+// SelftestCmd represents the selftest command
+var SelftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Run a task end to end to verify this installation",
+	Long: `Create a throwaway team, dispatch a task to a new agent daemon using the mock
+adapter, wait for its completion notification, read it back over the HTTP API,
+and clean up. Needs neither the claude CLI nor an API key.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		RunSelftest(timeout)
+	},
+}
+
+func init() {
+	SelftestCmd.Flags().Duration("timeout", time.Minute, "How long to wait for the agent at each stage")
+}
+
 // RunCmd represents the default run command
 var RunCmd = &cobra.Command{
 	Use:  "codes",
 	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check if claude is installed
-		if _, err := exec.LookPath("claude"); err != nil {
+		if !config.ClaudeAvailable() {
 			ui.ShowLoading("Claude CLI not found. Installing...")
 			InstallClaude("latest")
 			return
@@ -304,9 +465,11 @@ var ProjectRemoveCmd = &cobra.Command{
 var ProjectListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all project aliases",
-	Long:  "List all configured project aliases",
+	Long:  "List all configured project aliases. Archived projects are hidden unless --all is given; --tag lists only the projects with that tag.",
 	Run: func(cmd *cobra.Command, args []string) {
-		RunProjectList()
+		all, _ := cmd.Flags().GetBool("all")
+		tag, _ := cmd.Flags().GetString("tag")
+		RunProjectList(all, tag)
 	},
 }
 
@@ -345,8 +508,130 @@ var ProjectUnlinkCmd = &cobra.Command{
 	},
 }
 
+// ProjectArchiveCmd hides a project from default lists without deleting it.
+var ProjectArchiveCmd = &cobra.Command{
+	Use:   "archive <name>",
+	Short: "Archive a project",
+	Long: `Hide a project from default lists and the TUI while keeping its config entry,
+so team tasks and links that reference it still resolve. Restore it with
+'codes project restore'.
+
+With --compress, the local directory is packed into ~/.codes/archive/<name>-<time>.tar.gz
+and removed; restore extracts it back to the original path.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		compress, _ := cmd.Flags().GetBool("compress")
+		RunProjectArchive(args[0], compress)
+	},
+}
+
+// ProjectRestoreCmd un-archives a project.
+var ProjectRestoreCmd = &cobra.Command{
+	Use:               "restore <name>",
+	Short:             "Restore an archived project",
+	Long:              "Restore an archived project, extracting its directory if it was compressed",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunProjectRestore(args[0])
+	},
+}
+
+// ProjectTagCmd adds or removes project tags.
+var ProjectTagCmd = &cobra.Command{
+	Use:   "tag <name> [tag...]",
+	Short: "Tag a project",
+	Long: `Add tags such as work, personal or a client's name to a project, or remove
+them with --remove. Without tags, shows the project's tags. The TUI cycles
+a filter through the tags with '#', and 'codes project list --tag' filters by one.`,
+	Example: `  codes project tag api work client-acme
+  codes project tag api --remove client-acme`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeProjectNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		remove, _ := cmd.Flags().GetStringSlice("remove")
+		RunProjectTag(args[0], args[1:], remove)
+	},
+}
+
+// ProjectFavoriteCmd pins a project to the top of the TUI's list.
+var ProjectFavoriteCmd = &cobra.Command{
+	Use:               "favorite <name>",
+	Short:             "Pin a project as a favorite",
+	Long:              "Pin a project to the top of the TUI's project list, or unpin it with --off. In the TUI, '*' toggles it.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		off, _ := cmd.Flags().GetBool("off")
+		RunProjectFavorite(args[0], !off)
+	},
+}
+
 func init() {
 	ProjectLinkCmd.Flags().StringP("role", "r", "", "Role of the linked project (e.g. 'API provider')")
+	ProjectListCmd.Flags().BoolP("all", "a", false, "Include archived projects")
+	ProjectListCmd.Flags().String("tag", "", "Only list projects with this tag")
+	ProjectTagCmd.Flags().StringSlice("remove", nil, "Tags to remove")
+	ProjectFavoriteCmd.Flags().Bool("off", false, "Unpin the project")
+	ProjectArchiveCmd.Flags().Bool("compress", false, "Compress the local directory into a tarball and remove it")
+}
+
+// ImportCmd registers projects known to other tools.
+var ImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import projects from other tools",
+	Long:  "Register directories from VS Code, zoxide/z or GitHub as projects. Matches are listed and imported after confirmation; directories already configured are skipped.",
+}
+
+// ImportVSCodeCmd imports folders from VS Code workspaces.
+var ImportVSCodeCmd = &cobra.Command{
+	Use:   "vscode [file.code-workspace...]",
+	Short: "Import VS Code workspace folders",
+	Long:  "Import the folders of the given .code-workspace files, or the recently opened folders and workspaces of VS Code (also Insiders, VSCodium and Cursor) when no files are given.",
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+		RunImportVSCode(args, yes)
+	},
+}
+
+// ImportZoxideCmd imports git repositories from the zoxide/z database.
+var ImportZoxideCmd = &cobra.Command{
+	Use:   "zoxide",
+	Short: "Import frequently used git repositories from zoxide or z",
+	Long:  "Import git repositories from `zoxide query --list --score`, falling back to the z data file (~/.z or $_Z_DATA).",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+		minScore, _ := cmd.Flags().GetFloat64("min-score")
+		RunImportZoxide(minScore, yes)
+	},
+}
+
+// ImportGitHubCmd imports local clones of GitHub repositories.
+var ImportGitHubCmd = &cobra.Command{
+	Use:   "gh [owner]",
+	Short: "Import local clones of your GitHub repositories",
+	Long:  "List repositories with `gh repo list [owner]` and import those cloned under the projects directory (as <dir>/<repo> or <dir>/<owner>/<repo>) whose origin remote matches.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+		dir, _ := cmd.Flags().GetString("dir")
+		owner := ""
+		if len(args) > 0 {
+			owner = args[0]
+		}
+		RunImportGitHub(owner, dir, yes)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{ImportVSCodeCmd, ImportZoxideCmd, ImportGitHubCmd} {
+		c.Flags().BoolP("yes", "y", false, "Import without asking for confirmation")
+		ImportCmd.AddCommand(c)
+	}
+	ImportZoxideCmd.Flags().Float64("min-score", 0, "Skip directories with a lower frecency score")
+	ImportGitHubCmd.Flags().String("dir", "", "Directory containing clones (default: projects directory)")
 }
 
 func init() {
@@ -357,7 +642,12 @@ func init() {
 	ProjectCmd.AddCommand(ProjectScanCmd)
 	ProjectCmd.AddCommand(ProjectLinkCmd)
 	ProjectCmd.AddCommand(ProjectUnlinkCmd)
+	ProjectCmd.AddCommand(ProjectArchiveCmd)
+	ProjectCmd.AddCommand(ProjectRestoreCmd)
+	ProjectCmd.AddCommand(ProjectTagCmd)
+	ProjectCmd.AddCommand(ProjectFavoriteCmd)
 
+	SelectCmd.Flags().Bool("session-only", false, "Use the profile for this session only, without changing the saved default")
 	ProfileCmd.AddCommand(AddCmd, SelectCmd, TestCmd, ProfileListCmd, ProfileRemoveCmd)
 
 	ConfigCmd.AddCommand(ConfigSetCmd)
@@ -373,14 +663,26 @@ func init() {
 	// Remote sub-commands
 	RemoteAddCmd.Flags().IntP("port", "p", 0, "SSH port")
 	RemoteAddCmd.Flags().StringP("identity", "i", "", "SSH identity file")
+	RemoteAddCmd.Flags().String("security-key-provider", "", "FIDO2 middleware library for security-key identities")
 	RemoteCmd.AddCommand(RemoteAddCmd)
 	RemoteCmd.AddCommand(RemoteRemoveCmd)
 	RemoteCmd.AddCommand(RemoteListCmd)
 	RemoteCmd.AddCommand(RemoteStatusCmd)
+	RemoteTrustCmd.Flags().BoolP("yes", "y", false, "Trust the offered host key without prompting")
+	RemoteCmd.AddCommand(RemoteTrustCmd)
+	RemoteInstallCmd.Flags().String("limit-rate", "", "Cap download bandwidth on the remote (e.g. 500k, 2M)")
 	RemoteCmd.AddCommand(RemoteInstallCmd)
+	RemoteSyncCmd.Flags().Bool("dry-run", false, "Print the sync plan without writing to the remote")
 	RemoteCmd.AddCommand(RemoteSyncCmd)
+	RemoteCmd.AddCommand(RemoteDiffCmd)
+	RemoteSetupCmd.Flags().String("limit-rate", "", "Cap download bandwidth on the remote (e.g. 500k, 2M)")
 	RemoteCmd.AddCommand(RemoteSetupCmd)
 	RemoteCmd.AddCommand(RemoteSSHCmd)
+	RemoteLimitsCmd.Flags().Int("max-concurrent", 0, "Max Claude processes at once across all agents on the host (0 = unlimited)")
+	RemoteLimitsCmd.Flags().Int("nice", 0, "CPU niceness for agent Claude processes (0-19)")
+	RemoteLimitsCmd.Flags().Int("ionice-class", 0, "I/O scheduling class for agent Claude processes (2 best-effort, 3 idle; Linux)")
+	RemoteLimitsCmd.Flags().Bool("clear", false, "Remove all limits for the host")
+	RemoteCmd.AddCommand(RemoteLimitsCmd)
 
 	// Stats sub-commands
 	StatsCmd.AddCommand(StatsSummaryCmd)
@@ -437,13 +739,105 @@ Starts everything automatically — no flags needed (all on :3456):
   • Assistant scheduler (background)
   • stdio MCP when stdin is a pipe (Claude Code MCP mode)
 
+MCP clients that support elicitation are asked to confirm team_delete and
+task_redirect; pass --no-confirm when nobody is there to answer.
+
+To expose the API beyond localhost without sending tokens in cleartext, serve
+HTTPS with --tls-cert/--tls-key, or --tls-self-signed to generate a
+certificate for this machine in ~/.codes/tls/ (its fingerprint is printed so
+clients can pin it). --tls-client-ca additionally requires clients to present
+a certificate signed by that CA. The same settings can live in httpTLS in
+~/.codes/config.json; flags take precedence.
+
+--read-only refuses everything that changes state with 403 (code read_only)
+while listings, activity, transcripts and event streams keep working, and
+leaves the MCP SSE transport off. Use it to expose a status dashboard widely
+and keep control local.
+
 Example:
-  codes serve`,
+  codes serve
+  codes serve --tls-self-signed
+  codes serve --read-only
+  codes serve --tls-cert server.pem --tls-key server-key.pem --tls-client-ca clients.pem`,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunServe()
+		noConfirm, _ := cmd.Flags().GetBool("no-confirm")
+		mcpserver.ConfirmDestructive = !noConfirm
+		var tlsFlags config.HTTPTLS
+		tlsFlags.Cert, _ = cmd.Flags().GetString("tls-cert")
+		tlsFlags.Key, _ = cmd.Flags().GetString("tls-key")
+		tlsFlags.ClientCA, _ = cmd.Flags().GetString("tls-client-ca")
+		tlsFlags.SelfSigned, _ = cmd.Flags().GetBool("tls-self-signed")
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+		RunServe(tlsFlags, readOnly)
 	},
 }
 
+// ServeTokenCmd is the parent command for scoped HTTP API tokens.
+var ServeTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage scoped HTTP API tokens",
+	Long: `Manage HTTP API tokens limited to scopes and, optionally, teams.
+
+Scopes:
+  read            GET endpoints (every token has it)
+  tasks:write     create and change teams, tasks and messages; start and stop agents; run workflows
+  sessions:write  create, drive and delete chat sessions; the assistant; profile switches
+  profiles:write  create, change and delete API profiles
+  admin           everything, including actions on the host (POST /host/sessions)
+
+Tokens limited with --team get 403 for other teams and for endpoints that span
+teams (creating teams, running workflows, /metrics). codes serve reads tokens at
+startup, so restart it after creating or revoking one.`,
+}
+
+// ServeTokenCreateCmd creates a scoped token.
+var ServeTokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a scoped HTTP API token",
+	Long:  "Create an HTTP API token with the given scopes, optionally limited to some teams. The token is printed once.",
+	Example: `  codes serve token create dashboard --scope read
+  codes serve token create ci --scope tasks:write --team backend,frontend`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+		teams, _ := cmd.Flags().GetStringSlice("team")
+		RunServeTokenCreate(args[0], scopes, teams)
+	},
+}
+
+// ServeTokenListCmd lists scoped tokens.
+var ServeTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scoped HTTP API tokens",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunServeTokenList()
+	},
+}
+
+// ServeTokenRevokeCmd removes a scoped token.
+var ServeTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a scoped HTTP API token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		RunServeTokenRevoke(args[0])
+	},
+}
+
+func init() {
+	ServeCmd.Flags().Bool("no-confirm", false, "Do not ask MCP clients to confirm destructive tools (team_delete, task_redirect); for headless use")
+	ServeCmd.Flags().String("tls-cert", "", "Serve HTTPS with this PEM certificate (requires --tls-key)")
+	ServeCmd.Flags().String("tls-key", "", "PEM private key for --tls-cert")
+	ServeCmd.Flags().String("tls-client-ca", "", "Require client certificates signed by this PEM CA bundle (mutual TLS)")
+	ServeCmd.Flags().Bool("tls-self-signed", false, "Serve HTTPS with a self-signed certificate generated in ~/.codes/tls/")
+	ServeCmd.Flags().Bool("read-only", false, "Refuse state-changing HTTP requests (403) and disable MCP SSE; for status dashboards")
+	ServeTokenCreateCmd.Flags().StringSlice("scope", []string{"read"}, "Scopes: read, tasks:write, sessions:write, admin")
+	ServeTokenCreateCmd.Flags().StringSlice("team", nil, "Limit the token to these teams (default: all)")
+	ServeTokenCmd.AddCommand(ServeTokenCreateCmd, ServeTokenListCmd, ServeTokenRevokeCmd)
+	ServeCmd.AddCommand(ServeTokenCmd)
+}
+
 // RemoteCmd represents the remote command
 var RemoteCmd = &cobra.Command{
 	Use:     "remote",
@@ -461,7 +855,8 @@ var RemoteAddCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		port, _ := cmd.Flags().GetInt("port")
 		identity, _ := cmd.Flags().GetString("identity")
-		RunRemoteAdd(args[0], args[1], port, identity)
+		skProvider, _ := cmd.Flags().GetString("security-key-provider")
+		RunRemoteAdd(args[0], args[1], port, identity, skProvider)
 	},
 }
 
@@ -499,15 +894,29 @@ var RemoteStatusCmd = &cobra.Command{
 	},
 }
 
+// RemoteTrustCmd verifies and trusts a remote host key
+var RemoteTrustCmd = &cobra.Command{
+	Use:               "trust <name>",
+	Short:             "Verify and trust remote host key",
+	Long:              "Show the remote host's key fingerprints and add them to ~/.ssh/known_hosts after confirmation. SSH connections to hosts that are not in known_hosts are refused.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRemoteNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+		RunRemoteTrust(args[0], yes)
+	},
+}
+
 // RemoteInstallCmd installs codes on a remote host
 var RemoteInstallCmd = &cobra.Command{
 	Use:               "install <name>",
 	Short:             "Install codes on remote host",
-	Long:              "Download and install the codes binary on a remote SSH host",
+	Long:              "Download and install the codes binary on a remote SSH host. Skips the download when the installed binary matches the release checksum, and resumes interrupted downloads.",
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: completeRemoteNames,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunRemoteInstall(args[0])
+		rateLimit, _ := cmd.Flags().GetString("limit-rate")
+		RunRemoteInstall(args[0], rateLimit)
 	},
 }
 
@@ -519,7 +928,20 @@ var RemoteSyncCmd = &cobra.Command{
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: completeRemoteNames,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunRemoteSync(args[0])
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		RunRemoteSync(args[0], dryRun)
+	},
+}
+
+// RemoteDiffCmd reports config drift against a remote host
+var RemoteDiffCmd = &cobra.Command{
+	Use:               "diff <name>",
+	Short:             "Show config drift on remote host",
+	Long:              "Compare local profiles and settings against a remote host and report missing profiles, changed settings, and a stale codes version",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRemoteNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunRemoteDiff(args[0])
 	},
 }
 
@@ -531,7 +953,38 @@ var RemoteSetupCmd = &cobra.Command{
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: completeRemoteNames,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunRemoteSetup(args[0])
+		rateLimit, _ := cmd.Flags().GetString("limit-rate")
+		RunRemoteSetup(args[0], rateLimit)
+	},
+}
+
+// RemoteLimitsCmd shows or sets agent execution limits for a remote host
+var RemoteLimitsCmd = &cobra.Command{
+	Use:               "limits <name>",
+	Short:             "Show or set agent limits for remote host",
+	Long:              "Cap concurrent Claude processes and set nice/ionice for agents running on a remote host. Excess tasks stay queued until a slot frees up. Limits are pushed by `codes remote sync`.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRemoteNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		clear, _ := cmd.Flags().GetBool("clear")
+		var limits *config.AgentLimits
+		if cmd.Flags().Changed("max-concurrent") || cmd.Flags().Changed("nice") || cmd.Flags().Changed("ionice-class") {
+			host, ok := config.GetRemote(args[0])
+			limits = &config.AgentLimits{}
+			if ok && host.AgentLimits != nil {
+				*limits = *host.AgentLimits
+			}
+			if cmd.Flags().Changed("max-concurrent") {
+				limits.MaxConcurrent, _ = cmd.Flags().GetInt("max-concurrent")
+			}
+			if cmd.Flags().Changed("nice") {
+				limits.Nice, _ = cmd.Flags().GetInt("nice")
+			}
+			if cmd.Flags().Changed("ionice-class") {
+				limits.IONiceClass, _ = cmd.Flags().GetInt("ionice-class")
+			}
+		}
+		RunRemoteLimits(args[0], limits, clear)
 	},
 }
 
@@ -629,10 +1082,17 @@ func completeProjectNames(cmd *cobra.Command, args []string, toComplete string)
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 	var names []string
-	for name := range projects {
-		names = append(names, name)
+	for name, entry := range projects {
+		// restore offers archived projects; remove offers everything
+		if cmd.Name() == "restore" {
+			if entry.IsArchived() {
+				names = append(names, name)
+			}
+		} else if cmd.Name() == "remove" || !entry.IsArchived() {
+			names = append(names, name)
+		}
 	}
-	if cmd.Name() == "remove" {
+	if cmd.Name() == "remove" || cmd.Name() == "archive" || cmd.Name() == "restore" {
 		return names, cobra.ShellCompDirectiveNoFileComp
 	}
 	return names, cobra.ShellCompDirectiveDefault
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"codes/internal/config"
@@ -47,9 +48,37 @@ func RunConfigSet(key, value string) {
 			return
 		}
 		ui.ShowSuccess("editor set to: %s", value)
+	case "clone-depth", "cloneDepth", "clone-single-branch", "cloneSingleBranch", "clone-sparse", "cloneSparse":
+		RunCloneDefaultSet(key, value)
+	case "summary-model", "summaryModel":
+		if err := config.SetSummaryModel(value); err != nil {
+			ui.ShowError("Failed to set summary-model", err)
+			return
+		}
+		ui.ShowSuccess("summary-model set to: %s", value)
+	case "prevent-sleep", "preventSleep":
+		var on bool
+		switch strings.ToLower(value) {
+		case "true", "t", "yes", "y", "1", "on":
+			on = true
+		case "false", "f", "no", "n", "0", "off":
+			on = false
+		default:
+			ui.ShowError("Invalid value for prevent-sleep. Must be 'true' or 'false'", nil)
+			return
+		}
+		if err := config.SetPreventSleep(on); err != nil {
+			ui.ShowError("Failed to set prevent-sleep", err)
+			return
+		}
+		ui.ShowSuccess("prevent-sleep set to: %v", on)
+	case "pause-on-battery", "pauseOnBattery", "pause-on-thermal", "pauseOnThermal":
+		RunPowerPolicySet(key, value)
+	case "allowed-workdirs", "allowedWorkdirs", "denied-workdirs", "deniedWorkdirs":
+		RunWorkDirPolicySet(key, value)
 	default:
 		ui.ShowError(fmt.Sprintf("Unknown configuration key: %s", key), nil)
-		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor")
+		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor, clone-depth, clone-single-branch, clone-sparse, summary-model, prevent-sleep, pause-on-battery, pause-on-thermal, allowed-workdirs, denied-workdirs")
 	}
 }
 
@@ -89,6 +118,21 @@ func RunConfigGet(args []string) {
 			editor = "(auto-detect)"
 		}
 		fmt.Printf("  editor: %s\n", editor)
+		clone := config.CloneOptions{}
+		if cfg.CloneDefaults != nil {
+			clone = *cfg.CloneDefaults
+		}
+		fmt.Printf("  clone-depth: %s\n", formatCloneDepth(clone.Depth))
+		fmt.Printf("  clone-single-branch: %v\n", clone.SingleBranch)
+		fmt.Printf("  clone-sparse: %s\n", formatSparsePaths(clone.SparsePaths))
+		fmt.Printf("  summary-model: %s\n", formatSummaryModel(config.GetSummaryModel()))
+		fmt.Printf("  prevent-sleep: %v\n", cfg.PreventSleep)
+		power := config.GetPowerPolicy()
+		fmt.Printf("  pause-on-battery: %s\n", formatMinBattery(power.MinBattery))
+		fmt.Printf("  pause-on-thermal: %v\n", power.PauseOnThermal)
+		policy := config.GetWorkDirPolicy()
+		fmt.Printf("  allowed-workdirs: %s\n", formatPolicyPaths(policy.AllowedRoots, "(anywhere)"))
+		fmt.Printf("  denied-workdirs: %s\n", formatPolicyPaths(policy.DeniedPaths, "(none)"))
 		fmt.Printf("  default: %s\n", cfg.Default)
 		fmt.Printf("  projects: %d configured\n", len(cfg.Projects))
 		return
@@ -111,9 +155,27 @@ func RunConfigGet(args []string) {
 		} else {
 			fmt.Printf("editor: %s\n", editor)
 		}
+	case "clone-depth", "cloneDepth":
+		fmt.Printf("clone-depth: %s\n", formatCloneDepth(config.GetCloneDefaults().Depth))
+	case "clone-single-branch", "cloneSingleBranch":
+		fmt.Printf("clone-single-branch: %v\n", config.GetCloneDefaults().SingleBranch)
+	case "clone-sparse", "cloneSparse":
+		fmt.Printf("clone-sparse: %s\n", formatSparsePaths(config.GetCloneDefaults().SparsePaths))
+	case "summary-model", "summaryModel":
+		fmt.Printf("summary-model: %s\n", formatSummaryModel(config.GetSummaryModel()))
+	case "prevent-sleep", "preventSleep":
+		fmt.Printf("prevent-sleep: %v\n", config.GetPreventSleep())
+	case "pause-on-battery", "pauseOnBattery":
+		fmt.Printf("pause-on-battery: %s\n", formatMinBattery(config.GetPowerPolicy().MinBattery))
+	case "pause-on-thermal", "pauseOnThermal":
+		fmt.Printf("pause-on-thermal: %v\n", config.GetPowerPolicy().PauseOnThermal)
+	case "allowed-workdirs", "allowedWorkdirs":
+		fmt.Printf("allowed-workdirs: %s\n", formatPolicyPaths(config.GetWorkDirPolicy().AllowedRoots, "(anywhere)"))
+	case "denied-workdirs", "deniedWorkdirs":
+		fmt.Printf("denied-workdirs: %s\n", formatPolicyPaths(config.GetWorkDirPolicy().DeniedPaths, "(none)"))
 	default:
 		ui.ShowError(fmt.Sprintf("Unknown configuration key: %s", key), nil)
-		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor")
+		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor, clone-depth, clone-single-branch, clone-sparse, summary-model, prevent-sleep, pause-on-battery, pause-on-thermal, allowed-workdirs, denied-workdirs")
 	}
 }
 
@@ -303,6 +365,31 @@ func RunConfigReset(args []string) {
 		} else {
 			ui.ShowSuccess("editor reset to default (auto-detect)")
 		}
+		if err := config.SetCloneDefaults(config.CloneOptions{}); err != nil {
+			ui.ShowWarning("Failed to reset clone defaults: %v", err)
+		} else {
+			ui.ShowSuccess("clone defaults reset (full clone)")
+		}
+		if err := config.SetSummaryModel(""); err != nil {
+			ui.ShowWarning("Failed to reset summary-model: %v", err)
+		} else {
+			ui.ShowSuccess("summary-model reset to default (%s)", config.DefaultSummaryModel)
+		}
+		if err := config.SetPreventSleep(false); err != nil {
+			ui.ShowWarning("Failed to reset prevent-sleep: %v", err)
+		} else {
+			ui.ShowSuccess("prevent-sleep reset to default (false)")
+		}
+		if err := config.SetPowerPolicy(config.PowerPolicy{}); err != nil {
+			ui.ShowWarning("Failed to reset power policy: %v", err)
+		} else {
+			ui.ShowSuccess("pause-on-battery and pause-on-thermal reset to default (off)")
+		}
+		if err := config.SetWorkDirPolicy(config.WorkDirPolicy{}); err != nil {
+			ui.ShowWarning("Failed to reset work dir policy: %v", err)
+		} else {
+			ui.ShowSuccess("allowed-workdirs and denied-workdirs reset to default (anywhere)")
+		}
 		return
 	}
 
@@ -326,9 +413,29 @@ func RunConfigReset(args []string) {
 		} else {
 			ui.ShowSuccess("editor reset to default (auto-detect)")
 		}
+	case "clone-depth", "cloneDepth", "clone-single-branch", "cloneSingleBranch", "clone-sparse", "cloneSparse":
+		RunCloneDefaultSet(key, "")
+	case "summary-model", "summaryModel":
+		if err := config.SetSummaryModel(""); err != nil {
+			ui.ShowWarning("Failed to reset summary-model: %v", err)
+		} else {
+			ui.ShowSuccess("summary-model reset to default (%s)", config.DefaultSummaryModel)
+		}
+	case "prevent-sleep", "preventSleep":
+		if err := config.SetPreventSleep(false); err != nil {
+			ui.ShowWarning("Failed to reset prevent-sleep: %v", err)
+		} else {
+			ui.ShowSuccess("prevent-sleep reset to default (false)")
+		}
+	case "pause-on-battery", "pauseOnBattery":
+		RunPowerPolicySet(key, "off")
+	case "pause-on-thermal", "pauseOnThermal":
+		RunPowerPolicySet(key, "false")
+	case "allowed-workdirs", "allowedWorkdirs", "denied-workdirs", "deniedWorkdirs":
+		RunWorkDirPolicySet(key, "")
 	default:
 		ui.ShowError(fmt.Sprintf("Unknown configuration key: %s", key), nil)
-		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor")
+		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor, clone-depth, clone-single-branch, clone-sparse, summary-model, prevent-sleep, pause-on-battery, pause-on-thermal, allowed-workdirs, denied-workdirs")
 	}
 }
 
@@ -341,6 +448,15 @@ func RunConfigList(args []string) {
 		fmt.Println("  terminal          Terminal emulator for sessions")
 		fmt.Println("  auto-update       Auto-update check mode (notify, silent, off)")
 		fmt.Println("  editor            Editor command for opening projects")
+		fmt.Println("  clone-depth       Default --depth for git mode clones (0 = full history)")
+		fmt.Println("  clone-single-branch  Clone only the default branch (true, false)")
+		fmt.Println("  clone-sparse      Default sparse-checkout paths (comma-separated)")
+		fmt.Println("  summary-model     Model agents use to summarize long task results")
+		fmt.Println("  prevent-sleep     Keep the machine awake while agents run tasks (true, false)")
+		fmt.Println("  pause-on-battery  Pause task pickup on battery below this percentage (off, 1-100)")
+		fmt.Println("  pause-on-thermal  Pause task pickup under thermal pressure (true, false)")
+		fmt.Println("  allowed-workdirs  Directories teams, tasks and sessions may run in (comma-separated)")
+		fmt.Println("  denied-workdirs   Directories they may never run in (comma-separated)")
 		fmt.Println()
 		fmt.Println("Use 'codes config list <key>' to see available values for a key.")
 		return
@@ -373,9 +489,48 @@ func RunConfigList(args []string) {
 		fmt.Println("  vim      Vim")
 		fmt.Println("  nvim     Neovim")
 		fmt.Println("  <cmd>    Any command that accepts a path argument")
+	case "clone-depth", "cloneDepth":
+		fmt.Println("Available values for clone-depth:")
+		fmt.Println("  0        Full history (default)")
+		fmt.Println("  1        Latest commit only; fastest for huge repositories")
+		fmt.Println("  <n>      Last n commits")
+	case "clone-single-branch", "cloneSingleBranch":
+		fmt.Println("Available values for clone-single-branch:")
+		fmt.Println("  true     Fetch only the default branch")
+		fmt.Println("  false    Fetch all branches (default)")
+	case "clone-sparse", "cloneSparse":
+		fmt.Println("Available values for clone-sparse:")
+		fmt.Println("  <dirs>   Comma-separated directories to check out, e.g. services/api,libs")
+		fmt.Println("  (empty)  Check out the whole tree (default)")
+	case "summary-model", "summaryModel":
+		fmt.Println("Available values for summary-model:")
+		fmt.Println("  haiku    Claude Haiku (default)")
+		fmt.Println("  <model>  Any model name the claude CLI accepts")
+		fmt.Println("  off      Keep only the truncated result")
+	case "prevent-sleep", "preventSleep":
+		fmt.Println("Available values for prevent-sleep:")
+		fmt.Println("  true     Inhibit sleep while any agent runs a task (caffeinate on macOS, systemd-inhibit on Linux)")
+		fmt.Println("  false    Let the machine sleep as usual (default)")
+	case "pause-on-battery", "pauseOnBattery":
+		fmt.Println("Available values for pause-on-battery:")
+		fmt.Println("  off      Work on battery as usual (default)")
+		fmt.Println("  <n>      Leave new tasks queued while on battery below n%")
+		fmt.Println("  100      Leave new tasks queued whenever unplugged")
+	case "pause-on-thermal", "pauseOnThermal":
+		fmt.Println("Available values for pause-on-thermal:")
+		fmt.Println("  true     Leave new tasks queued while the OS reports thermal throttling")
+		fmt.Println("  false    Ignore thermal state (default)")
+	case "allowed-workdirs", "allowedWorkdirs":
+		fmt.Println("Available values for allowed-workdirs:")
+		fmt.Println("  <dirs>   Comma-separated roots, e.g. ~/code,/srv/repos; work dirs must be inside one")
+		fmt.Println("  (empty)  Allow any directory (default)")
+	case "denied-workdirs", "deniedWorkdirs":
+		fmt.Println("Available values for denied-workdirs:")
+		fmt.Println("  <dirs>   Comma-separated paths never to run in, e.g. ~/.ssh,~/.aws")
+		fmt.Println("  (empty)  Deny nothing (default)")
 	default:
 		ui.ShowError(fmt.Sprintf("Unknown configuration key: %s", key), nil)
-		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor")
+		fmt.Println("Available keys: default-behavior, skip-permissions, terminal, auto-update, editor, clone-depth, clone-single-branch, clone-sparse, summary-model, prevent-sleep, pause-on-battery, pause-on-thermal, allowed-workdirs, denied-workdirs")
 	}
 }
 
@@ -515,3 +670,144 @@ func RunTerminalList() {
 		ui.ShowInfo("  codes config set terminal /usr/bin/xterm")
 	}
 }
+
+// RunCloneDefaultSet updates one of the git mode clone defaults. An empty
+// value resets that option.
+func RunCloneDefaultSet(key, value string) {
+	opts := config.GetCloneDefaults()
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "clone-depth", "cloneDepth":
+		depth := 0
+		if value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				ui.ShowError("Invalid value for clone-depth. Must be a non-negative number", nil)
+				return
+			}
+			depth = n
+		}
+		opts.Depth = depth
+	case "clone-single-branch", "cloneSingleBranch":
+		switch strings.ToLower(value) {
+		case "true", "t", "yes", "y", "1":
+			opts.SingleBranch = true
+		case "false", "f", "no", "n", "0", "":
+			opts.SingleBranch = false
+		default:
+			ui.ShowError("Invalid value for clone-single-branch. Must be 'true' or 'false'", nil)
+			return
+		}
+	case "clone-sparse", "cloneSparse":
+		var paths []string
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.Trim(strings.TrimSpace(p), "/"); p != "" {
+				paths = append(paths, p)
+			}
+		}
+		opts.SparsePaths = paths
+	}
+
+	if err := config.SetCloneDefaults(opts); err != nil {
+		ui.ShowError("Failed to save clone defaults", err)
+		return
+	}
+	ui.ShowSuccess("clone defaults: depth=%s, single-branch=%v, sparse=%s",
+		formatCloneDepth(opts.Depth), opts.SingleBranch, formatSparsePaths(opts.SparsePaths))
+}
+
+func formatCloneDepth(depth int) string {
+	if depth <= 0 {
+		return "full"
+	}
+	return strconv.Itoa(depth)
+}
+
+func formatSparsePaths(paths []string) string {
+	if len(paths) == 0 {
+		return "(none)"
+	}
+	return strings.Join(paths, ",")
+}
+
+// formatSummaryModel renders the summary model, "" meaning turned off.
+func formatSummaryModel(model string) string {
+	if model == "" {
+		return "off"
+	}
+	return model
+}
+
+func formatMinBattery(percent int) string {
+	if percent == 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%d%%", percent)
+}
+
+// RunPowerPolicySet sets pause-on-battery or pause-on-thermal.
+func RunPowerPolicySet(key, value string) {
+	policy := config.GetPowerPolicy()
+	switch key {
+	case "pause-on-battery", "pauseOnBattery":
+		v := strings.TrimSuffix(strings.ToLower(value), "%")
+		if v == "off" || v == "" {
+			policy.MinBattery = 0
+		} else {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 100 {
+				ui.ShowError("Invalid value for pause-on-battery. Must be 'off' or a percentage from 1 to 100", nil)
+				return
+			}
+			policy.MinBattery = n
+		}
+	default:
+		switch strings.ToLower(value) {
+		case "true", "t", "yes", "y", "1", "on":
+			policy.PauseOnThermal = true
+		case "false", "f", "no", "n", "0", "off":
+			policy.PauseOnThermal = false
+		default:
+			ui.ShowError("Invalid value for pause-on-thermal. Must be 'true' or 'false'", nil)
+			return
+		}
+	}
+	if err := config.SetPowerPolicy(policy); err != nil {
+		ui.ShowError("Failed to save power policy", err)
+		return
+	}
+	ui.ShowSuccess("pause-on-battery: %s, pause-on-thermal: %v", formatMinBattery(policy.MinBattery), policy.PauseOnThermal)
+}
+
+func formatPolicyPaths(paths []string, empty string) string {
+	if len(paths) == 0 {
+		return empty
+	}
+	return strings.Join(paths, ",")
+}
+
+// RunWorkDirPolicySet sets allowed-workdirs or denied-workdirs from a
+// comma-separated list. An empty value clears the list.
+func RunWorkDirPolicySet(key, value string) {
+	var paths []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	policy := config.GetWorkDirPolicy()
+	switch key {
+	case "allowed-workdirs", "allowedWorkdirs":
+		policy.AllowedRoots = paths
+	default:
+		policy.DeniedPaths = paths
+	}
+	if err := config.SetWorkDirPolicy(policy); err != nil {
+		ui.ShowError("Failed to save work dir policy", err)
+		return
+	}
+	ui.ShowSuccess("allowed-workdirs: %s, denied-workdirs: %s",
+		formatPolicyPaths(policy.AllowedRoots, "(anywhere)"), formatPolicyPaths(policy.DeniedPaths, "(none)"))
+}
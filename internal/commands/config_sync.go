@@ -4,21 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 
 	"codes/internal/config"
 	"codes/internal/ui"
 )
 
-// isSensitive checks if an environment variable key contains sensitive information.
-func isSensitive(key string) bool {
-	upper := strings.ToUpper(key)
-	return strings.Contains(upper, "TOKEN") ||
-		strings.Contains(upper, "KEY") ||
-		strings.Contains(upper, "SECRET") ||
-		strings.Contains(upper, "PASSWORD")
-}
-
 // redactConfig returns a deep copy of the config with sensitive env vars replaced by <REDACTED>.
 func redactConfig(cfg *config.Config) *config.Config {
 	data, err := json.Marshal(cfg)
@@ -32,8 +22,8 @@ func redactConfig(cfg *config.Config) *config.Config {
 
 	for i := range cp.Profiles {
 		for k := range cp.Profiles[i].Env {
-			if isSensitive(k) {
-				cp.Profiles[i].Env[k] = "<REDACTED>"
+			if config.IsSensitiveEnv(k) {
+				cp.Profiles[i].Env[k] = config.RedactedValue
 			}
 		}
 	}
@@ -54,7 +44,7 @@ func mergeConfig(existing, imported *config.Config) {
 					existing.Profiles[i].Env = make(map[string]string)
 				}
 				for k, v := range imp.Env {
-					if v == "<REDACTED>" {
+					if v == config.RedactedValue {
 						continue
 					}
 					existing.Profiles[i].Env[k] = v
@@ -73,7 +63,7 @@ func mergeConfig(existing, imported *config.Config) {
 				Status:          imp.Status,
 			}
 			for k, v := range imp.Env {
-				if v != "<REDACTED>" {
+				if v != config.RedactedValue {
 					cleanProfile.Env[k] = v
 				}
 			}
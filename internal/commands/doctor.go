@@ -9,6 +9,7 @@ import (
 
 	"codes/internal/agent"
 	"codes/internal/config"
+	"codes/internal/trace"
 	"codes/internal/ui"
 )
 
@@ -23,17 +24,19 @@ func RunDoctor() {
 
 	// 1. Check Claude CLI installation
 	fmt.Println("1. Checking Claude CLI...")
-	claudePath, err := exec.LookPath("claude")
+	claudePath, err := config.ClaudePath()
 	if err != nil {
-		ui.ShowError("Claude CLI not found in PATH", err)
-		ui.ShowInfo("Install with: npm install -g @anthropic-ai/claude")
+		ui.ShowError("Claude CLI not found in PATH", nil)
+		ui.ShowInfo("Install with: codes install")
 		failCount++
 	} else {
 		ui.ShowSuccess("Claude CLI found: %s", claudePath)
 
 		// Check version
+		end := trace.Start("exec", "cmd", "claude --version")
 		cmd := exec.Command("claude", "--version")
 		output, err := cmd.Output()
+		end()
 		if err == nil {
 			version := strings.TrimSpace(string(output))
 			ui.ShowInfo("Version: %s", version)
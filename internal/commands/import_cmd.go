@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"codes/internal/config"
+	"codes/internal/output"
+	"codes/internal/ui"
+)
+
+// RunImportVSCode imports folders from VS Code workspace files, or from
+// VS Code's recently opened workspaces when no files are given.
+func RunImportVSCode(files []string, yes bool) {
+	discovered, err := config.DiscoverVSCodeProjects(files)
+	runImport("VS Code", discovered, err, yes)
+}
+
+// RunImportZoxide imports git repositories from the zoxide/z database.
+func RunImportZoxide(minScore float64, yes bool) {
+	discovered, err := config.DiscoverZoxideProjects(minScore)
+	runImport("zoxide", discovered, err, yes)
+}
+
+// RunImportGitHub imports local clones of the repositories listed by
+// `gh repo list`.
+func RunImportGitHub(owner, dir string, yes bool) {
+	ui.ShowLoading("Listing GitHub repositories...")
+	discovered, err := config.DiscoverGitHubProjects(owner, dir)
+	runImport("GitHub", discovered, err, yes)
+}
+
+// runImport shows the discovered directories that are not yet registered and
+// imports them after confirmation. In JSON mode nothing is imported unless
+// yes is set.
+func runImport(source string, discovered []config.DiscoveredProject, err error, yes bool) {
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError(fmt.Sprintf("Failed to read %s projects", source), err)
+		return
+	}
+
+	candidates := newImportCandidates(discovered)
+
+	if output.JSONMode {
+		result := map[string]any{"source": source, "candidates": importPaths(candidates), "added": 0, "skipped": len(discovered) - len(candidates)}
+		if yes && len(candidates) > 0 {
+			added, _, err := config.ImportDiscoveredProjects(candidates)
+			if err != nil {
+				output.PrintError(err)
+				return
+			}
+			result["added"] = added
+		}
+		output.Print(result, nil)
+		return
+	}
+
+	if len(candidates) == 0 {
+		if len(discovered) == 0 {
+			ui.ShowInfo("No %s projects found", source)
+		} else {
+			ui.ShowInfo("All %d %s project(s) are already configured", len(discovered), source)
+		}
+		return
+	}
+
+	fmt.Println()
+	ui.ShowHeader(fmt.Sprintf("Import from %s", source))
+	fmt.Println()
+	for i, p := range candidates {
+		ui.ShowInfo("%d. %s -> %s", i+1, p.Name, p.Path)
+	}
+	if skipped := len(discovered) - len(candidates); skipped > 0 {
+		ui.ShowInfo("(%d already configured)", skipped)
+	}
+	fmt.Println()
+
+	if !yes {
+		fmt.Printf("Import %d project(s)? (y/N): ", len(candidates))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			ui.ShowInfo("Import cancelled")
+			return
+		}
+	}
+
+	added, _, err := config.ImportDiscoveredProjects(candidates)
+	if err != nil {
+		ui.ShowError("Failed to import projects", err)
+		return
+	}
+	ui.ShowSuccess("Imported %d project(s) from %s", added, source)
+}
+
+// newImportCandidates drops directories that are already registered.
+func newImportCandidates(discovered []config.DiscoveredProject) []config.DiscoveredProject {
+	projects, _ := config.ListProjects()
+	existing := make(map[string]bool, len(projects))
+	for _, entry := range projects {
+		existing[entry.Path] = true
+	}
+	var candidates []config.DiscoveredProject
+	for _, p := range discovered {
+		if !existing[p.Path] {
+			candidates = append(candidates, p)
+		}
+	}
+	return candidates
+}
+
+func importPaths(projects []config.DiscoveredProject) []map[string]string {
+	out := make([]map[string]string, 0, len(projects))
+	for _, p := range projects {
+		out = append(out, map[string]string{"name": p.Name, "path": p.Path})
+	}
+	return out
+}
@@ -58,9 +58,9 @@ func RunInit(autoYes bool) {
 
 	// 4. Check if Claude CLI is installed
 	ui.ShowInfo("Checking Claude CLI installation...")
-	if _, err := exec.LookPath("claude"); err != nil {
+	if !config.ClaudeAvailable() {
 		ui.ShowError("Claude CLI not found", nil)
-		ui.ShowWarning("  Run 'codes claude update' to install Claude CLI")
+		ui.ShowWarning("  Run 'codes install' to install Claude CLI")
 		allGood = false
 	} else {
 		ui.ShowSuccess("Claude CLI is installed")
@@ -284,8 +284,8 @@ func RunInit(autoYes bool) {
 		if _, err := exec.LookPath("git"); err != nil {
 			ui.ShowInfo("  1. Install Git")
 		}
-		if _, err := exec.LookPath("claude"); err != nil {
-			ui.ShowInfo("  2. Install Claude CLI: codes claude update")
+		if !config.ClaudeAvailable() {
+			ui.ShowInfo("  2. Install Claude CLI: codes install")
 		}
 		if _, err := os.Stat(config.ConfigPath); err != nil {
 			ui.ShowInfo("  3. Add a configuration: codes profile add")
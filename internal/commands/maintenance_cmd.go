@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+
+	"codes/internal/maintenance"
+	"codes/internal/output"
+	"codes/internal/ui"
+)
+
+// RunMaintenance runs the maintenance steps now and prints what they did.
+func RunMaintenance() {
+	if !output.JSONMode {
+		ui.ShowLoading("Running maintenance")
+	}
+	r := maintenance.Run(nil)
+	if output.JSONMode {
+		output.Print(r, nil)
+		return
+	}
+	for _, s := range r.Steps {
+		switch {
+		case s.Error != "":
+			ui.ShowWarning("%s: %s", s.Name, s.Error)
+		case s.Summary != "":
+			ui.ShowSuccess("%s: %s", s.Name, s.Summary)
+		default:
+			ui.ShowInfo("%s: nothing to do", s.Name)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	mcpserver "codes/internal/mcp"
+	"codes/internal/output"
+	"codes/internal/ui"
+)
+
+// RunMcpHistory prints the saved MCP tool calls matching the filters, or
+// writes them to exportPath. importPath, if set, is merged in first.
+func RunMcpHistory(tool string, errorsOnly bool, limit int, full bool, exportPath, importPath string) {
+	if importPath != "" {
+		added, err := mcpserver.ImportToolHistory(importPath)
+		if err != nil {
+			ui.ShowError("Failed to import tool history", err)
+			return
+		}
+		ui.ShowSuccess("Imported %d tool calls from %s", added, importPath)
+	}
+
+	calls := mcpserver.FilterToolCalls(mcpserver.ToolHistory(), tool, errorsOnly, limit)
+	if exportPath != "" {
+		if err := mcpserver.WriteToolHistory(exportPath, calls); err != nil {
+			ui.ShowError("Failed to export tool history", err)
+			return
+		}
+		ui.ShowSuccess("Exported %d tool calls to %s", len(calls), exportPath)
+		return
+	}
+	if output.JSONMode {
+		if calls == nil {
+			calls = []mcpserver.ToolCall{}
+		}
+		output.Print(calls, nil)
+		return
+	}
+	if len(calls) == 0 {
+		path, _ := mcpserver.ToolHistoryPath()
+		ui.ShowInfo("No MCP tool calls recorded (%s is written when the MCP server shuts down)", path)
+		return
+	}
+	for _, c := range calls {
+		result := "ok"
+		if c.IsError {
+			result = "error"
+		}
+		client := c.Client
+		if client == "" {
+			client = "-"
+		}
+		fmt.Printf("%s  %-20s %-26s %-5s %6dms\n", c.Time.Local().Format("2006-01-02 15:04:05"), client, c.Tool, result, c.DurationMs)
+		if c.Error != "" {
+			fmt.Printf("    error: %s\n", c.Error)
+		}
+		if full {
+			if c.Input != "" {
+				fmt.Printf("    input:  %s\n", c.Input)
+			}
+			if c.Output != "" {
+				fmt.Printf("    output: %s\n", strings.ReplaceAll(c.Output, "\n", "\n            "))
+			}
+		}
+	}
+}
@@ -25,6 +25,11 @@ Supported formats:
   telegram   Telegram Bot API (requires --extra chat_id=<id>)
   custom     Custom JSON template (requires --extra template="<json>")
 
+Custom templates can use {{.Title}}, {{.Message}} and {{.Text}}, and for task
+events {{.Team}}, {{.TaskID}}, {{.Subject}}, {{.Status}}, {{.Agent}},
+{{.Duration}}, {{.DurationSeconds}}, {{.CostUSD}}, {{.FilesChanged}} and
+{{.Branch}} (zero or empty when unknown).
+
 Examples:
   codes notify add https://hooks.slack.com/xxx
   codes notify add https://oapi.dingtalk.com/robot/send?token=xxx -f dingtalk
@@ -79,10 +84,21 @@ var hookCmd = &cobra.Command{
 	Long: `Manage shell hook scripts that execute on task events.
 
 Available events:
-  on_task_completed   Triggered when an agent task completes successfully
-  on_task_failed      Triggered when an agent task fails
-
-Hook scripts receive a JSON payload via stdin with task details.`,
+  on_task_started      An agent starts a task
+  on_task_completed    An agent task completes successfully
+  on_task_failed       An agent task fails
+  on_task_cancelled    An agent task is cancelled
+  on_agent_started     An agent daemon starts
+  on_agent_stopped     An agent daemon stops
+  on_team_created      A team is created
+  on_team_idle         A team's idle policy stops its agents
+  on_session_started   A Claude session is opened (API chat or terminal)
+  on_update_available  A newer codes release is found
+
+Hook scripts receive a JSON payload via stdin: task details for the finished
+task events (hook.v1.json), and {"event", "timestamp"} plus a "task",
+"agent", "team", "session" or "update" object for the others
+(hook-event.v1.json). Both schemas are served by codes serve at /schemas/.`,
 }
 
 // hookSetCmd sets a hook for an event.
@@ -91,10 +107,10 @@ var hookSetCmd = &cobra.Command{
 	Short: "Set a hook script for an event",
 	Long: `Set a shell script to execute when the specified event occurs.
 
-Valid events: on_task_completed, on_task_failed
+See 'codes notify hook --help' for the events and their payloads.
 
-The script must exist and be executable. It will receive a JSON payload
-via stdin containing: team, taskId, subject, status, agent, result/error, timestamp.`,
+The script must exist and be executable. It runs with a 30 second timeout
+and receives the event's JSON payload via stdin.`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		RunHookSet(args[0], args[1])
@@ -6,39 +6,13 @@ import (
 	"codes/internal/output"
 	"codes/internal/ui"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 )
 
 // RunNotifyAdd adds a webhook configuration.
 func RunNotifyAdd(url, name, format string, events []string, extra map[string]string) {
-	// Validate URL
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		ui.ShowError("Invalid webhook URL: must start with http:// or https://", nil)
-		return
-	}
-
-	// Validate format
-	validFormats := map[string]bool{"slack": true, "feishu": true, "dingtalk": true, "telegram": true, "custom": true}
-	if !validFormats[format] {
-		ui.ShowError("Invalid format: must be one of 'slack', 'feishu', 'dingtalk', 'telegram', 'custom'", nil)
-		return
-	}
-
-	// Validate format-specific requirements
-	if format == "telegram" {
-		if extra == nil || extra["chat_id"] == "" {
-			ui.ShowError("Telegram format requires --extra chat_id=<id>", nil)
-			return
-		}
-	}
-	if format == "custom" {
-		if extra == nil || extra["template"] == "" {
-			ui.ShowError("Custom format requires --extra template=\"<json>\"", nil)
-			return
-		}
-	}
-
 	webhook := config.WebhookConfig{
 		Name:   name,
 		URL:    url,
@@ -46,6 +20,10 @@ func RunNotifyAdd(url, name, format string, events []string, extra map[string]st
 		Events: events,
 		Extra:  extra,
 	}
+	if err := config.ValidateWebhook(webhook); err != nil {
+		ui.ShowError("Invalid webhook", err)
+		return
+	}
 
 	if err := config.AddWebhook(webhook); err != nil {
 		ui.ShowError("Failed to add webhook", err)
@@ -230,7 +208,7 @@ func RunHookList() {
 		fmt.Println("No hooks configured")
 		fmt.Println("\nSet a hook with:")
 		fmt.Println("  codes notify hook set <event> <script-path>")
-		fmt.Println("\nAvailable events: on_task_completed, on_task_failed")
+		fmt.Printf("\nAvailable events: %s\n", strings.Join(config.HookEvents, ", "))
 		return
 	}
 
@@ -250,22 +228,8 @@ func RunHookTest(event string) {
 
 	ui.ShowInfo("Testing hook for %s: %s", event, scriptPath)
 
-	payload := notify.HookPayload{
-		Team:      "test-team",
-		TaskID:    0,
-		Subject:   "Test task (hook test)",
-		Status:    strings.TrimPrefix(event, "on_task_"),
-		Agent:     "test-agent",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-	}
-	if event == "on_task_completed" {
-		payload.Result = "Test result from hook test"
-	} else {
-		payload.Error = "Test error from hook test"
-	}
-
 	runner := notify.NewHookRunner(scriptPath)
-	err := runner.Execute(payload)
+	err := runner.Execute(sampleHookPayload(event))
 
 	if err != nil {
 		if output.JSONMode {
@@ -291,6 +255,50 @@ func RunHookTest(event string) {
 	ui.ShowSuccess("Hook test successful!")
 }
 
+// sampleHookPayload returns a made-up payload of the kind event's hook
+// receives.
+func sampleHookPayload(event string) any {
+	now := time.Now().UTC().Format(time.RFC3339)
+	switch event {
+	case "on_task_completed", "on_task_failed", "on_task_cancelled":
+		payload := notify.HookPayload{
+			Event:     event,
+			Team:      "test-team",
+			TaskID:    1,
+			Subject:   "Test task (hook test)",
+			Status:    strings.TrimPrefix(event, "on_task_"),
+			Agent:     "test-agent",
+			Timestamp: now,
+		}
+		if event == "on_task_completed" {
+			payload.Result = "Test result from hook test"
+		} else {
+			payload.Error = "Test error from hook test"
+		}
+		return payload
+	}
+
+	e := notify.HookEvent{Event: event, Timestamp: now}
+	switch event {
+	case "on_task_started":
+		e.Task = &notify.HookTask{Team: "test-team", ID: 1, Subject: "Test task (hook test)", Agent: "test-agent"}
+	case "on_agent_started", "on_agent_stopped":
+		e.Agent = &notify.HookAgent{Team: "test-team", Name: "test-agent", PID: os.Getpid()}
+		if event == "on_agent_stopped" {
+			e.Agent.Reason = "stop_requested"
+		}
+	case "on_team_created":
+		e.Team = &notify.HookTeam{Name: "test-team", Description: "Team from hook test"}
+	case "on_team_idle":
+		e.Team = &notify.HookTeam{Name: "test-team", IdleMinutes: 30, StoppedAgents: []string{"test-agent"}}
+	case "on_session_started":
+		e.Session = &notify.HookSession{ID: "test-session", Kind: "chat", Project: "test-project", Path: os.TempDir()}
+	case "on_update_available":
+		e.Update = &notify.HookUpdate{Current: Version, Latest: "v99.0.0"}
+	}
+	return e
+}
+
 // formatExtra formats a map as "key=value, key=value".
 func formatExtra(extra map[string]string) string {
 	parts := make([]string, 0, len(extra))
@@ -1,15 +1,27 @@
 package commands
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"codes/internal/config"
 	"codes/internal/ui"
 )
 
-// RunTest tests API configurations.
-func RunTest(args []string) {
+// RunTest tests API configurations. Without a name every profile is tested,
+// concurrency at a time; sortBy orders the summary table.
+func RunTest(args []string, concurrency int, sortBy string) {
+	if !slices.Contains(profileTestSorts, sortBy) {
+		ui.ShowError(fmt.Sprintf("Invalid --sort %q: must be one of %s", sortBy, strings.Join(profileTestSorts, ", ")), nil)
+		return
+	}
+
 	ui.ShowHeader("API Configuration Test")
 	fmt.Println()
 
@@ -43,15 +55,15 @@ func RunTest(args []string) {
 		ui.ShowInfo("Testing configuration: %s", configName)
 		testSingleConfiguration(targetConfig)
 	} else {
-		ui.ShowInfo("Testing all %d configurations...", len(cfg.Profiles))
-		testAllConfigurations(cfg.Profiles)
+		ui.ShowInfo("Testing all %d configurations, %d at a time...", len(cfg.Profiles), max(concurrency, 1))
+		if !testAllConfigurations(cfg.Profiles, cfg.Default, concurrency, sortBy) {
+			os.Exit(1)
+		}
 	}
 }
 
-// testSingleConfiguration tests a single API configuration.
-func testSingleConfiguration(apiConfig *config.APIConfig) {
-	fmt.Println()
-
+// testModel returns the model a profile test request uses.
+func testModel(apiConfig *config.APIConfig) string {
 	envVars := config.GetEnvironmentVars(apiConfig)
 	model := envVars["ANTHROPIC_MODEL"]
 	if model == "" {
@@ -60,8 +72,15 @@ func testSingleConfiguration(apiConfig *config.APIConfig) {
 			model = "claude-3-haiku-20240307"
 		}
 	}
+	return model
+}
 
-	ui.ShowInfo("Model: %s", model)
+// testSingleConfiguration tests a single API configuration.
+func testSingleConfiguration(apiConfig *config.APIConfig) {
+	fmt.Println()
+
+	envVars := config.GetEnvironmentVars(apiConfig)
+	ui.ShowInfo("Model: %s", testModel(apiConfig))
 	ui.ShowInfo("API: %s", envVars["ANTHROPIC_BASE_URL"])
 
 	ui.ShowLoading("Testing API connection...")
@@ -77,61 +96,124 @@ func testSingleConfiguration(apiConfig *config.APIConfig) {
 		ui.ShowWarning("Check your configuration and network connectivity")
 	}
 
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		ui.ShowError("Error loading config for update", err)
-		return
+	saveProfileStatuses(map[string]string{apiConfig.Name: apiConfig.Status})
+}
+
+// profileTestSorts are the orders `codes profile test --sort` accepts.
+var profileTestSorts = []string{"name", "latency", "status"}
+
+// profileTestResult is the outcome of testing one profile.
+type profileTestResult struct {
+	Name    string
+	Model   string
+	URL     string
+	OK      bool
+	Latency time.Duration
+}
+
+// testProfiles runs test on every profile, at most concurrency at a time,
+// and calls onResult (serially) as each one finishes. Results are returned in
+// profile order.
+func testProfiles(configs []config.APIConfig, concurrency int, test func(config.APIConfig) bool, onResult func(profileTestResult)) []profileTestResult {
+	results := make([]profileTestResult, len(configs))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := range configs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			ok := test(configs[i])
+			r := profileTestResult{
+				Name:    configs[i].Name,
+				Model:   testModel(&configs[i]),
+				URL:     config.GetEnvironmentVars(&configs[i])["ANTHROPIC_BASE_URL"],
+				OK:      ok,
+				Latency: time.Since(start),
+			}
+			mu.Lock()
+			results[i] = r
+			onResult(r)
+			mu.Unlock()
+		}(i)
 	}
+	wg.Wait()
+	return results
+}
 
-	for i := range cfg.Profiles {
-		if cfg.Profiles[i].Name == apiConfig.Name {
-			cfg.Profiles[i].Status = apiConfig.Status
-			break
+// sortProfileResults orders results by name, by latency (fastest first) or
+// by status (failures first), breaking ties by name.
+func sortProfileResults(results []profileTestResult, sortBy string) {
+	slices.SortStableFunc(results, func(a, b profileTestResult) int {
+		var c int
+		switch sortBy {
+		case "latency":
+			c = cmp.Compare(a.Latency, b.Latency)
+		case "status":
+			c = cmp.Compare(boolRank(a.OK), boolRank(b.OK))
 		}
-	}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		return c
+	})
+}
 
-	if err := config.SaveConfig(cfg); err != nil {
-		ui.ShowError("Failed to save config status", err)
+func boolRank(b bool) int {
+	if b {
+		return 1
 	}
+	return 0
 }
 
-// testAllConfigurations tests all API configurations.
-func testAllConfigurations(configs []config.APIConfig) {
-	results := make(map[string]bool)
-	statuses := make(map[string]string)
-	successCount := 0
-
+// testAllConfigurations tests every profile concurrently, printing each
+// result as it arrives and then a summary table, and saves their statuses.
+// It reports false if the default profile failed.
+func testAllConfigurations(configs []config.APIConfig, defaultName string, concurrency int, sortBy string) bool {
 	fmt.Println()
-	for i := range configs {
-		fmt.Printf("Testing %s...", configs[i].Name)
-
-		envVars := config.GetEnvironmentVars(&configs[i])
-		model := envVars["ANTHROPIC_MODEL"]
-		if model == "" {
-			model = envVars["ANTHROPIC_DEFAULT_HAIKU_MODEL"]
-			if model == "" {
-				model = "claude-3-haiku-20240307"
-			}
+	results := testProfiles(configs, concurrency, config.TestAPIConfig, func(r profileTestResult) {
+		mark := "✓"
+		if !r.OK {
+			mark = "✗"
 		}
+		fmt.Printf("  %s %s (Model: %s, Latency: %dms)\n", mark, r.Name, r.Model, r.Latency.Milliseconds())
+	})
+	sortProfileResults(results, sortBy)
 
-		start := time.Now()
-		success := config.TestAPIConfig(configs[i])
-		latency := time.Since(start)
-		results[configs[i].Name] = success
-
-		if success {
-			fmt.Printf(" ✓ (Model: %s, Latency: %dms)\n", model, latency.Milliseconds())
-			statuses[configs[i].Name] = "active"
+	successCount := 0
+	defaultOK := true
+	statuses := make(map[string]string, len(results))
+	for _, r := range results {
+		statuses[r.Name] = "inactive"
+		if r.OK {
+			statuses[r.Name] = "active"
 			successCount++
-		} else {
-			fmt.Printf(" ✗ (Model: %s, Latency: %dms)\n", model, latency.Milliseconds())
-			statuses[configs[i].Name] = "inactive"
+		} else if r.Name == defaultName {
+			defaultOK = false
 		}
 	}
 
 	fmt.Println()
 	ui.ShowHeader("Test Results")
-	fmt.Printf("Successfully tested: %d/%d\n", successCount, len(configs))
+	fmt.Println()
+	fmt.Printf("  %-6s %-24s %-10s %-32s %s\n", "STATUS", "PROFILE", "LATENCY", "MODEL", "API")
+	for _, r := range results {
+		status := "ok"
+		if !r.OK {
+			status = "FAIL"
+		}
+		name := r.Name
+		if name == defaultName {
+			name += " *"
+		}
+		fmt.Printf("  %-6s %-24s %-10s %-32s %s\n", status, name, fmt.Sprintf("%dms", r.Latency.Milliseconds()), r.Model, r.URL)
+	}
+	fmt.Println()
+	fmt.Printf("Successfully tested: %d/%d (* = default)\n", successCount, len(configs))
 
 	if successCount == len(configs) {
 		ui.ShowSuccess("All configurations are working!")
@@ -142,31 +224,28 @@ func testAllConfigurations(configs []config.APIConfig) {
 		ui.ShowWarning("Some configurations failed")
 		ui.ShowInfo("Use 'codes profile test <config-name>' to test individual configurations")
 	}
-
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		ui.ShowError("Error loading config for update", err)
-		return
+	if !defaultOK {
+		ui.ShowError(fmt.Sprintf("The default profile '%s' failed", defaultName), nil)
 	}
 
-	updated := false
-	for i := range cfg.Profiles {
-		if newStatus, ok := statuses[cfg.Profiles[i].Name]; ok {
-			if cfg.Profiles[i].Status != newStatus {
+	saveProfileStatuses(statuses)
+	return defaultOK
+}
+
+// saveProfileStatuses records test results (profile name → "active" or
+// "inactive") in the config.
+func saveProfileStatuses(statuses map[string]string) {
+	err := config.UpdateConfig(func(cfg *config.Config) error {
+		for i := range cfg.Profiles {
+			if newStatus, ok := statuses[cfg.Profiles[i].Name]; ok {
 				cfg.Profiles[i].Status = newStatus
-				updated = true
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		ui.ShowError("Failed to save config status", err)
 	}
-
-	if updated {
-		if err := config.SaveConfig(cfg); err != nil {
-			ui.ShowError("Failed to save config status", err)
-		}
-	}
-
-	// suppress unused variable warning
-	_ = results
 }
 
 // RunProfileList lists all profiles and their status.
@@ -216,40 +295,18 @@ func RunProfileList() {
 
 // RunProfileRemove removes a named profile.
 func RunProfileRemove(name string) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		ui.ShowError("Error loading config", err)
-		return
-	}
-
-	found := -1
-	for i, c := range cfg.Profiles {
-		if c.Name == name {
-			found = i
-			break
-		}
-	}
-
-	if found == -1 {
+	newDefault, err := config.RemoveProfile(name)
+	if errors.Is(err, config.ErrProfileNotFound) {
 		ui.ShowError(fmt.Sprintf("Profile '%s' not found", name), nil)
 		return
 	}
-
-	cfg.Profiles = append(cfg.Profiles[:found], cfg.Profiles[found+1:]...)
-
-	if cfg.Default == name {
-		if len(cfg.Profiles) > 0 {
-			cfg.Default = cfg.Profiles[0].Name
-			ui.ShowInfo("Default profile switched to: %s", cfg.Default)
-		} else {
-			cfg.Default = ""
-		}
-	}
-
-	if err := config.SaveConfig(cfg); err != nil {
+	if err != nil {
 		ui.ShowError("Failed to save config", err)
 		return
 	}
+	if newDefault != "" {
+		ui.ShowInfo("Default profile switched to: %s", newDefault)
+	}
 
 	ui.ShowSuccess("Profile '%s' removed successfully!", name)
 }
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"codes/internal/config"
+)
+
+// TestTestProfiles verifies the concurrency bound and that results come back
+// in profile order.
+func TestTestProfiles(t *testing.T) {
+	configs := []config.APIConfig{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+	var running, peak atomic.Int32
+	test := func(c config.APIConfig) bool {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return c.Name != "c"
+	}
+
+	var streamed []string
+	results := testProfiles(configs, 2, test, func(r profileTestResult) {
+		streamed = append(streamed, r.Name)
+	})
+
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+	if len(streamed) != len(configs) {
+		t.Errorf("streamed %v, want every profile", streamed)
+	}
+	for i, r := range results {
+		if r.Name != configs[i].Name || r.OK != (r.Name != "c") {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+}
+
+// TestSortProfileResults verifies the summary orders.
+func TestSortProfileResults(t *testing.T) {
+	results := []profileTestResult{
+		{Name: "b", OK: true, Latency: 30 * time.Millisecond},
+		{Name: "c", OK: false, Latency: 10 * time.Millisecond},
+		{Name: "a", OK: true, Latency: 20 * time.Millisecond},
+		{Name: "d", OK: false, Latency: 40 * time.Millisecond},
+	}
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"name", []string{"a", "b", "c", "d"}},
+		{"latency", []string{"c", "a", "b", "d"}},
+		{"status", []string{"c", "d", "a", "b"}},
+	}
+	for _, tt := range tests {
+		sorted := slices.Clone(results)
+		sortProfileResults(sorted, tt.sortBy)
+		var names []string
+		for _, r := range sorted {
+			names = append(names, r.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("sort by %s = %v, want %v", tt.sortBy, names, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"codes/internal/config"
 	"codes/internal/output"
@@ -68,9 +69,16 @@ func RunProjectRemove(name string) {
 	ui.ShowSuccess("Project '%s' removed successfully!", name)
 }
 
-// RunProjectList lists all configured projects.
-func RunProjectList() {
-	projects, err := config.ListProjects()
+// RunProjectList lists configured projects. Archived projects are only
+// included when all is set.
+func RunProjectList(all bool, tag string) {
+	var projects map[string]config.ProjectEntry
+	var err error
+	if all {
+		projects, err = config.ListProjects()
+	} else {
+		projects, err = config.ListActiveProjects()
+	}
 	if err != nil {
 		if output.JSONMode {
 			output.PrintError(err)
@@ -79,6 +87,14 @@ func RunProjectList() {
 		ui.ShowError("Failed to load projects", err)
 		return
 	}
+	if tag != "" {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		for name, entry := range projects {
+			if !entry.HasTag(tag) {
+				delete(projects, name)
+			}
+		}
+	}
 
 	if output.JSONMode {
 		infos := make([]config.ProjectInfo, 0, len(projects))
@@ -101,12 +117,22 @@ func RunProjectList() {
 
 	i := 1
 	for name, entry := range projects {
-		if entry.Remote != "" {
-			ui.ShowInfo("%d. %s -> %s @ %s", i, name, entry.Path, entry.Remote)
+		label := name
+		if entry.Favorite {
+			label = "★ " + name
+		}
+		tags := ""
+		if len(entry.Tags) > 0 {
+			tags = " [" + strings.Join(entry.Tags, ", ") + "]"
+		}
+		if entry.IsArchived() {
+			ui.ShowInfo("%d. %s -> %s (archived %s)%s", i, label, entry.Path, entry.Archived.At.Format("2006-01-02"), tags)
+		} else if entry.Remote != "" {
+			ui.ShowInfo("%d. %s -> %s @ %s%s", i, label, entry.Path, entry.Remote, tags)
 		} else if _, err := os.Stat(entry.Path); os.IsNotExist(err) {
-			ui.ShowWarning("%d. %s -> %s (not found)", i, name, entry.Path)
+			ui.ShowWarning("%d. %s -> %s (not found)%s", i, label, entry.Path, tags)
 		} else {
-			ui.ShowInfo("%d. %s -> %s", i, name, entry.Path)
+			ui.ShowInfo("%d. %s -> %s%s", i, label, entry.Path, tags)
 		}
 		i++
 	}
@@ -115,6 +141,111 @@ func RunProjectList() {
 	ui.ShowInfo("Start a project with: codes start <name>")
 }
 
+// RunProjectArchive hides a project from default lists, optionally
+// compressing its local directory.
+func RunProjectArchive(name string, compress bool) {
+	entry, err := config.ArchiveProject(name, compress)
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to archive project", err)
+		return
+	}
+
+	if output.JSONMode {
+		output.Print(config.GetProjectInfoFromEntry(name, entry), nil)
+		return
+	}
+
+	ui.ShowSuccess("Project '%s' archived", name)
+	if entry.Archived.Tarball != "" {
+		ui.ShowInfo("Directory compressed to %s", entry.Archived.Tarball)
+	}
+	ui.ShowInfo("Restore with: codes project restore %s", name)
+}
+
+// RunProjectRestore un-archives a project.
+func RunProjectRestore(name string) {
+	entry, err := config.RestoreProject(name)
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to restore project", err)
+		return
+	}
+
+	if output.JSONMode {
+		output.Print(config.GetProjectInfoFromEntry(name, entry), nil)
+		return
+	}
+
+	ui.ShowSuccess("Project '%s' restored", name)
+	ui.ShowInfo("Path: %s", entry.Path)
+}
+
+// RunProjectTag adds and removes a project's tags, or shows them when
+// there are none to change.
+func RunProjectTag(name string, add, remove []string) {
+	var entry config.ProjectEntry
+	var err error
+	if len(add) == 0 && len(remove) == 0 {
+		var projects map[string]config.ProjectEntry
+		projects, err = config.ListProjects()
+		if err == nil {
+			var ok bool
+			if entry, ok = projects[name]; !ok {
+				err = fmt.Errorf("project '%s' not found", name)
+			}
+		}
+	} else {
+		entry, err = config.TagProject(name, add, remove)
+	}
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to tag project", err)
+		return
+	}
+
+	if output.JSONMode {
+		output.Print(config.GetProjectInfoFromEntry(name, entry), nil)
+		return
+	}
+	if len(entry.Tags) == 0 {
+		ui.ShowInfo("Project '%s' has no tags", name)
+		return
+	}
+	ui.ShowSuccess("Project '%s' tags: %s", name, strings.Join(entry.Tags, ", "))
+}
+
+// RunProjectFavorite pins or unpins a project.
+func RunProjectFavorite(name string, favorite bool) {
+	if err := config.SetProjectFavorite(name, favorite); err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to update project", err)
+		return
+	}
+
+	if output.JSONMode {
+		output.Print(map[string]any{"name": name, "favorite": favorite}, nil)
+		return
+	}
+	if favorite {
+		ui.ShowSuccess("Project '%s' pinned as a favorite", name)
+	} else {
+		ui.ShowSuccess("Project '%s' unpinned", name)
+	}
+}
+
 // RunProjectScan scans for existing Claude Code projects and imports them.
 func RunProjectScan() {
 	ui.ShowLoading("Scanning ~/.claude/projects/...")
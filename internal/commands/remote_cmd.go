@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"codes/internal/config"
@@ -18,16 +21,58 @@ func parseSSHAddress(address string) (user, host string) {
 	return "", address
 }
 
+// ensureHostTrusted checks the remote's host key against known_hosts. For an
+// unknown host it shows the offered fingerprints and asks before trusting them
+// (trust on first use). In JSON mode or without a terminal it refuses instead.
+func ensureHostTrusted(host *config.RemoteHost) error {
+	err := remote.CheckHostKey(host)
+	var unknown *remote.HostKeyUnknownError
+	if !errors.As(err, &unknown) {
+		if err == nil && !output.JSONMode && remote.IsSecurityKeyIdentity(host.Identity) {
+			ui.ShowInfo("Using security key %s — touch it when it blinks", host.Identity)
+		}
+		return err
+	}
+	if output.JSONMode || isStdinPipe() {
+		return unknown
+	}
+	if !confirmHostKey(unknown) {
+		return fmt.Errorf("host key for %s not trusted", unknown.Address)
+	}
+	if err := remote.TrustHostKeys(host, unknown.Keys); err != nil {
+		return fmt.Errorf("failed to update known_hosts: %w", err)
+	}
+	ui.ShowSuccess("Added %s to known_hosts", unknown.Address)
+	return nil
+}
+
+// confirmHostKey prints the fingerprints of an unknown host and asks the user
+// to accept them. Anything other than an explicit "yes"/"y" declines.
+func confirmHostKey(unknown *remote.HostKeyUnknownError) bool {
+	ui.ShowWarning("The authenticity of host '%s' can't be established.", unknown.Address)
+	for _, fp := range unknown.Fingerprints {
+		ui.ShowInfo("  %s", fp)
+	}
+	ui.ShowInfo("Compare these fingerprints with the server's before continuing.")
+	fmt.Print("Trust this host and add it to known_hosts? (yes/no): ")
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == "y"
+}
+
 // RunRemoteAdd adds a new remote host.
-func RunRemoteAdd(name, address string, port int, identity string) {
+// skProvider sets ssh's SecurityKeyProvider for FIDO2 identities (empty = ssh default).
+func RunRemoteAdd(name, address string, port int, identity, skProvider string) {
 	user, host := parseSSHAddress(address)
 
 	rh := config.RemoteHost{
-		Name:     name,
-		Host:     host,
-		User:     user,
-		Port:     port,
-		Identity: identity,
+		Name:                name,
+		Host:                host,
+		User:                user,
+		Port:                port,
+		Identity:            identity,
+		SecurityKeyProvider: skProvider,
 	}
 
 	if output.JSONMode {
@@ -50,8 +95,53 @@ func RunRemoteAdd(name, address string, port int, identity string) {
 		ui.ShowInfo("Port: %d", port)
 	}
 	if identity != "" {
-		ui.ShowInfo("Identity: %s", identity)
+		if remote.IsSecurityKeyIdentity(identity) {
+			ui.ShowInfo("Identity: %s (security key)", identity)
+		} else {
+			ui.ShowInfo("Identity: %s", identity)
+		}
+	}
+	if skProvider != "" {
+		ui.ShowInfo("Security key provider: %s", skProvider)
+	}
+	ui.ShowInfo("Verify the host key with: codes remote trust %s", name)
+}
+
+// RunRemoteTrust shows a remote's host key fingerprints and adds them to
+// known_hosts once confirmed. yes skips the prompt for scripted setups.
+func RunRemoteTrust(name string, yes bool) {
+	host, ok := config.GetRemote(name)
+	if !ok {
+		ui.ShowError(fmt.Sprintf("Remote '%s' not found", name), nil)
+		return
+	}
+
+	err := remote.CheckHostKey(host)
+	if err == nil {
+		ui.ShowSuccess("Host key for '%s' is already trusted", name)
+		return
+	}
+	var unknown *remote.HostKeyUnknownError
+	if !errors.As(err, &unknown) {
+		ui.ShowError("Failed to check host key", err)
+		return
+	}
+
+	if yes {
+		ui.ShowWarning("Trusting host key for %s without confirmation:", unknown.Address)
+		for _, fp := range unknown.Fingerprints {
+			ui.ShowInfo("  %s", fp)
+		}
+	} else if !confirmHostKey(unknown) {
+		ui.ShowWarning("Host key not trusted")
+		return
+	}
+
+	if err := remote.TrustHostKeys(host, unknown.Keys); err != nil {
+		ui.ShowError("Failed to update known_hosts", err)
+		return
 	}
+	ui.ShowSuccess("Added %s to known_hosts", unknown.Address)
 }
 
 // RunRemoteRemove removes a remote host.
@@ -127,6 +217,15 @@ func RunRemoteStatus(name string) {
 		ui.ShowInfo("Checking %s (%s)...", name, host.UserAtHost())
 	}
 
+	if err := ensureHostTrusted(host); err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Host key verification failed", err)
+		return
+	}
+
 	if err := remote.TestConnection(host); err != nil {
 		if output.JSONMode {
 			output.PrintError(err)
@@ -169,17 +268,33 @@ func RunRemoteStatus(name string) {
 	}
 }
 
+// remoteInstallOptions builds install options that print each stage to the terminal.
+func remoteInstallOptions(rateLimit string) remote.InstallOptions {
+	return remote.InstallOptions{
+		RateLimit: rateLimit,
+		Progress: func(stage string) {
+			ui.ShowInfo("%s", stage)
+		},
+	}
+}
+
 // RunRemoteInstall installs codes on a remote host.
-func RunRemoteInstall(name string) {
+// rateLimit caps download bandwidth (curl --limit-rate syntax, empty = unlimited).
+func RunRemoteInstall(name, rateLimit string) {
 	host, ok := config.GetRemote(name)
 	if !ok {
 		ui.ShowError(fmt.Sprintf("Remote '%s' not found", name), nil)
 		return
 	}
 
+	if err := ensureHostTrusted(host); err != nil {
+		ui.ShowError("Host key verification failed", err)
+		return
+	}
+
 	ui.ShowLoading("Installing codes on %s...", host.UserAtHost())
 
-	out, err := remote.InstallOnRemote(host)
+	out, err := remote.InstallOnRemoteWithOptions(host, remoteInstallOptions(rateLimit))
 	if err != nil {
 		ui.ShowError("Installation failed", err)
 		return
@@ -192,13 +307,33 @@ func RunRemoteInstall(name string) {
 }
 
 // RunRemoteSync syncs profiles to a remote host.
-func RunRemoteSync(name string) {
+// With dryRun, it prints the sync plan and leaves the remote untouched.
+func RunRemoteSync(name string, dryRun bool) {
 	host, ok := config.GetRemote(name)
 	if !ok {
 		ui.ShowError(fmt.Sprintf("Remote '%s' not found", name), nil)
 		return
 	}
 
+	if err := ensureHostTrusted(host); err != nil {
+		ui.ShowError("Host key verification failed", err)
+		return
+	}
+
+	if dryRun {
+		ui.ShowLoading("Planning profile sync to %s", host.UserAtHost())
+		plan, err := remote.PlanSync(host)
+		if err != nil {
+			ui.ShowError("Failed to plan sync", err)
+			return
+		}
+		fmt.Println()
+		printSyncPlan(plan)
+		fmt.Println()
+		ui.ShowInfo("Dry run: nothing was written. Run without --dry-run to apply.")
+		return
+	}
+
 	ui.ShowLoading("Syncing profiles to %s...", host.UserAtHost())
 
 	if err := remote.SyncProfiles(host); err != nil {
@@ -210,15 +345,20 @@ func RunRemoteSync(name string) {
 }
 
 // RunRemoteSetup runs install + sync on a remote host.
-func RunRemoteSetup(name string) {
+func RunRemoteSetup(name, rateLimit string) {
 	host, ok := config.GetRemote(name)
 	if !ok {
 		ui.ShowError(fmt.Sprintf("Remote '%s' not found", name), nil)
 		return
 	}
 
+	if err := ensureHostTrusted(host); err != nil {
+		ui.ShowError("Host key verification failed", err)
+		return
+	}
+
 	ui.ShowLoading("Installing codes on %s...", host.UserAtHost())
-	if _, err := remote.InstallOnRemote(host); err != nil {
+	if _, err := remote.InstallOnRemoteWithOptions(host, remoteInstallOptions(rateLimit)); err != nil {
 		ui.ShowError("Installation failed", err)
 		return
 	}
@@ -248,6 +388,59 @@ func RunRemoteSetup(name string) {
 	ui.ShowInfo("Connect with: codes remote ssh %s", name)
 }
 
+// RunRemoteLimits shows or updates the agent execution limits for a remote.
+// A nil limits only prints the current values; clear removes all limits.
+// Changes take effect on the host after the next sync and agent restart.
+func RunRemoteLimits(name string, limits *config.AgentLimits, clear bool) {
+	host, ok := config.GetRemote(name)
+	if !ok {
+		ui.ShowError(fmt.Sprintf("Remote '%s' not found", name), nil)
+		return
+	}
+
+	if clear {
+		host.AgentLimits = nil
+	} else if limits != nil {
+		if err := limits.Validate(); err != nil {
+			ui.ShowError("Invalid limits", err)
+			return
+		}
+		host.AgentLimits = limits
+	}
+
+	if clear || limits != nil {
+		if err := config.UpdateRemote(*host); err != nil {
+			ui.ShowError("Failed to save limits", err)
+			return
+		}
+	}
+
+	if output.JSONMode {
+		output.Print(host.AgentLimits, nil)
+		return
+	}
+
+	l := config.AgentLimits{}
+	if host.AgentLimits != nil {
+		l = *host.AgentLimits
+	}
+	ui.ShowHeader(fmt.Sprintf("Agent limits for %s", name))
+	if l.MaxConcurrent > 0 {
+		ui.ShowInfo("Max concurrent Claude processes: %d", l.MaxConcurrent)
+	} else {
+		ui.ShowInfo("Max concurrent Claude processes: unlimited")
+	}
+	ui.ShowInfo("Nice: %d", l.Nice)
+	if l.IONiceClass > 0 {
+		ui.ShowInfo("ionice class: %d", l.IONiceClass)
+	} else {
+		ui.ShowInfo("ionice class: unchanged")
+	}
+	if clear || limits != nil {
+		ui.ShowInfo("Apply with: codes remote sync %s (then restart agents on the host)", name)
+	}
+}
+
 // RunRemoteSSH opens an interactive SSH session on the remote host.
 func RunRemoteSSH(name string, project string) {
 	host, ok := config.GetRemote(name)
@@ -256,6 +449,11 @@ func RunRemoteSSH(name string, project string) {
 		return
 	}
 
+	if err := ensureHostTrusted(host); err != nil {
+		ui.ShowError("Host key verification failed", err)
+		return
+	}
+
 	var cmd string
 	if project != "" {
 		cmd = fmt.Sprintf("cd %s && codes", project)
@@ -267,3 +465,104 @@ func RunRemoteSSH(name string, project string) {
 		ui.ShowError("SSH session failed", err)
 	}
 }
+
+// RunRemoteDiff reports drift between local profiles/config and a remote host.
+func RunRemoteDiff(name string) {
+	host, ok := config.GetRemote(name)
+	if !ok {
+		if output.JSONMode {
+			output.PrintError(fmt.Errorf("remote %q not found", name))
+			return
+		}
+		ui.ShowError(fmt.Sprintf("Remote '%s' not found", name), nil)
+		return
+	}
+
+	if err := ensureHostTrusted(host); err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Host key verification failed", err)
+		return
+	}
+
+	if !output.JSONMode {
+		ui.ShowLoading("Comparing with %s", host.UserAtHost())
+	}
+
+	drift, err := remote.Diff(host, Version)
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to compare", err)
+		return
+	}
+
+	if output.JSONMode {
+		output.Print(drift, nil)
+		return
+	}
+
+	fmt.Println()
+	if drift.InSync() {
+		ui.ShowSuccess("Remote '%s' is in sync", name)
+		return
+	}
+
+	printSyncPlan(drift)
+	switch {
+	case drift.RemoteVersion == "":
+		ui.ShowWarning("codes: not installed on remote")
+	case drift.VersionStale:
+		ui.ShowWarning("codes: remote %s, local %s (stale)", drift.RemoteVersion, drift.LocalVersion)
+	}
+
+	fmt.Println()
+	ui.ShowInfo("Apply with: codes remote sync %s", name)
+}
+
+// printSyncPlan prints the profile/config changes a sync would make.
+func printSyncPlan(d *remote.Drift) {
+	if d.RemoteConfigMissing {
+		ui.ShowWarning("Remote has no config.json (will be created)")
+	} else {
+		// SyncProfiles writes a fresh config, it doesn't merge
+		ui.ShowWarning("Sync replaces the remote's entire config.json: only profiles, default, skipPermissions and agentLimits are kept")
+	}
+	if len(d.Profiles) == 0 && !d.DefaultChanged() && !d.SkipPermissionsChanged() && !d.AgentLimitsChanged() {
+		ui.ShowSuccess("Profiles: no changes")
+		return
+	}
+
+	for _, p := range d.Profiles {
+		switch p.Kind {
+		case remote.DriftMissing:
+			ui.ShowInfo("+ %s (missing on remote)", p.Name)
+		case remote.DriftChanged:
+			ui.ShowInfo("~ %s (%s)", p.Name, strings.Join(p.Fields, ", "))
+		case remote.DriftExtra:
+			ui.ShowInfo("- %s (only on remote, will be removed)", p.Name)
+		}
+	}
+	if d.DefaultChanged() {
+		ui.ShowInfo("~ default: %q → %q", d.RemoteDefault, d.LocalDefault)
+	}
+	if d.SkipPermissionsChanged() {
+		ui.ShowInfo("~ skipPermissions: %v → %v", d.RemoteSkipPermissions, d.LocalSkipPermissions)
+	}
+	if d.AgentLimitsChanged() {
+		r, l := d.RemoteAgentLimits, d.LocalAgentLimits
+		if r.MaxConcurrent != l.MaxConcurrent {
+			ui.ShowInfo("~ agentLimits.maxConcurrent: %d → %d", r.MaxConcurrent, l.MaxConcurrent)
+		}
+		if r.Nice != l.Nice {
+			ui.ShowInfo("~ agentLimits.nice: %d → %d", r.Nice, l.Nice)
+		}
+		if r.IONiceClass != l.IONiceClass {
+			ui.ShowInfo("~ agentLimits.ioniceClass: %d → %d", r.IONiceClass, l.IONiceClass)
+		}
+	}
+}
@@ -12,7 +12,10 @@ import (
 	"codes/internal/ui"
 )
 
-func RunSelect() {
+// RunSelect lets the user pick a profile and starts Claude with it. The
+// choice is saved as the default unless sessionOnly is set, in which case
+// only the started session's environment uses it.
+func RunSelect(sessionOnly bool) {
 	// Load config
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -68,22 +71,25 @@ func RunSelect() {
 
 	if selectedIdx, err := strconv.Atoi(selection); err == nil && selectedIdx >= 1 && selectedIdx <= len(cfg.Profiles) {
 		selectedConfig := cfg.Profiles[selectedIdx-1]
-		cfg.Default = selectedConfig.Name
 
-		// Save config
-		if err := config.SaveConfig(cfg); err != nil {
-			ui.ShowError("Failed to save config", err)
-			return
+		if sessionOnly {
+			ui.ShowSuccess("Selected for this session: %s (default stays %s)", selectedConfig.Name, cfg.Default)
+		} else {
+			// Locked read-modify-write: the TUI, `codes serve` or another CLI
+			// may be changing the config at the same time.
+			if err := config.SetDefaultProfile(selectedConfig.Name); err != nil {
+				ui.ShowError("Failed to save config", err)
+				return
+			}
+			ui.ShowSuccess("Selected: %s", selectedConfig.Name)
 		}
-
-		ui.ShowSuccess("Selected: %s", selectedConfig.Name)
 		apiURL := selectedConfig.Env["ANTHROPIC_BASE_URL"]
 		if apiURL == "" {
 			apiURL = "unknown"
 		}
 		ui.ShowInfo("API: %s", apiURL)
 
-		RunClaudeWithConfig([]string{})
+		runClaudeWithProfile(cfg, selectedConfig, []string{})
 	} else {
 		ui.ShowWarning("Invalid selection, starting with current config...")
 		RunClaudeWithConfig([]string{})
@@ -91,8 +97,6 @@ func RunSelect() {
 }
 
 func RunClaudeWithConfig(args []string) {
-	checkForUpdates()
-
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		ui.ShowError("Error loading config", err)
@@ -100,12 +104,18 @@ func RunClaudeWithConfig(args []string) {
 	}
 
 	var selectedConfig config.APIConfig
-	for _, c := range cfg.Profiles {
-		if c.Name == cfg.Default {
-			selectedConfig = c
-			break
-		}
+	if p := config.FindProfile(cfg, cfg.Default); p != nil {
+		selectedConfig = *p
 	}
+	runClaudeWithProfile(cfg, selectedConfig, args)
+}
+
+// runClaudeWithProfile starts Claude with profile's environment. The
+// variables are set on this process only, so other sessions and the saved
+// config are unaffected.
+func runClaudeWithProfile(cfg *config.Config, selectedConfig config.APIConfig, args []string) {
+	checkForUpdates()
+	requireClaude()
 
 	config.SetEnvironmentVars(&selectedConfig)
 
@@ -135,6 +145,7 @@ func RunClaudeWithConfig(args []string) {
 // runClaudeInDirectory runs Claude in the specified directory.
 func runClaudeInDirectory(dir string) {
 	checkForUpdates()
+	requireClaude()
 
 	cmd := config.BuildClaudeCmd(dir)
 
@@ -145,3 +156,13 @@ func runClaudeInDirectory(dir string) {
 	cmd.Stderr = os.Stderr
 	cmd.Run()
 }
+
+// requireClaude exits with an install hint when the claude CLI is missing.
+func requireClaude() {
+	if config.ClaudeAvailable() {
+		return
+	}
+	ui.ShowError("Claude CLI not found in PATH", nil)
+	ui.ShowInfo("Run 'codes install' to install it, then try again")
+	os.Exit(1)
+}
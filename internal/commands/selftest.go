@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"codes/internal/agent"
+	"codes/internal/httpserver"
+	"codes/internal/output"
+	"codes/internal/ui"
+	"codes/pkg/client"
+)
+
+// selftestAgent is the name of the throwaway agent `codes selftest` starts.
+const selftestAgent = "probe"
+
+// selftestStep is the outcome of one selftest stage.
+type selftestStep struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// selftest holds the state shared between stages.
+type selftest struct {
+	team    string
+	workDir string
+	taskID  int
+	pid     int // agent daemon, once started
+	timeout time.Duration
+}
+
+// RunSelftest runs a task end to end through a throwaway team using the mock
+// adapter: create a team, dispatch a task, start an agent daemon, wait for the
+// completion notification, read the task back over the HTTP API, then clean
+// up. It needs neither the claude CLI nor an API key, and exits non-zero if
+// any stage fails.
+func RunSelftest(timeout time.Duration) {
+	st := &selftest{
+		team:    fmt.Sprintf("selftest-%d", time.Now().UnixNano()),
+		timeout: timeout,
+	}
+
+	stages := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"create team", st.createTeam},
+		{"dispatch task", st.dispatchTask},
+		{"start agent", st.startAgent},
+		{"receive notification", st.awaitNotification},
+		{"query HTTP API", st.queryHTTP},
+	}
+
+	if !output.JSONMode {
+		ui.ShowHeader("Running Self-Test")
+		fmt.Println()
+	}
+
+	var steps []selftestStep
+	record := func(name string, run func() (string, error)) bool {
+		start := time.Now()
+		detail, err := run()
+		step := selftestStep{Name: name, OK: err == nil, Detail: detail, Duration: time.Since(start).Round(time.Millisecond).String()}
+		if err != nil {
+			step.Detail = err.Error()
+		}
+		steps = append(steps, step)
+		if !output.JSONMode {
+			if step.OK {
+				ui.ShowSuccess("%s (%s) %s", name, step.Duration, detail)
+			} else {
+				ui.ShowError(name+" failed", err)
+			}
+		}
+		return step.OK
+	}
+
+	passed := true
+	for _, s := range stages {
+		if !record(s.name, s.run) {
+			passed = false
+			break
+		}
+	}
+	// Grab the daemon's log before cleanup removes it
+	var agentLog []string
+	if !passed {
+		agentLog, _ = agent.ReadAgentLog(st.team, selftestAgent, 20, "")
+	}
+	// Always tear down whatever was created, even after a failure
+	if !record("clean up", st.cleanup) {
+		passed = false
+	}
+
+	if output.JSONMode {
+		printJSON(map[string]any{"passed": passed, "team": st.team, "steps": steps, "agentLog": agentLog})
+	} else {
+		fmt.Println()
+		if len(agentLog) > 0 {
+			ui.ShowInfo("Last agent log lines:")
+			for _, line := range agentLog {
+				fmt.Println("  " + line)
+			}
+			fmt.Println()
+		}
+		if passed {
+			ui.ShowSuccess("Self-test passed: this installation can run agent tasks")
+		} else {
+			ui.ShowError("Self-test failed", nil)
+		}
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
+
+func (st *selftest) createTeam() (string, error) {
+	dir, err := os.MkdirTemp("", "codes-selftest-")
+	if err != nil {
+		return "", err
+	}
+	st.workDir = dir
+	if _, err := agent.CreateTeam(st.team, "codes selftest", dir); err != nil {
+		return "", err
+	}
+	if err := agent.AddMember(st.team, agent.TeamMember{Name: selftestAgent, Role: "selftest"}); err != nil {
+		return "", err
+	}
+	return st.team, nil
+}
+
+func (st *selftest) dispatchTask() (string, error) {
+	tasks, err := agent.CreateTasks(st.team, []agent.TaskSpec{{
+		Subject: "selftest ping",
+		Owner:   selftestAgent,
+		Adapter: "mock",
+	}})
+	if err != nil {
+		return "", err
+	}
+	st.taskID = tasks[0].ID
+	return fmt.Sprintf("task #%d", st.taskID), nil
+}
+
+func (st *selftest) startAgent() (string, error) {
+	pid, err := agent.StartAgent(st.team, selftestAgent)
+	if err != nil {
+		return "", err
+	}
+	st.pid = pid
+	return fmt.Sprintf("pid %d", pid), nil
+}
+
+func (st *selftest) awaitNotification() (string, error) {
+	path, err := agent.NotificationPath(st.team, st.taskID)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+
+	deadline := time.Now().Add(st.timeout)
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var n struct {
+				Status string `json:"status"`
+				Result string `json:"result"`
+				Error  string `json:"error"`
+			}
+			if err := json.Unmarshal(data, &n); err != nil {
+				return "", fmt.Errorf("malformed notification: %w", err)
+			}
+			if n.Status != "completed" {
+				return "", fmt.Errorf("task %s: %s", n.Status, n.Error)
+			}
+			return n.Result, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("no notification after %s; is the agent daemon running?", st.timeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func (st *selftest) queryHTTP() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	srv := &http.Server{Handler: httpserver.NewHTTPServer([]string{token}, Version).Handler()}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	base := "http://" + ln.Addr().String()
+	ctx, cancel := context.WithTimeout(context.Background(), st.timeout)
+	defer cancel()
+
+	c := client.New(base, token)
+	c.Version = Version
+	if _, err := c.Health(ctx); err != nil {
+		return "", fmt.Errorf("/health: %w", err)
+	}
+
+	var apiErr *client.Error
+	_, err = client.New(base, "").GetTask(ctx, st.team, st.taskID)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return "", fmt.Errorf("unauthenticated request returned %v, want 401", err)
+	}
+
+	task, err := c.GetTask(ctx, st.team, st.taskID)
+	if err != nil {
+		return "", err
+	}
+	if task.Status != string(agent.TaskCompleted) {
+		return "", fmt.Errorf("task status over HTTP is %q, want completed", task.Status)
+	}
+	return "task read back via " + base, nil
+}
+
+func (st *selftest) cleanup() (string, error) {
+	if st.workDir != "" {
+		defer os.RemoveAll(st.workDir)
+	}
+	if _, err := agent.GetTeam(st.team); err != nil {
+		return "nothing to remove", nil
+	}
+
+	if st.pid > 0 {
+		if _, err := agent.SendMessage(st.team, "__system__", selftestAgent, "__stop__"); err != nil {
+			return "", err
+		}
+		// The daemon is our child, so wait for it directly; this also reaps
+		// it, where polling its PID would see a zombie that never exits
+		exited := make(chan struct{})
+		go func() {
+			if p, err := os.FindProcess(st.pid); err == nil {
+				p.Wait()
+			}
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(st.timeout):
+			return "", fmt.Errorf("agent did not stop; team %q left in place", st.team)
+		}
+	}
+	if err := agent.DeleteTeam(st.team); err != nil {
+		return "", err
+	}
+	return "team removed", nil
+}
@@ -3,21 +3,30 @@ package commands
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"codes/internal/assistant"
 	"codes/internal/assistant/scheduler"
+	"codes/internal/chatsession"
 	"codes/internal/config"
 	"codes/internal/httpserver"
+	"codes/internal/maintenance"
 	mcpserver "codes/internal/mcp"
 	"codes/internal/ui"
+	"codes/internal/update"
 )
 
 // RunServe is the single entry point for `codes serve`.
@@ -26,7 +35,11 @@ import (
 //   - HTTP REST server + assistant scheduler
 //   - SSE MCP handler mounted at /mcp/
 //   - stdio MCP when stdin is a pipe (e.g. spawned by Claude Code)
-func RunServe() {
+//
+// tlsFlags holds the --tls-* flags; when any is set they replace httpTLS
+// from the config. readOnly (--read-only) refuses every state-changing HTTP
+// request and leaves the SSE MCP handler out; stdio MCP is local and stays.
+func RunServe(tlsFlags config.HTTPTLS, readOnly bool) {
 	// Detect whether we were spawned with a pipe on stdin (Claude Code MCP mode).
 	stdioMCP := isStdinPipe()
 
@@ -38,6 +51,8 @@ func RunServe() {
 		log.SetOutput(os.Stderr)
 	}
 
+	mcpserver.Version = Version
+
 	// ── Config & auth token ───────────────────────────────────────────────────
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -63,6 +78,27 @@ func RunServe() {
 		httpAddr = ":3456"
 	}
 
+	// ── TLS ───────────────────────────────────────────────────────────────────
+	tlsSettings := cfg.HTTPTLS
+	if tlsFlags.Enabled() || tlsFlags.ClientCA != "" {
+		tlsSettings = &tlsFlags
+	}
+	tlsConfig, err := serveTLSConfig(tlsSettings, out)
+	if err != nil {
+		ui.ShowError("Failed to set up TLS", err)
+		os.Exit(1)
+	}
+
+	// A server left running from before an upgrade keeps the port, so this
+	// one would silently fail to bind while clients talk to the old version.
+	if skew := checkRunningServer(httpAddr, tlsConfig != nil); skew != nil {
+		if skew.Skew == update.SkewMajor && !stdioMCP {
+			ui.ShowError("Another codes server is already running", skew)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "[warn] %v\n", skew)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
@@ -71,12 +107,50 @@ func RunServe() {
 	if sched != nil {
 		defer sched.Stop()
 		assistant.SetScheduler(sched)
+		mcpserver.SetScheduler(sched)
 	}
 
+	// ── Nightly maintenance (goroutine) ───────────────────────────────────────
+	go maintenance.RunNightly(ctx, log.Default())
+
 	// ── HTTP REST server + SSE MCP (goroutine) ───────────────────────────────
-	fmt.Fprintf(out, "HTTP + MCP SSE server listening on %s\n", httpAddr)
+	if tlsConfig != nil {
+		fmt.Fprintf(out, "HTTPS + MCP SSE server listening on %s\n", httpAddr)
+	} else {
+		fmt.Fprintf(out, "HTTP + MCP SSE server listening on %s\n", httpAddr)
+		if !isLoopbackAddr(httpAddr) {
+			fmt.Fprintf(out, "(plain HTTP: tokens are sent in cleartext; use --tls-self-signed or --tls-cert beyond a trusted network)\n")
+		}
+	}
+	if n, err := chatsession.DefaultManager.Restore(); err != nil {
+		fmt.Fprintf(os.Stderr, "[warn] could not restore chat sessions: %v\n", err)
+	} else if n > 0 {
+		fmt.Fprintf(out, "Restored %d chat sessions; each resumes on its next message\n", n)
+	}
+	idleTTL, err := chatsession.ParseIdleTTL(cfg.SessionIdleTTL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[warn] %v; using %s\n", err, chatsession.DefaultIdleTTL)
+		idleTTL = chatsession.DefaultIdleTTL
+	}
+	go chatsession.DefaultManager.RunReaper(ctx, idleTTL)
 	httpServer := httpserver.NewHTTPServer(cfg.HTTPTokens, Version)
-	httpServer.Handle("/mcp/", mcpserver.NewSSEHandler())
+	httpServer.SetTLS(tlsConfig)
+	httpServer.SetAdminTokens(cfg.HTTPAdminTokens)
+	httpServer.SetScopedTokens(cfg.HTTPScopedTokens)
+	httpServer.SetLimits(cfg.HTTPLimits)
+	httpServer.SetMetricsPublic(cfg.HTTPMetricsPublic)
+	httpServer.SetCORS(cfg.HTTPCORS)
+	if cfg.HTTPCORS != nil && len(cfg.HTTPCORS.AllowedOrigins) > 0 {
+		fmt.Fprintf(out, "CORS: allowing browser requests from %s\n", strings.Join(cfg.HTTPCORS.AllowedOrigins, ", "))
+	}
+	httpServer.SetAudit(true)
+	httpServer.SetReadOnly(readOnly)
+	if readOnly {
+		// MCP tools create and delete teams and tasks; there is no read-only subset
+		fmt.Fprintf(out, "Read-only: state-changing requests get 403 and MCP SSE is disabled\n")
+	} else {
+		httpServer.Handle("/mcp/", mcpserver.NewSSEHandler())
+	}
 	go func() {
 		if err := httpServer.ListenAndServe(httpAddr); err != nil && err.Error() != "http: Server closed" {
 			fmt.Fprintf(os.Stderr, "[http] error: %v\n", err)
@@ -87,19 +161,117 @@ func RunServe() {
 		shutCtx, c := context.WithTimeout(context.Background(), 5*time.Second)
 		defer c()
 		_ = httpServer.Shutdown(shutCtx)
+		// Stops the Claude processes; the sessions are restored on the next start
+		chatsession.DefaultManager.CloseAll()
 	}()
 
 	// ── stdio MCP (blocking) or wait for signal ───────────────────────────────
 	if stdioMCP {
 		// Stdout is now exclusively for the MCP JSON-RPC protocol.
-		if err := mcpserver.RunServer(); err != nil && err.Error() != "server is closing: EOF" {
+		err := mcpserver.RunServer()
+		saveToolHistory()
+		if err != nil && err.Error() != "server is closing: EOF" {
 			fmt.Fprintf(os.Stderr, "[mcp-stdio] error: %v\n", err)
 			os.Exit(1)
 		}
 	} else {
 		<-ctx.Done()
 		fmt.Fprintf(out, "\nShutting down...\n")
+		saveToolHistory()
+	}
+}
+
+// saveToolHistory keeps the MCP tool calls of this run for
+// `codes mcp history`.
+func saveToolHistory() {
+	if err := mcpserver.SaveToolHistory(); err != nil {
+		fmt.Fprintf(os.Stderr, "[warn] could not save MCP tool history: %v\n", err)
+	}
+}
+
+// serveTLSConfig returns the TLS config for httpTLS settings t, generating a
+// self-signed certificate if asked to, or nil when TLS is off.
+func serveTLSConfig(t *config.HTTPTLS, out io.Writer) (*tls.Config, error) {
+	if !t.Enabled() {
+		if t != nil && t.ClientCA != "" {
+			return nil, fmt.Errorf("a client CA needs TLS: set a certificate or use a self-signed one")
+		}
+		return nil, nil
+	}
+	certFile, keyFile := t.Cert, t.Key
+	switch {
+	case t.SelfSigned && (certFile != "" || keyFile != ""):
+		return nil, fmt.Errorf("use either a self-signed certificate or --tls-cert/--tls-key, not both")
+	case t.SelfSigned:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		certFile, keyFile, err = httpserver.EnsureSelfSignedCert(filepath.Join(home, ".codes", "tls"))
+		if err != nil {
+			return nil, fmt.Errorf("generate self-signed certificate: %w", err)
+		}
+		fingerprint, err := httpserver.CertFingerprint(certFile)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(out, "Self-signed certificate: %s\n", certFile)
+		fmt.Fprintf(out, "SHA-256 fingerprint: %s\n", fingerprint)
+	case certFile == "" || keyFile == "":
+		return nil, fmt.Errorf("both a certificate and a key are required")
+	}
+	return httpserver.LoadTLSConfig(certFile, keyFile, t.ClientCA)
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local
+// connections.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkRunningServer asks whatever already listens on addr for its version.
+// Returns nil when nothing answers or the versions are compatible.
+func checkRunningServer(addr string, useTLS bool) *update.SkewError {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: time.Second}
+	if useTLS {
+		// Only the version is read, from our own machine, so the (possibly
+		// self-signed) certificate does not need to be trusted
+		scheme = "https"
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	req, err := http.NewRequest(http.MethodGet, scheme+"://"+net.JoinHostPort(host, port)+"/health", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set(update.VersionHeader, Version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var health httpserver.HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || health.Status != "ok" {
+		return nil // something else owns the port
 	}
+	return update.CheckSkew("codes serve on "+addr, Version, health.Version)
 }
 
 // isStdinPipe returns true when stdin is a pipe or file (not a terminal),
@@ -114,18 +286,15 @@ func isStdinPipe() bool {
 
 // startScheduler initialises and starts the assistant scheduler.
 func startScheduler(out io.Writer) *scheduler.Scheduler {
-	sched := scheduler.New(func(sessionID, message string) {
+	sched := scheduler.New(func(sc *scheduler.Schedule) {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
-		result, err := assistant.Run(ctx, assistant.RunOptions{
-			SessionID: sessionID,
-			Message:   message,
-		})
+		result, err := assistant.RunSchedule(ctx, sc)
 		if err != nil {
-			log.Printf("[scheduler] trigger error (session=%s): %v", sessionID, err)
+			log.Printf("[scheduler] trigger error (id=%s): %v", sc.ID, err)
 			return
 		}
-		log.Printf("[scheduler] reply [%s]: %s", sessionID, result.Reply)
+		log.Printf("[scheduler] fired %s: %s", sc.ID, result)
 	})
 	if err := sched.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "[scheduler] start error: %v\n", err)
@@ -18,6 +18,10 @@ func RunStart(args []string) {
 		input := args[0]
 
 		if project, exists := config.GetProject(input); exists {
+			if project.IsArchived() {
+				ui.ShowError(fmt.Sprintf("Project '%s' is archived. Restore it with: codes project restore %s", input, input), nil)
+				os.Exit(1)
+			}
 			// Remote project → SSH
 			if project.Remote != "" {
 				host, ok := config.GetRemote(project.Remote)
@@ -25,6 +29,10 @@ func RunStart(args []string) {
 					ui.ShowError(fmt.Sprintf("Remote '%s' not found for project '%s'", project.Remote, input), nil)
 					os.Exit(1)
 				}
+				if err := ensureHostTrusted(host); err != nil {
+					ui.ShowError("Host key verification failed", err)
+					os.Exit(1)
+				}
 				ui.ShowInfo("Connecting to remote project: %s @ %s", input, host.UserAtHost())
 				if err := remote.RunSSHInteractive(host, fmt.Sprintf("cd %s && codes", project.Path)); err != nil {
 					ui.ShowError("SSH session failed", err)
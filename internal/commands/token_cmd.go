@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"codes/internal/config"
+	"codes/internal/httpserver"
+	"codes/internal/output"
+	"codes/internal/ui"
+)
+
+// RunServeTokenCreate creates a scoped HTTP API token and prints it once.
+func RunServeTokenCreate(name string, scopes, teams []string) {
+	err := validateTokenScopes(scopes)
+	var token string
+	if err == nil {
+		token, err = generateToken()
+	}
+	if err == nil {
+		err = config.AddHTTPToken(config.HTTPToken{
+			Name:      name,
+			Token:     token,
+			Scopes:    scopes,
+			Teams:     teams,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if output.JSONMode {
+		if err != nil {
+			output.PrintError(err)
+			return
+		}
+		output.Print(map[string]any{"name": name, "token": token, "scopes": scopes, "teams": teams}, nil)
+		return
+	}
+	if err != nil {
+		ui.ShowError("Failed to create token", err)
+		return
+	}
+
+	ui.ShowSuccess("Token '%s' created", name)
+	fmt.Printf("\n  %s\n\n", token)
+	ui.ShowInfo("Scopes: %s", strings.Join(scopes, ", "))
+	if len(teams) > 0 {
+		ui.ShowInfo("Teams: %s", strings.Join(teams, ", "))
+	}
+	ui.ShowInfo("The token is only shown once. Restart codes serve to start accepting it.")
+}
+
+// validateTokenScopes checks that scopes is non-empty and only names known
+// scopes.
+func validateTokenScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required (%s)", strings.Join(httpserver.Scopes, ", "))
+	}
+	for _, s := range scopes {
+		if !slices.Contains(httpserver.Scopes, s) {
+			return fmt.Errorf("unknown scope %q (valid: %s)", s, strings.Join(httpserver.Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// RunServeTokenList lists the scoped HTTP API tokens without their secrets.
+func RunServeTokenList() {
+	tokens, err := config.ListHTTPTokens()
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to list tokens", err)
+		return
+	}
+
+	if output.JSONMode {
+		list := make([]map[string]any, 0, len(tokens))
+		for _, t := range tokens {
+			list = append(list, map[string]any{"name": t.Name, "scopes": t.Scopes, "teams": t.Teams, "createdAt": t.CreatedAt})
+		}
+		output.Print(list, nil)
+		return
+	}
+
+	if len(tokens) == 0 {
+		ui.ShowInfo("No scoped tokens configured")
+		ui.ShowInfo("Create one with: codes serve token create <name> --scope read")
+		return
+	}
+
+	fmt.Println()
+	ui.ShowHeader("HTTP API Tokens")
+	fmt.Println()
+	for _, t := range tokens {
+		teams := "all teams"
+		if len(t.Teams) > 0 {
+			teams = strings.Join(t.Teams, ", ")
+		}
+		ui.ShowInfo("%s  %s…  %s  (%s; created %s)", t.Name, t.Token[:min(6, len(t.Token))],
+			strings.Join(t.Scopes, ","), teams, t.CreatedAt.Local().Format("2006-01-02"))
+	}
+	fmt.Println()
+}
+
+// RunServeTokenRevoke removes a scoped HTTP API token.
+func RunServeTokenRevoke(name string) {
+	err := config.RemoveHTTPToken(name)
+	if output.JSONMode {
+		if err != nil {
+			output.PrintError(err)
+			return
+		}
+		output.Print(map[string]any{"revoked": true, "name": name}, nil)
+		return
+	}
+	if err != nil {
+		ui.ShowError("Failed to revoke token", err)
+		return
+	}
+	ui.ShowSuccess("Token '%s' revoked. Restart codes serve to stop accepting it.", name)
+}
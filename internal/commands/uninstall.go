@@ -0,0 +1,329 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"codes/internal/agent"
+	"codes/internal/output"
+	"codes/internal/ui"
+)
+
+// uninstallStep is one thing `codes uninstall` removes.
+type uninstallStep struct {
+	Kind   string `json:"kind"` // binary, completion, service, mcp, state
+	Path   string `json:"path"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	remove func() error
+}
+
+// RunUninstall removes everything `codes init` and normal use set up: the
+// binary, shell completions, service units and MCP registrations. With purge,
+// all state under ~/.codes is deleted too.
+func RunUninstall(purge, yes bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		ui.ShowError("Failed to get home directory", err)
+		return
+	}
+
+	if purge {
+		if running := runningAgents(); len(running) > 0 {
+			err := fmt.Errorf("agents still running: %s (stop them with 'codes agent stop-all <team>')", strings.Join(running, ", "))
+			if output.JSONMode {
+				output.PrintError(err)
+				return
+			}
+			ui.ShowError("Cannot purge state", err)
+			return
+		}
+	}
+
+	steps := planUninstall(home, installedBinaries(home), purge)
+	if len(steps) == 0 {
+		if output.JSONMode {
+			printJSON(map[string]any{"removed": []uninstallStep{}})
+			return
+		}
+		ui.ShowInfo("Nothing to uninstall")
+		return
+	}
+
+	if !output.JSONMode {
+		fmt.Println()
+		ui.ShowHeader("Uninstall codes")
+		fmt.Println()
+		for _, s := range steps {
+			ui.ShowInfo("%-10s %s%s", s.Kind, s.Path, detailSuffix(s.Detail))
+		}
+		if !purge {
+			ui.ShowInfo("State in ~/.codes is kept (use --purge to delete it)")
+		}
+		fmt.Println()
+	}
+
+	if !yes {
+		if output.JSONMode {
+			output.PrintError(fmt.Errorf("uninstall requires --yes in JSON mode"))
+			return
+		}
+		fmt.Print("Remove these? (y/N): ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			ui.ShowInfo("Uninstall cancelled")
+			return
+		}
+	}
+
+	var removed, failed []uninstallStep
+	for _, s := range steps {
+		if err := s.remove(); err != nil {
+			s.Error = err.Error()
+			failed = append(failed, s)
+			continue
+		}
+		removed = append(removed, s)
+	}
+
+	if output.JSONMode {
+		printJSON(map[string]any{"removed": removed, "failed": failed})
+		return
+	}
+
+	fmt.Println()
+	for _, s := range removed {
+		ui.ShowSuccess("Removed %s: %s", s.Kind, s.Path)
+	}
+	for _, s := range failed {
+		ui.ShowError(fmt.Sprintf("Failed to remove %s: %s", s.Kind, s.Path), fmt.Errorf("%s", s.Error))
+	}
+	fmt.Println()
+	ui.ShowInfo("Removed %d item(s), %d failed", len(removed), len(failed))
+	if runtime.GOOS == "windows" {
+		ui.ShowInfo("The install directory may still be on your PATH; remove it in System Settings if needed")
+	}
+}
+
+func detailSuffix(detail string) string {
+	if detail == "" {
+		return ""
+	}
+	return " (" + detail + ")"
+}
+
+// runningAgents returns "team/agent" for every agent daemon still alive.
+func runningAgents() []string {
+	teams, _ := agent.ListTeams()
+	var running []string
+	for _, team := range teams {
+		cfg, err := agent.GetTeam(team)
+		if err != nil {
+			continue
+		}
+		for _, m := range cfg.Members {
+			if agent.IsAgentAlive(team, m.Name) {
+				running = append(running, team+"/"+m.Name)
+			}
+		}
+	}
+	return running
+}
+
+// installedBinaries returns the running executable plus the locations
+// `codes init` installs to.
+func installedBinaries(home string) []string {
+	binaries := []string{filepath.Join(home, "bin", "codes"), "/usr/local/bin/codes"}
+	if runtime.GOOS == "windows" {
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData == "" {
+			localAppData = filepath.Join(home, "AppData", "Local")
+		}
+		binaries = []string{filepath.Join(localAppData, "codes", "codes.exe")}
+	}
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		// Don't remove `go run`/test binaries from the build cache
+		if filepath.Base(exe) == "codes" || filepath.Base(exe) == "codes.exe" {
+			binaries = append([]string{exe}, binaries...)
+		}
+	}
+	return binaries
+}
+
+// planUninstall lists what is installed under home, plus any of binaries
+// that exist. Nothing is removed until the steps' remove functions are called.
+func planUninstall(home string, binaries []string, purge bool) []uninstallStep {
+	var steps []uninstallStep
+	seen := make(map[string]bool)
+
+	for _, bin := range binaries {
+		if seen[bin] || !fileExists(bin) {
+			continue
+		}
+		seen[bin] = true
+		path := bin
+		steps = append(steps, uninstallStep{Kind: "binary", Path: path, remove: func() error { return os.Remove(path) }})
+	}
+
+	// Shell completions
+	rcFiles := []string{".zshrc", ".bashrc", ".bash_profile"}
+	for _, rc := range rcFiles {
+		path := filepath.Join(home, rc)
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "codes completion") {
+			steps = append(steps, uninstallStep{Kind: "completion", Path: path, Detail: "remove completion line",
+				remove: func() error { return removeCompletionLines(path) }})
+		}
+	}
+	if runtime.GOOS == "windows" {
+		if out, err := exec.Command("powershell", "-NoProfile", "-Command", "$PROFILE").Output(); err == nil {
+			path := strings.TrimSpace(string(out))
+			if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "codes completion") {
+				steps = append(steps, uninstallStep{Kind: "completion", Path: path, Detail: "remove completion line",
+					remove: func() error { return removeCompletionLines(path) }})
+			}
+		}
+	}
+	if fish := filepath.Join(home, ".config", "fish", "completions", "codes.fish"); fileExists(fish) {
+		steps = append(steps, uninstallStep{Kind: "completion", Path: fish, remove: func() error { return os.Remove(fish) }})
+	}
+
+	// Service units that run `codes serve` or agents at login
+	for _, unit := range globAll(filepath.Join(home, ".config", "systemd", "user", "codes*.service")) {
+		path := unit
+		name := filepath.Base(unit)
+		steps = append(steps, uninstallStep{Kind: "service", Path: path, Detail: "systemd user unit",
+			remove: func() error {
+				exec.Command("systemctl", "--user", "disable", "--now", name).Run()
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				exec.Command("systemctl", "--user", "daemon-reload").Run()
+				return nil
+			}})
+	}
+	for _, plist := range globAll(filepath.Join(home, "Library", "LaunchAgents", "*codes*.plist")) {
+		path := plist
+		steps = append(steps, uninstallStep{Kind: "service", Path: path, Detail: "launchd agent",
+			remove: func() error {
+				exec.Command("launchctl", "unload", "-w", path).Run()
+				return os.Remove(path)
+			}})
+	}
+
+	// MCP server registrations in Claude Code's user config
+	for _, path := range []string{filepath.Join(home, ".claude.json"), filepath.Join(home, ".claude", "claude_code_config.json")} {
+		if hasCodesMCPServer(path) {
+			p := path
+			steps = append(steps, uninstallStep{Kind: "mcp", Path: p, Detail: `remove "codes" MCP server`,
+				remove: func() error { return removeCodesMCPServer(p) }})
+		}
+	}
+
+	if purge {
+		state := filepath.Join(home, ".codes")
+		if fileExists(state) {
+			steps = append(steps, uninstallStep{Kind: "state", Path: state, Detail: "config, teams, sessions, stats",
+				remove: func() error { return os.RemoveAll(state) }})
+		}
+	}
+
+	return steps
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func globAll(pattern string) []string {
+	matches, _ := filepath.Glob(pattern)
+	return matches
+}
+
+// removeCompletionLines deletes the lines `codes init` appended to a shell
+// profile: the "# codes CLI completion" marker and the completion command.
+func removeCompletionLines(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	kept := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "# codes CLI completion" || strings.Contains(line, "codes completion") {
+			// Also drop the blank line appendCompletionLine adds before the marker
+			if n := len(kept); n > 0 && strings.TrimSpace(kept[n-1]) == "" && line == "# codes CLI completion" {
+				kept = kept[:n-1]
+			}
+			continue
+		}
+		kept = append(kept, lines[i])
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(kept, "\n")), info.Mode().Perm())
+}
+
+// hasCodesMCPServer reports whether a Claude config file registers the
+// "codes" MCP server.
+func hasCodesMCPServer(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var cfg struct {
+		MCPServers map[string]json.RawMessage `json:"mcpServers"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return false
+	}
+	_, ok := cfg.MCPServers["codes"]
+	return ok
+}
+
+// removeCodesMCPServer deletes mcpServers.codes from a Claude config file,
+// leaving every other key untouched.
+func removeCodesMCPServer(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	var servers map[string]json.RawMessage
+	if err := json.Unmarshal(cfg["mcpServers"], &servers); err != nil {
+		return err
+	}
+	delete(servers, "codes")
+	raw, err := json.Marshal(servers)
+	if err != nil {
+		return err
+	}
+	cfg["mcpServers"] = raw
+
+	out, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), info.Mode().Perm())
+}
@@ -0,0 +1,84 @@
+//go:build !windows
+
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanUninstall(t *testing.T) {
+	home := t.TempDir()
+
+	bin := filepath.Join(home, "bin", "codes")
+	os.MkdirAll(filepath.Dir(bin), 0755)
+	os.WriteFile(bin, []byte("#!/bin/sh\n"), 0755)
+
+	zshrc := filepath.Join(home, ".zshrc")
+	os.WriteFile(zshrc, []byte("export EDITOR=vim\n\n# codes CLI completion\nsource <(codes completion zsh)\nalias g=git\n"), 0644)
+
+	fish := filepath.Join(home, ".config", "fish", "completions", "codes.fish")
+	os.MkdirAll(filepath.Dir(fish), 0755)
+	os.WriteFile(fish, []byte("codes completion fish | source\n"), 0644)
+
+	unit := filepath.Join(home, ".config", "systemd", "user", "codes-uninstall-test.service")
+	os.MkdirAll(filepath.Dir(unit), 0755)
+	os.WriteFile(unit, []byte("[Service]\nExecStart=codes serve\n"), 0644)
+
+	claudeJSON := filepath.Join(home, ".claude.json")
+	os.WriteFile(claudeJSON, []byte(`{"numStartups": 3, "mcpServers": {"codes": {"command": "codes", "args": ["serve"]}, "other": {"command": "x"}}}`), 0600)
+
+	os.MkdirAll(filepath.Join(home, ".codes", "teams"), 0755)
+
+	steps := planUninstall(home, []string{bin}, false)
+	kinds := map[string]int{}
+	for _, s := range steps {
+		kinds[s.Kind]++
+		if s.Kind == "state" {
+			t.Error("state must only be removed with purge")
+		}
+	}
+	if kinds["binary"] != 1 || kinds["completion"] != 2 || kinds["service"] != 1 || kinds["mcp"] != 1 {
+		t.Fatalf("unexpected plan: %+v", kinds)
+	}
+
+	steps = planUninstall(home, []string{bin}, true)
+	for _, s := range steps {
+		if err := s.remove(); err != nil {
+			t.Errorf("remove %s %s: %v", s.Kind, s.Path, err)
+		}
+	}
+
+	data, _ := os.ReadFile(zshrc)
+	if got := string(data); got != "export EDITOR=vim\nalias g=git\n" {
+		t.Errorf("zshrc after uninstall = %q", got)
+	}
+	for _, path := range []string{bin, fish, unit, filepath.Join(home, ".codes")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", path)
+		}
+	}
+
+	var cfg struct {
+		NumStartups int                        `json:"numStartups"`
+		MCPServers  map[string]json.RawMessage `json:"mcpServers"`
+	}
+	data, _ = os.ReadFile(claudeJSON)
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.MCPServers["codes"]; ok || cfg.MCPServers["other"] == nil || cfg.NumStartups != 3 {
+		t.Errorf("claude config after uninstall = %s", data)
+	}
+
+	if steps := planUninstall(home, []string{bin}, true); len(steps) != 0 {
+		var paths []string
+		for _, s := range steps {
+			paths = append(paths, s.Path)
+		}
+		t.Errorf("expected nothing left to uninstall, got %s", strings.Join(paths, ", "))
+	}
+}
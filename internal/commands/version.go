@@ -4,12 +4,18 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/agent"
 	"codes/internal/config"
+	"codes/internal/notify"
+	"codes/internal/output"
 	"codes/internal/ui"
 	"codes/internal/update"
 )
@@ -21,10 +27,47 @@ var (
 	Date    = "unknown"
 )
 
-func RunVersion() {
+func init() {
+	// Daemons record the version they were built from; see agent.Version
+	agent.Version = Version
+}
+
+func RunVersion(history bool) {
+	if history {
+		runVersionHistory()
+		return
+	}
 	fmt.Printf("codes version %s (commit %s, built %s)\n", Version, Commit, Date)
 }
 
+// runVersionHistory lists the updates applied to this installation.
+func runVersionHistory() {
+	history, err := update.LoadHistory()
+	if err != nil {
+		if output.JSONMode {
+			output.PrintError(err)
+			return
+		}
+		ui.ShowError("Failed to read update history", err)
+		return
+	}
+	if output.JSONMode {
+		if history == nil {
+			history = []update.HistoryEntry{}
+		}
+		printJSON(history)
+		return
+	}
+	if len(history) == 0 {
+		ui.ShowInfo("No updates recorded yet")
+		return
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		h := history[i]
+		fmt.Printf("  %s  %s → %s (%s)\n", h.At.Local().Format("2006-01-02 15:04"), h.From, h.To, h.Via)
+	}
+}
+
 func RunClaudeUpdate() {
 	ui.ShowHeader("Claude Version Manager")
 	ui.ShowLoading("Fetching available versions...")
@@ -95,20 +138,113 @@ func RunClaudeUpdate() {
 
 func checkForUpdates() {
 	// Apply any previously staged update (synchronous)
-	if err := update.ApplyStaged(); err != nil {
+	if err := update.ApplyStaged(Version); err != nil {
 		ui.ShowWarning("Failed to apply staged update: %v", err)
 	}
 
-	// Async version check
+	// Async version check; the hook is best effort, as the command may
+	// finish first
 	mode := config.GetAutoUpdate()
-	go update.AutoCheck(Version, mode)
+	go update.AutoCheck(Version, mode, func(release *update.ReleaseInfo) {
+		notify.RunEventHook(notify.HookEvent{
+			Event:  "on_update_available",
+			Update: &notify.HookUpdate{Current: Version, Latest: release.TagName, URL: release.HTMLURL},
+		})
+	})
 }
 
-// RunSelfUpdate performs a manual codes self-update.
-func RunSelfUpdate() {
+// RunSelfUpdate performs a manual codes self-update. It shows the release
+// notes between the running and the latest version and asks before
+// installing; major upgrades are only installed with yes.
+func RunSelfUpdate(yes bool) {
 	ui.ShowHeader("codes Self-Update")
-	if err := update.RunSelfUpdate(Version); err != nil {
-		ui.ShowError(err.Error(), nil)
+	release, err := update.CheckLatestVersion()
+	if err != nil {
+		ui.ShowError("Failed to check for updates", err)
+		os.Exit(1)
+	}
+
+	if Version != "dev" && !update.CompareVersions(Version, release.TagName) {
+		ui.ShowSuccess("Already up to date (%s)", Version)
+		return
+	}
+
+	fmt.Println()
+	releases, err := update.FetchReleaseNotes(Version, release.TagName)
+	if err != nil || len(releases) == 0 {
+		ui.ShowWarning("Could not fetch release notes; see %s", release.HTMLURL)
+	} else {
+		renderReleaseNotes(os.Stdout, Version, releases)
+	}
+	fmt.Println()
+
+	if update.IsMajorUpgrade(Version, release.TagName) && !yes {
+		ui.ShowError(fmt.Sprintf("%s → %s is a major upgrade; review the notes above and run 'codes update --yes' to install it", Version, release.TagName), nil)
 		os.Exit(1)
 	}
+	if update.HasBreakingChanges(Version, releases) {
+		ui.ShowWarning("This update contains breaking changes (highlighted above)")
+	}
+
+	if !yes && !isStdinPipe() {
+		fmt.Printf("Update %s → %s? (y/N): ", Version, release.TagName)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			ui.ShowInfo("Update cancelled")
+			return
+		}
+	}
+
+	ui.ShowLoading("Updating %s → %s", Version, release.TagName)
+	if err := update.Install(release, Version); err != nil {
+		ui.ShowError("Update failed", err)
+		os.Exit(1)
+	}
+	ui.ShowSuccess("Updated to %s", release.TagName)
+}
+
+var (
+	releaseTitleStyle = lipgloss.NewStyle().Bold(true)
+	breakingStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9"))
+)
+
+// renderReleaseNotes prints each release's notes, oldest first, with
+// Markdown headings in bold and breaking changes highlighted. Releases that
+// bump the major version over their predecessor are marked MAJOR.
+func renderReleaseNotes(w io.Writer, current string, releases []update.ReleaseInfo) {
+	for i, r := range releases {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		title := r.TagName
+		if r.Name != "" && r.Name != r.TagName {
+			title += " — " + r.Name
+		}
+		if len(r.PublishedAt) >= 10 {
+			title += " (" + r.PublishedAt[:10] + ")"
+		}
+		if update.IsMajorUpgrade(current, r.TagName) {
+			fmt.Fprintf(w, " %s %s\n", releaseTitleStyle.Render(title), breakingStyle.Render("MAJOR"))
+		} else {
+			fmt.Fprintf(w, " %s\n", releaseTitleStyle.Render(title))
+		}
+		current = r.TagName
+
+		body := strings.TrimSpace(strings.ReplaceAll(r.Body, "\r\n", "\n"))
+		if body == "" {
+			fmt.Fprintln(w, "   (no release notes)")
+			continue
+		}
+		for _, line := range strings.Split(body, "\n") {
+			switch {
+			case update.IsBreakingLine(line):
+				fmt.Fprintf(w, "   %s\n", breakingStyle.Render("⚠ "+strings.TrimSpace(line)))
+			case strings.HasPrefix(line, "#"):
+				fmt.Fprintf(w, "   %s\n", releaseTitleStyle.Render(strings.TrimSpace(strings.TrimLeft(line, "#"))))
+			default:
+				fmt.Fprintf(w, "   %s\n", line)
+			}
+		}
+	}
 }
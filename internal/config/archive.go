@@ -0,0 +1,270 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProjectArchive marks a project as archived. Archived projects stay in the
+// config (so team tasks and links that name them keep resolving) but are
+// hidden from default lists and the TUI.
+type ProjectArchive struct {
+	At      time.Time `json:"at"`
+	Tarball string    `json:"tarball,omitempty"` // compressed copy of the directory, which was removed
+}
+
+// IsArchived reports whether the project has been archived.
+func (p ProjectEntry) IsArchived() bool {
+	return p.Archived != nil
+}
+
+// ListActiveProjects returns projects that are not archived.
+func ListActiveProjects() (map[string]ProjectEntry, error) {
+	projects, err := ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]ProjectEntry, len(projects))
+	for name, entry := range projects {
+		if !entry.IsArchived() {
+			active[name] = entry
+		}
+	}
+	return active, nil
+}
+
+// archiveDir is where compressed project directories are stored.
+func archiveDir() string {
+	return filepath.Join(filepath.Dir(ConfigPath), "archive")
+}
+
+// ArchiveProject hides a project from default lists. With compress, the local
+// directory is packed into a tar.gz under ~/.codes/archive and then removed,
+// only once the config records the tarball, so a failed save never loses the
+// directory.
+func ArchiveProject(name string, compress bool) (ProjectEntry, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return ProjectEntry{}, err
+	}
+	entry, ok := cfg.Projects[name]
+	if !ok {
+		return ProjectEntry{}, fmt.Errorf("project '%s' not found", name)
+	}
+	if entry.IsArchived() {
+		return entry, fmt.Errorf("project '%s' is already archived", name)
+	}
+
+	archive := &ProjectArchive{At: time.Now()}
+	if compress {
+		if entry.Remote != "" {
+			return entry, fmt.Errorf("compression is only supported for local projects")
+		}
+		if err := os.MkdirAll(archiveDir(), 0755); err != nil {
+			return entry, err
+		}
+		tarball := filepath.Join(archiveDir(), fmt.Sprintf("%s-%s.tar.gz", name, archive.At.Format("20060102-150405")))
+		// Packed outside the config lock, which other writers would wait on
+		if err := writeTarball(entry.Path, tarball); err != nil {
+			os.Remove(tarball)
+			return entry, fmt.Errorf("compress %s: %w", entry.Path, err)
+		}
+		archive.Tarball = tarball
+	}
+
+	err = UpdateConfig(func(cfg *Config) error {
+		current, ok := cfg.Projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		if current.IsArchived() {
+			return fmt.Errorf("project '%s' is already archived", name)
+		}
+		current.Archived = archive
+		cfg.Projects[name] = current
+		entry = current
+		return nil
+	})
+	if err != nil {
+		if archive.Tarball != "" {
+			os.Remove(archive.Tarball)
+		}
+		return entry, err
+	}
+
+	if archive.Tarball != "" {
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return entry, fmt.Errorf("archived to %s but failed to remove directory: %w", archive.Tarball, err)
+		}
+	}
+	return entry, nil
+}
+
+// RestoreProject un-archives a project, extracting its directory back to the
+// original path if it was compressed. The tarball is removed once the config
+// no longer records it.
+func RestoreProject(name string) (ProjectEntry, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return ProjectEntry{}, err
+	}
+	entry, ok := cfg.Projects[name]
+	if !ok {
+		return ProjectEntry{}, fmt.Errorf("project '%s' not found", name)
+	}
+	if !entry.IsArchived() {
+		return entry, fmt.Errorf("project '%s' is not archived", name)
+	}
+
+	tarball := entry.Archived.Tarball
+	if tarball != "" {
+		if _, err := os.Stat(entry.Path); err == nil {
+			return entry, fmt.Errorf("cannot restore: %s already exists", entry.Path)
+		}
+		if err := extractTarball(tarball, entry.Path); err != nil {
+			return entry, fmt.Errorf("extract %s: %w", tarball, err)
+		}
+	}
+
+	err = UpdateConfig(func(cfg *Config) error {
+		current, ok := cfg.Projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		current.Archived = nil
+		cfg.Projects[name] = current
+		entry = current
+		return nil
+	})
+	if err != nil {
+		return entry, err
+	}
+	if tarball != "" {
+		os.Remove(tarball)
+	}
+	return entry, nil
+}
+
+// writeTarball packs the contents of dir into a gzip-compressed tar file.
+func writeTarball(dir, dest string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// extractTarball unpacks a tarball written by writeTarball into dir.
+func extractTarball(src, dir string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid entry %q", hdr.Name)
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
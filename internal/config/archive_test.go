@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveProject_CompressAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(tmpDir, "codes", "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	projDir := filepath.Join(tmpDir, "proj")
+	os.MkdirAll(filepath.Join(projDir, "src"), 0755)
+	os.WriteFile(filepath.Join(projDir, "src", "main.go"), []byte("package main\n"), 0644)
+	os.Symlink("src/main.go", filepath.Join(projDir, "link.go"))
+
+	if err := SaveConfig(&Config{Projects: map[string]ProjectEntry{
+		"proj":  {Path: projDir},
+		"other": {Path: tmpDir},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := ArchiveProject("proj", true)
+	if err != nil {
+		t.Fatalf("ArchiveProject: %v", err)
+	}
+	if !entry.IsArchived() || entry.Archived.Tarball == "" {
+		t.Fatalf("expected compressed archive, got %+v", entry.Archived)
+	}
+	if _, err := os.Stat(projDir); !os.IsNotExist(err) {
+		t.Error("project directory should be removed after compression")
+	}
+	if _, err := ArchiveProject("proj", false); err == nil {
+		t.Error("expected error archiving an archived project")
+	}
+
+	active, _ := ListActiveProjects()
+	if _, ok := active["proj"]; ok || len(active) != 1 {
+		t.Errorf("ListActiveProjects() = %v, want only 'other'", active)
+	}
+	all, _ := ListProjects()
+	if !all["proj"].IsArchived() {
+		t.Error("archived state should persist in config")
+	}
+
+	if _, err := RestoreProject("proj"); err != nil {
+		t.Fatalf("RestoreProject: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(projDir, "src", "main.go"))
+	if err != nil || string(data) != "package main\n" {
+		t.Errorf("restored file = %q, %v", data, err)
+	}
+	if target, err := os.Readlink(filepath.Join(projDir, "link.go")); err != nil || target != "src/main.go" {
+		t.Errorf("restored symlink = %q, %v", target, err)
+	}
+	if _, err := os.Stat(entry.Archived.Tarball); !os.IsNotExist(err) {
+		t.Error("tarball should be removed after restore")
+	}
+	if p, _ := GetProject("proj"); p.IsArchived() {
+		t.Error("project should no longer be archived")
+	}
+	if _, err := RestoreProject("proj"); err == nil {
+		t.Error("expected error restoring a project that is not archived")
+	}
+}
+
+func TestArchiveProject_SaveFailureKeepsDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(tmpDir, "codes", "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	projDir := filepath.Join(tmpDir, "proj")
+	os.MkdirAll(projDir, 0755)
+	os.WriteFile(filepath.Join(projDir, "main.go"), []byte("package main\n"), 0644)
+	if err := SaveConfig(&Config{Projects: map[string]ProjectEntry{"proj": {Path: projDir}}}); err != nil {
+		t.Fatal(err)
+	}
+	// The config lock cannot be taken, so the update fails
+	os.MkdirAll(ConfigPath+".lock", 0755)
+
+	if _, err := ArchiveProject("proj", true); err == nil {
+		t.Fatal("expected ArchiveProject to fail")
+	}
+	if _, err := os.Stat(filepath.Join(projDir, "main.go")); err != nil {
+		t.Errorf("project directory should be kept: %v", err)
+	}
+	if tarballs, _ := os.ReadDir(archiveDir()); len(tarballs) != 0 {
+		t.Errorf("unrecorded tarballs left behind: %v", tarballs)
+	}
+	if p, _ := GetProject("proj"); p.IsArchived() {
+		t.Error("project should not be archived")
+	}
+}
+
+func TestArchiveProject_RemoteCannotCompress(t *testing.T) {
+	tmpDir := t.TempDir()
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(tmpDir, "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	SaveConfig(&Config{Projects: map[string]ProjectEntry{"r": {Path: "/srv/app", Remote: "box"}}})
+	if _, err := ArchiveProject("r", true); err == nil {
+		t.Error("expected error compressing a remote project")
+	}
+	if _, err := ArchiveProject("r", false); err != nil {
+		t.Errorf("plain archive of remote project: %v", err)
+	}
+}
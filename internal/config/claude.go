@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// ClaudeInstallHint tells the user how to get the claude CLI.
+const ClaudeInstallHint = "install it with `codes install` (or `npm install -g @anthropic-ai/claude-code`)"
+
+// ErrClaudeNotFound is returned when the claude CLI is not on PATH.
+var ErrClaudeNotFound = errors.New("claude CLI not found in PATH; " + ClaudeInstallHint)
+
+// ClaudePath returns the path of the claude CLI, or ErrClaudeNotFound.
+// PATH is searched on every call so a freshly installed binary is picked up
+// without restarting long-running processes.
+func ClaudePath() (string, error) {
+	path, err := exec.LookPath("claude")
+	if err != nil {
+		return "", ErrClaudeNotFound
+	}
+	return path, nil
+}
+
+// ClaudeAvailable reports whether the claude CLI is on PATH.
+func ClaudeAvailable() bool {
+	_, err := ClaudePath()
+	return err == nil
+}
@@ -2,15 +2,25 @@ package config
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"codes/internal/filelock"
+	"codes/internal/trace"
 )
 
 type Config struct {
@@ -28,7 +38,62 @@ type Config struct {
 	Webhooks        []WebhookConfig   `json:"webhooks,omitempty"`        // Webhook 通知配置
 	Hooks           map[string]string `json:"hooks,omitempty"`           // 事件钩子 {"on_task_completed": "/path/to/script.sh"}
 	HTTPTokens      []string          `json:"httpTokens,omitempty"`      // HTTP API Bearer tokens
+	HTTPAdminTokens []string          `json:"httpAdminTokens,omitempty"` // HTTP API tokens with the admin scope (actions on the host, e.g. POST /host/sessions)
+	HTTPScopedTokens []HTTPToken      `json:"httpScopedTokens,omitempty"` // HTTP API tokens limited to scopes and teams (codes serve token)
 	HTTPBind        string            `json:"httpBind,omitempty"`        // HTTP server bind address (e.g., ":8080")
+	HTTPLimits      *HTTPLimits       `json:"httpLimits,omitempty"`      // HTTP API rate and request size limits
+	HTTPTLS         *HTTPTLS          `json:"httpTLS,omitempty"`         // serve HTTPS, optionally requiring client certificates
+	HTTPMetricsPublic bool            `json:"httpMetricsPublic,omitempty"` // serve GET /metrics without a token (for Prometheus scrapers)
+	HTTPCORS        *HTTPCORS         `json:"httpCORS,omitempty"`        // browser origins allowed to call the HTTP API
+	SessionIdleTTL  string            `json:"sessionIdleTTL,omitempty"`  // close chat sessions idle this long, e.g. "2h"; "off" keeps them (default 24h)
+	AgentLimits     *AgentLimits      `json:"agentLimits,omitempty"`     // 本机 agent 执行限制
+	CloneDefaults   *CloneOptions     `json:"cloneDefaults,omitempty"`   // git clone 默认选项 (浅克隆/稀疏检出)
+	SummaryModel    string            `json:"summaryModel,omitempty"`    // model for task result summaries, "off" to disable
+	PreventSleep    bool              `json:"preventSleep,omitempty"`    // keep the machine awake while agents run tasks
+	PowerPolicy     *PowerPolicy      `json:"powerPolicy,omitempty"`     // pause task pickup on low battery or thermal pressure
+	WorkDirPolicy   *WorkDirPolicy    `json:"workDirPolicy,omitempty"`   // directories teams, tasks and sessions may run in
+	Markdown        *MarkdownConfig   `json:"markdown,omitempty"`        // rendering of task reports and assistant replies
+}
+
+// CloneOptions controls how git mode clones a repository. The zero value is a
+// full clone, matching plain `git clone`.
+type CloneOptions struct {
+	Depth        int      `json:"depth,omitempty"`        // --depth N; 0 = full history
+	SingleBranch bool     `json:"singleBranch,omitempty"` // --single-branch
+	SparsePaths  []string `json:"sparsePaths,omitempty"`  // sparse-checkout directories; empty = full checkout
+}
+
+// CloneArgs returns the extra `git clone` flags for these options.
+func (o CloneOptions) CloneArgs() []string {
+	var args []string
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if len(o.SparsePaths) > 0 {
+		// Skip blobs outside the sparse cone until they are needed
+		args = append(args, "--filter=blob:none", "--sparse")
+	}
+	return args
+}
+
+// CloneRepo clones gitURL into dir with opts, then applies its sparse-checkout
+// paths. The error carries git's output.
+func CloneRepo(ctx context.Context, gitURL, dir string, opts CloneOptions) error {
+	defer trace.Start("git.clone", "url", gitURL)()
+	args := append(append([]string{"clone"}, opts.CloneArgs()...), gitURL, dir)
+	if out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone: %s", strings.TrimSpace(string(out)))
+	}
+	if len(opts.SparsePaths) > 0 {
+		args := append([]string{"-C", dir, "sparse-checkout", "set"}, opts.SparsePaths...)
+		if out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("cloned to %s but sparse-checkout failed: %s", dir, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
 }
 
 // WebhookConfig represents a webhook notification endpoint.
@@ -42,11 +107,95 @@ type WebhookConfig struct {
 
 // RemoteHost represents a remote SSH host configuration.
 type RemoteHost struct {
-	Name     string `json:"name"`
-	Host     string `json:"host"`
-	User     string `json:"user,omitempty"`
-	Port     int    `json:"port,omitempty"`
-	Identity string `json:"identity,omitempty"`
+	Name                string       `json:"name"`
+	Host                string       `json:"host"`
+	User                string       `json:"user,omitempty"`
+	Port                int          `json:"port,omitempty"`
+	Identity            string       `json:"identity,omitempty"`
+	SecurityKeyProvider string       `json:"securityKeyProvider,omitempty"` // FIDO2 middleware library for sk-* identities (ssh SecurityKeyProvider)
+	AgentLimits         *AgentLimits `json:"agentLimits,omitempty"`         // agent execution limits pushed to the host on sync
+}
+
+// AgentLimits caps agent execution on one host so dispatching a large team
+// cannot overload a shared machine. Zero values mean "no limit/unchanged".
+type AgentLimits struct {
+	MaxConcurrent int `json:"maxConcurrent,omitempty"` // max Claude processes at once across all agents on the host
+	Nice          int `json:"nice,omitempty"`          // CPU niceness for Claude processes, 1-19 (Unix)
+	IONiceClass   int `json:"ioniceClass,omitempty"`   // I/O scheduling class: 2 best-effort, 3 idle (Linux)
+}
+
+// HTTPToken is an HTTP API token limited to scopes ("read", "tasks:write",
+// "sessions:write", "admin") and, when Teams is set, to those teams.
+type HTTPToken struct {
+	Name      string    `json:"name"`
+	Token     string    `json:"token"`
+	Scopes    []string  `json:"scopes"`
+	Teams     []string  `json:"teams,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarkdownConfig controls how Markdown in task descriptions, results and
+// assistant replies is rendered in the TUI and the assistant chat.
+type MarkdownConfig struct {
+	Width int    `json:"width,omitempty"` // wrap column; 0 = the pane or terminal width
+	Theme string `json:"theme,omitempty"` // "dark" (default), "light", "plain" (no colors) or "raw" (unrendered)
+}
+
+// HTTPTLS makes codes serve use HTTPS, with the certificate in Cert and Key
+// or, with SelfSigned, one generated in ~/.codes/tls/. With ClientCA set,
+// clients must also present a certificate signed by it (mutual TLS).
+type HTTPTLS struct {
+	Cert       string `json:"cert,omitempty"`       // PEM certificate (chain) file
+	Key        string `json:"key,omitempty"`        // PEM private key file
+	ClientCA   string `json:"clientCA,omitempty"`   // PEM CA bundle for client certificates
+	SelfSigned bool   `json:"selfSigned,omitempty"` // generate and reuse a self-signed certificate
+}
+
+// Enabled reports whether t asks for TLS.
+func (t *HTTPTLS) Enabled() bool {
+	return t != nil && (t.Cert != "" || t.Key != "" || t.SelfSigned)
+}
+
+// HTTPLimits throttles the HTTP API served by `codes serve`. Zero fields use
+// the defaults; a negative rate turns that limit off.
+type HTTPLimits struct {
+	TokenRate    float64 `json:"tokenRate,omitempty"`    // requests per second per token (default 20)
+	TokenBurst   int     `json:"tokenBurst,omitempty"`   // requests a token may send at once (default 100)
+	IPRate       float64 `json:"ipRate,omitempty"`       // requests per second per client IP, authenticated or not (default 50)
+	IPBurst      int     `json:"ipBurst,omitempty"`      // requests an IP may send at once (default 200)
+	MaxBodyBytes int64   `json:"maxBodyBytes,omitempty"` // largest accepted request body (default 1 MiB)
+}
+
+// HTTPCORS lets web frontends served from other origins call the HTTP API
+// from a browser. Without it the API sends no CORS headers.
+type HTTPCORS struct {
+	AllowedOrigins   []string `json:"allowedOrigins"`             // e.g. "https://dash.example.com"; "*" allows any origin
+	AllowedHeaders   []string `json:"allowedHeaders,omitempty"`   // request headers besides the ones the API reads (Authorization, Content-Type, ...)
+	AllowCredentials bool     `json:"allowCredentials,omitempty"` // let the browser send cookies and client certificates
+	MaxAge           int      `json:"maxAge,omitempty"`           // seconds a browser may cache a preflight answer (default 600)
+}
+
+// PowerPolicy pauses agents on this machine before they pick up new tasks
+// while it runs on a low battery or is being thermally throttled. Running
+// tasks are not interrupted. The zero value never pauses.
+type PowerPolicy struct {
+	MinBattery     int  `json:"minBattery,omitempty"`     // on battery below this percentage, pause; 100 pauses whenever unplugged
+	PauseOnThermal bool `json:"pauseOnThermal,omitempty"` // pause while the OS reports thermal pressure
+}
+
+// Validate checks that limits are within the ranges nice/ionice accept.
+// Negative niceness and the realtime I/O class need root, so they are rejected.
+func (l AgentLimits) Validate() error {
+	if l.MaxConcurrent < 0 {
+		return fmt.Errorf("max concurrent must be >= 0, got %d", l.MaxConcurrent)
+	}
+	if l.Nice < 0 || l.Nice > 19 {
+		return fmt.Errorf("nice must be between 0 and 19, got %d", l.Nice)
+	}
+	if l.IONiceClass != 0 && l.IONiceClass != 2 && l.IONiceClass != 3 {
+		return fmt.Errorf("ionice class must be 2 (best-effort) or 3 (idle), got %d", l.IONiceClass)
+	}
+	return nil
 }
 
 // UserAtHost returns the SSH connection string (e.g., "user@host" or just "host").
@@ -59,9 +208,20 @@ func (r RemoteHost) UserAtHost() string {
 
 // ProjectEntry represents a project with an optional remote host.
 type ProjectEntry struct {
-	Path   string        `json:"path"`
-	Remote string        `json:"remote,omitempty"` // remote host name, empty = local
-	Links  []ProjectLink `json:"links,omitempty"`  // linked projects
+	Path     string          `json:"path"`
+	Remote   string          `json:"remote,omitempty"`   // remote host name, empty = local
+	Links    []ProjectLink   `json:"links,omitempty"`    // linked projects
+	Fork     *ForkInfo       `json:"fork,omitempty"`     // set when origin is a fork of another repo
+	Archived *ProjectArchive `json:"archived,omitempty"` // hidden from default lists when set
+	Tags     []string        `json:"tags,omitempty"`     // e.g. "work", "client"; see tags.go
+	Favorite bool            `json:"favorite,omitempty"` // pinned to the top of the TUI's project list
+}
+
+// ForkInfo records a fork relationship for a cloned project so PR automation
+// pushes to Origin and opens pull requests against Upstream.
+type ForkInfo struct {
+	Upstream string `json:"upstream"` // "owner/repo" PRs target (git remote "upstream")
+	Origin   string `json:"origin"`   // "owner/repo" of the fork (git remote "origin")
 }
 
 // UnmarshalJSON supports both old string format and new object format.
@@ -82,9 +242,9 @@ func (p *ProjectEntry) UnmarshalJSON(data []byte) error {
 }
 
 // MarshalJSON saves local projects as plain string (backward compat),
-// remote or linked projects as object.
+// remote, linked, forked or archived projects as object.
 func (p ProjectEntry) MarshalJSON() ([]byte, error) {
-	if p.Remote == "" && len(p.Links) == 0 {
+	if p.Remote == "" && len(p.Links) == 0 && p.Fork == nil && p.Archived == nil && len(p.Tags) == 0 && !p.Favorite {
 		return json.Marshal(p.Path)
 	}
 	type Alias ProjectEntry
@@ -199,6 +359,8 @@ func init() {
 }
 
 func LoadConfig() (*Config, error) {
+	defer trace.Start("config.load", "path", ConfigPath)()
+
 	// Check file permissions before reading
 	if err := checkConfigPermissions(ConfigPath); err != nil {
 		// Auto-fix insecure permissions instead of just warning
@@ -222,27 +384,216 @@ func LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
+// SaveConfig writes the config file. The file is replaced atomically, so a
+// concurrent LoadConfig sees either the old or the new config, never a
+// partial one. Use UpdateConfig to modify the current config.
 func SaveConfig(config *Config) error {
+	defer trace.Start("config.save", "path", ConfigPath)()
 	data, err := json.MarshalIndent(config, "", "    ")
 	if err != nil {
 		return err
 	}
 
-	dir := filepath.Dir(ConfigPath)
+	// Replace the target of a symlinked config (e.g. from a dotfiles repo)
+	// rather than the link itself.
+	path := ConfigPath
+	if target, err := os.Readlink(path); err == nil {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	dir := filepath.Dir(path)
 	os.MkdirAll(dir, 0755)
 
-	// Write with secure permissions (0600 = owner read/write only)
-	if err := os.WriteFile(ConfigPath, data, 0600); err != nil {
+	// Write with secure permissions (CreateTemp uses 0600 = owner read/write only)
+	tmp, err := os.CreateTemp(dir, ".config-*.json")
+	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
 
-	// Explicitly set permissions (WriteFile only applies mode on file creation, not on existing files)
-	if runtime.GOOS != "windows" {
-		if err := os.Chmod(ConfigPath, 0600); err != nil {
-			return fmt.Errorf("failed to set config file permissions: %w", err)
+// UpdateConfig loads the config, applies fn and saves the result while
+// holding an exclusive lock on the config, so concurrent writers (the TUI,
+// `codes serve`, MCP tools, other CLI invocations) don't overwrite each
+// other's changes. Nothing is saved if fn returns an error.
+func UpdateConfig(fn func(cfg *Config) error) error {
+	os.MkdirAll(filepath.Dir(ConfigPath), 0755)
+	fl := filelock.New(ConfigPath + ".lock")
+	if err := fl.Lock(); err != nil {
+		return fmt.Errorf("lock config: %w", err)
+	}
+	defer fl.Unlock()
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return SaveConfig(cfg)
+}
+
+// ErrProfileNotFound is returned for a profile name that is not configured.
+var ErrProfileNotFound = errors.New("profile not found")
+
+// ErrProfileExists is returned when adding a profile whose name is taken.
+var ErrProfileExists = errors.New("profile already exists")
+
+// RedactedValue replaces secret env values in exported or served configs.
+const RedactedValue = "<REDACTED>"
+
+// IsSensitiveEnv reports whether an env var key looks like it holds a secret
+// (a token, key, secret or password).
+func IsSensitiveEnv(key string) bool {
+	upper := strings.ToUpper(key)
+	return strings.Contains(upper, "TOKEN") ||
+		strings.Contains(upper, "KEY") ||
+		strings.Contains(upper, "SECRET") ||
+		strings.Contains(upper, "PASSWORD")
+}
+
+// AddProfile adds profile, making it the default if makeDefault is set or it
+// is the first one.
+func AddProfile(profile APIConfig, makeDefault bool) error {
+	return UpdateConfig(func(cfg *Config) error {
+		if FindProfile(cfg, profile.Name) != nil {
+			return fmt.Errorf("%w: %q", ErrProfileExists, profile.Name)
+		}
+		cfg.Profiles = append(cfg.Profiles, profile)
+		if makeDefault || cfg.Default == "" {
+			cfg.Default = profile.Name
+		}
+		return nil
+	})
+}
+
+// UpdateProfile applies fn to the profile called name and returns the result.
+func UpdateProfile(name string, fn func(p *APIConfig) error) (APIConfig, error) {
+	var updated APIConfig
+	err := UpdateConfig(func(cfg *Config) error {
+		p := FindProfile(cfg, name)
+		if p == nil {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+		updated = *p
+		return nil
+	})
+	return updated, err
+}
+
+// RemoveProfile deletes the profile called name. If it was the default, the
+// first remaining profile becomes the default, and is returned.
+func RemoveProfile(name string) (newDefault string, err error) {
+	err = UpdateConfig(func(cfg *Config) error {
+		found := -1
+		for i, c := range cfg.Profiles {
+			if c.Name == name {
+				found = i
+				break
+			}
 		}
+		if found == -1 {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+		}
+
+		cfg.Profiles = append(cfg.Profiles[:found], cfg.Profiles[found+1:]...)
+
+		if cfg.Default == name {
+			if len(cfg.Profiles) > 0 {
+				cfg.Default = cfg.Profiles[0].Name
+				newDefault = cfg.Default
+			} else {
+				cfg.Default = ""
+			}
+		}
+		return nil
+	})
+	return newDefault, err
+}
+
+// SetDefaultProfile makes name the default API profile.
+func SetDefaultProfile(name string) error {
+	return UpdateConfig(func(cfg *Config) error {
+		if FindProfile(cfg, name) == nil {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+		}
+		cfg.Default = name
+		return nil
+	})
+}
+
+// envKeyPattern matches the environment variable names a profile may set.
+var envKeyPattern = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+})
+
+// ValidateProfile checks a profile before it is saved: it needs a name, env
+// keys must be variable names, and ANTHROPIC_BASE_URL, when set, an http(s)
+// URL.
+func ValidateProfile(p APIConfig) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("profile name is required")
 	}
+	for key := range p.Env {
+		if !envKeyPattern().MatchString(key) {
+			return fmt.Errorf("invalid env var name %q", key)
+		}
+	}
+	if base := p.Env["ANTHROPIC_BASE_URL"]; base != "" {
+		if u, err := url.Parse(base); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("ANTHROPIC_BASE_URL %q is not an http(s) URL", base)
+		}
+	}
+	return nil
+}
+
+// ReplaceProfile replaces the profile called name with p, which may rename
+// it; the default follows a rename. p's Status is kept from the old profile
+// when empty.
+func ReplaceProfile(name string, p APIConfig) error {
+	if err := ValidateProfile(p); err != nil {
+		return err
+	}
+	return UpdateConfig(func(cfg *Config) error {
+		old := FindProfile(cfg, name)
+		if old == nil {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+		}
+		if p.Name != name && FindProfile(cfg, p.Name) != nil {
+			return fmt.Errorf("%w: %q", ErrProfileExists, p.Name)
+		}
+		if p.Status == "" {
+			p.Status = old.Status
+		}
+		*old = p
+		if cfg.Default == name {
+			cfg.Default = p.Name
+		}
+		return nil
+	})
+}
 
+// FindProfile returns the profile called name, or nil.
+func FindProfile(cfg *Config, name string) *APIConfig {
+	for i := range cfg.Profiles {
+		if cfg.Profiles[i].Name == name {
+			return &cfg.Profiles[i]
+		}
+	}
 	return nil
 }
 
@@ -615,11 +966,107 @@ func SetEditor(editor string) error {
 	return SaveConfig(cfg)
 }
 
+// DefaultSummaryModel is the model agents use to summarize long task results
+// unless summaryModel is set.
+const DefaultSummaryModel = "haiku"
+
+// GetSummaryModel returns the model for task result summaries, or "" when
+// summaries are turned off.
+func GetSummaryModel() string {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil || cfg.SummaryModel == "" {
+		return DefaultSummaryModel
+	}
+	if cfg.SummaryModel == "off" {
+		return ""
+	}
+	return cfg.SummaryModel
+}
+
+// SetSummaryModel saves the task result summary model ("off" disables
+// summaries, "" restores the default).
+func SetSummaryModel(model string) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.SummaryModel = model
+	return SaveConfig(cfg)
+}
+
+// GetPreventSleep reports whether agents keep the machine awake while they
+// run tasks.
+func GetPreventSleep() bool {
+	cfg, err := LoadConfig()
+	return err == nil && cfg != nil && cfg.PreventSleep
+}
+
+// SetPreventSleep saves the preventSleep setting.
+func SetPreventSleep(on bool) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.PreventSleep = on
+	return SaveConfig(cfg)
+}
+
+// GetPowerPolicy returns the battery/thermal policy for agents on this machine.
+func GetPowerPolicy() PowerPolicy {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil || cfg.PowerPolicy == nil {
+		return PowerPolicy{}
+	}
+	return *cfg.PowerPolicy
+}
+
+// SetPowerPolicy saves the battery/thermal policy. The zero value removes it
+// from config.
+func SetPowerPolicy(p PowerPolicy) error {
+	if p.MinBattery < 0 || p.MinBattery > 100 {
+		return fmt.Errorf("battery threshold must be between 0 and 100, got %d", p.MinBattery)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if p == (PowerPolicy{}) {
+		cfg.PowerPolicy = nil
+	} else {
+		cfg.PowerPolicy = &p
+	}
+	return SaveConfig(cfg)
+}
+
 // TerminalOptions returns the list of known terminal emulator options.
 func TerminalOptions() []string {
 	return []string{"terminal", "iterm", "warp"}
 }
 
+// GetCloneDefaults returns the default git mode clone options.
+func GetCloneDefaults() CloneOptions {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil || cfg.CloneDefaults == nil {
+		return CloneOptions{}
+	}
+	return *cfg.CloneDefaults
+}
+
+// SetCloneDefaults saves the default git mode clone options. The zero value
+// removes them from config.
+func SetCloneDefaults(opts CloneOptions) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if opts.Depth == 0 && !opts.SingleBranch && len(opts.SparsePaths) == 0 {
+		cfg.CloneDefaults = nil
+	} else {
+		cfg.CloneDefaults = &opts
+	}
+	return SaveConfig(cfg)
+}
+
 // GetProjectsDir returns the configured projects directory, defaulting to ~/Projects.
 func GetProjectsDir() string {
 	cfg, err := LoadConfig()
@@ -727,15 +1174,21 @@ func GetDefaultBehavior() string {
 
 // ProjectInfo holds detailed information about a registered project.
 type ProjectInfo struct {
-	Name           string        `json:"name"`
-	Path           string        `json:"path"`
-	Remote         string        `json:"remote,omitempty"` // remote host name, empty = local
-	Exists         bool          `json:"exists"`
-	GitBranch      string        `json:"gitBranch,omitempty"`
-	GitDirty       bool          `json:"gitDirty"`
-	HasClaudeMD    bool          `json:"hasClaudeMd"`
-	RecentBranches []string      `json:"recentBranches,omitempty"`
-	Links          []ProjectLink `json:"links,omitempty"`
+	Name           string          `json:"name"`
+	Path           string          `json:"path"`
+	Remote         string          `json:"remote,omitempty"` // remote host name, empty = local
+	Exists         bool            `json:"exists"`
+	GitBranch      string          `json:"gitBranch,omitempty"`
+	GitRemote      string          `json:"gitRemote,omitempty"` // URL of the origin remote
+	GitDirty       bool            `json:"gitDirty"`
+	HasClaudeMD    bool            `json:"hasClaudeMd"`
+	RecentBranches []string        `json:"recentBranches,omitempty"`
+	Worktrees      []Worktree      `json:"worktrees,omitempty"` // linked worktrees, without the main checkout
+	Links          []ProjectLink   `json:"links,omitempty"`
+	Fork           *ForkInfo       `json:"fork,omitempty"`
+	Archived       *ProjectArchive `json:"archived,omitempty"`
+	Tags           []string        `json:"tags,omitempty"`
+	Favorite       bool            `json:"favorite,omitempty"`
 }
 
 // GetProjectInfo aggregates project metadata including git status and file checks.
@@ -746,10 +1199,14 @@ func GetProjectInfo(name, path string) ProjectInfo {
 // GetProjectInfoFromEntry aggregates project metadata from a ProjectEntry.
 func GetProjectInfoFromEntry(name string, entry ProjectEntry) ProjectInfo {
 	info := ProjectInfo{
-		Name:   name,
-		Path:   entry.Path,
-		Remote: entry.Remote,
-		Links:  entry.Links,
+		Name:     name,
+		Path:     entry.Path,
+		Remote:   entry.Remote,
+		Links:    entry.Links,
+		Fork:     entry.Fork,
+		Archived: entry.Archived,
+		Tags:     entry.Tags,
+		Favorite: entry.Favorite,
 	}
 
 	// For remote projects, skip local filesystem checks
@@ -763,10 +1220,15 @@ func GetProjectInfoFromEntry(name string, entry ProjectEntry) ProjectInfo {
 	}
 	info.Exists = true
 
+	defer trace.Start("project.git", "project", name)()
 	info.GitBranch = getGitBranch(entry.Path)
+	info.GitRemote = getGitRemote(entry.Path)
 	info.GitDirty = isGitDirty(entry.Path)
 	info.HasClaudeMD = hasClaudeMD(entry.Path)
 	info.RecentBranches = getRecentGitBranches(entry.Path, 5)
+	if wts, err := ListWorktrees(entry.Path); err == nil && len(wts) > 1 {
+		info.Worktrees = wts[1:]
+	}
 
 	return info
 }
@@ -781,6 +1243,14 @@ func getGitBranch(dir string) string {
 	return strings.TrimSpace(string(out))
 }
 
+func getGitRemote(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 func isGitDirty(dir string) bool {
 	cmd := exec.Command("git", "status", "--porcelain")
 	cmd.Dir = dir
@@ -868,6 +1338,31 @@ func GetRemote(name string) (*RemoteHost, bool) {
 	return nil, false
 }
 
+// UpdateRemote replaces the stored configuration of an existing remote host.
+func UpdateRemote(host RemoteHost) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	for i, r := range cfg.Remotes {
+		if r.Name == host.Name {
+			cfg.Remotes[i] = host
+			return SaveConfig(cfg)
+		}
+	}
+	return fmt.Errorf("remote %q not found", host.Name)
+}
+
+// GetAgentLimits returns the agent execution limits for this host.
+func GetAgentLimits() AgentLimits {
+	cfg, err := LoadConfig()
+	if err != nil || cfg.AgentLimits == nil {
+		return AgentLimits{}
+	}
+	return *cfg.AgentLimits
+}
+
 // ListRemotes returns all configured remote hosts.
 func ListRemotes() ([]RemoteHost, error) {
 	cfg, err := LoadConfig()
@@ -877,45 +1372,73 @@ func ListRemotes() ([]RemoteHost, error) {
 	return cfg.Remotes, nil
 }
 
-// AddWebhook adds a webhook configuration.
-func AddWebhook(webhook WebhookConfig) error {
-	cfg, err := LoadConfig()
-	if err != nil {
-		return err
-	}
+// Webhook errors, matched with errors.Is.
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrWebhookExists   = errors.New("webhook already exists")
+)
 
-	// Check for duplicate name (if name is provided)
-	if webhook.Name != "" {
-		for _, w := range cfg.Webhooks {
-			if w.Name == webhook.Name {
-				return fmt.Errorf("webhook %q already exists", webhook.Name)
-			}
+// WebhookFormats are the payload formats a webhook can use.
+var WebhookFormats = []string{"slack", "feishu", "dingtalk", "telegram", "custom"}
+
+// WebhookEvents are the events a webhook can be limited to.
+var WebhookEvents = []string{"task_completed", "task_failed", "task_cancelled"}
+
+// ValidateWebhook checks that webhook has an http(s) URL, a known format
+// (empty means slack) with the extra parameters it needs, and known events.
+func ValidateWebhook(webhook WebhookConfig) error {
+	if !strings.HasPrefix(webhook.URL, "http://") && !strings.HasPrefix(webhook.URL, "https://") {
+		return fmt.Errorf("invalid webhook URL %q: must start with http:// or https://", webhook.URL)
+	}
+	if webhook.Format != "" && !slices.Contains(WebhookFormats, webhook.Format) {
+		return fmt.Errorf("invalid format %q: must be one of %s", webhook.Format, strings.Join(WebhookFormats, ", "))
+	}
+	if webhook.Format == "telegram" && webhook.Extra["chat_id"] == "" {
+		return fmt.Errorf("telegram format requires extra chat_id")
+	}
+	if webhook.Format == "custom" && webhook.Extra["template"] == "" {
+		return fmt.Errorf("custom format requires extra template")
+	}
+	for _, e := range webhook.Events {
+		if !slices.Contains(WebhookEvents, e) {
+			return fmt.Errorf("invalid event %q: must be one of %s", e, strings.Join(WebhookEvents, ", "))
 		}
 	}
+	return nil
+}
 
+// AddWebhook adds a webhook configuration.
+func AddWebhook(webhook WebhookConfig) error {
 	// Default format to "slack" if not specified
 	if webhook.Format == "" {
 		webhook.Format = "slack"
 	}
 
-	cfg.Webhooks = append(cfg.Webhooks, webhook)
-	return SaveConfig(cfg)
+	return UpdateConfig(func(cfg *Config) error {
+		// Check for duplicate name (if name is provided)
+		if webhook.Name != "" {
+			for _, w := range cfg.Webhooks {
+				if w.Name == webhook.Name {
+					return fmt.Errorf("%w: %q", ErrWebhookExists, webhook.Name)
+				}
+			}
+		}
+		cfg.Webhooks = append(cfg.Webhooks, webhook)
+		return nil
+	})
 }
 
 // RemoveWebhook removes a webhook by name or URL.
 func RemoveWebhook(identifier string) error {
-	cfg, err := LoadConfig()
-	if err != nil {
-		return err
-	}
-
-	for i, w := range cfg.Webhooks {
-		if w.Name == identifier || w.URL == identifier {
-			cfg.Webhooks = append(cfg.Webhooks[:i], cfg.Webhooks[i+1:]...)
-			return SaveConfig(cfg)
+	return UpdateConfig(func(cfg *Config) error {
+		for i, w := range cfg.Webhooks {
+			if w.Name == identifier || w.URL == identifier {
+				cfg.Webhooks = append(cfg.Webhooks[:i], cfg.Webhooks[i+1:]...)
+				return nil
+			}
 		}
-	}
-	return fmt.Errorf("webhook %q not found", identifier)
+		return fmt.Errorf("%w: %q", ErrWebhookNotFound, identifier)
+	})
 }
 
 // GetWebhook returns a webhook by name or URL.
@@ -942,10 +1465,53 @@ func ListWebhooks() ([]WebhookConfig, error) {
 	return cfg.Webhooks, nil
 }
 
-// validHookEvents defines the set of allowed hook event names.
-var validHookEvents = map[string]bool{
-	"on_task_completed": true,
-	"on_task_failed":    true,
+// AddHTTPToken adds a scoped HTTP API token.
+func AddHTTPToken(token HTTPToken) error {
+	return UpdateConfig(func(cfg *Config) error {
+		for _, t := range cfg.HTTPScopedTokens {
+			if t.Name == token.Name {
+				return fmt.Errorf("token %q already exists", token.Name)
+			}
+		}
+		cfg.HTTPScopedTokens = append(cfg.HTTPScopedTokens, token)
+		return nil
+	})
+}
+
+// RemoveHTTPToken removes a scoped HTTP API token by name.
+func RemoveHTTPToken(name string) error {
+	return UpdateConfig(func(cfg *Config) error {
+		for i, t := range cfg.HTTPScopedTokens {
+			if t.Name == name {
+				cfg.HTTPScopedTokens = append(cfg.HTTPScopedTokens[:i], cfg.HTTPScopedTokens[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("token %q not found", name)
+	})
+}
+
+// ListHTTPTokens returns the scoped HTTP API tokens.
+func ListHTTPTokens() ([]HTTPToken, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.HTTPScopedTokens, nil
+}
+
+// HookEvents are the events a hook script can be set for.
+var HookEvents = []string{
+	"on_task_started",
+	"on_task_completed",
+	"on_task_failed",
+	"on_task_cancelled",
+	"on_agent_started",
+	"on_agent_stopped",
+	"on_team_created",
+	"on_team_idle",
+	"on_session_started",
+	"on_update_available",
 }
 
 // GetHook returns the script path for the given event, or empty string if not set.
@@ -960,8 +1526,8 @@ func GetHook(event string) string {
 // SetHook sets a hook script for the given event.
 // Validates that the event name is valid and the script file exists and is executable.
 func SetHook(event, scriptPath string) error {
-	if !validHookEvents[event] {
-		return fmt.Errorf("invalid hook event %q (valid: on_task_completed, on_task_failed)", event)
+	if !slices.Contains(HookEvents, event) {
+		return fmt.Errorf("invalid hook event %q (valid: %s)", event, strings.Join(HookEvents, ", "))
 	}
 
 	info, err := os.Stat(scriptPath)
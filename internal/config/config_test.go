@@ -2,9 +2,15 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -251,6 +257,14 @@ func TestProjectEntry_MarshalJSON(t *testing.T) {
 			},
 			expected: `{"path":"/path/to/project","links":[{"name":"linked"}]}`,
 		},
+		{
+			name: "forked project - serialized as object",
+			entry: ProjectEntry{
+				Path: "/path/to/project",
+				Fork: &ForkInfo{Upstream: "acme/tool", Origin: "me/tool"},
+			},
+			expected: `{"path":"/path/to/project","fork":{"upstream":"acme/tool","origin":"me/tool"}}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -541,6 +555,133 @@ func TestLoadConfig_SaveConfig_RoundTrip(t *testing.T) {
 	}
 }
 
+// TestUpdateConfig_Concurrent checks that concurrent read-modify-write
+// updates don't lose each other's changes.
+func TestUpdateConfig_Concurrent(t *testing.T) {
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	if err := SaveConfig(&Config{Profiles: []APIConfig{{Name: "a"}, {Name: "b"}}, Default: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := UpdateConfig(func(cfg *Config) error {
+				if cfg.Projects == nil {
+					cfg.Projects = map[string]ProjectEntry{}
+				}
+				cfg.Projects[fmt.Sprintf("p%d", i)] = ProjectEntry{Path: "/src"}
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Projects) != writers {
+		t.Errorf("projects = %d, want %d (lost updates)", len(cfg.Projects), writers)
+	}
+
+	if err := SetDefaultProfile("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDefaultProfile("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("SetDefaultProfile(missing) = %v, want ErrProfileNotFound", err)
+	}
+	if cfg, _ := LoadConfig(); cfg.Default != "b" || len(cfg.Projects) != writers {
+		t.Errorf("default = %q, projects = %d", cfg.Default, len(cfg.Projects))
+	}
+}
+
+// TestReplaceProfile checks editing a profile in place, renaming it and the
+// validation that guards both.
+func TestReplaceProfile(t *testing.T) {
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	if err := SaveConfig(&Config{Profiles: []APIConfig{
+		{Name: "a", Env: map[string]string{"ANTHROPIC_AUTH_TOKEN": "t"}, Status: "active"},
+		{Name: "b"},
+	}, Default: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	yes := true
+	edited := APIConfig{Name: "work", Env: map[string]string{"ANTHROPIC_MODEL": "m", "ANTHROPIC_BASE_URL": "https://api.example.com"}, SkipPermissions: &yes}
+	if err := ReplaceProfile("a", edited); err != nil {
+		t.Fatalf("ReplaceProfile: %v", err)
+	}
+	cfg, _ := LoadConfig()
+	p := FindProfile(cfg, "work")
+	if p == nil || FindProfile(cfg, "a") != nil {
+		t.Fatalf("profiles = %+v, want a renamed to work", cfg.Profiles)
+	}
+	if cfg.Default != "work" || p.Status != "active" || p.Env["ANTHROPIC_AUTH_TOKEN"] != "" || p.SkipPermissions == nil || !*p.SkipPermissions {
+		t.Errorf("default = %q, profile = %+v", cfg.Default, *p)
+	}
+
+	if err := ReplaceProfile("work", APIConfig{Name: "b"}); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("rename onto b = %v, want ErrProfileExists", err)
+	}
+	if err := ReplaceProfile("missing", APIConfig{Name: "x"}); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("ReplaceProfile(missing) = %v, want ErrProfileNotFound", err)
+	}
+	for _, bad := range []APIConfig{
+		{Name: " "},
+		{Name: "x", Env: map[string]string{"BAD KEY": "v"}},
+		{Name: "x", Env: map[string]string{"ANTHROPIC_BASE_URL": "api.example.com"}},
+	} {
+		if err := ReplaceProfile("b", bad); err == nil {
+			t.Errorf("ReplaceProfile(%+v) succeeded, want a validation error", bad)
+		}
+	}
+}
+
+// TestSaveConfig_Symlink checks that saving through a symlinked config
+// updates the target instead of replacing the link.
+func TestSaveConfig_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "codes.json")
+	os.MkdirAll(filepath.Dir(target), 0755)
+	link := filepath.Join(dir, "config.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	origPath := ConfigPath
+	ConfigPath = link
+	defer func() { ConfigPath = origPath }()
+
+	if err := SaveConfig(&Config{Default: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("config link replaced: %v %v", fi, err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || !strings.Contains(string(data), `"x"`) {
+		t.Errorf("target = %s, %v", data, err)
+	}
+	if fi, _ := os.Stat(target); fi.Mode().Perm() != 0600 {
+		t.Errorf("mode = %o, want 600", fi.Mode().Perm())
+	}
+}
+
 // TestRemoteHost_UserAtHost tests SSH connection string formatting.
 func TestRemoteHost_UserAtHost(t *testing.T) {
 	tests := []struct {
@@ -575,3 +716,74 @@ func boolPtr(b bool) *bool {
 	return &b
 }
 
+
+func TestCloneOptions_CloneArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CloneOptions
+		want string
+	}{
+		{"full clone", CloneOptions{}, ""},
+		{"shallow", CloneOptions{Depth: 1}, "--depth 1"},
+		{"shallow single branch", CloneOptions{Depth: 1, SingleBranch: true}, "--depth 1 --single-branch"},
+		{"sparse", CloneOptions{SparsePaths: []string{"services/api"}}, "--filter=blob:none --sparse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.opts.CloneArgs(), " "); got != tt.want {
+				t.Errorf("CloneArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloneDefaults_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(tmpDir, "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	if err := SaveConfig(&Config{}); err != nil {
+		t.Fatal(err)
+	}
+	want := CloneOptions{Depth: 1, SingleBranch: true, SparsePaths: []string{"libs", "apps/web"}}
+	if err := SetCloneDefaults(want); err != nil {
+		t.Fatal(err)
+	}
+	got := GetCloneDefaults()
+	if got.Depth != 1 || !got.SingleBranch || strings.Join(got.SparsePaths, ",") != "libs,apps/web" {
+		t.Errorf("GetCloneDefaults() = %+v", got)
+	}
+
+	// The zero value removes the setting entirely
+	if err := SetCloneDefaults(CloneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := LoadConfig()
+	if cfg.CloneDefaults != nil {
+		t.Errorf("expected cloneDefaults to be cleared, got %+v", cfg.CloneDefaults)
+	}
+}
+
+func TestClaudeAvailable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the fake claude binary")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	if ClaudeAvailable() {
+		t.Fatal("expected claude to be missing from an empty PATH")
+	}
+	if _, err := ClaudePath(); !errors.Is(err, ErrClaudeNotFound) {
+		t.Errorf("ClaudePath() error = %v, want ErrClaudeNotFound", err)
+	}
+
+	fake := filepath.Join(dir, "claude")
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := ClaudePath(); err != nil || path != fake {
+		t.Errorf("ClaudePath() = %q, %v; want %q", path, err, fake)
+	}
+}
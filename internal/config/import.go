@@ -0,0 +1,364 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"codes/internal/trace"
+)
+
+// Importers discover projects known to other tools. Each returns
+// DiscoveredProject entries so they can be registered with
+// ImportDiscoveredProjects, just like `codes project scan`.
+
+// vscodeVariants are the per-editor config directories that use VS Code's
+// workspaceStorage layout.
+var vscodeVariants = []string{"Code", "Code - Insiders", "VSCodium", "Cursor"}
+
+// DiscoverVSCodeProjects returns the folders of the given .code-workspace
+// files. With no files, it reads the recently opened folders and workspaces
+// from VS Code's workspace storage.
+func DiscoverVSCodeProjects(workspaceFiles []string) ([]DiscoveredProject, error) {
+	seen := make(map[string]bool)
+	var discovered []DiscoveredProject
+	add := func(path string, lastActive time.Time) {
+		path = filepath.Clean(path)
+		if seen[path] || !isDir(path) {
+			return
+		}
+		seen[path] = true
+		discovered = append(discovered, DiscoveredProject{
+			Path:       path,
+			Name:       filepath.Base(path),
+			HasClaude:  hasClaudeMD(path),
+			LastActive: lastActive,
+		})
+	}
+
+	if len(workspaceFiles) > 0 {
+		for _, f := range workspaceFiles {
+			folders, err := readCodeWorkspace(f)
+			if err != nil {
+				return nil, err
+			}
+			for _, folder := range folders {
+				add(folder, time.Time{})
+			}
+		}
+		return discovered, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine config directory: %w", err)
+	}
+	for _, variant := range vscodeVariants {
+		storage := filepath.Join(configDir, variant, "User", "workspaceStorage")
+		entries, err := os.ReadDir(storage)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(storage, e.Name(), "workspace.json"))
+			if err != nil {
+				continue
+			}
+			var ws struct {
+				Folder    string `json:"folder"`
+				Workspace string `json:"workspace"`
+			}
+			if json.Unmarshal(data, &ws) != nil {
+				continue
+			}
+			var lastActive time.Time
+			if info, err := e.Info(); err == nil {
+				lastActive = info.ModTime()
+			}
+			if p := fileURIPath(ws.Folder); p != "" {
+				add(p, lastActive)
+			}
+			if p := fileURIPath(ws.Workspace); p != "" {
+				folders, _ := readCodeWorkspace(p)
+				for _, folder := range folders {
+					add(folder, lastActive)
+				}
+			}
+		}
+	}
+
+	sort.Slice(discovered, func(i, j int) bool {
+		return discovered[i].LastActive.After(discovered[j].LastActive)
+	})
+	return discovered, nil
+}
+
+// readCodeWorkspace returns the absolute folder paths of a .code-workspace
+// file. Relative paths are resolved against the file's directory.
+func readCodeWorkspace(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var ws struct {
+		Folders []struct {
+			Path string `json:"path"`
+			URI  string `json:"uri"`
+		} `json:"folders"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &ws); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	base := filepath.Dir(file)
+	var folders []string
+	for _, f := range ws.Folders {
+		switch {
+		case f.Path != "":
+			p := filepath.FromSlash(f.Path)
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(base, p)
+			}
+			folders = append(folders, p)
+		case f.URI != "":
+			if p := fileURIPath(f.URI); p != "" {
+				folders = append(folders, p)
+			}
+		}
+	}
+	return folders, nil
+}
+
+// fileURIPath converts a file:// URI to a local path. Other schemes, such as
+// vscode-remote://, return "".
+func fileURIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	p := u.Path
+	// file:///c:/Users/... on Windows
+	if runtime.GOOS == "windows" && len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas, which VS Code
+// allows in its JSON files.
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Drop a trailing comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// DiscoverZoxideProjects returns git repositories from the zoxide database,
+// falling back to the z/zsh-z data file (~/.z or $_Z_DATA). Results are
+// ordered by frecency and limited to directories scoring at least minScore.
+func DiscoverZoxideProjects(minScore float64) ([]DiscoveredProject, error) {
+	var entries []frecencyEntry
+	if out, err := exec.Command("zoxide", "query", "--list", "--score").Output(); err == nil {
+		entries = parseZoxideList(string(out))
+	} else {
+		dataFile := os.Getenv("_Z_DATA")
+		if dataFile == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("cannot determine home directory: %w", err)
+			}
+			dataFile = filepath.Join(home, ".z")
+		}
+		data, err := os.ReadFile(dataFile)
+		if err != nil {
+			return nil, fmt.Errorf("neither zoxide nor a z data file (%s) is available", dataFile)
+		}
+		entries = parseZData(string(data))
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].score > entries[j].score })
+
+	var discovered []DiscoveredProject
+	for _, e := range entries {
+		if e.score < minScore || !isGitRepo(e.path) {
+			continue
+		}
+		discovered = append(discovered, DiscoveredProject{
+			Path:       e.path,
+			Name:       filepath.Base(e.path),
+			HasClaude:  hasClaudeMD(e.path),
+			LastActive: e.lastAccess,
+		})
+	}
+	return discovered, nil
+}
+
+// frecencyEntry is a directory and its score from zoxide or z.
+type frecencyEntry struct {
+	path       string
+	score      float64
+	lastAccess time.Time
+}
+
+// parseZoxideList parses `zoxide query --list --score` output ("  12.5 /path").
+func parseZoxideList(out string) []frecencyEntry {
+	var entries []frecencyEntry
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		scoreStr, path, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		score, err := strconv.ParseFloat(scoreStr, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, frecencyEntry{path: strings.TrimSpace(path), score: score})
+	}
+	return entries
+}
+
+// parseZData parses the z data file format ("path|rank|unix-time").
+func parseZData(data string) []frecencyEntry {
+	var entries []frecencyEntry
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) != 3 || parts[0] == "" {
+			continue
+		}
+		score, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+		e := frecencyEntry{path: parts[0], score: score}
+		if ts, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
+			e.lastAccess = time.Unix(ts, 0)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// DiscoverGitHubProjects lists repositories with `gh repo list [owner]` and
+// returns the ones already cloned under root (the projects directory when
+// empty), either as root/<repo> or root/<owner>/<repo>. A clone matches only
+// if its origin remote points at the repository.
+func DiscoverGitHubProjects(owner, root string) ([]DiscoveredProject, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil, fmt.Errorf("GitHub CLI (gh) not found: https://cli.github.com")
+	}
+	args := []string{"repo", "list"}
+	if owner != "" {
+		args = append(args, owner)
+	}
+	args = append(args, "--limit", "1000", "--json", "name,nameWithOwner,pushedAt")
+	end := trace.Start("exec", "cmd", "gh repo list")
+	out, err := exec.Command("gh", args...).Output()
+	end()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("gh repo list: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, fmt.Errorf("gh repo list: %w", err)
+	}
+	var repos []struct {
+		Name          string    `json:"name"`
+		NameWithOwner string    `json:"nameWithOwner"`
+		PushedAt      time.Time `json:"pushedAt"`
+	}
+	if err := json.Unmarshal(out, &repos); err != nil {
+		return nil, fmt.Errorf("parse gh output: %w", err)
+	}
+
+	if root == "" {
+		root = GetProjectsDir()
+	}
+	var discovered []DiscoveredProject
+	for _, r := range repos {
+		repoOwner, _, _ := strings.Cut(r.NameWithOwner, "/")
+		for _, dir := range []string{filepath.Join(root, r.Name), filepath.Join(root, repoOwner, r.Name)} {
+			if !isGitRepo(dir) || !originMatches(dir, r.NameWithOwner) {
+				continue
+			}
+			discovered = append(discovered, DiscoveredProject{
+				Path:       dir,
+				Name:       r.Name,
+				HasClaude:  hasClaudeMD(dir),
+				LastActive: r.PushedAt,
+			})
+			break
+		}
+	}
+	return discovered, nil
+}
+
+// originMatches reports whether the origin remote of dir refers to the
+// GitHub repository nameWithOwner, over HTTPS or SSH.
+func originMatches(dir, nameWithOwner string) bool {
+	out, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return false
+	}
+	remote := strings.TrimSuffix(strings.TrimSpace(string(out)), ".git")
+	return strings.HasSuffix(strings.ToLower(remote), "/"+strings.ToLower(nameWithOwner)) ||
+		strings.HasSuffix(strings.ToLower(remote), ":"+strings.ToLower(nameWithOwner))
+}
+
+func isGitRepo(dir string) bool {
+	return pathExists(filepath.Join(dir, ".git"))
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCodeWorkspace(t *testing.T) {
+	tmpDir := t.TempDir()
+	abs := filepath.Join(tmpDir, "abs")
+	os.MkdirAll(filepath.Join(tmpDir, "ws", "app"), 0o755)
+	os.MkdirAll(abs, 0o755)
+
+	// VS Code workspace files are JSON with comments and trailing commas
+	file := filepath.Join(tmpDir, "ws", "team.code-workspace")
+	content := `{
+	// project folders
+	"folders": [
+		{ "path": "app" },
+		{ "uri": "file://` + filepath.ToSlash(abs) + `" }, /* absolute */
+		{ "uri": "vscode-remote://ssh-remote+box/srv/app" },
+	],
+	"settings": { "url": "http://example.com//x" },
+}`
+	os.WriteFile(file, []byte(content), 0o644)
+
+	folders, err := readCodeWorkspace(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(tmpDir, "ws", "app"), abs}
+	if len(folders) != len(want) {
+		t.Fatalf("folders = %v, want %v", folders, want)
+	}
+	for i := range want {
+		if folders[i] != want[i] {
+			t.Errorf("folders[%d] = %q, want %q", i, folders[i], want[i])
+		}
+	}
+
+	discovered, err := DiscoverVSCodeProjects([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discovered) != 2 || discovered[0].Name != "app" {
+		t.Errorf("DiscoverVSCodeProjects = %+v", discovered)
+	}
+}
+
+func TestParseFrecencyData(t *testing.T) {
+	zoxide := parseZoxideList("  48.0 /home/u/code/api\n   4.5 /home/u/my dir\ngarbage\n")
+	if len(zoxide) != 2 || zoxide[0].path != "/home/u/code/api" || zoxide[0].score != 48 || zoxide[1].path != "/home/u/my dir" {
+		t.Errorf("parseZoxideList = %+v", zoxide)
+	}
+
+	z := parseZData("/home/u/code/api|12|1700000000\nbad line\n/home/u/web|3.5|1700000100\n")
+	if len(z) != 2 || z[1].path != "/home/u/web" || z[1].score != 3.5 || z[0].lastAccess.Unix() != 1700000000 {
+		t.Errorf("parseZData = %+v", z)
+	}
+}
+
+func TestDiscoverZoxideProjects_ZDataFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "repo")
+	plain := filepath.Join(tmpDir, "plain")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0o755)
+	os.MkdirAll(plain, 0o755)
+
+	dataFile := filepath.Join(tmpDir, "z")
+	os.WriteFile(dataFile, []byte(repo+"|10|1700000000\n"+plain+"|50|1700000000\n"), 0o644)
+	t.Setenv("_Z_DATA", dataFile)
+	t.Setenv("PATH", tmpDir) // no zoxide binary
+
+	discovered, err := DiscoverZoxideProjects(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discovered) != 1 || discovered[0].Path != repo {
+		t.Errorf("expected only the git repo, got %+v", discovered)
+	}
+	if discovered, _ := DiscoverZoxideProjects(20); len(discovered) != 0 {
+		t.Errorf("min score should filter low-ranked repos, got %+v", discovered)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrWorkDirNotAllowed is returned when a team, task, agent or session would
+// run in a directory the work dir policy does not allow.
+var ErrWorkDirNotAllowed = errors.New("work dir not allowed by policy")
+
+// WorkDirPolicy limits the directories agents and sessions may run in, so a
+// misbehaving orchestrator cannot point them at / or ~/.ssh. The zero value
+// allows everything.
+type WorkDirPolicy struct {
+	AllowedRoots []string `json:"allowedRoots,omitempty"` // work dirs must be one of these or below one; empty = anywhere
+	DeniedPaths  []string `json:"deniedPaths,omitempty"`  // never these or anything below them, even inside an allowed root
+}
+
+// IsZero reports whether the policy allows every directory.
+func (p WorkDirPolicy) IsZero() bool {
+	return len(p.AllowedRoots) == 0 && len(p.DeniedPaths) == 0
+}
+
+// Check returns an error wrapping ErrWorkDirNotAllowed when dir is outside
+// the allowed roots or inside a denied path. Paths are compared after
+// expanding ~ and resolving symlinks, so a link into ~/.ssh is caught. An
+// empty dir is left to the caller's default and is not checked.
+func (p WorkDirPolicy) Check(dir string) error {
+	if dir == "" || p.IsZero() {
+		return nil
+	}
+	resolved, err := resolvePolicyPath(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrWorkDirNotAllowed, dir, err)
+	}
+	for _, denied := range p.DeniedPaths {
+		if root, err := resolvePolicyPath(denied); err == nil && pathWithin(resolved, root) {
+			return fmt.Errorf("%w: %s is inside denied path %s", ErrWorkDirNotAllowed, dir, denied)
+		}
+	}
+	if len(p.AllowedRoots) == 0 {
+		return nil
+	}
+	for _, allowed := range p.AllowedRoots {
+		if root, err := resolvePolicyPath(allowed); err == nil && pathWithin(resolved, root) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is outside the allowed roots (%s)", ErrWorkDirNotAllowed, dir, strings.Join(p.AllowedRoots, ", "))
+}
+
+// resolvePolicyPath returns path as an absolute, clean path with ~ expanded
+// and, as far as it exists, symlinks resolved.
+func resolvePolicyPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Resolve the longest existing prefix, so a directory that is about to
+	// be created below a symlink is judged by where it will really be
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if filepath.Dir(dir) == dir {
+			return path, nil
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// pathWithin reports whether path is root or below it.
+func pathWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// GetWorkDirPolicy returns the work dir policy for this machine.
+func GetWorkDirPolicy() WorkDirPolicy {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil || cfg.WorkDirPolicy == nil {
+		return WorkDirPolicy{}
+	}
+	return *cfg.WorkDirPolicy
+}
+
+// SetWorkDirPolicy saves the work dir policy. The zero value removes it from
+// config.
+func SetWorkDirPolicy(p WorkDirPolicy) error {
+	for _, path := range append(append([]string{}, p.AllowedRoots...), p.DeniedPaths...) {
+		if path == "" {
+			return fmt.Errorf("policy paths must not be empty")
+		}
+		if _, err := resolvePolicyPath(path); err != nil {
+			return fmt.Errorf("invalid policy path %q: %w", path, err)
+		}
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if p.IsZero() {
+		cfg.WorkDirPolicy = nil
+	} else {
+		cfg.WorkDirPolicy = &p
+	}
+	return SaveConfig(cfg)
+}
+
+// CheckWorkDir checks dir against the configured work dir policy.
+func CheckWorkDir(dir string) error {
+	return GetWorkDirPolicy().Check(dir)
+}
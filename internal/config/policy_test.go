@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWorkDirPolicy_Check(t *testing.T) {
+	root := t.TempDir()
+	code := filepath.Join(root, "code")
+	secrets := filepath.Join(code, "secrets")
+	os.MkdirAll(secrets, 0755)
+	t.Setenv("HOME", root)
+
+	policy := WorkDirPolicy{AllowedRoots: []string{"~/code"}, DeniedPaths: []string{secrets}}
+	tests := []struct {
+		dir     string
+		allowed bool
+	}{
+		{"", true}, // left to the caller's default
+		{code, true},
+		{filepath.Join(code, "app"), true}, // need not exist yet
+		{filepath.Join(code, "app", "..", "..", "other"), false},
+		{filepath.Join(root, "code-other"), false}, // a shared prefix is not a parent
+		{root, false},
+		{"/", false},
+		{secrets, false},
+		{filepath.Join(secrets, "keys"), false},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.dir)
+		if tt.allowed && err != nil {
+			t.Errorf("Check(%q) = %v, want allowed", tt.dir, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrWorkDirNotAllowed) {
+			t.Errorf("Check(%q) = %v, want ErrWorkDirNotAllowed", tt.dir, err)
+		}
+	}
+
+	// Only denied paths: everything else is allowed
+	if err := (WorkDirPolicy{DeniedPaths: []string{secrets}}).Check(root); err != nil {
+		t.Errorf("deny-only policy rejected %s: %v", root, err)
+	}
+	if err := (WorkDirPolicy{}).Check("/"); err != nil {
+		t.Errorf("zero policy rejected /: %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		// A link inside an allowed root is judged by where it points
+		link := filepath.Join(code, "escape")
+		if err := os.Symlink(root, link); err != nil {
+			t.Fatal(err)
+		}
+		if err := policy.Check(link); !errors.Is(err, ErrWorkDirNotAllowed) {
+			t.Errorf("Check(symlink out of root) = %v, want ErrWorkDirNotAllowed", err)
+		}
+		if err := policy.Check(filepath.Join(link, "new")); !errors.Is(err, ErrWorkDirNotAllowed) {
+			t.Errorf("Check(new dir below symlink) = %v, want ErrWorkDirNotAllowed", err)
+		}
+	}
+}
+
+func TestWorkDirPolicy_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(tmpDir, "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	if err := SaveConfig(&Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckWorkDir("/"); err != nil {
+		t.Errorf("CheckWorkDir without a policy: %v", err)
+	}
+	if err := SetWorkDirPolicy(WorkDirPolicy{AllowedRoots: []string{tmpDir}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckWorkDir("/"); !errors.Is(err, ErrWorkDirNotAllowed) {
+		t.Errorf("CheckWorkDir(/) = %v, want ErrWorkDirNotAllowed", err)
+	}
+	if err := CheckWorkDir(tmpDir); err != nil {
+		t.Errorf("CheckWorkDir(%s) = %v", tmpDir, err)
+	}
+	if err := SetWorkDirPolicy(WorkDirPolicy{DeniedPaths: []string{""}}); err == nil {
+		t.Error("expected an empty path to be rejected")
+	}
+
+	// The zero value removes the setting entirely
+	if err := SetWorkDirPolicy(WorkDirPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := LoadConfig()
+	if cfg.WorkDirPolicy != nil {
+		t.Errorf("expected workDirPolicy to be cleared, got %+v", cfg.WorkDirPolicy)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"codes/internal/trace"
 )
 
 // DiscoveredProject represents a project found by scanning ~/.claude/projects/
@@ -23,6 +25,7 @@ type DiscoveredProject struct {
 // It decodes the encoded directory names back to real filesystem paths,
 // validates they exist, and gathers metadata about each project.
 func ScanClaudeProjects() ([]DiscoveredProject, error) {
+	defer trace.Start("scan.claude_projects")()
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("cannot determine home directory: %w", err)
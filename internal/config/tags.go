@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tags group projects ("work", "personal", a client's name) and favorites
+// pin them, so long project lists stay usable: the TUI lists favorites
+// first and cycles a filter through the tags, and 'codes project list'
+// filters by one.
+
+// NormalizeTag lowercases and trims a tag. Tags may not contain spaces or
+// commas, which the CLI uses to separate them.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || strings.ContainsAny(tag, " \t,") {
+		return "", fmt.Errorf("invalid tag %q: use a single word such as work or client-acme", tag)
+	}
+	return tag, nil
+}
+
+// HasTag reports whether the project is tagged tag.
+func (p ProjectEntry) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// TagProject adds and removes tags on a project and returns its entry.
+// Tags are kept sorted and unique.
+func TagProject(name string, add, remove []string) (ProjectEntry, error) {
+	var entry ProjectEntry
+	err := UpdateConfig(func(cfg *Config) error {
+		var ok bool
+		if entry, ok = cfg.Projects[name]; !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		tags := make(map[string]bool, len(entry.Tags)+len(add))
+		for _, t := range entry.Tags {
+			tags[t] = true
+		}
+		for _, t := range add {
+			t, err := NormalizeTag(t)
+			if err != nil {
+				return err
+			}
+			tags[t] = true
+		}
+		for _, t := range remove {
+			t, err := NormalizeTag(t)
+			if err != nil {
+				return err
+			}
+			delete(tags, t)
+		}
+		entry.Tags = nil
+		for t := range tags {
+			entry.Tags = append(entry.Tags, t)
+		}
+		sort.Strings(entry.Tags)
+		cfg.Projects[name] = entry
+		return nil
+	})
+	return entry, err
+}
+
+// SetProjectFavorite pins or unpins a project.
+func SetProjectFavorite(name string, favorite bool) error {
+	return UpdateConfig(func(cfg *Config) error {
+		entry, ok := cfg.Projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		entry.Favorite = favorite
+		cfg.Projects[name] = entry
+		return nil
+	})
+}
+
+// ProjectTags returns every tag used by projects, sorted.
+func ProjectTags(projects map[string]ProjectEntry) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, p := range projects {
+		for _, t := range p.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTagProjectAndFavorite(t *testing.T) {
+	tmpDir := t.TempDir()
+	origPath := ConfigPath
+	ConfigPath = filepath.Join(tmpDir, "codes", "config.json")
+	defer func() { ConfigPath = origPath }()
+
+	if err := SaveConfig(&Config{Projects: map[string]ProjectEntry{
+		"api": {Path: tmpDir},
+		"web": {Path: tmpDir, Tags: []string{"work"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := TagProject("api", []string{" Work", "client-acme", "work"}, nil)
+	if err != nil {
+		t.Fatalf("TagProject: %v", err)
+	}
+	if want := []string{"client-acme", "work"}; !reflect.DeepEqual(entry.Tags, want) {
+		t.Errorf("tags = %v, want %v", entry.Tags, want)
+	}
+	if entry, _ = TagProject("api", nil, []string{"CLIENT-ACME"}); !reflect.DeepEqual(entry.Tags, []string{"work"}) {
+		t.Errorf("tags after removal = %v, want [work]", entry.Tags)
+	}
+	if _, err := TagProject("api", []string{"two words"}, nil); err == nil {
+		t.Error("expected an error for a tag with a space")
+	}
+	if _, err := TagProject("missing", []string{"work"}, nil); err == nil {
+		t.Error("expected an error for an unknown project")
+	}
+
+	if err := SetProjectFavorite("web", true); err != nil {
+		t.Fatalf("SetProjectFavorite: %v", err)
+	}
+	projects, _ := ListProjects()
+	if !projects["web"].Favorite || projects["api"].Favorite {
+		t.Errorf("favorites = web %v, api %v; want only web", projects["web"].Favorite, projects["api"].Favorite)
+	}
+	if !projects["api"].HasTag("work") || projects["api"].HasTag("client-acme") {
+		t.Errorf("api tags = %v", projects["api"].Tags)
+	}
+
+	TagProject("web", []string{"personal"}, nil)
+	projects, _ = ListProjects()
+	if got, want := ProjectTags(projects), []string{"personal", "work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProjectTags = %v, want %v", got, want)
+	}
+}
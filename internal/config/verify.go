@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Verify checks the config file for problems that hand edits or older
+// versions can leave behind, returning one description per problem. It
+// changes nothing; a missing config file has no problems.
+func Verify() ([]string, error) {
+	data, err := os.ReadFile(ConfigPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return []string{fmt.Sprintf("config.json is not valid JSON: %v", err)}, nil
+	}
+
+	var problems []string
+	if err := checkConfigPermissions(ConfigPath); err != nil {
+		problems = append(problems, err.Error())
+	}
+	problems = append(problems, verifyConfig(&cfg)...)
+	return problems, nil
+}
+
+// verifyConfig checks the references within cfg.
+func verifyConfig(cfg *Config) []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	var profiles []string
+	for _, p := range cfg.Profiles {
+		if slices.Contains(profiles, p.Name) {
+			add("profile %q is defined twice", p.Name)
+		}
+		profiles = append(profiles, p.Name)
+	}
+	if cfg.Default != "" && !slices.Contains(profiles, cfg.Default) {
+		add("default profile %q does not exist", cfg.Default)
+	}
+
+	var remotes []string
+	for _, r := range cfg.Remotes {
+		if slices.Contains(remotes, r.Name) {
+			add("remote %q is defined twice", r.Name)
+		}
+		remotes = append(remotes, r.Name)
+	}
+
+	names := make([]string, 0, len(cfg.Projects))
+	for name := range cfg.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Projects[name]
+		switch {
+		case p.Remote != "":
+			if !slices.Contains(remotes, p.Remote) {
+				add("project %q is on remote %q, which does not exist", name, p.Remote)
+			}
+		case !p.IsArchived():
+			if _, err := os.Stat(p.Path); err != nil {
+				add("project %q points to %s, which does not exist", name, p.Path)
+			}
+		}
+		for _, l := range p.Links {
+			if _, ok := cfg.Projects[l.Name]; !ok {
+				add("project %q links to %q, which does not exist", name, l.Name)
+			}
+		}
+	}
+
+	var tokens []string
+	for _, t := range cfg.HTTPScopedTokens {
+		if slices.Contains(tokens, t.Name) {
+			add("HTTP token %q is defined twice", t.Name)
+		}
+		tokens = append(tokens, t.Name)
+		if t.Token == "" {
+			add("HTTP token %q has no token value", t.Name)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{
+		Profiles: []APIConfig{{Name: "a"}, {Name: "a"}},
+		Default:  "b",
+		Remotes:  []RemoteHost{{Name: "box", Host: "box.local"}},
+		Projects: map[string]ProjectEntry{
+			"local":  {Path: dir, Links: []ProjectLink{{Name: "gone"}}},
+			"moved":  {Path: dir + "/missing"},
+			"remote": {Path: "/srv/app", Remote: "nowhere"},
+			"ok":     {Path: "/srv/app", Remote: "box"},
+		},
+		HTTPScopedTokens: []HTTPToken{{Name: "ci"}},
+	}
+
+	want := []string{
+		`profile "a" is defined twice`,
+		`default profile "b" does not exist`,
+		`project "local" links to "gone"`,
+		`project "moved" points to`,
+		`project "remote" is on remote "nowhere"`,
+		`HTTP token "ci" has no token value`,
+	}
+	problems := verifyConfig(cfg)
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %q, want %d", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(problems[i], w) {
+			t.Errorf("problem %d = %q, want prefix %q", i, problems[i], w)
+		}
+	}
+
+	if problems := verifyConfig(&Config{Profiles: []APIConfig{{Name: "a"}}, Default: "a"}); len(problems) != 0 {
+		t.Errorf("clean config has problems %q", problems)
+	}
+}
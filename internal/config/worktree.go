@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktrees let several sessions work on one project at once, each on its
+// own branch in its own checkout. New ones go in a "<project>-worktrees"
+// directory next to the project, one directory per branch.
+
+// Worktree is one entry of `git worktree list`.
+type Worktree struct {
+	Path     string `json:"path"`
+	Branch   string `json:"branch,omitempty"` // empty when detached
+	Head     string `json:"head,omitempty"`
+	Main     bool   `json:"main,omitempty"`     // the repository's own checkout
+	Prunable bool   `json:"prunable,omitempty"` // its directory is gone
+}
+
+// ListWorktrees returns the worktrees of the repository at dir, the main
+// checkout first.
+func ListWorktrees(dir string) ([]Worktree, error) {
+	out, err := exec.Command("git", "-C", dir, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git worktree list: %w", err)
+	}
+	return parseWorktrees(string(out)), nil
+}
+
+// parseWorktrees parses `git worktree list --porcelain`: blank-line
+// separated records of "key value" lines.
+func parseWorktrees(out string) []Worktree {
+	var wts []Worktree
+	for _, record := range strings.Split(strings.TrimSpace(out), "\n\n") {
+		var wt Worktree
+		for _, line := range strings.Split(record, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				wt.Path = value
+			case "HEAD":
+				wt.Head = value
+			case "branch":
+				wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+			case "prunable":
+				wt.Prunable = true
+			}
+		}
+		if wt.Path == "" {
+			continue
+		}
+		wt.Main = len(wts) == 0
+		wts = append(wts, wt)
+	}
+	return wts
+}
+
+// WorktreePath returns where AddWorktree puts branch's worktree for the
+// project at projectPath.
+func WorktreePath(projectPath, branch string) string {
+	dir := strings.NewReplacer("/", "-", "\\", "-", " ", "-").Replace(branch)
+	return filepath.Join(filepath.Dir(projectPath), filepath.Base(projectPath)+"-worktrees", dir)
+}
+
+// AddWorktree checks out branch in a new worktree of the project at
+// projectPath, creating the branch from HEAD if it does not exist, and
+// returns the worktree's path.
+func AddWorktree(projectPath, branch string) (string, error) {
+	branch = strings.TrimSpace(branch)
+	if branch == "" {
+		return "", errors.New("branch name is required")
+	}
+	if err := exec.Command("git", "-C", projectPath, "check-ref-format", "--branch", branch).Run(); err != nil {
+		return "", fmt.Errorf("invalid branch name %q", branch)
+	}
+	path := WorktreePath(projectPath, branch)
+	args := []string{"-C", projectPath, "worktree", "add"}
+	if exec.Command("git", "-C", projectPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil {
+		args = append(args, path, branch)
+	} else {
+		args = append(args, "-b", branch, path)
+	}
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git worktree add: %s", strings.TrimSpace(string(out)))
+	}
+	return path, nil
+}
+
+// RemoveWorktree deletes a worktree of the project at projectPath. Its
+// branch is kept. A stale worktree, whose directory is already gone, is
+// pruned instead. git refuses to remove a worktree with uncommitted changes
+// unless force is set.
+func RemoveWorktree(projectPath string, wt Worktree, force bool) error {
+	if wt.Main {
+		return errors.New("cannot remove the project's main checkout")
+	}
+	if wt.Prunable {
+		if out, err := exec.Command("git", "-C", projectPath, "worktree", "prune").CombinedOutput(); err != nil {
+			return fmt.Errorf("git worktree prune: %s", strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	args := []string{"-C", projectPath, "worktree", "remove"}
+	if force {
+		args = append(args, "--force")
+	}
+	if out, err := exec.Command("git", append(args, wt.Path)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree remove: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
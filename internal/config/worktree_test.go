@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorktrees(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	repo := filepath.Join(t.TempDir(), "app")
+	os.Mkdir(repo, 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0644)
+	git("add", ".")
+	git("commit", "-q", "-m", "initial commit")
+	git("branch", "existing")
+
+	path, err := AddWorktree(repo, "feature/login")
+	if err != nil {
+		t.Fatalf("AddWorktree: %v", err)
+	}
+	if want := filepath.Join(filepath.Dir(repo), "app-worktrees", "feature-login"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if _, err := AddWorktree(repo, "existing"); err != nil {
+		t.Fatalf("AddWorktree(existing branch): %v", err)
+	}
+	if _, err := AddWorktree(repo, "bad..name"); err == nil {
+		t.Error("expected an error for an invalid branch name")
+	}
+
+	wts, err := ListWorktrees(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wts) != 3 || !wts[0].Main || wts[0].Branch != "main" {
+		t.Fatalf("worktrees = %+v", wts)
+	}
+	if wts[1].Branch != "existing" && wts[2].Branch != "existing" {
+		t.Errorf("no worktree on branch existing: %+v", wts)
+	}
+
+	if err := RemoveWorktree(repo, wts[0], false); err == nil {
+		t.Error("expected an error removing the main checkout")
+	}
+	os.WriteFile(filepath.Join(path, "a.txt"), []byte("changed\n"), 0644)
+	login := Worktree{Path: path, Branch: "feature/login"}
+	if err := RemoveWorktree(repo, login, false); err == nil {
+		t.Error("expected git to refuse removing a dirty worktree")
+	}
+	if err := RemoveWorktree(repo, login, true); err != nil {
+		t.Fatalf("RemoveWorktree(force): %v", err)
+	}
+
+	// A worktree whose directory was deleted by hand is stale
+	os.RemoveAll(WorktreePath(repo, "existing"))
+	wts, _ = ListWorktrees(repo)
+	if len(wts) != 2 || !wts[1].Prunable {
+		t.Fatalf("worktrees after deleting a directory = %+v", wts)
+	}
+	if err := RemoveWorktree(repo, wts[1], false); err != nil {
+		t.Fatalf("RemoveWorktree(stale): %v", err)
+	}
+	if wts, _ = ListWorktrees(repo); len(wts) != 1 {
+		t.Errorf("worktrees after pruning = %+v", wts)
+	}
+}
@@ -0,0 +1,19 @@
+// Package filelock provides advisory locks on files, shared by processes that
+// update the same state on disk (agent tasks, execution slots, the config).
+package filelock
+
+import "os"
+
+// Lock provides mutual exclusion via file-based locking.
+// Platform-specific implementations are in filelock_unix.go and filelock_windows.go.
+type Lock struct {
+	path string
+	f    *os.File
+}
+
+// New creates a new file lock for the given path.
+func New(path string) *Lock {
+	return &Lock{path: path}
+}
+
+// Lock and Unlock are implemented in platform-specific files.
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package filelock
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock acquires an exclusive file lock (blocking).
+func (fl *Lock) Lock() error {
+	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	fl.f = f
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// TryLock attempts to acquire an exclusive file lock without blocking.
+// It reports false if another process holds the lock.
+func (fl *Lock) TryLock() (bool, error) {
+	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+		return false, err
+	}
+	fl.f = f
+	return true, nil
+}
+
+// Unlock releases the file lock.
+func (fl *Lock) Unlock() error {
+	if fl.f == nil {
+		return nil
+	}
+	f := fl.f
+	fl.f = nil
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return f.Close()
+}
@@ -1,6 +1,6 @@
 //go:build windows
 
-package agent
+package filelock
 
 import (
 	"os"
@@ -15,11 +15,13 @@ var (
 )
 
 const (
-	lockfileExclusiveLock = 0x00000002
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+	errorLockViolation      = syscall.Errno(33)
 )
 
 // Lock acquires an exclusive file lock (blocking).
-func (fl *FileLock) Lock() error {
+func (fl *Lock) Lock() error {
 	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return err
@@ -40,8 +42,35 @@ func (fl *FileLock) Lock() error {
 	return nil
 }
 
+// TryLock attempts to acquire an exclusive file lock without blocking.
+// It reports false if another process holds the lock.
+func (fl *Lock) TryLock() (bool, error) {
+	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, err
+	}
+
+	var ol syscall.Overlapped
+	r1, _, err := procLockFileEx.Call(
+		uintptr(f.Fd()),
+		uintptr(lockfileExclusiveLock|lockfileFailImmediately),
+		0,
+		1, 0,
+		uintptr(unsafe.Pointer(&ol)),
+	)
+	if r1 == 0 {
+		f.Close()
+		if err == errorLockViolation {
+			return false, nil
+		}
+		return false, err
+	}
+	fl.f = f
+	return true, nil
+}
+
 // Unlock releases the file lock.
-func (fl *FileLock) Unlock() error {
+func (fl *Lock) Unlock() error {
 	if fl.f == nil {
 		return nil
 	}
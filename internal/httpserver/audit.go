@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"codes/internal/audit"
+)
+
+// SetAudit turns on the audit log: every request that is not a GET, HEAD or
+// OPTIONS is recorded with audit.Record once it has been handled. It is off
+// by default so tests do not write to the user's audit file.
+func (s *HTTPServer) SetAudit(on bool) {
+	s.auditing = on
+}
+
+// audited records next's requests that change state (see SetAudit).
+func (s *HTTPServer) audited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.auditing || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		// Keep what the handler reads of the body for the digest; it is
+		// bounded by the body limit
+		var body bytes.Buffer
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(r.Body, &body), r.Body}
+		}
+		lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(lrw, r)
+
+		e := audit.Entry{
+			Source: audit.SourceHTTP,
+			Action: r.Method + " " + r.URL.Path,
+			Params: audit.Digest(body.Bytes()),
+			Result: audit.ResultOK,
+			Status: lrw.statusCode,
+		}
+		if lrw.statusCode >= 400 {
+			e.Result = audit.ResultError
+		}
+		if info := requestInfoFrom(r.Context()); info != nil {
+			e.Actor, e.RequestID = info.token, info.id
+		}
+		if err := audit.Record(e); err != nil {
+			log.Printf("[HTTP] audit: %v", err)
+		}
+	}
+}
+
+// handleAudit handles GET /audit (admin scope).
+func (s *HTTPServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := audit.Filter{Source: q.Get("source"), Actor: q.Get("actor"), Limit: 100}
+	if v := q.Get("since"); v != "" {
+		since, err := audit.ParseSince(v, time.Now())
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := audit.Query(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read audit log: %v", err))
+		return
+	}
+	resp := AuditResponse{Entries: make([]AuditEntry, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, AuditEntry{
+			Time:      e.Time,
+			Source:    e.Source,
+			Actor:     e.Actor,
+			Action:    e.Action,
+			Params:    e.Params,
+			Result:    e.Result,
+			Status:    e.Status,
+			Error:     e.Error,
+			RequestID: e.RequestID,
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
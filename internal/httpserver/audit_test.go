@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	captureAccessLog(t)
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetAdminTokens([]string{"admin-token"})
+	server.SetAudit(true)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		var req *http.Request
+		if body != "" {
+			req = httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req = httptest.NewRequest(method, path, nil)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	team := uniqueTeamName("audit")
+	if w := send(http.MethodPost, "/teams", "test-token", `{"name":"`+team+`"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create team: %d %s", w.Code, w.Body.String())
+	}
+	send(http.MethodGet, "/teams", "test-token", "")                 // reads are not audited
+	send(http.MethodDelete, "/teams/no-such-team", "test-token", "") // failures are
+
+	if w := send(http.MethodGet, "/audit", "test-token", ""); w.Code != http.StatusForbidden {
+		t.Errorf("GET /audit without admin scope: %d, want 403", w.Code)
+	}
+
+	w := send(http.MethodGet, "/audit?source=http&since=1h", "admin-token", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /audit: %d %s", w.Code, w.Body.String())
+	}
+	var resp AuditResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 2 {
+		t.Fatalf("%d entries, want 2: %+v", len(resp.Entries), resp.Entries)
+	}
+	deleted, created := resp.Entries[0], resp.Entries[1]
+	if created.Action != "POST /teams" || created.Result != "ok" || created.Status != http.StatusCreated ||
+		!strings.HasPrefix(created.Actor, "sha256:") || created.Params == "" || created.RequestID == "" {
+		t.Errorf("create entry = %+v", created)
+	}
+	if deleted.Action != "DELETE /teams/no-such-team" || deleted.Result != "error" {
+		t.Errorf("delete entry = %+v", deleted)
+	}
+
+	if w := send(http.MethodGet, "/audit?since=yesterday", "admin-token", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad since: %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,233 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Responses are compressed with gzip or deflate when the client accepts it
+// (Accept-Encoding), the body is text such as JSON, HTML or JavaScript, and
+// it is at least minCompressSize bytes. Streams are left alone: WebSocket
+// upgrades, the MCP SSE endpoint and anything sent as text/event-stream.
+
+// minCompressSize is the smallest body worth compressing; shorter ones are
+// sent as they are.
+const minCompressSize = 1024
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() any { return zlib.NewWriter(io.Discard) }}
+)
+
+// compressMiddleware compresses the responses of requests that negotiate an
+// encoding.
+func compressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isStreamRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// isStreamRequest reports whether r is for a response that must reach the
+// client as it is written: a WebSocket upgrade or a server-sent event stream.
+func isStreamRequest(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" ||
+		strings.HasPrefix(r.URL.Path, "/mcp/") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip when both are equally welcome, or returns "" when the
+// client accepts neither.
+func negotiateEncoding(header string) string {
+	q := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = f
+		}
+		if name == "*" {
+			wildcard = weight
+		} else {
+			q[name] = weight
+		}
+	}
+	weightOf := func(enc string) float64 {
+		if w, ok := q[enc]; ok {
+			return w
+		}
+		return max(wildcard, 0)
+	}
+	gz, df := weightOf("gzip"), weightOf("deflate")
+	switch {
+	case gz > 0 && gz >= df:
+		return "gzip"
+	case df > 0:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressible reports whether a response with this Content-Type is text
+// that compresses well.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/javascript", mediaType == "application/xml",
+		mediaType == "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter holds the start of the body back until it knows whether
+// the response is worth compressing, then writes the headers and the body,
+// compressed or not.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	status      int
+	wroteHeader bool           // the handler called WriteHeader or Write
+	started     bool           // headers sent to the client
+	buf         []byte         // body held back, under minCompressSize
+	zw          io.WriteCloser // set when compressing
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.WriteHeader(http.StatusOK)
+	if !cw.started {
+		if !cw.eligible() {
+			cw.start(false)
+		} else if len(cw.buf)+len(p) < minCompressSize {
+			cw.buf = append(cw.buf, p...)
+			return len(p), nil
+		} else {
+			cw.start(true)
+		}
+	}
+	if cw.zw != nil {
+		return cw.zw.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// eligible reports whether the response as the handler set it up may be
+// compressed.
+func (cw *compressWriter) eligible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	switch cw.status {
+	case http.StatusNoContent, http.StatusPartialContent, http.StatusNotModified:
+		return false
+	}
+	return cw.status >= 200 && compressible(h.Get("Content-Type"))
+}
+
+// start sends the headers, switching to the compressed encoding if compress
+// is set, and writes out the held-back body.
+func (cw *compressWriter) start(compress bool) {
+	cw.started = true
+	if compress {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		if cw.encoding == "gzip" {
+			gw := gzipWriters.Get().(*gzip.Writer)
+			gw.Reset(cw.ResponseWriter)
+			cw.zw = gw
+		} else {
+			zw := zlibWriters.Get().(*zlib.Writer)
+			zw.Reset(cw.ResponseWriter)
+			cw.zw = zw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) > 0 {
+		buf := cw.buf
+		cw.buf = nil
+		if cw.zw != nil {
+			cw.zw.Write(buf)
+		} else {
+			cw.ResponseWriter.Write(buf)
+		}
+	}
+}
+
+// Flush sends what has been written so far, so long polls and progress
+// output are not held back.
+func (cw *compressWriter) Flush() {
+	if !cw.started && cw.wroteHeader {
+		cw.start(false)
+	}
+	if f, ok := cw.zw.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close finishes the response: a body too short to compress is sent as it
+// is, and a compressed one is terminated.
+func (cw *compressWriter) close() {
+	if !cw.started {
+		if !cw.wroteHeader {
+			return // nothing written; net/http sends the default response
+		}
+		cw.start(false)
+	}
+	switch zw := cw.zw.(type) {
+	case *gzip.Writer:
+		zw.Close()
+		gzipWriters.Put(zw)
+	case *zlib.Writer:
+		zw.Close()
+		zlibWriters.Put(zw)
+	}
+}
@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header, want string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"*", "gzip"},
+		{"gzip;q=0, *", "deflate"},
+		{"br, GZIP;q=0.8", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"subject":"task"},`, 200)
+	handler := compressMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			respondJSON(w, http.StatusOK, "ok")
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(large))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "999999")
+			w.Write([]byte(large[:500]))
+			w.Write([]byte(large[500:]))
+		}
+	}))
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, enc := range []string{"gzip", "deflate"} {
+		w := get("/large", map[string]string{"Accept-Encoding": enc})
+		if got := w.Header().Get("Content-Encoding"); got != enc {
+			t.Fatalf("%s: Content-Encoding = %q", enc, got)
+		}
+		if w.Header().Get("Content-Length") != "" {
+			t.Errorf("%s: Content-Length of the uncompressed body kept", enc)
+		}
+		var r io.Reader
+		var err error
+		if enc == "gzip" {
+			r, err = gzip.NewReader(w.Body)
+		} else {
+			r, err = zlib.NewReader(w.Body)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(r)
+		if err != nil || string(body) != large {
+			t.Errorf("%s: decoded %d bytes (%v), want the original %d", enc, len(body), err, len(large))
+		}
+	}
+
+	if w := get("/large", nil); w.Header().Get("Content-Encoding") != "" || w.Body.String() != large {
+		t.Error("compressed without Accept-Encoding")
+	}
+	if w := get("/large", nil); w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q", w.Header().Get("Vary"))
+	}
+	if w := get("/small", map[string]string{"Accept-Encoding": "gzip"}); w.Header().Get("Content-Encoding") != "" || w.Body.String() != "\"ok\"\n" {
+		t.Errorf("small body: encoding %q, body %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+	if w := get("/binary", map[string]string{"Accept-Encoding": "gzip"}); w.Header().Get("Content-Encoding") != "" {
+		t.Error("binary body compressed")
+	}
+	for _, h := range []map[string]string{
+		{"Accept-Encoding": "gzip", "Upgrade": "websocket"},
+		{"Accept-Encoding": "gzip", "Accept": "text/event-stream"},
+	} {
+		if w := get("/large", h); w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" {
+			t.Errorf("stream request %v was compressed", h)
+		}
+	}
+	if w := get("/mcp/sse", map[string]string{"Accept-Encoding": "gzip"}); w.Header().Get("Content-Encoding") != "" {
+		t.Error("MCP SSE endpoint compressed")
+	}
+}
+
+func TestCompressedAPIResponse(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("status %d, Content-Encoding %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if !strings.Contains(string(body), `"openapi"`) {
+		t.Errorf("decoded body is not the OpenAPI document: %.100s", body)
+	}
+}
@@ -0,0 +1,133 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"codes/internal/config"
+	"codes/internal/update"
+)
+
+// CORS: once SetCORS is called with allowed origins (httpCORS), a web
+// frontend served from one of them may call the API from a browser. Requests
+// from other origins get no CORS headers, so the browser keeps their
+// responses from the page, and their WebSocket upgrades are refused, since
+// browsers do not apply CORS to WebSockets.
+
+const defaultCORSMaxAge = 600
+
+// corsHeaders are the request headers the API reads, always allowed.
+var corsHeaders = []string{"Authorization", "Content-Type", idempotencyKeyHeader, requestIDHeader, update.VersionHeader, "Last-Event-ID"}
+
+// corsExposedHeaders are the response headers a page may read.
+var corsExposedHeaders = []string{requestIDHeader, update.VersionHeader, idempotentReplayedHeader, "Retry-After", "Content-Disposition"}
+
+const corsMethods = "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// corsPolicy is the CORS configuration set by SetCORS; nil sends no CORS
+// headers.
+type corsPolicy struct {
+	origins     []string // normalized, see normalizeOrigin
+	anyOrigin   bool
+	headers     string // Access-Control-Allow-Headers
+	credentials bool
+	maxAge      string
+}
+
+// SetCORS lets browsers on the configured origins call the API. A nil
+// config or one without origins turns CORS off.
+func (s *HTTPServer) SetCORS(c *config.HTTPCORS) {
+	if c == nil || len(c.AllowedOrigins) == 0 {
+		s.cors = nil
+		return
+	}
+	p := &corsPolicy{credentials: c.AllowCredentials, maxAge: strconv.Itoa(defaultCORSMaxAge)}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			p.anyOrigin = true
+		} else if o = normalizeOrigin(o); o != "" {
+			p.origins = append(p.origins, o)
+		}
+	}
+	headers := append([]string(nil), corsHeaders...)
+	for _, h := range c.AllowedHeaders {
+		h = http.CanonicalHeaderKey(strings.TrimSpace(h))
+		if h != "" && !slices.Contains(headers, h) {
+			headers = append(headers, h)
+		}
+	}
+	p.headers = strings.Join(headers, ", ")
+	if c.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(c.MaxAge)
+	}
+	s.cors = p
+}
+
+// normalizeOrigin returns the scheme://host[:port] of an origin in lower
+// case, without a trailing slash, or "" if it is not one.
+func normalizeOrigin(origin string) string {
+	u, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// allows reports whether a page from origin may call the API.
+func (p *corsPolicy) allows(origin string) bool {
+	return p.anyOrigin || slices.Contains(p.origins, normalizeOrigin(origin))
+}
+
+// sameOrigin reports whether the Origin of r is the server itself, as for
+// the dashboard.
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// corsMiddleware answers preflight requests from allowed origins and adds
+// the CORS headers to their other responses, errors included. It runs
+// before the rate limit and authentication: browsers send preflights
+// without the Authorization header.
+func (s *HTTPServer) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := s.cors
+		origin := r.Header.Get("Origin")
+		if p == nil || origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !p.allows(origin) {
+			if r.Header.Get("Upgrade") != "" && !sameOrigin(r, origin) {
+				respondError(w, http.StatusForbidden, "origin not allowed (httpCORS)")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		if p.anyOrigin && !p.credentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", corsMethods)
+			h.Set("Access-Control-Allow-Headers", p.headers)
+			h.Set("Access-Control-Max-Age", p.maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,86 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestCORS(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	do := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w
+	}
+	const frontend = "https://dash.example.com"
+	preflight := map[string]string{"Origin": frontend, "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "authorization, content-type"}
+
+	// Off by default: no CORS headers, preflights reach the API
+	if w := do(http.MethodGet, "/teams", map[string]string{"Origin": frontend, "Authorization": "Bearer test-token"}); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers sent without httpCORS")
+	}
+	if w := do(http.MethodOptions, "/teams", preflight); w.Code == http.StatusNoContent {
+		t.Error("preflight answered without httpCORS")
+	}
+
+	server.SetCORS(&config.HTTPCORS{AllowedOrigins: []string{"https://DASH.example.com/"}, AllowedHeaders: []string{"x-trace"}, MaxAge: 60})
+
+	w := do(http.MethodOptions, "/teams", preflight)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("preflight: %d %s", w.Code, w.Body.String())
+	}
+	h := w.Header()
+	if h.Get("Access-Control-Allow-Origin") != frontend || h.Get("Access-Control-Max-Age") != "60" {
+		t.Errorf("preflight headers: %v", h)
+	}
+	if allowed := h.Get("Access-Control-Allow-Headers"); !strings.Contains(allowed, "Authorization") || !strings.Contains(allowed, "X-Trace") {
+		t.Errorf("Access-Control-Allow-Headers = %q", allowed)
+	}
+	if !strings.Contains(h.Get("Access-Control-Allow-Methods"), "PATCH") || h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("preflight headers: %v", h)
+	}
+
+	w = do(http.MethodGet, "/teams", map[string]string{"Origin": frontend, "Authorization": "Bearer test-token"})
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != frontend {
+		t.Errorf("GET: %d, Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), requestIDHeader) {
+		t.Errorf("Access-Control-Expose-Headers = %q", w.Header().Get("Access-Control-Expose-Headers"))
+	}
+	// Errors carry the headers too, so the page can read them
+	if w := do(http.MethodGet, "/teams", map[string]string{"Origin": frontend}); w.Code != http.StatusUnauthorized || w.Header().Get("Access-Control-Allow-Origin") != frontend {
+		t.Errorf("401: %d, Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// Other origins get nothing, and cannot open a WebSocket
+	w = do(http.MethodOptions, "/teams", map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"})
+	if w.Code == http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight from another origin: %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodGet, "/sessions/x/ws", map[string]string{"Origin": "https://evil.example.com", "Upgrade": "websocket"}); w.Code != http.StatusForbidden {
+		t.Errorf("WebSocket from another origin: %d, want 403", w.Code)
+	}
+	// The dashboard's own WebSocket is same-origin
+	if w := do(http.MethodGet, "/sessions/x/ws", map[string]string{"Origin": "http://example.com", "Upgrade": "websocket"}); w.Code == http.StatusForbidden {
+		t.Errorf("same-origin WebSocket refused: %s", w.Body.String())
+	}
+
+	// "*" allows any origin; with credentials the origin is echoed
+	server.SetCORS(&config.HTTPCORS{AllowedOrigins: []string{"*"}})
+	if w := do(http.MethodOptions, "/teams", preflight); w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("wildcard: Allow-Origin %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	server.SetCORS(&config.HTTPCORS{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	w = do(http.MethodOptions, "/teams", preflight)
+	if w.Header().Get("Access-Control-Allow-Origin") != frontend || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("wildcard with credentials: %v", w.Header())
+	}
+}
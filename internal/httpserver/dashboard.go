@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// The web dashboard is a static page in web/ that signs in with an API token
+// and then uses the same REST endpoints and session WebSocket as any other
+// client, so it needs no handlers of its own beyond serving the files.
+
+//go:embed web
+var webFiles embed.FS
+
+// webFS is web/ without the directory prefix.
+var webFS, _ = fs.Sub(webFiles, "web")
+
+// handleDashboard handles GET /, the dashboard page.
+func (s *HTTPServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.serveWebFile(w, r, "index.html")
+}
+
+// handleDashboardAsset handles GET /ui/{file}, the dashboard's scripts and
+// styles.
+func (s *HTTPServer) handleDashboardAsset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.serveWebFile(w, r, strings.TrimPrefix(r.URL.Path, "/ui/"))
+}
+
+func (s *HTTPServer) serveWebFile(w http.ResponseWriter, r *http.Request, name string) {
+	if !fs.ValidPath(name) {
+		respondError(w, http.StatusBadRequest, "invalid asset path")
+		return
+	}
+	if info, err := fs.Stat(webFS, name); err != nil || info.IsDir() {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("asset %s does not exist", name))
+		return
+	}
+	// Assets change with the binary, so revalidate instead of caching
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFileFS(w, r, webFS, name)
+}
@@ -0,0 +1,75 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboard(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<script src="/ui/app.js">`) {
+		t.Fatalf("GET /: status %d, body %.200s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("GET / Content-Type = %q", ct)
+	}
+
+	for path, wantType := range map[string]string{"/ui/app.js": "javascript", "/ui/style.css": "text/css"} {
+		w := get(path)
+		if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), wantType) {
+			t.Errorf("GET %s: status %d, Content-Type %q", path, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+	if w := get("/ui/missing.js"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /ui/missing.js: status %d, want 404", w.Code)
+	}
+
+	// The page only takes the root; unknown paths are still not found
+	if w := get("/no-such-endpoint"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /no-such-endpoint: status %d, want 404", w.Code)
+	}
+}
+
+func TestWebSocketProtocolToken(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+
+	upgrade := func(protocols string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/sessions/missing/ws", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		if protocols != "" {
+			req.Header.Set("Sec-WebSocket-Protocol", protocols)
+		}
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := upgrade(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status %d, want 401", w.Code)
+	}
+	if w := upgrade("codes, bearer.wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d, want 401", w.Code)
+	}
+	// Past auth, the session lookup fails
+	if w := upgrade("codes, bearer.test-token"); w.Code != http.StatusNotFound {
+		t.Errorf("subprotocol token: status %d, want 404 (%s)", w.Code, w.Body.String())
+	}
+
+	// Only WebSocket handshakes may carry the token this way
+	req := httptest.NewRequest(http.MethodGet, "/teams", nil)
+	req.Header.Set("Sec-WebSocket-Protocol", "bearer.test-token")
+	if websocketProtocolToken(req) != "" {
+		t.Error("token accepted from a plain request")
+	}
+}
@@ -17,8 +17,9 @@ func (s *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondJSON(w, http.StatusOK, HealthResponse{
-		Status:  "ok",
-		Version: s.version,
+		Status:   "ok",
+		Version:  s.version,
+		ReadOnly: s.readOnly,
 	})
 }
 
@@ -81,6 +82,13 @@ func (s *HTTPServer) handleGetTask(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// teamSortKeys are the sort keys of GET /teams.
+var teamSortKeys = sortKeys[TeamSummary]{
+	"name":         func(a, b TeamSummary) int { return strings.Compare(a.Name, b.Name) },
+	"created_at":   func(a, b TeamSummary) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"member_count": func(a, b TeamSummary) int { return a.MemberCount - b.MemberCount },
+}
+
 // handleListTeams handles GET /teams
 func (s *HTTPServer) handleListTeams(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -88,14 +96,24 @@ func (s *HTTPServer) handleListTeams(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := parseListParams(r.URL.Query(), teamSortKeys, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	teamNames, err := agent.ListTeams()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list teams: %v", err))
 		return
 	}
 
+	g := grantFrom(r.Context())
 	summaries := make([]TeamSummary, 0, len(teamNames))
 	for _, name := range teamNames {
+		if !g.allowsTeam(name) {
+			continue
+		}
 		team, err := agent.GetTeam(name)
 		if err != nil {
 			continue
@@ -108,7 +126,8 @@ func (s *HTTPServer) handleListTeams(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	respondJSON(w, http.StatusOK, TeamListResponse{Teams: summaries})
+	page, total, next := sortAndPage(summaries, params, teamSortKeys)
+	respondList(w, TeamListResponse{Teams: page, Total: total, NextOffset: next}, params.Fields)
 }
 
 // handleGetTeam handles GET /teams/{name}
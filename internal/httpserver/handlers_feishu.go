@@ -40,8 +40,10 @@ func (s *HTTPServer) handleFeishuWebhook(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	if s.rejectReadOnly(w, r) {
+		return
+	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var event FeishuEvent
 	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -0,0 +1,97 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"codes/internal/config"
+	"codes/internal/session"
+)
+
+// hostSessionStarter opens terminal sessions on the machine running the
+// server. *session.Manager implements it.
+type hostSessionStarter interface {
+	StartSession(name, path string, args []string, env map[string]string) (*session.Session, error)
+	StartRemoteSession(name string, host *config.RemoteHost, project string) (*session.Session, error)
+}
+
+// hostSessionManager returns the server's session manager, creating it on
+// first use so servers that never open a terminal don't restore sessions.
+func (s *HTTPServer) hostSessionManager() hostSessionStarter {
+	s.hostSessionsMu.Lock()
+	defer s.hostSessionsMu.Unlock()
+	if s.hostSessions == nil {
+		s.hostSessions = session.NewManager(config.GetTerminal())
+	}
+	return s.hostSessions
+}
+
+// handleStartHostSession handles POST /host/sessions.
+// Opens a Claude terminal session for a project on this machine, like
+// pressing Enter on the project in the TUI. Requires the admin scope.
+func (s *HTTPServer) handleStartHostSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req StartHostSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+	if req.ProjectName == "" {
+		respondError(w, http.StatusBadRequest, "'project_name' is required")
+		return
+	}
+
+	entry, ok := config.GetProject(req.ProjectName)
+	if !ok {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("unknown project: %s", req.ProjectName))
+		return
+	}
+
+	var (
+		sess *session.Session
+		err  error
+	)
+	if entry.Remote != "" {
+		host, ok := config.GetRemote(entry.Remote)
+		if !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("remote %q of project %s not found", entry.Remote, req.ProjectName))
+			return
+		}
+		sess, err = s.hostSessionManager().StartRemoteSession(req.ProjectName, host, entry.Path)
+	} else {
+		if _, statErr := os.Stat(entry.Path); statErr != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("project path %s does not exist", entry.Path))
+			return
+		}
+		if err := config.CheckWorkDir(entry.Path); err != nil {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if !config.ClaudeAvailable() {
+			respondError(w, http.StatusServiceUnavailable, config.ErrClaudeNotFound.Error())
+			return
+		}
+		args, env := config.ClaudeCmdSpec()
+		args = append(args, config.LinkedContextArgs(req.ProjectName)...)
+		sess, err = s.hostSessionManager().StartSession(req.ProjectName, entry.Path, args, env)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to start session: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, HostSessionResponse{
+		ID:          sess.ID,
+		ProjectName: req.ProjectName,
+		ProjectPath: sess.ProjectPath,
+		Remote:      entry.Remote,
+		PID:         sess.PID,
+		StartedAt:   sess.StartedAt,
+	})
+}
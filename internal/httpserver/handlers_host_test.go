@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"codes/internal/config"
+	"codes/internal/session"
+)
+
+// fakeHostSessions records sessions instead of opening terminals.
+type fakeHostSessions struct {
+	started []string
+	args    []string
+}
+
+func (f *fakeHostSessions) StartSession(name, path string, args []string, env map[string]string) (*session.Session, error) {
+	f.started = append(f.started, name)
+	f.args = args
+	return &session.Session{ID: name + "-1", ProjectName: name, ProjectPath: path, PID: 4242, StartedAt: time.Now()}, nil
+}
+
+func (f *fakeHostSessions) StartRemoteSession(name string, host *config.RemoteHost, project string) (*session.Session, error) {
+	f.started = append(f.started, "remote:"+name)
+	return &session.Session{ID: "remote-" + name + "-1", ProjectName: "remote:" + name, ProjectPath: host.UserAtHost(), PID: 4243, StartedAt: time.Now()}, nil
+}
+
+func TestStartHostSession(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake claude binary is a shell script")
+	}
+	// A fake claude on PATH so the availability check passes.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	projectDir := t.TempDir()
+	cleanup := setupTestConfig(t, &config.Config{
+		Profiles: []config.APIConfig{{Name: "default"}},
+		Default:  "default",
+		Projects: map[string]config.ProjectEntry{
+			"my-app":  {Path: projectDir},
+			"gone":    {Path: filepath.Join(projectDir, "missing")},
+			"on-box":  {Path: "/srv/app", Remote: "box"},
+			"no-host": {Path: "/srv/app", Remote: "nowhere"},
+		},
+		Remotes: []config.RemoteHost{{Name: "box", Host: "box.example.com", User: "dev"}},
+	})
+	defer cleanup()
+
+	server := NewHTTPServer([]string{"user-token"}, "test")
+	server.SetAdminTokens([]string{"admin-token"})
+	fake := &fakeHostSessions{}
+	server.hostSessions = fake
+
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/host/sessions", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	// Scope checks
+	if w := post("bad-token", `{"project_name":"my-app"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d, want 401", w.Code)
+	}
+	if w := post("user-token", `{"project_name":"my-app"}`); w.Code != http.StatusForbidden {
+		t.Errorf("token without admin scope: status %d, want 403", w.Code)
+	}
+	if len(fake.started) != 0 {
+		t.Fatalf("sessions started without admin scope: %v", fake.started)
+	}
+
+	// Local project
+	w := post("admin-token", `{"project_name":"my-app"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201 (body: %s)", w.Code, w.Body.String())
+	}
+	var resp HostSessionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "my-app-1" || resp.ProjectPath != projectDir || resp.PID != 4242 || resp.Remote != "" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	// Remote project
+	w = post("admin-token", `{"project_name":"on-box"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("remote: status %d, want 201 (body: %s)", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.ProjectPath != "dev@box.example.com" || resp.Remote != "box" {
+		t.Errorf("remote response = %+v", resp)
+	}
+
+	// Errors
+	for body, want := range map[string]int{
+		`{}`:                         http.StatusBadRequest,
+		`{"project_name":"nope"}`:    http.StatusNotFound,
+		`{"project_name":"gone"}`:    http.StatusBadRequest,
+		`{"project_name":"no-host"}`: http.StatusBadRequest,
+	} {
+		if w := post("admin-token", body); w.Code != want {
+			t.Errorf("%s: status %d, want %d", body, w.Code, want)
+		}
+	}
+	if strings.Join(fake.started, ",") != "my-app,remote:on-box" {
+		t.Errorf("started = %v", fake.started)
+	}
+
+	// Admin tokens work on ordinary endpoints too
+	req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /projects with admin token: status %d", w.Code)
+	}
+}
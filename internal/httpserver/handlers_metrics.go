@@ -0,0 +1,33 @@
+package httpserver
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"codes/internal/agent"
+)
+
+// handleMetrics handles GET /metrics (Prometheus text exposition format): the
+// agent and task metrics read from disk, then the HTTP and chat session
+// metrics of this server.
+func (s *HTTPServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := agent.WritePrometheusMetrics(&buf); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to collect metrics: %v", err))
+		return
+	}
+	if err := s.writeServerMetrics(&buf); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to collect metrics: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,210 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+
+	"codes/internal/config"
+)
+
+// routeProfiles dispatches /profiles.
+func (s *HTTPServer) routeProfiles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleListProfiles(w, r)
+	case http.MethodPost:
+		jsonContentTypeMiddleware(s.handleCreateProfile)(w, r)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// routeProfileByName dispatches /profiles/{name}. POST /profiles/switch has
+// its own route.
+func (s *HTTPServer) routeProfileByName(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[1] == "" {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /profiles/{name})")
+		return
+	}
+	name := parts[1]
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetProfile(w, name)
+	case http.MethodPatch:
+		jsonContentTypeMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			s.handleUpdateProfile(w, r, name)
+		})(w, r)
+	case http.MethodDelete:
+		s.handleDeleteProfile(w, name)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// profileToResponse converts a profile for a response, redacting secrets.
+// Profiles are never served with their tokens, whatever the caller's scope.
+func profileToResponse(p config.APIConfig, defaultName string) ProfileInfo {
+	info := ProfileInfo{
+		Name:            p.Name,
+		IsDefault:       p.Name == defaultName,
+		SkipPermissions: p.SkipPermissions,
+		Status:          p.Status,
+	}
+	if len(p.Env) > 0 {
+		info.Env = make(map[string]string, len(p.Env))
+		for k, v := range p.Env {
+			if config.IsSensitiveEnv(k) {
+				v = config.RedactedValue
+			}
+			info.Env[k] = v
+		}
+	}
+	return info
+}
+
+// validateProfileEnv rejects empty keys and, when creating, redacted values,
+// which would otherwise end up as the literal token.
+func validateProfileEnv(env map[string]string, creating bool) error {
+	for k, v := range env {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("env keys must not be empty")
+		}
+		if creating && v == config.RedactedValue {
+			return fmt.Errorf("env %s is %s: send the real value", k, config.RedactedValue)
+		}
+	}
+	return nil
+}
+
+// handleGetProfile handles GET /profiles/{name}
+func (s *HTTPServer) handleGetProfile(w http.ResponseWriter, name string) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to load config: %v", err))
+		return
+	}
+	p := config.FindProfile(cfg, name)
+	if p == nil {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("profile %q not found", name))
+		return
+	}
+	respondJSON(w, http.StatusOK, profileToResponse(*p, cfg.Default))
+}
+
+// handleCreateProfile handles POST /profiles (profiles:write scope).
+func (s *HTTPServer) handleCreateProfile(w http.ResponseWriter, r *http.Request) {
+	var req CreateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "field 'name' is required")
+		return
+	}
+	if req.Name == "switch" || strings.ContainsAny(req.Name, `/\`) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid profile name %q", req.Name))
+		return
+	}
+	if err := validateProfileEnv(req.Env, true); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	profile := config.APIConfig{
+		Name:            req.Name,
+		Env:             req.Env,
+		SkipPermissions: req.SkipPermissions,
+	}
+	if err := config.AddProfile(profile, req.Default); errors.Is(err, config.ErrProfileExists) {
+		respondError(w, http.StatusConflict, fmt.Sprintf("profile %q already exists", req.Name))
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save config: %v", err))
+		return
+	}
+
+	s.respondProfile(w, http.StatusCreated, profile)
+}
+
+// handleUpdateProfile handles PATCH /profiles/{name} (profiles:write scope).
+func (s *HTTPServer) handleUpdateProfile(w http.ResponseWriter, r *http.Request, name string) {
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+	if err := validateProfileEnv(req.Env, false); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := config.UpdateProfile(name, func(p *config.APIConfig) error {
+		if len(req.Env) > 0 {
+			env := maps.Clone(p.Env)
+			if env == nil {
+				env = make(map[string]string)
+			}
+			for k, v := range req.Env {
+				switch v {
+				case config.RedactedValue:
+				case "":
+					delete(env, k)
+				default:
+					env[k] = v
+				}
+			}
+			p.Env = env
+		}
+		if req.SkipPermissions != nil {
+			p.SkipPermissions = req.SkipPermissions
+		}
+		// The old test result says nothing about the new settings
+		p.Status = ""
+		return nil
+	})
+	if errors.Is(err, config.ErrProfileNotFound) {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("profile %q not found", name))
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save config: %v", err))
+		return
+	}
+
+	s.respondProfile(w, http.StatusOK, updated)
+}
+
+// handleDeleteProfile handles DELETE /profiles/{name} (profiles:write scope).
+// Deleting the default profile makes the first remaining one the default.
+func (s *HTTPServer) handleDeleteProfile(w http.ResponseWriter, name string) {
+	newDefault, err := config.RemoveProfile(name)
+	if errors.Is(err, config.ErrProfileNotFound) {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("profile %q not found", name))
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save config: %v", err))
+		return
+	}
+
+	msg := fmt.Sprintf("Profile %s removed", name)
+	if newDefault != "" {
+		msg += fmt.Sprintf("; default profile is now %s", newDefault)
+	}
+	respondJSON(w, http.StatusOK, StatusResponse{Status: "deleted", Message: msg})
+}
+
+// respondProfile writes profile, redacted, with its current default flag.
+func (s *HTTPServer) respondProfile(w http.ResponseWriter, status int, profile config.APIConfig) {
+	defaultName := ""
+	if cfg, err := config.LoadConfig(); err == nil {
+		defaultName = cfg.Default
+	}
+	respondJSON(w, status, profileToResponse(profile, defaultName))
+}
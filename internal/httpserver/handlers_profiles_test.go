@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"codes/internal/config"
+	"codes/pkg/client"
+)
+
+func TestProfileEndpoints(t *testing.T) {
+	cleanup := setupTestConfig(t, &config.Config{
+		Profiles: []config.APIConfig{{Name: "default", Env: map[string]string{
+			"ANTHROPIC_BASE_URL":   "https://api.anthropic.com",
+			"ANTHROPIC_AUTH_TOKEN": "sk-default-secret",
+		}}},
+		Default: "default",
+	})
+	defer cleanup()
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetScopedTokens([]config.HTTPToken{{Name: "provisioner", Token: "prov-token", Scopes: []string{ScopeProfilesWrite}}})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	ctx := context.Background()
+	c := client.New(ts.URL, "prov-token")
+
+	// Writes need the dedicated scope, even for full-access legacy tokens
+	var apiErr *client.Error
+	legacy := client.New(ts.URL, "test-token")
+	if _, err := legacy.CreateProfile(ctx, client.CreateProfileRequest{Name: "x"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("CreateProfile with a legacy token: %v, want 403", err)
+	}
+	if err := legacy.DeleteProfile(ctx, "default"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("DeleteProfile with a legacy token: %v, want 403", err)
+	}
+
+	created, err := c.CreateProfile(ctx, client.CreateProfileRequest{Name: "relay", Env: map[string]string{
+		"ANTHROPIC_BASE_URL": "https://relay.example.com",
+		"ANTHROPIC_API_KEY":  "sk-relay-secret",
+	}})
+	if err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if created.Env["ANTHROPIC_API_KEY"] != config.RedactedValue || created.Env["ANTHROPIC_BASE_URL"] != "https://relay.example.com" || created.IsDefault {
+		t.Errorf("created = %+v", created)
+	}
+	if _, err := c.CreateProfile(ctx, client.CreateProfileRequest{Name: "relay"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("duplicate profile: %v, want 409", err)
+	}
+	for _, bad := range []client.CreateProfileRequest{
+		{},
+		{Name: "switch"},
+		{Name: "copy", Env: map[string]string{"ANTHROPIC_AUTH_TOKEN": config.RedactedValue}},
+	} {
+		if _, err := c.CreateProfile(ctx, bad); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("CreateProfile(%+v): %v, want 400", bad, err)
+		}
+	}
+
+	profiles, err := legacy.ListProfiles(ctx)
+	if err != nil || len(profiles) != 2 {
+		t.Fatalf("ListProfiles = %+v, %v", profiles, err)
+	}
+	for _, p := range profiles {
+		for k, v := range p.Env {
+			if v == "sk-default-secret" || v == "sk-relay-secret" {
+				t.Errorf("profile %s serves %s in the clear", p.Name, k)
+			}
+		}
+	}
+
+	// Redacted values are kept, empty ones removed
+	skip := true
+	updated, err := c.UpdateProfile(ctx, "relay", client.UpdateProfileRequest{
+		Env: map[string]string{
+			"ANTHROPIC_API_KEY":  config.RedactedValue,
+			"ANTHROPIC_BASE_URL": "",
+			"ANTHROPIC_MODEL":    "claude-sonnet-4-5",
+		},
+		SkipPermissions: &skip,
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if _, ok := updated.Env["ANTHROPIC_BASE_URL"]; ok || updated.Env["ANTHROPIC_MODEL"] != "claude-sonnet-4-5" || updated.SkipPermissions == nil || !*updated.SkipPermissions {
+		t.Errorf("updated = %+v", updated)
+	}
+	cfg, _ := config.LoadConfig()
+	if got := config.FindProfile(cfg, "relay").Env["ANTHROPIC_API_KEY"]; got != "sk-relay-secret" {
+		t.Errorf("stored key = %q, want it unchanged", got)
+	}
+	if _, err := c.UpdateProfile(ctx, "missing", client.UpdateProfileRequest{}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("UpdateProfile(missing): %v, want 404", err)
+	}
+
+	got, err := c.GetProfile(ctx, "default")
+	if err != nil || !got.IsDefault || got.Env["ANTHROPIC_AUTH_TOKEN"] != config.RedactedValue {
+		t.Errorf("GetProfile = %+v, %v", got, err)
+	}
+
+	// Deleting the default moves it to the first remaining profile
+	if err := c.DeleteProfile(ctx, "default"); err != nil {
+		t.Fatalf("DeleteProfile: %v", err)
+	}
+	if got, err := c.GetProfile(ctx, "relay"); err != nil || !got.IsDefault {
+		t.Errorf("relay after deleting default = %+v, %v", got, err)
+	}
+	if err := c.DeleteProfile(ctx, "default"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("second DeleteProfile: %v, want 404", err)
+	}
+}
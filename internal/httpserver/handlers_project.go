@@ -2,21 +2,56 @@ package httpserver
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"codes/internal/config"
 )
 
-// handleListProjects handles GET /projects
-func (s *HTTPServer) handleListProjects(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// routeProjects dispatches /projects.
+func (s *HTTPServer) routeProjects(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleListProjects(w, r)
+	case http.MethodPost:
+		jsonContentTypeMiddleware(s.handleCreateProject)(w, r)
+	default:
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// routeProjectByName dispatches /projects/{name}.
+func (s *HTTPServer) routeProjectByName(w http.ResponseWriter, r *http.Request) {
+	// Parse path: /projects/{name}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 2 {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /projects/{name})")
+		return
+	}
+
+	name := parts[1]
+	if name == "" {
+		respondError(w, http.StatusBadRequest, "project name is required")
 		return
 	}
 
-	projects, err := config.ListProjects()
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetProject(w, name)
+	case http.MethodDelete:
+		s.handleDeleteProject(w, name)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleListProjects handles GET /projects
+func (s *HTTPServer) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := config.ListActiveProjects()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list projects: %v", err))
 		return
@@ -34,46 +69,139 @@ func (s *HTTPServer) handleListProjects(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, ProjectListResponse{Projects: list})
 }
 
-// handleGetProject handles GET /projects/{name}
-func (s *HTTPServer) handleGetProject(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+// handleGetProject handles GET /projects/{name}: the project with the git
+// remote, branch and dirty status of its directory.
+func (s *HTTPServer) handleGetProject(w http.ResponseWriter, name string) {
+	entry, exists := config.GetProject(name)
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("project %q not found", name))
 		return
 	}
 
-	// Parse path: /projects/{name}
-	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(parts) != 2 {
-		respondError(w, http.StatusBadRequest, "invalid path format (expected /projects/{name})")
+	respondJSON(w, http.StatusOK, projectDetail(name, entry))
+}
+
+func projectDetail(name string, entry config.ProjectEntry) ProjectInfoResponse {
+	info := config.GetProjectInfoFromEntry(name, entry)
+	return ProjectInfoResponse{
+		Name:      name,
+		Path:      entry.Path,
+		Host:      entry.Remote,
+		Missing:   !info.Exists,
+		GitRemote: info.GitRemote,
+		GitBranch: info.GitBranch,
+		GitDirty:  info.GitDirty,
+	}
+}
+
+// handleCreateProject handles POST /projects: it registers an existing
+// directory, or clones a git URL into the projects directory (or the given
+// path) with the configured clone defaults and registers the clone.
+func (s *HTTPServer) handleCreateProject(w http.ResponseWriter, r *http.Request) {
+	var req CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
 
-	name := parts[1]
+	switch {
+	case req.Path == "" && req.GitURL == "":
+		respondError(w, http.StatusBadRequest, "field 'path' or 'git_url' is required")
+		return
+	case req.GitURL != "" && req.Host != "":
+		respondError(w, http.StatusBadRequest, "cloning on a remote host is not supported")
+		return
+	case req.Host != "" && req.Path == "":
+		respondError(w, http.StatusBadRequest, "field 'path' is required with 'host'")
+		return
+	}
+	if req.Host != "" {
+		if _, ok := config.GetRemote(req.Host); !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("remote host %q not found", req.Host))
+			return
+		}
+	}
+
+	path := req.Path
+	if req.Host == "" && path != "" {
+		if !filepath.IsAbs(path) {
+			respondError(w, http.StatusBadRequest, "field 'path' must be absolute")
+			return
+		}
+		path = filepath.Clean(path)
+	}
+	if req.GitURL != "" && path == "" {
+		path = filepath.Join(config.GetProjectsDir(), repoNameFromURL(req.GitURL))
+	}
+
+	name := req.Name
 	if name == "" {
-		respondError(w, http.StatusBadRequest, "project name is required")
+		name = filepath.Base(path)
+	}
+	if name == "" || name == "." || name == "/" || strings.ContainsAny(name, `/\`) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid project name %q", name))
+		return
+	}
+	if _, exists := config.GetProject(name); exists {
+		respondError(w, http.StatusConflict, fmt.Sprintf("project %q already exists", name))
 		return
 	}
 
-	entry, exists := config.GetProject(name)
-	if !exists {
-		respondError(w, http.StatusNotFound, fmt.Sprintf("project %q not found", name))
+	if req.GitURL != "" {
+		if _, err := os.Stat(path); err == nil {
+			respondError(w, http.StatusConflict, fmt.Sprintf("%s already exists", path))
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create projects directory: %v", err))
+			return
+		}
+		if err := config.CloneRepo(r.Context(), req.GitURL, path, config.GetCloneDefaults()); err != nil {
+			respondError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+	} else if req.Host == "" {
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("directory %s does not exist", path))
+			return
+		}
+	}
+
+	entry := config.ProjectEntry{Path: path, Remote: req.Host}
+	if err := config.AddProjectEntry(name, entry); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save config: %v", err))
 		return
 	}
 
-	respondJSON(w, http.StatusOK, ProjectInfoResponse{
-		Name: name,
-		Path: entry.Path,
-		Host: entry.Remote,
-	})
+	respondJSON(w, http.StatusCreated, projectDetail(name, entry))
 }
 
-// handleListProfiles handles GET /profiles
-func (s *HTTPServer) handleListProfiles(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+// handleDeleteProject handles DELETE /projects/{name}. Only the registration
+// is removed; the directory is left alone.
+func (s *HTTPServer) handleDeleteProject(w http.ResponseWriter, name string) {
+	if _, exists := config.GetProject(name); !exists {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("project %q not found", name))
+		return
+	}
+	if err := config.RemoveProject(name); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save config: %v", err))
 		return
 	}
+	respondJSON(w, http.StatusOK, StatusResponse{Status: "deleted", Message: fmt.Sprintf("Project %s removed", name)})
+}
 
+// repoNameFromURL returns the repository name of a git URL:
+// "git@github.com:user/repo.git" and "https://github.com/user/repo" give "repo".
+func repoNameFromURL(gitURL string) string {
+	gitURL = strings.TrimSuffix(strings.TrimRight(strings.TrimSpace(gitURL), "/"), ".git")
+	if i := strings.LastIndexAny(gitURL, "/:"); i >= 0 {
+		return gitURL[i+1:]
+	}
+	return gitURL
+}
+
+// handleListProfiles handles GET /profiles
+func (s *HTTPServer) handleListProfiles(w http.ResponseWriter, r *http.Request) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to load config: %v", err))
@@ -82,10 +210,7 @@ func (s *HTTPServer) handleListProfiles(w http.ResponseWriter, r *http.Request)
 
 	profiles := make([]ProfileInfo, 0, len(cfg.Profiles))
 	for _, p := range cfg.Profiles {
-		profiles = append(profiles, ProfileInfo{
-			Name:      p.Name,
-			IsDefault: p.Name == cfg.Default,
-		})
+		profiles = append(profiles, profileToResponse(p, cfg.Default))
 	}
 
 	respondJSON(w, http.StatusOK, ProfileListResponse{Profiles: profiles})
@@ -98,7 +223,6 @@ func (s *HTTPServer) handleSwitchProfile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req SwitchProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -110,27 +234,10 @@ func (s *HTTPServer) handleSwitchProfile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to load config: %v", err))
-		return
-	}
-
-	// Verify the profile exists
-	found := false
-	for _, p := range cfg.Profiles {
-		if p.Name == req.Name {
-			found = true
-			break
-		}
-	}
-	if !found {
+	if err := config.SetDefaultProfile(req.Name); errors.Is(err, config.ErrProfileNotFound) {
 		respondError(w, http.StatusNotFound, fmt.Sprintf("profile %q not found", req.Name))
 		return
-	}
-
-	cfg.Default = req.Name
-	if err := config.SaveConfig(cfg); err != nil {
+	} else if err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save config: %v", err))
 		return
 	}
@@ -0,0 +1,119 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"codes/internal/config"
+	"codes/pkg/client"
+)
+
+func TestProjectEndpoints(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// A repository with an origin and an uncommitted change
+	repo := filepath.Join(t.TempDir(), "app")
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main", repo)
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0644)
+	git("-C", repo, "add", ".")
+	git("-C", repo, "commit", "-q", "-m", "initial commit")
+	git("-C", repo, "remote", "add", "origin", "https://example.com/team/app.git")
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0644)
+
+	projectsDir := t.TempDir()
+	cleanup := setupTestConfig(t, &config.Config{ProjectsDir: projectsDir})
+	defer cleanup()
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetScopedTokens([]config.HTTPToken{{Name: "reader", Token: "read-token", Scopes: []string{ScopeRead}}})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	ctx := context.Background()
+	c := client.New(ts.URL, "test-token")
+
+	added, err := c.AddProject(ctx, client.CreateProjectRequest{Path: repo})
+	if err != nil {
+		t.Fatalf("AddProject: %v", err)
+	}
+	if added.Name != "app" || added.Path != repo {
+		t.Errorf("added = %+v", added)
+	}
+
+	var apiErr *client.Error
+	if _, err := client.New(ts.URL, "read-token").AddProject(ctx, client.CreateProjectRequest{Name: "other", Path: repo}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("AddProject with read scope: %v, want 403", err)
+	}
+	for _, bad := range []client.CreateProjectRequest{
+		{Name: "none"},
+		{Name: "relative", Path: "app"},
+		{Name: "missing", Path: filepath.Join(repo, "missing")},
+		{Name: "a/b", Path: repo},
+		{Name: "remote", GitURL: repo, Host: "build-box"},
+	} {
+		if _, err := c.AddProject(ctx, bad); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("AddProject(%+v): %v, want 400", bad, err)
+		}
+	}
+	if _, err := c.AddProject(ctx, client.CreateProjectRequest{Name: "app", Path: repo}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("duplicate name: %v, want 409", err)
+	}
+
+	got, err := c.GetProject(ctx, "app")
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if got.GitRemote != "https://example.com/team/app.git" || got.GitBranch != "main" || !got.GitDirty || got.Missing {
+		t.Errorf("GetProject = %+v", got)
+	}
+
+	// Clone into the projects directory, named after the repository
+	cloned, err := c.AddProject(ctx, client.CreateProjectRequest{Name: "app-clone", GitURL: repo})
+	if err != nil {
+		t.Fatalf("AddProject(git_url): %v", err)
+	}
+	if cloned.Path != filepath.Join(projectsDir, "app") || cloned.GitRemote != repo || cloned.GitDirty {
+		t.Errorf("cloned = %+v", cloned)
+	}
+	if _, err := os.Stat(filepath.Join(projectsDir, "app", "a.txt")); err != nil {
+		t.Errorf("clone has no checkout: %v", err)
+	}
+	if _, err := c.AddProject(ctx, client.CreateProjectRequest{Name: "again", GitURL: repo}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("clone into existing directory: %v, want 409", err)
+	}
+	if _, err := c.AddProject(ctx, client.CreateProjectRequest{Name: "bad", GitURL: filepath.Join(repo, "missing")}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("failed clone: %v, want 502", err)
+	}
+
+	if err := c.DeleteProject(ctx, "app-clone"); err != nil {
+		t.Errorf("DeleteProject: %v", err)
+	}
+	if err := c.DeleteProject(ctx, "app-clone"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("second DeleteProject: %v, want 404", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectsDir, "app")); err != nil {
+		t.Errorf("DeleteProject removed the directory: %v", err)
+	}
+	projects, err := c.ListProjects(ctx)
+	if err != nil || len(projects) != 1 || projects[0].Name != "app" {
+		t.Errorf("ListProjects = %+v, %v", projects, err)
+	}
+}
@@ -109,11 +109,11 @@ func TestListProjectsEmpty(t *testing.T) {
 	}
 }
 
-// TestListProjectsMethodNotAllowed tests that POST /projects returns 405.
+// TestListProjectsMethodNotAllowed tests that PUT /projects returns 405.
 func TestListProjectsMethodNotAllowed(t *testing.T) {
 	server := NewHTTPServer([]string{"test-token"}, "test")
 
-	req := httptest.NewRequest(http.MethodPost, "/projects", nil)
+	req := httptest.NewRequest(http.MethodPut, "/projects", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 
 	w := httptest.NewRecorder()
@@ -225,12 +225,14 @@ func TestListProfiles(t *testing.T) {
 	}
 }
 
-// TestListProfilesMethodNotAllowed tests that POST /profiles returns 405.
+// TestListProfilesMethodNotAllowed tests that PUT /profiles returns 405 (to a
+// token that may change profiles).
 func TestListProfilesMethodNotAllowed(t *testing.T) {
 	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetAdminTokens([]string{"admin-token"})
 
-	req := httptest.NewRequest(http.MethodPost, "/profiles", nil)
-	req.Header.Set("Authorization", "Bearer test-token")
+	req := httptest.NewRequest(http.MethodPut, "/profiles", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
 
 	w := httptest.NewRecorder()
 	server.mux.ServeHTTP(w, req)
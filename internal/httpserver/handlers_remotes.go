@@ -0,0 +1,358 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"codes/internal/config"
+	"codes/internal/remote"
+)
+
+// remoteFleet reaches the remote hosts in the config over SSH. sshFleet
+// implements it; tests swap in a fake.
+type remoteFleet interface {
+	// Status checks what is installed on host and updates the status cache.
+	Status(host *config.RemoteHost) (*remote.RemoteStatus, error)
+	Sync(host *config.RemoteHost) error
+	Install(host *config.RemoteHost, opts remote.InstallOptions) error
+	InstallClaude(host *config.RemoteHost) error
+	// Endpoint returns where the codes serve API of host can be reached from
+	// this machine.
+	Endpoint(host *config.RemoteHost) (remoteAPI, error)
+	Close()
+}
+
+// remoteAPI is a codes serve instance on a remote host.
+type remoteAPI struct {
+	URL   *url.URL // base URL, local end of the tunnel
+	Token string   // sent in place of the caller's token; empty if the host has none
+}
+
+// remoteFleetManager returns the server's remote fleet, creating it on first
+// use.
+func (s *HTTPServer) remoteFleetManager() remoteFleet {
+	s.remotesMu.Lock()
+	defer s.remotesMu.Unlock()
+	if s.remotes == nil {
+		s.remotes = newSSHFleet()
+	}
+	return s.remotes
+}
+
+// closeRemotes stops the tunnels opened for proxying, if any.
+func (s *HTTPServer) closeRemotes() {
+	s.remotesMu.Lock()
+	defer s.remotesMu.Unlock()
+	if s.remotes != nil {
+		s.remotes.Close()
+	}
+}
+
+// sshFleet keeps one SSH tunnel per host to its codes serve port, opened on
+// the first proxied request and reopened if ssh exits.
+type sshFleet struct {
+	mu      sync.Mutex
+	tunnels map[string]*fleetTunnel
+}
+
+type fleetTunnel struct {
+	tunnel *remote.Tunnel
+	api    remoteAPI
+}
+
+func newSSHFleet() *sshFleet {
+	return &sshFleet{tunnels: make(map[string]*fleetTunnel)}
+}
+
+func (f *sshFleet) Status(host *config.RemoteHost) (*remote.RemoteStatus, error) {
+	status, err := remote.CheckRemoteStatus(host)
+	if err != nil {
+		return nil, err
+	}
+	remote.UpdateStatusCache(host.Name, status)
+	return status, nil
+}
+
+func (f *sshFleet) Sync(host *config.RemoteHost) error {
+	// The synced config replaces the remote's, tokens included
+	defer f.forget(host.Name)
+	return remote.SyncProfiles(host)
+}
+
+func (f *sshFleet) Install(host *config.RemoteHost, opts remote.InstallOptions) error {
+	_, err := remote.InstallOnRemoteWithOptions(host, opts)
+	return err
+}
+
+func (f *sshFleet) InstallClaude(host *config.RemoteHost) error {
+	_, err := remote.InstallClaudeOnRemote(host)
+	return err
+}
+
+func (f *sshFleet) Endpoint(host *config.RemoteHost) (remoteAPI, error) {
+	f.mu.Lock()
+	ft := f.tunnels[host.Name]
+	f.mu.Unlock()
+	if ft != nil && ft.tunnel.Alive() {
+		return ft.api, nil
+	}
+
+	remoteCfg, err := remote.FetchRemoteConfig(host)
+	if err != nil {
+		return remoteAPI{}, err
+	}
+	ep := remote.ServeEndpointFromConfig(remoteCfg)
+	tunnel, err := remote.OpenTunnel(host, ep.Port)
+	if err != nil {
+		return remoteAPI{}, err
+	}
+	scheme := "http"
+	if ep.TLS {
+		scheme = "https"
+	}
+	ft = &fleetTunnel{
+		tunnel: tunnel,
+		api:    remoteAPI{URL: &url.URL{Scheme: scheme, Host: tunnel.LocalAddr}, Token: ep.Token},
+	}
+
+	f.mu.Lock()
+	old := f.tunnels[host.Name]
+	f.tunnels[host.Name] = ft
+	f.mu.Unlock()
+	if old != nil {
+		old.tunnel.Close()
+	}
+	return ft.api, nil
+}
+
+// forget closes the tunnel to name so the next request rereads its config.
+func (f *sshFleet) forget(name string) {
+	f.mu.Lock()
+	ft := f.tunnels[name]
+	delete(f.tunnels, name)
+	f.mu.Unlock()
+	if ft != nil {
+		ft.tunnel.Close()
+	}
+}
+
+func (f *sshFleet) Close() {
+	f.mu.Lock()
+	tunnels := f.tunnels
+	f.tunnels = make(map[string]*fleetTunnel)
+	f.mu.Unlock()
+	for _, ft := range tunnels {
+		ft.tunnel.Close()
+	}
+}
+
+// tunnelTransport talks to remote codes serve instances that use TLS. Their
+// certificates name the remote host, not the local end of the SSH tunnel the
+// connection arrives through, and the tunnel already authenticates the host.
+var tunnelTransport = &http.Transport{
+	TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+}
+
+// routeRemotes dispatches GET /remotes.
+func (s *HTTPServer) routeRemotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	hosts, err := config.ListRemotes()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to load config: %v", err))
+		return
+	}
+	cached := remote.LoadStatusCache()
+	resp := RemoteListResponse{Remotes: []RemoteHostInfo{}}
+	for _, host := range hosts {
+		resp.Remotes = append(resp.Remotes, remoteHostInfo(host, cached[host.Name]))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// routeRemoteByName dispatches /remotes/{name}/status, /sync, /setup and
+// /proxy/{path}.
+func (s *HTTPServer) routeRemoteByName(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/", 4)
+	if len(parts) < 3 || parts[1] == "" {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /remotes/{name}/{action})")
+		return
+	}
+	name, err := url.PathUnescape(parts[1])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid remote name: %v", err))
+		return
+	}
+	host, ok := config.GetRemote(name)
+	if !ok {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("remote %q not found", name))
+		return
+	}
+
+	action := parts[2]
+	if action == "proxy" {
+		rest := ""
+		if len(parts) == 4 {
+			rest = parts[3]
+		}
+		s.handleRemoteProxy(w, r, host, "/"+rest)
+		return
+	}
+	if len(parts) != 3 {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /remotes/{name}/{action})")
+		return
+	}
+	switch action {
+	case "status":
+		if r.Method != http.MethodGet {
+			respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.handleRemoteStatus(w, host)
+	case "sync":
+		if r.Method != http.MethodPost {
+			respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.handleRemoteSync(w, host)
+	case "setup":
+		if r.Method != http.MethodPost {
+			respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		jsonContentTypeMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			s.handleRemoteSetup(w, r, host)
+		})(w, r)
+	default:
+		respondError(w, http.StatusNotFound, "unknown remote action: "+action)
+	}
+}
+
+// remoteHostInfo describes host with status, its last known state (nil if
+// never checked).
+func remoteHostInfo(host config.RemoteHost, status *remote.RemoteStatus) RemoteHostInfo {
+	info := RemoteHostInfo{Name: host.Name, Host: host.Host, User: host.User, Port: host.Port}
+	if status != nil {
+		info.Status = &RemoteHostStatus{
+			CodesInstalled:  status.CodesInstalled,
+			CodesVersion:    status.CodesVersion,
+			ClaudeInstalled: status.ClaudeInstalled,
+			OS:              status.OS,
+			Arch:            status.Arch,
+		}
+	}
+	return info
+}
+
+// respondRemoteError reports a failed SSH operation on host: 409 if its host
+// key has not been trusted yet, otherwise 502.
+func respondRemoteError(w http.ResponseWriter, host *config.RemoteHost, what string, err error) {
+	var unknown *remote.HostKeyUnknownError
+	if errors.As(err, &unknown) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	respondError(w, http.StatusBadGateway, fmt.Sprintf("%s %s: %v", what, host.Name, err))
+}
+
+// handleRemoteStatus handles GET /remotes/{name}/status, a live check over
+// SSH.
+func (s *HTTPServer) handleRemoteStatus(w http.ResponseWriter, host *config.RemoteHost) {
+	status, err := s.remoteFleetManager().Status(host)
+	if err != nil {
+		respondRemoteError(w, host, "check", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, remoteHostInfo(*host, status))
+}
+
+// handleRemoteSync handles POST /remotes/{name}/sync: uploads the local
+// profiles to the host.
+func (s *HTTPServer) handleRemoteSync(w http.ResponseWriter, host *config.RemoteHost) {
+	if err := s.remoteFleetManager().Sync(host); err != nil {
+		respondRemoteError(w, host, "sync", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, StatusResponse{Status: "synced", Message: fmt.Sprintf("Profiles synced to %s", host.Name)})
+}
+
+// handleRemoteSetup handles POST /remotes/{name}/setup: installs codes and
+// the Claude CLI on the host and syncs the profiles, like `codes remote
+// setup`. It can take minutes.
+func (s *HTTPServer) handleRemoteSetup(w http.ResponseWriter, r *http.Request, host *config.RemoteHost) {
+	var req RemoteSetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	fleet := s.remoteFleetManager()
+	resp := RemoteSetupResponse{Stages: []string{}}
+	progress := func(stage string) { resp.Stages = append(resp.Stages, stage) }
+
+	if err := fleet.Install(host, remote.InstallOptions{RateLimit: req.RateLimit, Progress: progress}); err != nil {
+		respondRemoteError(w, host, "install codes on", err)
+		return
+	}
+	if !req.SkipClaude {
+		progress("installing Claude CLI")
+		if err := fleet.InstallClaude(host); err != nil {
+			resp.ClaudeError = err.Error()
+		}
+	}
+	progress("syncing profiles")
+	if err := fleet.Sync(host); err != nil {
+		respondRemoteError(w, host, "sync", err)
+		return
+	}
+
+	var status *remote.RemoteStatus
+	if st, err := fleet.Status(host); err == nil {
+		status = st
+	}
+	resp.Remote = remoteHostInfo(*host, status)
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleRemoteProxy handles /remotes/{name}/proxy/{path}: forwards the
+// request to path on the host's codes serve API through an SSH tunnel, with
+// the host's own token in place of the caller's.
+func (s *HTTPServer) handleRemoteProxy(w http.ResponseWriter, r *http.Request, host *config.RemoteHost, path string) {
+	api, err := s.remoteFleetManager().Endpoint(host)
+	if err != nil {
+		respondRemoteError(w, host, "connect to", err)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Scheme = api.URL.Scheme
+			pr.Out.URL.Host = api.URL.Host
+			pr.Out.URL.RawPath = path
+			pr.Out.URL.Path, _ = url.PathUnescape(path)
+			pr.Out.Host = ""
+			pr.Out.Header.Del("Authorization")
+			if api.Token != "" {
+				pr.Out.Header.Set("Authorization", "Bearer "+api.Token)
+			}
+			pr.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			respondError(w, http.StatusBadGateway, fmt.Sprintf("codes serve on %s not reachable: %v", host.Name, err))
+		},
+	}
+	if api.URL.Scheme == "https" {
+		proxy.Transport = tunnelTransport
+	}
+	proxy.ServeHTTP(w, r)
+}
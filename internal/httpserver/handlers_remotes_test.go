@@ -0,0 +1,155 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"codes/internal/config"
+	"codes/internal/remote"
+	"codes/pkg/client"
+)
+
+// fakeFleet answers for remote hosts without SSH; the proxy goes to api.
+type fakeFleet struct {
+	api     remoteAPI
+	synced  []string
+	unknown bool // report an untrusted host key
+}
+
+func (f *fakeFleet) Status(host *config.RemoteHost) (*remote.RemoteStatus, error) {
+	if f.unknown {
+		return nil, &remote.HostKeyUnknownError{Name: host.Name, Address: host.Host}
+	}
+	return &remote.RemoteStatus{CodesInstalled: true, CodesVersion: "v9", OS: "Linux", Arch: "x86_64"}, nil
+}
+
+func (f *fakeFleet) Sync(host *config.RemoteHost) error {
+	f.synced = append(f.synced, host.Name)
+	return nil
+}
+
+func (f *fakeFleet) Install(host *config.RemoteHost, opts remote.InstallOptions) error {
+	opts.Progress("installing codes")
+	return nil
+}
+
+func (f *fakeFleet) InstallClaude(host *config.RemoteHost) error {
+	return errors.New("npm not found")
+}
+
+func (f *fakeFleet) Endpoint(host *config.RemoteHost) (remoteAPI, error) {
+	if f.api.URL == nil {
+		return remoteAPI{}, errors.New("no tunnel")
+	}
+	return f.api, nil
+}
+
+func (f *fakeFleet) Close() {}
+
+func TestRemoteEndpoints(t *testing.T) {
+	cleanup := setupTestConfig(t, &config.Config{Remotes: []config.RemoteHost{
+		{Name: "gpu", Host: "gpu.example.com", User: "dev"},
+	}})
+	defer cleanup()
+
+	// Stands in for codes serve on the remote host
+	var gotPath, gotQuery, gotAuth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		respondJSON(w, http.StatusOK, TeamListResponse{Teams: []TeamSummary{{Name: "remote-team"}}})
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetAdminTokens([]string{"admin-token"})
+	fleet := &fakeFleet{api: remoteAPI{URL: upstreamURL, Token: "remote-token"}}
+	server.remotes = fleet
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	ctx := context.Background()
+	admin := client.New(ts.URL, "admin-token")
+
+	var apiErr *client.Error
+	if _, err := client.New(ts.URL, "test-token").ListRemotes(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("ListRemotes without admin scope: %v, want 403", err)
+	}
+
+	remotes, err := admin.ListRemotes(ctx)
+	if err != nil {
+		t.Fatalf("ListRemotes: %v", err)
+	}
+	if len(remotes) != 1 || remotes[0].Name != "gpu" || remotes[0].User != "dev" {
+		t.Errorf("remotes = %+v", remotes)
+	}
+
+	info, err := admin.RemoteStatus(ctx, "gpu")
+	if err != nil {
+		t.Fatalf("RemoteStatus: %v", err)
+	}
+	if info.Status == nil || !info.Status.CodesInstalled || info.Status.CodesVersion != "v9" {
+		t.Errorf("status = %+v", info.Status)
+	}
+	if _, err := admin.RemoteStatus(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("RemoteStatus(missing): %v, want 404", err)
+	}
+	fleet.unknown = true
+	if _, err := admin.RemoteStatus(ctx, "gpu"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("RemoteStatus with unknown host key: %v, want 409", err)
+	}
+	fleet.unknown = false
+
+	if err := admin.SyncRemote(ctx, "gpu"); err != nil {
+		t.Errorf("SyncRemote: %v", err)
+	}
+	setup, err := admin.SetupRemote(ctx, "gpu", client.RemoteSetupRequest{})
+	if err != nil {
+		t.Fatalf("SetupRemote: %v", err)
+	}
+	if len(setup.Stages) != 3 || setup.ClaudeError != "npm not found" || setup.Remote.Status == nil {
+		t.Errorf("setup = %+v", setup)
+	}
+	if len(fleet.synced) != 2 {
+		t.Errorf("synced %v, want twice", fleet.synced)
+	}
+
+	// The proxy strips its prefix and swaps in the remote's token
+	teams, err := admin.Remote("gpu").ListTeams(ctx)
+	if err != nil {
+		t.Fatalf("proxied ListTeams: %v", err)
+	}
+	if len(teams) != 1 || teams[0].Name != "remote-team" {
+		t.Errorf("proxied teams = %+v", teams)
+	}
+	if gotPath != "/teams" || gotAuth != "Bearer remote-token" {
+		t.Errorf("upstream got path %q auth %q", gotPath, gotAuth)
+	}
+	resp, err := http.Get(ts.URL + "/remotes/gpu/proxy/teams?limit=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("proxy without token: status %d, want 401", resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/remotes/gpu/proxy/teams?limit=2", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+	if gotQuery != "limit=2" {
+		t.Errorf("upstream got query %q, want limit=2", gotQuery)
+	}
+
+	// A dead upstream is a bad gateway, not a hang
+	upstream.Close()
+	if _, err := admin.Remote("gpu").ListTeams(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("proxy to closed upstream: %v, want 502", err)
+	}
+}
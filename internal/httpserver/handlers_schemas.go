@@ -0,0 +1,50 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"codes/pkg/schemas"
+)
+
+// handleListSchemas handles GET /schemas
+func (s *HTTPServer) handleListSchemas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	list, err := schemas.List()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, SchemaListResponse{Schemas: list})
+}
+
+// handleGetSchema handles GET /schemas/{file}
+func (s *HTTPServer) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	file := strings.TrimPrefix(r.URL.Path, "/schemas/")
+	if file == "" {
+		s.handleListSchemas(w, r)
+		return
+	}
+	data, err := schemas.Get(file)
+	if errors.Is(err, schemas.ErrNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
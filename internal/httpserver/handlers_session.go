@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -18,7 +19,6 @@ func (s *HTTPServer) handleCreateSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req CreateSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -40,22 +40,45 @@ func (s *HTTPServer) handleCreateSession(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusBadRequest, "either 'project_path' or 'project_name' is required")
 		return
 	}
+	if req.MaxCostUSD < 0 || req.MaxTurns < 0 {
+		respondError(w, http.StatusBadRequest, "'max_cost_usd' and 'max_turns' must not be negative")
+		return
+	}
 
 	session, err := chatsession.DefaultManager.Create(projectName, projectPath, req.Model)
 	if err != nil {
+		if errors.Is(err, config.ErrWorkDirNotAllowed) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create session: %v", err))
 		return
 	}
+	if req.MaxCostUSD > 0 || req.MaxTurns > 0 {
+		session.SetLimits(req.MaxCostUSD, req.MaxTurns)
+	}
 
 	if err := session.Start(req.Message); err != nil {
 		chatsession.DefaultManager.Delete(session.ID)
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to start session: %v", err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrClaudeNotFound) {
+			status = http.StatusServiceUnavailable
+		}
+		respondError(w, status, fmt.Sprintf("failed to start session: %v", err))
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, sessionToResponse(session))
 }
 
+// sessionSortKeys are the sort keys of GET /sessions.
+var sessionSortKeys = sortKeys[SessionResponse]{
+	"created_at":     func(a, b SessionResponse) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"last_active_at": func(a, b SessionResponse) int { return a.LastActiveAt.Compare(b.LastActiveAt) },
+	"status":         func(a, b SessionResponse) int { return strings.Compare(a.Status, b.Status) },
+	"project_name":   func(a, b SessionResponse) int { return strings.Compare(a.ProjectName, b.ProjectName) },
+}
+
 // handleListSessions handles GET /sessions.
 func (s *HTTPServer) handleListSessions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -63,15 +86,20 @@ func (s *HTTPServer) handleListSessions(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	sessions := chatsession.DefaultManager.List()
-	resp := SessionListResponse{
-		Sessions: make([]SessionResponse, 0, len(sessions)),
+	params, err := parseListParams(r.URL.Query(), sessionSortKeys, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+
+	sessions := chatsession.DefaultManager.List()
+	list := make([]SessionResponse, 0, len(sessions))
 	for _, sess := range sessions {
-		resp.Sessions = append(resp.Sessions, sessionToResponse(sess))
+		list = append(list, sessionToResponse(sess))
 	}
 
-	respondJSON(w, http.StatusOK, resp)
+	page, total, next := sortAndPage(list, params, sessionSortKeys)
+	respondList(w, SessionListResponse{Sessions: page, Total: total, NextOffset: next}, params.Fields)
 }
 
 // handleGetSession handles GET /sessions/{id}.
@@ -155,7 +183,6 @@ func (s *HTTPServer) handleResumeSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req ResumeSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -183,6 +210,12 @@ func (s *HTTPServer) handleResumeSession(w http.ResponseWriter, r *http.Request)
 
 	info := existing.Snapshot()
 
+	// Check before closing the old session so a missing CLI doesn't lose it
+	if !config.ClaudeAvailable() {
+		respondError(w, http.StatusServiceUnavailable, config.ErrClaudeNotFound.Error())
+		return
+	}
+
 	// Close the old session and create a resumed one.
 	chatsession.DefaultManager.Delete(id)
 
@@ -193,6 +226,7 @@ func (s *HTTPServer) handleResumeSession(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("resume failed: %v", err))
 		return
 	}
+	resumed.InheritLimits(info)
 
 	respondJSON(w, http.StatusOK, sessionToResponse(resumed))
 }
@@ -211,7 +245,8 @@ func (s *HTTPServer) handleSessionWebSocket(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	chatsession.HandleWebSocket(session, w, r)
+	// Share links only watch
+	chatsession.HandleWebSocket(session, w, r, s.readOnly || grantFrom(r.Context()).session != "")
 }
 
 // handleSessionMessage handles POST /sessions/{id}/message.
@@ -233,19 +268,34 @@ func (s *HTTPServer) handleSessionMessage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req SessionSendMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
 
-	if req.Content == "" {
-		respondError(w, http.StatusBadRequest, "field 'content' is required")
+	if req.Content == "" && len(req.Attachments) == 0 {
+		respondError(w, http.StatusBadRequest, "field 'content' or 'attachments' is required")
 		return
 	}
 
-	if err := session.SendMessage(req.Content); err != nil {
+	attachments := make([]chatsession.Attachment, 0, len(req.Attachments))
+	for _, a := range req.Attachments {
+		attachments = append(attachments, chatsession.Attachment(a))
+	}
+	if err := session.SendMessage(req.Content, attachments...); err != nil {
+		if errors.Is(err, chatsession.ErrInvalidAttachment) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, chatsession.ErrLimitExceeded) {
+			respondJSON(w, http.StatusForbidden, ErrorResponse{
+				Error:     err.Error(),
+				Code:      chatsession.ErrCodeLimitExceeded,
+				RequestID: w.Header().Get(requestIDHeader),
+			})
+			return
+		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("send message failed: %v", err))
 		return
 	}
@@ -275,7 +325,10 @@ func extractSessionIDFromAction(path, action string) string {
 
 // sessionToResponse converts a ChatSession to the API response type.
 func sessionToResponse(s *chatsession.ChatSession) SessionResponse {
-	info := s.Snapshot()
+	return sessionInfoToResponse(s.Snapshot())
+}
+
+func sessionInfoToResponse(info chatsession.SessionInfo) SessionResponse {
 	return SessionResponse{
 		ID:              info.ID,
 		ProjectName:     info.ProjectName,
@@ -287,6 +340,80 @@ func sessionToResponse(s *chatsession.ChatSession) SessionResponse {
 		LastActiveAt:    info.LastActiveAt,
 		CostUSD:         info.CostUSD,
 		TurnCount:       info.TurnCount,
+		MaxCostUSD:      info.MaxCostUSD,
+		MaxTurns:        info.MaxTurns,
 		ClientCount:     info.ClientCount,
 	}
 }
+
+// sessionMessageSortKeys are the sort keys of GET /sessions/{id}/messages.
+var sessionMessageSortKeys = sortKeys[SessionMessage]{
+	"seq": func(a, b SessionMessage) int { return a.Seq - b.Seq },
+}
+
+// handleSessionMessages handles GET /sessions/{id}/messages: the session's
+// recorded events, oldest first and 100 per page by default. It also works
+// for sessions that are closed or from before a server restart.
+func (s *HTTPServer) handleSessionMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	params, err := parseListParams(r.URL.Query(), sessionMessageSortKeys, 100)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	info, entries, ok := loadSessionTranscript(w, extractSessionIDFromAction(r.URL.Path, "messages"))
+	if !ok {
+		return
+	}
+
+	messages := make([]SessionMessage, 0, len(entries))
+	for _, e := range entries {
+		messages = append(messages, SessionMessage{Seq: e.Seq, Time: e.Time, Event: e.Event})
+	}
+	page, total, next := sortAndPage(messages, params, sessionMessageSortKeys)
+	respondList(w, SessionMessageListResponse{Session: sessionInfoToResponse(info), Messages: page, Total: total, NextOffset: next}, params.Fields)
+}
+
+// handleSessionTranscript handles GET /sessions/{id}/transcript: the whole
+// conversation as Markdown.
+func (s *HTTPServer) handleSessionTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := extractSessionIDFromAction(r.URL.Path, "transcript")
+	info, entries, ok := loadSessionTranscript(w, id)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	if r.URL.Query().Get("download") == "true" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".md"))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(chatsession.TranscriptMarkdown(info, entries)))
+}
+
+// loadSessionTranscript loads a session's transcript, answering the error
+// and returning false when it cannot.
+func loadSessionTranscript(w http.ResponseWriter, id string) (chatsession.SessionInfo, []chatsession.TranscriptEntry, bool) {
+	if id == "" {
+		respondError(w, http.StatusBadRequest, "session ID is required")
+		return chatsession.SessionInfo{}, nil, false
+	}
+	info, entries, err := chatsession.LoadTranscript(id)
+	if errors.Is(err, chatsession.ErrTranscriptNotFound) {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("session %s not found", id))
+		return info, nil, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read transcript: %v", err))
+		return info, nil, false
+	}
+	return info, entries, true
+}
@@ -2,14 +2,19 @@ package httpserver
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"codes/internal/chatsession"
+	"codes/pkg/schemas"
 
 	"github.com/gorilla/websocket"
 )
@@ -20,6 +25,7 @@ import (
 // and returns a new HTTPServer. This ensures test isolation.
 func setupSessionTest(t *testing.T) *HTTPServer {
 	t.Helper()
+	t.Setenv("HOME", t.TempDir()) // sessions write their transcripts there
 	chatsession.DefaultManager = chatsession.NewSessionManager()
 	return NewHTTPServer([]string{"test-token"}, "test")
 }
@@ -65,6 +71,7 @@ type wsTestMsg struct {
 	Status  string          `json:"status,omitempty"`
 	Event   json.RawMessage `json:"event,omitempty"`
 	Message string          `json:"message,omitempty"`
+	Code    string          `json:"code,omitempty"`
 }
 
 // ============================================================
@@ -321,8 +328,8 @@ func TestSendMessageValidation(t *testing.T) {
 	}
 	var errResp ErrorResponse
 	decodeJSON(t, w, &errResp)
-	if errResp.Error != "field 'content' is required" {
-		t.Errorf("Error = %q, want 'field 'content' is required'", errResp.Error)
+	if errResp.Error != "field 'content' or 'attachments' is required" {
+		t.Errorf("Error = %q, want 'field 'content' or 'attachments' is required'", errResp.Error)
 	}
 
 	// Nonexistent session.
@@ -333,6 +340,184 @@ func TestSendMessageValidation(t *testing.T) {
 	}
 }
 
+func TestSendMessageAttachments(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake claude binary is a shell script")
+	}
+	server := setupSessionTest(t)
+	// A fake claude that saves the first message it is sent
+	bin := t.TempDir()
+	capture := filepath.Join(t.TempDir(), "stdin.json")
+	script := "#!/bin/sh\nhead -n 1 > " + capture + ".tmp && mv " + capture + ".tmp " + capture + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	sess, _ := chatsession.DefaultManager.Create("", t.TempDir(), "")
+	defer chatsession.DefaultManager.Delete(sess.ID)
+	png := []byte("\x89PNG\r\n\x1a\n fake image")
+	b64 := base64.StdEncoding.EncodeToString
+
+	// Rejected before anything is sent
+	for _, bad := range []SessionAttachment{
+		{Name: "x.png", Data: "not base64!"},
+		{Name: "empty.txt", Data: ""},
+	} {
+		w := doReq(t, server, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/message",
+			SessionSendMessageRequest{Attachments: []SessionAttachment{bad}}))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("attachment %q: %d %s, want 400", bad.Name, w.Code, w.Body.String())
+		}
+	}
+
+	w := doReq(t, server, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/message",
+		SessionSendMessageRequest{Content: "Why does it look like this?", Attachments: []SessionAttachment{
+			{Name: "../screenshot.png", Data: b64(png)},
+			{Name: "log.txt", MediaType: "text/plain", Data: b64([]byte("panic: boom"))},
+		}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("send: %d %s", w.Code, w.Body.String())
+	}
+
+	var data []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if data, _ = os.ReadFile(capture); len(data) > 0 {
+			break
+		}
+	}
+	var msg struct {
+		Message struct {
+			Content []struct {
+				Type   string `json:"type"`
+				Text   string `json:"text"`
+				Source struct {
+					MediaType string `json:"media_type"`
+					Data      string `json:"data"`
+				} `json:"source"`
+			} `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("claude got %q: %v", data, err)
+	}
+	blocks := msg.Message.Content
+	if len(blocks) != 2 || blocks[0].Type != "text" || blocks[1].Type != "image" || blocks[1].Source.MediaType != "image/png" || blocks[1].Source.Data != b64(png) {
+		t.Fatalf("content blocks = %+v", blocks)
+	}
+	dir := chatsession.AttachmentDir(sess.ID)
+	for _, want := range []string{"Why does it look like this?", filepath.Join(dir, "1-screenshot.png"), filepath.Join(dir, "2-log.txt")} {
+		if !strings.Contains(blocks[0].Text, want) {
+			t.Errorf("text lacks %q: %s", want, blocks[0].Text)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "2-log.txt")); string(got) != "panic: boom" {
+		t.Errorf("saved log.txt = %q", got)
+	}
+
+	// The transcript names the files without their contents
+	_, entries, _ := chatsession.LoadTranscript(sess.ID)
+	if len(entries) == 0 || !strings.Contains(string(entries[0].Event), `"name":"screenshot.png"`) || strings.Contains(string(entries[0].Event), b64(png)) {
+		t.Errorf("transcript entry = %s", entries[0].Event)
+	}
+
+	chatsession.DefaultManager.Delete(sess.ID)
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("attachments kept after the session closed: %v", err)
+	}
+}
+
+// TestSendMessageLargeAttachment sends an attachment over the general body
+// limit through the full handler chain.
+func TestSendMessageLargeAttachment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake claude binary is a shell script")
+	}
+	server := setupSessionTest(t)
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte("#!/bin/sh\ncat > /dev/null\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	handler := server.Handler()
+
+	sess, _ := chatsession.DefaultManager.Create("", t.TempDir(), "")
+	defer chatsession.DefaultManager.Delete(sess.ID)
+	screenshot := bytes.Repeat([]byte{0x89}, 3<<20)
+	body := SessionSendMessageRequest{Attachments: []SessionAttachment{
+		{Name: "screenshot.png", Data: base64.StdEncoding.EncodeToString(screenshot)},
+	}}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/message", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("send: %d %s", w.Code, w.Body.String())
+	}
+	if info, err := os.Stat(filepath.Join(chatsession.AttachmentDir(sess.ID), "1-screenshot.png")); err != nil || info.Size() != int64(len(screenshot)) {
+		t.Errorf("saved attachment: %v, %v", info, err)
+	}
+
+	// Other routes keep the general limit
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/resume", body))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("resume with a large body: %d, want 413", w.Code)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake claude binary is a shell script")
+	}
+	server := setupSessionTest(t)
+	ts := httptest.NewServer(server.mux)
+	defer ts.Close()
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte("#!/bin/sh\ncat > /dev/null\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	w := doReq(t, server, authedReq(t, http.MethodPost, "/sessions", CreateSessionRequest{ProjectPath: t.TempDir(), MaxTurns: -1}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("negative max_turns: %d, want 400", w.Code)
+	}
+	w = doReq(t, server, authedReq(t, http.MethodPost, "/sessions", CreateSessionRequest{ProjectPath: t.TempDir(), MaxCostUSD: 0.5, MaxTurns: 1}))
+	var created SessionResponse
+	decodeJSON(t, w, &created)
+	if w.Code != http.StatusCreated || created.MaxCostUSD != 0.5 || created.MaxTurns != 1 {
+		t.Fatalf("create: %d %+v", w.Code, created)
+	}
+	defer chatsession.DefaultManager.Delete(created.ID)
+	sess, _ := chatsession.DefaultManager.Get(created.ID)
+
+	w = doReq(t, server, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/message", SessionSendMessageRequest{Content: "first"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first message: %d %s", w.Code, w.Body.String())
+	}
+	w = doReq(t, server, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/message", SessionSendMessageRequest{Content: "second"}))
+	var errResp ErrorResponse
+	decodeJSON(t, w, &errResp)
+	if w.Code != http.StatusForbidden || errResp.Code != "limit_exceeded" || !strings.Contains(errResp.Error, "max_turns") {
+		t.Errorf("past max_turns: %d %+v", w.Code, errResp)
+	}
+
+	// Over WebSocket too, and for cost
+	sess.SetLimits(0.5, 0)
+	sess.CostUSD = 0.75
+	conn := dialWS(t, ts, sess.ID)
+	defer conn.Close()
+	for msg := readWSMsg(t, conn); msg.Type != "session_status"; msg = readWSMsg(t, conn) {
+	}
+	if err := conn.WriteJSON(map[string]string{"type": "user_message", "content": "third"}); err != nil {
+		t.Fatal(err)
+	}
+	msg := readWSMsg(t, conn)
+	if msg.Type != "error" || msg.Code != "limit_exceeded" || !strings.Contains(msg.Message, "max_cost_usd") {
+		t.Errorf("WebSocket past max_cost_usd: %+v", msg)
+	}
+}
+
 func TestResumeSessionValidation(t *testing.T) {
 	server := setupSessionTest(t)
 
@@ -445,7 +630,8 @@ func dialWS(t *testing.T, ts *httptest.Server, sessionID string) *websocket.Conn
 	return conn
 }
 
-// readWSMsg reads one WebSocket message with a timeout.
+// readWSMsg reads one WebSocket message with a timeout and checks it against
+// the published session event schema.
 func readWSMsg(t *testing.T, conn *websocket.Conn) wsTestMsg {
 	t.Helper()
 	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
@@ -453,6 +639,9 @@ func readWSMsg(t *testing.T, conn *websocket.Conn) wsTestMsg {
 	if err != nil {
 		t.Fatalf("WebSocket read: %v", err)
 	}
+	if err := schemas.Validate(schemas.SessionEvent, raw); err != nil {
+		t.Errorf("WebSocket message %s: %v", raw, err)
+	}
 
 	var msg wsTestMsg
 	if err := json.Unmarshal(raw, &msg); err != nil {
@@ -643,8 +832,8 @@ func TestSessionWebSocketEmptyUserMessage(t *testing.T) {
 	if msg.Type != "error" {
 		t.Errorf("Type = %q, want error", msg.Type)
 	}
-	if !strings.Contains(msg.Message, "content is required") {
-		t.Errorf("Message should mention 'content is required', got: %s", msg.Message)
+	if !strings.Contains(msg.Message, "content or attachments are required") {
+		t.Errorf("Message should mention 'content or attachments are required', got: %s", msg.Message)
 	}
 }
 
@@ -684,6 +873,176 @@ func TestSessionWebSocketClientDisconnect(t *testing.T) {
 // Path Extraction Helper Tests
 // ============================================================
 
+func TestSessionTranscript(t *testing.T) {
+	server := setupSessionTest(t)
+	sess, err := chatsession.DefaultManager.Create("my-project", "/tmp/test-project", "sonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Events as recordLocked appends them
+	dir, _ := chatsession.TranscriptDir()
+	events := []string{
+		`{"type":"user","content":"What does main.go do?"}`,
+		`{"type":"system","subtype":"init","session_id":"claude-1"}`,
+		`{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read","input":{"file_path":"main.go"}}]}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"It starts the server."}]}}`,
+		`{"type":"result","total_cost_usd":0.0123}`,
+	}
+	var lines []string
+	for i, e := range events {
+		data, _ := json.Marshal(chatsession.TranscriptEntry{Seq: i + 1, Time: time.Now(), Event: json.RawMessage(e)})
+		lines = append(lines, string(data))
+	}
+	if err := os.WriteFile(filepath.Join(dir, sess.ID+".jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doReq(t, server, authedReq(t, http.MethodGet, "/sessions/"+sess.ID+"/messages?limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("messages: %d %s", w.Code, w.Body.String())
+	}
+	var page SessionMessageListResponse
+	decodeJSON(t, w, &page)
+	if page.Total != 5 || len(page.Messages) != 2 || page.Messages[0].Seq != 1 || page.NextOffset != 2 {
+		t.Errorf("first page: total %d, %d messages, next %d", page.Total, len(page.Messages), page.NextOffset)
+	}
+	if page.Session.ID != sess.ID || page.Session.Status != string(chatsession.StatusCreating) {
+		t.Errorf("session = %+v", page.Session)
+	}
+
+	w = doReq(t, server, authedReq(t, http.MethodGet, "/sessions/"+sess.ID+"/transcript", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") {
+		t.Fatalf("transcript: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	md := w.Body.String()
+	for _, want := range []string{"# Session " + sess.ID + ": my-project", "What does main.go do?", "It starts the server.", "Tool: `Read`", "$0.0123"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("transcript lacks %q:\n%s", want, md)
+		}
+	}
+
+	// Still there once the session is gone, as after a restart
+	chatsession.DefaultManager.Delete(sess.ID)
+	chatsession.DefaultManager = chatsession.NewSessionManager()
+	w = doReq(t, server, authedReq(t, http.MethodGet, "/sessions/"+sess.ID+"/messages?offset=4", nil))
+	decodeJSON(t, w, &page)
+	if w.Code != http.StatusOK || len(page.Messages) != 1 || page.Messages[0].Seq != 5 || page.Session.Status != string(chatsession.StatusClosed) {
+		t.Errorf("after close: %d, %+v", w.Code, page)
+	}
+	if w := doReq(t, server, authedReq(t, http.MethodGet, "/sessions/cs-missing/messages", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("unknown session: %d, want 404", w.Code)
+	}
+	if w := doReq(t, server, authedReq(t, http.MethodGet, "/sessions/"+sess.ID+"/messages?limit=0", nil)); w.Code != http.StatusBadRequest {
+		t.Errorf("limit=0: %d, want 400", w.Code)
+	}
+
+	// Old transcripts are pruned, live sessions never
+	if n, err := chatsession.PruneTranscripts(time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("PruneTranscripts = %d, %v; want 1", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sess.ID+".jsonl")); !os.IsNotExist(err) {
+		t.Errorf("transcript kept after pruning: %v", err)
+	}
+}
+
+func TestSessionRestore(t *testing.T) {
+	server := setupSessionTest(t)
+	kept, err := chatsession.DefaultManager.Create("kept", "/tmp/test-project", "sonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := chatsession.DefaultManager.Create("deleted", "/tmp/test-project", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chatsession.DefaultManager.Delete(deleted.ID)
+
+	// Server shutdown, then a new server
+	chatsession.DefaultManager.CloseAll()
+	chatsession.DefaultManager = chatsession.NewSessionManager()
+	n, err := chatsession.DefaultManager.Restore()
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v; want 1", n, err)
+	}
+
+	w := doReq(t, server, authedReq(t, http.MethodGet, "/sessions", nil))
+	var list SessionListResponse
+	decodeJSON(t, w, &list)
+	if len(list.Sessions) != 1 || list.Sessions[0].ID != kept.ID {
+		t.Fatalf("sessions after restart = %+v, want only %s", list.Sessions, kept.ID)
+	}
+	got := list.Sessions[0]
+	if got.Status != string(chatsession.StatusReady) || got.ProjectName != "kept" || got.Model != "sonnet" {
+		t.Errorf("restored session = %+v", got)
+	}
+
+	// Restoring again adds nothing
+	if n, _ := chatsession.DefaultManager.Restore(); n != 0 {
+		t.Errorf("second Restore = %d, want 0", n)
+	}
+}
+
+func TestSessionIdleReaper(t *testing.T) {
+	server := setupSessionTest(t)
+	ts := httptest.NewServer(server.mux)
+	defer ts.Close()
+
+	idle, _ := chatsession.DefaultManager.Create("idle", "/tmp/test", "")
+	active, _ := chatsession.DefaultManager.Create("active", "/tmp/test", "")
+	defer chatsession.DefaultManager.Delete(active.ID)
+	idle.LastActiveAt = time.Now().Add(-2 * time.Hour)
+
+	conn := dialWS(t, ts, idle.ID)
+	defer conn.Close()
+	readWSMsg(t, conn) // initial status
+
+	if got := chatsession.DefaultManager.ReapIdle(time.Hour); len(got) != 1 || got[0] != idle.ID {
+		t.Fatalf("ReapIdle = %v, want [%s]", got, idle.ID)
+	}
+	if msg := readWSMsg(t, conn); msg.Type != "session_expired" || !strings.Contains(msg.Message, "1h0m0s") {
+		t.Errorf("first message = %+v, want session_expired", msg)
+	}
+	if msg := readWSMsg(t, conn); msg.Type != "session_status" || msg.Status != string(chatsession.StatusClosed) {
+		t.Errorf("second message = %+v, want session_status closed", msg)
+	}
+
+	if w := doReq(t, server, authedReq(t, http.MethodGet, "/sessions/"+idle.ID, nil)); w.Code != http.StatusNotFound {
+		t.Errorf("expired session: %d, want 404", w.Code)
+	}
+	if _, ok := chatsession.DefaultManager.Get(active.ID); !ok {
+		t.Error("active session was reaped")
+	}
+	// Saved as closed: readable, but not restored
+	if info, _, err := chatsession.LoadTranscript(idle.ID); err != nil || info.Status != chatsession.StatusClosed {
+		t.Errorf("LoadTranscript = %+v, %v", info, err)
+	}
+	if n, _ := chatsession.NewSessionManager().Restore(); n != 1 {
+		t.Errorf("Restore = %d, want only the active session", n)
+	}
+}
+
+func TestParseIdleTTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", chatsession.DefaultIdleTTL, true},
+		{"2h", 2 * time.Hour, true},
+		{"off", 0, true},
+		{"0", 0, true},
+		{"-1h", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := chatsession.ParseIdleTTL(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseIdleTTL(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
 func TestExtractSessionID(t *testing.T) {
 	tests := []struct {
 		path string
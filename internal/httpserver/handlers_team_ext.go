@@ -2,34 +2,66 @@ package httpserver
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	"codes/internal/agent"
+	"codes/internal/config"
+	"codes/pkg/client"
 )
 
 // --- Conversion helpers ---
 
 func taskToResponse(t *agent.Task) TaskResponse {
 	return TaskResponse{
-		ID:          t.ID,
-		Subject:     t.Subject,
-		Description: t.Description,
-		Status:      string(t.Status),
-		Priority:    string(t.Priority),
-		Owner:       t.Owner,
-		Project:     t.Project,
-		WorkDir:     t.WorkDir,
-		Result:      t.Result,
-		Error:       t.Error,
-		CreatedAt:   t.CreatedAt,
-		UpdatedAt:   t.UpdatedAt,
-		CompletedAt: t.CompletedAt,
+		ID:           t.ID,
+		Subject:      t.Subject,
+		Description:  t.Description,
+		Status:       string(t.Status),
+		Priority:     string(t.Priority),
+		Owner:        t.Owner,
+		Type:         string(t.Type),
+		Choices:      t.Choices,
+		Project:      t.Project,
+		WorkDir:      t.WorkDir,
+		FollowUpOf:   t.FollowUpOf,
+		RequestID:    t.RequestID,
+		Result:       t.Result,
+		AnsweredBy:   t.AnsweredBy,
+		Summary:      summaryToResponse(t.Summary),
+		Error:        t.Error,
+		Artifacts:    t.ArtifactFiles,
+		ContextFiles: t.ContextFiles,
+		History:      historyToResponse(t.History),
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+		AskedAt:      t.AskedAt,
+		CompletedAt:  t.CompletedAt,
 	}
 }
 
+func summaryToResponse(s *agent.TaskSummary) *client.TaskSummary {
+	if s == nil {
+		return nil
+	}
+	return &client.TaskSummary{Changes: s.Changes, Files: s.Files, FollowUps: s.FollowUps}
+}
+
+func historyToResponse(history []agent.TaskTransition) []client.TaskTransition {
+	if len(history) == 0 {
+		return nil
+	}
+	out := make([]client.TaskTransition, len(history))
+	for i, h := range history {
+		out[i] = client.TaskTransition{From: string(h.From), To: string(h.To), At: h.At}
+	}
+	return out
+}
+
 func messageToResponse(m *agent.Message) MessageResponse {
 	return MessageResponse{
 		ID:        m.ID,
@@ -52,7 +84,6 @@ func (s *HTTPServer) handleCreateTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req CreateTeamRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -70,6 +101,10 @@ func (s *HTTPServer) handleCreateTeam(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusConflict, fmt.Sprintf("team already exists: %v", err))
 			return
 		}
+		if errors.Is(err, config.ErrWorkDirNotAllowed) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create team: %v", err))
 		return
 	}
@@ -116,6 +151,26 @@ func (s *HTTPServer) handleDeleteTeam(w http.ResponseWriter, r *http.Request) {
 
 // --- Task handlers ---
 
+// taskStatusOrder ranks task statuses along the task lifecycle for sorting.
+var taskStatusOrder = []string{
+	string(agent.TaskPending), string(agent.TaskAssigned), string(agent.TaskRunning),
+	string(agent.TaskCompleted), string(agent.TaskFailed), string(agent.TaskCancelled),
+}
+
+// taskSortKeys are the sort keys of GET /teams/{name}/tasks. The default
+// order is by priority, then ID.
+var taskSortKeys = sortKeys[TaskResponse]{
+	"id":         func(a, b TaskResponse) int { return a.ID - b.ID },
+	"created_at": func(a, b TaskResponse) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b TaskResponse) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+	"priority": func(a, b TaskResponse) int {
+		return agent.PriorityRank(agent.TaskPriority(a.Priority)) - agent.PriorityRank(agent.TaskPriority(b.Priority))
+	},
+	"status": func(a, b TaskResponse) int {
+		return slices.Index(taskStatusOrder, a.Status) - slices.Index(taskStatusOrder, b.Status)
+	},
+}
+
 // handleListTeamTasks handles GET /teams/{name}/tasks
 func (s *HTTPServer) handleListTeamTasks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -135,6 +190,12 @@ func (s *HTTPServer) handleListTeamTasks(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	params, err := parseListParams(r.URL.Query(), taskSortKeys, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Parse query filters
 	statusFilter := agent.TaskStatus(r.URL.Query().Get("status"))
 	ownerFilter := r.URL.Query().Get("owner")
@@ -150,7 +211,8 @@ func (s *HTTPServer) handleListTeamTasks(w http.ResponseWriter, r *http.Request)
 		resp = append(resp, taskToResponse(t))
 	}
 
-	respondJSON(w, http.StatusOK, TaskListResponse{Tasks: resp})
+	page, total, next := sortAndPage(resp, params, taskSortKeys)
+	respondList(w, TaskListResponse{Tasks: page, Total: total, NextOffset: next}, params.Fields)
 }
 
 // handleCreateTeamTask handles POST /teams/{name}/tasks
@@ -172,7 +234,6 @@ func (s *HTTPServer) handleCreateTeamTask(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req CreateTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -194,13 +255,125 @@ func (s *HTTPServer) handleCreateTeamTask(w http.ResponseWriter, r *http.Request
 		priority = agent.PriorityNormal
 	}
 
-	task, err := agent.CreateTask(teamName, req.Subject, req.Description, req.Owner, req.BlockedBy, priority, req.Project, req.WorkDir)
+	s.createTask(w, r, teamName, req, priority)
+}
+
+// createTask creates the task in a single write, with its artifacts and
+// context files: a daemon may claim it as soon as it is written.
+func (s *HTTPServer) createTask(w http.ResponseWriter, r *http.Request, teamName string, req CreateTaskRequest, priority agent.TaskPriority) {
+	tasks, err := agent.CreateTasks(teamName, []agent.TaskSpec{{
+		Subject:      req.Subject,
+		Description:  req.Description,
+		Owner:        req.Owner,
+		BlockedBy:    req.BlockedBy,
+		Priority:     priority,
+		Project:      req.Project,
+		WorkDir:      req.WorkDir,
+		Artifacts:    req.Artifacts,
+		ContextFiles: req.ContextFiles,
+		Type:         agent.TaskType(req.Type),
+		Choices:      req.Choices,
+	}})
 	if err != nil {
+		if errors.Is(err, config.ErrWorkDirNotAllowed) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if strings.HasPrefix(err.Error(), "task ") {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid task: %v", err))
+			return
+		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create task: %v", err))
 		return
 	}
+	respondJSON(w, http.StatusCreated, taskToResponse(tagTask(r, teamName, tasks[0])))
+}
+
+// handleCreateTeamTaskBatch handles POST /teams/{name}/tasks/batch. The
+// tasks are created all-or-nothing; depends_on refers to earlier tasks in
+// the same request by 1-based position.
+func (s *HTTPServer) handleCreateTeamTaskBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] != "tasks" || parts[3] != "batch" {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /teams/{name}/tasks/batch)")
+		return
+	}
 
-	respondJSON(w, http.StatusCreated, taskToResponse(task))
+	teamName := parts[1]
+	if teamName == "" {
+		respondError(w, http.StatusBadRequest, "team name is required")
+		return
+	}
+
+	var req CreateTaskBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+	if len(req.Tasks) == 0 {
+		respondError(w, http.StatusBadRequest, "field 'tasks' is required")
+		return
+	}
+
+	if _, err := agent.GetTeam(teamName); err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not exist") {
+			respondError(w, http.StatusNotFound, fmt.Sprintf("team not found: %v", err))
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get team: %v", err))
+		return
+	}
+
+	specs := make([]agent.TaskSpec, len(req.Tasks))
+	for i, t := range req.Tasks {
+		specs[i] = agent.TaskSpec{
+			Subject:      t.Subject,
+			Description:  t.Description,
+			Owner:        t.Owner,
+			BlockedBy:    t.BlockedBy,
+			DependsOn:    t.DependsOn,
+			Priority:     agent.TaskPriority(t.Priority),
+			Project:      t.Project,
+			WorkDir:      t.WorkDir,
+			Skills:       t.Skills,
+			Artifacts:    t.Artifacts,
+			ContextFiles: t.ContextFiles,
+			Type:         agent.TaskType(t.Type),
+			Choices:      t.Choices,
+		}
+	}
+
+	// CreateTasks checks the whole batch before writing anything, so its
+	// errors are the request's fault unless a write failed.
+	tasks, err := agent.CreateTasks(teamName, specs)
+	if err != nil {
+		if errors.Is(err, config.ErrWorkDirNotAllowed) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if strings.HasPrefix(err.Error(), "task ") || strings.HasPrefix(err.Error(), "no tasks") {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid batch: %v", err))
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create tasks: %v", err))
+		return
+	}
+
+	resp := CreateTaskBatchResponse{
+		Tasks: make([]TaskResponse, len(tasks)),
+		IDs:   make([]int, len(tasks)),
+	}
+	for i, task := range tasks {
+		resp.Tasks[i] = taskToResponse(tagTask(r, teamName, task))
+		resp.IDs[i] = task.ID
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
 }
 
 // handleUpdateTeamTask handles PATCH /teams/{name}/tasks/{id}
@@ -228,7 +401,6 @@ func (s *HTTPServer) handleUpdateTeamTask(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req UpdateTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
@@ -257,12 +429,24 @@ func (s *HTTPServer) handleUpdateTeamTask(w http.ResponseWriter, r *http.Request
 			return
 		}
 		task, err = agent.RedirectTask(teamName, taskID, req.Instructions, req.Subject)
+	case "followup":
+		if req.Instructions == "" {
+			respondError(w, http.StatusBadRequest, "field 'instructions' is required for followup action")
+			return
+		}
+		task, err = agent.FollowUpTask(teamName, taskID, req.Instructions, req.Subject)
 	case "complete":
 		task, err = agent.CompleteTask(teamName, taskID, req.Result)
 	case "fail":
 		task, err = agent.FailTask(teamName, taskID, req.Error)
+	case "answer":
+		if req.Answer == "" {
+			respondError(w, http.StatusBadRequest, "field 'answer' is required for answer action")
+			return
+		}
+		task, err = agent.AnswerTask(teamName, taskID, req.Answer, "")
 	default:
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown action: %s (valid: cancel, assign, redirect, complete, fail)", req.Action))
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown action: %s (valid: cancel, assign, redirect, followup, complete, fail, answer)", req.Action))
 		return
 	}
 
@@ -271,15 +455,179 @@ func (s *HTTPServer) handleUpdateTeamTask(w http.ResponseWriter, r *http.Request
 			respondError(w, http.StatusNotFound, fmt.Sprintf("task not found: %v", err))
 			return
 		}
+		var invalid *agent.InvalidTransitionError
+		if errors.As(err, &invalid) {
+			respondError(w, http.StatusConflict, fmt.Sprintf("failed to %s task: %v", req.Action, err))
+			return
+		}
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to %s task: %v", req.Action, err))
 		return
 	}
+	if req.Action == "redirect" || req.Action == "followup" {
+		task = tagTask(r, teamName, task)
+	}
 
 	respondJSON(w, http.StatusOK, taskToResponse(task))
 }
 
+// handleListQuestions handles GET /teams/{name}/questions: the team's human
+// tasks that are waiting for an answer.
+func (s *HTTPServer) handleListQuestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	teamName := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[1]
+	if _, err := agent.GetTeam(teamName); err != nil {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("team not found: %v", err))
+		return
+	}
+	tasks, err := agent.OpenQuestions(teamName)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list questions: %v", err))
+		return
+	}
+
+	resp := TaskListResponse{Tasks: make([]TaskResponse, 0, len(tasks)), Total: len(tasks)}
+	for _, t := range tasks {
+		resp.Tasks = append(resp.Tasks, taskToResponse(t))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// tagTask records r's request ID on a task it created or redirected, so the
+// daemon's log lines for the task can be traced back to the request.
+func tagTask(r *http.Request, teamName string, task *agent.Task) *agent.Task {
+	id := requestID(r)
+	if id == "" {
+		return task
+	}
+	if tagged, err := agent.SetTaskRequestID(teamName, task.ID, id); err == nil {
+		return tagged
+	}
+	return task
+}
+
+// handleListTaskArtifacts handles GET /teams/{name}/tasks/{id}/artifacts
+func (s *HTTPServer) handleListTaskArtifacts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 5 || parts[2] != "tasks" || parts[4] != "artifacts" {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /teams/{name}/tasks/{id}/artifacts)")
+		return
+	}
+
+	teamName := parts[1]
+	taskID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	if _, err := agent.GetTask(teamName, taskID); err != nil {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("task not found: %v", err))
+		return
+	}
+
+	files, err := agent.ListArtifacts(teamName, taskID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list artifacts: %v", err))
+		return
+	}
+	if files == nil {
+		files = []string{}
+	}
+
+	respondJSON(w, http.StatusOK, ArtifactListResponse{Artifacts: files})
+}
+
+// handleAgentLogs handles GET /teams/{name}/agents/{agent}/logs?lines=N&grep=pattern
+func (s *HTTPServer) handleAgentLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 5 || parts[2] != "agents" || parts[4] != "logs" {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /teams/{name}/agents/{agent}/logs)")
+		return
+	}
+
+	teamName, agentName := parts[1], parts[3]
+	lines := 100
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "invalid lines parameter")
+			return
+		}
+		lines = n
+	}
+
+	logLines, err := agent.ReadAgentLog(teamName, agentName, lines, r.URL.Query().Get("grep"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "invalid grep pattern"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read logs: %v", err))
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, AgentLogsResponse{
+		Lines: logLines,
+		Alive: agent.IsAgentAlive(teamName, agentName),
+	})
+}
+
+// handleGetTaskArtifact handles GET /teams/{name}/tasks/{id}/artifacts/{file}
+func (s *HTTPServer) handleGetTaskArtifact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 6 || parts[2] != "tasks" || parts[4] != "artifacts" {
+		respondError(w, http.StatusBadRequest, "invalid path format (expected /teams/{name}/tasks/{id}/artifacts/{file})")
+		return
+	}
+
+	teamName := parts[1]
+	taskID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	path, err := agent.ArtifactPath(teamName, taskID, parts[5])
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", parts[5]))
+	http.ServeFile(w, r, path)
+}
+
 // --- Message handlers ---
 
+// messageSortKeys are the sort keys of GET /teams/{name}/messages.
+var messageSortKeys = sortKeys[MessageResponse]{
+	"created_at": func(a, b MessageResponse) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"from":       func(a, b MessageResponse) int { return strings.Compare(a.From, b.From) },
+	"type":       func(a, b MessageResponse) int { return strings.Compare(a.Type, b.Type) },
+}
+
 // handleListTeamMessages handles GET /teams/{name}/messages
 func (s *HTTPServer) handleListTeamMessages(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -303,19 +651,23 @@ func (s *HTTPServer) handleListTeamMessages(w http.ResponseWriter, r *http.Reque
 	agentName := query.Get("agent")
 	unreadOnly := query.Get("unread") == "true" || query.Get("unread") == "1"
 
-	var messages []*agent.Message
-	var err error
+	// The team-wide view is newest first and capped at 50 by default; an
+	// agent's inbox is returned whole unless a limit is given.
+	defaultLimit := 50
+	if agentName != "" {
+		defaultLimit = 0
+	}
+	params, err := parseListParams(query, messageSortKeys, defaultLimit)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
+	var messages []*agent.Message
 	if agentName != "" {
 		messages, err = agent.GetMessages(teamName, agentName, unreadOnly)
 	} else {
-		limit := 50
-		if limitStr := query.Get("limit"); limitStr != "" {
-			if l, parseErr := strconv.Atoi(limitStr); parseErr == nil && l > 0 {
-				limit = l
-			}
-		}
-		messages, err = agent.GetAllTeamMessages(teamName, limit)
+		messages, err = agent.GetAllTeamMessages(teamName, 0)
 	}
 
 	if err != nil {
@@ -328,7 +680,8 @@ func (s *HTTPServer) handleListTeamMessages(w http.ResponseWriter, r *http.Reque
 		resp = append(resp, messageToResponse(m))
 	}
 
-	respondJSON(w, http.StatusOK, MessageListResponse{Messages: resp})
+	page, total, next := sortAndPage(resp, params, messageSortKeys)
+	respondList(w, MessageListResponse{Messages: page, Total: total, NextOffset: next}, params.Fields)
 }
 
 // handleSendTeamMessage handles POST /teams/{name}/messages
@@ -350,7 +703,6 @@ func (s *HTTPServer) handleSendTeamMessage(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req SendMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
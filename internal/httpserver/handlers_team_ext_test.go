@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	"codes/internal/agent"
+	"codes/internal/config"
 )
 
 // uniqueTeamName generates a unique team name for test isolation.
@@ -317,6 +321,75 @@ func TestCreateTeamTaskMissingSubject(t *testing.T) {
 	}
 }
 
+// TestCreateTeamTaskBatch tests POST /teams/{name}/tasks/batch.
+func TestCreateTeamTaskBatch(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("task-batch")
+
+	_, err := agent.CreateTeam(teamName, "", "")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	post := func(team string, req CreateTaskBatchRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/teams/"+team+"/tasks/batch", bytes.NewReader(body))
+		r.Header.Set("Authorization", "Bearer test-token")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post(teamName, CreateTaskBatchRequest{Tasks: []BatchTaskRequest{
+		{Subject: "Build", Priority: "high"},
+		{Subject: "Test", DependsOn: []int{1}},
+		{Subject: "Release", DependsOn: []int{1, 2}},
+	}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var resp CreateTaskBatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Tasks) != 3 || len(resp.IDs) != 3 {
+		t.Fatalf("Expected 3 tasks and IDs, got %+v", resp)
+	}
+	for i, subject := range []string{"Build", "Test", "Release"} {
+		if resp.Tasks[i].Subject != subject || resp.Tasks[i].ID != resp.IDs[i] {
+			t.Errorf("task %d = %+v (id %d), want subject %q", i+1, resp.Tasks[i], resp.IDs[i], subject)
+		}
+	}
+	release, err := agent.GetTask(teamName, resp.IDs[2])
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if !slices.Equal(release.BlockedBy, resp.IDs[:2]) {
+		t.Errorf("Release blocked by %v, want %v", release.BlockedBy, resp.IDs[:2])
+	}
+
+	// A bad entry anywhere rejects the whole batch
+	w = post(teamName, CreateTaskBatchRequest{Tasks: []BatchTaskRequest{
+		{Subject: "Fine"},
+		{Subject: "Forward reference", DependsOn: []int{3}},
+	}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("forward depends_on: expected status 400, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if tasks, _ := agent.ListTasks(teamName, "", ""); len(tasks) != 3 {
+		t.Errorf("rejected batch left tasks behind: %d tasks", len(tasks))
+	}
+
+	if w := post(teamName, CreateTaskBatchRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: expected status 400, got %d", w.Code)
+	}
+	if w := post(uniqueTeamName("missing"), CreateTaskBatchRequest{Tasks: []BatchTaskRequest{{Subject: "x"}}}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown team: expected status 404, got %d", w.Code)
+	}
+}
+
 // TestListTeamTasks tests GET /teams/{name}/tasks.
 func TestListTeamTasks(t *testing.T) {
 	server := NewHTTPServer([]string{"test-token"}, "test")
@@ -533,6 +606,185 @@ func TestUpdateTeamTaskComplete(t *testing.T) {
 	}
 }
 
+// TestUpdateTeamTaskFollowup tests PATCH followup action.
+func TestUpdateTeamTaskFollowup(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("taskfollowup")
+
+	_, err := agent.CreateTeam(teamName, "", "")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	task, err := agent.CreateTask(teamName, "Add login", "", "worker", nil, agent.PriorityNormal, "", "")
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if _, err := agent.CompleteTask(teamName, task.ID, "ok"); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	agent.UpdateTask(teamName, task.ID, func(t *agent.Task) error {
+		t.SessionID = "sess-1"
+		return nil
+	})
+
+	body, _ := json.Marshal(UpdateTaskRequest{Action: "followup", Instructions: "also add logout"})
+	path := fmt.Sprintf("/teams/%s/tasks/%d", teamName, task.ID)
+
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	var resp TaskResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp.ID == task.ID || resp.FollowUpOf != task.ID {
+		t.Errorf("Expected a new task following up #%d, got id %d follow_up_of %d", task.ID, resp.ID, resp.FollowUpOf)
+	}
+	if resp.Owner != "worker" || resp.Description != "also add logout" {
+		t.Errorf("Unexpected follow-up task: %+v", resp)
+	}
+}
+
+// TestUpdateTeamTaskInvalidTransition tests that PATCH rejects a status change
+// the task state machine does not allow.
+func TestUpdateTeamTaskInvalidTransition(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("taskfsm")
+
+	_, err := agent.CreateTeam(teamName, "", "")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	task, err := agent.CreateTask(teamName, "Done already", "", "worker", nil, agent.PriorityNormal, "", "")
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if _, err := agent.CompleteTask(teamName, task.ID, "ok"); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	body, _ := json.Marshal(UpdateTaskRequest{Action: "fail", Error: "late failure"})
+	path := fmt.Sprintf("/teams/%s/tasks/%d", teamName, task.ID)
+
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	got, _ := agent.GetTask(teamName, task.ID)
+	if got.Status != agent.TaskCompleted {
+		t.Errorf("Expected task to stay completed, got %q", got.Status)
+	}
+}
+
+// TestHumanTaskQuestions tests creating a human task, listing it under
+// /questions once unblocked and answering it with PATCH.
+func TestHumanTaskQuestions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // the answer writes a notification file
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("questions")
+
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	blocker, err := agent.CreateTask(teamName, "Build release", "", "worker", nil, agent.PriorityNormal, "", "")
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+	questions := func() []TaskResponse {
+		w := do(http.MethodGet, "/teams/"+teamName+"/questions", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("questions: status %d (body: %s)", w.Code, w.Body.String())
+		}
+		var resp TaskListResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Tasks
+	}
+
+	if w := do(http.MethodPost, "/teams/"+teamName+"/tasks", CreateTaskRequest{Subject: "Ship?", Choices: []string{"yes"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("choices without type: status %d, want 400", w.Code)
+	}
+	w := do(http.MethodPost, "/teams/"+teamName+"/tasks", CreateTaskRequest{
+		Subject: "Ship to production?", Type: "human", Choices: []string{"yes", "no"}, BlockedBy: []int{blocker.ID},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var created TaskResponse
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Type != "human" || created.Owner != agent.HumanOwner || created.Status != "assigned" {
+		t.Errorf("Unexpected human task: %+v", created)
+	}
+
+	if qs := questions(); len(qs) != 0 {
+		t.Errorf("Expected no questions while blocked, got %+v", qs)
+	}
+	path := fmt.Sprintf("/teams/%s/tasks/%d", teamName, created.ID)
+	if w := do(http.MethodPatch, path, UpdateTaskRequest{Action: "answer", Answer: "yes"}); w.Code != http.StatusBadRequest {
+		t.Errorf("answer while blocked: status %d, want 400", w.Code)
+	}
+
+	if _, err := agent.CompleteTask(teamName, blocker.ID, "built"); err != nil {
+		t.Fatal(err)
+	}
+	if qs := questions(); len(qs) != 1 || qs[0].ID != created.ID || len(qs[0].Choices) != 2 {
+		t.Fatalf("Expected the question to be open, got %+v", qs)
+	}
+
+	if w := do(http.MethodPatch, path, UpdateTaskRequest{Action: "answer", Answer: "maybe"}); w.Code != http.StatusBadRequest {
+		t.Errorf("answer outside choices: status %d, want 400", w.Code)
+	}
+	w = do(http.MethodPatch, path, UpdateTaskRequest{Action: "answer", Answer: "YES"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var answered TaskResponse
+	json.NewDecoder(w.Body).Decode(&answered)
+	if answered.Status != "completed" || answered.Result != "yes" || answered.AnsweredBy != agent.HumanOwner {
+		t.Errorf("Unexpected answered task: %+v", answered)
+	}
+	if qs := questions(); len(qs) != 0 {
+		t.Errorf("Expected no questions after the answer, got %+v", qs)
+	}
+	if w := do(http.MethodPatch, path, UpdateTaskRequest{Action: "answer", Answer: "no"}); w.Code != http.StatusConflict {
+		t.Errorf("second answer: status %d, want 409", w.Code)
+	}
+}
+
 // --- Messages ---
 
 // TestSendTeamMessage tests POST /teams/{name}/messages.
@@ -818,3 +1070,154 @@ func TestTeamActivityMethodNotAllowed(t *testing.T) {
 		t.Errorf("Expected status 405, got %d", w.Code)
 	}
 }
+
+// TestTaskArtifacts tests listing and downloading collected task artifacts.
+func TestTaskArtifacts(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("artifacts")
+
+	_, err := agent.CreateTeam(teamName, "", "")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	workDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, "report.txt"), []byte("hello report"), 0644)
+
+	task, _ := agent.CreateTask(teamName, "Report", "", "", nil, agent.PriorityNormal, "", "")
+	task, _ = agent.SetTaskArtifacts(teamName, task.ID, []string{"report.txt"})
+	if _, err := agent.CollectArtifacts(teamName, task, workDir); err != nil {
+		t.Fatalf("CollectArtifacts: %v", err)
+	}
+
+	base := fmt.Sprintf("/teams/%s/tasks/%d/artifacts", teamName, task.ID)
+
+	req := httptest.NewRequest(http.MethodGet, base, nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var list ArtifactListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Artifacts) != 1 || list.Artifacts[0] != "report.txt" {
+		t.Errorf("Expected [report.txt], got %v", list.Artifacts)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, base+"/report.txt", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if w.Body.String() != "hello report" {
+		t.Errorf("Expected artifact content 'hello report', got %q", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, base+"/missing.txt", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for missing artifact, got %d", w.Code)
+	}
+}
+
+// TestAgentLogs tests GET /teams/{name}/agents/{agent}/logs.
+func TestAgentLogs(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("logs")
+
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+	agent.AddMember(teamName, agent.TeamMember{Name: "worker"})
+
+	home, _ := os.UserHomeDir()
+	logPath := filepath.Join(home, ".codes", "teams", teamName, "agents", "worker.log")
+	os.WriteFile(logPath, []byte("picked up task #1\ntask #1 failed: timeout\npicked up task #2\n"), 0644)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedLines  int
+	}{
+		{"Tail", "/teams/" + teamName + "/agents/worker/logs?lines=2", http.StatusOK, 2},
+		{"Grep", "/teams/" + teamName + "/agents/worker/logs?grep=failed", http.StatusOK, 1},
+		{"Invalid grep", "/teams/" + teamName + "/agents/worker/logs?grep=(", http.StatusBadRequest, 0},
+		{"Invalid lines", "/teams/" + teamName + "/agents/worker/logs?lines=x", http.StatusBadRequest, 0},
+		{"Unknown agent", "/teams/" + teamName + "/agents/ghost/logs", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d (body: %s)", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var resp AgentLogsResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(resp.Lines) != tt.expectedLines {
+				t.Errorf("Expected %d lines, got %q", tt.expectedLines, resp.Lines)
+			}
+		})
+	}
+}
+
+func TestWorkDirPolicyForbidden(t *testing.T) {
+	allowed := t.TempDir()
+	cleanup := setupTestConfig(t, &config.Config{
+		WorkDirPolicy: &config.WorkDirPolicy{AllowedRoots: []string{allowed}},
+	})
+	defer cleanup()
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("policy")
+	defer agent.DeleteTeam(teamName)
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		r.Header.Set("Authorization", "Bearer test-token")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/teams", CreateTeamRequest{Name: teamName, WorkDir: "/"}); w.Code != http.StatusForbidden {
+		t.Errorf("create team in /: expected 403, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if w := post("/teams", CreateTeamRequest{Name: teamName, WorkDir: allowed}); w.Code != http.StatusCreated {
+		t.Fatalf("create team: expected 201, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if w := post("/teams/"+teamName+"/tasks", CreateTaskRequest{Subject: "x", WorkDir: os.TempDir()}); w.Code != http.StatusForbidden {
+		t.Errorf("create task: expected 403, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	batch := CreateTaskBatchRequest{Tasks: []BatchTaskRequest{{Subject: "x", WorkDir: "/"}}}
+	if w := post("/teams/"+teamName+"/tasks/batch", batch); w.Code != http.StatusForbidden {
+		t.Errorf("create task batch: expected 403, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if w := post("/sessions", CreateSessionRequest{ProjectPath: "/"}); w.Code != http.StatusForbidden {
+		t.Errorf("create session: expected 403, got %d (body: %s)", w.Code, w.Body.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"codes/internal/agent"
@@ -134,3 +135,94 @@ func TestGetTaskByPathRequiresAuth(t *testing.T) {
 		t.Errorf("Expected status 401, got %d", w.Code)
 	}
 }
+
+// TestMetricsEndpoint tests GET /metrics returns Prometheus text output.
+func TestMetricsEndpoint(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("metrics")
+
+	_, err := agent.CreateTeam(teamName, "", "")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	agent.CreateTask(teamName, "Pending task", "", "", nil, agent.PriorityNormal, "", "")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", ct)
+	}
+	want := fmt.Sprintf(`codes_tasks{team=%q,status="pending"} 1`, teamName)
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected metrics to contain %q", want)
+	}
+}
+
+// TestMetricsHTTPRequests tests that /metrics reports the server's own
+// requests and chat sessions.
+func TestMetricsHTTPRequests(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+
+	for _, path := range []string{"/health", "/health", "/teams"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		server.mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	out := w.Body.String()
+	for _, want := range []string{
+		`codes_http_requests_total{route="/health",method="GET",status="200"} 2`,
+		`codes_http_requests_total{route="/teams",method="GET",status="401"} 1`,
+		`codes_http_request_duration_seconds_count{route="/health",method="GET"} 2`,
+		`codes_http_request_duration_seconds_bucket{route="/health",method="GET",le="+Inf"} 2`,
+		"# TYPE codes_http_request_duration_seconds histogram",
+		`codes_chat_sessions{status="ready"}`,
+		"codes_websocket_clients ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics to contain %q", want)
+		}
+	}
+}
+
+// TestMetricsPublic tests that SetMetricsPublic serves /metrics without a
+// token and leaves the other endpoints protected.
+func TestMetricsPublic(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 without a token, got %d", w.Code)
+	}
+
+	server.SetMetricsPublic(true)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for public metrics, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected /teams to still require a token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"codes/internal/config"
+	"codes/internal/notify"
+)
+
+// routeWebhooks dispatches /webhooks.
+func (s *HTTPServer) routeWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleListWebhooks(w, r)
+	case http.MethodPost:
+		jsonContentTypeMiddleware(s.handleCreateWebhook)(w, r)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// routeWebhookByID dispatches /webhooks/{id} and /webhooks/{id}/test. The ID
+// is the webhook's name, or its path-escaped URL if it has none, so it is
+// taken from the escaped path.
+func (s *HTTPServer) routeWebhookByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/webhooks/"), "/")
+	escaped, action, _ := strings.Cut(rest, "/")
+	id, err := url.PathUnescape(escaped)
+	if err != nil || id == "" {
+		respondError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		s.handleDeleteWebhook(w, id)
+	case action == "test" && r.Method == http.MethodPost:
+		s.handleTestWebhook(w, id)
+	case action == "" || action == "test":
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	default:
+		respondError(w, http.StatusNotFound, "unknown webhook action: "+action)
+	}
+}
+
+func webhookToResponse(wh config.WebhookConfig) Webhook {
+	id := wh.Name
+	if id == "" {
+		id = wh.URL
+	}
+	return Webhook{
+		ID:     id,
+		Name:   wh.Name,
+		URL:    wh.URL,
+		Format: wh.Format,
+		Events: wh.Events,
+		Extra:  wh.Extra,
+	}
+}
+
+// handleListWebhooks handles GET /webhooks (admin scope).
+func (s *HTTPServer) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := config.ListWebhooks()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to load config: %v", err))
+		return
+	}
+	resp := WebhookListResponse{Webhooks: make([]Webhook, 0, len(webhooks))}
+	for _, wh := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, webhookToResponse(wh))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleCreateWebhook handles POST /webhooks (admin scope).
+func (s *HTTPServer) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	wh := config.WebhookConfig{
+		Name:   req.Name,
+		URL:    req.URL,
+		Format: req.Format,
+		Events: req.Events,
+		Extra:  req.Extra,
+	}
+	if err := config.ValidateWebhook(wh); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := config.AddWebhook(wh); err != nil {
+		if errors.Is(err, config.ErrWebhookExists) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to add webhook: %v", err))
+		return
+	}
+	if wh.Format == "" {
+		wh.Format = "slack"
+	}
+
+	respondJSON(w, http.StatusCreated, webhookToResponse(wh))
+}
+
+// handleDeleteWebhook handles DELETE /webhooks/{id} (admin scope).
+func (s *HTTPServer) handleDeleteWebhook(w http.ResponseWriter, id string) {
+	if err := config.RemoveWebhook(id); err != nil {
+		if errors.Is(err, config.ErrWebhookNotFound) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to remove webhook: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, StatusResponse{Status: "deleted", Message: fmt.Sprintf("Webhook %s removed", id)})
+}
+
+// handleTestWebhook handles POST /webhooks/{id}/test (admin scope): it sends
+// a sample task_completed event and reports whether the target accepted it.
+func (s *HTTPServer) handleTestWebhook(w http.ResponseWriter, id string) {
+	wh, ok := config.GetWebhook(id)
+	if !ok {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("%v: %q", config.ErrWebhookNotFound, id))
+		return
+	}
+
+	notifier := notify.NewWebhookNotifier(wh.URL, wh.Format, wh.Extra)
+	if err := notifier.Send(notify.Notification{
+		Title:   "codes: Task completed",
+		Message: "[example] #1 Test notification from the codes API",
+	}); err != nil {
+		respondError(w, http.StatusBadGateway, fmt.Sprintf("webhook test failed: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, StatusResponse{Status: "delivered", Message: fmt.Sprintf("Sample event sent to %s", id)})
+}
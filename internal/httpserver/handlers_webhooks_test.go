@@ -0,0 +1,97 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"codes/internal/config"
+	"codes/pkg/client"
+)
+
+func TestWebhookEndpoints(t *testing.T) {
+	cleanup := setupTestConfig(t, &config.Config{Webhooks: []config.WebhookConfig{
+		{URL: "https://hooks.example.com/services/unnamed", Format: "slack"},
+	}})
+	defer cleanup()
+
+	// Delivery target for the test endpoint
+	var received map[string]any
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &received)
+		if received["msg_type"] == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer target.Close()
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetAdminTokens([]string{"admin-token"})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	ctx := context.Background()
+	admin := client.New(ts.URL, "admin-token")
+
+	var apiErr *client.Error
+	if _, err := client.New(ts.URL, "test-token").ListWebhooks(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("ListWebhooks without admin scope: %v, want 403", err)
+	}
+
+	added, err := admin.AddWebhook(ctx, client.CreateWebhookRequest{Name: "ops", URL: target.URL, Format: "feishu", Events: []string{"task_failed"}})
+	if err != nil {
+		t.Fatalf("AddWebhook: %v", err)
+	}
+	if added.ID != "ops" || added.Format != "feishu" {
+		t.Errorf("added = %+v", added)
+	}
+	if _, err := admin.AddWebhook(ctx, client.CreateWebhookRequest{Name: "ops", URL: target.URL}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("duplicate name: %v, want 409", err)
+	}
+	for _, bad := range []client.CreateWebhookRequest{
+		{URL: "ftp://example.com"},
+		{URL: target.URL, Format: "carrier-pigeon"},
+		{URL: target.URL, Format: "telegram"},
+		{URL: target.URL, Events: []string{"task_started"}},
+	} {
+		if _, err := admin.AddWebhook(ctx, bad); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("AddWebhook(%+v): %v, want 400", bad, err)
+		}
+	}
+
+	webhooks, err := admin.ListWebhooks(ctx)
+	if err != nil {
+		t.Fatalf("ListWebhooks: %v", err)
+	}
+	if len(webhooks) != 2 || webhooks[0].ID != "https://hooks.example.com/services/unnamed" || webhooks[1].ID != "ops" {
+		t.Fatalf("webhooks = %+v", webhooks)
+	}
+
+	if err := admin.TestWebhook(ctx, "ops"); err != nil {
+		t.Errorf("TestWebhook: %v", err)
+	}
+	if received["msg_type"] != "text" {
+		t.Errorf("target received %v, want a feishu text message", received)
+	}
+	if err := admin.TestWebhook(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("TestWebhook(missing): %v, want 404", err)
+	}
+
+	// Unnamed webhooks are addressed by their URL
+	if err := admin.DeleteWebhook(ctx, webhooks[0].ID); err != nil {
+		t.Errorf("DeleteWebhook by URL: %v", err)
+	}
+	if err := admin.DeleteWebhook(ctx, "ops"); err != nil {
+		t.Errorf("DeleteWebhook: %v", err)
+	}
+	if err := admin.DeleteWebhook(ctx, "ops"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("second DeleteWebhook: %v, want 404", err)
+	}
+	if remaining, _ := config.ListWebhooks(); len(remaining) != 0 {
+		t.Errorf("webhooks left in config: %+v", remaining)
+	}
+}
@@ -90,7 +90,6 @@ func (s *HTTPServer) handleRunWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse optional request body
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req RunWorkflowRequest
 	// Body is optional; ignore decode errors for empty body
 	_ = json.NewDecoder(r.Body).Decode(&req)
@@ -0,0 +1,173 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyKeyHeader lets a client retry a POST that creates something
+// without creating it twice: the first response for a key is kept for
+// idempotencyWindow and replayed for every retry with the same key.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentReplayedHeader is set on replayed responses.
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
+const (
+	idempotencyWindow     = 24 * time.Hour
+	maxIdempotencyKeyLen  = 255
+	maxIdempotencyEntries = 10000
+)
+
+// idempotencyCache holds the responses of requests made with an
+// Idempotency-Key, keyed by token, path and key.
+type idempotencyCache struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotentResponse
+	lastSweep time.Time
+}
+
+// idempotentResponse is a request's response, or a placeholder while the
+// request is still being handled (done is false).
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte // of the request body
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: make(map[string]*idempotentResponse)}
+}
+
+// sweep drops expired entries and, past maxIdempotencyEntries, the ones that
+// expire soonest. Runs at most once a minute unless the cache is full.
+func (c *idempotencyCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < time.Minute && len(c.entries) < maxIdempotencyEntries {
+		return
+	}
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if e.done && now.After(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+	for len(c.entries) >= maxIdempotencyEntries {
+		oldest := ""
+		for key, e := range c.entries {
+			if e.done && (oldest == "" || e.expires.Before(c.entries[oldest].expires)) {
+				oldest = key
+			}
+		}
+		if oldest == "" {
+			return // all in flight
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+// idempotent makes next honor the Idempotency-Key header. A retry with the
+// same key and body gets the stored response; the same key with a different
+// body is rejected with 422, and a retry while the first request is still
+// running with 409. Server errors are not stored, so they can be retried.
+// Requests without the header are passed through.
+func (s *HTTPServer) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			respondError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		// The body is bounded by limitMiddleware
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256(body)
+
+		token := ""
+		if info := requestInfoFrom(r.Context()); info != nil {
+			token = info.token
+		}
+		cacheKey := token + "\x00" + r.URL.Path + "\x00" + key
+
+		c := s.idempotency
+		now := time.Now()
+		c.mu.Lock()
+		c.sweep(now)
+		e, ok := c.entries[cacheKey]
+		if ok && e.done && now.After(e.expires) {
+			ok = false
+		}
+		switch {
+		case ok && e.fingerprint != fingerprint:
+			c.mu.Unlock()
+			respondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body")
+			return
+		case ok && !e.done:
+			c.mu.Unlock()
+			respondError(w, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+			return
+		case ok:
+			c.mu.Unlock()
+			w.Header().Set(idempotentReplayedHeader, "true")
+			if e.contentType != "" {
+				w.Header().Set("Content-Type", e.contentType)
+			}
+			w.WriteHeader(e.status)
+			w.Write(e.body)
+			return
+		}
+		e = &idempotentResponse{fingerprint: fingerprint}
+		c.entries[cacheKey] = e
+		c.mu.Unlock()
+
+		rec := &recordingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		handled := false
+		defer func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if !handled || rec.status >= 500 {
+				delete(c.entries, cacheKey)
+				return
+			}
+			e.done = true
+			e.status = rec.status
+			e.contentType = rec.Header().Get("Content-Type")
+			e.body = rec.body.Bytes()
+			e.expires = time.Now().Add(idempotencyWindow)
+		}()
+		next(rec, r)
+		handled = true
+	}
+}
+
+// recordingResponseWriter passes a response through and keeps a copy.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
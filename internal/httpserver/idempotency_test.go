@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"codes/internal/agent"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token", "other-token"}, "test")
+	teamName := uniqueTeamName("idem")
+	defer agent.DeleteTeam(teamName)
+
+	post := func(path, token, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	// Retrying a team creation replays the 201 instead of failing with 409
+	teamBody := `{"name":"` + teamName + `"}`
+	first := post("/teams", "test-token", "team-1", teamBody)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("create team: %d %s", first.Code, first.Body.String())
+	}
+	retry := post("/teams", "test-token", "team-1", teamBody)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry: %d %s, want the first response", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(idempotentReplayedHeader) != "true" || first.Header().Get(idempotentReplayedHeader) != "" {
+		t.Errorf("Idempotent-Replayed: first %q, retry %q", first.Header().Get(idempotentReplayedHeader), retry.Header().Get(idempotentReplayedHeader))
+	}
+	if w := post("/teams", "test-token", "team-1", `{"name":"other"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("same key, other body: %d, want 422", w.Code)
+	}
+	// Without a key, or with another token's key space, the request runs
+	if w := post("/teams", "test-token", "", teamBody); w.Code != http.StatusConflict {
+		t.Errorf("no key: %d, want 409 from the duplicate team", w.Code)
+	}
+	if w := post("/teams", "other-token", "team-1", teamBody); w.Code != http.StatusConflict {
+		t.Errorf("other token: %d, want 409 from the duplicate team", w.Code)
+	}
+
+	// Tasks are created once per key
+	tasksPath := "/teams/" + teamName + "/tasks"
+	for range 3 {
+		if w := post(tasksPath, "test-token", "task-1", `{"subject":"once"}`); w.Code != http.StatusCreated {
+			t.Fatalf("create task: %d %s", w.Code, w.Body.String())
+		}
+	}
+	post(tasksPath, "test-token", "task-2", `{"subject":"twice"}`)
+	if tasks, _ := agent.ListTasks(teamName, "", ""); len(tasks) != 2 {
+		t.Errorf("got %d tasks, want 2", len(tasks))
+	}
+
+	if w := post(tasksPath, "test-token", strings.Repeat("k", maxIdempotencyKeyLen+1), `{"subject":"x"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("long key: %d, want 400", w.Code)
+	}
+}
+
+func TestIdempotentHandler(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+
+	var calls atomic.Int32
+	status := http.StatusInternalServerError
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := server.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		respondJSON(w, status, map[string]int{"call": int(calls.Load())})
+	})
+	call := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		req.Header.Set(idempotencyKeyHeader, "k")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// Server errors are not kept, so the retry runs again
+	call("/fail")
+	status = http.StatusCreated
+	if w := call("/fail"); w.Code != http.StatusCreated || calls.Load() != 2 {
+		t.Errorf("retry after 500: %d after %d calls", w.Code, calls.Load())
+	}
+
+	// A retry while the first request runs is turned away
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- call("/slow") }()
+	<-started
+	if w := call("/slow"); w.Code != http.StatusConflict {
+		t.Errorf("concurrent retry: %d, want 409", w.Code)
+	}
+	close(release)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Errorf("slow request: %d", w.Code)
+	}
+	if w := call("/slow"); w.Header().Get(idempotentReplayedHeader) != "true" || calls.Load() != 3 {
+		t.Errorf("retry after completion: replayed %q after %d calls", w.Header().Get(idempotentReplayedHeader), calls.Load())
+	}
+}
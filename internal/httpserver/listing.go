@@ -0,0 +1,174 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// List endpoints (GET /teams, /sessions, /teams/{name}/tasks and
+// /teams/{name}/messages) share these query parameters:
+//
+//	limit, offset  return one page; the response's total counts every match
+//	               and next_offset is set while more remain
+//	sort           one of the endpoint's sort keys, "-" prefix for descending;
+//	               without it the endpoint's usual order is kept
+//	fields         comma-separated JSON field names to keep in each item
+
+const maxListLimit = 1000
+
+// listParams holds the parsed list query parameters.
+type listParams struct {
+	Limit  int // 0 = no limit
+	Offset int
+	Sort   string
+	Desc   bool
+	Fields []string
+}
+
+// sortKeys maps the sort names an endpoint accepts to comparison funcs.
+type sortKeys[T any] map[string]func(a, b T) int
+
+// parseListParams reads the list parameters from q. defaultLimit applies
+// when no limit is given (0 = no limit).
+func parseListParams[T any](q url.Values, keys sortKeys[T], defaultLimit int) (listParams, error) {
+	p := listParams{Limit: defaultLimit}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			return p, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		p.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("offset must be a non-negative integer")
+		}
+		p.Offset = n
+	}
+	if v := q.Get("sort"); v != "" {
+		p.Sort, p.Desc = strings.TrimPrefix(v, "-"), strings.HasPrefix(v, "-")
+		if _, ok := keys[p.Sort]; !ok {
+			return p, fmt.Errorf("cannot sort by %q (valid: %s)", p.Sort, strings.Join(slices.Sorted(maps.Keys(keys)), ", "))
+		}
+	}
+	if v := q.Get("fields"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				p.Fields = append(p.Fields, f)
+			}
+		}
+	}
+	return p, nil
+}
+
+// sortAndPage sorts items by p.Sort (keeping their order without one) and
+// returns the requested page, the total count and the offset of the next
+// page (0 when this is the last).
+func sortAndPage[T any](items []T, p listParams, keys sortKeys[T]) (page []T, total, next int) {
+	if cmp, ok := keys[p.Sort]; ok {
+		slices.SortStableFunc(items, func(a, b T) int {
+			if p.Desc {
+				return cmp(b, a)
+			}
+			return cmp(a, b)
+		})
+	}
+	total = len(items)
+	start := min(p.Offset, total)
+	end := total
+	if p.Limit > 0 {
+		end = min(start+p.Limit, total)
+	}
+	if end < total {
+		next = end
+	}
+	return items[start:end], total, next
+}
+
+// respondList writes a list response. With fields set, every item of the
+// response's list is cut down to those JSON fields.
+func respondList(w http.ResponseWriter, resp any, fields []string) {
+	if len(fields) == 0 {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	body, err := selectFields(resp, fields)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, body)
+}
+
+// selectFields re-encodes a list response struct, keeping only fields in the
+// items of its slice field.
+func selectFields(resp any, fields []string) (map[string]json.RawMessage, error) {
+	rt := reflect.TypeOf(resp)
+	listKey, allowed := "", map[string]bool{}
+	for i := 0; i < rt.NumField(); i++ {
+		if f := rt.Field(i); f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct {
+			listKey = jsonName(f)
+			for _, name := range jsonFieldNames(f.Type.Elem()) {
+				allowed[name] = true
+			}
+		}
+	}
+	for _, f := range fields {
+		if !allowed[f] {
+			return nil, fmt.Errorf("unknown field %q (valid: %s)", f, strings.Join(slices.Sorted(maps.Keys(allowed)), ", "))
+		}
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var env map[string]json.RawMessage
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(env[listKey], &items); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		for k := range item {
+			if !slices.Contains(fields, k) {
+				delete(item, k)
+			}
+		}
+	}
+	if env[listKey], err = json.Marshal(items); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
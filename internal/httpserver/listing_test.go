@@ -0,0 +1,188 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"codes/internal/agent"
+)
+
+func listTasks(t *testing.T, server *HTTPServer, teamName, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/teams/"+teamName+"/tasks"+query, nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestListTeamTasksPaging tests limit, offset, total and next_offset.
+func TestListTeamTasksPaging(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("taskpage")
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	for _, subject := range []string{"a", "b", "c", "d", "e"} {
+		agent.CreateTask(teamName, subject, "", "", nil, agent.PriorityNormal, "", "")
+	}
+
+	tests := []struct {
+		query      string
+		wantIDs    []int
+		wantNext   int
+		wantStatus int
+	}{
+		{"?limit=2", []int{1, 2}, 2, http.StatusOK},
+		{"?limit=2&offset=2", []int{3, 4}, 4, http.StatusOK},
+		{"?limit=2&offset=4", []int{5}, 0, http.StatusOK},
+		{"?offset=10", []int{}, 0, http.StatusOK},
+		{"?sort=-id&limit=3", []int{5, 4, 3}, 3, http.StatusOK},
+		{"?limit=0", nil, 0, http.StatusBadRequest},
+		{"?limit=5000", nil, 0, http.StatusBadRequest},
+		{"?offset=-1", nil, 0, http.StatusBadRequest},
+		{"?sort=subject", nil, 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := listTasks(t, server, teamName, tt.query)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.query, w.Code, tt.wantStatus, w.Body.String())
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var resp TaskListResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", tt.query, err)
+		}
+		if resp.Total != 5 {
+			t.Errorf("%s: total = %d, want 5", tt.query, resp.Total)
+		}
+		if resp.NextOffset != tt.wantNext {
+			t.Errorf("%s: next_offset = %d, want %d", tt.query, resp.NextOffset, tt.wantNext)
+		}
+		var ids []int
+		for _, task := range resp.Tasks {
+			ids = append(ids, task.ID)
+		}
+		if len(ids) != len(tt.wantIDs) {
+			t.Errorf("%s: ids = %v, want %v", tt.query, ids, tt.wantIDs)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.wantIDs[i] {
+				t.Errorf("%s: ids = %v, want %v", tt.query, ids, tt.wantIDs)
+				break
+			}
+		}
+	}
+}
+
+// TestListTeamTasksSortPriority tests that sort=priority ranks by urgency,
+// not alphabetically.
+func TestListTeamTasksSortPriority(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("tasksort")
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	agent.CreateTask(teamName, "low", "", "", nil, agent.PriorityLow, "", "")
+	agent.CreateTask(teamName, "high", "", "", nil, agent.PriorityHigh, "", "")
+	agent.CreateTask(teamName, "normal", "", "", nil, agent.PriorityNormal, "", "")
+
+	for query, want := range map[string][]string{
+		"?sort=priority":  {"high", "normal", "low"},
+		"?sort=-priority": {"low", "normal", "high"},
+	} {
+		w := listTasks(t, server, teamName, query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d (%s)", query, w.Code, w.Body.String())
+		}
+		var resp TaskListResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", query, err)
+		}
+		for i, task := range resp.Tasks {
+			if task.Subject != want[i] {
+				t.Errorf("%s: task %d = %q, want %q", query, i, task.Subject, want[i])
+			}
+		}
+	}
+}
+
+// TestListTeamTasksFields tests that fields cuts each item down and rejects
+// unknown names.
+func TestListTeamTasksFields(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("taskfields")
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	agent.CreateTask(teamName, "Task 1", "desc", "", nil, agent.PriorityNormal, "", "")
+
+	w := listTasks(t, server, teamName, "?fields=id,status")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Tasks []map[string]any `json:"tasks"`
+		Total int              `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Tasks) != 1 {
+		t.Fatalf("got %d tasks of %d, want 1", len(resp.Tasks), resp.Total)
+	}
+	if len(resp.Tasks[0]) != 2 || resp.Tasks[0]["id"] == nil || resp.Tasks[0]["status"] == nil {
+		t.Errorf("task = %v, want only id and status", resp.Tasks[0])
+	}
+
+	if w := listTasks(t, server, teamName, "?fields=id,bogus"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown field: status = %d, want 400", w.Code)
+	}
+}
+
+// TestListTeamMessagesSort tests sorting and the default limit of the team
+// message view.
+func TestListTeamMessagesSort(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	teamName := uniqueTeamName("msgsort")
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	for _, content := range []string{"first", "second", "third"} {
+		if _, err := agent.SendMessage(teamName, "lead", "worker", content); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/"+teamName+"/messages?sort=created_at&limit=2", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", w.Code, w.Body.String())
+	}
+	var resp MessageListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Total != 3 || resp.NextOffset != 2 || len(resp.Messages) != 2 {
+		t.Fatalf("got %d messages, total %d, next %d; want 2, 3, 2", len(resp.Messages), resp.Total, resp.NextOffset)
+	}
+	if resp.Messages[0].Content != "first" || resp.Messages[1].Content != "second" {
+		t.Errorf("messages = %q, %q; want first, second", resp.Messages[0].Content, resp.Messages[1].Content)
+	}
+}
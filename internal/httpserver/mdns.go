@@ -10,13 +10,14 @@ import (
 )
 
 // startMDNS registers a Bonjour/mDNS service so iOS clients can discover
-// this HTTP server on the local network via _codes._tcp.
+// this HTTP server on the local network via _codes._tcp. The TXT record's
+// scheme is "https" when the server uses TLS.
 //
 // Instead of using a Go mDNS library (which conflicts with macOS
 // mDNSResponder on UDP 5353), we delegate to the system's dns-sd command
 // (macOS) or avahi-publish-service (Linux).
 // Returns a shutdown function that kills the registration process.
-func startMDNS(port int, version string) func() {
+func startMDNS(port int, version, scheme string) func() {
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "codes-server"
@@ -24,25 +25,26 @@ func startMDNS(port int, version string) func() {
 
 	// macOS: dns-sd -R delegates to mDNSResponder (no port conflicts)
 	if path, err := exec.LookPath("dns-sd"); err == nil {
-		return registerViaDNSSD(path, hostname, port, version)
+		return registerViaDNSSD(path, hostname, port, version, scheme)
 	}
 
 	// Linux: avahi-publish-service
 	if path, err := exec.LookPath("avahi-publish-service"); err == nil {
-		return registerViaAvahi(path, hostname, port, version)
+		return registerViaAvahi(path, hostname, port, version, scheme)
 	}
 
 	log.Printf("[mDNS] No dns-sd or avahi-publish-service found; skipping mDNS registration")
 	return func() {}
 }
 
-func registerViaDNSSD(path, hostname string, port int, version string) func() {
+func registerViaDNSSD(path, hostname string, port int, version, scheme string) func() {
 	cmd := exec.Command(path, "-R", hostname,
 		"_codes._tcp", "local",
 		strconv.Itoa(port),
 		fmt.Sprintf("port=%d", port),
 		fmt.Sprintf("version=%s", version),
 		fmt.Sprintf("host=%s.local", hostname),
+		"scheme="+scheme,
 	)
 	if err := cmd.Start(); err != nil {
 		log.Printf("[mDNS] Failed to start dns-sd: %v", err)
@@ -52,13 +54,14 @@ func registerViaDNSSD(path, hostname string, port int, version string) func() {
 	return killProcess(cmd)
 }
 
-func registerViaAvahi(path, hostname string, port int, version string) func() {
+func registerViaAvahi(path, hostname string, port int, version, scheme string) func() {
 	cmd := exec.Command(path, hostname,
 		"_codes._tcp",
 		strconv.Itoa(port),
 		fmt.Sprintf("port=%d", port),
 		fmt.Sprintf("version=%s", version),
 		fmt.Sprintf("host=%s.local", hostname),
+		"scheme="+scheme,
 	)
 	if err := cmd.Start(); err != nil {
 		log.Printf("[mDNS] Failed to start avahi-publish: %v", err)
@@ -0,0 +1,219 @@
+package httpserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"codes/internal/chatsession"
+)
+
+// httpDurationBuckets are the histogram upper bounds (seconds) for request
+// latency.
+var httpDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey identifies one series of the request metrics. Route is the
+// registered pattern (e.g. /teams/), not the request path, so the number of
+// series stays bounded.
+type requestKey struct {
+	route  string
+	method string
+	status int
+}
+
+type requestStats struct {
+	count   int64
+	sum     float64 // seconds
+	buckets []int64 // cumulative counts per httpDurationBuckets
+}
+
+// httpMetrics counts requests served by this process. Requests turned away
+// before routing (rate limits, version skew) are not counted.
+type httpMetrics struct {
+	mu       sync.Mutex
+	requests map[requestKey]*requestStats
+}
+
+func newHTTPMetrics() *httpMetrics {
+	return &httpMetrics{requests: make(map[requestKey]*requestStats)}
+}
+
+// observe records a request. Upgraded (WebSocket) requests are counted but
+// not timed, since their duration is that of the connection.
+func (m *httpMetrics) observe(key requestKey, d time.Duration, timed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.requests[key]
+	if !ok {
+		st = &requestStats{buckets: make([]int64, len(httpDurationBuckets))}
+		m.requests[key] = st
+	}
+	st.count++
+	if !timed {
+		return
+	}
+	secs := d.Seconds()
+	st.sum += secs
+	for i, le := range httpDurationBuckets {
+		if secs <= le {
+			st.buckets[i]++
+		}
+	}
+}
+
+// instrument wraps the handler registered for route.
+func (m *httpMetrics) instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mw := &metricsResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(mw, r)
+		status := mw.statusCode
+		if mw.hijacked {
+			status = http.StatusSwitchingProtocols
+		}
+		m.observe(requestKey{route: route, method: r.Method, status: status}, time.Since(start), !mw.hijacked)
+	}
+}
+
+// metricsResponseWriter captures the status code and whether the connection
+// was taken over for a WebSocket.
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	hijacked   bool
+}
+
+func (mw *metricsResponseWriter) WriteHeader(code int) {
+	mw.statusCode = code
+	mw.ResponseWriter.WriteHeader(code)
+}
+
+func (mw *metricsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := mw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not implement http.Hijacker")
+	}
+	mw.hijacked = true
+	return hj.Hijack()
+}
+
+// write writes the request metrics in the Prometheus text format.
+func (m *httpMetrics) write(b *strings.Builder) {
+	m.mu.Lock()
+	keys := make([]requestKey, 0, len(m.requests))
+	stats := make(map[requestKey]requestStats, len(m.requests))
+	for k, st := range m.requests {
+		keys = append(keys, k)
+		stats[k] = requestStats{count: st.count, sum: st.sum, buckets: append([]int64(nil), st.buckets...)}
+	}
+	m.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	writeMetricHeader(b, "codes_http_requests_total", "counter", "HTTP requests by route, method and status.")
+	for _, k := range keys {
+		fmt.Fprintf(b, "codes_http_requests_total{route=%q,method=%q,status=\"%d\"} %d\n", k.route, k.method, k.status, stats[k].count)
+	}
+
+	writeMetricHeader(b, "codes_http_request_duration_seconds", "histogram", "HTTP request latency by route and method (WebSocket connections excluded).")
+	type series struct{ route, method string }
+	merged := make(map[series]*requestStats)
+	var order []series
+	for _, k := range keys {
+		if k.status == http.StatusSwitchingProtocols {
+			continue
+		}
+		sk := series{k.route, k.method}
+		st := merged[sk]
+		if st == nil {
+			st = &requestStats{buckets: make([]int64, len(httpDurationBuckets))}
+			merged[sk] = st
+			order = append(order, sk)
+		}
+		src := stats[k]
+		st.count += src.count
+		st.sum += src.sum
+		for i := range st.buckets {
+			st.buckets[i] += src.buckets[i]
+		}
+	}
+	for _, sk := range order {
+		st := merged[sk]
+		for i, le := range httpDurationBuckets {
+			fmt.Fprintf(b, "codes_http_request_duration_seconds_bucket{route=%q,method=%q,le=\"%g\"} %d\n", sk.route, sk.method, le, st.buckets[i])
+		}
+		fmt.Fprintf(b, "codes_http_request_duration_seconds_bucket{route=%q,method=%q,le=\"+Inf\"} %d\n", sk.route, sk.method, st.count)
+		fmt.Fprintf(b, "codes_http_request_duration_seconds_sum{route=%q,method=%q} %g\n", sk.route, sk.method, st.sum)
+		fmt.Fprintf(b, "codes_http_request_duration_seconds_count{route=%q,method=%q} %d\n", sk.route, sk.method, st.count)
+	}
+}
+
+// chatSessionStatuses is the fixed reporting order for the chat session gauge.
+var chatSessionStatuses = []chatsession.SessionStatus{
+	chatsession.StatusCreating, chatsession.StatusReady, chatsession.StatusBusy, chatsession.StatusClosed,
+}
+
+// writeChatSessionMetrics writes the chat session and WebSocket client gauges.
+func writeChatSessionMetrics(b *strings.Builder, sessions []*chatsession.ChatSession) {
+	byStatus := make(map[chatsession.SessionStatus]int)
+	clients := 0
+	for _, s := range sessions {
+		info := s.Snapshot()
+		byStatus[info.Status]++
+		clients += info.ClientCount
+	}
+
+	writeMetricHeader(b, "codes_chat_sessions", "gauge", "Number of chat sessions by status.")
+	for _, st := range chatSessionStatuses {
+		fmt.Fprintf(b, "codes_chat_sessions{status=%q} %d\n", st, byStatus[st])
+	}
+
+	writeMetricHeader(b, "codes_websocket_clients", "gauge", "Number of WebSocket clients connected to chat sessions.")
+	fmt.Fprintf(b, "codes_websocket_clients %d\n", clients)
+}
+
+func writeMetricHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// SetMetricsPublic serves /metrics without a token, for Prometheus scrapers
+// on a trusted network. Every other endpoint still requires one.
+func (s *HTTPServer) SetMetricsPublic(public bool) {
+	s.metricsPublic = public
+}
+
+// metricsAuthMiddleware requires a token for /metrics unless
+// SetMetricsPublic turned that off.
+func (s *HTTPServer) metricsAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	authed := s.authMiddleware(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.metricsPublic {
+			next(w, r)
+			return
+		}
+		authed(w, r)
+	}
+}
+
+// writeServerMetrics writes the metrics kept by this process.
+func (s *HTTPServer) writeServerMetrics(w io.Writer) error {
+	var b strings.Builder
+	s.metrics.write(&b)
+	writeChatSessionMetrics(&b, chatsession.DefaultManager.List())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"bufio"
+	"context"
 	"crypto/subtle"
 	"encoding/json"
 	"fmt"
@@ -10,13 +11,39 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"codes/internal/update"
 )
 
+// websocketProtocolToken returns the token a browser sent as a
+// "bearer.<token>" WebSocket subprotocol, since it cannot set the
+// Authorization header on a WebSocket. The server only ever agrees to the
+// "codes" subprotocol, so the token is not echoed back.
+func websocketProtocolToken(r *http.Request) string {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return ""
+	}
+	for _, proto := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		if token, ok := strings.CutPrefix(strings.TrimSpace(proto), "bearer."); ok {
+			return token
+		}
+	}
+	return ""
+}
+
 // authMiddleware validates Bearer token authentication
 func (s *HTTPServer) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract Authorization header
 		authHeader := r.Header.Get("Authorization")
+		if token := websocketProtocolToken(r); authHeader == "" && token != "" {
+			authHeader = "Bearer " + token
+		}
+		// Share links are opened in a browser, so they may come in the URL;
+		// API tokens may not
+		if share := r.URL.Query().Get("share"); authHeader == "" && strings.HasPrefix(share, shareTokenPrefix) {
+			authHeader = "Bearer " + share
+		}
 		if authHeader == "" {
 			respondError(w, http.StatusUnauthorized, "missing Authorization header")
 			return
@@ -32,24 +59,83 @@ func (s *HTTPServer) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		token := parts[1]
 
 		// Validate token against configured tokens (constant-time comparison)
-		valid := false
-		for _, validToken := range s.tokens {
-			if subtle.ConstantTimeCompare([]byte(token), []byte(validToken)) == 1 {
-				valid = true
-				break
+		g := s.lookupToken(token)
+		if g == nil && strings.HasPrefix(token, shareTokenPrefix) {
+			var err error
+			if g, err = s.shareGrant(token); err != nil {
+				respondError(w, http.StatusUnauthorized, err.Error())
+				return
 			}
 		}
-
-		if !valid {
+		if g == nil {
 			respondError(w, http.StatusUnauthorized, "invalid token")
 			return
 		}
 
+		if info := requestInfoFrom(r.Context()); info != nil {
+			info.token = tokenIdentity(token, g)
+		}
+
+		if !s.allowToken(w, token) {
+			return
+		}
+
+		if s.rejectReadOnly(w, r) {
+			return
+		}
+
+		// Check the token's scopes and team restriction (see scope.go)
+		if msg := g.authorize(r); msg != "" {
+			respondError(w, http.StatusForbidden, msg)
+			return
+		}
+
 		// Token valid, proceed to next handler
+		next(w, r.WithContext(context.WithValue(r.Context(), grantKey{}, g)))
+	}
+}
+
+// adminMiddleware restricts an endpoint to tokens with the admin scope. It
+// must run after authMiddleware, which has already checked the header.
+func (s *HTTPServer) adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !grantFrom(r.Context()).has(ScopeAdmin) {
+			respondError(w, http.StatusForbidden, "token lacks the admin scope (add it to httpAdminTokens)")
+			return
+		}
 		next(w, r)
 	}
 }
 
+// tokenIn reports whether token is one of tokens. Every entry is compared in
+// constant time.
+func tokenIn(token string, tokens []string) bool {
+	valid := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// versionMiddleware advertises the server version on every response and
+// rejects clients built from an incompatible major version. Clients that
+// don't send a version (iOS app, curl) are always let through, as is /health
+// so any client can learn the server version.
+func (s *HTTPServer) versionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(update.VersionHeader, s.version)
+		clientVersion := r.Header.Get(update.VersionHeader)
+		if r.URL.Path != "/health" && update.VersionSkew(s.version, clientVersion) == update.SkewMajor {
+			respondError(w, http.StatusUpgradeRequired, fmt.Sprintf(
+				"client codes %s is incompatible with server codes %s; upgrade the older one", clientVersion, s.version))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // jsonContentTypeMiddleware ensures request has JSON Content-Type for POST requests
 func jsonContentTypeMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -64,7 +150,8 @@ func jsonContentTypeMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// loggingMiddleware logs incoming requests
+// loggingMiddleware writes the access log line for a request (see
+// requestid.go), with the token that made it once authMiddleware knows it.
 func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -74,8 +161,19 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		next(lrw, r)
 
-		duration := time.Since(start)
-		log.Printf("[HTTP] %s %s - %d (%v)", r.Method, r.URL.Path, lrw.statusCode, duration)
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", lrw.statusCode,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if info := requestInfoFrom(r.Context()); info != nil {
+			attrs = append(attrs, "request_id", info.id)
+			if info.token != "" {
+				attrs = append(attrs, "token", info.token)
+			}
+		}
+		accessLog.Info("http request", attrs...)
 	}
 }
 
@@ -108,7 +206,8 @@ func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	}
 }
 
-// respondError sends an error response
+// respondError sends an error response, with the request ID set by
+// requestIDMiddleware so clients can quote it.
 func respondError(w http.ResponseWriter, statusCode int, message string) {
-	respondJSON(w, statusCode, ErrorResponse{Error: message})
+	respondJSON(w, statusCode, ErrorResponse{Error: message, RequestID: w.Header().Get(requestIDHeader)})
 }
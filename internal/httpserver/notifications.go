@@ -0,0 +1,214 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codes/internal/agent"
+)
+
+// GET /notifications is a long poll over the task notifications agent
+// daemons write to ~/.codes/notifications, the files the MCP monitor reads.
+// Scripts and CI jobs wait for tasks to finish with plain HTTP requests,
+// passing back next_sequence each time.
+
+const (
+	defaultNotificationWait = 30 * time.Second
+	maxNotificationWait     = 5 * time.Minute
+
+	// maxNotificationFeed is how many recent notifications can be replayed.
+	maxNotificationFeed = 500
+)
+
+// notificationScanInterval is how often waiting requests look for new
+// notification files; tests shorten it.
+var notificationScanInterval = time.Second
+
+// notificationFeed numbers the notification files in the order they are
+// found, so clients can resume from a sequence number. Files are never
+// removed here: the MCP monitor and agent.PruneNotifications clean up.
+type notificationFeed struct {
+	mu       sync.Mutex
+	dir      string               // overrides agent.NotificationsDir in tests
+	seen     map[string]time.Time // file name -> modification time when queued
+	events   []Notification       // the last maxNotificationFeed, in sequence order
+	seq      int64
+	lastScan time.Time
+}
+
+func newNotificationFeed() *notificationFeed {
+	return &notificationFeed{seen: make(map[string]time.Time)}
+}
+
+// notificationFile mirrors the notification written by the agent daemon.
+type notificationFile struct {
+	Team      string             `json:"team"`
+	TaskID    int                `json:"taskId"`
+	Subject   string             `json:"subject"`
+	Status    string             `json:"status"`
+	Agent     string             `json:"agent"`
+	Result    string             `json:"result,omitempty"`
+	Summary   *agent.TaskSummary `json:"summary,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// scan queues the files that are new or rewritten since the last scan, oldest
+// first. Scans closer together than notificationScanInterval do nothing, so
+// many waiting requests cost one directory read. Caller must hold f.mu.
+func (f *notificationFeed) scanLocked(now time.Time) {
+	if now.Sub(f.lastScan) < notificationScanInterval {
+		return
+	}
+	f.lastScan = now
+
+	dir := f.dir
+	if dir == "" {
+		var err error
+		if dir, err = agent.NotificationsDir(); err != nil {
+			return
+		}
+	}
+	// The directory does not exist until the first notification
+	entries, _ := os.ReadDir(dir)
+
+	type found struct {
+		name    string
+		modTime time.Time
+	}
+	var files []found
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		present[e.Name()] = true
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if queued, ok := f.seen[e.Name()]; ok && queued.Equal(info.ModTime()) {
+			continue
+		}
+		files = append(files, found{e.Name(), info.ModTime()})
+	}
+	for name := range f.seen {
+		if !present[name] {
+			delete(f.seen, name)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.name))
+		if err != nil {
+			continue
+		}
+		var n notificationFile
+		if err := json.Unmarshal(data, &n); err != nil {
+			continue // the daemon may still be writing it; retried next scan
+		}
+		f.seen[file.name] = file.modTime
+		f.seq++
+		f.events = append(f.events, Notification{
+			Sequence:  f.seq,
+			Team:      n.Team,
+			TaskID:    n.TaskID,
+			Subject:   n.Subject,
+			Status:    n.Status,
+			Agent:     n.Agent,
+			Result:    n.Result,
+			Summary:   summaryToResponse(n.Summary),
+			Error:     n.Error,
+			Timestamp: n.Timestamp,
+		})
+	}
+	if len(f.events) > maxNotificationFeed {
+		f.events = f.events[len(f.events)-maxNotificationFeed:]
+	}
+}
+
+// handleNotifications handles GET /notifications. It answers as soon as a
+// notification after ?since= matches the team, agent and status filters, or
+// with timed_out after ?timeout= (a duration or seconds; 0 answers at once).
+// Tokens limited to some teams only see those teams' notifications.
+func (s *HTTPServer) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	var since int64
+	if v := q.Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "since must be a sequence number from next_sequence")
+			return
+		}
+		since = n
+	}
+	wait := defaultNotificationWait
+	if v := q.Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			secs, convErr := strconv.Atoi(v)
+			if convErr != nil {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid timeout %q: use a duration like 30s or a number of seconds", v))
+				return
+			}
+			d = time.Duration(secs) * time.Second
+		}
+		if d < 0 {
+			respondError(w, http.StatusBadRequest, "timeout must not be negative")
+			return
+		}
+		wait = min(d, maxNotificationWait)
+	}
+	team, agentName, status := q.Get("team"), q.Get("agent"), q.Get("status")
+	g := grantFrom(r.Context())
+	match := func(n Notification) bool {
+		return g.allowsTeam(n.Team) &&
+			(team == "" || n.Team == team) &&
+			(agentName == "" || n.Agent == agentName) &&
+			(status == "" || n.Status == status)
+	}
+
+	deadline := time.Now().Add(wait)
+	f := s.notifications
+	for {
+		f.mu.Lock()
+		f.scanLocked(time.Now())
+		if since > f.seq {
+			// Sequences restart with the server; a cursor from before the
+			// restart would otherwise skip every new notification
+			since = 0
+		}
+		resp := NotificationsResponse{Notifications: []Notification{}, NextSequence: f.seq}
+		for _, n := range f.events {
+			if n.Sequence > since && match(n) {
+				resp.Notifications = append(resp.Notifications, n)
+			}
+		}
+		f.mu.Unlock()
+
+		if len(resp.Notifications) > 0 || !time.Now().Before(deadline) {
+			resp.TimedOut = len(resp.Notifications) == 0
+			respondJSON(w, http.StatusOK, resp)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(min(notificationScanInterval, time.Until(deadline))):
+		}
+	}
+}
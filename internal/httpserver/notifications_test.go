@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codes/internal/config"
+)
+
+func TestNotificationsLongPoll(t *testing.T) {
+	orig := notificationScanInterval
+	notificationScanInterval = 10 * time.Millisecond
+	defer func() { notificationScanInterval = orig }()
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetScopedTokens([]config.HTTPToken{{Name: "ci", Token: "ci-token", Scopes: []string{ScopeRead}, Teams: []string{"alpha"}}})
+	dir := t.TempDir()
+	server.notifications.dir = dir
+
+	write := func(team string, id int, status string) {
+		data, _ := json.Marshal(map[string]any{
+			"team": team, "taskId": id, "subject": "s", "status": status, "agent": "worker",
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		})
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%s__%d.json", team, id)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	poll := func(token, query string) (int, NotificationsResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/notifications?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var resp NotificationsResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	write("alpha", 1, "completed")
+	write("beta", 1, "failed")
+	code, resp := poll("test-token", "timeout=0")
+	if code != http.StatusOK || len(resp.Notifications) != 2 || resp.NextSequence != 2 || resp.TimedOut {
+		t.Fatalf("buffered: %d %+v", code, resp)
+	}
+	if n := resp.Notifications[0]; n.Sequence != 1 || n.TaskID != 1 || n.Agent != "worker" || n.Timestamp.IsZero() {
+		t.Errorf("notification = %+v", n)
+	}
+
+	// Nothing new after the cursor: times out empty
+	start := time.Now()
+	_, resp = poll("test-token", "since=2&timeout=50ms")
+	if !resp.TimedOut || len(resp.Notifications) != 0 || resp.NextSequence != 2 || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("timeout: %+v after %v", resp, time.Since(start))
+	}
+
+	// A notification written while waiting ends the wait; filters apply
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		write("beta", 2, "completed")
+		time.Sleep(30 * time.Millisecond)
+		write("alpha", 2, "completed")
+	}()
+	_, resp = poll("test-token", "since=2&timeout=5s&team=alpha&status=completed")
+	if len(resp.Notifications) != 1 || resp.Notifications[0].Team != "alpha" || resp.Notifications[0].TaskID != 2 {
+		t.Fatalf("waited: %+v", resp)
+	}
+
+	// Tokens limited to a team only see that team
+	_, resp = poll("ci-token", "timeout=0")
+	for _, n := range resp.Notifications {
+		if n.Team != "alpha" {
+			t.Errorf("team-limited token saw %+v", n)
+		}
+	}
+	if len(resp.Notifications) != 2 {
+		t.Errorf("team-limited token got %d notifications, want 2", len(resp.Notifications))
+	}
+
+	// A cursor from before a restart starts over
+	if _, resp = poll("test-token", "since=999&timeout=0"); len(resp.Notifications) != 4 {
+		t.Errorf("stale cursor: got %d notifications, want 4", len(resp.Notifications))
+	}
+
+	for _, q := range []string{"since=x", "since=-1", "timeout=soon", "timeout=-1s"} {
+		if code, _ := poll("test-token", q); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", q, code)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"codes/internal/workflow"
+)
+
+// The OpenAPI document is generated from apiOperations and the request and
+// response types they name. TestOpenAPIMatchesRoutes sends a request for
+// every operation through the mux and fails when a documented route is not
+// served or a registered route is not documented, so add an entry here with
+// every new endpoint.
+
+// authLevel is what an operation requires in the Authorization header.
+type authLevel int
+
+const (
+	authToken  authLevel = iota // any configured token
+	authPublic                  // no token
+	authAdmin                   // a token with the admin scope
+)
+
+// apiParam is a query parameter of an operation.
+type apiParam struct {
+	Name        string
+	Type        string // JSON Schema type, default string
+	Description string
+}
+
+// apiOperation documents one method on one path.
+type apiOperation struct {
+	Method      string
+	Path        string // OpenAPI template, e.g. /teams/{name}
+	Tag         string
+	Summary     string
+	Request     any    // zero value of the JSON request body, nil if none
+	Response    any    // zero value of the JSON response body, nil if not JSON
+	Status      int    // success status (default 200)
+	ContentType string // response media type when Response is nil
+	Query       []apiParam
+	Auth        authLevel
+	Idempotent  bool // honors Idempotency-Key, see idempotency.go
+}
+
+var apiOperations = []apiOperation{
+	// General
+	{Method: "GET", Path: "/health", Tag: "general", Summary: "Health check and server version", Response: HealthResponse{}, Auth: authPublic},
+	{Method: "GET", Path: "/openapi.json", Tag: "general", Summary: "This OpenAPI document", ContentType: "application/json", Auth: authPublic},
+	{Method: "GET", Path: "/docs", Tag: "general", Summary: "Swagger UI for this API", ContentType: "text/html", Auth: authPublic},
+	{Method: "GET", Path: "/", Tag: "general", Summary: "Web dashboard: teams, agents, task kanban and chat sessions", ContentType: "text/html", Auth: authPublic},
+	{Method: "GET", Path: "/ui/{file}", Tag: "general", Summary: "Dashboard script or stylesheet, e.g. app.js", ContentType: "text/javascript", Auth: authPublic},
+	{Method: "GET", Path: "/schemas", Tag: "general", Summary: "List published payload schemas", Response: SchemaListResponse{}, Auth: authPublic},
+	{Method: "GET", Path: "/schemas/{file}", Tag: "general", Summary: "Get a payload schema, e.g. task-notification.v1.json", ContentType: "application/schema+json", Auth: authPublic},
+	{Method: "GET", Path: "/metrics", Tag: "general", Summary: "Prometheus metrics for teams, tasks, agent daemons, HTTP requests and chat sessions (no token with httpMetricsPublic)", ContentType: "text/plain"},
+	{Method: "GET", Path: "/audit", Tag: "general", Summary: "Audit log of state-changing API requests and MCP tool calls, newest first", Response: AuditResponse{}, Auth: authAdmin, Query: []apiParam{
+		{Name: "since", Description: "Only entries after this duration ago (24h) or RFC 3339 time"},
+		{Name: "source", Description: "http or mcp"},
+		{Name: "actor", Description: "Token name or hash, or MCP client name"},
+		{Name: "limit", Type: "integer", Description: "Number of entries (default 100, max 1000)"},
+	}},
+	{Method: "GET", Path: "/webhooks", Tag: "general", Summary: "List webhook delivery targets", Response: WebhookListResponse{}, Auth: authAdmin},
+	{Method: "POST", Path: "/webhooks", Tag: "general", Summary: "Add a webhook delivery target", Request: CreateWebhookRequest{}, Response: Webhook{}, Status: http.StatusCreated, Auth: authAdmin},
+	{Method: "DELETE", Path: "/webhooks/{id}", Tag: "general", Summary: "Remove a webhook by name, or path-escaped URL if unnamed", Response: StatusResponse{}, Auth: authAdmin},
+	{Method: "POST", Path: "/webhooks/{id}/test", Tag: "general", Summary: "Send a sample task_completed event to a webhook (502 if delivery fails)", Response: StatusResponse{}, Auth: authAdmin},
+	{Method: "GET", Path: "/remotes", Tag: "remotes", Summary: "List remote SSH hosts with their last known status", Response: RemoteListResponse{}, Auth: authAdmin},
+	{Method: "GET", Path: "/remotes/{name}/status", Tag: "remotes", Summary: "Check a remote host over SSH (502 if unreachable, 409 if its host key is not trusted)", Response: RemoteHostInfo{}, Auth: authAdmin},
+	{Method: "POST", Path: "/remotes/{name}/sync", Tag: "remotes", Summary: "Sync the local profiles to a remote host", Response: StatusResponse{}, Auth: authAdmin},
+	{Method: "POST", Path: "/remotes/{name}/setup", Tag: "remotes", Summary: "Install codes and the Claude CLI on a remote host and sync profiles (can take minutes)", Request: RemoteSetupRequest{}, Response: RemoteSetupResponse{}, Auth: authAdmin},
+	{Method: "GET", Path: "/remotes/{name}/proxy/{path}", Tag: "remotes", Summary: "Forward a request (any method) to the codes serve API on a remote host over an SSH tunnel, e.g. /remotes/gpu/proxy/teams", ContentType: "application/json", Auth: authAdmin},
+	{Method: "POST", Path: "/assistant", Tag: "general", Summary: "Send a message to the personal assistant", Request: AssistantRequest{}, Response: AssistantResponse{}},
+	{Method: "POST", Path: "/feishu/webhook", Tag: "general", Summary: "Inbound Feishu events (verified by the Feishu token, not a bearer token)", Request: FeishuEvent{}, Response: FeishuChallengeResponse{}, Auth: authPublic},
+
+	// Projects and profiles
+	{Method: "GET", Path: "/projects", Tag: "projects", Summary: "List projects", Response: ProjectListResponse{}},
+	{Method: "POST", Path: "/projects", Tag: "projects", Summary: "Register a directory, or clone a git URL, as a project", Request: CreateProjectRequest{}, Response: ProjectInfoResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/projects/{name}", Tag: "projects", Summary: "Get a project with git status", Response: ProjectInfoResponse{}},
+	{Method: "DELETE", Path: "/projects/{name}", Tag: "projects", Summary: "Unregister a project (its directory is kept)", Response: StatusResponse{}},
+	{Method: "GET", Path: "/profiles", Tag: "projects", Summary: "List API profiles (secret env values redacted)", Response: ProfileListResponse{}},
+	{Method: "POST", Path: "/profiles", Tag: "projects", Summary: "Add an API profile (profiles:write scope)", Request: CreateProfileRequest{}, Response: ProfileInfo{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/profiles/{name}", Tag: "projects", Summary: "Get an API profile (secret env values redacted)", Response: ProfileInfo{}},
+	{Method: "PATCH", Path: "/profiles/{name}", Tag: "projects", Summary: "Change an API profile's env or permissions (profiles:write scope)", Request: UpdateProfileRequest{}, Response: ProfileInfo{}},
+	{Method: "DELETE", Path: "/profiles/{name}", Tag: "projects", Summary: "Delete an API profile (profiles:write scope)", Response: StatusResponse{}},
+	{Method: "POST", Path: "/profiles/switch", Tag: "projects", Summary: "Switch the default API profile", Request: SwitchProfileRequest{}, Response: SwitchProfileResponse{}},
+
+	// Chat sessions
+	{Method: "GET", Path: "/sessions", Tag: "sessions", Summary: "List chat sessions", Response: SessionListResponse{}, Query: listQuery("created_at, last_active_at, status, project_name")},
+	{Method: "POST", Path: "/sessions", Tag: "sessions", Summary: "Create a chat session", Request: CreateSessionRequest{}, Response: SessionResponse{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: "GET", Path: "/sessions/{id}", Tag: "sessions", Summary: "Get a chat session", Response: SessionResponse{}},
+	{Method: "DELETE", Path: "/sessions/{id}", Tag: "sessions", Summary: "Stop and delete a chat session", Response: StatusResponse{}},
+	{Method: "GET", Path: "/sessions/{id}/ws", Tag: "sessions", Summary: "WebSocket stream of session events (SessionEvent messages)", Status: http.StatusSwitchingProtocols},
+	{Method: "POST", Path: "/sessions/{id}/message", Tag: "sessions", Summary: "Send a message to a chat session", Request: SessionSendMessageRequest{}, Response: SessionResponse{}},
+	{Method: "GET", Path: "/sessions/{id}/messages", Tag: "sessions", Summary: "Recorded events of a chat session, also after it closed or the server restarted", Response: SessionMessageListResponse{}, Query: listQuery("seq")},
+	{Method: "GET", Path: "/sessions/{id}/transcript", Tag: "sessions", Summary: "Chat session transcript as Markdown (download=true to save it as a file)", ContentType: "text/markdown"},
+	{Method: "POST", Path: "/sessions/{id}/share", Tag: "sessions", Summary: "Create an expiring read-only link to a chat session: its token (as Bearer or ?share=) only reads the session, its messages and transcript, and watches its WebSocket", Request: SessionShareRequest{}, Response: SessionShareResponse{}, Status: http.StatusCreated},
+	{Method: "POST", Path: "/sessions/{id}/interrupt", Tag: "sessions", Summary: "Interrupt a running chat session", Response: StatusResponse{}},
+	{Method: "POST", Path: "/sessions/{id}/resume", Tag: "sessions", Summary: "Resume an earlier Claude conversation", Request: ResumeSessionRequest{}, Response: SessionResponse{}},
+	{Method: "POST", Path: "/host/sessions", Tag: "sessions", Summary: "Open a Claude terminal session for a project on the server's machine", Request: StartHostSessionRequest{}, Response: HostSessionResponse{}, Status: http.StatusCreated, Auth: authAdmin},
+
+	// Teams, tasks and messages
+	{Method: "GET", Path: "/teams", Tag: "teams", Summary: "List teams", Response: TeamListResponse{}, Query: listQuery("name, created_at, member_count")},
+	{Method: "POST", Path: "/teams", Tag: "teams", Summary: "Create a team", Request: CreateTeamRequest{}, Response: TeamDetailResponse{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: "GET", Path: "/teams/{name}", Tag: "teams", Summary: "Get a team with member statuses", Response: TeamDetailResponse{}},
+	{Method: "DELETE", Path: "/teams/{name}", Tag: "teams", Summary: "Delete a team with its tasks and messages", Response: StatusResponse{}},
+	{Method: "POST", Path: "/teams/{name}/start", Tag: "teams", Summary: "Start every agent of a team", Response: StartTeamResponse{}},
+	{Method: "POST", Path: "/teams/{name}/stop", Tag: "teams", Summary: "Stop every agent of a team", Response: StopTeamResponse{}},
+	{Method: "GET", Path: "/teams/{name}/activity", Tag: "teams", Summary: "Team dashboard: member activity and task counts", Response: TeamActivityResponse{}},
+	{Method: "GET", Path: "/teams/{name}/agents/{agent}/logs", Tag: "teams", Summary: "Tail an agent daemon's log", Response: AgentLogsResponse{}, Query: []apiParam{
+		{Name: "lines", Type: "integer", Description: "Number of lines (default 100)"},
+		{Name: "grep", Description: "Only lines matching this regular expression"},
+	}},
+	{Method: "GET", Path: "/teams/{name}/tasks", Tag: "tasks", Summary: "List a team's tasks", Response: TaskListResponse{}, Query: append([]apiParam{
+		{Name: "status", Description: "Filter by status"},
+		{Name: "owner", Description: "Filter by owner agent"},
+	}, listQuery("id, created_at, updated_at, priority, status")...)},
+	{Method: "POST", Path: "/teams/{name}/tasks", Tag: "tasks", Summary: "Create a task", Request: CreateTaskRequest{}, Response: TaskResponse{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: "POST", Path: "/teams/{name}/tasks/batch", Tag: "tasks", Summary: "Create several tasks at once, with dependencies between them", Request: CreateTaskBatchRequest{}, Response: CreateTaskBatchResponse{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: "PATCH", Path: "/teams/{name}/tasks/{id}", Tag: "tasks", Summary: "Update, cancel, redirect, follow up on or answer a task", Request: UpdateTaskRequest{}, Response: TaskResponse{}},
+	{Method: "GET", Path: "/teams/{name}/questions", Tag: "tasks", Summary: "List human tasks waiting for an answer", Response: TaskListResponse{}},
+	{Method: "GET", Path: "/teams/{name}/tasks/{id}/artifacts", Tag: "tasks", Summary: "List files collected from a task", Response: ArtifactListResponse{}},
+	{Method: "GET", Path: "/teams/{name}/tasks/{id}/artifacts/{file}", Tag: "tasks", Summary: "Download a collected file", ContentType: "application/octet-stream"},
+	{Method: "GET", Path: "/tasks/{team}/{id}", Tag: "tasks", Summary: "Get a task", Response: TaskResponse{}},
+	{Method: "GET", Path: "/notifications", Tag: "tasks", Summary: "Wait for agents to finish tasks (long poll): answers as soon as a matching notification arrives after since, or with timed_out when the timeout passes", Response: NotificationsResponse{}, Query: []apiParam{
+		{Name: "since", Type: "integer", Description: "next_sequence from the previous call (default 0: every buffered notification)"},
+		{Name: "timeout", Description: "How long to wait, as a duration (30s) or seconds; default 30s, max 5m, 0 answers at once"},
+		{Name: "team", Description: "Only this team's notifications"},
+		{Name: "agent", Description: "Only this agent's notifications"},
+		{Name: "status", Description: "completed, failed or cancelled"},
+	}},
+	{Method: "GET", Path: "/teams/{name}/messages", Tag: "messages", Summary: "List a team's messages", Response: MessageListResponse{}, Query: append([]apiParam{
+		{Name: "agent", Description: "Only messages to this agent"},
+		{Name: "unread", Type: "boolean", Description: "With agent, only unread messages"},
+	}, listQuery("created_at, from, type")...)},
+	{Method: "POST", Path: "/teams/{name}/messages", Tag: "messages", Summary: "Send a message to an agent or the whole team", Request: SendMessageRequest{}, Response: MessageResponse{}, Status: http.StatusCreated},
+
+	// Stats
+	{Method: "GET", Path: "/stats/summary", Tag: "stats", Summary: "Cost summary", Response: StatsSummaryResponse{}, Query: []apiParam{statsPeriod}},
+	{Method: "GET", Path: "/stats/projects", Tag: "stats", Summary: "Cost by project", Response: StatsProjectsResponse{}, Query: []apiParam{statsPeriod}},
+	{Method: "GET", Path: "/stats/models", Tag: "stats", Summary: "Cost by model", Response: StatsModelsResponse{}, Query: []apiParam{statsPeriod}},
+	{Method: "POST", Path: "/stats/refresh", Tag: "stats", Summary: "Rescan Claude session files and rebuild the stats cache", Response: StatsRefreshResponse{}},
+
+	// Workflows
+	{Method: "GET", Path: "/workflows", Tag: "workflows", Summary: "List workflows", Response: WorkflowListResponse{}},
+	{Method: "GET", Path: "/workflows/{name}", Tag: "workflows", Summary: "Get a workflow", Response: workflow.Workflow{}},
+	{Method: "POST", Path: "/workflows/{name}/run", Tag: "workflows", Summary: "Run a workflow as a new team", Request: RunWorkflowRequest{}, Response: RunWorkflowResponse{}, Status: http.StatusCreated},
+}
+
+var statsPeriod = apiParam{Name: "period", Description: "today, week, month or all"}
+
+// listQuery returns the paging, sorting and field selection parameters
+// shared by list endpoints (see listing.go).
+func listQuery(sortKeys string) []apiParam {
+	return []apiParam{
+		{Name: "limit", Type: "integer", Description: fmt.Sprintf("Page size (1-%d)", maxListLimit)},
+		{Name: "offset", Type: "integer", Description: "Items to skip; use next_offset from the previous page"},
+		{Name: "sort", Description: "Sort by " + sortKeys + "; prefix with - for descending"},
+		{Name: "fields", Description: "Comma-separated item fields to return"},
+	}
+}
+
+var pathParam = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`\{([^}]+)\}`)
+})
+
+// buildOpenAPI returns the OpenAPI 3.1 document for the API.
+func buildOpenAPI(version string) (map[string]any, error) {
+	schemas := map[string]any{
+		"ErrorResponse": mustSchema(ErrorResponse{}),
+	}
+	ref := func(v any) (map[string]any, error) {
+		t := reflect.TypeOf(v)
+		name := t.Name()
+		if _, ok := schemas[name]; !ok {
+			s, err := jsonschema.ForType(t, &jsonschema.ForOptions{IgnoreInvalidTypes: true})
+			if err != nil {
+				return nil, err
+			}
+			schemas[name] = s
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}, nil
+	}
+	errorContent := map[string]any{
+		"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/ErrorResponse"}},
+	}
+
+	paths := make(map[string]map[string]any)
+	for _, op := range apiOperations {
+		o := map[string]any{
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+			"operationId": operationID(op),
+		}
+
+		var params []map[string]any
+		for _, m := range pathParam().FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+		for _, q := range op.Query {
+			typ := q.Type
+			if typ == "" {
+				typ = "string"
+			}
+			params = append(params, map[string]any{"name": q.Name, "in": "query", "description": q.Description, "schema": map[string]any{"type": typ}})
+		}
+		if op.Idempotent {
+			params = append(params, map[string]any{"name": idempotencyKeyHeader, "in": "header", "description": "Retries with the same key and body within 24 hours get the first response again (with Idempotent-Replayed: true) instead of repeating the request; the same key with another body gets 422", "schema": map[string]any{"type": "string", "maxLength": maxIdempotencyKeyLen}})
+		}
+		if len(params) > 0 {
+			o["parameters"] = params
+		}
+
+		if op.Request != nil {
+			schema, err := ref(op.Request)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s request: %w", op.Method, op.Path, err)
+			}
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case op.Response != nil:
+			schema, err := ref(op.Response)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s response: %w", op.Method, op.Path, err)
+			}
+			success["content"] = map[string]any{"application/json": map[string]any{"schema": schema}}
+		case op.ContentType != "":
+			success["content"] = map[string]any{op.ContentType: map[string]any{}}
+		}
+		responses := map[string]any{
+			fmt.Sprint(status): success,
+			"default":          map[string]any{"description": "Error", "content": errorContent},
+		}
+		o["responses"] = responses
+
+		switch op.Auth {
+		case authPublic:
+			o["security"] = []any{}
+		case authAdmin:
+			o["description"] = "Requires a token with the admin scope (from httpAdminTokens, or a scoped token); other tokens get 403."
+		default:
+			if scope := requiredScope(op.Method, op.Path); scope != ScopeRead {
+				o["description"] = fmt.Sprintf("Requires a token with the %s scope; other tokens get 403.", scope)
+			}
+		}
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = make(map[string]any)
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = o
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "codes HTTP API",
+			"version":     version,
+			"description": "REST API of `codes serve`. Send `Authorization: Bearer <token>` with a token from httpTokens in ~/.codes/config.json, or one created with `codes serve token create`. Tokens limited to teams get 403 for other teams. Requests over the rate limits (httpLimits) get 429 with a Retry-After header; bodies over the size limit (1 MiB by default) get 413. Creating a team, task or session in a directory the work dir policy (workDirPolicy) does not allow gets 403. A server started with `codes serve --read-only` answers every request other than GET, HEAD and OPTIONS with 403 and code `read_only`; /health reports `read_only: true`. Messages to a chat session that has reached its max_cost_usd or max_turns get 403 with code `limit_exceeded`. Browsers on other origins may call the API once they are listed in httpCORS.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{map[string]any{"bearer": []string{}}},
+	}, nil
+}
+
+// operationID derives a stable ID such as getTeamsNameTasks from the method
+// and path.
+func operationID(op apiOperation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '-'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func mustSchema(v any) *jsonschema.Schema {
+	s, err := jsonschema.ForType(reflect.TypeOf(v), nil)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// handleOpenAPI handles GET /openapi.json
+func (s *HTTPServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	doc, err := buildOpenAPI(s.version)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, doc)
+}
+
+// handleDocs handles GET /docs, a Swagger UI page for /openapi.json. The UI
+// itself is loaded from a CDN.
+func (s *HTTPServer) handleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>codes HTTP API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true});
+  </script>
+</body>
+</html>
+`
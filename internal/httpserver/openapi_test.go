@@ -0,0 +1,138 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "1.2.3")
+
+	// Public, like /health.
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Info.Version != "1.2.3" {
+		t.Errorf("openapi = %q, version = %q", doc.OpenAPI, doc.Info.Version)
+	}
+	for _, op := range apiOperations {
+		if doc.Paths[op.Path][strings.ToLower(op.Method)] == nil {
+			t.Errorf("%s %s missing from the document", op.Method, op.Path)
+		}
+	}
+
+	// Every referenced schema is defined.
+	for _, m := range schemaRefs(w.Body.String()) {
+		if doc.Components.Schemas[m] == nil {
+			t.Errorf("schema %s is referenced but not defined", m)
+		}
+	}
+
+	// Schemas come from the structs' JSON tags.
+	task := doc.Components.Schemas["CreateTaskRequest"]
+	props, _ := task["properties"].(map[string]any)
+	if props["subject"] == nil || props["blocked_by"] == nil {
+		t.Errorf("CreateTaskRequest properties = %v", props)
+	}
+
+	// Swagger UI
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/openapi.json") {
+		t.Errorf("GET /docs: status %d", w.Code)
+	}
+}
+
+func schemaRefs(body string) []string {
+	var refs []string
+	for _, part := range strings.Split(body, `"$ref":"#/components/schemas/`)[1:] {
+		refs = append(refs, part[:strings.Index(part, `"`)])
+	}
+	return refs
+}
+
+// TestOpenAPIMatchesRoutes sends a request for every documented operation
+// and checks that it reaches a handler, and that every registered route is
+// documented.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cleanup := setupTestConfig(t, &config.Config{
+		Profiles: []config.APIConfig{{Name: "default"}},
+		Default:  "default",
+	})
+	defer cleanup()
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetAdminTokens([]string{"admin-token"})
+	server.hostSessions = &fakeHostSessions{}
+	server.remotes = &fakeFleet{}
+
+	samples := strings.NewReplacer(
+		"{name}", "no-such-team",
+		"{team}", "no-such-team",
+		"{id}", "1",
+		"{agent}", "worker",
+		"{file}", "out.txt",
+		"{path}", "health",
+		"/notifications", "/notifications?timeout=0",
+	)
+	served := make(map[string]bool)
+	for _, op := range apiOperations {
+		path := samples.Replace(op.Path)
+		var body *strings.Reader
+		if op.Request != nil {
+			body = strings.NewReader(`{}`)
+		} else {
+			body = strings.NewReader("")
+		}
+		req := httptest.NewRequest(op.Method, path, body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer admin-token")
+
+		_, pattern := server.mux.Handler(req)
+		served[pattern] = true
+
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		if w.Code == http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: method not allowed", op.Method, op.Path)
+			continue
+		}
+		var errResp ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &errResp)
+		if strings.HasPrefix(w.Body.String(), "404 page not found") ||
+			errResp.Error == "not found" || errResp.Error == "invalid path" ||
+			strings.HasPrefix(errResp.Error, "unknown ") {
+			t.Errorf("%s %s: not routed (status %d: %s)", op.Method, op.Path, w.Code, strings.TrimSpace(w.Body.String()))
+		}
+	}
+
+	for _, pattern := range server.patterns {
+		if !served[pattern] {
+			t.Errorf("route %s is registered but has no operation in apiOperations", pattern)
+		}
+	}
+}
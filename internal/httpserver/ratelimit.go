@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codes/internal/chatsession"
+	"codes/internal/config"
+)
+
+// Defaults for config.HTTPLimits fields left at zero.
+const (
+	defaultTokenRate    = 20
+	defaultTokenBurst   = 100
+	defaultIPRate       = 50
+	defaultIPBurst      = 200
+	defaultMaxBodyBytes = 1 << 20
+)
+
+// limiter is a set of token buckets, one per key (an API token or a client
+// IP). Each bucket holds up to burst requests and refills at rate per second.
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter returns a limiter, or nil (which allows everything) when rate
+// is negative.
+func newLimiter(rate float64, burst int, defRate float64, defBurst int) *limiter {
+	if rate < 0 {
+		return nil
+	}
+	if rate == 0 {
+		rate = defRate
+	}
+	if burst <= 0 {
+		burst = defBurst
+	}
+	return &limiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket)}
+}
+
+// allow takes a request from key's bucket. When the bucket is empty it
+// returns false and how long until the next request would be allowed.
+func (l *limiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely, so clients that went
+// away do not accumulate. Runs at most once a minute.
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// limits are the rate and size limits set by SetLimits. The zero value
+// applies no rate limits and the default body limit.
+type limits struct {
+	token   *limiter
+	ip      *limiter
+	maxBody int64
+}
+
+// SetLimits turns on rate limiting and sets the request body limit, using
+// the defaults for fields left at zero (or for all of them when l is nil).
+// Without it requests are not rate limited, which tests rely on.
+func (s *HTTPServer) SetLimits(l *config.HTTPLimits) {
+	if l == nil {
+		l = &config.HTTPLimits{}
+	}
+	s.limits = limits{
+		token:   newLimiter(l.TokenRate, l.TokenBurst, defaultTokenRate, defaultTokenBurst),
+		ip:      newLimiter(l.IPRate, l.IPBurst, defaultIPRate, defaultIPBurst),
+		maxBody: l.MaxBodyBytes,
+	}
+}
+
+// maxBodyBytes returns the largest accepted request body.
+func (l *limits) maxBodyBytes() int64 {
+	if l.maxBody <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return l.maxBody
+}
+
+// limitMiddleware applies the per-IP rate limit and the body size limit to
+// every request, including unauthenticated ones and /mcp/. The per-token
+// limit is applied by authMiddleware once the token is known to be valid.
+func (s *HTTPServer) limitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := s.limits.ip.allow(clientIP(r), time.Now()); !ok {
+			respondRateLimited(w, wait, "too many requests from this address")
+			return
+		}
+		maxBody := s.limits.maxBodyBytes()
+		if isSessionMessage(r) {
+			// Room for the attachments chatsession accepts
+			maxBody = max(maxBody, chatsession.MaxMessageBodyBytes)
+		}
+		if r.ContentLength > maxBody {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBody))
+			return
+		}
+		// Bodies without a Content-Length are cut off at the limit instead
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isSessionMessage reports whether r is POST /sessions/{id}/message.
+func isSessionMessage(r *http.Request) bool {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	return r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "sessions" && parts[2] == "message"
+}
+
+// allowToken applies the per-token rate limit, writing a 429 response and
+// returning false when token is over it.
+func (s *HTTPServer) allowToken(w http.ResponseWriter, token string) bool {
+	ok, wait := s.limits.token.allow(token, time.Now())
+	if !ok {
+		respondRateLimited(w, wait, "too many requests with this token")
+	}
+	return ok
+}
+
+// clientIP returns the address the request came from. Forwarding headers
+// are ignored since any client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// respondRateLimited sends 429 with a Retry-After header in whole seconds.
+func respondRateLimited(w http.ResponseWriter, wait time.Duration, message string) {
+	secs := max(1, int(math.Ceil(wait.Seconds())))
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	respondError(w, http.StatusTooManyRequests, fmt.Sprintf("%s, retry in %ds", message, secs))
+}
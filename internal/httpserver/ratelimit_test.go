@@ -0,0 +1,86 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"codes/internal/config"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, 3, defaultTokenRate, defaultTokenBurst)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("request %d within burst refused", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("over burst: allow = %v, wait %v; want refused, 500ms", ok, wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("other key refused")
+	}
+	if ok, _ := l.allow("a", now.Add(500*time.Millisecond)); !ok {
+		t.Error("refused after refill")
+	}
+
+	// Buckets that refilled are dropped
+	l.allow("a", now.Add(time.Hour))
+	if len(l.buckets) != 1 {
+		t.Errorf("%d buckets after sweep, want 1", len(l.buckets))
+	}
+
+	if ok, _ := newLimiter(-1, 0, 1, 1).allow("a", now); !ok {
+		t.Error("disabled limiter refused a request")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := NewHTTPServer([]string{"tok-a", "tok-b"}, "test")
+	server.SetLimits(&config.HTTPLimits{TokenRate: 1, TokenBurst: 2, IPRate: 1, IPBurst: 4, MaxBodyBytes: 64})
+	handler := server.Handler()
+
+	do := func(token, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/teams", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do("tok-a", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+	}
+	w := do("tok-a", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("token over limit: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := do("tok-b", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("second token: status %d", w.Code)
+	}
+
+	// The IP budget is shared by every token, valid or not
+	if w := do("bogus", "10.0.0.1"); w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "address") {
+		t.Errorf("IP over limit: status %d (%s)", w.Code, w.Body.String())
+	}
+	if w := do("tok-b", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("other IP: status %d", w.Code)
+	}
+
+	req := httptest.NewRequest("POST", "/profiles/switch", strings.NewReader(strings.Repeat("x", 65)))
+	req.RemoteAddr = "10.0.0.3:1234"
+	req.Header.Set("Authorization", "Bearer tok-a")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: status %d, want 413", rec.Code)
+	}
+}
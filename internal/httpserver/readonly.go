@@ -0,0 +1,33 @@
+package httpserver
+
+import "net/http"
+
+// errCodeReadOnly is the ErrorResponse code of requests refused because the
+// server runs with --read-only.
+const errCodeReadOnly = "read_only"
+
+// SetReadOnly turns every endpoint that changes state off (codes serve
+// --read-only): listings, activity, transcripts and event streams keep
+// working, anything else answers 403 with the read_only code. Useful for
+// exposing a status dashboard widely while control stays local.
+func (s *HTTPServer) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// rejectReadOnly answers 403 and returns true when the server is read-only
+// and r could change state, that is any method but GET, HEAD and OPTIONS.
+func (s *HTTPServer) rejectReadOnly(w http.ResponseWriter, r *http.Request) bool {
+	if !s.readOnly {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	respondJSON(w, http.StatusForbidden, ErrorResponse{
+		Error:     "server is read-only (started with codes serve --read-only)",
+		Code:      errCodeReadOnly,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
+	return true
+}
@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codes/internal/agent"
+)
+
+func TestReadOnly(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	server.SetReadOnly(true)
+	teamName := uniqueTeamName("readonly")
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/teams", "/teams/" + teamName, "/teams/" + teamName + "/tasks", "/teams/" + teamName + "/activity"} {
+		if w := do(http.MethodGet, path, "test-token", ""); w.Code != http.StatusOK {
+			t.Errorf("GET %s: %d %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/teams", `{"name":"` + teamName + `-x"}`},
+		{http.MethodPost, "/teams/" + teamName + "/tasks", `{"subject":"nope"}`},
+		{http.MethodDelete, "/teams/" + teamName, ""},
+		{http.MethodPost, "/feishu/webhook", `{"challenge":"c"}`},
+	} {
+		w := do(tc.method, tc.path, "test-token", tc.body)
+		var resp ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusForbidden || resp.Code != errCodeReadOnly {
+			t.Errorf("%s %s: %d %s, want 403 read_only", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+	if tasks, _ := agent.ListTasks(teamName, "", ""); len(tasks) != 0 {
+		t.Errorf("read-only server created %d tasks", len(tasks))
+	}
+
+	// A bad token is still told so, not that the server is read-only
+	if w := do(http.MethodPost, "/teams", "wrong", "{}"); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad token: %d, want 401", w.Code)
+	}
+
+	var health HealthResponse
+	json.Unmarshal(do(http.MethodGet, "/health", "", "").Body.Bytes(), &health)
+	if !health.ReadOnly {
+		t.Errorf("health = %+v, want read_only", health)
+	}
+}
@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// requestIDHeader carries the request ID. A client may send its own to tie
+// the server's log lines to its own; the server echoes it (or the one it
+// generated) on every response.
+const requestIDHeader = "X-Request-ID"
+
+// accessLog writes one JSON line per request to stderr.
+var accessLog = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// requestInfo is what the access log line reports beyond the request
+// itself. authMiddleware fills in the token once it is known.
+type requestInfo struct {
+	id    string
+	token string
+}
+
+type requestInfoKey struct{}
+
+// requestIDMiddleware assigns every request an ID, sets it on the response
+// and stores it in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestInfoKey{}, &requestInfo{id: id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID accepts client IDs of up to 128 letters, digits and
+// -_.: so they are safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// requestInfoFrom returns the info stored by requestIDMiddleware, or nil for
+// requests that did not go through it (handlers called directly in tests).
+func requestInfoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// requestID returns the ID of r, or "".
+func requestID(r *http.Request) string {
+	if info := requestInfoFrom(r.Context()); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// tokenIdentity names a token in logs without revealing it: the name of a
+// scoped token, or the start of the token's SHA-256 otherwise.
+func tokenIdentity(token string, g *grant) string {
+	if g != nil && g.name != "" {
+		return g.name
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
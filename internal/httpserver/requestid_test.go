@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codes/internal/agent"
+)
+
+// captureAccessLog redirects the access log to a buffer for the test.
+func captureAccessLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	orig := accessLog
+	accessLog = slog.New(slog.NewJSONHandler(&buf, nil))
+	t.Cleanup(func() { accessLog = orig })
+	return &buf
+}
+
+func TestRequestID(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	logBuf := captureAccessLog(t)
+
+	// Generated when the client sends none, and quoted in error bodies
+	req := httptest.NewRequest(http.MethodGet, "/teams", nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	id := w.Header().Get(requestIDHeader)
+	if id == "" {
+		t.Fatal("no X-Request-ID on the response")
+	}
+	var errResp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&errResp)
+	if errResp.RequestID != id {
+		t.Errorf("error body requestId = %q, want %q", errResp.RequestID, id)
+	}
+
+	// A client's own ID is kept, an unsafe one replaced
+	for _, tc := range []struct {
+		sent string
+		kept bool
+	}{
+		{"trace-42.a:b", true},
+		{"bad id\nwith newline", false},
+		{strings.Repeat("x", 200), false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set(requestIDHeader, tc.sent)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if got := w.Header().Get(requestIDHeader); (got == tc.sent) != tc.kept || got == "" {
+			t.Errorf("sent %q: response ID %q, kept = %v", tc.sent, got, tc.kept)
+		}
+	}
+
+	// The access log line names the request and the token
+	logBuf.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/teams", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set(requestIDHeader, "log-check")
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+	var line map[string]any
+	if err := json.Unmarshal(logBuf.Bytes(), &line); err != nil {
+		t.Fatalf("access log is not one JSON line: %v\n%s", err, logBuf.String())
+	}
+	if line["request_id"] != "log-check" || line["path"] != "/teams" || line["status"] != float64(200) {
+		t.Errorf("unexpected log line: %v", line)
+	}
+	if tok, _ := line["token"].(string); !strings.HasPrefix(tok, "sha256:") || strings.Contains(tok, "test-token") {
+		t.Errorf("token identity = %q, want a sha256 prefix", tok)
+	}
+}
+
+func TestRequestIDOnCreatedTask(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	captureAccessLog(t)
+	teamName := uniqueTeamName("reqid")
+	if _, err := agent.CreateTeam(teamName, "", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer agent.DeleteTeam(teamName)
+
+	req := httptest.NewRequest(http.MethodPost, "/teams/"+teamName+"/tasks", strings.NewReader(`{"subject":"Traced"}`))
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestIDHeader, "create-1")
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	var resp TaskResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.RequestID != "create-1" {
+		t.Errorf("response request_id = %q, want create-1", resp.RequestID)
+	}
+	task, err := agent.GetTask(teamName, resp.ID)
+	if err != nil || task.RequestID != "create-1" {
+		t.Errorf("stored task request ID = %v (err %v), want create-1", task, err)
+	}
+}
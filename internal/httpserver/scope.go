@@ -0,0 +1,159 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Token scopes. Every token can read; write scopes add the matching
+// mutations and admin allows everything, including actions on the host.
+// Tokens from httpTokens hold read, tasks:write and sessions:write (not
+// profiles:write, which can change the credentials every session uses); tokens
+// from httpAdminTokens hold admin. Scoped tokens (httpScopedTokens) hold the
+// scopes they were created with and may also be limited to some teams.
+const (
+	ScopeRead          = "read"
+	ScopeTasksWrite    = "tasks:write"    // teams, tasks, messages, agents, workflow runs
+	ScopeSessionsWrite = "sessions:write" // chat sessions, the assistant, profile switches
+	ScopeProfilesWrite = "profiles:write" // creating, changing and deleting API profiles
+	ScopeAdmin         = "admin"
+)
+
+// Scopes lists the valid token scopes.
+var Scopes = []string{ScopeRead, ScopeTasksWrite, ScopeSessionsWrite, ScopeProfilesWrite, ScopeAdmin}
+
+// grant is what an authenticated token may do.
+type grant struct {
+	name   string   // token name for scoped tokens, for error messages
+	scopes []string // see Scopes
+	teams  []string // nil = all teams
+	// session is set for share links: the grant may only read that
+	// session (see share.go)
+	session string
+}
+
+// has reports whether g holds scope.
+func (g *grant) has(scope string) bool {
+	return scope == ScopeRead || slices.Contains(g.scopes, ScopeAdmin) || slices.Contains(g.scopes, scope)
+}
+
+// allowsTeam reports whether g may act on team.
+func (g *grant) allowsTeam(team string) bool {
+	return g.teams == nil || slices.Contains(g.teams, team)
+}
+
+var (
+	legacyGrant = &grant{scopes: []string{ScopeTasksWrite, ScopeSessionsWrite}}
+	adminGrant  = &grant{scopes: []string{ScopeAdmin}}
+)
+
+type grantKey struct{}
+
+// grantFrom returns the grant authMiddleware attached to the request. Requests
+// that did not pass through it are unrestricted.
+func grantFrom(ctx context.Context) *grant {
+	if g, ok := ctx.Value(grantKey{}).(*grant); ok {
+		return g
+	}
+	return adminGrant
+}
+
+// lookupToken returns the grant of token, or nil if it is not valid. Every
+// configured token is compared in constant time.
+func (s *HTTPServer) lookupToken(token string) *grant {
+	var g *grant
+	if tokenIn(token, s.tokens) {
+		g = legacyGrant
+	}
+	if tokenIn(token, s.adminTokens) {
+		g = adminGrant
+	}
+	for _, t := range s.scopedTokens {
+		if tokenIn(token, []string{t.Token}) {
+			g = &grant{name: t.Name, scopes: t.Scopes, teams: t.Teams}
+			if len(t.Teams) == 0 {
+				g.teams = nil
+			}
+		}
+	}
+	return g
+}
+
+// requiredScope returns the scope a request needs, from its method and path.
+// Reads need ScopeRead except the session WebSocket, which sends input.
+func requiredScope(method, path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch parts[0] {
+	case "host", "webhooks", "remotes":
+		return ScopeAdmin
+	case "sessions":
+		if len(parts) == 3 && parts[2] == "ws" {
+			return ScopeSessionsWrite
+		}
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return ScopeRead
+	}
+	switch parts[0] {
+	case "profiles":
+		if len(parts) == 2 && parts[1] == "switch" {
+			return ScopeSessionsWrite
+		}
+		return ScopeProfilesWrite
+	case "sessions", "assistant", "projects":
+		return ScopeSessionsWrite
+	case "teams", "tasks", "workflows":
+		return ScopeTasksWrite
+	}
+	// The rest only serve reads (other methods get 405), apart from POST
+	// /stats/refresh, which just rebuilds a cache
+	return ScopeRead
+}
+
+// requestTeam returns the team a request acts on, and whether it touches
+// several teams at once (creating teams, running workflows, metrics). GET
+// /teams is filtered by handleListTeams instead.
+func requestTeam(method, path string) (team string, crossTeam bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch parts[0] {
+	case "teams", "tasks":
+		if len(parts) >= 2 {
+			return parts[1], false
+		}
+		return "", method != http.MethodGet
+	case "workflows":
+		return "", len(parts) == 3 && parts[2] == "run"
+	case "metrics":
+		return "", true
+	}
+	return "", false
+}
+
+// authorize checks g against the request, returning a message for a 403 or
+// "" if the request is allowed.
+func (g *grant) authorize(r *http.Request) string {
+	token := "token"
+	if g.name != "" {
+		token = fmt.Sprintf("token %q", g.name)
+	}
+	if g.session != "" {
+		if !shareAllows(g.session, r) {
+			return "share link only allows watching session " + g.session
+		}
+		return ""
+	}
+	if scope := requiredScope(r.Method, r.URL.Path); !g.has(scope) {
+		return token + " lacks the " + scope + " scope"
+	}
+	if g.teams == nil {
+		return ""
+	}
+	team, crossTeam := requestTeam(r.Method, r.URL.Path)
+	if crossTeam || (team != "" && !g.allowsTeam(team)) {
+		return token + " is restricted to teams " + strings.Join(g.teams, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codes/internal/agent"
+	"codes/internal/config"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/teams", ScopeRead},
+		{"GET", "/teams/t/tasks", ScopeRead},
+		{"POST", "/teams", ScopeTasksWrite},
+		{"PATCH", "/teams/t/tasks/1", ScopeTasksWrite},
+		{"POST", "/teams/t/start", ScopeTasksWrite},
+		{"POST", "/workflows/w/run", ScopeTasksWrite},
+		{"POST", "/sessions", ScopeSessionsWrite},
+		{"DELETE", "/sessions/abc", ScopeSessionsWrite},
+		{"GET", "/sessions/abc/ws", ScopeSessionsWrite},
+		{"POST", "/assistant", ScopeSessionsWrite},
+		{"POST", "/profiles/switch", ScopeSessionsWrite},
+		{"POST", "/profiles", ScopeProfilesWrite},
+		{"PATCH", "/profiles/relay", ScopeProfilesWrite},
+		{"DELETE", "/profiles/relay", ScopeProfilesWrite},
+		{"POST", "/projects", ScopeSessionsWrite},
+		{"DELETE", "/projects/my-app", ScopeSessionsWrite},
+		{"POST", "/stats/refresh", ScopeRead},
+		{"POST", "/host/sessions", ScopeAdmin},
+		{"GET", "/webhooks", ScopeAdmin},
+		{"DELETE", "/webhooks/ops", ScopeAdmin},
+		{"GET", "/remotes", ScopeAdmin},
+		{"GET", "/remotes/gpu/proxy/teams", ScopeAdmin},
+	}
+	for _, tt := range tests {
+		if got := requiredScope(tt.method, tt.path); got != tt.want {
+			t.Errorf("requiredScope(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	// Every documented mutation needs more than read, so a new endpoint
+	// cannot become writable by read-only tokens unnoticed.
+	for _, op := range apiOperations {
+		if op.Auth == authPublic || op.Method == "GET" || op.Path == "/stats/refresh" {
+			continue
+		}
+		if requiredScope(op.Method, op.Path) == ScopeRead {
+			t.Errorf("%s %s only requires the read scope", op.Method, op.Path)
+		}
+	}
+}
+
+func TestScopedTokens(t *testing.T) {
+	allowed := uniqueTeamName("scope-ok")
+	other := uniqueTeamName("scope-other")
+	for _, name := range []string{allowed, other} {
+		if _, err := agent.CreateTeam(name, "", ""); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		defer agent.DeleteTeam(name)
+	}
+
+	server := NewHTTPServer([]string{"legacy-token"}, "test")
+	server.SetScopedTokens([]config.HTTPToken{
+		{Name: "reader", Token: "read-token", Scopes: []string{ScopeRead}},
+		{Name: "ci", Token: "ci-token", Scopes: []string{ScopeTasksWrite}, Teams: []string{allowed}},
+		{Name: "ops", Token: "ops-token", Scopes: []string{ScopeAdmin}},
+	})
+
+	do := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+	task := `{"subject":"scoped"}`
+
+	tests := []struct {
+		name, token, method, path, body string
+		want                            int
+	}{
+		{"reader lists teams", "read-token", "GET", "/teams", "", http.StatusOK},
+		{"reader cannot create tasks", "read-token", "POST", "/teams/" + allowed + "/tasks", task, http.StatusForbidden},
+		{"reader cannot create sessions", "read-token", "POST", "/sessions", `{}`, http.StatusForbidden},
+		{"ci creates tasks in its team", "ci-token", "POST", "/teams/" + allowed + "/tasks", task, http.StatusCreated},
+		{"ci cannot touch other teams", "ci-token", "POST", "/teams/" + other + "/tasks", task, http.StatusForbidden},
+		{"ci cannot read other teams", "ci-token", "GET", "/tasks/" + other + "/1", "", http.StatusForbidden},
+		{"ci cannot create teams", "ci-token", "POST", "/teams", `{"name":"x"}`, http.StatusForbidden},
+		{"ci cannot read metrics", "ci-token", "GET", "/metrics", "", http.StatusForbidden},
+		{"ci cannot create sessions", "ci-token", "POST", "/sessions", `{}`, http.StatusForbidden},
+		{"legacy token keeps task access", "legacy-token", "POST", "/teams/" + other + "/tasks", task, http.StatusCreated},
+		{"legacy token lacks admin", "legacy-token", "POST", "/host/sessions", `{}`, http.StatusForbidden},
+		{"unknown token", "nope", "GET", "/teams", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if w := do(tt.token, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	// The admin scope passes adminMiddleware; the empty body fails validation
+	if w := do("ops-token", "POST", "/host/sessions", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("admin token on /host/sessions: status %d, want 400 (%s)", w.Code, w.Body.String())
+	}
+
+	// Team-restricted tokens only see their teams
+	w := do("ci-token", "GET", "/teams", "")
+	var resp TeamListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Teams) != 1 || resp.Teams[0].Name != allowed {
+		t.Errorf("ci token sees teams %+v, want only %s", resp.Teams, allowed)
+	}
+}
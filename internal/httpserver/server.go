@@ -2,25 +2,54 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+
+	"codes/internal/config"
 )
 
 // HTTPServer represents the HTTP API server
 type HTTPServer struct {
-	mux     *http.ServeMux
-	tokens  []string
-	version string
-	srv     *http.Server
+	mux           *http.ServeMux
+	tokens        []string
+	adminTokens   []string           // also grant the admin scope
+	scopedTokens  []config.HTTPToken // limited to scopes and teams, see scope.go
+	limits        limits             // see ratelimit.go
+	tlsConfig     *tls.Config        // nil = plain HTTP, see tls.go
+	metrics       *httpMetrics       // request counts and latency, see metrics.go
+	metricsPublic bool               // serve /metrics without a token
+	auditing      bool               // record state-changing requests, see audit.go
+	readOnly      bool               // refuse state-changing requests, see readonly.go
+	cors          *corsPolicy        // browser origins allowed, see cors.go
+	version       string
+	srv           *http.Server
+	patterns      []string // registered by registerRoutes
+
+	hostSessionsMu sync.Mutex
+	hostSessions   hostSessionStarter // created on first use
+
+	remotesMu sync.Mutex
+	remotes   remoteFleet // created on first use, see handlers_remotes.go
+
+	shareKeyMu sync.Mutex
+	shareKey   []byte // signs share links, loaded on first use, see share.go
+
+	idempotency   *idempotencyCache // responses to replay, see idempotency.go
+	notifications *notificationFeed // task notifications for GET /notifications
 }
 
 // NewHTTPServer creates a new HTTP server instance
 func NewHTTPServer(tokens []string, version string) *HTTPServer {
 	s := &HTTPServer{
-		mux:     http.NewServeMux(),
-		tokens:  tokens,
-		version: version,
+		mux:           http.NewServeMux(),
+		tokens:        tokens,
+		version:       version,
+		metrics:       newHTTPMetrics(),
+		idempotency:   newIdempotencyCache(),
+		notifications: newNotificationFeed(),
 	}
 
 	// Register routes
@@ -29,41 +58,95 @@ func NewHTTPServer(tokens []string, version string) *HTTPServer {
 	return s
 }
 
+// SetAdminTokens sets the tokens that carry the admin scope, required by
+// endpoints that act on the host machine. They are valid for every other
+// endpoint too.
+func (s *HTTPServer) SetAdminTokens(tokens []string) {
+	s.adminTokens = tokens
+}
+
+// SetScopedTokens sets the tokens limited to scopes and, optionally, teams
+// (created with `codes serve token create`).
+func (s *HTTPServer) SetScopedTokens(tokens []config.HTTPToken) {
+	s.scopedTokens = tokens
+}
+
 // registerRoutes sets up all HTTP routes with middleware
 func (s *HTTPServer) registerRoutes() {
 	// Health check (no auth required)
-	s.mux.HandleFunc("/health", loggingMiddleware(s.handleHealth))
+	s.route("/health", loggingMiddleware(s.handleHealth))
+
+	// Payload schemas for integrations (no auth required)
+	s.route("/schemas", loggingMiddleware(s.handleListSchemas))
+	s.route("/schemas/", loggingMiddleware(s.handleGetSchema))
+
+	// API description (no auth required, see openapi.go)
+	s.route("/openapi.json", loggingMiddleware(s.handleOpenAPI))
+	s.route("/docs", loggingMiddleware(s.handleDocs))
+
+	// Web dashboard (no auth required: the page asks for a token, see dashboard.go)
+	s.route("/{$}", loggingMiddleware(s.handleDashboard))
+	s.route("/ui/", loggingMiddleware(s.handleDashboardAsset))
 
 	// === Projects & Profiles (Block B) ===
-	s.mux.HandleFunc("/projects", loggingMiddleware(s.authMiddleware(s.handleListProjects)))
-	s.mux.HandleFunc("/projects/", loggingMiddleware(s.authMiddleware(s.handleGetProject)))
-	s.mux.HandleFunc("/profiles", loggingMiddleware(s.authMiddleware(s.handleListProfiles)))
-	s.mux.HandleFunc("/profiles/switch", loggingMiddleware(s.authMiddleware(jsonContentTypeMiddleware(s.handleSwitchProfile))))
+	s.route("/projects", loggingMiddleware(s.authMiddleware(s.routeProjects)))
+	s.route("/projects/", loggingMiddleware(s.authMiddleware(s.routeProjectByName)))
+	s.route("/profiles", loggingMiddleware(s.authMiddleware(s.routeProfiles)))
+	s.route("/profiles/", loggingMiddleware(s.authMiddleware(s.routeProfileByName)))
+	s.route("/profiles/switch", loggingMiddleware(s.authMiddleware(jsonContentTypeMiddleware(s.handleSwitchProfile))))
 
 	// === Sessions (Block A) ===
-	s.mux.HandleFunc("/sessions", loggingMiddleware(s.authMiddleware(s.routeSessions)))
-	s.mux.HandleFunc("/sessions/", loggingMiddleware(s.authMiddleware(s.routeSessionByID)))
+	s.route("/sessions", loggingMiddleware(s.authMiddleware(s.routeSessions)))
+	s.route("/sessions/", loggingMiddleware(s.authMiddleware(s.routeSessionByID)))
 
 	// === Teams (Block D enhanced) ===
-	s.mux.HandleFunc("/teams", loggingMiddleware(s.authMiddleware(s.routeTeams)))
-	s.mux.HandleFunc("/teams/", loggingMiddleware(s.authMiddleware(s.routeTeamByName)))
+	s.route("/teams", loggingMiddleware(s.authMiddleware(s.routeTeams)))
+	s.route("/teams/", loggingMiddleware(s.authMiddleware(s.routeTeamByName)))
 
 	// === Tasks (direct access, existing) ===
-	s.mux.HandleFunc("/tasks/", loggingMiddleware(s.authMiddleware(s.handleGetTask)))
+	s.route("/tasks/", loggingMiddleware(s.authMiddleware(s.handleGetTask)))
+
+	// === Task notifications (long poll) ===
+	s.route("/notifications", loggingMiddleware(s.authMiddleware(s.handleNotifications)))
 
 	// === Stats (Block E) ===
-	s.mux.HandleFunc("/stats/summary", loggingMiddleware(s.authMiddleware(s.handleStatsSummary)))
-	s.mux.HandleFunc("/stats/projects", loggingMiddleware(s.authMiddleware(s.handleStatsProjects)))
-	s.mux.HandleFunc("/stats/models", loggingMiddleware(s.authMiddleware(s.handleStatsModels)))
-	s.mux.HandleFunc("/stats/refresh", loggingMiddleware(s.authMiddleware(s.handleStatsRefresh)))
+	s.route("/stats/summary", loggingMiddleware(s.authMiddleware(s.handleStatsSummary)))
+	s.route("/stats/projects", loggingMiddleware(s.authMiddleware(s.handleStatsProjects)))
+	s.route("/stats/models", loggingMiddleware(s.authMiddleware(s.handleStatsModels)))
+	s.route("/stats/refresh", loggingMiddleware(s.authMiddleware(s.handleStatsRefresh)))
+
+	// === Metrics (Prometheus scrape) ===
+	s.route("/metrics", loggingMiddleware(s.metricsAuthMiddleware(s.handleMetrics)))
 
 	// === Workflows (Block F) ===
-	s.mux.HandleFunc("/workflows", loggingMiddleware(s.authMiddleware(s.handleListWorkflows)))
-	s.mux.HandleFunc("/workflows/", loggingMiddleware(s.authMiddleware(s.routeWorkflow)))
+	s.route("/workflows", loggingMiddleware(s.authMiddleware(s.handleListWorkflows)))
+	s.route("/workflows/", loggingMiddleware(s.authMiddleware(s.routeWorkflow)))
 
 	// === Feishu inbound ===
-	s.mux.HandleFunc("/feishu/webhook", loggingMiddleware(s.handleFeishuWebhook))
-	s.mux.HandleFunc("/assistant", loggingMiddleware(s.authMiddleware(jsonContentTypeMiddleware(s.handleAssistant))))
+	s.route("/feishu/webhook", loggingMiddleware(s.handleFeishuWebhook))
+	s.route("/assistant", loggingMiddleware(s.authMiddleware(jsonContentTypeMiddleware(s.handleAssistant))))
+
+	// Audit log of state-changing requests and MCP tool calls (admin scope)
+	s.route("/audit", loggingMiddleware(s.authMiddleware(s.adminMiddleware(s.handleAudit))))
+
+	// Webhook delivery targets (admin scope: URLs carry secrets)
+	s.route("/webhooks", loggingMiddleware(s.authMiddleware(s.adminMiddleware(s.routeWebhooks))))
+	s.route("/webhooks/", loggingMiddleware(s.authMiddleware(s.adminMiddleware(s.routeWebhookByID))))
+
+	// Host actions (admin scope)
+	s.route("/host/sessions", loggingMiddleware(s.authMiddleware(s.adminMiddleware(jsonContentTypeMiddleware(s.handleStartHostSession)))))
+
+	// Remote hosts and their codes serve APIs (admin scope)
+	s.route("/remotes", loggingMiddleware(s.authMiddleware(s.adminMiddleware(s.routeRemotes))))
+	s.route("/remotes/", loggingMiddleware(s.authMiddleware(s.adminMiddleware(s.routeRemoteByName))))
+}
+
+// route registers an API handler, counting its requests for /metrics and
+// auditing those that change state. The patterns are checked against the
+// OpenAPI document in tests.
+func (s *HTTPServer) route(pattern string, handler http.HandlerFunc) {
+	s.patterns = append(s.patterns, pattern)
+	s.mux.HandleFunc(pattern, s.metrics.instrument(pattern, s.audited(handler)))
 }
 
 // --- Route dispatchers for multi-method / sub-path endpoints ---
@@ -74,7 +157,7 @@ func (s *HTTPServer) routeSessions(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		s.handleListSessions(w, r)
 	case http.MethodPost:
-		jsonContentTypeMiddleware(s.handleCreateSession)(w, r)
+		jsonContentTypeMiddleware(s.idempotent(s.handleCreateSession))(w, r)
 	default:
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
@@ -108,6 +191,12 @@ func (s *HTTPServer) routeSessionByID(w http.ResponseWriter, r *http.Request) {
 			jsonContentTypeMiddleware(s.handleResumeSession)(w, r)
 		case "message":
 			jsonContentTypeMiddleware(s.handleSessionMessage)(w, r)
+		case "messages":
+			s.handleSessionMessages(w, r)
+		case "transcript":
+			s.handleSessionTranscript(w, r)
+		case "share":
+			s.handleShareSession(w, r)
 		default:
 			respondError(w, http.StatusNotFound, "unknown session action: "+action)
 		}
@@ -123,7 +212,7 @@ func (s *HTTPServer) routeTeams(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		s.handleListTeams(w, r)
 	case http.MethodPost:
-		jsonContentTypeMiddleware(s.handleCreateTeam)(w, r)
+		jsonContentTypeMiddleware(s.idempotent(s.handleCreateTeam))(w, r)
 	default:
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
@@ -154,7 +243,7 @@ func (s *HTTPServer) routeTeamByName(w http.ResponseWriter, r *http.Request) {
 			case http.MethodGet:
 				s.handleListTeamTasks(w, r)
 			case http.MethodPost:
-				jsonContentTypeMiddleware(s.handleCreateTeamTask)(w, r)
+				jsonContentTypeMiddleware(s.idempotent(s.handleCreateTeamTask))(w, r)
 			default:
 				respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 			}
@@ -173,18 +262,37 @@ func (s *HTTPServer) routeTeamByName(w http.ResponseWriter, r *http.Request) {
 			s.handleStopTeamAgents(w, r)
 		case "activity":
 			s.handleTeamActivity(w, r)
+		case "questions":
+			s.handleListQuestions(w, r)
 		default:
 			respondError(w, http.StatusNotFound, "unknown team sub-resource: "+sub)
 		}
 
 	case 4:
-		// /teams/{name}/tasks/{id}
-		if parts[2] == "tasks" {
+		// /teams/{name}/tasks/batch, /teams/{name}/tasks/{id}
+		if parts[2] == "tasks" && parts[3] == "batch" {
+			jsonContentTypeMiddleware(s.idempotent(s.handleCreateTeamTaskBatch))(w, r)
+		} else if parts[2] == "tasks" {
 			s.handleUpdateTeamTask(w, r)
 		} else {
 			respondError(w, http.StatusNotFound, "not found")
 		}
 
+	case 5, 6:
+		// /teams/{name}/agents/{agent}/logs
+		if len(parts) == 5 && parts[2] == "agents" && parts[4] == "logs" {
+			s.handleAgentLogs(w, r)
+			return
+		}
+		// /teams/{name}/tasks/{id}/artifacts[/{file}]
+		if parts[2] != "tasks" || parts[4] != "artifacts" {
+			respondError(w, http.StatusNotFound, "not found")
+		} else if len(parts) == 5 {
+			s.handleListTaskArtifacts(w, r)
+		} else {
+			s.handleGetTaskArtifact(w, r)
+		}
+
 	default:
 		respondError(w, http.StatusBadRequest, "invalid path")
 	}
@@ -210,26 +318,43 @@ func (s *HTTPServer) routeWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// ListenAndServe starts the HTTP server on the given address and registers
-// a Bonjour/mDNS service so iOS clients can discover it automatically.
+// ListenAndServe starts the HTTP server (HTTPS after SetTLS) on the given
+// address and registers a Bonjour/mDNS service so iOS clients can discover
+// it automatically.
 func (s *HTTPServer) ListenAndServe(addr string) error {
+	scheme := "http"
+	if s.tlsConfig != nil {
+		scheme = "https"
+	}
 	if port := parsePort(addr); port > 0 {
-		stop := startMDNS(port, s.version)
+		stop := startMDNS(port, s.version, scheme)
 		defer stop()
 	}
-	log.Printf("[HTTP] Starting server on %s", addr)
-	log.Printf("[HTTP] Registered %d valid tokens", len(s.tokens))
-	s.srv = &http.Server{Addr: addr, Handler: s.mux}
+	log.Printf("[HTTP] Starting %s server on %s", scheme, addr)
+	log.Printf("[HTTP] Registered %d valid tokens", len(s.tokens)+len(s.adminTokens)+len(s.scopedTokens))
+	s.srv = &http.Server{Addr: addr, Handler: s.Handler(), TLSConfig: s.tlsConfig}
+	if s.tlsConfig != nil {
+		return s.srv.ListenAndServeTLS("", "")
+	}
 	return s.srv.ListenAndServe()
 }
 
+// Handler returns the server's routes with request IDs, CORS, rate
+// limiting, response compression and version checking, for serving on a
+// caller-provided listener (e.g. an ephemeral port in `codes selftest`).
+func (s *HTTPServer) Handler() http.Handler {
+	return requestIDMiddleware(s.corsMiddleware(s.limitMiddleware(compressMiddleware(s.versionMiddleware(s.mux)))))
+}
+
 // Handle registers an additional handler on the server mux before ListenAndServe is called.
 func (s *HTTPServer) Handle(pattern string, handler http.Handler) {
 	s.mux.Handle(pattern, handler)
 }
 
-// Shutdown gracefully stops the HTTP server.
+// Shutdown gracefully stops the HTTP server and closes the SSH tunnels to
+// remote hosts.
 func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	s.closeRemotes()
 	if s.srv == nil {
 		return nil
 	}
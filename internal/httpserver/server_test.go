@@ -102,6 +102,46 @@ func TestJSONContentTypeMiddleware(t *testing.T) {
 	}
 }
 
+// TestVersionMiddleware tests the version handshake header
+func TestVersionMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		clientVersion  string
+		expectedStatus int
+	}{
+		{"No client version", "/test", "", http.StatusOK},
+		{"Same version", "/test", "v1.4.0", http.StatusOK},
+		{"Minor skew", "/test", "v1.2.0", http.StatusOK},
+		{"Major skew", "/test", "v2.0.0", http.StatusUpgradeRequired},
+		{"Major skew on health", "/health", "v2.0.0", http.StatusOK},
+	}
+
+	server := NewHTTPServer([]string{"test-token"}, "v1.4.0")
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.clientVersion != "" {
+				req.Header.Set("X-Codes-Version", tt.clientVersion)
+			}
+
+			w := httptest.NewRecorder()
+			server.versionMiddleware(testHandler).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("X-Codes-Version"); got != "v1.4.0" {
+				t.Errorf("Expected server version header, got %q", got)
+			}
+		})
+	}
+}
+
 // TestMethodNotAllowed tests that endpoints reject wrong HTTP methods
 func TestMethodNotAllowed(t *testing.T) {
 	server := NewHTTPServer([]string{"test-token"}, "test")
@@ -129,3 +169,45 @@ func TestMethodNotAllowed(t *testing.T) {
 		})
 	}
 }
+
+// TestSchemaEndpoints tests /schemas and /schemas/{file}, which need no auth.
+func TestSchemaEndpoints(t *testing.T) {
+	server := NewHTTPServer([]string{"test-token"}, "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/schemas", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /schemas: status %d", w.Code)
+	}
+	var list SchemaListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Schemas) == 0 {
+		t.Fatal("GET /schemas: no schemas listed")
+	}
+
+	for _, s := range list.Schemas {
+		req := httptest.NewRequest(http.MethodGet, "/schemas/"+s.File, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("GET /schemas/%s: status %d", s.File, w.Code)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/schema+json" {
+			t.Errorf("GET /schemas/%s: Content-Type = %q", s.File, ct)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Errorf("GET /schemas/%s: body is not JSON", s.File)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/schemas/missing.v1.json", nil)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /schemas/missing.v1.json: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,184 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"codes/internal/chatsession"
+)
+
+// Share links: POST /sessions/{id}/share returns a token that lets whoever
+// holds it watch that one session, without an API token. The token is
+// "share.<session>.<expiry>.<signature>", signed with the server's share key
+// (~/.codes/share.key, created on first use), so nothing needs storing and
+// links outlive restarts. authMiddleware accepts it as a Bearer token, a
+// WebSocket subprotocol or a ?share= query parameter, and its grant only
+// allows reading the session, its messages and transcript, and a WebSocket
+// that cannot send anything. Deleting the key file revokes every link.
+
+const (
+	shareTokenPrefix = "share."
+	defaultShareTTL  = 24 * time.Hour
+	maxShareTTL      = 30 * 24 * time.Hour
+)
+
+// shareActions are the /sessions/{id}/{action} paths a share link may read.
+var shareActions = map[string]bool{"ws": true, "messages": true, "transcript": true}
+
+// shareKeyPath returns ~/.codes/share.key.
+func shareKeyPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codes", "share.key"), nil
+}
+
+// loadShareKey returns the share key, creating it on first use.
+func (s *HTTPServer) loadShareKey() ([]byte, error) {
+	s.shareKeyMu.Lock()
+	defer s.shareKeyMu.Unlock()
+	if s.shareKey != nil {
+		return s.shareKey, nil
+	}
+	path, err := shareKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if key, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil && len(key) >= 32 {
+			s.shareKey = key
+			return key, nil
+		}
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	s.shareKey = key
+	return key, nil
+}
+
+// signShare returns a share token for session id that expires at expires.
+func (s *HTTPServer) signShare(id string, expires time.Time) (string, error) {
+	key, err := s.loadShareKey()
+	if err != nil {
+		return "", err
+	}
+	payload := shareTokenPrefix + id + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + shareSignature(key, payload), nil
+}
+
+func shareSignature(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// shareGrant checks a share token and returns its grant.
+func (s *HTTPServer) shareGrant(token string) (*grant, error) {
+	payload, sig, ok := cutLast(token, ".")
+	id, expiry, ok2 := cutLast(strings.TrimPrefix(payload, shareTokenPrefix), ".")
+	if !ok || !ok2 || id == "" {
+		return nil, errors.New("invalid share link")
+	}
+	key, err := s.loadShareKey()
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(sig), []byte(shareSignature(key, payload))) {
+		return nil, errors.New("invalid share link")
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return nil, errors.New("share link expired")
+	}
+	return &grant{name: "share link", session: id}, nil
+}
+
+// cutLast splits s around the last sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// shareAllows reports whether a share link for session may make request r:
+// GET /sessions/{session} and its ws, messages and transcript.
+func shareAllows(session string, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "sessions" || parts[1] != session {
+		return false
+	}
+	return len(parts) == 2 || (len(parts) == 3 && shareActions[parts[2]])
+}
+
+// handleShareSession handles POST /sessions/{id}/share.
+func (s *HTTPServer) handleShareSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := extractSessionIDFromAction(r.URL.Path, "share")
+	if id == "" {
+		respondError(w, http.StatusBadRequest, "invalid path")
+		return
+	}
+	if _, ok := chatsession.DefaultManager.Get(id); !ok {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("session %s not found", id))
+		return
+	}
+
+	// The body is optional
+	var req SessionShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+	ttl := defaultShareTTL
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 || d > maxShareTTL {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid expires_in %q: want a duration up to %s, e.g. 2h", req.ExpiresIn, maxShareTTL))
+			return
+		}
+		ttl = d
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	token, err := s.signShare(id, expires)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to sign share link: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusCreated, SessionShareResponse{
+		Token:          token,
+		ExpiresAt:      expires,
+		WebSocketPath:  "/sessions/" + id + "/ws?share=" + token,
+		TranscriptPath: "/sessions/" + id + "/transcript?share=" + token,
+	})
+}
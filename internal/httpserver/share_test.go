@@ -0,0 +1,97 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"codes/internal/chatsession"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSessionShareLink(t *testing.T) {
+	server := setupSessionTest(t)
+	ts := httptest.NewServer(server.mux)
+	defer ts.Close()
+
+	sess, _ := chatsession.DefaultManager.Create("shared", "/tmp/test", "")
+	defer chatsession.DefaultManager.Delete(sess.ID)
+	other, _ := chatsession.DefaultManager.Create("other", "/tmp/test", "")
+	defer chatsession.DefaultManager.Delete(other.ID)
+
+	if w := doReq(t, server, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/share", SessionShareRequest{ExpiresIn: "forever"})); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid expires_in: %d, want 400", w.Code)
+	}
+	w := doReq(t, server, authedReq(t, http.MethodPost, "/sessions/"+sess.ID+"/share", SessionShareRequest{ExpiresIn: "2h"}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("share: %d %s", w.Code, w.Body.String())
+	}
+	var share SessionShareResponse
+	decodeJSON(t, w, &share)
+	if !strings.HasPrefix(share.Token, shareTokenPrefix) || time.Until(share.ExpiresAt) > 2*time.Hour || time.Until(share.ExpiresAt) < time.Hour {
+		t.Fatalf("share = %+v", share)
+	}
+
+	as := func(token, method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return doReq(t, server, req).Code
+	}
+	for _, path := range []string{"/sessions/" + sess.ID, "/sessions/" + sess.ID + "/messages", "/sessions/" + sess.ID + "/transcript"} {
+		if code := as(share.Token, http.MethodGet, path); code != http.StatusOK {
+			t.Errorf("GET %s with the share link: %d, want 200", path, code)
+		}
+	}
+	if code := as("", http.MethodGet, share.TranscriptPath); code != http.StatusOK {
+		t.Errorf("GET %s: %d, want 200", share.TranscriptPath, code)
+	}
+	if code := as("", http.MethodGet, "/sessions/"+sess.ID+"?share=test-token"); code != http.StatusUnauthorized {
+		t.Errorf("API token in the URL: %d, want 401", code)
+	}
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodGet, "/sessions"},
+		{http.MethodGet, "/sessions/" + other.ID},
+		{http.MethodGet, "/teams"},
+		{http.MethodPost, "/sessions/" + sess.ID + "/message"},
+		{http.MethodPost, "/sessions/" + sess.ID + "/share"},
+		{http.MethodDelete, "/sessions/" + sess.ID},
+	} {
+		if code := as(share.Token, tt.method, tt.path); code != http.StatusForbidden {
+			t.Errorf("%s %s with the share link: %d, want 403", tt.method, tt.path, code)
+		}
+	}
+
+	// Tampered, expired, and another server with the same key
+	if code := as(strings.Replace(share.Token, sess.ID, other.ID, 1), http.MethodGet, "/sessions/"+other.ID); code != http.StatusUnauthorized {
+		t.Errorf("link edited to another session: %d, want 401", code)
+	}
+	expired, err := server.signShare(sess.ID, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := as(expired, http.MethodGet, "/sessions/"+sess.ID); code != http.StatusUnauthorized {
+		t.Errorf("expired link: %d, want 401", code)
+	}
+	if _, err := NewHTTPServer([]string{"test-token"}, "test").shareGrant(share.Token); err != nil {
+		t.Errorf("link after a restart: %v", err)
+	}
+
+	// The WebSocket only watches
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+share.WebSocketPath, nil)
+	if err != nil {
+		t.Fatalf("WebSocket with the share link: %v", err)
+	}
+	defer conn.Close()
+	readWSMsg(t, conn) // initial status
+	if err := conn.WriteJSON(map[string]string{"type": "user_message", "content": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readWSMsg(t, conn); msg.Type != "error" || !strings.Contains(msg.Message, "read-only") {
+		t.Errorf("message over a shared WebSocket: %+v", msg)
+	}
+}
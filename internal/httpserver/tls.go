@@ -0,0 +1,177 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SetTLS makes ListenAndServe serve HTTPS with cfg (see LoadTLSConfig).
+func (s *HTTPServer) SetTLS(cfg *tls.Config) {
+	s.tlsConfig = cfg
+}
+
+// LoadTLSConfig builds the server TLS config from a PEM certificate and key.
+// With clientCAFile set, clients must present a certificate signed by one of
+// the CAs in it (mutual TLS); bearer tokens are still required on top.
+func LoadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		data, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("client CA %s contains no PEM certificates", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// selfSignedValidity is how long a generated certificate is valid. Apple
+// platforms reject server certificates valid for more than 825 days.
+const selfSignedValidity = 825 * 24 * time.Hour
+
+// EnsureSelfSignedCert returns server.crt and server.key in dir, generating
+// a self-signed certificate for this machine's names and addresses when
+// there is none, it expires within 30 days, or it no longer covers one of
+// the addresses. The key is only readable by the owner.
+func EnsureSelfSignedCert(dir string) (certFile, keyFile string, err error) {
+	certFile = filepath.Join(dir, "server.crt")
+	keyFile = filepath.Join(dir, "server.key")
+	names, ips := localNames()
+	if certCovers(certFile, names, ips, time.Now().Add(30*24*time.Hour)) {
+		return certFile, keyFile, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: names[0], Organization: []string{"codes serve (self-signed)"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              names,
+		IPAddresses:           ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// localNames returns the host names and IP addresses a certificate for this
+// machine should cover: localhost, the hostname and its .local mDNS name,
+// and every interface address.
+func localNames() ([]string, []net.IP) {
+	names := []string{"localhost"}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		host = strings.TrimSuffix(host, ".local")
+		names = append([]string{host}, names...)
+		names = append(names, host+".local")
+	}
+	ips := []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && !ipnet.IP.IsLinkLocalUnicast() {
+			ips = append(ips, ipnet.IP)
+		}
+	}
+	return names, ips
+}
+
+// certCovers reports whether the certificate in file is valid until after
+// and lists all names and ips.
+func certCovers(file string, names []string, ips []net.IP, after time.Time) bool {
+	cert, err := readCert(file)
+	if err != nil || cert.NotAfter.Before(after) {
+		return false
+	}
+	for _, n := range names {
+		if cert.VerifyHostname(n) != nil {
+			return false
+		}
+	}
+	for _, ip := range ips {
+		if cert.VerifyHostname(ip.String()) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func readCert(file string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s: no PEM certificate", file)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// CertFingerprint returns the SHA-256 fingerprint of the certificate in file,
+// as colon-separated hex, for pinning a self-signed certificate in clients.
+func CertFingerprint(file string) (string, error) {
+	cert, err := readCert(file)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(cert.Raw)
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	parts := make([]string, 0, len(sum))
+	for i := 0; i < len(h); i += 2 {
+		parts = append(parts, h[i:i+2])
+	}
+	return strings.Join(parts, ":"), nil
+}
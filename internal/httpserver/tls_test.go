@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := EnsureSelfSignedCert(dir)
+	if err != nil {
+		t.Fatalf("EnsureSelfSignedCert: %v", err)
+	}
+	cert, err := readCert(certFile)
+	if err != nil {
+		t.Fatalf("readCert: %v", err)
+	}
+	for _, host := range []string{"localhost", "127.0.0.1", "::1"} {
+		if err := cert.VerifyHostname(host); err != nil {
+			t.Errorf("certificate does not cover %s: %v", host, err)
+		}
+	}
+	if info, _ := os.Stat(keyFile); info.Mode().Perm() != 0600 {
+		t.Errorf("key mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A valid certificate is reused
+	before, _ := os.ReadFile(certFile)
+	if _, _, err := EnsureSelfSignedCert(dir); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(certFile); string(after) != string(before) {
+		t.Error("certificate regenerated although still valid")
+	}
+
+	fp, err := CertFingerprint(certFile)
+	if err != nil || len(fp) != 95 || strings.Count(fp, ":") != 31 {
+		t.Errorf("CertFingerprint = %q, %v", fp, err)
+	}
+	if _, err := LoadTLSConfig(certFile, keyFile, ""); err != nil {
+		t.Errorf("LoadTLSConfig: %v", err)
+	}
+}
+
+// writeTestCA writes a CA certificate to dir and returns a client
+// certificate it signed.
+func writeTestCA(t *testing.T, dir string) (caFile string, client tls.Certificate) {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	caFile = filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0644)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return caFile, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := EnsureSelfSignedCert(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile, clientCert := writeTestCA(t, dir)
+	cfg, err := LoadTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig: %v", err)
+	}
+
+	server := NewHTTPServer([]string{"test-token"}, "test")
+	ts := httptest.NewUnstartedServer(server.Handler())
+	ts.TLS = cfg
+	ts.StartTLS()
+	defer ts.Close()
+
+	serverCert, _ := readCert(certFile)
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert)
+	get := func(certs []tls.Certificate) (*http.Response, error) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+			ServerName:   "localhost",
+		}}}
+		return c.Get(ts.URL + "/health")
+	}
+
+	if resp, err := get(nil); err == nil {
+		resp.Body.Close()
+		t.Error("request without a client certificate succeeded")
+	}
+	resp, err := get([]tls.Certificate{clientCert})
+	if err != nil {
+		t.Fatalf("request with a client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d", resp.StatusCode)
+	}
+
+	if _, err := LoadTLSConfig(certFile, keyFile, certFile+".missing"); err == nil {
+		t.Error("missing client CA accepted")
+	}
+}
@@ -1,76 +1,100 @@
 package httpserver
 
-import "time"
+import "codes/pkg/client"
 
-// TaskResponse represents the task status response
-type TaskResponse struct {
-	ID          int       `json:"id"`
-	Subject     string    `json:"subject"`
-	Description string    `json:"description,omitempty"`
-	Status      string    `json:"status"`
-	Priority    string    `json:"priority,omitempty"`
-	Owner       string    `json:"owner,omitempty"`
-	Project     string    `json:"project,omitempty"`
-	WorkDir     string    `json:"work_dir,omitempty"`
-	Result      string    `json:"result,omitempty"`
-	Error       string    `json:"error,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
-}
+// The API's request and response bodies live in pkg/client so Go programs
+// can share them; these aliases keep the handlers' names short.
 
-// TeamListResponse represents the teams list response
-type TeamListResponse struct {
-	Teams []TeamSummary `json:"teams"`
-}
+// General
+type (
+	TaskResponse       = client.TaskResponse
+	TeamListResponse   = client.TeamListResponse
+	TeamSummary        = client.TeamSummary
+	TeamDetailResponse = client.TeamDetailResponse
+	TeamMember         = client.TeamMember
+	ErrorResponse      = client.ErrorResponse
+	HealthResponse     = client.HealthResponse
+	StatusResponse     = client.StatusResponse
+	AssistantRequest   = client.AssistantRequest
+	AssistantResponse  = client.AssistantResponse
+	AuditEntry         = client.AuditEntry
+	AuditResponse      = client.AuditResponse
+)
 
-// TeamSummary represents a summary of a team
-type TeamSummary struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	MemberCount int       `json:"member_count"`
-	CreatedAt   time.Time `json:"created_at"`
-}
+// Webhooks
+type (
+	Webhook              = client.Webhook
+	WebhookListResponse  = client.WebhookListResponse
+	CreateWebhookRequest = client.CreateWebhookRequest
+)
 
-// TeamDetailResponse represents detailed team information
-type TeamDetailResponse struct {
-	Name        string        `json:"name"`
-	Description string        `json:"description,omitempty"`
-	WorkDir     string        `json:"work_dir,omitempty"`
-	Members     []TeamMember  `json:"members"`
-	CreatedAt   time.Time     `json:"created_at"`
-}
+// Remotes
+type (
+	RemoteHostInfo      = client.RemoteHostInfo
+	RemoteHostStatus    = client.RemoteHostStatus
+	RemoteListResponse  = client.RemoteListResponse
+	RemoteSetupRequest  = client.RemoteSetupRequest
+	RemoteSetupResponse = client.RemoteSetupResponse
+)
 
-// TeamMember represents a team member with status
-type TeamMember struct {
-	Name   string `json:"name"`
-	Role   string `json:"role,omitempty"`
-	Model  string `json:"model,omitempty"`
-	Type   string `json:"type,omitempty"`
-	Status string `json:"status,omitempty"` // Agent status: "idle", "running", "stopped"
-	PID    int    `json:"pid,omitempty"`
-}
+// Sessions
+type (
+	CreateSessionRequest       = client.CreateSessionRequest
+	ResumeSessionRequest       = client.ResumeSessionRequest
+	SessionSendMessageRequest  = client.SessionSendMessageRequest
+	SessionAttachment          = client.SessionAttachment
+	SessionShareRequest        = client.SessionShareRequest
+	SessionShareResponse       = client.SessionShareResponse
+	SessionResponse            = client.SessionResponse
+	SessionListResponse        = client.SessionListResponse
+	SessionMessage             = client.SessionMessage
+	SessionMessageListResponse = client.SessionMessageListResponse
+	StartHostSessionRequest    = client.StartHostSessionRequest
+	HostSessionResponse        = client.HostSessionResponse
+)
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
+// Teams, tasks and messages
+type (
+	CreateTeamRequest       = client.CreateTeamRequest
+	CreateTaskRequest       = client.CreateTaskRequest
+	CreateTaskBatchRequest  = client.CreateTaskBatchRequest
+	BatchTaskRequest        = client.BatchTaskRequest
+	CreateTaskBatchResponse = client.CreateTaskBatchResponse
+	Notification            = client.Notification
+	NotificationsResponse   = client.NotificationsResponse
+	UpdateTaskRequest       = client.UpdateTaskRequest
+	SendMessageRequest      = client.SendMessageRequest
+	TaskListResponse        = client.TaskListResponse
+	MessageListResponse     = client.MessageListResponse
+	MessageResponse         = client.MessageResponse
+	TeamActivityResponse    = client.TeamActivityResponse
+	MemberActivity          = client.MemberActivity
+	TaskStats               = client.TaskStats
+	StartTeamResponse       = client.StartTeamResponse
+	AgentStartResponse      = client.AgentStartResponse
+	ArtifactListResponse    = client.ArtifactListResponse
+	StopTeamResponse        = client.StopTeamResponse
+	AgentStopResponse       = client.AgentStopResponse
+	AgentLogsResponse       = client.AgentLogsResponse
+)
 
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status  string `json:"status"`
-	Version string `json:"version,omitempty"`
-}
+// Projects and profiles
+type (
+	ProjectListResponse   = client.ProjectListResponse
+	ProjectInfoResponse   = client.ProjectInfoResponse
+	CreateProjectRequest  = client.CreateProjectRequest
+	ProfileListResponse   = client.ProfileListResponse
+	ProfileInfo           = client.ProfileInfo
+	CreateProfileRequest  = client.CreateProfileRequest
+	UpdateProfileRequest  = client.UpdateProfileRequest
+	SwitchProfileRequest  = client.SwitchProfileRequest
+	SwitchProfileResponse = client.SwitchProfileResponse
+)
 
-// AssistantRequest is the request body for POST /assistant
-type AssistantRequest struct {
-	Text      string `json:"text"`                 // User message
-	SessionID string `json:"session_id,omitempty"` // Conversation session (default: "default")
-	Model     string `json:"model,omitempty"`      // Override model
-}
-
-// AssistantResponse is the response body for POST /assistant
-type AssistantResponse struct {
-	Reply     string `json:"reply"`
-	SessionID string `json:"session_id"`
-}
+// Workflows
+type (
+	WorkflowListResponse = client.WorkflowListResponse
+	WorkflowSummary      = client.WorkflowSummary
+	RunWorkflowRequest   = client.RunWorkflowRequest
+	RunWorkflowResponse  = client.RunWorkflowResponse
+)
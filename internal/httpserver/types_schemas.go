@@ -0,0 +1,8 @@
+package httpserver
+
+import "codes/pkg/schemas"
+
+// SchemaListResponse lists the published payload schemas.
+type SchemaListResponse struct {
+	Schemas []schemas.Schema `json:"schemas"`
+}
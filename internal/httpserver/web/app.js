@@ -0,0 +1,360 @@
+// codes dashboard: a thin client of the REST API and the session WebSocket.
+// No build step and no dependencies; served from the binary by `codes serve`.
+"use strict";
+
+const TOKEN_KEY = "codes.token";
+const POLL_MS = 5000;
+
+// Kanban columns and the task statuses they hold.
+const COLUMNS = [
+  ["Pending", ["pending", "assigned"]],
+  ["Running", ["running"]],
+  ["Completed", ["completed"]],
+  ["Failed", ["failed", "cancelled"]],
+];
+
+const state = {
+  token: localStorage.getItem(TOKEN_KEY) || "",
+  view: "teams",
+  team: null,
+  session: null,
+  socket: null,
+  timer: null,
+};
+
+const $ = (sel) => document.querySelector(sel);
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    if (k === "class") node.className = v;
+    else if (k.startsWith("on")) node.addEventListener(k.slice(2), v);
+    else node.setAttribute(k, v);
+  }
+  for (const child of children.flat()) {
+    if (child == null || child === false) continue;
+    node.append(child instanceof Node ? child : document.createTextNode(String(child)));
+  }
+  return node;
+}
+
+function statusBadge(status) {
+  return el("span", { class: "status status-" + status }, status);
+}
+
+// api calls the REST API with the stored token; a 401 sends the user back to
+// the sign-in form.
+async function api(method, path, body) {
+  const opts = { method, headers: { Authorization: "Bearer " + state.token } };
+  if (body !== undefined) {
+    opts.headers["Content-Type"] = "application/json";
+    opts.body = JSON.stringify(body);
+  }
+  const resp = await fetch(path, opts);
+  const data = await resp.json().catch(() => ({}));
+  if (resp.status === 401) {
+    signOut();
+    throw new Error(data.error || "unauthorized");
+  }
+  if (!resp.ok) throw new Error(data.error || resp.statusText);
+  return data;
+}
+
+// --- Sign in ---
+
+async function signIn(token) {
+  state.token = token;
+  await api("GET", "/teams?limit=1");
+  localStorage.setItem(TOKEN_KEY, token);
+}
+
+function signOut() {
+  state.token = "";
+  localStorage.removeItem(TOKEN_KEY);
+  closeSocket();
+  clearInterval(state.timer);
+  show("login");
+}
+
+$("#login-form").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  $("#login-error").textContent = "";
+  try {
+    await signIn($("#token").value.trim());
+    $("#token").value = "";
+    route();
+  } catch (err) {
+    $("#login-error").textContent = err.message;
+  }
+});
+
+$("#logout").addEventListener("click", signOut);
+
+// --- Navigation ---
+
+function show(view) {
+  for (const section of document.querySelectorAll("main > section")) {
+    section.hidden = section.id !== view;
+  }
+  for (const link of document.querySelectorAll("nav a")) {
+    link.classList.toggle("active", link.dataset.view === view);
+  }
+  $("#logout").hidden = view === "login";
+}
+
+function route() {
+  if (!state.token) {
+    show("login");
+    return;
+  }
+  state.view = location.hash === "#sessions" ? "sessions" : "teams";
+  show(state.view);
+  clearInterval(state.timer);
+  refresh();
+  state.timer = setInterval(refresh, POLL_MS);
+}
+
+window.addEventListener("hashchange", route);
+
+async function refresh() {
+  try {
+    if (state.view === "teams") await refreshTeams();
+    else await refreshSessions();
+  } catch (err) {
+    console.error(err);
+  }
+}
+
+// --- Teams: agents and task kanban ---
+
+async function refreshTeams() {
+  const { teams } = await api("GET", "/teams?sort=name");
+  const list = $("#team-list");
+  list.replaceChildren(...teams.map((t) =>
+    el("li", {
+      class: t.name === state.team ? "selected" : "",
+      onclick: () => { state.team = t.name; refreshTeams(); },
+    }, t.name, el("small", {}, `${t.member_count} agents`))));
+  if (!teams.length) list.append(el("li", { class: "empty" }, "No teams yet"));
+
+  if (state.team && teams.some((t) => t.name === state.team)) {
+    await renderTeam(state.team);
+  }
+}
+
+async function renderTeam(name) {
+  // Redrawing would throw away an answer being typed
+  if (document.activeElement && document.activeElement.matches(".questions input")) return;
+  const team = encodeURIComponent(name);
+  const [activity, { tasks }, { tasks: questions }] = await Promise.all([
+    api("GET", `/teams/${team}/activity`),
+    api("GET", `/teams/${team}/tasks?sort=-updated_at&limit=200`),
+    api("GET", `/teams/${team}/questions`),
+  ]);
+  if (state.team !== name) return;
+
+  const stats = activity.task_stats;
+  const agents = el("div", { class: "agents" }, activity.members.map((m) =>
+    el("div", { class: "agent" },
+      el("strong", {}, m.name), " ", statusBadge(m.status),
+      el("div", { class: "activity" },
+        m.current_task ? `#${m.current_task} ` : "",
+        m.activity || m.role || ""))));
+
+  const kanban = el("div", { class: "kanban" }, COLUMNS.map(([title, statuses]) => {
+    const cards = tasks.filter((t) => statuses.includes(t.status));
+    return el("div", { class: "column" },
+      el("h3", {}, `${title} (${cards.length})`),
+      cards.map(taskCard));
+  }));
+
+  $("#team-detail").replaceChildren(
+    el("h2", {}, name),
+    el("p", { class: "empty" },
+      `${stats.total} tasks: ${stats.pending} pending, ${stats.running} running, ` +
+      `${stats.completed} completed, ${stats.failed} failed`),
+    ...(questions.length ? [
+      el("h3", {}, `Questions for you (${questions.length})`),
+      el("div", { class: "questions" }, questions.map((q) => questionCard(name, q))),
+    ] : []),
+    el("h3", {}, "Agents"),
+    activity.members.length ? agents : el("p", { class: "empty" }, "No agents"),
+    el("h3", {}, "Tasks"),
+    kanban);
+}
+
+function taskCard(t) {
+  return el("div", { class: "card" },
+    el("div", {}, `#${t.id} ${t.subject}`),
+    el("div", { class: "meta" },
+      [t.owner, t.priority, t.status === "cancelled" ? "cancelled" : ""].filter(Boolean).join(" · ")),
+    t.error ? el("div", { class: "failure" }, t.error) : null);
+}
+
+// questionCard shows a human task waiting for an answer, with a button per
+// choice or a text field when any answer goes.
+function questionCard(team, q) {
+  const error = el("div", { class: "failure" });
+  const answer = async (text) => {
+    if (!text) return;
+    try {
+      await api("PATCH", `/teams/${encodeURIComponent(team)}/tasks/${q.id}`, { action: "answer", answer: text });
+      document.activeElement.blur();
+      await renderTeam(team);
+    } catch (err) {
+      error.textContent = err.message;
+    }
+  };
+
+  let controls;
+  if (q.choices && q.choices.length) {
+    controls = q.choices.map((c) => el("button", { onclick: () => answer(c) }, c));
+  } else {
+    const input = el("input", { placeholder: "Your answer" });
+    input.addEventListener("keydown", (e) => {
+      if (e.key === "Enter") answer(input.value.trim());
+    });
+    controls = [input, el("button", { onclick: () => answer(input.value.trim()) }, "Answer")];
+  }
+  return el("div", { class: "card question" },
+    el("div", {}, `#${q.id} ${q.subject}`),
+    q.description ? el("div", { class: "meta" }, q.description) : null,
+    el("div", { class: "composer" }, controls),
+    error);
+}
+
+// --- Chat sessions ---
+
+async function refreshSessions() {
+  const { sessions } = await api("GET", "/sessions?sort=-last_active_at");
+  const list = $("#session-list");
+  list.replaceChildren(...sessions.map((s) =>
+    el("li", {
+      class: s.id === state.session ? "selected" : "",
+      onclick: () => openSession(s),
+    }, s.project_name || s.project_path, " ", statusBadge(s.status),
+    el("small", {}, `${s.turn_count} turns · $${s.cost_usd.toFixed(2)}`))));
+  if (!sessions.length) list.append(el("li", { class: "empty" }, "No chat sessions"));
+}
+
+function closeSocket() {
+  if (state.socket) {
+    state.socket.onclose = null;
+    state.socket.close();
+    state.socket = null;
+  }
+}
+
+function openSession(s) {
+  closeSocket();
+  state.session = s.id;
+  refreshSessions();
+
+  const transcript = el("div", { class: "transcript" });
+  const status = el("span", {}, statusBadge(s.status));
+  const input = el("textarea", { placeholder: "Message Claude… (paste a screenshot to attach it)", rows: "2" });
+  const pending = el("div", { class: "attachments" });
+  let attachments = [];
+  const showPending = () => pending.replaceChildren(...attachments.map((a, i) =>
+    el("span", { class: "attachment" }, a.name, " ",
+      el("button", { title: "Remove", onclick: () => { attachments.splice(i, 1); showPending(); } }, "×"))));
+  const attach = (files) => {
+    for (const file of files) {
+      const reader = new FileReader();
+      reader.onload = () => {
+        // Strip the "data:<type>;base64," prefix
+        const data = String(reader.result).split(",")[1] || "";
+        attachments.push({ name: file.name || "pasted.png", media_type: file.type, data });
+        showPending();
+      };
+      reader.readAsDataURL(file);
+    }
+  };
+  const picker = el("input", { type: "file", multiple: "", hidden: "" });
+  picker.addEventListener("change", () => { attach(picker.files); picker.value = ""; });
+  input.addEventListener("paste", (e) => {
+    const files = [...(e.clipboardData ? e.clipboardData.files : [])];
+    if (files.length) { e.preventDefault(); attach(files); }
+  });
+  const send = () => {
+    const content = input.value.trim();
+    if ((!content && !attachments.length) || !state.socket) return;
+    state.socket.send(JSON.stringify({ type: "user_message", content, attachments }));
+    addMessage(transcript, "you", attachmentText(content, attachments));
+    input.value = "";
+    attachments = [];
+    showPending();
+  };
+  input.addEventListener("keydown", (e) => {
+    if (e.key === "Enter" && !e.shiftKey) { e.preventDefault(); send(); }
+  });
+  // A read-only link for a teammate, valid for a day
+  const share = async () => {
+    const link = await api("POST", `/sessions/${encodeURIComponent(s.id)}/share`, {});
+    window.prompt("Read-only link to this session's transcript, valid until " +
+      new Date(link.expires_at).toLocaleString() + ":", location.origin + link.transcript_path);
+  };
+
+  $("#session-detail").replaceChildren(
+    el("h2", {}, s.project_name || s.project_path, " ", status),
+    transcript,
+    pending,
+    el("div", { class: "composer" },
+      input,
+      picker,
+      el("button", { onclick: () => picker.click() }, "Attach"),
+      el("button", { onclick: send }, "Send"),
+      el("button", { onclick: () => state.socket && state.socket.send(JSON.stringify({ type: "interrupt" })) }, "Interrupt"),
+      el("button", { onclick: () => share().catch((err) => addMessage(transcript, "error", err.message)) }, "Share")));
+
+  // Browsers cannot set headers on a WebSocket, so the token travels as a
+  // subprotocol (see authMiddleware).
+  const scheme = location.protocol === "https:" ? "wss:" : "ws:";
+  const socket = new WebSocket(`${scheme}//${location.host}/sessions/${encodeURIComponent(s.id)}/ws`,
+    ["codes", "bearer." + state.token]);
+  socket.onmessage = (e) => {
+    const msg = JSON.parse(e.data);
+    if (msg.type === "session_status") status.replaceChildren(statusBadge(msg.status));
+    else if (msg.type === "error" || msg.type === "session_expired") addMessage(transcript, "error", msg.message);
+    else if (msg.type === "claude_event") renderEvent(transcript, msg.event);
+  };
+  socket.onclose = () => addMessage(transcript, "error", "Disconnected");
+  state.socket = socket;
+}
+
+// renderEvent shows the parts of a Claude stream-json event worth reading:
+// user turns, assistant text and tool calls, and the turn's cost.
+function renderEvent(transcript, evt) {
+  if (!evt) return;
+  if (evt.type === "user" && typeof evt.content === "string") {
+    addMessage(transcript, "you", attachmentText(evt.content, evt.attachments || []));
+  } else if (evt.type === "assistant" && evt.message) {
+    for (const block of evt.message.content || []) {
+      if (block.type === "text") addMessage(transcript, "claude", block.text);
+      else if (block.type === "tool_use") addMessage(transcript, "tool", `${block.name} ${JSON.stringify(block.input || {})}`, "tool");
+    }
+  } else if (evt.type === "result" && typeof evt.total_cost_usd === "number") {
+    addMessage(transcript, "result", `Turn finished · $${evt.total_cost_usd.toFixed(4)}`, "tool");
+  }
+}
+
+// attachmentText appends the names of a message's attachments to its text.
+function attachmentText(content, attachments) {
+  const names = attachments.map((a) => "📎 " + a.name);
+  return [content, ...names].filter(Boolean).join("\n");
+}
+
+function addMessage(transcript, who, text, cls) {
+  const atBottom = transcript.scrollHeight - transcript.scrollTop - transcript.clientHeight < 40;
+  transcript.append(el("div", { class: "message " + (cls || "") },
+    el("div", { class: "who" }, who), text));
+  if (atBottom) transcript.scrollTop = transcript.scrollHeight;
+}
+
+// --- Start ---
+
+fetch("/health").then((r) => r.json()).then((h) => {
+  $("#server").textContent = `${location.host} · ${h.version}`;
+}).catch(() => {});
+
+route();
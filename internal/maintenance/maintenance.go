@@ -0,0 +1,287 @@
+// Package maintenance is the nightly housekeeping of ~/.codes: it prunes
+// leftover task notifications, compacts old team messages, archives finished
+// tasks, deletes old chat session transcripts, refreshes the remote status
+// cache and checks the config. `codes serve` runs it every night and `codes
+// maintenance` runs it on demand (e.g. from cron). The last report is saved
+// so the TUI can show it on its next launch.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codes/internal/agent"
+	"codes/internal/chatsession"
+	"codes/internal/config"
+	"codes/internal/remote"
+)
+
+const (
+	NotificationMaxAge = 7 * 24 * time.Hour  // notifications nobody picked up
+	MessageMaxAge      = 30 * 24 * time.Hour // read messages
+	TaskMaxAge         = 30 * 24 * time.Hour // completed, failed and cancelled tasks
+	SessionMaxAge      = 90 * 24 * time.Hour // chat session transcripts, from the session's last activity
+)
+
+// Step is the outcome of one maintenance step.
+type Step struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a maintenance run.
+type Report struct {
+	RanAt time.Time `json:"ranAt"`
+	Steps []Step    `json:"steps"`
+	Shown bool      `json:"shown,omitempty"` // shown by the TUI
+}
+
+// Summary condenses the report to one line.
+func (r *Report) Summary() string {
+	var parts []string
+	for _, s := range r.Steps {
+		if s.Error != "" {
+			parts = append(parts, s.Name+" failed")
+		} else if s.Summary != "" {
+			parts = append(parts, s.Summary)
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing to do"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// reportPath returns ~/.codes/maintenance.json.
+func reportPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codes", "maintenance.json"), nil
+}
+
+// Run performs every maintenance step, logs each outcome with logger (if not
+// nil) and saves the report.
+func Run(logger *log.Logger) *Report {
+	now := time.Now()
+	r := &Report{RanAt: now}
+	steps := []struct {
+		name string
+		fn   func() (string, error)
+	}{
+		{"notifications", func() (string, error) { return pruneNotifications(now.Add(-NotificationMaxAge)) }},
+		{"messages", func() (string, error) { return compactMessages(now.Add(-MessageMaxAge)) }},
+		{"tasks", func() (string, error) { return archiveTasks(now.Add(-TaskMaxAge)) }},
+		{"sessions", func() (string, error) { return pruneTranscripts(now.Add(-SessionMaxAge)) }},
+		{"remotes", refreshRemotes},
+		{"config", verifyConfig},
+	}
+	for _, st := range steps {
+		summary, err := st.fn()
+		step := Step{Name: st.name, Summary: summary}
+		if err != nil {
+			step.Error = err.Error()
+		}
+		if logger != nil {
+			if err != nil {
+				logger.Printf("[maintenance] %s: %v", st.name, err)
+			} else if summary != "" {
+				logger.Printf("[maintenance] %s: %s", st.name, summary)
+			}
+		}
+		r.Steps = append(r.Steps, step)
+	}
+	saveReport(r)
+	return r
+}
+
+func pruneNotifications(before time.Time) (string, error) {
+	n, err := agent.PruneNotifications(before)
+	if err != nil || n == 0 {
+		return "", err
+	}
+	return fmt.Sprintf("pruned %d old notifications", n), nil
+}
+
+func pruneTranscripts(before time.Time) (string, error) {
+	n, err := chatsession.PruneTranscripts(before)
+	if err != nil || n == 0 {
+		return "", err
+	}
+	return fmt.Sprintf("deleted %d old session transcripts", n), nil
+}
+
+func compactMessages(before time.Time) (string, error) {
+	return forEachTeam(before, agent.CompactMessages, "compacted %d messages")
+}
+
+func archiveTasks(before time.Time) (string, error) {
+	return forEachTeam(before, agent.ArchiveTasks, "archived %d tasks")
+}
+
+// forEachTeam runs fn on every team and sums the counts. A failing team is
+// reported but does not stop the others.
+func forEachTeam(before time.Time, fn func(string, time.Time) (int, error), format string) (string, error) {
+	teams, err := agent.ListTeams()
+	if err != nil {
+		return "", err
+	}
+	total := 0
+	var failed []string
+	for _, team := range teams {
+		n, err := fn(team, before)
+		total += n
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", team, err))
+		}
+	}
+	summary := ""
+	if total > 0 {
+		summary = fmt.Sprintf(format, total)
+	}
+	if len(failed) > 0 {
+		return summary, fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return summary, nil
+}
+
+// refreshRemotes re-checks every configured remote host and drops cached
+// status for hosts that were removed. Unreachable hosts keep their last
+// known status, and hosts using a security key are skipped since nobody is
+// there to touch it.
+func refreshRemotes() (string, error) {
+	hosts, err := config.ListRemotes()
+	if err != nil {
+		return "", err
+	}
+	cache := remote.LoadStatusCache()
+	known := make(map[string]bool, len(hosts))
+	refreshed, unreachable := 0, 0
+	for i := range hosts {
+		known[hosts[i].Name] = true
+		if remote.IsSecurityKeyIdentity(hosts[i].Identity) {
+			continue
+		}
+		status, err := remote.CheckRemoteStatus(&hosts[i])
+		if err != nil {
+			unreachable++
+			continue
+		}
+		cache[hosts[i].Name] = status
+		refreshed++
+	}
+	for name := range cache {
+		if !known[name] {
+			delete(cache, name)
+		}
+	}
+	if err := remote.SaveStatusCache(cache); err != nil {
+		return "", err
+	}
+	if len(hosts) == 0 {
+		return "", nil
+	}
+	summary := fmt.Sprintf("refreshed %d/%d remotes", refreshed, len(hosts))
+	if unreachable > 0 {
+		summary += fmt.Sprintf(" (%d unreachable)", unreachable)
+	}
+	return summary, nil
+}
+
+// verifyConfig reports config problems as the step's error, so they stand
+// out in the report.
+func verifyConfig() (string, error) {
+	problems, err := config.Verify()
+	if err != nil {
+		return "", err
+	}
+	if len(problems) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return "", nil
+}
+
+func saveReport(r *Report) {
+	path, err := reportPath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	_ = os.WriteFile(path, data, 0644)
+}
+
+// LastReport returns the report of the last run, or nil if there was none.
+func LastReport() *Report {
+	path, err := reportPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var r Report
+	if json.Unmarshal(data, &r) != nil {
+		return nil
+	}
+	return &r
+}
+
+// TakeUnshownReport returns the last report if it has not been shown yet and
+// marks it shown, so the TUI mentions each run once.
+func TakeUnshownReport() *Report {
+	r := LastReport()
+	if r == nil || r.Shown {
+		return nil
+	}
+	r.Shown = true
+	saveReport(r)
+	return r
+}
+
+// nightlyHour is the local hour RunNightly runs at.
+const nightlyHour = 3
+
+// nextRun returns when the next nightly run is due: the next nightlyHour
+// o'clock, or shortly after startup when the last run is more than a day old
+// (the machine was off or asleep at night).
+func nextRun(last, now time.Time) time.Time {
+	if now.Sub(last) > 24*time.Hour {
+		return now.Add(5 * time.Minute)
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), nightlyHour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// RunNightly runs maintenance every night until ctx is done.
+func RunNightly(ctx context.Context, logger *log.Logger) {
+	for {
+		var last time.Time
+		if r := LastReport(); r != nil {
+			last = r.RanAt
+		}
+		timer := time.NewTimer(time.Until(nextRun(last, time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			Run(logger)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRun(t *testing.T) {
+	loc := time.Local
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 3, day, hour, min, 0, 0, loc) }
+
+	tests := []struct {
+		name      string
+		last, now time.Time
+		want      time.Time
+	}{
+		{"evening runs tonight", at(10, 3, 0), at(10, 22, 0), at(11, 3, 0)},
+		{"early morning runs today", at(9, 3, 0), at(10, 1, 0), at(10, 3, 0)},
+		{"just ran", at(10, 3, 0), at(10, 3, 0), at(11, 3, 0)},
+		{"missed a night", at(8, 3, 0), at(10, 12, 0), at(10, 12, 5)},
+		{"never ran", time.Time{}, at(10, 12, 0), at(10, 12, 5)},
+	}
+	for _, tt := range tests {
+		if got := nextRun(tt.last, tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: nextRun = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReportSummary(t *testing.T) {
+	r := &Report{Steps: []Step{
+		{Name: "notifications"},
+		{Name: "tasks", Summary: "archived 3 tasks"},
+		{Name: "config", Error: "default profile \"x\" does not exist"},
+	}}
+	if got, want := r.Summary(), "archived 3 tasks; config failed"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	if got := (&Report{Steps: []Step{{Name: "remotes"}}}).Summary(); got != "nothing to do" {
+		t.Errorf("empty Summary = %q", got)
+	}
+}
+
+func TestTakeUnshownReport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if TakeUnshownReport() != nil {
+		t.Fatal("report without any run")
+	}
+	saveReport(&Report{RanAt: time.Now(), Steps: []Step{{Name: "tasks", Summary: "archived 1 tasks"}}})
+	if r := TakeUnshownReport(); r == nil || r.Summary() != "archived 1 tasks" {
+		t.Fatalf("TakeUnshownReport = %+v", r)
+	}
+	if TakeUnshownReport() != nil {
+		t.Error("report shown twice")
+	}
+}
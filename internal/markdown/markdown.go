@@ -0,0 +1,264 @@
+// Package markdown renders the Markdown that agents and the assistant write
+// (task descriptions, results, replies) for the terminal. It covers what
+// those reports use: headings, paragraphs, lists, block quotes, fenced code,
+// rules, and bold, italic, inline code and links within a line. Anything
+// else, such as tables, is printed as written.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"codes/internal/config"
+)
+
+// Themes. Plain wraps and indents without colors, Raw returns the text
+// untouched.
+const (
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+	ThemePlain = "plain"
+	ThemeRaw   = "raw"
+)
+
+// Themes lists the valid themes.
+var Themes = []string{ThemeDark, ThemeLight, ThemePlain, ThemeRaw}
+
+// DefaultWidth is used when neither the caller nor the config sets a width.
+const DefaultWidth = 80
+
+// Options control rendering. The zero value renders with the dark theme at
+// DefaultWidth.
+type Options struct {
+	Width int    // wrap column; <= 0 uses DefaultWidth
+	Theme string // one of Themes; "" is dark
+}
+
+// FromConfig returns the options set in the config's markdown section, with
+// width as the fallback when the config sets none (e.g. the pane width).
+// A configured width larger than the fallback is capped at it.
+func FromConfig(cfg *config.Config, width int) Options {
+	opts := Options{Width: width}
+	if cfg == nil || cfg.Markdown == nil {
+		return opts
+	}
+	opts.Theme = cfg.Markdown.Theme
+	if w := cfg.Markdown.Width; w > 0 && (width <= 0 || w < width) {
+		opts.Width = w
+	}
+	return opts
+}
+
+// theme holds the styles for one theme.
+type theme struct {
+	heading, heading1, bold, italic, code, codeBlock, link, quote, rule, bullet lipgloss.Style
+}
+
+func themeFor(name string) theme {
+	s := lipgloss.NewStyle
+	var accent, muted, codeFg lipgloss.Color
+	switch name {
+	case ThemePlain:
+		return theme{
+			heading: s(), heading1: s(), bold: s(), italic: s(), code: s(), codeBlock: s(),
+			link: s(), quote: s(), rule: s(), bullet: s(),
+		}
+	case ThemeLight:
+		accent, muted, codeFg = "#3D6A8A", "#6B7280", "#8A4B3D"
+	default:
+		accent, muted, codeFg = "#7B9DB7", "#6B7280", "#C4AD88"
+	}
+	return theme{
+		heading:   s().Bold(true).Foreground(accent),
+		heading1:  s().Bold(true).Foreground(accent).Underline(true),
+		bold:      s().Bold(true),
+		italic:    s().Italic(true),
+		code:      s().Foreground(codeFg),
+		codeBlock: s().Foreground(codeFg),
+		link:      s().Underline(true).Foreground(accent),
+		quote:     s().Foreground(muted).Italic(true),
+		rule:      s().Foreground(muted),
+		bullet:    s().Foreground(accent),
+	}
+}
+
+// The patterns are compiled on first use, so commands that never render
+// Markdown don't pay for them at startup.
+var (
+	headingRe = lazyRegexp(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe  = lazyRegexp(`^(\s*)([-*+])\s+(.*)$`)
+	orderedRe = lazyRegexp(`^(\s*)(\d+[.)])\s+(.*)$`)
+	ruleRe    = lazyRegexp(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	fenceRe   = lazyRegexp("^\\s*(```|~~~)")
+)
+
+// lazyRegexp returns a func that compiles expr the first time it is called.
+func lazyRegexp(expr string) func() *regexp.Regexp {
+	return sync.OnceValue(func() *regexp.Regexp { return regexp.MustCompile(expr) })
+}
+
+// Render formats src for a terminal.
+func Render(src string, opts Options) string {
+	if opts.Theme == ThemeRaw {
+		return src
+	}
+	width := opts.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	r := &renderer{t: themeFor(opts.Theme), width: width}
+	r.render(strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n"))
+	return strings.TrimRight(r.out.String(), "\n")
+}
+
+type renderer struct {
+	t     theme
+	width int
+	out   strings.Builder
+	para  []string // lines of the paragraph being collected
+}
+
+func (r *renderer) render(lines []string) {
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fenceRe().FindStringSubmatch(line); m != nil {
+			r.flush()
+			i++
+			for ; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				r.out.WriteString("    " + r.t.codeBlock.Render(lines[i]) + "\n")
+			}
+			r.out.WriteString("\n")
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			r.flush()
+		case headingRe().MatchString(trimmed):
+			r.flush()
+			m := headingRe().FindStringSubmatch(trimmed)
+			style := r.t.heading
+			if len(m[1]) == 1 {
+				style = r.t.heading1
+			}
+			r.out.WriteString(r.inline(m[2], style) + "\n\n")
+		case ruleRe().MatchString(line):
+			r.flush()
+			r.out.WriteString(r.t.rule.Render(strings.Repeat("─", min(r.width, 40))) + "\n\n")
+		case strings.HasPrefix(trimmed, ">"):
+			r.flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			r.block(strings.Join(quoted, " "), r.t.quote.Render("│ "), r.t.quote.Render("│ "), r.t.quote)
+			r.out.WriteString("\n")
+		case bulletRe().MatchString(line):
+			r.flush()
+			m := bulletRe().FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(m[1])/2*2)
+			i = r.listItem(lines, i, m[3], indent+r.t.bullet.Render("•")+" ", indent+"  ")
+		case orderedRe().MatchString(line):
+			r.flush()
+			m := orderedRe().FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(m[1])/2*2)
+			i = r.listItem(lines, i, m[3], indent+r.t.bullet.Render(m[2])+" ", indent+strings.Repeat(" ", len(m[2])+1))
+		default:
+			r.para = append(r.para, trimmed)
+		}
+	}
+	r.flush()
+}
+
+// listItem renders the item starting at lines[i], including continuation
+// lines indented under it, and returns the index of its last line.
+func (r *renderer) listItem(lines []string, i int, text, first, rest string) int {
+	parts := []string{text}
+	for i+1 < len(lines) {
+		next := lines[i+1]
+		if strings.TrimSpace(next) == "" || !strings.HasPrefix(next, "  ") ||
+			bulletRe().MatchString(next) || orderedRe().MatchString(next) {
+			break
+		}
+		parts = append(parts, strings.TrimSpace(next))
+		i++
+	}
+	r.block(strings.Join(parts, " "), first, rest, lipgloss.NewStyle())
+	// Keep consecutive items together; a blank line or other block ends the list
+	if i+1 >= len(lines) || !(bulletRe().MatchString(lines[i+1]) || orderedRe().MatchString(lines[i+1])) {
+		r.out.WriteString("\n")
+	}
+	return i
+}
+
+// flush writes the paragraph collected so far.
+func (r *renderer) flush() {
+	if len(r.para) == 0 {
+		return
+	}
+	r.block(strings.Join(r.para, " "), "", "", lipgloss.NewStyle())
+	r.out.WriteString("\n")
+	r.para = nil
+}
+
+// block wraps text to the width, prefixing the first line with first and
+// the others with rest.
+func (r *renderer) block(text, first, rest string, base lipgloss.Style) {
+	avail := max(10, r.width-ansi.StringWidth(first))
+	wrapped := ansi.Wrap(r.inline(text, base), avail, "")
+	for j, l := range strings.Split(wrapped, "\n") {
+		prefix := rest
+		if j == 0 {
+			prefix = first
+		}
+		r.out.WriteString(prefix + l + "\n")
+	}
+}
+
+var inlineRe = lazyRegexp("`([^`]+)`|\\*\\*([^*]+)\\*\\*|__([^_]+)__|\\*([^*\\s][^*]*)\\*|\\b_([^_\\s][^_]*)_\\b|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+
+// inline styles code spans, emphasis and links in one line of text. Plain
+// runs are rendered with base so block styles (quotes, headings) carry over.
+func (r *renderer) inline(text string, base lipgloss.Style) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inlineRe().FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(renderPlain(base, text[last:m[0]]))
+		group := func(n int) string { return text[m[2*n]:m[2*n+1]] }
+		switch {
+		case m[2] >= 0:
+			b.WriteString(r.t.code.Render(group(1)))
+		case m[4] >= 0:
+			b.WriteString(base.Inherit(r.t.bold).Render(group(2)))
+		case m[6] >= 0:
+			b.WriteString(base.Inherit(r.t.bold).Render(group(3)))
+		case m[8] >= 0:
+			b.WriteString(base.Inherit(r.t.italic).Render(group(4)))
+		case m[10] >= 0:
+			b.WriteString(base.Inherit(r.t.italic).Render(group(5)))
+		default:
+			b.WriteString(r.t.link.Render(group(6)))
+			if url := group(7); url != group(6) {
+				b.WriteString(renderPlain(base, " ("+url+")"))
+			}
+		}
+		last = m[1]
+	}
+	b.WriteString(renderPlain(base, text[last:]))
+	return b.String()
+}
+
+// renderPlain renders s with style, leaving empty strings empty.
+func renderPlain(style lipgloss.Style, s string) string {
+	if s == "" {
+		return ""
+	}
+	return style.Render(s)
+}
@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestRenderPlain(t *testing.T) {
+	src := strings.Join([]string{
+		"# Summary",
+		"",
+		"Split the **parser** into `lexer.go` and",
+		"`parser.go`, see [the issue](https://example.com/1).",
+		"",
+		"- first item that is long enough to wrap around",
+		"- second",
+		"  continued",
+		"",
+		"1. one",
+		"2. two",
+		"",
+		"> quoted",
+		"",
+		"```go",
+		"func main() {}",
+		"```",
+		"---",
+		"snake_case_name stays",
+	}, "\n")
+
+	want := strings.Join([]string{
+		"Summary",
+		"",
+		"Split the parser into lexer.go and parser.go,",
+		"see the issue (https://example.com/1).",
+		"",
+		"• first item that is long enough to wrap",
+		"  around",
+		"• second continued",
+		"",
+		"1. one",
+		"2. two",
+		"",
+		"│ quoted",
+		"",
+		"    func main() {}",
+		"",
+		"────────────────────────────────────────",
+		"",
+		"snake_case_name stays",
+	}, "\n")
+
+	got := Render(src, Options{Width: 45, Theme: ThemePlain})
+	if got != want {
+		t.Errorf("Render =\n%s\n\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderRaw(t *testing.T) {
+	src := "# Title\n\n**bold**"
+	if got := Render(src, Options{Theme: ThemeRaw}); got != src {
+		t.Errorf("raw theme changed the text: %q", got)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	if got := FromConfig(nil, 60); got != (Options{Width: 60}) {
+		t.Errorf("no config: %+v", got)
+	}
+	cfg := &config.Config{Markdown: &config.MarkdownConfig{Width: 100, Theme: ThemeLight}}
+	if got := FromConfig(cfg, 60); got != (Options{Width: 60, Theme: ThemeLight}) {
+		t.Errorf("width wider than the pane: %+v", got)
+	}
+	cfg.Markdown.Width = 50
+	if got := FromConfig(cfg, 60); got.Width != 50 {
+		t.Errorf("configured width: %+v", got)
+	}
+	if got := FromConfig(cfg, 0); got.Width != 50 {
+		t.Errorf("configured width without a pane: %+v", got)
+	}
+}
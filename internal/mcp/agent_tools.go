@@ -3,13 +3,14 @@ package mcpserver
 import (
 	"context"
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"codes/internal/agent"
+	"codes/internal/config"
 )
 
 // mcpServer holds the server reference for the background notification monitor.
@@ -21,6 +22,9 @@ type teamCreateInput struct {
 	Name        string `json:"name" jsonschema:"Team name"`
 	Description string `json:"description,omitempty" jsonschema:"Team description"`
 	WorkDir     string `json:"workDir,omitempty" jsonschema:"Working directory for agents"`
+	Assignment  string `json:"assignment,omitempty" jsonschema:"How tasks created without an owner are assigned: round_robin, least_loaded or random among running agents with the task's skills. Default: left for agents to auto-claim"`
+	IdleStop    int    `json:"idleStopMinutes,omitempty" jsonschema:"Stop all agents once the team has had no pending or running task for this many minutes, with a notification. Default: agents keep running"`
+	IdleArchive bool   `json:"idleArchive,omitempty" jsonschema:"With idleStopMinutes, also archive the team's finished tasks when it stops"`
 }
 
 type teamCreateOutput struct {
@@ -32,10 +36,30 @@ func teamCreateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 	if input.Name == "" {
 		return nil, teamCreateOutput{}, fmt.Errorf("name is required")
 	}
+	strategy, err := agent.ParseAssignStrategy(input.Assignment)
+	if err != nil {
+		return nil, teamCreateOutput{}, err
+	}
+	if input.IdleStop < 0 {
+		return nil, teamCreateOutput{}, fmt.Errorf("idleStopMinutes must not be negative")
+	}
 	cfg, err := agent.CreateTeam(input.Name, input.Description, input.WorkDir)
 	if err != nil {
 		return nil, teamCreateOutput{}, err
 	}
+	if strategy != "" {
+		if err := agent.SetAssignStrategy(input.Name, strategy); err != nil {
+			return nil, teamCreateOutput{}, err
+		}
+		cfg.Assignment = strategy
+	}
+	if input.IdleStop > 0 {
+		idle := &agent.IdlePolicy{StopAfter: input.IdleStop, Archive: input.IdleArchive}
+		if err := agent.SetIdlePolicy(input.Name, idle); err != nil {
+			return nil, teamCreateOutput{}, err
+		}
+		cfg.Idle = idle
+	}
 	return nil, teamCreateOutput{Created: true, Team: cfg}, nil
 }
 
@@ -50,6 +74,12 @@ type teamDeleteOutput struct {
 }
 
 func teamDeleteHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamDeleteInput) (*mcpsdk.CallToolResult, teamDeleteOutput, error) {
+	if _, err := agent.GetTeam(input.Name); err != nil {
+		return nil, teamDeleteOutput{}, err
+	}
+	if err := confirmAction(ctx, req, teamDeleteSummary(input.Name)); err != nil {
+		return nil, teamDeleteOutput{}, err
+	}
 	if err := agent.DeleteTeam(input.Name); err != nil {
 		return nil, teamDeleteOutput{}, err
 	}
@@ -113,11 +143,14 @@ func teamGetHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input team
 // -- agent_add --
 
 type agentAddInput struct {
-	Team  string `json:"team" jsonschema:"Team name"`
-	Name  string `json:"name" jsonschema:"Agent name"`
-	Role  string `json:"role,omitempty" jsonschema:"Agent role description"`
-	Model string `json:"model,omitempty" jsonschema:"Claude model (e.g. sonnet, opus)"`
-	Type  string `json:"type,omitempty" jsonschema:"Agent type (worker, leader)"`
+	Team            string   `json:"team" jsonschema:"Team name"`
+	Name            string   `json:"name" jsonschema:"Agent name"`
+	Role            string   `json:"role,omitempty" jsonschema:"Agent role description"`
+	Model           string   `json:"model,omitempty" jsonschema:"Claude model (e.g. sonnet, opus)"`
+	Type            string   `json:"type,omitempty" jsonschema:"Agent type (worker, leader)"`
+	Skills          []string `json:"skills,omitempty" jsonschema:"Skills of the agent (e.g. go, frontend); tasks that require skills only go to agents that have them all"`
+	PollInterval    int      `json:"pollInterval,omitempty" jsonschema:"Seconds between polls while active (default 3)"`
+	MaxPollInterval int      `json:"maxPollInterval,omitempty" jsonschema:"Maximum seconds between polls when idle (default 60)"`
 }
 
 type agentAddOutput struct {
@@ -129,10 +162,14 @@ func agentAddHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input age
 		return nil, agentAddOutput{}, fmt.Errorf("team and name are required")
 	}
 	member := agent.TeamMember{
-		Name:  input.Name,
-		Role:  input.Role,
-		Model: input.Model,
-		Type:  input.Type,
+		Name:   input.Name,
+		Role:   input.Role,
+		Model:  input.Model,
+		Type:   input.Type,
+		Skills: input.Skills,
+	}
+	if input.PollInterval > 0 || input.MaxPollInterval > 0 {
+		member.Poll = &agent.PollSettings{Interval: input.PollInterval, MaxInterval: input.MaxPollInterval}
 	}
 	if err := agent.AddMember(input.Team, member); err != nil {
 		return nil, agentAddOutput{}, err
@@ -184,7 +221,7 @@ func agentListHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input ag
 		agents = append(agents, info)
 	}
 
-	return nil, agentListOutput{Agents: agents, Notifications: drainPendingNotifications()}, nil
+	return nil, agentListOutput{Agents: agents, Notifications: drainPendingNotifications(req.Session)}, nil
 }
 
 // -- agent_start --
@@ -197,6 +234,7 @@ type agentStartInput struct {
 type agentStartOutput struct {
 	Started       bool               `json:"started"`
 	PID           int                `json:"pid,omitempty"`
+	Warning       string             `json:"warning,omitempty"` // e.g. claude CLI missing: tasks queue until installed
 	MonitorActive bool               `json:"monitor_active"`
 	Notifications []taskNotification `json:"pending_notifications,omitempty"`
 }
@@ -213,11 +251,21 @@ func agentStartHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input a
 	return nil, agentStartOutput{
 		Started:       true,
 		PID:           pid,
+		Warning:       claudeMissingWarning(),
 		MonitorActive: monitorRunning.Load(),
-		Notifications: drainPendingNotifications(),
+		Notifications: drainPendingNotifications(req.Session),
 	}, nil
 }
 
+// claudeMissingWarning explains that started agents will sit idle until the
+// claude CLI is installed. Empty when it is available.
+func claudeMissingWarning() string {
+	if config.ClaudeAvailable() {
+		return ""
+	}
+	return "claude CLI not found on this host: agents are running but tasks stay queued until it is installed (`codes install`)"
+}
+
 // -- agent_stop --
 
 type agentStopInput struct {
@@ -230,28 +278,64 @@ type agentStopOutput struct {
 }
 
 func agentStopHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input agentStopInput) (*mcpsdk.CallToolResult, agentStopOutput, error) {
-	_, err := agent.SendMessage(input.Team, "__system__", input.Name, "__stop__")
-	if err != nil {
+	if err := agent.StopAgent(input.Team, input.Name); err != nil {
 		return nil, agentStopOutput{}, err
 	}
 	return nil, agentStopOutput{Stopping: true}, nil
 }
 
+// -- agent_logs --
+
+type agentLogsInput struct {
+	Team  string `json:"team" jsonschema:"Team name"`
+	Name  string `json:"name" jsonschema:"Agent name"`
+	Lines int    `json:"lines,omitempty" jsonschema:"Number of most recent lines to return (default 100, max 2000)"`
+	Grep  string `json:"grep,omitempty" jsonschema:"Only return lines matching this regular expression (optional)"`
+}
+
+type agentLogsOutput struct {
+	Lines []string `json:"lines"`
+	Alive bool     `json:"alive"`
+}
+
+func agentLogsHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input agentLogsInput) (*mcpsdk.CallToolResult, agentLogsOutput, error) {
+	n := input.Lines
+	if n <= 0 {
+		n = 100
+	}
+	if n > 2000 {
+		n = 2000
+	}
+	lines, err := agent.ReadAgentLog(input.Team, input.Name, n, input.Grep)
+	if err != nil {
+		return nil, agentLogsOutput{}, err
+	}
+	return nil, agentLogsOutput{Lines: lines, Alive: agent.IsAgentAlive(input.Team, input.Name)}, nil
+}
+
 // -- task_create --
 
 type taskCreateInput struct {
-	Team        string `json:"team" jsonschema:"Team name"`
-	Subject     string `json:"subject" jsonschema:"Task subject/title"`
-	Description string `json:"description,omitempty" jsonschema:"Detailed task description"`
-	Assign      string `json:"assign,omitempty" jsonschema:"Agent name to assign the task to"`
-	BlockedBy   []int  `json:"blockedBy,omitempty" jsonschema:"Task IDs that must complete before this task"`
-	Priority    string `json:"priority,omitempty" jsonschema:"Task priority: high, normal, or low (default: normal)"`
-	Project     string `json:"project,omitempty" jsonschema:"Project name to execute in (registered via add_project)"`
-	WorkDir     string `json:"workDir,omitempty" jsonschema:"Explicit working directory (overrides project)"`
+	Team         string   `json:"team" jsonschema:"Team name"`
+	Subject      string   `json:"subject" jsonschema:"Task subject/title"`
+	Description  string   `json:"description,omitempty" jsonschema:"Detailed task description"`
+	Assign       string   `json:"assign,omitempty" jsonschema:"Agent name to assign the task to (default: the team's assignment strategy, or auto-claim)"`
+	Skills       []string `json:"skills,omitempty" jsonschema:"Skills the agent doing the task must have"`
+	Labels       []string `json:"labels,omitempty" jsonschema:"Free-form labels for people, e.g. bug or frontend"`
+	BlockedBy    []int    `json:"blockedBy,omitempty" jsonschema:"Task IDs that must complete before this task"`
+	Priority     string   `json:"priority,omitempty" jsonschema:"Task priority: high, normal, or low (default: normal)"`
+	Project      string   `json:"project,omitempty" jsonschema:"Project name to execute in (registered via add_project)"`
+	WorkDir      string   `json:"workDir,omitempty" jsonschema:"Explicit working directory (overrides project)"`
+	Artifacts    []string `json:"artifacts,omitempty" jsonschema:"Output file paths or globs (relative to the working directory) to collect when the task completes"`
+	ContextFiles []string `json:"contextFiles,omitempty" jsonschema:"Files (relative to the working directory) whose current contents are inlined into the prompt when the task starts, e.g. a spec or interface definition"`
+	Type         string   `json:"type,omitempty" jsonschema:"'human' to ask the human a question instead of running an agent: the subject is the question, posted once blockedBy tasks complete; the answer becomes the result and is passed to tasks blocked by it"`
+	Choices      []string `json:"choices,omitempty" jsonschema:"Allowed answers to a human task (default: any answer)"`
+	DryRun       bool     `json:"dryRun,omitempty" jsonschema:"Validate and report the task that would be created, where it would run and any problems, without creating it"`
 }
 
 type taskCreateOutput struct {
-	Task          *agent.Task        `json:"task"`
+	Task          *agent.Task        `json:"task,omitempty"`
+	DryRun        *agent.TaskPlan    `json:"dryRun,omitempty"`
 	MonitorActive bool               `json:"monitor_active"`
 	Notifications []taskNotification `json:"pending_notifications,omitempty"`
 }
@@ -260,10 +344,33 @@ func taskCreateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 	if input.Team == "" || input.Subject == "" {
 		return nil, taskCreateOutput{}, fmt.Errorf("team and subject are required")
 	}
-	task, err := agent.CreateTask(input.Team, input.Subject, input.Description, input.Assign, input.BlockedBy, agent.TaskPriority(input.Priority), input.Project, input.WorkDir)
+	spec := agent.TaskSpec{
+		Subject:      input.Subject,
+		Description:  input.Description,
+		Owner:        input.Assign,
+		BlockedBy:    input.BlockedBy,
+		Priority:     agent.TaskPriority(input.Priority),
+		Project:      input.Project,
+		WorkDir:      input.WorkDir,
+		Skills:       input.Skills,
+		Labels:       input.Labels,
+		Artifacts:    input.Artifacts,
+		ContextFiles: input.ContextFiles,
+		Type:         agent.TaskType(input.Type),
+		Choices:      input.Choices,
+	}
+	if input.DryRun {
+		plan, err := agent.PlanTask(input.Team, spec)
+		if err != nil {
+			return nil, taskCreateOutput{}, err
+		}
+		return nil, taskCreateOutput{DryRun: plan, MonitorActive: monitorRunning.Load()}, nil
+	}
+	tasks, err := agent.CreateTasks(input.Team, []agent.TaskSpec{spec})
 	if err != nil {
 		return nil, taskCreateOutput{}, err
 	}
+	task := tasks[0]
 
 	// Ensure background notification monitor is running
 	ensureMonitorRunning(mcpServer)
@@ -271,7 +378,82 @@ func taskCreateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 	return nil, taskCreateOutput{
 		Task:          task,
 		MonitorActive: monitorRunning.Load(),
-		Notifications: drainPendingNotifications(),
+		Notifications: drainPendingNotifications(req.Session),
+	}, nil
+}
+
+// -- tasks_create_batch --
+
+type batchTaskDef struct {
+	Subject      string   `json:"subject" jsonschema:"Task subject/title"`
+	Description  string   `json:"description,omitempty" jsonschema:"Detailed task description"`
+	Assign       string   `json:"assign,omitempty" jsonschema:"Agent name to assign the task to (default: the team's assignment strategy, or auto-claim)"`
+	Skills       []string `json:"skills,omitempty" jsonschema:"Skills the agent doing the task must have"`
+	DependsOn    []int    `json:"dependsOn,omitempty" jsonschema:"1-based positions of earlier tasks in this batch that must complete first"`
+	BlockedBy    []int    `json:"blockedBy,omitempty" jsonschema:"IDs of existing tasks that must complete first"`
+	Priority     string   `json:"priority,omitempty" jsonschema:"Task priority: high, normal, or low (default: normal)"`
+	Project      string   `json:"project,omitempty" jsonschema:"Project name to execute in (registered via add_project)"`
+	WorkDir      string   `json:"workDir,omitempty" jsonschema:"Explicit working directory (overrides project)"`
+	Artifacts    []string `json:"artifacts,omitempty" jsonschema:"Output file paths or globs (relative to the working directory) to collect when the task completes"`
+	ContextFiles []string `json:"contextFiles,omitempty" jsonschema:"Files whose current contents are inlined into the prompt when the task starts"`
+	Type         string   `json:"type,omitempty" jsonschema:"'human' for a question to the human instead of agent work (see task_create)"`
+	Choices      []string `json:"choices,omitempty" jsonschema:"Allowed answers to a human task"`
+}
+
+type tasksCreateBatchInput struct {
+	Team  string         `json:"team" jsonschema:"Team name"`
+	Tasks []batchTaskDef `json:"tasks" jsonschema:"Tasks to create, in order"`
+}
+
+type tasksCreateBatchOutput struct {
+	Tasks         []*agent.Task      `json:"tasks"`
+	IDMap         map[string]int     `json:"idMap"` // batch position ("1", "2", ...) -> task ID
+	MonitorActive bool               `json:"monitor_active"`
+	Notifications []taskNotification `json:"pending_notifications,omitempty"`
+}
+
+func tasksCreateBatchHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input tasksCreateBatchInput) (*mcpsdk.CallToolResult, tasksCreateBatchOutput, error) {
+	if input.Team == "" {
+		return nil, tasksCreateBatchOutput{}, fmt.Errorf("team is required")
+	}
+	if _, err := agent.GetTeam(input.Team); err != nil {
+		return nil, tasksCreateBatchOutput{}, err
+	}
+	specs := make([]agent.TaskSpec, len(input.Tasks))
+	for i, t := range input.Tasks {
+		specs[i] = agent.TaskSpec{
+			Subject:      t.Subject,
+			Description:  t.Description,
+			Owner:        t.Assign,
+			BlockedBy:    t.BlockedBy,
+			DependsOn:    t.DependsOn,
+			Priority:     agent.TaskPriority(t.Priority),
+			Project:      t.Project,
+			WorkDir:      t.WorkDir,
+			Skills:       t.Skills,
+			Artifacts:    t.Artifacts,
+			ContextFiles: t.ContextFiles,
+			Type:         agent.TaskType(t.Type),
+			Choices:      t.Choices,
+		}
+	}
+	tasks, err := agent.CreateTasks(input.Team, specs)
+	if err != nil {
+		return nil, tasksCreateBatchOutput{}, err
+	}
+
+	idMap := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		idMap[strconv.Itoa(i+1)] = task.ID
+	}
+
+	ensureMonitorRunning(mcpServer)
+
+	return nil, tasksCreateBatchOutput{
+		Tasks:         tasks,
+		IDMap:         idMap,
+		MonitorActive: monitorRunning.Load(),
+		Notifications: drainPendingNotifications(req.Session),
 	}, nil
 }
 
@@ -294,12 +476,8 @@ type taskUpdateOutput struct {
 func taskUpdateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input taskUpdateInput) (*mcpsdk.CallToolResult, taskUpdateOutput, error) {
 	task, err := agent.UpdateTask(input.Team, input.TaskID, func(t *agent.Task) error {
 		if input.Status != "" {
+			// Validated against the task state machine by UpdateTask
 			t.Status = agent.TaskStatus(input.Status)
-			// Auto-set StartedAt when transitioning to running
-			if input.Status == "running" && t.StartedAt == nil {
-				now := time.Now()
-				t.StartedAt = &now
-			}
 		}
 		if input.Owner != "" {
 			t.Owner = input.Owner
@@ -328,17 +506,29 @@ type taskRedirectInput struct {
 	TaskID          int    `json:"taskId" jsonschema:"Task ID of the running task to cancel and redirect"`
 	NewInstructions string `json:"newInstructions" jsonschema:"New task description/instructions for the replacement task"`
 	Subject         string `json:"subject,omitempty" jsonschema:"Optional new subject (inherits from original task if not provided)"`
+	DryRun          bool   `json:"dryRun,omitempty" jsonschema:"Validate and report which task would be cancelled and the replacement that would be created, without changing anything"`
 }
 
 type taskRedirectOutput struct {
-	CancelledTaskID int        `json:"cancelled_task_id"`
-	NewTask         *agent.Task `json:"new_task"`
+	CancelledTaskID int                 `json:"cancelled_task_id,omitempty"`
+	NewTask         *agent.Task         `json:"new_task,omitempty"`
+	DryRun          *agent.RedirectPlan `json:"dryRun,omitempty"`
 }
 
 func taskRedirectHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input taskRedirectInput) (*mcpsdk.CallToolResult, taskRedirectOutput, error) {
 	if input.Team == "" || input.TaskID == 0 || input.NewInstructions == "" {
 		return nil, taskRedirectOutput{}, fmt.Errorf("team, taskId, and newInstructions are required")
 	}
+	if input.DryRun {
+		plan, err := agent.PlanRedirect(input.Team, input.TaskID, input.NewInstructions, input.Subject)
+		if err != nil {
+			return nil, taskRedirectOutput{}, err
+		}
+		return nil, taskRedirectOutput{DryRun: plan}, nil
+	}
+	if err := confirmAction(ctx, req, taskRedirectSummary(input.Team, input.TaskID)); err != nil {
+		return nil, taskRedirectOutput{}, err
+	}
 	newTask, err := agent.RedirectTask(input.Team, input.TaskID, input.NewInstructions, input.Subject)
 	if err != nil {
 		return nil, taskRedirectOutput{}, err
@@ -349,6 +539,53 @@ func taskRedirectHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input
 	}, nil
 }
 
+// -- task_followup --
+
+type taskFollowupInput struct {
+	Team         string `json:"team" jsonschema:"Team name"`
+	TaskID       int    `json:"taskId" jsonschema:"ID of the completed task to follow up"`
+	Instructions string `json:"instructions" jsonschema:"Additional instructions; the agent resumes the task's Claude session, so prior context need not be repeated"`
+	Subject      string `json:"subject,omitempty" jsonschema:"Optional subject (default: Follow-up: <original subject>)"`
+}
+
+type taskFollowupOutput struct {
+	Task *agent.Task `json:"task"`
+}
+
+func taskFollowupHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input taskFollowupInput) (*mcpsdk.CallToolResult, taskFollowupOutput, error) {
+	if input.Team == "" || input.TaskID == 0 {
+		return nil, taskFollowupOutput{}, fmt.Errorf("team and taskId are required")
+	}
+	task, err := agent.FollowUpTask(input.Team, input.TaskID, input.Instructions, input.Subject)
+	if err != nil {
+		return nil, taskFollowupOutput{}, err
+	}
+	return nil, taskFollowupOutput{Task: task}, nil
+}
+
+// -- task_answer --
+
+type taskAnswerInput struct {
+	Team   string `json:"team" jsonschema:"Team name"`
+	TaskID int    `json:"taskId" jsonschema:"ID of the human task"`
+	Answer string `json:"answer" jsonschema:"The human's answer; one of the task's choices when it has any"`
+}
+
+type taskAnswerOutput struct {
+	Task *agent.Task `json:"task"`
+}
+
+func taskAnswerHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input taskAnswerInput) (*mcpsdk.CallToolResult, taskAnswerOutput, error) {
+	if input.Team == "" || input.TaskID == 0 {
+		return nil, taskAnswerOutput{}, fmt.Errorf("team and taskId are required")
+	}
+	task, err := agent.AnswerTask(input.Team, input.TaskID, input.Answer, "")
+	if err != nil {
+		return nil, taskAnswerOutput{}, err
+	}
+	return nil, taskAnswerOutput{Task: task}, nil
+}
+
 // -- task_list --
 
 type taskListInput struct {
@@ -370,7 +607,7 @@ func taskListHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input tas
 	if tasks == nil {
 		tasks = []*agent.Task{}
 	}
-	return nil, taskListOutput{Tasks: tasks, Notifications: drainPendingNotifications()}, nil
+	return nil, taskListOutput{Tasks: tasks, Notifications: drainPendingNotifications(req.Session)}, nil
 }
 
 // -- task_get --
@@ -383,6 +620,7 @@ type taskGetInput struct {
 type taskGetOutput struct {
 	Task            *agent.Task        `json:"task"`
 	RunningDuration string             `json:"runningDuration,omitempty"`
+	Artifacts       []string           `json:"artifacts,omitempty"` // collected artifact files, downloadable via GET /teams/{name}/tasks/{id}/artifacts/{file}
 	Notifications   []taskNotification `json:"pending_notifications,omitempty"`
 }
 
@@ -391,10 +629,13 @@ func taskGetHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input task
 	if err != nil {
 		return nil, taskGetOutput{}, err
 	}
-	out := taskGetOutput{Task: task, Notifications: drainPendingNotifications()}
+	out := taskGetOutput{Task: task, Notifications: drainPendingNotifications(req.Session)}
 	if task.Status == agent.TaskRunning && task.StartedAt != nil {
 		out.RunningDuration = time.Since(*task.StartedAt).Truncate(time.Second).String()
 	}
+	if files, err := agent.ListArtifacts(input.Team, input.TaskID); err == nil {
+		out.Artifacts = files
+	}
 	return nil, out, nil
 }
 
@@ -496,6 +737,9 @@ type teamStatusAgentInfo struct {
 	Activity           string `json:"activity,omitempty"`
 	RunningDuration    string `json:"runningDuration,omitempty"`
 	Uptime             string `json:"uptime,omitempty"`
+	Version            string `json:"version,omitempty"`        // codes version the daemon was built from
+	VersionWarning     string `json:"versionWarning,omitempty"` // set when the daemon predates an upgrade
+	PausedReason       string `json:"pausedReason,omitempty"`   // why the power policy holds new tasks back
 }
 
 type teamStatusTaskSummary struct {
@@ -507,10 +751,21 @@ type teamStatusTaskSummary struct {
 }
 
 type teamStatusRecentCompletion struct {
-	ID          int    `json:"id"`
-	Subject     string `json:"subject"`
-	Owner       string `json:"owner,omitempty"`
-	CompletedAt string `json:"completedAt,omitempty"`
+	ID          int                `json:"id"`
+	Subject     string             `json:"subject"`
+	Owner       string             `json:"owner,omitempty"`
+	CompletedAt string             `json:"completedAt,omitempty"`
+	Summary     *agent.TaskSummary `json:"summary,omitempty"` // digest of a long result
+}
+
+// teamStatusRunningTask is a running task with the plan its agent posted, if
+// any, so a wrong approach can be redirected early.
+type teamStatusRunningTask struct {
+	ID        int              `json:"id"`
+	Subject   string           `json:"subject"`
+	Owner     string           `json:"owner,omitempty"`
+	StartedAt string           `json:"startedAt,omitempty"`
+	Plan      *agent.AgentPlan `json:"plan,omitempty"`
 }
 
 type teamStatusRecentMessage struct {
@@ -526,16 +781,30 @@ type teamStatusOutput struct {
 	Team              string                      `json:"team"`
 	Agents            []teamStatusAgentInfo       `json:"agents"`
 	Tasks             teamStatusTaskSummary       `json:"tasks"`
+	RunningTasks      []teamStatusRunningTask     `json:"runningTasks,omitempty"`
 	RecentCompletions []teamStatusRecentCompletion `json:"recentCompletions"`
 	RecentMessages    []teamStatusRecentMessage   `json:"recentMessages,omitempty"`
 	Notifications     []taskNotification          `json:"pending_notifications,omitempty"`
+	Warning           string                      `json:"warning,omitempty"` // e.g. agents paused by the power policy
 }
 
 func teamStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamStatusInput) (*mcpsdk.CallToolResult, teamStatusOutput, error) {
-	cfg, err := agent.GetTeam(input.Name)
+	out, err := buildTeamStatus(input.Name)
 	if err != nil {
 		return nil, teamStatusOutput{}, err
 	}
+	out.Notifications = drainPendingNotifications(req.Session)
+	return nil, out, nil
+}
+
+// buildTeamStatus assembles the team dashboard shared by the team_status tool
+// and the codes://teams/{team}/status resource. It does not drain notifications.
+func buildTeamStatus(name string) (teamStatusOutput, error) {
+	input := teamStatusInput{Name: name}
+	cfg, err := agent.GetTeam(input.Name)
+	if err != nil {
+		return teamStatusOutput{}, err
+	}
 
 	// Agents
 	agents := make([]teamStatusAgentInfo, 0, len(cfg.Members))
@@ -552,6 +821,13 @@ func teamStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 			if !state.StartedAt.IsZero() {
 				info.Uptime = time.Since(state.StartedAt).Truncate(time.Second).String()
 			}
+			info.Version = state.Version
+			if skew := agent.AgentVersionSkew(input.Name, m.Name); skew != nil {
+				info.VersionWarning = skew.Error()
+			}
+			if alive {
+				info.PausedReason = state.PausedReason
+			}
 			// Calculate running duration from current task's StartedAt
 			if state.CurrentTask > 0 {
 				if t, err := agent.GetTask(input.Name, state.CurrentTask); err == nil && t.StartedAt != nil {
@@ -568,6 +844,7 @@ func teamStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 	allTasks, _ := agent.ListTasks(input.Name, "", "")
 	var summary teamStatusTaskSummary
 	var completions []teamStatusRecentCompletion
+	var running []teamStatusRunningTask
 
 	for _, t := range allTasks {
 		switch t.Status {
@@ -577,6 +854,11 @@ func teamStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 			summary.Assigned++
 		case agent.TaskRunning:
 			summary.Running++
+			rt := teamStatusRunningTask{ID: t.ID, Subject: t.Subject, Owner: t.Owner, Plan: t.Plan}
+			if t.StartedAt != nil {
+				rt.StartedAt = t.StartedAt.Format("2006-01-02T15:04:05Z")
+			}
+			running = append(running, rt)
 		case agent.TaskCompleted:
 			summary.Completed++
 			cat := ""
@@ -588,6 +870,7 @@ func teamStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 				Subject:     t.Subject,
 				Owner:       t.Owner,
 				CompletedAt: cat,
+				Summary:     t.Summary,
 			})
 		case agent.TaskFailed:
 			summary.Failed++
@@ -614,20 +897,40 @@ func teamStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input t
 		}
 	}
 
-	return nil, teamStatusOutput{
+	return teamStatusOutput{
 		Team:              input.Name,
 		Agents:            agents,
 		Tasks:             summary,
+		RunningTasks:      running,
 		RecentCompletions: completions,
 		RecentMessages:    recentMessages,
-		Notifications:     drainPendingNotifications(),
+		Warning:           powerPauseWarning(agents),
 	}, nil
 }
 
+// powerPauseWarning explains a stalled queue when agents have stopped picking
+// up tasks because of the power policy on their machine.
+func powerPauseWarning(agents []teamStatusAgentInfo) string {
+	var paused []string
+	reason := ""
+	for _, a := range agents {
+		if a.PausedReason != "" {
+			paused = append(paused, a.Name)
+			reason = a.PausedReason
+		}
+	}
+	if len(paused) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("task pickup paused by the power policy (%s) for %s; queued tasks resume when the machine is plugged in or cools down",
+		reason, strings.Join(paused, ", "))
+}
+
 // -- team_start_all --
 
 type teamStartAllInput struct {
-	Name string `json:"name" jsonschema:"Team name"`
+	Name   string `json:"name" jsonschema:"Team name"`
+	DryRun bool   `json:"dryRun,omitempty" jsonschema:"Report which agents would be started and why others would not, without starting any"`
 }
 
 type teamStartAllResult struct {
@@ -638,12 +941,21 @@ type teamStartAllResult struct {
 }
 
 type teamStartAllOutput struct {
-	Results       []teamStartAllResult `json:"results"`
-	MonitorActive bool                 `json:"monitor_active"`
-	Notifications []taskNotification   `json:"pending_notifications,omitempty"`
+	Results       []teamStartAllResult   `json:"results,omitempty"`
+	DryRun        []agent.AgentStartPlan `json:"dryRun,omitempty"`
+	Warning       string                 `json:"warning,omitempty"`
+	MonitorActive bool                   `json:"monitor_active"`
+	Notifications []taskNotification     `json:"pending_notifications,omitempty"`
 }
 
 func teamStartAllHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamStartAllInput) (*mcpsdk.CallToolResult, teamStartAllOutput, error) {
+	if input.DryRun {
+		plan, err := agent.PlanStartAll(input.Name)
+		if err != nil {
+			return nil, teamStartAllOutput{}, err
+		}
+		return nil, teamStartAllOutput{DryRun: plan, Warning: claudeMissingWarning(), MonitorActive: monitorRunning.Load()}, nil
+	}
 	agentResults, err := agent.StartAllAgents(input.Name)
 	if err != nil {
 		return nil, teamStartAllOutput{}, err
@@ -664,8 +976,9 @@ func teamStartAllHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input
 
 	return nil, teamStartAllOutput{
 		Results:       results,
+		Warning:       claudeMissingWarning(),
 		MonitorActive: monitorRunning.Load(),
-		Notifications: drainPendingNotifications(),
+		Notifications: drainPendingNotifications(req.Session),
 	}, nil
 }
 
@@ -716,6 +1029,94 @@ func truncateMCP(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
+// -- team_template_save --
+
+type teamTemplateSaveInput struct {
+	Team      string `json:"team" jsonschema:"Team whose members and defaults to capture"`
+	Name      string `json:"name" jsonschema:"Template name"`
+	Overwrite bool   `json:"overwrite,omitempty" jsonschema:"Replace an existing template with the same name"`
+}
+
+type teamTemplateSaveOutput struct {
+	Template *agent.TeamTemplate `json:"template"`
+}
+
+func teamTemplateSaveHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamTemplateSaveInput) (*mcpsdk.CallToolResult, teamTemplateSaveOutput, error) {
+	tmpl, err := agent.SaveTeamTemplate(input.Team, input.Name, input.Overwrite)
+	if err != nil {
+		return nil, teamTemplateSaveOutput{}, err
+	}
+	return nil, teamTemplateSaveOutput{Template: tmpl}, nil
+}
+
+// -- team_template_list --
+
+type teamTemplateListInput struct{}
+
+type teamTemplateListOutput struct {
+	Templates []*agent.TeamTemplate `json:"templates"`
+}
+
+func teamTemplateListHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamTemplateListInput) (*mcpsdk.CallToolResult, teamTemplateListOutput, error) {
+	templates, err := agent.ListTeamTemplates()
+	if err != nil {
+		return nil, teamTemplateListOutput{}, err
+	}
+	if templates == nil {
+		templates = []*agent.TeamTemplate{}
+	}
+	return nil, teamTemplateListOutput{Templates: templates}, nil
+}
+
+// -- team_template_instantiate --
+
+type teamTemplateInstantiateInput struct {
+	Template    string `json:"template" jsonschema:"Template to create the team from"`
+	Name        string `json:"name" jsonschema:"Name of the new team"`
+	Description string `json:"description,omitempty" jsonschema:"Team description (default: the template's)"`
+	WorkDir     string `json:"workDir,omitempty" jsonschema:"Working directory for agents (default: the template's)"`
+	Start       bool   `json:"start,omitempty" jsonschema:"Start all agents once the team is created"`
+}
+
+type teamTemplateInstantiateOutput struct {
+	Team    *agent.TeamConfig    `json:"team"`
+	Started []teamStartAllResult `json:"started,omitempty"`
+	Warning string               `json:"warning,omitempty"`
+}
+
+func teamTemplateInstantiateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamTemplateInstantiateInput) (*mcpsdk.CallToolResult, teamTemplateInstantiateOutput, error) {
+	if input.Name == "" {
+		return nil, teamTemplateInstantiateOutput{}, fmt.Errorf("name is required")
+	}
+	cfg, err := agent.InstantiateTeamTemplate(input.Template, input.Name, agent.TeamFromTemplate{
+		Description: input.Description,
+		WorkDir:     input.WorkDir,
+	})
+	if err != nil {
+		return nil, teamTemplateInstantiateOutput{}, err
+	}
+	out := teamTemplateInstantiateOutput{Team: cfg}
+	if !input.Start {
+		return nil, out, nil
+	}
+
+	agentResults, err := agent.StartAllAgents(input.Name)
+	if err != nil {
+		return nil, teamTemplateInstantiateOutput{}, err
+	}
+	for _, ar := range agentResults {
+		out.Started = append(out.Started, teamStartAllResult{
+			Name:    ar.Name,
+			Started: ar.Started,
+			PID:     ar.PID,
+			Error:   ar.Error,
+		})
+	}
+	out.Warning = claudeMissingWarning()
+	ensureMonitorRunning(mcpServer)
+	return nil, out, nil
+}
+
 // -- team_activity --
 
 type teamActivityInput struct {
@@ -749,90 +1150,26 @@ func teamActivityHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input
 		limit = 100
 	}
 
-	var events []activityEvent
-
-	// Source 1: Messages
-	if msgs, err := agent.GetAllTeamMessages(input.Name, 0); err == nil {
-		for _, msg := range msgs {
-			eventType := "message"
-			switch msg.Type {
-			case agent.MsgTaskCompleted:
-				eventType = "task_completed"
-			case agent.MsgTaskFailed:
-				eventType = "task_failed"
-			case agent.MsgProgress:
-				eventType = "progress"
-			case agent.MsgHelpRequest:
-				eventType = "help_request"
-			case agent.MsgDiscovery:
-				eventType = "discovery"
-			}
-			summary := truncateMCP(msg.Content, 150)
-			if msg.To != "" {
-				summary = fmt.Sprintf("[to %s] %s", msg.To, summary)
-			}
-			events = append(events, activityEvent{
-				Timestamp: msg.CreatedAt.Format("2006-01-02T15:04:05Z"),
-				Type:      eventType,
-				Agent:     msg.From,
-				Summary:   summary,
-				TaskID:    msg.TaskID,
-			})
-		}
+	activity, err := agent.TeamActivity(input.Name)
+	if err != nil {
+		return nil, teamActivityOutput{}, err
 	}
-
-	// Source 2: Task lifecycle events
-	if tasks, err := agent.ListTasks(input.Name, "", ""); err == nil {
-		for _, t := range tasks {
-			// Task created
-			events = append(events, activityEvent{
-				Timestamp: t.CreatedAt.Format("2006-01-02T15:04:05Z"),
-				Type:      "task_created",
-				Agent:     t.Owner,
-				Summary:   fmt.Sprintf("Task #%d created: %s", t.ID, t.Subject),
-				TaskID:    t.ID,
-			})
-			// Task started
-			if t.StartedAt != nil {
-				events = append(events, activityEvent{
-					Timestamp: t.StartedAt.Format("2006-01-02T15:04:05Z"),
-					Type:      "task_started",
-					Agent:     t.Owner,
-					Summary:   fmt.Sprintf("Task #%d started: %s", t.ID, t.Subject),
-					TaskID:    t.ID,
-				})
-			}
-			// Task completed
-			if t.CompletedAt != nil && t.Status == agent.TaskCompleted {
-				events = append(events, activityEvent{
-					Timestamp: t.CompletedAt.Format("2006-01-02T15:04:05Z"),
-					Type:      "task_completed",
-					Agent:     t.Owner,
-					Summary:   fmt.Sprintf("Task #%d completed: %s", t.ID, t.Subject),
-					TaskID:    t.ID,
-				})
-			}
-			// Task failed
-			if t.CompletedAt != nil && t.Status == agent.TaskFailed {
-				events = append(events, activityEvent{
-					Timestamp: t.CompletedAt.Format("2006-01-02T15:04:05Z"),
-					Type:      "task_failed",
-					Agent:     t.Owner,
-					Summary:   fmt.Sprintf("Task #%d failed: %s", t.ID, t.Subject),
-					TaskID:    t.ID,
-				})
-			}
-		}
+	if len(activity) > limit {
+		activity = activity[:limit]
 	}
-
-	// Sort all events by timestamp descending
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].Timestamp > events[j].Timestamp
-	})
-
-	// Apply limit
-	if len(events) > limit {
-		events = events[:limit]
+	events := make([]activityEvent, 0, len(activity))
+	for _, e := range activity {
+		summary := truncateMCP(e.Summary, 150)
+		if e.To != "" {
+			summary = fmt.Sprintf("[to %s] %s", e.To, summary)
+		}
+		events = append(events, activityEvent{
+			Timestamp: e.Time.Format("2006-01-02T15:04:05Z"),
+			Type:      e.Type,
+			Agent:     e.Agent,
+			Summary:   summary,
+			TaskID:    e.TaskID,
+		})
 	}
 
 	return nil, teamActivityOutput{
@@ -841,141 +1178,230 @@ func teamActivityHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input
 	}, nil
 }
 
+// -- usage_report --
+
+type usageReportInput struct {
+	Team   string `json:"team,omitempty" jsonschema:"Team name (optional, default: all teams)"`
+	Period string `json:"period,omitempty" jsonschema:"Time period: today, week, month, all (default: today). Ignored when since is set"`
+	Since  string `json:"since,omitempty" jsonschema:"Start of the window: a duration back from now such as 12h, or an RFC 3339 timestamp (optional)"`
+	Until  string `json:"until,omitempty" jsonschema:"End of the window as an RFC 3339 timestamp (optional, default: now)"`
+}
+
+type usageReportOutput struct {
+	*agent.UsageReport
+}
+
+func usageReportHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input usageReportInput) (*mcpsdk.CallToolResult, usageReportOutput, error) {
+	period := input.Period
+	if period == "" {
+		period = "today"
+	}
+	from, to := parseTimeRange(period)
+	if input.Since != "" {
+		t, err := parseUsageTime(input.Since)
+		if err != nil {
+			return nil, usageReportOutput{}, fmt.Errorf("invalid since: %w", err)
+		}
+		from, to = t, time.Time{}
+	}
+	if input.Until != "" {
+		t, err := time.Parse(time.RFC3339, input.Until)
+		if err != nil {
+			return nil, usageReportOutput{}, fmt.Errorf("invalid until: %w", err)
+		}
+		to = t
+	}
+
+	report, err := agent.GetUsageReport(input.Team, from, to)
+	if err != nil {
+		return nil, usageReportOutput{}, err
+	}
+	return nil, usageReportOutput{report}, nil
+}
+
+// parseUsageTime accepts either a duration back from now ("12h", "30m") or
+// an RFC 3339 timestamp.
+func parseUsageTime(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 func registerAgentTools(server *mcpsdk.Server) {
 	mcpServer = server
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_create",
+		Annotations: additive(true),
 		Description: "Create a new agent team workspace with directories for tasks, messages, and agent state",
 	}, teamCreateHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_delete",
-		Description: "Delete a team and all its data (tasks, messages, agents)",
+		Annotations: destructive(true),
+		Description: "Delete a team and all its data (tasks, messages, agents). Clients that support elicitation are asked to confirm first.",
 	}, teamDeleteHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_list",
+		Annotations: readOnly(),
 		Description: "List all configured teams",
 	}, teamListHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_get",
+		Annotations: readOnly(),
 		Description: "Get team configuration and live agent statuses",
 	}, teamGetHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_status",
-		Description: "Get a team dashboard with agent statuses, task summary, and recent completions. Also returns any pending agent notifications.",
+		Annotations: readOnly(),
+		Description: "Get a team dashboard with agent statuses, task summary, running tasks with the plan each agent posted when it started (redirect a task whose plan is wrong), and recent completions. Also returns any pending agent notifications.",
 	}, teamStatusHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_start_all",
-		Description: "Start all agent daemons in a team, skipping already running agents. Notifications are piggybacked in subsequent agent tool responses via pending_notifications. RECOMMENDED: after starting, call team_watch and run the returned command in a background Task (run_in_background=true, subagent_type=Bash) for real-time notifications. Also call team_status periodically to check progress.",
+		Annotations: additive(true),
+		Description: "Start all agent daemons in a team, skipping already running agents. Notifications are piggybacked in subsequent agent tool responses via pending_notifications. RECOMMENDED: after starting, call notifications_poll in a loop (passing back nextSequence) for real-time notifications, or team_status periodically to check progress. With dryRun, only reports which agents would start.",
 	}, teamStartAllHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_stop_all",
+		Annotations: destructive(true),
 		Description: "Send stop signals to all agents in a team",
 	}, teamStopAllHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "agent_add",
+		Annotations: additive(true),
 		Description: "Register a new agent in a team",
 	}, agentAddHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "agent_remove",
+		Annotations: destructive(true),
 		Description: "Remove an agent from a team",
 	}, agentRemoveHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "agent_list",
+		Annotations: readOnly(),
 		Description: "List all agents in a team with their live status. Also returns any pending agent notifications.",
 	}, agentListHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "agent_start",
-		Description: "Start an agent daemon that polls for and executes tasks. Notifications are piggybacked in subsequent agent tool responses via pending_notifications. RECOMMENDED: after starting, call team_watch and run the returned command in a background Task (run_in_background=true, subagent_type=Bash) for real-time notifications.",
+		Annotations: additive(true),
+		Description: "Start an agent daemon that polls for and executes tasks. Notifications are piggybacked in subsequent agent tool responses via pending_notifications. RECOMMENDED: after starting, call notifications_poll in a loop (passing back nextSequence) for real-time notifications.",
 	}, agentStartHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "agent_stop",
+		Annotations: destructive(true),
 		Description: "Stop a running agent daemon gracefully",
 	}, agentStopHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
+		Name:        "agent_logs",
+		Annotations: readOnly(),
+		Description: "Read the tail of an agent daemon's log (task pickup, Claude runs, errors), optionally filtered by a regular expression. Use to diagnose an agent that is stuck or failing tasks.",
+	}, agentLogsHandler)
+
+	addTool(server, &mcpsdk.Tool{
 		Name:        "task_create",
-		Description: "Create a new task in a team, optionally assigning it to an agent. Notifications are piggybacked in subsequent agent tool responses via pending_notifications. After creating tasks, periodically call team_status to check for completion. For real-time monitoring, call team_watch and run the returned command in a background Task.",
+		Annotations: additive(false),
+		Description: "Create a new task in a team, optionally assigning it to an agent. Notifications are piggybacked in subsequent agent tool responses via pending_notifications. After creating tasks, periodically call team_status to check for completion. For real-time monitoring, call notifications_poll. With dryRun, validates and reports the task that would be created, its working directory and any problems, without creating it.",
 	}, taskCreateHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
+		Name:        "tasks_create_batch",
+		Annotations: additive(false),
+		Description: "Create several tasks in one call. Use dependsOn with 1-based positions to make a task wait for earlier tasks in the same batch. Either every task is created or none are; the result maps each position to its new task ID.",
+	}, tasksCreateBatchHandler)
+
+	addTool(server, &mcpsdk.Tool{
 		Name:        "task_update",
-		Description: "Update task fields including status, owner, result, or description",
+		Annotations: destructive(true),
+		Description: "Update task fields including status, owner, result, or description. Status changes must follow the task lifecycle (pending → assigned → running → completed/failed/cancelled; failed tasks may be re-queued); invalid transitions are rejected.",
 	}, taskUpdateHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "task_redirect",
-		Description: "Cancel a running task and create a new one with updated instructions. The new task inherits the original task's owner, priority, project, and working directory. The agent daemon will automatically detect the cancellation (within ~3 seconds), terminate the running Claude subprocess, and pick up the new task.",
+		Annotations: destructive(false),
+		Description: "Cancel a running task and create a new one with updated instructions. The new task inherits the original task's owner, priority, project, and working directory. The agent daemon will automatically detect the cancellation (within ~3 seconds), terminate the running Claude subprocess, and pick up the new task. Clients that support elicitation are asked to confirm first. With dryRun, reports what would be cancelled and created without asking or changing anything.",
 	}, taskRedirectHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
+		Name:        "task_followup",
+		Annotations: additive(false),
+		Description: "Follow up on a completed task: creates a new task that resumes the original task's Claude session with additional instructions, on the same agent and in the same working directory. Cheaper and more coherent than a fresh task that has to re-explain the earlier context.",
+	}, taskFollowupHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "task_answer",
+		Annotations: additive(false),
+		Description: "Answer a human task (type 'human') with what the user decided. Only relay the user's own answer; never make the decision for them. The task completes with the answer as its result and the tasks blocked by it start.",
+	}, taskAnswerHandler)
+
+	addTool(server, &mcpsdk.Tool{
 		Name:        "task_list",
+		Annotations: readOnly(),
 		Description: "List tasks in a team with optional status and owner filters. Also returns any pending agent notifications.",
 	}, taskListHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "task_get",
-		Description: "Get full details of a specific task including result and session info. Also returns any pending agent notifications.",
+		Annotations: readOnly(),
+		Description: "Get full details of a specific task including result, session info, and collected artifact files. Also returns any pending agent notifications.",
 	}, taskGetHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "message_send",
+		Annotations: additive(false),
 		Description: "Send a message from one agent to another, or broadcast to all agents",
 	}, messageSendHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "message_list",
+		Annotations: readOnly(),
 		Description: "List messages for an agent, with optional type and unread filters. Use this to read task completion reports and agent responses.",
 	}, messageListHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "message_mark_read",
+		Annotations: additive(true),
 		Description: "Mark a specific message as read",
 	}, messageMarkReadHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "test_sampling",
+		Annotations: readOnly(),
 		Description: "Test MCP sampling: send a createMessage request back to the client to verify sampling support",
 	}, testSamplingHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "test_progress",
+		Annotations: readOnly(),
 		Description: "Test MCP progress notifications: checks if client sends a progress token and attempts to send progress notifications back. Use this to verify if real-time progress updates work.",
 	}, testProgressHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
-		Name: "team_watch",
-		Description: `Get a bash command that monitors agent task completion notifications in real time.
-
-RECOMMENDED USAGE — run BOTH steps after starting agents:
-
-Step 1 (bash log): Call team_watch, then run the returned command in a background Bash Task:
-  Task(subagent_type="Bash", run_in_background=True, prompt="Run: <command>")
-  → Writes notifications to an output file. You must actively read that file to see results.
-  → Does NOT automatically wake the main session.
-
-Step 2 (auto-wakeup): ALSO spawn a background general-purpose Task that calls team_subscribe:
-  Task(subagent_type="general-purpose", run_in_background=True,
-       prompt="Call team_subscribe for team '<name>' and report when any task notification arrives.")
-  → Blocks until a notification arrives, then exits and triggers a <task-notification> that
-    automatically wakes the main session — no polling needed.
-
-Use both together: bash watch for the running log, team_subscribe for automatic wakeup.`,
-	}, teamWatchHandler)
-
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
-		Name: "team_subscribe",
+	addTool(server, &mcpsdk.Tool{
+		Name:        "notifications_poll",
+		Annotations: readOnly(),
+		Description: "Wait for agent task notifications (completed, failed, cancelled) and return them as structured events. Returns as soon as an event after sinceSequence matches the team, agent and status filters, or when timeout seconds pass. Pass the returned nextSequence as sinceSequence on the next call to continue where you left off; nothing is consumed, so several callers can poll independently. Clients that support MCP resource subscriptions can subscribe to codes://teams/<name>/status instead.",
+	}, notificationsPollHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "monitor_status",
+		Annotations: readOnly(),
+		Description: "Report the health of the background notification monitor: whether it is running, when it last scanned for notifications, how often its watchdog has restarted it, how many notifications are queued for delivery, and the recent errors it ran into. The monitor starts with the first agent tool call and is restarted with backoff when it dies or stalls.",
+	}, monitorStatusHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "team_subscribe",
+		Annotations: readOnly(),
 		Description: `Subscribe to agent task notifications for a team. This tool BLOCKS until a notification arrives or timeout is reached.
 
 HOW IT WORKS:
@@ -983,6 +1409,7 @@ HOW IT WORKS:
 - The agent blocks waiting; when a task completes/fails, the tool returns.
 - The background agent then exits, automatically triggering a <task-notification> that wakes the main session.
 - No polling required — this is event-driven and instant.
+- Clients that support MCP resource subscriptions can instead subscribe to codes://teams/<name>/status or codes://teams/<name>/tasks/<id> and receive notifications/resources/updated without blocking a tool call.
 
 CORRECT USAGE (after starting agents):
   Task(subagent_type="general-purpose", run_in_background=True,
@@ -992,14 +1419,38 @@ WRONG USAGE:
   ❌ Calling team_subscribe directly in the main session — blocks everything
   ❌ Running inside a Bash Task — Bash cannot call MCP tools
 
-IMPORTANT: Also run team_watch in a background Bash Task to capture the full notification log.
-Prefer this over team_watch alone for automatic main-session wakeup.`,
+To follow every event without blocking a background Task, use notifications_poll instead.`,
 	}, teamSubscribeHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
+		Name:        "team_template_save",
+		Annotations: destructive(true),
+		Description: "Save a team's member roster (names, roles, models, types, poll settings) and defaults (description, workDir) as a named template. Tasks and messages are not included. Set overwrite to replace an existing template.",
+	}, teamTemplateSaveHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "team_template_list",
+		Annotations: readOnly(),
+		Description: "List saved team templates with their members",
+	}, teamTemplateListHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "team_template_instantiate",
+		Annotations: additive(true),
+		Description: "Create a new team from a saved template, e.g. a standard 1 leader + 3 workers review team, in one call. description and workDir override the template's. Set start to also start all agents.",
+	}, teamTemplateInstantiateHandler)
+
+	addTool(server, &mcpsdk.Tool{
 		Name:        "team_activity",
+		Annotations: readOnly(),
 		Description: "Get a unified activity timeline for a team, combining messages and task lifecycle events. Returns events sorted by time (newest first). Use limit parameter to control how many events to return (default 20, max 100).",
 	}, teamActivityHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "usage_report",
+		Annotations: readOnly(),
+		Description: "Report token usage and cost of agent task runs per team and per agent over a time window (by default today). Use since (e.g. 12h) to answer questions like how much last night's run cost. Only tasks that finished in the window are counted; tasks whose CLI reported no usage are counted as untracked.",
+	}, usageReportHandler)
 }
 
 // -- test_sampling --
@@ -1119,38 +1570,65 @@ func testProgressHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input
 	return nil, output, nil
 }
 
-// -- team_watch --
+// -- notifications_poll --
+
+const (
+	defaultPollTimeout = 60 * time.Second
+	maxPollTimeout     = 10 * time.Minute
+)
 
-type teamWatchInput struct {
-	Team    string `json:"team,omitempty" jsonschema:"Optional team name to filter notifications"`
-	Timeout int    `json:"timeout,omitempty" jsonschema:"Monitoring duration in minutes (default 30)"`
+type notificationsPollInput struct {
+	Team          string `json:"team,omitempty" jsonschema:"Only return events for this team"`
+	Agent         string `json:"agent,omitempty" jsonschema:"Only return events from this agent"`
+	Status        string `json:"status,omitempty" jsonschema:"Only return events with this task status (completed, failed, cancelled)"`
+	SinceSequence int64  `json:"sinceSequence,omitempty" jsonschema:"Return events after this sequence number: nextSequence from the previous call (default 0: every buffered event)"`
+	Timeout       int    `json:"timeout,omitempty" jsonschema:"Max seconds to wait for a matching event (default 60, max 600)"`
 }
 
-type teamWatchOutput struct {
-	Command     string `json:"command" jsonschema:"Bash command to run in a background Task for monitoring"`
-	Instruction string `json:"instruction" jsonschema:"How to use this command"`
+type notificationsPollOutput struct {
+	Events       []taskNotification `json:"events"`
+	NextSequence int64              `json:"nextSequence"`
+	TimedOut     bool               `json:"timedOut"`
 }
 
-func teamWatchHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input teamWatchInput) (*mcpsdk.CallToolResult, teamWatchOutput, error) {
-	timeout := input.Timeout
-	if timeout <= 0 {
-		timeout = 30
+func notificationsPollHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input notificationsPollInput) (*mcpsdk.CallToolResult, notificationsPollOutput, error) {
+	timeout := defaultPollTimeout
+	if input.Timeout > 0 {
+		timeout = min(time.Duration(input.Timeout)*time.Second, maxPollTimeout)
 	}
-	iterations := timeout * 12 // 5-second intervals
+	filter := notificationFilter{Team: input.Team, Agent: input.Agent, Status: input.Status}
 
-	// Use find instead of ls to avoid TOCTOU races and handle filenames with spaces.
-	// The __ separator matches the updated writeNotification filename format.
-	filter := "*.json"
-	if input.Team != "" {
-		filter = fmt.Sprintf("%s__*.json", input.Team)
-	}
+	ensureMonitorRunning(mcpServer)
 
-	cmd := fmt.Sprintf(`mkdir -p ~/.codes/notifications && echo "Monitoring agent notifications (timeout: %dm)..." && for i in $(seq 1 %d); do found=0; for f in $(find ~/.codes/notifications -maxdepth 1 -name '%s' -type f 2>/dev/null); do echo "=== Agent Notification ==="; cat "$f" && rm -f "$f"; echo ""; found=1; done; sleep 5; done && echo "Monitor timeout reached"`, timeout, iterations, filter)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Wake the wait below when the poll times out or the client goes away.
+	stop := context.AfterFunc(ctx, func() {
+		pendingMu.Lock()
+		notifCond.Broadcast()
+		pendingMu.Unlock()
+	})
+	defer stop()
 
-	return nil, teamWatchOutput{
-		Command:     cmd,
-		Instruction: "Run this command using the Task tool with run_in_background=true and subagent_type=Bash. You will be automatically notified when agents complete tasks.",
-	}, nil
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	since := input.SinceSequence
+	if since > notificationSeq {
+		// Sequences restart with the server; a cursor from before the
+		// restart would otherwise skip every new event.
+		since = 0
+	}
+	for {
+		events := notificationsSinceLocked(since, filter)
+		if len(events) > 0 || ctx.Err() != nil {
+			return nil, notificationsPollOutput{
+				Events:       append([]taskNotification{}, events...),
+				NextSequence: notificationSeq,
+				TimedOut:     len(events) == 0,
+			}, nil
+		}
+		notifCond.Wait()
+	}
 }
 
 // -- team_subscribe --
@@ -1188,7 +1666,7 @@ func teamSubscribeHandler(ctx context.Context, req *mcpsdk.CallToolRequest, inpu
 	// The cond is based on pendingMu, so we hold the lock during Wait.
 	pendingMu.Lock()
 	for {
-		matched := drainTeamNotificationsLocked(input.Team)
+		matched := drainTeamNotificationsLocked(req.Session, input.Team)
 		if len(matched) > 0 {
 			// Return immediately so the background agent exits and triggers
 			// a <task-notification> to the main session.
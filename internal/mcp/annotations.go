@@ -0,0 +1,28 @@
+package mcpserver
+
+import mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+// Every tool carries annotations describing its effect, so clients can
+// auto-approve reads such as team_status and task_list and still ask before
+// tools that delete, stop or overwrite things.
+
+// readOnly annotates a tool that changes nothing.
+func readOnly() *mcpsdk.ToolAnnotations {
+	return &mcpsdk.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// additive annotates a tool that creates or updates state without removing
+// or overwriting anything. idempotent means repeating the call with the same
+// arguments has no further effect.
+func additive(idempotent bool) *mcpsdk.ToolAnnotations {
+	destructive := false
+	return &mcpsdk.ToolAnnotations{DestructiveHint: &destructive, IdempotentHint: idempotent}
+}
+
+// destructive annotates a tool that deletes, overwrites or interrupts
+// something: removing a team, stopping an agent mid-task, syncing over a
+// remote host's settings.
+func destructive(idempotent bool) *mcpsdk.ToolAnnotations {
+	destructive := true
+	return &mcpsdk.ToolAnnotations{DestructiveHint: &destructive, IdempotentHint: idempotent}
+}
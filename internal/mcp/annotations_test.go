@@ -0,0 +1,55 @@
+package mcpserver
+
+import (
+	"context"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestToolAnnotations(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ss, err := buildServer().Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	cs, err := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	res, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := make(map[string]*mcpsdk.ToolAnnotations)
+	for _, tool := range res.Tools {
+		if tool.Annotations == nil {
+			t.Errorf("tool %s has no annotations", tool.Name)
+			continue
+		}
+		if !tool.Annotations.ReadOnlyHint && tool.Annotations.DestructiveHint == nil {
+			t.Errorf("tool %s changes state but does not say whether it is destructive", tool.Name)
+		}
+		tools[tool.Name] = tool.Annotations
+	}
+
+	for _, name := range []string{"team_status", "task_list", "task_get", "agent_logs", "usage_report"} {
+		if a := tools[name]; a == nil || !a.ReadOnlyHint {
+			t.Errorf("%s should be read-only: %+v", name, a)
+		}
+	}
+	for _, name := range []string{"team_delete", "agent_stop", "task_redirect", "schedule_cancel"} {
+		if a := tools[name]; a == nil || a.ReadOnlyHint || a.DestructiveHint == nil || !*a.DestructiveHint {
+			t.Errorf("%s should be destructive: %+v", name, a)
+		}
+	}
+	if a := tools["task_create"]; a == nil || a.ReadOnlyHint || *a.DestructiveHint || a.IdempotentHint {
+		t.Errorf("task_create should be additive and not idempotent: %+v", a)
+	}
+}
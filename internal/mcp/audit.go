@@ -0,0 +1,79 @@
+package mcpserver
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/audit"
+)
+
+// readOnlyTools holds the names of tools annotated readOnly, whose calls are
+// not audited.
+var (
+	readOnlyMu    sync.RWMutex
+	readOnlyTools = make(map[string]bool)
+)
+
+// addTool registers a tool like mcpsdk.AddTool and notes whether it is
+// read-only for auditCalls.
+func addTool[In, Out any](s *mcpsdk.Server, t *mcpsdk.Tool, h mcpsdk.ToolHandlerFor[In, Out]) {
+	if t.Annotations != nil && t.Annotations.ReadOnlyHint {
+		readOnlyMu.Lock()
+		readOnlyTools[t.Name] = true
+		readOnlyMu.Unlock()
+	}
+	mcpsdk.AddTool(s, t, h)
+}
+
+// auditCalls records calls to tools that change state with audit.Record,
+// naming the client that made them.
+func auditCalls(next mcpsdk.MethodHandler) mcpsdk.MethodHandler {
+	return func(ctx context.Context, method string, req mcpsdk.Request) (mcpsdk.Result, error) {
+		call, ok := req.(*mcpsdk.CallToolRequest)
+		if !ok || call.Params == nil {
+			return next(ctx, method, req)
+		}
+		readOnlyMu.RLock()
+		skip := readOnlyTools[call.Params.Name]
+		readOnlyMu.RUnlock()
+		if skip {
+			return next(ctx, method, req)
+		}
+
+		res, err := next(ctx, method, req)
+
+		e := audit.Entry{
+			Source: audit.SourceMCP,
+			Actor:  clientName(call.Session),
+			Action: call.Params.Name,
+			Params: audit.Digest(call.Params.Arguments),
+			Result: audit.ResultOK,
+		}
+		if err != nil {
+			e.Result, e.Error = audit.ResultError, err.Error()
+		} else if result, ok := res.(*mcpsdk.CallToolResult); ok && result.IsError {
+			e.Result = audit.ResultError
+			if toolErr := result.GetError(); toolErr != nil {
+				e.Error = toolErr.Error()
+			}
+		}
+		if rerr := audit.Record(e); rerr != nil {
+			log.Printf("[mcp] audit: %v", rerr)
+		}
+		return res, err
+	}
+}
+
+// clientName returns the name the MCP client gave when it connected.
+func clientName(ss *mcpsdk.ServerSession) string {
+	if ss == nil {
+		return ""
+	}
+	if p := ss.InitializeParams(); p != nil && p.ClientInfo != nil {
+		return p.ClientInfo.Name
+	}
+	return ""
+}
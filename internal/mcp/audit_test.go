@@ -0,0 +1,73 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/audit"
+)
+
+func TestAuditCalls(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	type in struct {
+		Name string `json:"name"`
+	}
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "codes-test", Version: "0.0.1"}, nil)
+	server.AddReceivingMiddleware(auditCalls)
+	addTool(server, &mcpsdk.Tool{Name: "audit_test_read", Annotations: readOnly()},
+		func(context.Context, *mcpsdk.CallToolRequest, in) (*mcpsdk.CallToolResult, any, error) {
+			return &mcpsdk.CallToolResult{}, nil, nil
+		})
+	addTool(server, &mcpsdk.Tool{Name: "audit_test_write", Annotations: additive(false)},
+		func(_ context.Context, _ *mcpsdk.CallToolRequest, args in) (*mcpsdk.CallToolResult, any, error) {
+			if args.Name == "bad" {
+				return nil, nil, errors.New("no such thing")
+			}
+			return &mcpsdk.CallToolResult{}, nil, nil
+		})
+
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	cs, err := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "audit-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	for _, call := range []struct{ tool, name string }{
+		{"audit_test_read", "x"},
+		{"audit_test_write", "good"},
+		{"audit_test_write", "bad"},
+	} {
+		if _, err := cs.CallTool(ctx, &mcpsdk.CallToolParams{Name: call.tool, Arguments: map[string]any{"name": call.name}}); err != nil {
+			t.Fatalf("CallTool(%s): %v", call.tool, err)
+		}
+	}
+
+	entries, err := audit.Query(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%d audit entries, want 2 (read-only call skipped): %+v", len(entries), entries)
+	}
+	failed, ok := entries[0], entries[1]
+	if ok.Action != "audit_test_write" || ok.Result != audit.ResultOK || ok.Actor != "audit-client" || ok.Source != audit.SourceMCP || ok.Params == "" {
+		t.Errorf("successful call entry = %+v", ok)
+	}
+	if failed.Result != audit.ResultError || failed.Error != "no such thing" {
+		t.Errorf("failed call entry = %+v", failed)
+	}
+	if ok.Params == failed.Params {
+		t.Error("different arguments have the same digest")
+	}
+}
@@ -0,0 +1,111 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+)
+
+// ConfirmDestructive makes irreversible tools (team_delete, task_redirect)
+// ask the user to confirm through MCP elicitation before acting. Clients
+// without elicitation support are never asked. Set by serve; `codes serve
+// --no-confirm` turns it off for headless use.
+var ConfirmDestructive = true
+
+// confirmSchema is the elicitation form: a single yes/no field.
+var confirmSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"confirm": map[string]any{
+			"type":        "boolean",
+			"title":       "Proceed",
+			"description": "This cannot be undone",
+		},
+	},
+	"required": []string{"confirm"},
+}
+
+// confirmAction asks the user to approve a destructive action described by
+// message. It returns nil when confirmation is disabled, the client cannot
+// elicit, or the user accepted; otherwise an error the tool should return.
+func confirmAction(ctx context.Context, req *mcpsdk.CallToolRequest, message string) error {
+	if !ConfirmDestructive || req == nil || req.Session == nil {
+		return nil
+	}
+	params := req.Session.InitializeParams()
+	if params == nil || params.Capabilities == nil || params.Capabilities.Elicitation == nil {
+		return nil
+	}
+
+	res, err := req.Session.Elicit(ctx, &mcpsdk.ElicitParams{
+		Message:         message,
+		RequestedSchema: confirmSchema,
+	})
+	if err != nil {
+		return fmt.Errorf("could not get confirmation: %w", err)
+	}
+	if res.Action != "accept" {
+		return fmt.Errorf("not confirmed by the user (%s); nothing was changed", res.Action)
+	}
+	if ok, _ := res.Content["confirm"].(bool); !ok {
+		return fmt.Errorf("not confirmed by the user; nothing was changed")
+	}
+	return nil
+}
+
+// teamDeleteSummary describes what deleting a team throws away.
+func teamDeleteSummary(name string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Delete team %q and all of its data?", name)
+
+	if tasks, err := agent.ListTasks(name, "", ""); err == nil && len(tasks) > 0 {
+		counts := map[agent.TaskStatus]int{}
+		for _, t := range tasks {
+			counts[t.Status]++
+		}
+		var parts []string
+		for _, s := range []agent.TaskStatus{agent.TaskRunning, agent.TaskAssigned, agent.TaskPending, agent.TaskCompleted, agent.TaskFailed, agent.TaskCancelled} {
+			if counts[s] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+			}
+		}
+		fmt.Fprintf(&sb, "\n- %d tasks with their results and artifacts (%s)", len(tasks), strings.Join(parts, ", "))
+	}
+	if msgs, err := agent.GetAllTeamMessages(name, 0); err == nil && len(msgs) > 0 {
+		fmt.Fprintf(&sb, "\n- %d messages", len(msgs))
+	}
+	if cfg, err := agent.GetTeam(name); err == nil {
+		var alive []string
+		for _, m := range cfg.Members {
+			if agent.IsAgentAlive(name, m.Name) {
+				alive = append(alive, m.Name)
+			}
+		}
+		fmt.Fprintf(&sb, "\n- %d agents and their logs", len(cfg.Members))
+		if len(alive) > 0 {
+			fmt.Fprintf(&sb, " (still running: %s)", strings.Join(alive, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// taskRedirectSummary describes what redirecting a task throws away.
+func taskRedirectSummary(team string, taskID int) string {
+	task, err := agent.GetTask(team, taskID)
+	if err != nil {
+		return fmt.Sprintf("Cancel task #%d in team %q and replace it with new instructions?", taskID, team)
+	}
+	msg := fmt.Sprintf("Cancel task #%d %q (%s) in team %q and replace it with new instructions?", task.ID, task.Subject, task.Status, team)
+	if task.Status == agent.TaskRunning {
+		who := task.Owner
+		if who == "" {
+			who = "its agent"
+		}
+		msg += fmt.Sprintf("\n- the run in progress on %s is stopped and its unfinished work is lost", who)
+	}
+	return msg
+}
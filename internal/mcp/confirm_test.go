@@ -0,0 +1,117 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+)
+
+// connectElicitingClient connects a client that answers every elicitation
+// with action, recording the messages it was shown.
+func connectElicitingClient(t *testing.T, action string, confirm bool) (*mcpsdk.ClientSession, *[]string) {
+	t.Helper()
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "codes-test", Version: "0.0.1"}, nil)
+	registerAgentTools(server)
+	ct, st := mcpsdk.NewInMemoryTransports()
+
+	ctx := context.Background()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asked []string
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "0.0.1"}, &mcpsdk.ClientOptions{
+		ElicitationHandler: func(ctx context.Context, req *mcpsdk.ElicitRequest) (*mcpsdk.ElicitResult, error) {
+			asked = append(asked, req.Params.Message)
+			res := &mcpsdk.ElicitResult{Action: action}
+			if action == "accept" {
+				res.Content = map[string]any{"confirm": confirm}
+			}
+			return res, nil
+		},
+	})
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cs.Close()
+		ss.Close()
+	})
+	return cs, &asked
+}
+
+func callToolErr(t *testing.T, cs *mcpsdk.ClientSession, name string, args any) bool {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := cs.CallTool(ctx, &mcpsdk.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("CallTool(%s): %v", name, err)
+	}
+	return res.IsError
+}
+
+func TestTeamDeleteAsksForConfirmation(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	if _, err := agent.CreateTeam(team, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.DeleteTeam(team)
+	agent.CreateTask(team, "keep me", "", "", nil, "", "", "")
+
+	// Declined: the team survives
+	cs, asked := connectElicitingClient(t, "decline", false)
+	if !callToolErr(t, cs, "team_delete", map[string]any{"name": team}) {
+		t.Error("expected team_delete to fail when declined")
+	}
+	if _, err := agent.GetTeam(team); err != nil {
+		t.Fatalf("team deleted despite decline: %v", err)
+	}
+	if len(*asked) != 1 || !strings.Contains((*asked)[0], "1 tasks") {
+		t.Errorf("confirmation message = %q, want a task summary", *asked)
+	}
+
+	// Accepted without ticking confirm: still refused
+	cs, _ = connectElicitingClient(t, "accept", false)
+	if !callToolErr(t, cs, "team_delete", map[string]any{"name": team}) {
+		t.Error("expected team_delete to fail without confirm=true")
+	}
+
+	// Disabled by the server flag: deleted without asking
+	ConfirmDestructive = false
+	defer func() { ConfirmDestructive = true }()
+	cs, asked = connectElicitingClient(t, "decline", false)
+	if callToolErr(t, cs, "team_delete", map[string]any{"name": team}) {
+		t.Error("expected team_delete to succeed with confirmation disabled")
+	}
+	if len(*asked) != 0 {
+		t.Errorf("asked for confirmation although disabled: %q", *asked)
+	}
+}
+
+func TestTaskRedirectConfirmed(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	if _, err := agent.CreateTeam(team, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.DeleteTeam(team)
+	task, _ := agent.CreateTask(team, "old plan", "", "", nil, "", "", "")
+
+	cs, asked := connectElicitingClient(t, "accept", true)
+	if callToolErr(t, cs, "task_redirect", map[string]any{"team": team, "taskId": task.ID, "newInstructions": "new plan"}) {
+		t.Fatal("expected task_redirect to succeed once confirmed")
+	}
+	if len(*asked) != 1 || !strings.Contains((*asked)[0], "old plan") {
+		t.Errorf("confirmation message = %q, want the task subject", *asked)
+	}
+	if got, _ := agent.GetTask(team, task.ID); got.Status != agent.TaskCancelled {
+		t.Errorf("old task status = %s, want cancelled", got.Status)
+	}
+}
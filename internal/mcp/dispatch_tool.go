@@ -43,8 +43,9 @@ func dispatchHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input dis
 }
 
 func registerDispatchTool(server *mcpsdk.Server) {
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
-		Name: "dispatch",
+	addTool(server, &mcpsdk.Tool{
+		Name:        "dispatch",
+		Annotations: destructive(false),
 		Description: `Send a natural language request to the personal assistant.
 The assistant understands intent, manages agent teams, runs tasks, and remembers context.
 
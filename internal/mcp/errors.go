@@ -0,0 +1,102 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+	"codes/internal/config"
+)
+
+// Tool failures carry a machine-readable payload next to the usual error text,
+// so orchestrators can branch on the kind of failure instead of parsing prose:
+//
+//	{"error": {"code": "task_not_found", "message": "task 7 not found in team \"x\"", "details": {"team": "x", "taskId": 7}}}
+//
+// It is sent as the result's structuredContent and as a second text block.
+
+// Error codes reported in toolError.Code.
+const (
+	codeTeamNotFound          = "team_not_found"
+	codeTeamExists            = "team_exists"
+	codeTaskNotFound          = "task_not_found"
+	codeTaskInvalidTransition = "task_invalid_transition"
+	codeAgentNotFound         = "agent_not_found"
+	codeAgentExists           = "agent_exists"
+	codeAgentAlreadyRunning   = "agent_already_running"
+	codeAgentNotRunning       = "agent_not_running"
+	codeTemplateNotFound      = "template_not_found"
+	codeTemplateExists        = "template_exists"
+	codeClaudeNotFound        = "claude_not_found"
+	codeWorkDirNotAllowed     = "workdir_not_allowed"
+	codeCancelled             = "cancelled"
+	codeToolError             = "tool_error" // anything not classified above
+)
+
+// errorCodes maps agent sentinel errors to their codes.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{agent.ErrTeamNotFound, codeTeamNotFound},
+	{agent.ErrTeamExists, codeTeamExists},
+	{agent.ErrTaskNotFound, codeTaskNotFound},
+	{agent.ErrAgentNotFound, codeAgentNotFound},
+	{agent.ErrAgentExists, codeAgentExists},
+	{agent.ErrAgentRunning, codeAgentAlreadyRunning},
+	{agent.ErrAgentNotRunning, codeAgentNotRunning},
+	{agent.ErrTemplateNotFound, codeTemplateNotFound},
+	{agent.ErrTemplateExists, codeTemplateExists},
+	{config.ErrClaudeNotFound, codeClaudeNotFound},
+	{config.ErrWorkDirNotAllowed, codeWorkDirNotAllowed},
+	{context.Canceled, codeCancelled},
+}
+
+type toolError struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type toolErrorPayload struct {
+	Error toolError `json:"error"`
+}
+
+// classifyError describes a tool handler's error as a toolError.
+func classifyError(err error) toolError {
+	te := toolError{Code: codeToolError, Message: err.Error(), Details: agent.ErrorDetails(err)}
+	var transition *agent.InvalidTransitionError
+	if errors.As(err, &transition) {
+		te.Code = codeTaskInvalidTransition
+		return te
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			te.Code = c.code
+			break
+		}
+	}
+	return te
+}
+
+// structuredErrors adds the error payload to failed tool calls. Handlers just
+// return errors; the SDK turns them into error results that still hold the
+// original error, which is classified here.
+func structuredErrors(next mcpsdk.MethodHandler) mcpsdk.MethodHandler {
+	return func(ctx context.Context, method string, req mcpsdk.Request) (mcpsdk.Result, error) {
+		res, err := next(ctx, method, req)
+		result, ok := res.(*mcpsdk.CallToolResult)
+		if err != nil || !ok || !result.IsError || result.GetError() == nil {
+			return res, err
+		}
+		payload := toolErrorPayload{Error: classifyError(result.GetError())}
+		result.StructuredContent = payload
+		if data, jerr := json.Marshal(payload); jerr == nil {
+			result.Content = append(result.Content, &mcpsdk.TextContent{Text: string(data)})
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,122 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+)
+
+// callToolError calls a tool that is expected to fail and returns the
+// structured error it reported.
+func callToolError(t *testing.T, cs *mcpsdk.ClientSession, name string, args any) toolError {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := cs.CallTool(ctx, &mcpsdk.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("CallTool(%s): %v", name, err)
+	}
+	if !res.IsError {
+		t.Fatalf("CallTool(%s): expected an error result", name)
+	}
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload toolErrorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.Error.Code == "" {
+		t.Fatalf("CallTool(%s): structuredContent = %s, want an error payload", name, data)
+	}
+	if len(res.Content) != 2 {
+		t.Errorf("CallTool(%s): %d content blocks, want message and payload", name, len(res.Content))
+	}
+	return payload.Error
+}
+
+func TestStructuredToolErrors(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	if _, err := agent.CreateTeam(team, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer agent.DeleteTeam(team)
+	if err := agent.AddMember(team, agent.TeamMember{Name: "worker"}); err != nil {
+		t.Fatal(err)
+	}
+	task, err := agent.CreateTask(team, "done already", "", "", nil, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agent.UpdateTask(team, task.ID, func(t *agent.Task) error {
+		t.Status = agent.TaskCancelled
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "codes-test", Version: "0.0.1"}, nil)
+	server.AddReceivingMiddleware(structuredErrors)
+	registerAgentTools(server)
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ctx := context.Background()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	cs, err := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	tests := []struct {
+		tool    string
+		args    map[string]any
+		code    string
+		details map[string]any
+	}{
+		{"team_get", map[string]any{"name": team + "-missing"}, codeTeamNotFound, map[string]any{"team": team + "-missing"}},
+		{"task_get", map[string]any{"team": team, "taskId": 999}, codeTaskNotFound, map[string]any{"team": team, "taskId": float64(999)}},
+		{"task_update", map[string]any{"team": team, "taskId": task.ID, "status": "running"}, codeTaskInvalidTransition,
+			map[string]any{"taskId": float64(task.ID), "from": "cancelled", "to": "running"}},
+		{"agent_stop", map[string]any{"team": team, "name": "worker"}, codeAgentNotRunning, map[string]any{"team": team, "agent": "worker"}},
+		{"agent_stop", map[string]any{"team": team, "name": "ghost"}, codeAgentNotFound, map[string]any{"team": team, "agent": "ghost"}},
+	}
+	for _, tt := range tests {
+		got := callToolError(t, cs, tt.tool, tt.args)
+		if got.Code != tt.code {
+			t.Errorf("%s: code = %q (%s), want %q", tt.tool, got.Code, got.Message, tt.code)
+			continue
+		}
+		for k, v := range tt.details {
+			if got.Details[k] != v {
+				t.Errorf("%s: details[%s] = %v, want %v", tt.tool, k, got.Details[k], v)
+			}
+		}
+	}
+
+	// Errors the server doesn't classify still get a payload
+	if got := callToolError(t, cs, "task_get", map[string]any{"team": "", "taskId": 0}); got.Code == "" || got.Message == "" {
+		t.Errorf("unclassified error = %+v", got)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	wrapped := fmt.Errorf("loading: %w", &agent.InvalidTransitionError{TaskID: 3, From: agent.TaskCompleted, To: agent.TaskRunning})
+	if got := classifyError(wrapped); got.Code != codeTaskInvalidTransition || got.Details["taskId"] != 3 {
+		t.Errorf("classifyError(transition) = %+v", got)
+	}
+	if got := classifyError(errors.New("boom")); got.Code != codeToolError || got.Details != nil {
+		t.Errorf("classifyError(plain) = %+v", got)
+	}
+	if got := classifyError(context.Canceled); got.Code != codeCancelled {
+		t.Errorf("classifyError(canceled) = %+v", got)
+	}
+}
@@ -0,0 +1,198 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+)
+
+// task_git gives the orchestrator a fixed set of git operations inside a
+// task's working directory, so it can review and publish an agent's changes
+// without generic shell access.
+
+// maxGitOutput caps the output returned by task_git; large diffs are cut.
+const maxGitOutput = 64 << 10
+
+// gitTimeout bounds every git/gh invocation (pushes can be slow).
+const gitTimeout = 2 * time.Minute
+
+type taskGitInput struct {
+	Team   string `json:"team" jsonschema:"Team name"`
+	TaskID int    `json:"taskId" jsonschema:"Task whose working directory to operate in"`
+	Action string `json:"action" jsonschema:"One of: status, diff, log, branch, create_pr"`
+	Base   string `json:"base,omitempty" jsonschema:"Base branch: diff and log compare against it, create_pr targets it (optional)"`
+	Staged bool   `json:"staged,omitempty" jsonschema:"diff: show staged changes instead of unstaged (ignored with base)"`
+	Stat   bool   `json:"stat,omitempty" jsonschema:"diff: show a per-file summary instead of the full patch"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"log: number of commits (default 20, max 200)"`
+	Name   string `json:"name,omitempty" jsonschema:"branch: create and switch to this branch, keeping uncommitted changes (omit to list branches)"`
+	Title  string `json:"title,omitempty" jsonschema:"create_pr: PR title (default: filled from commits)"`
+	Body   string `json:"body,omitempty" jsonschema:"create_pr: PR body"`
+	Draft  bool   `json:"draft,omitempty" jsonschema:"create_pr: open as a draft"`
+}
+
+type taskGitOutput struct {
+	WorkDir   string `json:"workDir"`
+	Action    string `json:"action"`
+	Output    string `json:"output"`
+	Truncated bool   `json:"truncated,omitempty"`
+	URL       string `json:"url,omitempty"` // create_pr: the new pull request
+}
+
+func taskGitHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input taskGitInput) (*mcpsdk.CallToolResult, taskGitOutput, error) {
+	if input.Team == "" || input.TaskID == 0 || input.Action == "" {
+		return nil, taskGitOutput{}, fmt.Errorf("team, taskId, and action are required")
+	}
+	// Refs come from the caller; never let them be parsed as options
+	for _, ref := range []string{input.Base, input.Name} {
+		if strings.HasPrefix(ref, "-") {
+			return nil, taskGitOutput{}, fmt.Errorf("invalid ref %q", ref)
+		}
+	}
+
+	task, err := agent.GetTask(input.Team, input.TaskID)
+	if err != nil {
+		return nil, taskGitOutput{}, err
+	}
+	dir := agent.TaskWorkDir(input.Team, task)
+	if dir == "" {
+		return nil, taskGitOutput{}, fmt.Errorf("task %d has no working directory (set workDir or project, or the team's workDir)", task.ID)
+	}
+	if _, err := runGit(ctx, dir, "rev-parse", "--git-dir"); err != nil {
+		return nil, taskGitOutput{}, fmt.Errorf("%s is not a git repository", dir)
+	}
+
+	out := taskGitOutput{WorkDir: dir, Action: input.Action}
+	var text string
+	switch input.Action {
+	case "status":
+		text, err = runGit(ctx, dir, "status", "--short", "--branch")
+	case "diff":
+		args := []string{"diff"}
+		if input.Stat {
+			args = append(args, "--stat")
+		}
+		if input.Base != "" {
+			args = append(args, input.Base+"...HEAD")
+		} else if input.Staged {
+			args = append(args, "--staged")
+		}
+		text, err = runGit(ctx, dir, args...)
+	case "log":
+		limit := input.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		if limit > 200 {
+			limit = 200
+		}
+		args := []string{"log", "--oneline", "--decorate", fmt.Sprintf("-n%d", limit)}
+		if input.Base != "" {
+			args = append(args, input.Base+"..HEAD")
+		}
+		text, err = runGit(ctx, dir, args...)
+	case "branch":
+		if input.Name != "" {
+			text, err = runGit(ctx, dir, "switch", "-c", input.Name)
+		} else {
+			text, err = runGit(ctx, dir, "branch", "-vv")
+		}
+	case "create_pr":
+		text, err = createTaskPR(ctx, dir, input)
+		if err == nil {
+			out.URL = lastLine(text)
+		}
+	default:
+		return nil, taskGitOutput{}, fmt.Errorf("unknown action %q (want status, diff, log, branch or create_pr)", input.Action)
+	}
+	if err != nil {
+		return nil, taskGitOutput{}, err
+	}
+
+	if len(text) > maxGitOutput {
+		text = text[:maxGitOutput]
+		out.Truncated = true
+	}
+	out.Output = text
+	return nil, out, nil
+}
+
+// createTaskPR pushes the current branch and opens a pull request with gh.
+func createTaskPR(ctx context.Context, dir string, input taskGitInput) (string, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", fmt.Errorf("gh CLI not found in PATH; install it from https://cli.github.com")
+	}
+	branch, err := runGit(ctx, dir, "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	branch = strings.TrimSpace(branch)
+	if branch == "" {
+		return "", fmt.Errorf("HEAD is detached; create a branch first (action branch with name)")
+	}
+
+	pushed, err := runGit(ctx, dir, "push", "-u", "origin", branch)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"pr", "create", "--head", branch}
+	if input.Title != "" {
+		args = append(args, "--title", input.Title, "--body", input.Body)
+	} else {
+		args = append(args, "--fill")
+	}
+	if input.Base != "" {
+		args = append(args, "--base", input.Base)
+	}
+	if input.Draft {
+		args = append(args, "--draft")
+	}
+	created, err := runCmd(ctx, dir, "gh", args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(pushed) + "\n" + strings.TrimSpace(created), nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	return runCmd(ctx, dir, "git", args...)
+}
+
+// runCmd runs a command in dir and returns its combined output. A failure
+// includes the output, which is where git and gh explain what went wrong.
+func runCmd(ctx context.Context, dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %v: %s", name, args[0], err, strings.TrimSpace(buf.String()))
+	}
+	return buf.String(), nil
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func registerTaskGitTool(server *mcpsdk.Server) {
+	addTool(server, &mcpsdk.Tool{
+		Name:        "task_git",
+		Annotations: additive(false),
+		Description: "Run a git operation in a task's working directory: status, diff (optionally against a base branch or staged), log, branch (list, or create one with name), or create_pr (push the current branch and open a pull request with gh). Use to review and publish an agent's changes.",
+	}, taskGitHandler)
+}
@@ -0,0 +1,78 @@
+package mcpserver
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codes/internal/agent"
+)
+
+func TestTaskGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0644)
+	git("add", ".")
+	git("commit", "-q", "-m", "initial commit")
+
+	if _, err := agent.CreateTeam("git-team", "", repo); err != nil {
+		t.Fatal(err)
+	}
+	task, err := agent.CreateTask("git-team", "edit", "", "", nil, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0644)
+
+	ctx := context.Background()
+	call := func(in taskGitInput) (taskGitOutput, error) {
+		in.Team, in.TaskID = "git-team", task.ID
+		_, out, err := taskGitHandler(ctx, nil, in)
+		return out, err
+	}
+
+	out, err := call(taskGitInput{Action: "status"})
+	if err != nil || out.WorkDir != repo || !strings.Contains(out.Output, "a.txt") {
+		t.Errorf("status = %+v, %v", out, err)
+	}
+	if out, err = call(taskGitInput{Action: "diff"}); err != nil || !strings.Contains(out.Output, "+two") {
+		t.Errorf("diff = %+v, %v", out, err)
+	}
+	if out, err = call(taskGitInput{Action: "branch", Name: "agent/edit"}); err != nil {
+		t.Errorf("branch create: %v", err)
+	}
+	git("commit", "-q", "-am", "edit a")
+	if out, err = call(taskGitInput{Action: "log", Base: "main"}); err != nil || !strings.Contains(out.Output, "edit a") || strings.Contains(out.Output, "initial commit") {
+		t.Errorf("log against base = %+v, %v", out, err)
+	}
+	if out, err = call(taskGitInput{Action: "branch"}); err != nil || !strings.Contains(out.Output, "* agent/edit") {
+		t.Errorf("branch list = %+v, %v", out, err)
+	}
+
+	if _, err := call(taskGitInput{Action: "rebase"}); err == nil {
+		t.Error("expected error for unknown action")
+	}
+	if _, err := call(taskGitInput{Action: "diff", Base: "--output=/tmp/x"}); err == nil {
+		t.Error("expected option-like base to be rejected")
+	}
+}
@@ -0,0 +1,333 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/config"
+)
+
+// Tool call history: the last maxToolHistory calls with their arguments and
+// results, kept in memory by recordCalls and written to
+// ~/.codes/mcp-history.json when the server shuts down, so the orchestrating
+// session can be reconstructed after something went wrong. Unlike the audit
+// log it keeps values (secrets redacted) and read-only calls too.
+
+const (
+	maxToolHistory = 200
+
+	// maxHistoryText caps the stored arguments and output of one call.
+	maxHistoryText = 4096
+)
+
+// ToolCall is one recorded MCP tool call.
+type ToolCall struct {
+	Time       time.Time `json:"time"`
+	Client     string    `json:"client,omitempty"` // name the MCP client gave when it connected
+	Tool       string    `json:"tool"`
+	Input      string    `json:"input,omitempty"`  // arguments as JSON, secrets redacted, truncated
+	Output     string    `json:"output,omitempty"` // text content of the result, truncated
+	IsError    bool      `json:"isError,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// key identifies a call across saves, so merging histories keeps one copy.
+func (c ToolCall) key() string {
+	return c.Time.Format(time.RFC3339Nano) + "\x00" + c.Client + "\x00" + c.Tool
+}
+
+var (
+	historyMu     sync.Mutex
+	historyCalls  []ToolCall // oldest first, at most maxToolHistory
+	historyLoaded bool
+)
+
+// historyPathFunc returns the history file path; tests override it.
+var historyPathFunc = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codes", "mcp-history.json"), nil
+}
+
+// ToolHistoryPath returns the file the tool call history is saved to.
+func ToolHistoryPath() (string, error) {
+	return historyPathFunc()
+}
+
+// recordCalls keeps every tool call in the history, except tool_history
+// itself.
+func recordCalls(next mcpsdk.MethodHandler) mcpsdk.MethodHandler {
+	return func(ctx context.Context, method string, req mcpsdk.Request) (mcpsdk.Result, error) {
+		call, ok := req.(*mcpsdk.CallToolRequest)
+		if !ok || call.Params == nil || call.Params.Name == "tool_history" {
+			return next(ctx, method, req)
+		}
+
+		start := time.Now()
+		res, err := next(ctx, method, req)
+
+		c := ToolCall{
+			Time:       start,
+			Client:     clientName(call.Session),
+			Tool:       call.Params.Name,
+			Input:      historyInput(call.Params.Arguments),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			c.IsError, c.Error = true, err.Error()
+		} else if result, ok := res.(*mcpsdk.CallToolResult); ok {
+			c.IsError = result.IsError
+			var text []string
+			for _, content := range result.Content {
+				if t, ok := content.(*mcpsdk.TextContent); ok {
+					text = append(text, t.Text)
+				}
+			}
+			c.Output = truncateHistory(strings.Join(text, "\n"))
+		}
+		addToolCall(c)
+		return res, err
+	}
+}
+
+// addToolCall appends c to the history, dropping the oldest call when full.
+func addToolCall(c ToolCall) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	loadHistoryLocked()
+	historyCalls = append(historyCalls, c)
+	if len(historyCalls) > maxToolHistory {
+		historyCalls = historyCalls[len(historyCalls)-maxToolHistory:]
+	}
+}
+
+// loadHistoryLocked fills the history from the file once, so calls made
+// before the last restart are still shown. Caller must hold historyMu.
+func loadHistoryLocked() {
+	if historyLoaded {
+		return
+	}
+	historyLoaded = true
+	path, err := historyPathFunc()
+	if err != nil {
+		return
+	}
+	saved, err := LoadToolHistory(path)
+	if err != nil {
+		return
+	}
+	historyCalls = mergeToolCalls(saved, historyCalls)
+}
+
+// historyInput returns the arguments as compact JSON with the values of
+// secret-looking keys (config.IsSensitiveEnv) replaced.
+func historyInput(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return truncateHistory(string(raw))
+	}
+	data, err := json.Marshal(redactArgs(v))
+	if err != nil {
+		return ""
+	}
+	return truncateHistory(string(data))
+}
+
+func redactArgs(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if _, isString := val.(string); isString && config.IsSensitiveEnv(k) {
+				v[k] = "[redacted]"
+			} else {
+				v[k] = redactArgs(val)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactArgs(v[i])
+		}
+	}
+	return v
+}
+
+func truncateHistory(s string) string {
+	if len(s) <= maxHistoryText {
+		return s
+	}
+	return s[:maxHistoryText] + "…"
+}
+
+// ToolHistory returns the recorded calls, oldest first.
+func ToolHistory() []ToolCall {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	loadHistoryLocked()
+	return append([]ToolCall(nil), historyCalls...)
+}
+
+// SaveToolHistory writes the history to ~/.codes/mcp-history.json, merged
+// with what is already there: a stdio MCP server and `codes serve` may both
+// save. `codes serve` calls it on shutdown.
+func SaveToolHistory() error {
+	path, err := historyPathFunc()
+	if err != nil {
+		return err
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if !historyLoaded && len(historyCalls) == 0 {
+		return nil // no tool was called; leave the file alone
+	}
+	saved, err := LoadToolHistory(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return WriteToolHistory(path, mergeToolCalls(saved, historyCalls))
+}
+
+// LoadToolHistory reads a history file written by SaveToolHistory or
+// `codes mcp history --export`.
+func LoadToolHistory(path string) ([]ToolCall, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var calls []ToolCall
+	if err := json.Unmarshal(data, &calls); err != nil {
+		return nil, fmt.Errorf("invalid tool history %s: %w", path, err)
+	}
+	return calls, nil
+}
+
+// WriteToolHistory writes calls to path, readable only by the user since
+// arguments and output may still hold sensitive data.
+func WriteToolHistory(path string, calls []ToolCall) error {
+	if calls == nil {
+		calls = []ToolCall{}
+	}
+	data, err := json.MarshalIndent(calls, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ImportToolHistory merges the calls in the file at path, e.g. one exported
+// on another machine, into the saved history and returns how many were new.
+func ImportToolHistory(path string) (int, error) {
+	imported, err := LoadToolHistory(path)
+	if err != nil {
+		return 0, err
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	loadHistoryLocked()
+	known := make(map[string]bool, len(historyCalls))
+	for _, c := range historyCalls {
+		known[c.key()] = true
+	}
+	added := 0
+	for _, c := range imported {
+		if !known[c.key()] {
+			added++
+		}
+	}
+	historyCalls = mergeToolCalls(historyCalls, imported)
+	target, err := historyPathFunc()
+	if err != nil {
+		return 0, err
+	}
+	return added, WriteToolHistory(target, historyCalls)
+}
+
+// mergeToolCalls returns the calls of a and b without duplicates, oldest
+// first, keeping the newest maxToolHistory.
+func mergeToolCalls(a, b []ToolCall) []ToolCall {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []ToolCall
+	for _, c := range append(append([]ToolCall(nil), a...), b...) {
+		if k := c.key(); !seen[k] {
+			seen[k] = true
+			merged = append(merged, c)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	if len(merged) > maxToolHistory {
+		merged = merged[len(merged)-maxToolHistory:]
+	}
+	return merged
+}
+
+// FilterToolCalls returns the newest limit calls (all when limit <= 0) of
+// tool, or of every tool when tool is "", optionally only failed ones;
+// oldest first.
+func FilterToolCalls(calls []ToolCall, tool string, errorsOnly bool, limit int) []ToolCall {
+	var out []ToolCall
+	for _, c := range calls {
+		if (tool == "" || c.Tool == tool) && (!errorsOnly || c.IsError) {
+			out = append(out, c)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// -- tool_history --
+
+type toolHistoryInput struct {
+	Tool       string `json:"tool,omitempty" jsonschema:"Only calls of this tool"`
+	ErrorsOnly bool   `json:"errorsOnly,omitempty" jsonschema:"Only calls that failed"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"Number of most recent calls to return (default 20, max 200)"`
+}
+
+type toolHistoryOutput struct {
+	Calls []ToolCall `json:"calls"`
+	Total int        `json:"total"` // calls recorded, before filtering
+}
+
+func toolHistoryHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input toolHistoryInput) (*mcpsdk.CallToolResult, toolHistoryOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, maxToolHistory)
+	calls := ToolHistory()
+	out := toolHistoryOutput{Calls: FilterToolCalls(calls, input.Tool, input.ErrorsOnly, limit), Total: len(calls)}
+	if out.Calls == nil {
+		out.Calls = []ToolCall{}
+	}
+	return nil, out, nil
+}
+
+func registerHistoryTool(server *mcpsdk.Server) {
+	addTool(server, &mcpsdk.Tool{
+		Name:        "tool_history",
+		Annotations: readOnly(),
+		Description: "List the most recent MCP tool calls made to this server (including before its last restart): tool, client, arguments with secrets redacted, output, error and duration, oldest first. Use it to reconstruct what was actually done when an orchestration went wrong.",
+	}, toolHistoryHandler)
+}
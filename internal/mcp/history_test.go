@@ -0,0 +1,103 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestToolHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp-history.json")
+	origPath := historyPathFunc
+	historyPathFunc = func() (string, error) { return path, nil }
+	historyMu.Lock()
+	historyCalls, historyLoaded = nil, false
+	historyMu.Unlock()
+	defer func() {
+		historyPathFunc = origPath
+		historyMu.Lock()
+		historyCalls, historyLoaded = nil, false
+		historyMu.Unlock()
+	}()
+	ctx := context.Background()
+
+	type in struct {
+		Name  string `json:"name"`
+		Token string `json:"token,omitempty"`
+	}
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "codes-test", Version: "0.0.1"}, nil)
+	server.AddReceivingMiddleware(recordCalls)
+	addTool(server, &mcpsdk.Tool{Name: "history_test_echo", Annotations: readOnly()},
+		func(_ context.Context, _ *mcpsdk.CallToolRequest, args in) (*mcpsdk.CallToolResult, any, error) {
+			if args.Name == "bad" {
+				return nil, nil, errors.New("no such thing")
+			}
+			return &mcpsdk.CallToolResult{Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: "hello " + args.Name}}}, nil, nil
+		})
+	registerHistoryTool(server)
+
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	cs, err := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "history-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	for _, args := range []map[string]any{{"name": "a", "token": "s3cret"}, {"name": "bad"}} {
+		if _, err := cs.CallTool(ctx, &mcpsdk.CallToolParams{Name: "history_test_echo", Arguments: args}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp := callTool(t, cs, "tool_history", map[string]any{})
+	if resp["total"] != float64(2) {
+		t.Fatalf("tool_history = %v, want 2 calls (its own call not recorded)", resp)
+	}
+	calls := ToolHistory()
+	ok, failed := calls[0], calls[1]
+	if ok.Tool != "history_test_echo" || ok.Client != "history-client" || ok.IsError || ok.Output != "hello a" {
+		t.Errorf("successful call = %+v", ok)
+	}
+	if strings.Contains(ok.Input, "s3cret") || !strings.Contains(ok.Input, `"name":"a"`) {
+		t.Errorf("input = %s, want the token redacted", ok.Input)
+	}
+	if !failed.IsError || !strings.Contains(failed.Output, "no such thing") {
+		t.Errorf("failed call = %+v", failed)
+	}
+	if resp := callTool(t, cs, "tool_history", map[string]any{"errorsOnly": true}); len(resp["calls"].([]any)) != 1 {
+		t.Errorf("errorsOnly: %v", resp)
+	}
+
+	// Saved on shutdown and read back by a later process
+	if err := SaveToolHistory(); err != nil {
+		t.Fatal(err)
+	}
+	historyMu.Lock()
+	historyCalls, historyLoaded = nil, false
+	historyMu.Unlock()
+	if got := ToolHistory(); len(got) != 2 || got[0].key() != ok.key() {
+		t.Errorf("after reload: %+v", got)
+	}
+
+	// Exported files merge back without duplicates
+	export := filepath.Join(dir, "export.json")
+	if err := WriteToolHistory(export, append(calls, ToolCall{Time: failed.Time.Add(1), Tool: "elsewhere"})); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := ImportToolHistory(export); err != nil || added != 1 {
+		t.Errorf("ImportToolHistory = %d, %v, want 1 new call", added, err)
+	}
+	if got, _ := LoadToolHistory(path); len(got) != 3 || got[2].Tool != "elsewhere" {
+		t.Errorf("saved after import: %+v", got)
+	}
+}
@@ -3,6 +3,8 @@ package mcpserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -12,35 +14,75 @@ import (
 	"time"
 
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
 )
 
-const maxPendingNotifications = 100
+const (
+	maxPendingNotifications = 100
+
+	// maxNotificationLog is how many recent notifications notifications_poll
+	// can replay.
+	maxNotificationLog = 500
+
+	// maxMonitorErrors is how many recent monitor errors monitor_status
+	// reports.
+	maxMonitorErrors = 20
+)
 
 // taskNotification mirrors the notification struct from internal/agent/daemon.go.
+// Sequence is assigned by this server when the notification is queued.
 type taskNotification struct {
-	Team      string `json:"team"`
-	TaskID    int    `json:"taskId"`
-	Subject   string `json:"subject"`
-	Status    string `json:"status"`
-	Agent     string `json:"agent"`
-	Result    string `json:"result,omitempty"`
-	Error     string `json:"error,omitempty"`
-	Timestamp string `json:"timestamp"`
+	Sequence  int64              `json:"sequence,omitempty"`
+	Team      string             `json:"team"`
+	TaskID    int                `json:"taskId"`
+	Subject   string             `json:"subject"`
+	Status    string             `json:"status"`
+	Agent     string             `json:"agent"`
+	Result    string             `json:"result,omitempty"`
+	Summary   *agent.TaskSummary `json:"summary,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	Timestamp string             `json:"timestamp"`
 }
 
 var (
 	monitorMu      sync.Mutex
 	monitorStarted bool
 	monitorRunning atomic.Bool
+	monitorCancel  context.CancelFunc
+	monitorDone    chan struct{} // closed when the watchdog returns
+
+	// health is what monitor_status reports about the monitor.
+	health monitorHealth
+
+	// Scan and watchdog timing; tests shorten them.
+	monitorInterval   = 3 * time.Second
+	watchdogInterval  = 10 * time.Second
+	monitorStallAfter = 30 * time.Second
+	monitorBackoffMin = time.Second
+	monitorBackoffMax = time.Minute
 
+	// monitorScanHook, when set, runs before every scan. Tests use it to
+	// make the monitor panic or hang.
+	monitorScanHook func()
+
+	// pendingNotifications holds a queue per connected MCP session, so
+	// two clients (e.g. two Claude windows) each receive every
+	// notification instead of one draining the other's.
 	pendingMu            sync.Mutex
-	pendingNotifications []taskNotification
+	pendingNotifications = make(map[*mcpsdk.ServerSession][]taskNotification)
 
 	// notifCond is broadcast whenever new notifications are appended to
 	// pendingNotifications. team_subscribe uses this to wake up
 	// immediately instead of polling with time.Sleep.
 	notifCond = sync.NewCond(&pendingMu)
 
+	// notificationLog keeps the last maxNotificationLog notifications in
+	// sequence order for notifications_poll, which reads it by cursor
+	// instead of draining a queue. Guarded by pendingMu.
+	notificationLog []taskNotification
+	notificationSeq int64
+
 	// notifDirOverride allows tests to redirect notification scanning
 	// to an isolated temp directory. Empty string means use the default
 	// ~/.codes/notifications path.
@@ -51,10 +93,12 @@ var (
 	subscribeTimeoutOverride time.Duration
 )
 
-// ensureMonitorRunning starts the singleton notification monitor goroutine
-// if it is not already running. The server reference is used to attempt
-// best-effort MCP logging push; all notifications are also queued for
-// piggyback delivery via drainPendingNotifications.
+// ensureMonitorRunning starts the singleton notification monitor if it is
+// not already running. The monitor runs under a watchdog (see watchMonitor)
+// that restarts it when it exits, panics or stops scanning. The server
+// reference is used to attempt best-effort MCP logging push; all
+// notifications are also queued for piggyback delivery via
+// drainPendingNotifications.
 func ensureMonitorRunning(server *mcpsdk.Server) {
 	monitorMu.Lock()
 	defer monitorMu.Unlock()
@@ -62,42 +106,65 @@ func ensureMonitorRunning(server *mcpsdk.Server) {
 		return
 	}
 	monitorStarted = true
+	ctx, cancel := context.WithCancel(context.Background())
+	monitorCancel = cancel
+	monitorDone = make(chan struct{})
 	monitorRunning.Store(true)
-	go runNotificationMonitor(server)
+	go func(done chan struct{}) {
+		defer close(done)
+		watchMonitor(ctx, server)
+	}(monitorDone)
+}
+
+// stopMonitor stops the monitor and its watchdog, waits for them to return
+// and clears their health record, so the next ensureMonitorRunning starts
+// afresh.
+func stopMonitor() {
+	monitorMu.Lock()
+	defer monitorMu.Unlock()
+	if monitorCancel != nil {
+		monitorCancel()
+		<-monitorDone
+		monitorCancel, monitorDone = nil, nil
+	}
+	monitorStarted = false
+	monitorRunning.Store(false)
+	health.reset()
 }
 
-// drainPendingNotifications returns and clears all buffered notifications.
-// Call this from any agent tool handler to piggyback unread notifications
-// onto the response.
-func drainPendingNotifications() []taskNotification {
+// drainPendingNotifications returns and clears the notifications buffered
+// for session. Call this from any agent tool handler with req.Session to
+// piggyback unread notifications onto the response.
+func drainPendingNotifications(session *mcpsdk.ServerSession) []taskNotification {
 	pendingMu.Lock()
 	defer pendingMu.Unlock()
-	if len(pendingNotifications) == 0 {
+	out := pendingNotifications[session]
+	if len(out) == 0 {
 		return nil
 	}
-	out := pendingNotifications
-	pendingNotifications = nil
+	pendingNotifications[session] = nil
 	return out
 }
 
-// drainTeamNotifications extracts and returns notifications matching the
-// given team name, leaving non-matching ones in the buffer. This is used
-// by team_subscribe to wait for specific team events without consuming
-// notifications destined for other teams' piggyback delivery.
-func drainTeamNotifications(team string) []taskNotification {
+// drainTeamNotifications extracts and returns session's notifications
+// matching the given team name, leaving non-matching ones in the buffer.
+// This is used by team_subscribe to wait for specific team events without
+// consuming notifications destined for other teams' piggyback delivery.
+func drainTeamNotifications(session *mcpsdk.ServerSession, team string) []taskNotification {
 	pendingMu.Lock()
 	defer pendingMu.Unlock()
-	return drainTeamNotificationsLocked(team)
+	return drainTeamNotificationsLocked(session, team)
 }
 
 // drainTeamNotificationsLocked is the lock-free inner implementation.
 // Caller must hold pendingMu. Used inside notifCond.Wait loops.
-func drainTeamNotificationsLocked(team string) []taskNotification {
-	if len(pendingNotifications) == 0 {
+func drainTeamNotificationsLocked(session *mcpsdk.ServerSession, team string) []taskNotification {
+	pending := pendingNotifications[session]
+	if len(pending) == 0 {
 		return nil
 	}
 	var matched, remaining []taskNotification
-	for _, n := range pendingNotifications {
+	for _, n := range pending {
 		if n.Team == team {
 			matched = append(matched, n)
 		} else {
@@ -107,10 +174,64 @@ func drainTeamNotificationsLocked(team string) []taskNotification {
 	if len(matched) == 0 {
 		return nil
 	}
-	pendingNotifications = remaining
+	pendingNotifications[session] = remaining
 	return matched
 }
 
+// queueNotificationLocked gives n the next sequence number, records it in
+// notificationLog, appends it to the queue of every session connected to
+// server and drops the queues of sessions that have disconnected. Caller
+// must hold pendingMu.
+func queueNotificationLocked(server *mcpsdk.Server, n taskNotification) {
+	notificationSeq++
+	n.Sequence = notificationSeq
+	notificationLog = append(notificationLog, n)
+	if len(notificationLog) > maxNotificationLog {
+		notificationLog = notificationLog[len(notificationLog)-maxNotificationLog:]
+	}
+
+	live := make(map[*mcpsdk.ServerSession]bool)
+	if server != nil {
+		for ss := range server.Sessions() {
+			live[ss] = true
+			if len(pendingNotifications[ss]) < maxPendingNotifications {
+				pendingNotifications[ss] = append(pendingNotifications[ss], n)
+			}
+		}
+	}
+	for ss := range pendingNotifications {
+		if !live[ss] {
+			delete(pendingNotifications, ss)
+		}
+	}
+}
+
+// notificationFilter selects notifications for notifications_poll. Empty
+// fields match everything.
+type notificationFilter struct {
+	Team   string
+	Agent  string
+	Status string
+}
+
+func (f notificationFilter) match(n taskNotification) bool {
+	return (f.Team == "" || n.Team == f.Team) &&
+		(f.Agent == "" || n.Agent == f.Agent) &&
+		(f.Status == "" || n.Status == f.Status)
+}
+
+// notificationsSinceLocked returns the logged notifications after sequence
+// since that match f. Caller must hold pendingMu.
+func notificationsSinceLocked(since int64, f notificationFilter) []taskNotification {
+	var out []taskNotification
+	for _, n := range notificationLog {
+		if n.Sequence > since && f.match(n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
 // notificationDir returns the directory to scan for notification files.
 func notificationDir() string {
 	if notifDirOverride != "" {
@@ -123,93 +244,209 @@ func notificationDir() string {
 	return filepath.Join(home, ".codes", "notifications")
 }
 
-func runNotificationMonitor(server *mcpsdk.Server) {
+// watchMonitor runs the notification monitor until ctx is cancelled. When
+// the monitor returns, panics or goes monitorStallAfter without finishing a
+// scan, the error is recorded and a new monitor is started after a backoff
+// that doubles from monitorBackoffMin up to monitorBackoffMax. The backoff
+// is reset once a monitor has stayed healthy for monitorBackoffMax.
+func watchMonitor(ctx context.Context, server *mcpsdk.Server) {
+	sc := &monitorScanner{server: server, seen: make(map[string]time.Time), failed: make(map[string]bool)}
+	backoff := monitorBackoffMin
+
+	for {
+		runCtx, stop := context.WithCancel(ctx)
+		started := time.Now()
+		health.start(started)
+		monitorRunning.Store(true)
+		done := make(chan error, 1)
+		go func() { done <- runMonitorSafely(runCtx, sc) }()
+
+		err := superviseMonitor(ctx, done)
+		stop()
+		if ctx.Err() != nil {
+			// Give the monitor the time a scan may take to notice.
+			select {
+			case <-done:
+			case <-time.After(monitorStallAfter):
+			}
+			return
+		}
+		monitorRunning.Store(false)
+		log.Printf("monitor: %v", err)
+
+		if time.Since(started) >= monitorBackoffMax {
+			backoff = monitorBackoffMin
+		}
+		health.failed(err, time.Now().Add(backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, monitorBackoffMax)
+	}
+}
+
+// superviseMonitor waits for the monitor reporting on done to fail. It
+// returns the reason, or nil when ctx is cancelled first.
+func superviseMonitor(ctx context.Context, done <-chan error) error {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-done:
+			if err == nil {
+				err = errors.New("monitor exited")
+			}
+			return err
+		case <-ticker.C:
+			if last := health.lastBeat(); time.Since(last) > monitorStallAfter {
+				return fmt.Errorf("monitor stalled: no scan since %s", last.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+}
+
+// runMonitorSafely runs the monitor, turning a panic into an error.
+func runMonitorSafely(ctx context.Context, sc *monitorScanner) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("monitor panicked: %v", r)
+		}
+	}()
+	return runNotificationMonitor(ctx, sc)
+}
+
+// runNotificationMonitor scans the notification directory every
+// monitorInterval until ctx is cancelled.
+func runNotificationMonitor(ctx context.Context, sc *monitorScanner) error {
 	dir := notificationDir()
 	if dir == "" {
-		log.Printf("monitor: cannot determine notification directory")
-		monitorMu.Lock()
-		monitorStarted = false
-		monitorMu.Unlock()
-		monitorRunning.Store(false)
-		return
+		return errors.New("cannot determine notification directory")
 	}
 
-	ticker := time.NewTicker(3 * time.Second)
+	ticker := time.NewTicker(monitorInterval)
 	defer ticker.Stop()
 
-	// Track files already processed by this monitor so we don't
-	// queue duplicate notifications. We leave files on disk for
-	// team_watch (shell-based consumer) to read and delete.
-	seenFiles := make(map[string]time.Time) // filename -> first-seen time
-	cleanupTick := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if monitorScanHook != nil {
+			monitorScanHook()
+		}
+		sc.scan(ctx, dir)
+		health.scanned(time.Now())
+	}
+}
 
-	for range ticker.C {
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			continue // directory may not exist yet
+// monitorScanner remembers which notification files have been queued. It
+// outlives a single monitor so a restart does not queue them again.
+type monitorScanner struct {
+	server *mcpsdk.Server
+
+	mu sync.Mutex
+	// Files already queued, with when they were first seen. Files stay on
+	// disk because every codes MCP server (one per stdio client) scans the
+	// same directory.
+	seen map[string]time.Time
+	// Files that could not be parsed, reported once each. They are retried
+	// because the daemon may still be writing them.
+	failed      map[string]bool
+	cleanupTick int
+}
+
+// scan queues the notifications in dir that have not been seen yet. A scan
+// for a monitor whose ctx has been cancelled (one the watchdog gave up on)
+// does nothing.
+func (sc *monitorScanner) scan(ctx context.Context, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// The directory does not exist until the first notification.
+		if !os.IsNotExist(err) {
+			health.recordError(fmt.Errorf("read notification directory: %w", err))
+		}
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Track which files still exist for seen-map cleanup.
+	existingFiles := make(map[string]struct{}, len(entries))
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
 		}
+		existingFiles[e.Name()] = struct{}{}
 
-		// Track which files still exist for seen-map cleanup.
-		existingFiles := make(map[string]struct{}, len(entries))
+		// Skip files we've already processed.
+		if _, seen := sc.seen[e.Name()]; seen {
+			continue
+		}
 
-		for _, e := range entries {
-			if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
-				continue
-			}
-			existingFiles[e.Name()] = struct{}{}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
 
-			// Skip files we've already processed.
-			if _, seen := seenFiles[e.Name()]; seen {
-				continue
+		var n taskNotification
+		if err := json.Unmarshal(data, &n); err != nil {
+			if !sc.failed[e.Name()] {
+				sc.failed[e.Name()] = true
+				health.recordError(fmt.Errorf("parse %s: %w", e.Name(), err))
 			}
+			continue
+		}
+		delete(sc.failed, e.Name())
 
-			path := filepath.Join(dir, e.Name())
-			data, err := os.ReadFile(path)
-			if err != nil {
-				continue
-			}
+		// Always queue for piggyback delivery — this is the
+		// reliable path. MCP logging push is best-effort only
+		// because ServerSession.Log silently drops messages when
+		// the client has not called SetLevel.
+		pendingMu.Lock()
+		queueNotificationLocked(sc.server, n)
+		notifCond.Broadcast()
+		pendingMu.Unlock()
 
-			var n taskNotification
-			if err := json.Unmarshal(data, &n); err != nil {
+		// Best-effort: also try MCP logging push.
+		tryLogToSessions(sc.server, &n)
+
+		// Mark as seen (don't delete — other servers may not have read it yet).
+		sc.seen[e.Name()] = time.Now()
+	}
+
+	// Periodic cleanup: every ~30 scans (~90s), remove files older than
+	// two minutes, by which time every running server has picked them up.
+	// Also prune the seen-map of entries for files that no longer exist.
+	sc.cleanupTick++
+	if sc.cleanupTick >= 30 {
+		sc.cleanupTick = 0
+		staleThreshold := time.Now().Add(-2 * time.Minute)
+		for name, firstSeen := range sc.seen {
+			if _, exists := existingFiles[name]; !exists {
+				// File was deleted by another server — remove from seen map.
+				delete(sc.seen, name)
 				continue
 			}
-
-			// Always queue for piggyback delivery — this is the
-			// reliable path. MCP logging push is best-effort only
-			// because ServerSession.Log silently drops messages when
-			// the client has not called SetLevel.
-			pendingMu.Lock()
-			if len(pendingNotifications) < maxPendingNotifications {
-				pendingNotifications = append(pendingNotifications, n)
+			if firstSeen.Before(staleThreshold) {
+				// File is stale — clean up.
+				os.Remove(filepath.Join(dir, name))
+				delete(sc.seen, name)
 			}
-			notifCond.Broadcast()
-			pendingMu.Unlock()
-
-			// Best-effort: also try MCP logging push.
-			tryLogToSessions(server, &n)
-
-			// Mark as seen (don't delete — let team_watch consume it).
-			seenFiles[e.Name()] = time.Now()
-		}
-
-		// Periodic cleanup: every ~30 ticks (~90s), remove stale files
-		// that team_watch didn't delete (e.g. watcher not running).
-		// Also prune the seen-map of entries for files that no longer exist.
-		cleanupTick++
-		if cleanupTick >= 30 {
-			cleanupTick = 0
-			staleThreshold := time.Now().Add(-2 * time.Minute)
-			for name, firstSeen := range seenFiles {
-				if _, exists := existingFiles[name]; !exists {
-					// File was deleted by team_watch — remove from seen map.
-					delete(seenFiles, name)
-					continue
-				}
-				if firstSeen.Before(staleThreshold) {
-					// File is stale and team_watch didn't pick it up — clean up.
-					os.Remove(filepath.Join(dir, name))
-					delete(seenFiles, name)
-				}
+		}
+		for name := range sc.failed {
+			if _, exists := existingFiles[name]; !exists {
+				delete(sc.failed, name)
 			}
 		}
 	}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -44,15 +46,23 @@ func setupTestServer(t *testing.T, teamName string) (*mcpsdk.ClientSession, func
 		cs.Close()
 		ss.Close()
 		// Reset monitor state for test isolation.
-		monitorMu.Lock()
-		monitorStarted = false
-		monitorMu.Unlock()
-		monitorRunning.Store(false)
+		stopMonitor()
 	}
 
 	return cs, cleanup
 }
 
+// serverSession returns the server side of the session opened by
+// setupTestServer.
+func serverSession(t *testing.T) *mcpsdk.ServerSession {
+	t.Helper()
+	for ss := range mcpServer.Sessions() {
+		return ss
+	}
+	t.Fatal("no server session")
+	return nil
+}
+
 // callTool is a helper that calls a tool and returns the unmarshaled JSON
 // content from the first TextContent block.
 func callTool(t *testing.T, cs *mcpsdk.ClientSession, name string, args any) map[string]any {
@@ -198,31 +208,30 @@ func TestE2E_PiggybackNotifications(t *testing.T) {
 		t.Errorf("notification result = %v, want 'all good'", first["result"])
 	}
 
-	// 5. File should still exist (monitor no longer deletes — team_watch does).
+	// 5. File should still exist for other servers scanning the directory.
 	if _, err := os.Stat(notifPath); os.IsNotExist(err) {
-		t.Errorf("notification file should still exist for team_watch to consume")
+		t.Errorf("notification file should still exist for other servers to read")
 	}
 }
 
 func TestE2E_PendingNotificationsCapLimit(t *testing.T) {
-	// Directly test the cap by stuffing the buffer.
-	pendingMu.Lock()
-	pendingNotifications = nil
-	pendingMu.Unlock()
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	_, cleanup := setupTestServer(t, team)
+	defer cleanup()
+	ss := serverSession(t)
 
+	// Directly test the cap by stuffing the buffer.
 	for i := 0; i < maxPendingNotifications+50; i++ {
 		pendingMu.Lock()
-		if len(pendingNotifications) < maxPendingNotifications {
-			pendingNotifications = append(pendingNotifications, taskNotification{
-				TaskID:  i,
-				Subject: fmt.Sprintf("task-%d", i),
-			})
-		}
+		queueNotificationLocked(mcpServer, taskNotification{
+			TaskID:  i,
+			Subject: fmt.Sprintf("task-%d", i),
+		})
 		pendingMu.Unlock()
 	}
 
 	pendingMu.Lock()
-	n := len(pendingNotifications)
+	n := len(pendingNotifications[ss])
 	pendingMu.Unlock()
 
 	if n != maxPendingNotifications {
@@ -230,18 +239,137 @@ func TestE2E_PendingNotificationsCapLimit(t *testing.T) {
 	}
 
 	// Drain and verify.
-	drained := drainPendingNotifications()
+	drained := drainPendingNotifications(ss)
 	if len(drained) != maxPendingNotifications {
 		t.Errorf("drained %d, want %d", len(drained), maxPendingNotifications)
 	}
 
 	// Second drain should be empty.
-	again := drainPendingNotifications()
+	again := drainPendingNotifications(ss)
 	if again != nil {
 		t.Errorf("second drain should be nil, got %d items", len(again))
 	}
 }
 
+func TestE2E_NotificationsPerSession(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs1, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	// Connect a second client to the same server, like a second Claude window.
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ss2, err := mcpServer.Connect(context.Background(), st, nil)
+	if err != nil {
+		t.Fatalf("server.Connect: %v", err)
+	}
+	defer ss2.Close()
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client-2", Version: "0.0.1"}, nil)
+	cs2, err := client.Connect(context.Background(), ct, nil)
+	if err != nil {
+		t.Fatalf("client.Connect: %v", err)
+	}
+	defer cs2.Close()
+
+	callTool(t, cs1, "team_create", map[string]any{"name": team})
+	pendingMu.Lock()
+	queueNotificationLocked(mcpServer, taskNotification{Team: team, TaskID: 1, Status: "completed"})
+	queueNotificationLocked(mcpServer, taskNotification{Team: team + "-other", TaskID: 2, Status: "completed"})
+	pendingMu.Unlock()
+
+	// The first client consumes its team's notification through team_subscribe...
+	resp := callToolLong(t, cs1, "team_subscribe", map[string]any{"team": team, "timeout": 1}, 15*time.Second)
+	if notifs, _ := resp["notifications"].([]any); len(notifs) != 1 {
+		t.Fatalf("client 1 team_subscribe = %v", resp)
+	}
+
+	// ...and the second client still receives both notifications.
+	resp = callTool(t, cs2, "task_list", map[string]any{"team": team})
+	if notifs, _ := resp["pending_notifications"].([]any); len(notifs) != 2 {
+		t.Errorf("client 2 pending_notifications = %v, want 2", resp["pending_notifications"])
+	}
+
+	// The first client only has the other team's notification left.
+	resp = callTool(t, cs1, "task_list", map[string]any{"team": team})
+	notifs, _ := resp["pending_notifications"].([]any)
+	if len(notifs) != 1 || notifs[0].(map[string]any)["team"] != team+"-other" {
+		t.Errorf("client 1 pending_notifications = %v", resp["pending_notifications"])
+	}
+
+	// Closing a session drops its queue on the next notification.
+	ss2.Close()
+	cs2.Close()
+	pendingMu.Lock()
+	queueNotificationLocked(mcpServer, taskNotification{Team: team, TaskID: 3, Status: "completed"})
+	_, kept := pendingNotifications[ss2]
+	pendingMu.Unlock()
+	if kept {
+		t.Error("queue of a closed session was kept")
+	}
+}
+
+func TestE2E_NotificationsPoll(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	queue := func(n taskNotification) {
+		pendingMu.Lock()
+		queueNotificationLocked(mcpServer, n)
+		notifCond.Broadcast()
+		pendingMu.Unlock()
+	}
+	pendingMu.Lock()
+	start := notificationSeq
+	pendingMu.Unlock()
+
+	queue(taskNotification{Team: team, TaskID: 1, Status: "completed", Agent: "w1"})
+	queue(taskNotification{Team: team + "-other", TaskID: 1, Status: "completed", Agent: "w1"})
+	queue(taskNotification{Team: team, TaskID: 2, Status: "failed", Agent: "w2"})
+
+	// Buffered events are returned right away, filtered.
+	resp := callTool(t, cs, "notifications_poll", map[string]any{"team": team, "sinceSequence": start})
+	events, _ := resp["events"].([]any)
+	if len(events) != 2 || resp["timedOut"] != false {
+		t.Fatalf("poll = %v, want 2 events for the team", resp)
+	}
+	resp = callTool(t, cs, "notifications_poll", map[string]any{"team": team, "status": "failed", "agent": "w2", "sinceSequence": start})
+	if events, _ := resp["events"].([]any); len(events) != 1 || events[0].(map[string]any)["taskId"] != float64(2) {
+		t.Errorf("filtered poll = %v, want task 2 only", resp)
+	}
+	next := resp["nextSequence"].(float64)
+	if int64(next) != start+3 {
+		t.Errorf("nextSequence = %v, want %d", next, start+3)
+	}
+
+	// Nothing new after the cursor: the poll times out with no events.
+	resp = callToolLong(t, cs, "notifications_poll", map[string]any{"team": team, "sinceSequence": next, "timeout": 1}, 5*time.Second)
+	if events, _ := resp["events"].([]any); len(events) != 0 || resp["timedOut"] != true {
+		t.Errorf("idle poll = %v, want timed out", resp)
+	}
+
+	// A waiting poll returns as soon as a matching event arrives.
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		queue(taskNotification{Team: team + "-other", TaskID: 2, Status: "completed"})
+		queue(taskNotification{Team: team, TaskID: 3, Status: "completed"})
+	}()
+	begin := time.Now()
+	resp = callToolLong(t, cs, "notifications_poll", map[string]any{"team": team, "sinceSequence": next, "timeout": 30}, 10*time.Second)
+	events, _ = resp["events"].([]any)
+	if len(events) != 1 || events[0].(map[string]any)["taskId"] != float64(3) {
+		t.Errorf("waiting poll = %v, want task 3", resp)
+	}
+	if time.Since(begin) > 5*time.Second {
+		t.Errorf("waiting poll took %s", time.Since(begin))
+	}
+
+	// A cursor from before a server restart is ahead of the log: start over.
+	resp = callTool(t, cs, "notifications_poll", map[string]any{"team": team, "sinceSequence": 1 << 40})
+	if events, _ := resp["events"].([]any); len(events) < 3 {
+		t.Errorf("poll with a stale cursor = %v, want the buffered events", resp)
+	}
+}
+
 func TestE2E_NoMonitorCmdInResponses(t *testing.T) {
 	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
 	cs, cleanup := setupTestServer(t, team)
@@ -444,7 +572,7 @@ func TestE2E_TeamSubscribeFiltersTeam(t *testing.T) {
 	}
 
 	// Team-B notification should still be in the pending buffer.
-	remaining := drainPendingNotifications()
+	remaining := drainPendingNotifications(serverSession(t))
 	foundB := false
 	for _, n := range remaining {
 		if n.Team == teamB {
@@ -455,3 +583,269 @@ func TestE2E_TeamSubscribeFiltersTeam(t *testing.T) {
 		t.Error("team-B notification should remain in pending buffer after team-A subscribe")
 	}
 }
+
+func TestE2E_UsageReport(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	callTool(t, cs, "team_create", map[string]any{"name": team})
+	task, err := agent.CreateTask(team, "priced", "", "w1", nil, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	agent.UpdateTask(team, task.ID, func(tk *agent.Task) error {
+		tk.Status = agent.TaskCompleted
+		tk.StartedAt, tk.CompletedAt = &now, &now
+		tk.Cost = &agent.CostInfo{OutputTokens: 7, TotalCostUSD: 0.25}
+		return nil
+	})
+
+	resp := callTool(t, cs, "usage_report", map[string]any{"team": team, "since": "1h"})
+	total, _ := resp["total"].(map[string]any)
+	if total["costUSD"] != 0.25 || total["outputTokens"] != float64(7) {
+		t.Errorf("usage_report total = %v", resp["total"])
+	}
+}
+
+func TestE2E_DryRun(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	callTool(t, cs, "team_create", map[string]any{"name": team, "workDir": t.TempDir()})
+	callTool(t, cs, "agent_add", map[string]any{"team": team, "name": "w1"})
+
+	resp := callTool(t, cs, "task_create", map[string]any{"team": team, "subject": "plan me", "assign": "w1", "dryRun": true})
+	plan, _ := resp["dryRun"].(map[string]any)
+	planned, _ := plan["task"].(map[string]any)
+	if resp["task"] != nil || planned["subject"] != "plan me" || planned["status"] != "assigned" {
+		t.Errorf("task_create dryRun = %v", resp)
+	}
+	if tasks, _ := agent.ListTasks(team, "", ""); len(tasks) != 0 {
+		t.Fatalf("dry run created %d tasks", len(tasks))
+	}
+
+	task, _ := agent.CreateTask(team, "real", "", "w1", nil, "", "", "")
+	resp = callTool(t, cs, "task_redirect", map[string]any{"team": team, "taskId": task.ID, "newInstructions": "do it differently", "dryRun": true})
+	redirect, _ := resp["dryRun"].(map[string]any)
+	if redirect["cancel"] == nil || redirect["create"] == nil {
+		t.Errorf("task_redirect dryRun = %v", resp)
+	}
+	if got, _ := agent.GetTask(team, task.ID); got.Status != agent.TaskAssigned {
+		t.Errorf("dry run changed task status to %s", got.Status)
+	}
+
+	resp = callTool(t, cs, "team_start_all", map[string]any{"name": team, "dryRun": true})
+	starts, _ := resp["dryRun"].([]any)
+	if len(starts) != 1 || starts[0].(map[string]any)["start"] != true {
+		t.Errorf("team_start_all dryRun = %v", resp)
+	}
+	if agent.IsAgentAlive(team, "w1") {
+		t.Error("dry run started an agent")
+	}
+}
+
+func TestE2E_TeamTemplates(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+	copyTeam := team + "-copy"
+	defer agent.DeleteTeam(copyTeam)
+	defer agent.DeleteTeamTemplate(team)
+
+	callTool(t, cs, "team_create", map[string]any{"name": team, "description": "review team"})
+	callTool(t, cs, "agent_add", map[string]any{"team": team, "name": "lead", "type": "leader", "model": "opus"})
+	callTool(t, cs, "agent_add", map[string]any{"team": team, "name": "w1", "role": "reviewer"})
+
+	resp := callTool(t, cs, "team_template_save", map[string]any{"team": team, "name": team})
+	tmpl, _ := resp["template"].(map[string]any)
+	if members, _ := tmpl["members"].([]any); len(members) != 2 || tmpl["source"] != team {
+		t.Errorf("team_template_save = %v", resp)
+	}
+
+	resp = callTool(t, cs, "team_template_list", map[string]any{})
+	found := false
+	for _, tm := range resp["templates"].([]any) {
+		if tm.(map[string]any)["name"] == team {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("team_template_list = %v, want %s", resp, team)
+	}
+
+	workDir := t.TempDir()
+	callTool(t, cs, "team_template_instantiate", map[string]any{"template": team, "name": copyTeam, "workDir": workDir})
+	cfg, err := agent.GetTeam(copyTeam)
+	if err != nil {
+		t.Fatalf("GetTeam(copy): %v", err)
+	}
+	if cfg.Description != "review team" || cfg.WorkDir != workDir || len(cfg.Members) != 2 || cfg.Members[0].Model != "opus" {
+		t.Errorf("instantiated team = %+v", cfg)
+	}
+	if agent.IsAgentAlive(copyTeam, "lead") {
+		t.Error("agents started without start")
+	}
+}
+
+func TestE2E_TaskFollowup(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	callTool(t, cs, "team_create", map[string]any{"name": team})
+	task, _ := agent.CreateTask(team, "write docs", "", "w1", nil, "", "", "")
+	agent.CompleteTask(team, task.ID, "done")
+	agent.UpdateTask(team, task.ID, func(t *agent.Task) error {
+		t.SessionID = "sess-e2e"
+		return nil
+	})
+
+	resp := callTool(t, cs, "task_followup", map[string]any{"team": team, "taskId": task.ID, "instructions": "add an example"})
+	got, _ := resp["task"].(map[string]any)
+	if got["followUpOf"] != float64(task.ID) || got["sessionId"] != "sess-e2e" || got["subject"] != "Follow-up: write docs" {
+		t.Errorf("task_followup = %v", resp)
+	}
+}
+
+func TestE2E_TeamStatusShowsPlans(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	callTool(t, cs, "team_create", map[string]any{"name": team})
+	planned, _ := agent.CreateTask(team, "add login", "", "w1", nil, "", "", "")
+	unplanned, _ := agent.CreateTask(team, "add logout", "", "w2", nil, "", "", "")
+	for _, id := range []int{planned.ID, unplanned.ID} {
+		agent.UpdateTask(team, id, func(t *agent.Task) error {
+			t.Status = agent.TaskRunning
+			return nil
+		})
+	}
+	if _, err := agent.PostTaskPlan(team, "w1", planned.ID, "1. add the form\n2. call the API"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := callTool(t, cs, "team_status", map[string]any{"name": team})
+	running, _ := resp["runningTasks"].([]any)
+	if len(running) != 2 {
+		t.Fatalf("runningTasks = %v, want 2", resp["runningTasks"])
+	}
+	first, second := running[0].(map[string]any), running[1].(map[string]any)
+	plan, _ := first["plan"].(map[string]any)
+	if steps, _ := plan["steps"].([]any); len(steps) != 2 || plan["agent"] != "w1" {
+		t.Errorf("planned task = %v", first)
+	}
+	if _, has := second["plan"]; has {
+		t.Errorf("unplanned task = %v", second)
+	}
+}
+
+func TestE2E_TaskPlacement(t *testing.T) {
+	team := fmt.Sprintf("e2e-test-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	res, err := cs.CallTool(context.Background(), &mcpsdk.CallToolParams{
+		Name:      "team_create",
+		Arguments: map[string]any{"name": team, "assignment": "fastest"},
+	})
+	if err != nil || !res.IsError {
+		t.Errorf("team_create with unknown strategy: err = %v, result = %+v", err, res)
+	}
+	callTool(t, cs, "team_create", map[string]any{"name": team, "assignment": "least_loaded"})
+	callTool(t, cs, "agent_add", map[string]any{"team": team, "name": "w1", "skills": []string{"go"}})
+	callTool(t, cs, "agent_add", map[string]any{"team": team, "name": "w2", "skills": []string{"docs"}})
+	for _, name := range []string{"w1", "w2"} {
+		agent.SaveAgentState(&agent.AgentState{Name: name, Team: team, Status: agent.AgentIdle, PID: os.Getpid()})
+	}
+
+	resp := callTool(t, cs, "task_create", map[string]any{"team": team, "subject": "write docs", "skills": []string{"docs"}})
+	task, _ := resp["task"].(map[string]any)
+	if task["owner"] != "w2" || task["status"] != "assigned" {
+		t.Errorf("task_create with skills = %v", resp)
+	}
+}
+
+func TestMonitorWatchdogRestarts(t *testing.T) {
+	team := fmt.Sprintf("e2e-watchdog-%d", time.Now().UnixNano())
+	cs, cleanup := setupTestServer(t, team)
+	defer cleanup()
+
+	intervals := []*time.Duration{&monitorInterval, &watchdogInterval, &monitorStallAfter, &monitorBackoffMin, &monitorBackoffMax}
+	saved := make([]time.Duration, len(intervals))
+	for i, d := range intervals {
+		saved[i] = *d
+	}
+	t.Cleanup(func() {
+		for i, d := range intervals {
+			*d = saved[i]
+		}
+		monitorScanHook = nil
+	})
+	monitorInterval, watchdogInterval, monitorStallAfter = 20*time.Millisecond, 20*time.Millisecond, 300*time.Millisecond
+	monitorBackoffMin, monitorBackoffMax = 10*time.Millisecond, 50*time.Millisecond
+
+	tmpDir := t.TempDir()
+	notifDirOverride = tmpDir
+	defer func() { notifDirOverride = "" }()
+	data, _ := json.Marshal(taskNotification{Team: team, TaskID: 1, Status: "completed", Agent: "w1"})
+	os.WriteFile(filepath.Join(tmpDir, team+"__1.json"), data, 0644)
+
+	// The third scan panics and the sixth hangs until the test ends.
+	release := make(chan struct{})
+	defer close(release)
+	var scans atomic.Int32
+	monitorScanHook = func() {
+		switch scans.Add(1) {
+		case 3:
+			panic("boom")
+		case 6:
+			<-release
+		}
+	}
+
+	ensureMonitorRunning(mcpServer)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		health.mu.Lock()
+		restarts := health.restarts
+		health.mu.Unlock()
+		if restarts >= 2 && scans.Load() > 8 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("monitor restarted %d times after %d scans, want 2", restarts, scans.Load())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	resp := callTool(t, cs, "monitor_status", map[string]any{})
+	if resp["running"] != true || resp["restarts"].(float64) < 2 || resp["lastScan"] == nil || resp["notificationDir"] != tmpDir {
+		t.Errorf("monitor_status = %v", resp)
+	}
+	var messages []string
+	for _, e := range resp["errors"].([]any) {
+		messages = append(messages, e.(map[string]any)["message"].(string))
+	}
+	joined := strings.Join(messages, "\n")
+	if !strings.Contains(joined, "monitor panicked: boom") || !strings.Contains(joined, "monitor stalled") {
+		t.Errorf("monitor_status errors = %v, want the panic and the stall", messages)
+	}
+
+	// Restarts keep the record of queued files.
+	pendingMu.Lock()
+	queued := 0
+	for _, n := range notificationLog {
+		if n.Team == team {
+			queued++
+		}
+	}
+	pendingMu.Unlock()
+	if queued != 1 {
+		t.Errorf("notification queued %d times across restarts, want 1", queued)
+	}
+}
@@ -0,0 +1,138 @@
+package mcpserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// monitorError is an error the notification monitor or its watchdog ran
+// into.
+type monitorError struct {
+	Time    string `json:"time"`
+	Message string `json:"message"`
+}
+
+// monitorHealth records what the notification monitor has been doing, for
+// the watchdog and monitor_status.
+type monitorHealth struct {
+	mu          sync.Mutex
+	startedAt   time.Time
+	lastScan    time.Time
+	restarts    int
+	nextRestart time.Time
+	errorCount  int
+	errors      []monitorError
+}
+
+// start notes that a monitor was started at t.
+func (h *monitorHealth) start(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.startedAt.IsZero() {
+		h.restarts++
+	}
+	h.startedAt = t
+	h.nextRestart = time.Time{}
+}
+
+// scanned notes that the monitor finished a scan at t.
+func (h *monitorHealth) scanned(t time.Time) {
+	h.mu.Lock()
+	h.lastScan = t
+	h.mu.Unlock()
+}
+
+// lastBeat returns when the current monitor last showed it was alive: its
+// last scan, or its start if it has not scanned yet.
+func (h *monitorHealth) lastBeat() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.lastScan.After(h.startedAt) {
+		return h.lastScan
+	}
+	return h.startedAt
+}
+
+// failed records why the monitor stopped and when it will be restarted.
+func (h *monitorHealth) failed(err error, restartAt time.Time) {
+	h.recordError(err)
+	h.mu.Lock()
+	h.nextRestart = restartAt
+	h.mu.Unlock()
+}
+
+// recordError keeps err among the last maxMonitorErrors errors.
+func (h *monitorHealth) recordError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errorCount++
+	h.errors = append(h.errors, monitorError{Time: time.Now().UTC().Format(time.RFC3339), Message: err.Error()})
+	if len(h.errors) > maxMonitorErrors {
+		h.errors = h.errors[len(h.errors)-maxMonitorErrors:]
+	}
+}
+
+func (h *monitorHealth) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.startedAt, h.lastScan, h.nextRestart = time.Time{}, time.Time{}, time.Time{}
+	h.restarts, h.errorCount, h.errors = 0, 0, nil
+}
+
+// -- monitor_status --
+
+type monitorStatusInput struct{}
+
+type monitorStatusOutput struct {
+	Running             bool           `json:"running"`
+	Healthy             bool           `json:"healthy"`
+	NotificationDir     string         `json:"notificationDir"`
+	StartedAt           string         `json:"startedAt,omitempty"`
+	LastScan            string         `json:"lastScan,omitempty"`
+	NextRestart         string         `json:"nextRestart,omitempty"`
+	Restarts            int            `json:"restarts"`
+	QueuedNotifications int            `json:"queuedNotifications"`
+	QueuedForSession    int            `json:"queuedForSession"`
+	LastSequence        int64          `json:"lastSequence"`
+	ErrorCount          int            `json:"errorCount"`
+	Errors              []monitorError `json:"errors,omitempty"`
+}
+
+func monitorStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input monitorStatusInput) (*mcpsdk.CallToolResult, monitorStatusOutput, error) {
+	output := monitorStatusOutput{
+		Running:         monitorRunning.Load(),
+		NotificationDir: notificationDir(),
+	}
+
+	health.mu.Lock()
+	output.StartedAt = formatMonitorTime(health.startedAt)
+	output.LastScan = formatMonitorTime(health.lastScan)
+	output.NextRestart = formatMonitorTime(health.nextRestart)
+	output.Restarts = health.restarts
+	output.ErrorCount = health.errorCount
+	output.Errors = append([]monitorError(nil), health.errors...)
+	health.mu.Unlock()
+	output.Healthy = output.Running && time.Since(health.lastBeat()) <= monitorStallAfter
+
+	pendingMu.Lock()
+	for ss, queue := range pendingNotifications {
+		output.QueuedNotifications += len(queue)
+		if ss == req.Session {
+			output.QueuedForSession = len(queue)
+		}
+	}
+	output.LastSequence = notificationSeq
+	pendingMu.Unlock()
+
+	return nil, output, nil
+}
+
+func formatMonitorTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,204 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/config"
+	"codes/internal/remote"
+)
+
+// Remote host tools mirror the TUI's Remotes tab (test, sync, setup) so an
+// LLM can check a host is reachable and in sync before targeting it.
+
+// Version is the running codes version, used to detect stale installs on
+// remote hosts. Set by the serve command; "dev" skips the version check.
+var Version = "dev"
+
+// remoteSummary is a configured remote with its last known status.
+type remoteSummary struct {
+	Name    string               `json:"name"`
+	Address string               `json:"address"` // user@host
+	Port    int                  `json:"port,omitempty"`
+	Status  *remote.RemoteStatus `json:"lastStatus,omitempty"` // cached from the last check, may be stale
+}
+
+// remote_list
+
+type remoteListInput struct{}
+
+type remoteListOutput struct {
+	Remotes []remoteSummary `json:"remotes"`
+}
+
+func remoteListHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input remoteListInput) (*mcpsdk.CallToolResult, remoteListOutput, error) {
+	remotes, err := config.ListRemotes()
+	if err != nil {
+		return nil, remoteListOutput{}, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	cache := remote.LoadStatusCache()
+	out := remoteListOutput{Remotes: make([]remoteSummary, 0, len(remotes))}
+	for _, r := range remotes {
+		out.Remotes = append(out.Remotes, remoteSummary{
+			Name:    r.Name,
+			Address: r.UserAtHost(),
+			Port:    r.Port,
+			Status:  cache[r.Name],
+		})
+	}
+	return nil, out, nil
+}
+
+// remote_status
+
+type remoteStatusInput struct {
+	Name string `json:"name" jsonschema:"Remote host alias name"`
+}
+
+type remoteStatusOutput struct {
+	Name           string               `json:"name"`
+	Reachable      bool                 `json:"reachable"`
+	Error          string               `json:"error,omitempty"`
+	HostKeyUnknown bool                 `json:"hostKeyUnknown,omitempty"` // run `codes remote trust <name>` first
+	Status         *remote.RemoteStatus `json:"status,omitempty"`
+	Drift          *remote.Drift        `json:"drift,omitempty"`
+	InSync         bool                 `json:"inSync"`
+}
+
+func remoteStatusHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input remoteStatusInput) (*mcpsdk.CallToolResult, remoteStatusOutput, error) {
+	host, ok := config.GetRemote(input.Name)
+	if !ok {
+		return nil, remoteStatusOutput{}, fmt.Errorf("remote %q not found", input.Name)
+	}
+
+	// An unreachable host is a result, not a tool failure
+	out := remoteStatusOutput{Name: host.Name}
+	status, err := remote.CheckRemoteStatus(host)
+	if err != nil {
+		out.Error = err.Error()
+		var unknown *remote.HostKeyUnknownError
+		out.HostKeyUnknown = errors.As(err, &unknown)
+		return nil, out, nil
+	}
+	out.Reachable = true
+	out.Status = status
+	remote.UpdateStatusCache(host.Name, status)
+
+	if status.CodesInstalled {
+		drift, err := remote.Diff(host, Version)
+		if err != nil {
+			out.Error = fmt.Sprintf("compare config: %v", err)
+			return nil, out, nil
+		}
+		out.Drift = drift
+		out.InSync = drift.InSync()
+	}
+	return nil, out, nil
+}
+
+// remote_sync
+
+type remoteSyncInput struct {
+	Name string `json:"name" jsonschema:"Remote host alias name to sync profiles to"`
+}
+
+type remoteSyncOutput struct {
+	Synced bool                 `json:"synced"`
+	Status *remote.RemoteStatus `json:"status,omitempty"`
+}
+
+func remoteSyncHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input remoteSyncInput) (*mcpsdk.CallToolResult, remoteSyncOutput, error) {
+	host, ok := config.GetRemote(input.Name)
+	if !ok {
+		return nil, remoteSyncOutput{}, fmt.Errorf("remote %q not found", input.Name)
+	}
+	if err := remote.SyncProfiles(host); err != nil {
+		return nil, remoteSyncOutput{}, fmt.Errorf("failed to sync: %w", err)
+	}
+	out := remoteSyncOutput{Synced: true}
+	if status, err := remote.CheckRemoteStatus(host); err == nil {
+		out.Status = status
+		remote.UpdateStatusCache(host.Name, status)
+	}
+	return nil, out, nil
+}
+
+// remote_setup
+
+type remoteSetupInput struct {
+	Name       string `json:"name" jsonschema:"Remote host alias name to set up"`
+	RateLimit  string `json:"rateLimit,omitempty" jsonschema:"Cap download bandwidth, e.g. 500k or 2M (optional)"`
+	SkipClaude bool   `json:"skipClaude,omitempty" jsonschema:"Do not install the Claude CLI (optional)"`
+}
+
+type remoteSetupOutput struct {
+	Installed   bool                 `json:"installed"`
+	ClaudeError string               `json:"claudeError,omitempty"` // Claude CLI install failure (non-fatal)
+	Synced      bool                 `json:"synced"`
+	Stages      []string             `json:"stages"`
+	Status      *remote.RemoteStatus `json:"status,omitempty"`
+}
+
+func remoteSetupHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input remoteSetupInput) (*mcpsdk.CallToolResult, remoteSetupOutput, error) {
+	host, ok := config.GetRemote(input.Name)
+	if !ok {
+		return nil, remoteSetupOutput{}, fmt.Errorf("remote %q not found", input.Name)
+	}
+
+	out := remoteSetupOutput{Stages: []string{}}
+	progress := func(stage string) { out.Stages = append(out.Stages, stage) }
+
+	// Same steps as the TUI's setup: install codes, Claude CLI, then sync
+	if _, err := remote.InstallOnRemoteWithOptions(host, remote.InstallOptions{RateLimit: input.RateLimit, Progress: progress}); err != nil {
+		return nil, out, fmt.Errorf("install codes: %w", err)
+	}
+	out.Installed = true
+
+	if !input.SkipClaude {
+		progress("installing Claude CLI")
+		if _, err := remote.InstallClaudeOnRemote(host); err != nil {
+			out.ClaudeError = err.Error()
+		}
+	}
+
+	progress("syncing profiles")
+	if err := remote.SyncProfiles(host); err != nil {
+		return nil, out, fmt.Errorf("sync profiles: %w", err)
+	}
+	out.Synced = true
+
+	if status, err := remote.CheckRemoteStatus(host); err == nil {
+		out.Status = status
+		remote.UpdateStatusCache(host.Name, status)
+	}
+	return nil, out, nil
+}
+
+func registerRemoteTools(server *mcpsdk.Server) {
+	addTool(server, &mcpsdk.Tool{
+		Name:        "remote_list",
+		Annotations: readOnly(),
+		Description: "List configured remote SSH hosts with their last known status (codes/Claude installed, OS, arch). Call remote_status for a live check.",
+	}, remoteListHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "remote_status",
+		Annotations: readOnly(),
+		Description: "Check a remote host live: whether it is reachable over SSH, what is installed, and whether its profiles and codes version are in sync with this machine. Use before creating tasks that run on the host.",
+	}, remoteStatusHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "remote_sync",
+		Annotations: destructive(true),
+		Description: "Sync local API profiles and settings to a remote host, then return its refreshed status",
+	}, remoteSyncHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "remote_setup",
+		Annotations: additive(true),
+		Description: "Fully set up a remote host: install or update codes, install the Claude CLI, and sync profiles. Can take a few minutes.",
+	}, remoteSetupHandler)
+}
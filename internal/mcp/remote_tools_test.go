@@ -0,0 +1,45 @@
+package mcpserver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestRemoteToolsUseConfiguredHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { config.ConfigPath = origPath }()
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.AddRemote(config.RemoteHost{Name: "build", Host: "build.example.com", User: "ci", Port: 2222}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, list, err := remoteListHandler(ctx, nil, remoteListInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Remotes) != 1 {
+		t.Fatalf("expected 1 remote, got %+v", list.Remotes)
+	}
+	if r := list.Remotes[0]; r.Name != "build" || r.Address != "ci@build.example.com" || r.Port != 2222 {
+		t.Errorf("unexpected remote summary: %+v", r)
+	}
+
+	if _, _, err := remoteStatusHandler(ctx, nil, remoteStatusInput{Name: "missing"}); err == nil {
+		t.Error("expected error for unknown remote in remote_status")
+	}
+	if _, _, err := remoteSyncHandler(ctx, nil, remoteSyncInput{Name: "missing"}); err == nil {
+		t.Error("expected error for unknown remote in remote_sync")
+	}
+	if _, _, err := remoteSetupHandler(ctx, nil, remoteSetupInput{Name: "missing"}); err == nil {
+		t.Error("expected error for unknown remote in remote_setup")
+	}
+}
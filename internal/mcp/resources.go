@@ -0,0 +1,353 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+)
+
+// Team data is exposed as read-only MCP resources:
+//
+//	codes://teams/{team}/status      team dashboard (same shape as team_status)
+//	codes://teams/{team}/messages    recent team messages, newest first
+//	codes://teams/{team}/tasks/{id}  a single task
+//
+// Concrete resources are kept in sync with disk so resources/list works, and
+// clients that call resources/subscribe get notifications/resources/updated
+// when data changes, e.g. a task moving from running to completed. This is the
+// push alternative to notifications_poll and team_subscribe.
+
+const teamResourcePrefix = "codes://teams/"
+
+// resourceMessageLimit caps how many messages the messages resource returns.
+const resourceMessageLimit = 50
+
+const (
+	// resourceSyncInterval is how often the tracker rescans team data on
+	// disk when file watching is unavailable.
+	resourceSyncInterval = 3 * time.Second
+
+	// resourceFallbackInterval is the rescan interval while file watching
+	// works; it only catches events the watcher may have dropped.
+	resourceFallbackInterval = 30 * time.Second
+
+	// resourceDebounce coalesces bursts of file events (a task update writes
+	// a temp file, renames it and touches its lock) into one rescan.
+	resourceDebounce = 150 * time.Millisecond
+)
+
+// teamResourceServerOptions returns server options enabling resource
+// subscriptions. The SDK records subscribers; the tracker notifies them.
+func teamResourceServerOptions() *mcpsdk.ServerOptions {
+	return &mcpsdk.ServerOptions{
+		SubscribeHandler: func(ctx context.Context, req *mcpsdk.SubscribeRequest) error {
+			if _, _, _, ok := parseTeamResourceURI(req.Params.URI); !ok {
+				return mcpsdk.ResourceNotFoundError(req.Params.URI)
+			}
+			return nil
+		},
+		UnsubscribeHandler: func(ctx context.Context, req *mcpsdk.UnsubscribeRequest) error {
+			return nil
+		},
+	}
+}
+
+// registerTeamResources adds the team resource templates and the concrete
+// resources currently on disk.
+func registerTeamResources(server *mcpsdk.Server) *teamResourceTracker {
+	server.AddResourceTemplate(&mcpsdk.ResourceTemplate{
+		Name:        "team-status",
+		Title:       "Team dashboard",
+		Description: "Agents, task counts, recent completions and messages for a team",
+		URITemplate: teamResourcePrefix + "{team}/status",
+		MIMEType:    "application/json",
+	}, readTeamResource)
+
+	server.AddResourceTemplate(&mcpsdk.ResourceTemplate{
+		Name:        "team-messages",
+		Title:       "Team messages",
+		Description: "Recent messages exchanged in a team, newest first",
+		URITemplate: teamResourcePrefix + "{team}/messages",
+		MIMEType:    "application/json",
+	}, readTeamResource)
+
+	server.AddResourceTemplate(&mcpsdk.ResourceTemplate{
+		Name:        "team-task",
+		Title:       "Team task",
+		Description: "A task with its description, status, result and history",
+		URITemplate: teamResourcePrefix + "{team}/tasks/{id}",
+		MIMEType:    "application/json",
+	}, readTeamResource)
+
+	tracker := newTeamResourceTracker()
+	tracker.sync(context.Background(), server)
+	return tracker
+}
+
+// parseTeamResourceURI splits a codes://teams URI into its team, kind
+// ("status", "messages" or "tasks") and task ID.
+func parseTeamResourceURI(uri string) (team, kind string, id int, ok bool) {
+	rest, found := strings.CutPrefix(uri, teamResourcePrefix)
+	if !found {
+		return "", "", 0, false
+	}
+	parts := strings.Split(rest, "/")
+	for i, p := range parts {
+		unescaped, err := url.PathUnescape(p)
+		if err != nil || unescaped == "" {
+			return "", "", 0, false
+		}
+		parts[i] = unescaped
+	}
+
+	switch {
+	case len(parts) == 2 && (parts[1] == "status" || parts[1] == "messages"):
+		return parts[0], parts[1], 0, true
+	case len(parts) == 3 && parts[1] == "tasks":
+		n, err := strconv.Atoi(parts[2])
+		if err != nil || n <= 0 {
+			return "", "", 0, false
+		}
+		return parts[0], "tasks", n, true
+	}
+	return "", "", 0, false
+}
+
+// teamResourceURI builds the URI for a team resource. Pass id 0 for
+// status and messages.
+func teamResourceURI(team, kind string, id int) string {
+	uri := teamResourcePrefix + url.PathEscape(team) + "/" + kind
+	if id > 0 {
+		uri += "/" + strconv.Itoa(id)
+	}
+	return uri
+}
+
+// readTeamResource serves resources/read for all team resource URIs.
+func readTeamResource(ctx context.Context, req *mcpsdk.ReadResourceRequest) (*mcpsdk.ReadResourceResult, error) {
+	uri := req.Params.URI
+	team, kind, id, ok := parseTeamResourceURI(uri)
+	if !ok {
+		return nil, mcpsdk.ResourceNotFoundError(uri)
+	}
+
+	var v any
+	switch kind {
+	case "status":
+		out, err := buildTeamStatus(team)
+		if err != nil {
+			return nil, mcpsdk.ResourceNotFoundError(uri)
+		}
+		v = out
+	case "messages":
+		if _, err := agent.GetTeam(team); err != nil {
+			return nil, mcpsdk.ResourceNotFoundError(uri)
+		}
+		msgs, err := agent.GetAllTeamMessages(team, resourceMessageLimit)
+		if err != nil {
+			return nil, err
+		}
+		if msgs == nil {
+			msgs = []*agent.Message{}
+		}
+		v = msgs
+	case "tasks":
+		task, err := agent.GetTask(team, id)
+		if err != nil {
+			return nil, mcpsdk.ResourceNotFoundError(uri)
+		}
+		v = task
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcpsdk.ReadResourceResult{Contents: []*mcpsdk.ResourceContents{
+		{URI: uri, MIMEType: "application/json", Text: string(data)},
+	}}, nil
+}
+
+// teamResourceTracker mirrors team data on disk as concrete server resources
+// and remembers a fingerprint per URI to detect updates.
+type teamResourceTracker struct {
+	mu           sync.Mutex
+	fingerprints map[string]string
+}
+
+func newTeamResourceTracker() *teamResourceTracker {
+	return &teamResourceTracker{fingerprints: make(map[string]string)}
+}
+
+// watch keeps resources in sync until ctx is cancelled. File changes under
+// the teams directory trigger a rescan after a short debounce, so subscribers
+// see task state changes almost immediately; the ticker is a fallback for
+// missed events and for filesystems where watching is unavailable.
+func (rt *teamResourceTracker) watch(ctx context.Context, server *mcpsdk.Server) {
+	interval := resourceFallbackInterval
+	changes, err := agent.WatchTeams(ctx)
+	if err != nil {
+		log.Printf("resources: file watching unavailable, polling every %s: %v", resourceSyncInterval, err)
+		interval = resourceSyncInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	debounce := time.NewTimer(0)
+	<-debounce.C
+	pending := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+				ticker.Reset(resourceSyncInterval)
+				continue
+			}
+			if !pending {
+				pending = true
+				debounce.Reset(resourceDebounce)
+			}
+		case <-debounce.C:
+			pending = false
+			rt.sync(ctx, server)
+		case <-ticker.C:
+			rt.sync(ctx, server)
+		}
+	}
+}
+
+// teamResourceEntry is a resource found on disk and its current fingerprint.
+type teamResourceEntry struct {
+	resource    *mcpsdk.Resource
+	fingerprint string
+}
+
+// scanTeamResources lists all team resources currently on disk.
+func scanTeamResources() map[string]teamResourceEntry {
+	entries := make(map[string]teamResourceEntry)
+	teams, err := agent.ListTeams()
+	if err != nil {
+		return entries
+	}
+
+	for _, team := range teams {
+		cfg, err := agent.GetTeam(team)
+		if err != nil {
+			continue
+		}
+
+		var status strings.Builder
+		tasks, _ := agent.ListTasks(team, "", "")
+		for _, t := range tasks {
+			fp := fmt.Sprintf("%s|%s|%d", t.Status, t.Owner, t.UpdatedAt.UnixNano())
+			fmt.Fprintf(&status, "t%d=%s;", t.ID, fp)
+			uri := teamResourceURI(team, "tasks", t.ID)
+			entries[uri] = teamResourceEntry{
+				resource: &mcpsdk.Resource{
+					URI:         uri,
+					Name:        fmt.Sprintf("%s-task-%d", team, t.ID),
+					Title:       fmt.Sprintf("#%d %s", t.ID, t.Subject),
+					Description: fmt.Sprintf("Task #%d in team %s (%s)", t.ID, team, t.Status),
+					MIMEType:    "application/json",
+				},
+				fingerprint: fp,
+			}
+		}
+
+		msgFP := ""
+		if msgs, err := agent.GetAllTeamMessages(team, resourceMessageLimit); err == nil && len(msgs) > 0 {
+			read := 0
+			for _, m := range msgs {
+				if m.Read {
+					read++
+				}
+			}
+			msgFP = fmt.Sprintf("%s|%d|%d", msgs[0].ID, len(msgs), read)
+		}
+		uri := teamResourceURI(team, "messages", 0)
+		entries[uri] = teamResourceEntry{
+			resource: &mcpsdk.Resource{
+				URI:         uri,
+				Name:        team + "-messages",
+				Title:       fmt.Sprintf("%s messages", team),
+				Description: fmt.Sprintf("Recent messages in team %s", team),
+				MIMEType:    "application/json",
+			},
+			fingerprint: msgFP,
+		}
+
+		fmt.Fprintf(&status, "m=%s;", msgFP)
+		for _, m := range cfg.Members {
+			if state, _ := agent.GetAgentState(team, m.Name); state != nil {
+				fmt.Fprintf(&status, "a%s=%s|%d|%s;", m.Name, state.Status, state.CurrentTask, state.Activity)
+			}
+		}
+		uri = teamResourceURI(team, "status", 0)
+		entries[uri] = teamResourceEntry{
+			resource: &mcpsdk.Resource{
+				URI:         uri,
+				Name:        team + "-status",
+				Title:       fmt.Sprintf("%s dashboard", team),
+				Description: fmt.Sprintf("Agents and task progress for team %s", team),
+				MIMEType:    "application/json",
+			},
+			fingerprint: status.String(),
+		}
+	}
+	return entries
+}
+
+// sync adds new resources, removes deleted ones, and sends
+// resources/updated for any whose fingerprint changed.
+func (rt *teamResourceTracker) sync(ctx context.Context, server *mcpsdk.Server) {
+	current := scanTeamResources()
+
+	rt.mu.Lock()
+	var added []*mcpsdk.Resource
+	var removed, updated []string
+	for uri, e := range current {
+		prev, known := rt.fingerprints[uri]
+		switch {
+		case !known:
+			added = append(added, e.resource)
+		case prev != e.fingerprint:
+			updated = append(updated, uri)
+			// Titles and descriptions include task status, so re-register.
+			added = append(added, e.resource)
+		}
+		rt.fingerprints[uri] = e.fingerprint
+	}
+	for uri := range rt.fingerprints {
+		if _, ok := current[uri]; !ok {
+			removed = append(removed, uri)
+			delete(rt.fingerprints, uri)
+		}
+	}
+	rt.mu.Unlock()
+
+	for _, r := range added {
+		server.AddResource(r, readTeamResource)
+	}
+	if len(removed) > 0 {
+		server.RemoveResources(removed...)
+	}
+	for _, uri := range append(updated, removed...) {
+		if err := server.ResourceUpdated(ctx, &mcpsdk.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+			log.Printf("resources: notify %s: %v", uri, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package mcpserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+)
+
+func TestParseTeamResourceURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		team string
+		kind string
+		id   int
+		ok   bool
+	}{
+		{"codes://teams/dev/status", "dev", "status", 0, true},
+		{"codes://teams/dev/messages", "dev", "messages", 0, true},
+		{"codes://teams/dev/tasks/7", "dev", "tasks", 7, true},
+		{"codes://teams/my%20team/tasks/1", "my team", "tasks", 1, true},
+		{"codes://teams/dev/tasks/abc", "", "", 0, false},
+		{"codes://teams/dev/tasks/0", "", "", 0, false},
+		{"codes://teams/dev", "", "", 0, false},
+		{"codes://teams//status", "", "", 0, false},
+		{"file:///etc/passwd", "", "", 0, false},
+	}
+	for _, tt := range tests {
+		team, kind, id, ok := parseTeamResourceURI(tt.uri)
+		if ok != tt.ok || team != tt.team || kind != tt.kind || id != tt.id {
+			t.Errorf("parseTeamResourceURI(%q) = %q, %q, %d, %v", tt.uri, team, kind, id, ok)
+		}
+	}
+	if uri := teamResourceURI("my team", "tasks", 3); uri != "codes://teams/my%20team/tasks/3" {
+		t.Errorf("teamResourceURI = %q", uri)
+	}
+}
+
+// connectResourceClient connects an in-memory client to server that forwards
+// resources/updated URIs to updates.
+func connectResourceClient(t *testing.T, ctx context.Context, server *mcpsdk.Server, updates chan<- string) *mcpsdk.ClientSession {
+	t.Helper()
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "0.0.1"}, &mcpsdk.ClientOptions{
+		ResourceUpdatedHandler: func(ctx context.Context, req *mcpsdk.ResourceUpdatedNotificationRequest) {
+			updates <- req.Params.URI
+		},
+	})
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func TestTeamResources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	const team = "res-team"
+	if _, err := agent.CreateTeam(team, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	task, err := agent.CreateTask(team, "Write the docs", "Cover the resources API", "", nil, agent.PriorityNormal, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskURI := teamResourceURI(team, "tasks", task.ID)
+
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "codes-test", Version: "0.0.1"}, teamResourceServerOptions())
+	tracker := registerTeamResources(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	updates := make(chan string, 10)
+	cs := connectResourceClient(t, ctx, server, updates)
+
+	templates, err := cs.ListResourceTemplates(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates.ResourceTemplates) != 3 {
+		t.Errorf("expected 3 resource templates, got %d", len(templates.ResourceTemplates))
+	}
+
+	list, err := cs.ListResources(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, r := range list.Resources {
+		found[r.URI] = true
+	}
+	for _, uri := range []string{taskURI, teamResourceURI(team, "status", 0), teamResourceURI(team, "messages", 0)} {
+		if !found[uri] {
+			t.Errorf("resources/list missing %s", uri)
+		}
+	}
+
+	res, err := cs.ReadResource(ctx, &mcpsdk.ReadResourceParams{URI: taskURI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Contents) != 1 || !strings.Contains(res.Contents[0].Text, "Cover the resources API") {
+		t.Errorf("unexpected task contents: %+v", res.Contents)
+	}
+
+	res, err = cs.ReadResource(ctx, &mcpsdk.ReadResourceParams{URI: teamResourceURI(team, "status", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res.Contents[0].Text, `"pending": 1`) {
+		t.Errorf("status should count the pending task: %s", res.Contents[0].Text)
+	}
+
+	// Templates serve tasks created after the last sync
+	late, _ := agent.CreateTask(team, "Late task", "", "", nil, agent.PriorityNormal, "", "")
+	if _, err := cs.ReadResource(ctx, &mcpsdk.ReadResourceParams{URI: teamResourceURI(team, "tasks", late.ID)}); err != nil {
+		t.Errorf("template read of unsynced task: %v", err)
+	}
+
+	if _, err := cs.ReadResource(ctx, &mcpsdk.ReadResourceParams{URI: teamResourceURI(team, "tasks", 999)}); err == nil {
+		t.Error("expected error reading a missing task")
+	}
+
+	if err := cs.Subscribe(ctx, &mcpsdk.SubscribeParams{URI: taskURI}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agent.UpdateTask(team, task.ID, func(t *agent.Task) error {
+		t.Owner = "worker"
+		t.Status = agent.TaskAssigned
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tracker.sync(ctx, server)
+
+	select {
+	case uri := <-updates:
+		if uri != taskURI {
+			t.Errorf("update for %s, want %s", uri, taskURI)
+		}
+	case <-ctx.Done():
+		t.Fatal("no resources/updated notification after task change")
+	}
+}
+
+func TestTeamResourceSubscriptionIsEventDriven(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	const team = "sub-team"
+	if _, err := agent.CreateTeam(team, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	task, err := agent.CreateTask(team, "Ship it", "", "", nil, agent.PriorityNormal, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskURI := teamResourceURI(team, "tasks", task.ID)
+
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "codes-test", Version: "0.0.1"}, teamResourceServerOptions())
+	tracker := registerTeamResources(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go tracker.watch(ctx, server)
+
+	updates := make(chan string, 10)
+	cs := connectResourceClient(t, ctx, server, updates)
+	if err := cs.Subscribe(ctx, &mcpsdk.SubscribeParams{URI: taskURI}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Subscribe(ctx, &mcpsdk.SubscribeParams{URI: "codes://elsewhere/x"}); err == nil {
+		t.Error("expected subscribe to a non-team URI to fail")
+	}
+
+	// Give the watcher a moment to register before changing state.
+	time.Sleep(100 * time.Millisecond)
+	if _, err := agent.CancelTask(team, task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Well under resourceFallbackInterval, so only a file event can deliver it.
+	select {
+	case uri := <-updates:
+		if uri != taskURI {
+			t.Errorf("update for %s, want %s", uri, taskURI)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no resources/updated notification after task was cancelled")
+	}
+}
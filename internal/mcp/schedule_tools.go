@@ -0,0 +1,185 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"codes/internal/agent"
+	"codes/internal/assistant/scheduler"
+)
+
+// Schedule tools share the assistant's schedule store, so reminders and
+// scheduled agent work fire from the scheduler run by `codes serve`.
+
+// runningScheduler is the scheduler started by the serve command. It is
+// reloaded after changes so they take effect without a restart.
+var runningScheduler *scheduler.Scheduler
+
+// SetScheduler registers the running scheduler for the schedule tools.
+func SetScheduler(s *scheduler.Scheduler) {
+	runningScheduler = s
+}
+
+// reloadScheduler makes the running scheduler pick up schedule changes.
+func reloadScheduler() {
+	if runningScheduler == nil {
+		return
+	}
+	if err := runningScheduler.Reload(); err != nil {
+		log.Printf("[mcp] reload scheduler: %v", err)
+	}
+}
+
+// schedule_create
+
+type scheduleCreateInput struct {
+	Message   string `json:"message" jsonschema:"Reminder text, or the task description when team is set"`
+	At        string `json:"at,omitempty" jsonschema:"Fire once at this time (RFC 3339, e.g. 2026-02-21T09:00:00+08:00)"`
+	Cron      string `json:"cron,omitempty" jsonschema:"Fire repeatedly on this 5-field cron expression (e.g. '0 9 * * 1-5' for 9am on weekdays)"`
+	Team      string `json:"team,omitempty" jsonschema:"Create a task in this team when the schedule fires, instead of a reminder"`
+	Subject   string `json:"subject,omitempty" jsonschema:"Subject of the scheduled task (default: the message)"`
+	Assign    string `json:"assign,omitempty" jsonschema:"Agent to assign the scheduled task to"`
+	SessionID string `json:"sessionId,omitempty" jsonschema:"Assistant session that receives a reminder (default: default)"`
+}
+
+type scheduleCreateOutput struct {
+	Schedule *scheduler.Schedule `json:"schedule"`
+	Active   bool                `json:"active"` // false: saved, but fires only once codes serve is running
+}
+
+func scheduleCreateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input scheduleCreateInput) (*mcpsdk.CallToolResult, scheduleCreateOutput, error) {
+	if (input.At == "") == (input.Cron == "") {
+		return nil, scheduleCreateOutput{}, fmt.Errorf("exactly one of at and cron is required")
+	}
+	sc := &scheduler.Schedule{
+		Message:   input.Message,
+		SessionID: input.SessionID,
+		Team:      input.Team,
+		Subject:   input.Subject,
+		Owner:     input.Assign,
+		Enabled:   true,
+	}
+	if sc.SessionID == "" {
+		sc.SessionID = "default"
+	}
+	if input.At != "" {
+		at, err := time.Parse(time.RFC3339, input.At)
+		if err != nil {
+			return nil, scheduleCreateOutput{}, fmt.Errorf("invalid at %q: use RFC 3339, e.g. 2026-02-21T09:00:00+08:00", input.At)
+		}
+		if at.Before(time.Now()) {
+			return nil, scheduleCreateOutput{}, fmt.Errorf("at %s is in the past", input.At)
+		}
+		sc.Type = scheduler.TypeOnce
+		sc.At = &at
+	} else {
+		sc.Type = scheduler.TypePeriodic
+		sc.Cron = input.Cron
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, scheduleCreateOutput{}, err
+	}
+	if sc.Team != "" {
+		cfg, err := agent.GetTeam(sc.Team)
+		if err != nil {
+			return nil, scheduleCreateOutput{}, err
+		}
+		if sc.Owner != "" && !hasMember(cfg, sc.Owner) {
+			return nil, scheduleCreateOutput{}, fmt.Errorf("agent %q is not a member of team %q", sc.Owner, sc.Team)
+		}
+	}
+
+	if err := scheduler.AddSchedule(sc); err != nil {
+		return nil, scheduleCreateOutput{}, fmt.Errorf("save schedule: %w", err)
+	}
+	reloadScheduler()
+	return nil, scheduleCreateOutput{Schedule: sc, Active: runningScheduler != nil}, nil
+}
+
+func hasMember(cfg *agent.TeamConfig, name string) bool {
+	for _, m := range cfg.Members {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// schedule_list
+
+type scheduleListInput struct {
+	Team string `json:"team,omitempty" jsonschema:"Only list schedules that create tasks in this team"`
+}
+
+type scheduleListOutput struct {
+	Schedules []*scheduler.Schedule `json:"schedules"`
+}
+
+func scheduleListHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input scheduleListInput) (*mcpsdk.CallToolResult, scheduleListOutput, error) {
+	schedules, err := scheduler.ListSchedules()
+	if err != nil {
+		return nil, scheduleListOutput{}, err
+	}
+	out := scheduleListOutput{Schedules: []*scheduler.Schedule{}}
+	for _, sc := range schedules {
+		if input.Team == "" || sc.Team == input.Team {
+			out.Schedules = append(out.Schedules, sc)
+		}
+	}
+	return nil, out, nil
+}
+
+// schedule_cancel
+
+type scheduleCancelInput struct {
+	ID string `json:"id" jsonschema:"Schedule ID from schedule_list or schedule_create"`
+}
+
+type scheduleCancelOutput struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+func scheduleCancelHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input scheduleCancelInput) (*mcpsdk.CallToolResult, scheduleCancelOutput, error) {
+	schedules, err := scheduler.ListSchedules()
+	if err != nil {
+		return nil, scheduleCancelOutput{}, err
+	}
+	found := false
+	for _, sc := range schedules {
+		if sc.ID == input.ID {
+			found = true
+		}
+	}
+	if !found {
+		return nil, scheduleCancelOutput{}, fmt.Errorf("schedule %q not found", input.ID)
+	}
+	if err := scheduler.RemoveSchedule(input.ID); err != nil {
+		return nil, scheduleCancelOutput{}, err
+	}
+	reloadScheduler()
+	return nil, scheduleCancelOutput{Cancelled: true}, nil
+}
+
+func registerScheduleTools(server *mcpsdk.Server) {
+	addTool(server, &mcpsdk.Tool{
+		Name:        "schedule_create",
+		Annotations: additive(false),
+		Description: "Schedule future work, once (at) or repeatedly (cron). With team, each firing creates a task in that team, optionally assigned to an agent; without, it is a reminder delivered to the assistant session. Schedules fire while codes serve is running.",
+	}, scheduleCreateHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "schedule_list",
+		Annotations: readOnly(),
+		Description: "List scheduled reminders and scheduled agent tasks, including those set from the assistant",
+	}, scheduleListHandler)
+
+	addTool(server, &mcpsdk.Tool{
+		Name:        "schedule_cancel",
+		Annotations: destructive(true),
+		Description: "Cancel and remove a schedule by ID",
+	}, scheduleCancelHandler)
+}
@@ -0,0 +1,69 @@
+package mcpserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"codes/internal/agent"
+	"codes/internal/assistant/scheduler"
+)
+
+func TestScheduleTools(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	if _, err := agent.CreateTeam("nightly", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.AddMember("nightly", agent.TeamMember{Name: "w1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Now().Add(time.Hour).Format(time.RFC3339)
+	invalid := []scheduleCreateInput{
+		{Message: "no time"},
+		{Message: "both", At: at, Cron: "0 9 * * *"},
+		{Message: "past", At: "2020-01-01T00:00:00Z"},
+		{Message: "bad cron", Cron: "every day"},
+		{At: at},
+		{Message: "missing team", Cron: "0 9 * * *", Team: "nope"},
+		{Message: "missing agent", Cron: "0 9 * * *", Team: "nightly", Assign: "w9"},
+	}
+	for _, in := range invalid {
+		if _, _, err := scheduleCreateHandler(ctx, nil, in); err == nil {
+			t.Errorf("schedule_create(%+v) should fail", in)
+		}
+	}
+
+	_, reminder, err := scheduleCreateHandler(ctx, nil, scheduleCreateInput{Message: "stand-up", At: at})
+	if err != nil {
+		t.Fatalf("schedule_create reminder: %v", err)
+	}
+	if reminder.Schedule.Type != scheduler.TypeOnce || reminder.Schedule.SessionID != "default" || reminder.Active {
+		t.Errorf("reminder = %+v", reminder)
+	}
+	_, work, err := scheduleCreateHandler(ctx, nil, scheduleCreateInput{Message: "run the test suite", Cron: "0 2 * * *", Team: "nightly", Subject: "Nightly tests", Assign: "w1"})
+	if err != nil {
+		t.Fatalf("schedule_create task: %v", err)
+	}
+	if work.Schedule.Type != scheduler.TypePeriodic || work.Schedule.Team != "nightly" || work.Schedule.Owner != "w1" {
+		t.Errorf("scheduled work = %+v", work.Schedule)
+	}
+
+	_, all, _ := scheduleListHandler(ctx, nil, scheduleListInput{})
+	_, team, _ := scheduleListHandler(ctx, nil, scheduleListInput{Team: "nightly"})
+	if len(all.Schedules) != 2 || len(team.Schedules) != 1 || team.Schedules[0].ID != work.Schedule.ID {
+		t.Errorf("schedule_list = %d all, %+v for team", len(all.Schedules), team.Schedules)
+	}
+
+	if _, out, err := scheduleCancelHandler(ctx, nil, scheduleCancelInput{ID: reminder.Schedule.ID}); err != nil || !out.Cancelled {
+		t.Errorf("schedule_cancel = %+v, %v", out, err)
+	}
+	if _, _, err := scheduleCancelHandler(ctx, nil, scheduleCancelInput{ID: reminder.Schedule.ID}); err == nil {
+		t.Error("cancelling a removed schedule should fail")
+	}
+	if _, all, _ = scheduleListHandler(ctx, nil, scheduleListInput{}); len(all.Schedules) != 1 {
+		t.Errorf("after cancel, %d schedules left", len(all.Schedules))
+	}
+}
@@ -7,80 +7,113 @@ import (
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// buildServer creates and registers all MCP tools on a new server instance.
+// buildServer creates and registers all MCP tools and resources on a new server instance.
 // Both stdio and SSE modes share this same server.
 func buildServer() *mcpsdk.Server {
 	server := mcpsdk.NewServer(
 		&mcpsdk.Implementation{
 			Name:    "codes",
-			Version: "1.0.0",
+			Version: Version,
 		},
-		nil,
+		teamResourceServerOptions(),
 	)
+	server.AddReceivingMiddleware(recordCalls, structuredErrors, auditCalls)
 
 	// Register tools
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "list_projects",
-		Description: "List all configured project aliases with their paths and git status",
+		Annotations: readOnly(),
+		Description: "List all configured project aliases with their paths and git status (archived projects only with includeArchived)",
 	}, listProjectsHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "add_project",
+		Annotations: additive(true),
 		Description: "Add a new project alias mapping a name to a directory path",
 	}, addProjectHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "remove_project",
+		Annotations: destructive(true),
 		Description: "Remove a project alias by name",
 	}, removeProjectHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "list_profiles",
+		Annotations: readOnly(),
 		Description: "List all API profiles with their status and settings",
 	}, listProfilesHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "switch_profile",
+		Annotations: additive(true),
 		Description: "Switch the default API profile",
 	}, switchProfileHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "get_project_info",
+		Annotations: readOnly(),
 		Description: "Get detailed information about a project including git status and branch info",
 	}, getProjectInfoHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "list_remotes",
-		Description: "List all configured remote SSH hosts",
+		Annotations: readOnly(),
+		Description: "List all configured remote SSH hosts (see also remote_list, which includes last known status)",
 	}, listRemotesHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "add_remote",
+		Annotations: additive(true),
 		Description: "Add a new remote SSH host configuration",
 	}, addRemoteHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "remove_remote",
+		Annotations: destructive(true),
 		Description: "Remove a remote SSH host configuration by name",
 	}, removeRemoteHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "sync_remote",
-		Description: "Sync local API profiles and settings to a remote SSH host",
+		Annotations: destructive(true),
+		Description: "Sync local API profiles and settings to a remote SSH host (remote_sync also returns the refreshed status)",
 	}, syncRemoteHandler)
 
+	addTool(server, &mcpsdk.Tool{
+		Name:        "config_get",
+		Annotations: readOnly(),
+		Description: "Get the codes configuration: API profiles (models and env var names, never their values), the default profile, projects, remotes, default behavior, and which agent CLI adapters are available. Use it to see which projects and models exist instead of guessing.",
+	}, configGetHandler)
+
+	// Remote host tools
+	registerRemoteTools(server)
+
 	// Agent team tools
 	registerAgentTools(server)
 
 	// Dispatch tool
 	registerDispatchTool(server)
 
+	// Git operations in a task's working directory
+	registerTaskGitTool(server)
+
 	// Workflow tools
 	registerWorkflowTools(server)
 
 	// Stats tools
 	registerStatsTools(server)
 
+	// Scheduled reminders and agent work
+	registerScheduleTools(server)
+
+	// Recent tool calls, for debugging orchestration
+	registerHistoryTool(server)
+
+	// Team resources (tasks, messages, dashboards), kept in sync with disk
+	resources := registerTeamResources(server)
+	go resources.watch(context.Background(), server)
+
 	return server
 }
 
@@ -2,24 +2,34 @@ package mcpserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"codes/internal/agent"
 	"codes/internal/config"
 	"codes/internal/remote"
 )
 
 // list_projects
 
-type listProjectsInput struct{}
+type listProjectsInput struct {
+	IncludeArchived bool `json:"includeArchived,omitempty" jsonschema:"Also list archived projects"`
+}
 
 type listProjectsOutput struct {
 	Projects []config.ProjectInfo `json:"projects"`
 }
 
 func listProjectsHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input listProjectsInput) (*mcpsdk.CallToolResult, listProjectsOutput, error) {
-	projects, err := config.ListProjects()
+	listFn := config.ListActiveProjects
+	if input.IncludeArchived {
+		listFn = config.ListProjects
+	}
+	projects, err := listFn()
 	if err != nil {
 		return nil, listProjectsOutput{}, fmt.Errorf("failed to list projects: %w", err)
 	}
@@ -121,24 +131,9 @@ type switchProfileOutput struct {
 }
 
 func switchProfileHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input switchProfileInput) (*mcpsdk.CallToolResult, switchProfileOutput, error) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return nil, switchProfileOutput{}, fmt.Errorf("failed to load config: %w", err)
-	}
-
-	found := false
-	for _, c := range cfg.Profiles {
-		if c.Name == input.Name {
-			found = true
-			break
-		}
-	}
-	if !found {
+	if err := config.SetDefaultProfile(input.Name); errors.Is(err, config.ErrProfileNotFound) {
 		return nil, switchProfileOutput{}, fmt.Errorf("profile %q not found", input.Name)
-	}
-
-	cfg.Default = input.Name
-	if err := config.SaveConfig(cfg); err != nil {
+	} else if err != nil {
 		return nil, switchProfileOutput{}, fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -249,3 +244,119 @@ func syncRemoteHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input s
 	}
 	return nil, syncRemoteOutput{Synced: true}, nil
 }
+
+// config_get
+
+type configGetInput struct{}
+
+type configProfile struct {
+	Name            string            `json:"name"`
+	Status          string            `json:"status,omitempty"`
+	SkipPermissions bool              `json:"skipPermissions"`
+	BaseURL         string            `json:"baseUrl,omitempty"`
+	Models          map[string]string `json:"models,omitempty"` // *_MODEL env vars
+	EnvKeys         []string          `json:"envKeys"`          // names only: values may be secrets
+}
+
+type configProject struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Remote   string `json:"remote,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+}
+
+type configAdapter struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Default   bool   `json:"default,omitempty"`
+}
+
+type configGetOutput struct {
+	DefaultProfile  string             `json:"defaultProfile"`
+	Profiles        []configProfile    `json:"profiles"`
+	Projects        []configProject    `json:"projects"`
+	Remotes         []string           `json:"remotes"`
+	DefaultBehavior string             `json:"defaultBehavior"`
+	SkipPermissions bool               `json:"skipPermissions"`
+	ProjectsDir     string             `json:"projectsDir"`
+	SummaryModel    string             `json:"summaryModel"` // "" when summaries are off
+	PreventSleep    bool               `json:"preventSleep"`
+	PowerPolicy     config.PowerPolicy `json:"powerPolicy"`
+	Adapters        []configAdapter    `json:"adapters"`
+}
+
+func configGetHandler(ctx context.Context, req *mcpsdk.CallToolRequest, input configGetInput) (*mcpsdk.CallToolResult, configGetOutput, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, configGetOutput{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	out := configGetOutput{
+		DefaultProfile:  cfg.Default,
+		Profiles:        make([]configProfile, 0, len(cfg.Profiles)),
+		Projects:        make([]configProject, 0, len(cfg.Projects)),
+		Remotes:         make([]string, 0, len(cfg.Remotes)),
+		DefaultBehavior: config.GetDefaultBehavior(),
+		SkipPermissions: cfg.SkipPermissions,
+		ProjectsDir:     config.GetProjectsDir(),
+		SummaryModel:    config.GetSummaryModel(),
+		PreventSleep:    cfg.PreventSleep,
+		PowerPolicy:     config.GetPowerPolicy(),
+		Adapters:        []configAdapter{},
+	}
+
+	for _, p := range cfg.Profiles {
+		info := configProfile{
+			Name:            p.Name,
+			Status:          p.Status,
+			SkipPermissions: cfg.SkipPermissions,
+			BaseURL:         p.Env["ANTHROPIC_BASE_URL"],
+			EnvKeys:         make([]string, 0, len(p.Env)),
+		}
+		if p.SkipPermissions != nil {
+			info.SkipPermissions = *p.SkipPermissions
+		}
+		for key, value := range p.Env {
+			info.EnvKeys = append(info.EnvKeys, key)
+			if strings.HasSuffix(key, "_MODEL") {
+				if info.Models == nil {
+					info.Models = make(map[string]string)
+				}
+				info.Models[key] = value
+			}
+		}
+		sort.Strings(info.EnvKeys)
+		out.Profiles = append(out.Profiles, info)
+	}
+
+	for name, entry := range cfg.Projects {
+		out.Projects = append(out.Projects, configProject{
+			Name:     name,
+			Path:     entry.Path,
+			Remote:   entry.Remote,
+			Archived: entry.Archived != nil,
+		})
+	}
+	sort.Slice(out.Projects, func(i, j int) bool { return out.Projects[i].Name < out.Projects[j].Name })
+
+	for _, r := range cfg.Remotes {
+		out.Remotes = append(out.Remotes, r.Name)
+	}
+
+	var defaultAdapter string
+	if a := agent.DefaultAdapter(); a != nil {
+		defaultAdapter = a.Name()
+	}
+	names := agent.ListAdapters()
+	sort.Strings(names)
+	for _, name := range names {
+		_, err := agent.GetAdapter(name)
+		out.Adapters = append(out.Adapters, configAdapter{
+			Name:      name,
+			Available: err == nil,
+			Default:   name == defaultAdapter,
+		})
+	}
+
+	return nil, out, nil
+}
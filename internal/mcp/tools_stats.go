@@ -12,23 +12,27 @@ import (
 
 // registerStatsTools registers stats-related MCP tools.
 func registerStatsTools(server *mcpsdk.Server) {
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "stats_summary",
+		Annotations: readOnly(),
 		Description: "Get Claude usage cost summary for a time period (today, week, month, all)",
 	}, statsSummaryHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "stats_by_project",
+		Annotations: readOnly(),
 		Description: "Get cost breakdown by project. Optionally filter to a specific project.",
 	}, statsByProjectHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "stats_by_model",
+		Annotations: readOnly(),
 		Description: "Get cost breakdown by Claude model",
 	}, statsByModelHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "stats_refresh",
+		Annotations: additive(true),
 		Description: "Force a full rescan of Claude session files and rebuild the stats cache",
 	}, statsRefreshHandler)
 }
@@ -0,0 +1,74 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestConfigGetHidesSecrets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { config.ConfigPath = origPath }()
+
+	cfg := &config.Config{
+		Default: "work",
+		Profiles: []config.APIConfig{{
+			Name: "work",
+			Env: map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://api.example.com",
+				"ANTHROPIC_AUTH_TOKEN": "sk-secret-token",
+				"ANTHROPIC_MODEL":      "claude-sonnet-4-5",
+			},
+		}},
+		Projects: map[string]config.ProjectEntry{
+			"web": {Path: "/src/web"},
+			"api": {Path: "/src/api", Remote: "build"},
+		},
+		Remotes: []config.RemoteHost{{Name: "build", Host: "build.example.com"}},
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	_, out, err := configGetHandler(context.Background(), nil, configGetInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(out)
+	if strings.Contains(string(data), "sk-secret-token") {
+		t.Fatalf("config_get leaked a secret: %s", data)
+	}
+
+	if out.DefaultProfile != "work" || len(out.Profiles) != 1 {
+		t.Fatalf("profiles = %+v, default %q", out.Profiles, out.DefaultProfile)
+	}
+	p := out.Profiles[0]
+	if p.BaseURL != "https://api.example.com" || p.Models["ANTHROPIC_MODEL"] != "claude-sonnet-4-5" {
+		t.Errorf("profile = %+v", p)
+	}
+	if strings.Join(p.EnvKeys, ",") != "ANTHROPIC_AUTH_TOKEN,ANTHROPIC_BASE_URL,ANTHROPIC_MODEL" {
+		t.Errorf("envKeys = %v", p.EnvKeys)
+	}
+	if len(out.Projects) != 2 || out.Projects[0].Name != "api" || out.Projects[0].Remote != "build" {
+		t.Errorf("projects = %+v", out.Projects)
+	}
+	if len(out.Remotes) != 1 || out.DefaultBehavior != "current" {
+		t.Errorf("remotes = %v, defaultBehavior = %q", out.Remotes, out.DefaultBehavior)
+	}
+
+	found := false
+	for _, a := range out.Adapters {
+		if a.Name == "mock" && a.Available {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("adapters = %+v, want the mock adapter available", out.Adapters)
+	}
+}
@@ -166,23 +166,27 @@ func workflowCreateHandler(ctx context.Context, req *mcpsdk.CallToolRequest, inp
 
 // registerWorkflowTools registers workflow-related MCP tools.
 func registerWorkflowTools(server *mcpsdk.Server) {
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "workflow_list",
+		Annotations: readOnly(),
 		Description: "List all available workflow templates (built-in and custom)",
 	}, workflowListHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "workflow_get",
+		Annotations: readOnly(),
 		Description: "Get details of a specific workflow including all steps",
 	}, workflowGetHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "workflow_run",
+		Annotations: additive(false),
 		Description: "Execute a workflow by name, running all steps sequentially",
 	}, workflowRunHandler)
 
-	mcpsdk.AddTool(server, &mcpsdk.Tool{
+	addTool(server, &mcpsdk.Tool{
 		Name:        "workflow_create",
+		Annotations: additive(true),
 		Description: "Create a new workflow template with agents and tasks. Validates that task assignments reference defined agents and blockedBy indices are valid.",
 	}, workflowCreateHandler)
 }
@@ -7,10 +7,15 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"codes/internal/config"
 )
 
-// HookPayload is the JSON structure passed to hook scripts via stdin.
+// HookPayload is the JSON structure passed via stdin to the hook scripts for
+// finished tasks: on_task_completed, on_task_failed and on_task_cancelled
+// (hook.v1.json).
 type HookPayload struct {
+	Event     string `json:"event,omitempty"`
 	Team      string `json:"team"`
 	TaskID    int    `json:"taskId"`
 	Subject   string `json:"subject"`
@@ -19,6 +24,92 @@ type HookPayload struct {
 	Result    string `json:"result,omitempty"`
 	Error     string `json:"error,omitempty"`
 	Timestamp string `json:"timestamp"`
+
+	// Set when known: tasks cancelled before they ran have no duration,
+	// and only runs in a git repository report files and branch.
+	DurationSeconds float64 `json:"durationSeconds,omitempty"` // from start to finish
+	CostUSD         float64 `json:"costUsd,omitempty"`
+	FilesChanged    *int    `json:"filesChanged,omitempty"` // committed, modified or added since the start
+	Branch          string  `json:"branch,omitempty"`
+}
+
+// HookEvent is passed via stdin to the hook scripts for the other lifecycle
+// events (hook-event.v1.json). Event names the event; the field for what it
+// is about is set and the others are omitted.
+type HookEvent struct {
+	Event     string       `json:"event"`
+	Timestamp string       `json:"timestamp"`
+	Task      *HookTask    `json:"task,omitempty"`    // on_task_started
+	Agent     *HookAgent   `json:"agent,omitempty"`   // on_agent_started, on_agent_stopped
+	Team      *HookTeam    `json:"team,omitempty"`    // on_team_created, on_team_idle
+	Session   *HookSession `json:"session,omitempty"` // on_session_started
+	Update    *HookUpdate  `json:"update,omitempty"`  // on_update_available
+}
+
+// HookTask is a task an agent has started.
+type HookTask struct {
+	Team     string `json:"team"`
+	ID       int    `json:"id"`
+	Subject  string `json:"subject"`
+	Agent    string `json:"agent"`
+	Priority string `json:"priority,omitempty"`
+	Project  string `json:"project,omitempty"`
+	WorkDir  string `json:"workDir,omitempty"` // set when the task was given one
+}
+
+// HookAgent is an agent daemon that started or stopped.
+type HookAgent struct {
+	Team   string `json:"team"`
+	Name   string `json:"name"`
+	Role   string `json:"role,omitempty"`
+	Model  string `json:"model,omitempty"`
+	PID    int    `json:"pid"`
+	Reason string `json:"reason,omitempty"` // on_agent_stopped: stop_requested, signal or idle
+}
+
+// HookTeam is a newly created team, or one its idle policy stopped.
+type HookTeam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	WorkDir     string `json:"workDir,omitempty"`
+
+	// on_team_idle
+	IdleMinutes   int      `json:"idleMinutes,omitempty"`   // how long it had no pending or running task
+	StoppedAgents []string `json:"stoppedAgents,omitempty"` // agents told to stop
+	ArchivedTasks int      `json:"archivedTasks,omitempty"` // finished tasks archived
+}
+
+// HookSession is a Claude session that was opened.
+type HookSession struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"` // chat (stream-json, over the API) or terminal
+	Project string `json:"project,omitempty"`
+	Path    string `json:"path"`             // project directory, or user@host for remote projects
+	Remote  string `json:"remote,omitempty"` // remote host of the project
+}
+
+// HookUpdate is a codes release newer than the running one.
+type HookUpdate struct {
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	URL     string `json:"url,omitempty"` // release page
+}
+
+// RunHook runs the script configured for event, if there is one, with
+// payload on stdin.
+func RunHook(event string, payload any) error {
+	scriptPath := config.GetHook(event)
+	if scriptPath == "" {
+		return nil
+	}
+	return NewHookRunner(scriptPath).Execute(payload)
+}
+
+// RunEventHook runs the hook for e.Event, if there is one, stamping e with
+// the current time.
+func RunEventHook(e HookEvent) error {
+	e.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	return RunHook(e.Event, e)
 }
 
 // HookRunner executes a shell hook script with a JSON payload on stdin.
@@ -32,8 +123,8 @@ func NewHookRunner(scriptPath string) *HookRunner {
 }
 
 // Execute runs the hook script with a 30-second timeout.
-// The JSON-encoded payload is passed via stdin.
-func (h *HookRunner) Execute(payload HookPayload) error {
+// The JSON-encoded payload (a HookPayload or HookEvent) is passed via stdin.
+func (h *HookRunner) Execute(payload any) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+
+	"codes/internal/config"
+	"codes/pkg/schemas"
 )
 
 func TestHookRunner_Execute(t *testing.T) {
@@ -43,6 +46,9 @@ func TestHookRunner_Execute(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to read output file: %v", err)
 	}
+	if err := schemas.Validate(schemas.Hook, data); err != nil {
+		t.Errorf("payload does not match schema: %v", err)
+	}
 
 	var received HookPayload
 	if err := json.Unmarshal(data, &received); err != nil {
@@ -69,6 +75,57 @@ func TestHookRunner_Execute(t *testing.T) {
 	}
 }
 
+func TestRunEventHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	origPath := config.ConfigPath
+	config.ConfigPath = filepath.Join(tmpDir, "config.json")
+	defer func() { config.ConfigPath = origPath }()
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// No hook configured: nothing runs
+	if err := RunEventHook(HookEvent{Event: "on_team_created", Team: &HookTeam{Name: "t"}}); err != nil {
+		t.Fatalf("RunEventHook() without a hook: %v", err)
+	}
+
+	outputFile := filepath.Join(tmpDir, "output.json")
+	scriptPath := filepath.Join(tmpDir, "hook.sh")
+	if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\ncat > "+outputFile+"\n"), 0755); err != nil {
+		t.Fatalf("failed to create script: %v", err)
+	}
+	if err := config.SetHook("on_agent_stopped", scriptPath); err != nil {
+		t.Fatalf("SetHook() error: %v", err)
+	}
+
+	err := RunEventHook(HookEvent{
+		Event: "on_agent_stopped",
+		Agent: &HookAgent{Team: "test-team", Name: "worker-1", PID: 42, Reason: "signal"},
+	})
+	if err != nil {
+		t.Fatalf("RunEventHook() error: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if err := schemas.Validate(schemas.HookEvent, data); err != nil {
+		t.Errorf("payload does not match schema: %v", err)
+	}
+	var received HookEvent
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatalf("failed to unmarshal output: %v", err)
+	}
+	if received.Timestamp == "" || received.Agent == nil || received.Agent.Reason != "signal" {
+		t.Errorf("received %+v", received)
+	}
+}
+
 func TestHookRunner_Timeout(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("shell scripts not supported on Windows")
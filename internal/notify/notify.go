@@ -7,6 +7,7 @@ type Notification struct {
 	Title   string
 	Message string
 	Sound   bool
+	Data    map[string]any // extra values for custom webhook templates
 }
 
 // Notifier sends notifications.
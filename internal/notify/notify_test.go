@@ -2,9 +2,12 @@ package notify
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"codes/pkg/schemas"
 )
 
 func TestMultiNotifier_Send(t *testing.T) {
@@ -181,6 +184,36 @@ func TestWebhookNotifier_Custom(t *testing.T) {
 	}
 }
 
+func TestWebhookNotifier_CustomData(t *testing.T) {
+	var received map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	extra := map[string]string{
+		"template": `{"task": {{.TaskID}}, "files": {{.FilesChanged}}, "branch": "{{.Branch}}", "title": "{{.Title}}"}`,
+	}
+	wh := NewWebhookNotifier(srv.URL, "custom", extra)
+	err := wh.Send(Notification{
+		Title:   "done",
+		Message: "m",
+		Data:    map[string]any{"TaskID": 7, "FilesChanged": 3, "Branch": "main", "Title": "ignored"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received["task"] != float64(7) || received["files"] != float64(3) || received["branch"] != "main" {
+		t.Fatalf("unexpected payload: %v", received)
+	}
+	if received["title"] != "done" {
+		t.Fatalf("Data overrode the title: %v", received["title"])
+	}
+}
+
 func TestWebhookNotifier_Custom_MissingTemplate(t *testing.T) {
 	wh := NewWebhookNotifier("http://localhost", "custom", nil)
 	err := wh.Send(Notification{Title: "test", Message: "msg"})
@@ -189,6 +222,25 @@ func TestWebhookNotifier_Custom_MissingTemplate(t *testing.T) {
 	}
 }
 
+func TestWebhookNotifier_MatchesSchema(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	for _, format := range []string{"slack", "feishu", "dingtalk", "telegram"} {
+		notifier := NewWebhookNotifier(server.URL, format, map[string]string{"chat_id": "123"})
+		if err := notifier.Send(Notification{Title: "codes: Task completed", Message: "[team] #1 Build"}); err != nil {
+			t.Fatalf("%s: Send() error: %v", format, err)
+		}
+		if err := schemas.Validate(schemas.Webhook, body); err != nil {
+			t.Errorf("%s payload %s: %v", format, body, err)
+		}
+	}
+}
+
 func TestNewDesktopNotifier(t *testing.T) {
 	n := NewDesktopNotifier()
 	if n == nil {
@@ -63,11 +63,16 @@ func (w *WebhookNotifier) Send(n Notification) error {
 		if err != nil {
 			return fmt.Errorf("webhook custom template parse: %w", err)
 		}
-		data := map[string]string{
+		data := map[string]any{
 			"Title":   n.Title,
 			"Message": n.Message,
 			"Text":    text,
 		}
+		for k, v := range n.Data {
+			if _, ok := data[k]; !ok {
+				data[k] = v
+			}
+		}
 		var buf bytes.Buffer
 		if err := tmpl.Execute(&buf, data); err != nil {
 			return fmt.Errorf("webhook custom template execute: %w", err)
@@ -0,0 +1,212 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"codes/internal/config"
+)
+
+// Profile drift kinds reported by Diff and PlanSync.
+const (
+	DriftMissing = "missing" // profile exists locally but not on the remote (sync adds it)
+	DriftChanged = "changed" // profile exists on both sides with different settings (sync updates it)
+	DriftExtra   = "extra"   // profile exists only on the remote (sync removes it)
+)
+
+// ProfileDrift describes how a single profile differs between local and remote.
+type ProfileDrift struct {
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`
+	Fields []string `json:"fields,omitempty"` // changed fields (env keys are listed by name only)
+}
+
+// Drift is the result of comparing the local config against a remote host.
+type Drift struct {
+	Remote                string         `json:"remote"`
+	RemoteConfigMissing   bool           `json:"remoteConfigMissing,omitempty"`
+	Profiles              []ProfileDrift `json:"profiles,omitempty"`
+	LocalDefault          string         `json:"localDefault"`
+	RemoteDefault         string         `json:"remoteDefault"`
+	LocalSkipPermissions  bool           `json:"localSkipPermissions"`
+	RemoteSkipPermissions bool           `json:"remoteSkipPermissions"`
+	// Agent limits: the host's entry in the local config, which sync pushes,
+	// against the remote config's. Unset limits compare as zero.
+	LocalAgentLimits  config.AgentLimits `json:"localAgentLimits"`
+	RemoteAgentLimits config.AgentLimits `json:"remoteAgentLimits"`
+
+	LocalVersion  string `json:"localVersion,omitempty"`
+	RemoteVersion string `json:"remoteVersion,omitempty"`
+	VersionStale  bool   `json:"versionStale,omitempty"`
+}
+
+// DefaultChanged reports whether the default profile differs.
+func (d *Drift) DefaultChanged() bool {
+	return d.LocalDefault != d.RemoteDefault
+}
+
+// SkipPermissionsChanged reports whether the global skipPermissions flag differs.
+func (d *Drift) SkipPermissionsChanged() bool {
+	return d.LocalSkipPermissions != d.RemoteSkipPermissions
+}
+
+// AgentLimitsChanged reports whether sync would change the remote's agent
+// limits.
+func (d *Drift) AgentLimitsChanged() bool {
+	return d.LocalAgentLimits != d.RemoteAgentLimits
+}
+
+// InSync reports whether a profile sync would be a no-op and versions match.
+func (d *Drift) InSync() bool {
+	return !d.RemoteConfigMissing && len(d.Profiles) == 0 &&
+		!d.DefaultChanged() && !d.SkipPermissionsChanged() && !d.AgentLimitsChanged() && !d.VersionStale
+}
+
+// FetchRemoteConfig reads ~/.codes/config.json from the remote host.
+// Returns (nil, nil) if the remote has no config file yet.
+func FetchRemoteConfig(host *config.RemoteHost) (*config.Config, error) {
+	out, err := RunSSH(host, "cat ~/.codes/config.json 2>/dev/null || true")
+	if err != nil {
+		return nil, fmt.Errorf("read remote config: %w", err)
+	}
+	if out == "" {
+		return nil, nil
+	}
+
+	var cfg config.Config
+	if err := json.Unmarshal([]byte(out), &cfg); err != nil {
+		return nil, fmt.Errorf("parse remote config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// PlanSync computes what SyncProfiles would change on the remote host without writing anything.
+func PlanSync(host *config.RemoteHost) (*Drift, error) {
+	local, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load local config: %w", err)
+	}
+
+	remoteCfg, err := FetchRemoteConfig(host)
+	if err != nil {
+		return nil, err
+	}
+
+	d := compareConfigs(local, remoteCfg, host.AgentLimits)
+	d.Remote = host.Name
+	return d, nil
+}
+
+// Diff compares local profiles/config and the local codes version against the remote host.
+// localVersion is the version of the running binary; "dev" or empty skips the version check.
+func Diff(host *config.RemoteHost, localVersion string) (*Drift, error) {
+	d, err := PlanSync(host)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := CheckRemoteStatus(host)
+	if err != nil {
+		return nil, err
+	}
+
+	d.LocalVersion = localVersion
+	if status.CodesInstalled {
+		d.RemoteVersion = parseVersionOutput(status.CodesVersion)
+	}
+	if localVersion != "" && localVersion != "dev" {
+		d.VersionStale = d.RemoteVersion != localVersion
+	}
+	return d, nil
+}
+
+// compareConfigs diffs the sync-relevant fields of two configs, and limits,
+// the agent limits sync pushes to the host, against the remote's.
+// A nil remote is treated as an empty config with RemoteConfigMissing set.
+func compareConfigs(local, remoteCfg *config.Config, limits *config.AgentLimits) *Drift {
+	d := &Drift{
+		LocalDefault:         local.Default,
+		LocalSkipPermissions: local.SkipPermissions,
+	}
+	if remoteCfg == nil {
+		d.RemoteConfigMissing = true
+		remoteCfg = &config.Config{}
+	}
+	d.RemoteDefault = remoteCfg.Default
+	d.RemoteSkipPermissions = remoteCfg.SkipPermissions
+	if limits != nil {
+		d.LocalAgentLimits = *limits
+	}
+	if remoteCfg.AgentLimits != nil {
+		d.RemoteAgentLimits = *remoteCfg.AgentLimits
+	}
+
+	remoteByName := make(map[string]config.APIConfig, len(remoteCfg.Profiles))
+	for _, p := range remoteCfg.Profiles {
+		remoteByName[p.Name] = p
+	}
+
+	seen := make(map[string]bool, len(local.Profiles))
+	for _, lp := range local.Profiles {
+		seen[lp.Name] = true
+		rp, ok := remoteByName[lp.Name]
+		if !ok {
+			d.Profiles = append(d.Profiles, ProfileDrift{Name: lp.Name, Kind: DriftMissing})
+			continue
+		}
+		if fields := profileFieldDiff(lp, rp); len(fields) > 0 {
+			d.Profiles = append(d.Profiles, ProfileDrift{Name: lp.Name, Kind: DriftChanged, Fields: fields})
+		}
+	}
+	for _, rp := range remoteCfg.Profiles {
+		if !seen[rp.Name] {
+			d.Profiles = append(d.Profiles, ProfileDrift{Name: rp.Name, Kind: DriftExtra})
+		}
+	}
+
+	return d
+}
+
+// profileFieldDiff returns the names of fields that differ between two profiles.
+// Env values are never included, only the keys, so secrets are not leaked into output.
+func profileFieldDiff(a, b config.APIConfig) []string {
+	var fields []string
+
+	keys := make(map[string]bool)
+	for k := range a.Env {
+		keys[k] = true
+	}
+	for k := range b.Env {
+		keys[k] = true
+	}
+	var envKeys []string
+	for k := range keys {
+		av, aok := a.Env[k]
+		bv, bok := b.Env[k]
+		if aok != bok || av != bv {
+			envKeys = append(envKeys, "env."+k)
+		}
+	}
+	sort.Strings(envKeys)
+	fields = append(fields, envKeys...)
+
+	if !reflect.DeepEqual(a.SkipPermissions, b.SkipPermissions) {
+		fields = append(fields, "skipPermissions")
+	}
+	return fields
+}
+
+// parseVersionOutput extracts the version from `codes version` output,
+// e.g. "codes version v1.2.3 (commit abc, built ...)" → "v1.2.3".
+func parseVersionOutput(out string) string {
+	fields := strings.Fields(out)
+	for i, f := range fields {
+		if f == "version" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return strings.TrimSpace(out)
+}
@@ -0,0 +1,106 @@
+package remote
+
+import (
+	"reflect"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestCompareConfigs(t *testing.T) {
+	yes := true
+	local := &config.Config{
+		Default: "work",
+		Profiles: []config.APIConfig{
+			{Name: "work", Env: map[string]string{"ANTHROPIC_AUTH_TOKEN": "new"}},
+			{Name: "personal", Env: map[string]string{"ANTHROPIC_BASE_URL": "https://a"}},
+			{Name: "same", Env: map[string]string{"X": "1"}},
+		},
+	}
+	remoteCfg := &config.Config{
+		Default: "personal",
+		Profiles: []config.APIConfig{
+			{Name: "work", Env: map[string]string{"ANTHROPIC_AUTH_TOKEN": "old"}, SkipPermissions: &yes},
+			{Name: "same", Env: map[string]string{"X": "1"}},
+			{Name: "legacy"},
+		},
+	}
+
+	d := compareConfigs(local, remoteCfg, nil)
+
+	want := []ProfileDrift{
+		{Name: "work", Kind: DriftChanged, Fields: []string{"env.ANTHROPIC_AUTH_TOKEN", "skipPermissions"}},
+		{Name: "personal", Kind: DriftMissing},
+		{Name: "legacy", Kind: DriftExtra},
+	}
+	if !reflect.DeepEqual(d.Profiles, want) {
+		t.Errorf("Profiles = %+v; want %+v", d.Profiles, want)
+	}
+	if !d.DefaultChanged() {
+		t.Error("expected default to be reported as changed")
+	}
+	if d.InSync() {
+		t.Error("expected drift, got InSync")
+	}
+}
+
+func TestCompareConfigsMissingRemote(t *testing.T) {
+	local := &config.Config{Profiles: []config.APIConfig{{Name: "a"}}}
+
+	d := compareConfigs(local, nil, nil)
+	if !d.RemoteConfigMissing {
+		t.Error("expected RemoteConfigMissing")
+	}
+	if len(d.Profiles) != 1 || d.Profiles[0].Kind != DriftMissing {
+		t.Errorf("Profiles = %+v; want one missing profile", d.Profiles)
+	}
+}
+
+func TestCompareConfigsInSync(t *testing.T) {
+	cfg := &config.Config{Default: "a", Profiles: []config.APIConfig{{Name: "a", Env: map[string]string{"K": "v"}}}}
+	if d := compareConfigs(cfg, cfg, nil); !d.InSync() {
+		t.Errorf("expected InSync, got %+v", d)
+	}
+}
+
+func TestCompareConfigsAgentLimits(t *testing.T) {
+	limits := &config.AgentLimits{MaxConcurrent: 4, Nice: 10}
+	synced := &config.Config{AgentLimits: &config.AgentLimits{MaxConcurrent: 4, Nice: 10}}
+
+	if d := compareConfigs(&config.Config{}, synced, limits); !d.InSync() {
+		t.Errorf("expected InSync, got %+v", d)
+	}
+	// Unset on both sides, or zero on one, is the same
+	if d := compareConfigs(&config.Config{}, &config.Config{AgentLimits: &config.AgentLimits{}}, nil); !d.InSync() {
+		t.Errorf("expected InSync for empty limits, got %+v", d)
+	}
+
+	d := compareConfigs(&config.Config{}, &config.Config{}, limits)
+	if !d.AgentLimitsChanged() || d.InSync() {
+		t.Errorf("expected agent limits drift, got %+v", d)
+	}
+	if d.LocalAgentLimits != *limits || d.RemoteAgentLimits != (config.AgentLimits{}) {
+		t.Errorf("limits = %+v → %+v", d.RemoteAgentLimits, d.LocalAgentLimits)
+	}
+
+	// Sync clears limits that were dropped locally
+	if d := compareConfigs(&config.Config{}, synced, nil); !d.AgentLimitsChanged() {
+		t.Error("expected drift when the remote has limits the host entry no longer sets")
+	}
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"codes version v1.2.3 (commit abc, built 2025-01-01)", "v1.2.3"},
+		{"v1.0.0", "v1.0.0"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseVersionOutput(tt.input); got != tt.expected {
+			t.Errorf("parseVersionOutput(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package remote
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"codes/internal/config"
+	"codes/internal/trace"
+)
+
+// HostKeyUnknownError is returned when a remote's host key is not present in
+// known_hosts. SSH runs with StrictHostKeyChecking=yes, so the connection is
+// refused until the user has checked Fingerprints and trusted Keys.
+type HostKeyUnknownError struct {
+	Name         string   // remote name from config
+	Address      string   // known_hosts name, e.g. "example.com" or "[example.com]:2222"
+	Keys         []string // known_hosts lines offered by the server
+	Fingerprints []string // ssh-keygen -l output for Keys
+}
+
+func (e *HostKeyUnknownError) Error() string {
+	return fmt.Sprintf("host key for %s is not in known_hosts; verify its fingerprint and trust it with `codes remote trust %s`", e.Address, e.Name)
+}
+
+// hostKeyTarget is where ssh will look up a host's key after applying
+// ~/.ssh/config (HostName, Port, UserKnownHostsFile).
+type hostKeyTarget struct {
+	hostname   string
+	port       int
+	knownHosts string
+}
+
+// address returns the host in known_hosts notation.
+func (t hostKeyTarget) address() string {
+	return knownHostsName(t.hostname, t.port)
+}
+
+// knownHostsName formats a host the way ssh records it in known_hosts.
+func knownHostsName(hostname string, port int) string {
+	if port == 0 || port == 22 {
+		return hostname
+	}
+	return fmt.Sprintf("[%s]:%d", hostname, port)
+}
+
+// resolveHostKeyTarget asks `ssh -G` for the effective hostname, port, and
+// known_hosts file so aliases from ~/.ssh/config are checked correctly.
+func resolveHostKeyTarget(host *config.RemoteHost) hostKeyTarget {
+	t := hostKeyTarget{hostname: host.Host, port: host.Port}
+	if t.port == 0 {
+		t.port = 22
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		t.knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	args := []string{"-G"}
+	if host.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(host.Port))
+	}
+	args = append(args, host.UserAtHost())
+	end := trace.Start("ssh.config", "host", host.UserAtHost())
+	out, err := exec.Command("ssh", args...).Output()
+	end()
+	if err != nil {
+		return t
+	}
+	return parseSSHConfigOutput(string(out), t)
+}
+
+// parseSSHConfigOutput overlays the relevant `ssh -G` settings onto t.
+func parseSSHConfigOutput(out string, t hostKeyTarget) hostKeyTarget {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "hostname":
+			t.hostname = value
+		case "port":
+			if p, err := strconv.Atoi(value); err == nil {
+				t.port = p
+			}
+		case "userknownhostsfile":
+			// ssh reads several files but writes to the first.
+			if fields := strings.Fields(value); len(fields) > 0 {
+				t.knownHosts = expandHome(fields[0])
+			}
+		}
+	}
+	return t
+}
+
+// IsHostKnown reports whether known_hosts already has a key for the remote.
+func IsHostKnown(host *config.RemoteHost) (bool, error) {
+	t := resolveHostKeyTarget(host)
+	if t.knownHosts == "" {
+		return false, nil
+	}
+	if _, err := os.Stat(t.knownHosts); os.IsNotExist(err) {
+		return false, nil
+	}
+	err := exec.Command("ssh-keygen", "-F", t.address(), "-f", t.knownHosts).Run()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return false, nil
+	}
+	return false, fmt.Errorf("ssh-keygen: %w", err)
+}
+
+// CheckHostKey returns nil if the remote's host key is already trusted, or a
+// *HostKeyUnknownError carrying the keys and fingerprints the server offers.
+func CheckHostKey(host *config.RemoteHost) error {
+	known, err := IsHostKnown(host)
+	if err != nil {
+		return err
+	}
+	if known {
+		return nil
+	}
+
+	t := resolveHostKeyTarget(host)
+	keys, err := scanHostKeys(t)
+	if err != nil {
+		return fmt.Errorf("%s is not in known_hosts and its host key could not be fetched: %w", t.address(), err)
+	}
+	fingerprints, err := fingerprintKeys(keys)
+	if err != nil {
+		return err
+	}
+	return &HostKeyUnknownError{
+		Name:         host.Name,
+		Address:      t.address(),
+		Keys:         keys,
+		Fingerprints: fingerprints,
+	}
+}
+
+// TrustHostKeys appends keys previously returned in a HostKeyUnknownError to
+// the user's known_hosts file. Callers must only do this after the user has
+// confirmed the fingerprints.
+func TrustHostKeys(host *config.RemoteHost, keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("no host keys to trust")
+	}
+	t := resolveHostKeyTarget(host)
+	if t.knownHosts == "" {
+		return fmt.Errorf("cannot locate known_hosts file")
+	}
+	if err := os.MkdirAll(filepath.Dir(t.knownHosts), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(t.knownHosts, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.Join(keys, "\n") + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// scanHostKeys fetches the host keys a server offers, in known_hosts format.
+func scanHostKeys(t hostKeyTarget) ([]string, error) {
+	out, err := exec.Command("ssh-keyscan", "-T", "5", "-p", strconv.Itoa(t.port), t.hostname).Output()
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("ssh-keyscan %s: %w", t.address(), err)
+	}
+	var keys []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("ssh-keyscan %s: no host keys returned", t.address())
+	}
+	return keys, nil
+}
+
+// fingerprintKeys returns the SHA256 fingerprint line for each known_hosts key.
+func fingerprintKeys(keys []string) ([]string, error) {
+	cmd := exec.Command("ssh-keygen", "-l", "-f", "-")
+	cmd.Stdin = strings.NewReader(strings.Join(keys, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh-keygen: %w", err)
+	}
+	var fps []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fps = append(fps, line)
+		}
+	}
+	return fps, nil
+}
+
+// sshError wraps a failed ssh/scp invocation. Exit status 255 is ssh's own
+// failure code; if the host key is unknown at that point, the more useful
+// HostKeyUnknownError is returned instead so the caller can prompt.
+func sshError(host *config.RemoteHost, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 255 {
+		var unknown *HostKeyUnknownError
+		if hkErr := CheckHostKey(host); errors.As(hkErr, &unknown) {
+			return unknown
+		}
+	}
+	return fmt.Errorf("ssh %s: %w", host.UserAtHost(), err)
+}
+
+// IsSecurityKeyIdentity reports whether an identity file is a FIDO2/U2F
+// security key (sk-ssh-ed25519 or sk-ecdsa), which needs a touch to sign.
+func IsSecurityKeyIdentity(identity string) bool {
+	if identity == "" {
+		return false
+	}
+	path := expandHome(identity)
+	if !strings.HasSuffix(path, ".pub") {
+		path += ".pub"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return isSecurityKeyType(strings.TrimSpace(string(data)))
+}
+
+// isSecurityKeyType reports whether a public key line uses an sk-* key type.
+func isSecurityKeyType(pubKey string) bool {
+	return strings.HasPrefix(pubKey, "sk-ssh-ed25519@openssh.com ") ||
+		strings.HasPrefix(pubKey, "sk-ecdsa-sha2-nistp256@openssh.com ")
+}
@@ -0,0 +1,76 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestKnownHostsName(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+	}{
+		{"example.com", 0, "example.com"},
+		{"example.com", 22, "example.com"},
+		{"example.com", 2222, "[example.com]:2222"},
+	}
+	for _, tt := range tests {
+		if got := knownHostsName(tt.host, tt.port); got != tt.want {
+			t.Errorf("knownHostsName(%q, %d) = %q, want %q", tt.host, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestParseSSHConfigOutput(t *testing.T) {
+	out := "user dev\nhostname 10.0.0.5\nport 2200\nuserknownhostsfile /tmp/kh /tmp/kh2\nstricthostkeychecking true\n"
+	got := parseSSHConfigOutput(out, hostKeyTarget{hostname: "box", port: 22, knownHosts: "/default"})
+	if got.hostname != "10.0.0.5" || got.port != 2200 || got.knownHosts != "/tmp/kh" {
+		t.Errorf("unexpected target: %+v", got)
+	}
+	if got.address() != "[10.0.0.5]:2200" {
+		t.Errorf("address = %q", got.address())
+	}
+}
+
+func TestSSHOptionsStrictHostKeyChecking(t *testing.T) {
+	host := &config.RemoteHost{Name: "x", Host: "h", Port: 2222, Identity: "/k/id_ed25519_sk", SecurityKeyProvider: "internal"}
+	args := strings.Join(sshArgs(host), " ")
+	for _, want := range []string{"StrictHostKeyChecking=yes", "-p 2222", "-i /k/id_ed25519_sk", "SecurityKeyProvider=internal"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("sshArgs missing %q: %s", want, args)
+		}
+	}
+	if strings.Contains(args, "accept-new") || strings.Contains(args, "StrictHostKeyChecking=no") {
+		t.Errorf("sshArgs must not relax host key checking: %s", args)
+	}
+}
+
+func TestIsSecurityKeyIdentity(t *testing.T) {
+	dir := t.TempDir()
+	sk := filepath.Join(dir, "id_ed25519_sk")
+	plain := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(sk+".pub", []byte("sk-ssh-ed25519@openssh.com AAAAGnNr user@host\n"), 0600)
+	os.WriteFile(plain+".pub", []byte("ssh-ed25519 AAAAC3Nz user@host\n"), 0600)
+
+	if !IsSecurityKeyIdentity(sk) {
+		t.Error("expected sk identity to be detected")
+	}
+	if IsSecurityKeyIdentity(plain) {
+		t.Error("plain ed25519 identity reported as security key")
+	}
+	if IsSecurityKeyIdentity(filepath.Join(dir, "missing")) {
+		t.Error("missing identity reported as security key")
+	}
+}
+
+func TestHostKeyUnknownErrorMessage(t *testing.T) {
+	err := &HostKeyUnknownError{Name: "dev", Address: "[box]:2222"}
+	if !strings.Contains(err.Error(), "codes remote trust dev") {
+		t.Errorf("error should point at the trust command: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package remote
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 
 	"codes/internal/config"
 )
@@ -61,11 +63,49 @@ echo "OS=$(uname -s)"; echo "ARCH=$(uname -m)"; echo "CODES=$(command -v codes >
 	return status, nil
 }
 
+// InstallOptions tunes how InstallOnRemoteWithOptions downloads the release.
+type InstallOptions struct {
+	// RateLimit caps download bandwidth using curl's --limit-rate syntax
+	// (e.g. "500k", "2M"). Empty means unlimited.
+	RateLimit string
+	// Progress, if set, is called with a short description each time the
+	// install moves to a new stage (resolving, downloading, verifying, ...).
+	Progress func(stage string)
+}
+
+// rateLimitPattern validates InstallOptions.RateLimit before it is embedded in a shell script.
+// Compiled on first use to keep it out of every command's startup.
+var rateLimitPattern = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+})
+
+// stagePrefix marks install script output lines that report progress.
+const stagePrefix = "STAGE:"
+
 // InstallOnRemote installs the codes binary on the remote host.
 // Uses a non-interactive script (no sudo, no init) to avoid hanging over SSH.
 // Returns the install script output along with any error.
 func InstallOnRemote(host *config.RemoteHost) (string, error) {
+	return InstallOnRemoteWithOptions(host, InstallOptions{})
+}
+
+// InstallOnRemoteWithOptions installs the codes binary on the remote host.
+//
+// The release archive is verified against the published checksums file. If the
+// checksum matches the one recorded at the last install and the binary is still
+// present, the download is skipped entirely. Downloads go to ~/.codes/downloads
+// and are resumed (curl -C -) after a dropped connection instead of restarting.
+func InstallOnRemoteWithOptions(host *config.RemoteHost, opts InstallOptions) (string, error) {
+	if opts.RateLimit != "" && !rateLimitPattern().MatchString(opts.RateLimit) {
+		return "", fmt.Errorf("invalid rate limit %q (expected e.g. 500k or 2M)", opts.RateLimit)
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+
 	// First detect platform via CheckRemoteStatus
+	progress("detecting platform")
 	status, err := CheckRemoteStatus(host)
 	if err != nil {
 		return "", fmt.Errorf("detect platform: %w", err)
@@ -77,13 +117,52 @@ func InstallOnRemote(host *config.RemoteHost) (string, error) {
 		return "", fmt.Errorf("unsupported platform: %s/%s", status.OS, status.Arch)
 	}
 
-	// Non-interactive install: resolve latest version, download tar.gz, extract to ~/bin
-	installScript := fmt.Sprintf(`
+	out, err := RunSSHStream(host, installScript(goOS, goArch, opts.RateLimit), func(line string) {
+		if strings.HasPrefix(line, stagePrefix) {
+			progress(strings.TrimPrefix(line, stagePrefix))
+		}
+	})
+	out = stripStageLines(out)
+	if err != nil {
+		return out, fmt.Errorf("remote install failed: %w", err)
+	}
+
+	return out, nil
+}
+
+// stripStageLines removes progress marker lines from install script output.
+func stripStageLines(out string) string {
+	lines := strings.Split(out, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, stagePrefix) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// installScript builds the non-interactive install script: resolve latest
+// version, fetch checksums, skip if current, resume-download, verify, extract to ~/bin.
+func installScript(goOS, goArch, rateLimit string) string {
+	curlOpts := "--retry 5 --retry-delay 2 --connect-timeout 15"
+	if rateLimit != "" {
+		curlOpts += " --limit-rate " + rateLimit
+	}
+
+	return fmt.Sprintf(`
 set -e
-mkdir -p ~/bin
+mkdir -p ~/bin ~/.codes/downloads
+CURL_OPTS="%[3]s"
+
+sha256() {
+    if command -v sha256sum >/dev/null 2>&1; then sha256sum "$1" | cut -d' ' -f1
+    else shasum -a 256 "$1" | cut -d' ' -f1; fi
+}
 
 # Resolve latest version
-VERSION=$(curl -fsSL "https://api.github.com/repos/ourines/codes/releases/latest" \
+echo "%[4]sresolving latest version"
+VERSION=$(curl -fsSL $CURL_OPTS "https://api.github.com/repos/ourines/codes/releases/latest" \
     | grep '"tag_name"' | sed -E 's/.*"tag_name": *"([^"]+)".*/\1/')
 if [ -z "$VERSION" ]; then
     echo "Failed to determine latest version" >&2
@@ -91,16 +170,51 @@ if [ -z "$VERSION" ]; then
 fi
 echo "Latest version: $VERSION"
 
-ARCHIVE="codes-${VERSION}-%s-%s.tar.gz"
-URL="https://github.com/ourines/codes/releases/download/${VERSION}/${ARCHIVE}"
+ARCHIVE="codes-${VERSION}-%[1]s-%[2]s.tar.gz"
+BASE="https://github.com/ourines/codes/releases/download/${VERSION}"
+DL="$HOME/.codes/downloads"
+STAMP="$HOME/.codes/installed.sha256"
+
+echo "%[4]sfetching checksums"
+EXPECTED=$(curl -fsSL $CURL_OPTS "$BASE/codes-${VERSION}-checksums.txt" 2>/dev/null | grep " ${ARCHIVE}\$" | cut -d' ' -f1 || true)
+
+# Skip if the installed binary came from the same archive
+if [ -n "$EXPECTED" ] && [ -x ~/bin/codes ] && [ -f "$STAMP" ] && [ "$(cat "$STAMP")" = "$EXPECTED" ]; then
+    echo "%[4]salready up to date ($VERSION)"
+    ~/bin/codes version
+    exit 0
+fi
+
+# Resumable download: keep the partial file across attempts. -s: no progress
+# meter, the stage lines above report progress instead
+PART="$DL/$ARCHIVE.part"
+if [ -s "$PART" ]; then
+    echo "%[4]sresuming download of $VERSION ($(wc -c < "$PART" | tr -d ' ') bytes done)"
+else
+    echo "%[4]sdownloading $VERSION"
+fi
+curl -fsSL $CURL_OPTS -C - "$BASE/$ARCHIVE" -o "$PART"
+
+if [ -n "$EXPECTED" ]; then
+    echo "%[4]sverifying checksum"
+    ACTUAL=$(sha256 "$PART")
+    if [ "$ACTUAL" != "$EXPECTED" ]; then
+        rm -f "$PART"
+        echo "Checksum mismatch for $ARCHIVE (expected $EXPECTED, got $ACTUAL)" >&2
+        exit 1
+    fi
+fi
 
+echo "%[4]sinstalling"
 TMPDIR=$(mktemp -d)
 trap 'rm -rf "$TMPDIR"' EXIT
-
-curl -fsSL "$URL" -o "$TMPDIR/$ARCHIVE"
-tar -xzf "$TMPDIR/$ARCHIVE" -C "$TMPDIR"
+tar -xzf "$PART" -C "$TMPDIR"
 mv "$TMPDIR/codes" ~/bin/codes
 chmod +x ~/bin/codes
+rm -f "$PART"
+if [ -n "$EXPECTED" ]; then
+    echo "$EXPECTED" > "$STAMP"
+fi
 
 # Ensure ~/bin is in PATH for future logins
 if ! echo "$PATH" | grep -q "$HOME/bin"; then
@@ -113,14 +227,7 @@ if ! echo "$PATH" | grep -q "$HOME/bin"; then
 fi
 
 ~/bin/codes version
-`, goOS, goArch)
-
-	out, err := RunSSH(host, installScript)
-	if err != nil {
-		return out, fmt.Errorf("remote install failed: %w", err)
-	}
-
-	return out, nil
+`, goOS, goArch, curlOpts, stagePrefix)
 }
 
 // InstallClaudeOnRemote installs Claude CLI (@anthropic-ai/claude-code) on the remote host via npm.
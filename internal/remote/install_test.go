@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"strings"
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestInstallScript(t *testing.T) {
+	script := installScript("linux", "amd64", "500k")
+
+	for _, want := range []string{
+		`ARCHIVE="codes-${VERSION}-linux-amd64.tar.gz"`,
+		"--limit-rate 500k",
+		"-C -",
+		// No progress meter: remote stderr is the local terminal, which the
+		// TUI may be drawing on
+		`curl -fsSL $CURL_OPTS -C -`,
+		stagePrefix + "downloading",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("install script missing %q", want)
+		}
+	}
+
+	if strings.Contains(installScript("linux", "amd64", ""), "--limit-rate") {
+		t.Error("install script should not limit rate when RateLimit is empty")
+	}
+}
+
+func TestInstallRejectsInvalidRateLimit(t *testing.T) {
+	host := &config.RemoteHost{Name: "x", Host: "127.0.0.1"}
+	for _, bad := range []string{"500k; rm -rf ~", "fast", "1.5M"} {
+		if _, err := InstallOnRemoteWithOptions(host, InstallOptions{RateLimit: bad}); err == nil || !strings.Contains(err.Error(), "invalid rate limit") {
+			t.Errorf("RateLimit %q: expected invalid rate limit error, got %v", bad, err)
+		}
+	}
+}
+
+func TestStripStageLines(t *testing.T) {
+	out := stripStageLines(stagePrefix + "downloading\nLatest version: v1.0.0\n" + stagePrefix + "installing\ncodes version v1.0.0")
+	if out != "Latest version: v1.0.0\ncodes version v1.0.0" {
+		t.Errorf("stripStageLines = %q", out)
+	}
+}
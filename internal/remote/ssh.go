@@ -1,30 +1,44 @@
 package remote
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 
 	"codes/internal/config"
+	"codes/internal/trace"
 )
 
-// sshArgs builds common SSH arguments from a RemoteHost config.
-func sshArgs(host *config.RemoteHost) []string {
+// sshOptions builds the options shared by ssh and scp. Host keys are always
+// checked strictly; unknown hosts must be trusted explicitly via TrustHostKeys.
+func sshOptions(host *config.RemoteHost) []string {
 	args := []string{
-		"-o", "StrictHostKeyChecking=accept-new",
-	}
-	if host.Port != 0 {
-		args = append(args, "-p", fmt.Sprintf("%d", host.Port))
+		"-o", "StrictHostKeyChecking=yes",
 	}
 	if host.Identity != "" {
 		args = append(args, "-i", expandHome(host.Identity))
 	}
+	if host.SecurityKeyProvider != "" {
+		args = append(args, "-o", "SecurityKeyProvider="+host.SecurityKeyProvider)
+	}
+	return args
+}
+
+// sshArgs builds common SSH arguments from a RemoteHost config.
+func sshArgs(host *config.RemoteHost) []string {
+	args := sshOptions(host)
+	if host.Port != 0 {
+		args = append(args, "-p", fmt.Sprintf("%d", host.Port))
+	}
 	return args
 }
 
 // RunSSH executes a command on the remote host and returns stdout.
 func RunSSH(host *config.RemoteHost, command string) (string, error) {
+	defer trace.Start("ssh", "host", host.UserAtHost())()
 	args := sshArgs(host)
 	args = append(args, host.UserAtHost(), command)
 
@@ -32,20 +46,59 @@ func RunSSH(host *config.RemoteHost, command string) (string, error) {
 	cmd.Stderr = os.Stderr
 	out, err := cmd.Output()
 	if err != nil {
-		return "", fmt.Errorf("ssh %s: %w", host.UserAtHost(), err)
+		return "", sshError(host, err)
 	}
 	return strings.TrimSpace(string(out)), nil
 }
 
+// RunSSHStream executes a command on the remote host, calling onLine for each
+// line of stdout as it arrives. Returns the full trimmed stdout.
+func RunSSHStream(host *config.RemoteHost, command string, onLine func(string)) (string, error) {
+	defer trace.Start("ssh", "host", host.UserAtHost(), "stream", true)()
+	args := sshArgs(host)
+	args = append(args, host.UserAtHost(), command)
+
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("ssh %s: %w", host.UserAtHost(), err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("ssh %s: %w", host.UserAtHost(), err)
+	}
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		out.WriteString(line)
+		out.WriteByte('\n')
+		if onLine != nil {
+			onLine(line)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return strings.TrimSpace(out.String()), sshError(host, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
 // RunSSHWithAgent runs a command on a remote host with SSH agent forwarding (-A).
 // This allows the remote host to use the local SSH keys for operations like git clone.
 func RunSSHWithAgent(host *config.RemoteHost, command string) (string, error) {
+	defer trace.Start("ssh", "host", host.UserAtHost(), "agent_forwarding", true)()
 	args := sshArgs(host)
 	args = append(args, "-A", host.UserAtHost(), command)
 
 	cmd := exec.Command("ssh", args...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
+		var unknown *HostKeyUnknownError
+		if errors.As(sshError(host, err), &unknown) {
+			return "", unknown
+		}
 		detail := strings.TrimSpace(string(out))
 		if detail != "" {
 			return "", fmt.Errorf("ssh %s: %s", host.UserAtHost(), detail)
@@ -68,31 +121,34 @@ func RunSSHInteractive(host *config.RemoteHost, command string) error {
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	if err := cmd.Run(); err != nil {
+		return sshError(host, err)
+	}
+	return nil
 }
 
 // CopyToRemote copies a local file to the remote host via scp.
 func CopyToRemote(host *config.RemoteHost, localPath, remotePath string) error {
-	args := []string{
-		"-o", "StrictHostKeyChecking=accept-new",
-	}
+	defer trace.Start("scp", "host", host.UserAtHost(), "file", localPath)()
+	args := sshOptions(host)
 	if host.Port != 0 {
 		args = append(args, "-P", fmt.Sprintf("%d", host.Port))
 	}
-	if host.Identity != "" {
-		args = append(args, "-i", expandHome(host.Identity))
-	}
 
 	dest := fmt.Sprintf("%s:%s", host.UserAtHost(), remotePath)
 	args = append(args, localPath, dest)
 
 	cmd := exec.Command("scp", args...)
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	if err := cmd.Run(); err != nil {
+		return sshError(host, err)
+	}
+	return nil
 }
 
 // TestConnection verifies SSH connectivity to the remote host.
 func TestConnection(host *config.RemoteHost) error {
+	defer trace.Start("ssh.test", "host", host.UserAtHost())()
 	args := sshArgs(host)
 	args = append(args, "-o", "ConnectTimeout=5")
 	args = append(args, host.UserAtHost(), "echo ok")
@@ -100,6 +156,10 @@ func TestConnection(host *config.RemoteHost) error {
 	cmd := exec.Command("ssh", args...)
 	out, err := cmd.Output()
 	if err != nil {
+		var unknown *HostKeyUnknownError
+		if errors.As(sshError(host, err), &unknown) {
+			return unknown
+		}
 		return fmt.Errorf("connection failed: %w", err)
 	}
 	if strings.TrimSpace(string(out)) != "ok" {
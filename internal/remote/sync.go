@@ -8,7 +8,8 @@ import (
 	"codes/internal/config"
 )
 
-// SyncProfiles uploads a minimal config.json (profiles + default + skipPermissions) to the remote host.
+// SyncProfiles uploads a minimal config.json (profiles + default + skipPermissions)
+// to the remote host, along with the agent limits configured for that host.
 func SyncProfiles(host *config.RemoteHost) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -20,6 +21,7 @@ func SyncProfiles(host *config.RemoteHost) error {
 		Profiles:        cfg.Profiles,
 		Default:         cfg.Default,
 		SkipPermissions: cfg.SkipPermissions,
+		AgentLimits:     host.AgentLimits,
 	}
 
 	data, err := json.MarshalIndent(remoteCfg, "", "    ")
@@ -0,0 +1,147 @@
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codes/internal/config"
+	"codes/internal/trace"
+)
+
+// defaultServePort is the port codes serve listens on without httpBind.
+const defaultServePort = 3456
+
+// tunnelReadyTimeout is how long OpenTunnel waits for the forward to accept
+// connections.
+var tunnelReadyTimeout = 15 * time.Second
+
+// ServeEndpoint is how to reach the codes serve API of a remote host from the
+// host itself, read from its config.
+type ServeEndpoint struct {
+	Port  int
+	Token string // an admin token if it has one, else a full-access token; empty if none
+	TLS   bool
+}
+
+// ServeEndpointFromConfig returns the API endpoint described by a remote
+// host's config (nil means no config yet: the defaults, without a token).
+func ServeEndpointFromConfig(cfg *config.Config) ServeEndpoint {
+	ep := ServeEndpoint{Port: defaultServePort}
+	if cfg == nil {
+		return ep
+	}
+	if i := strings.LastIndex(cfg.HTTPBind, ":"); i >= 0 {
+		if port, err := strconv.Atoi(cfg.HTTPBind[i+1:]); err == nil && port > 0 {
+			ep.Port = port
+		}
+	}
+	switch {
+	case len(cfg.HTTPAdminTokens) > 0:
+		ep.Token = cfg.HTTPAdminTokens[0]
+	case len(cfg.HTTPTokens) > 0:
+		ep.Token = cfg.HTTPTokens[0]
+	}
+	ep.TLS = cfg.HTTPTLS != nil
+	return ep
+}
+
+// Tunnel is an SSH local port forward from LocalAddr to a port on the remote
+// host's loopback interface.
+type Tunnel struct {
+	LocalAddr string
+
+	cmd    *exec.Cmd
+	stderr bytes.Buffer
+	done   chan struct{} // closed when ssh exits
+	once   sync.Once
+}
+
+// OpenTunnel forwards a free local port to remotePort on host with
+// `ssh -N -L` and waits until it accepts connections.
+func OpenTunnel(host *config.RemoteHost, remotePort int) (*Tunnel, error) {
+	defer trace.Start("ssh.tunnel", "host", host.UserAtHost(), "port", remotePort)()
+
+	localPort, err := freeLocalPort()
+	if err != nil {
+		return nil, fmt.Errorf("pick local port: %w", err)
+	}
+	t := &Tunnel{
+		LocalAddr: net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)),
+		done:      make(chan struct{}),
+	}
+
+	args := sshArgs(host)
+	args = append(args,
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=30",
+		"-L", fmt.Sprintf("%s:127.0.0.1:%d", t.LocalAddr, remotePort),
+		host.UserAtHost())
+	t.cmd = exec.Command("ssh", args...)
+	t.cmd.Stderr = &t.stderr
+	if err := t.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", host.UserAtHost(), err)
+	}
+	var waitErr error
+	go func() {
+		waitErr = t.cmd.Wait()
+		close(t.done)
+	}()
+
+	deadline := time.Now().Add(tunnelReadyTimeout)
+	for {
+		select {
+		case <-t.done:
+			if detail := strings.TrimSpace(t.stderr.String()); detail != "" {
+				return nil, fmt.Errorf("ssh %s: %s", host.UserAtHost(), detail)
+			}
+			return nil, sshError(host, waitErr)
+		default:
+		}
+		if conn, err := net.DialTimeout("tcp", t.LocalAddr, time.Second); err == nil {
+			conn.Close()
+			return t, nil
+		}
+		if time.Now().After(deadline) {
+			t.Close()
+			return nil, fmt.Errorf("ssh %s: tunnel to port %d not ready after %s", host.UserAtHost(), remotePort, tunnelReadyTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Alive reports whether the ssh process is still running.
+func (t *Tunnel) Alive() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close stops the tunnel.
+func (t *Tunnel) Close() {
+	t.once.Do(func() {
+		if t.Alive() {
+			t.cmd.Process.Kill()
+		}
+		<-t.done
+	})
+}
+
+// freeLocalPort returns a loopback port that was free a moment ago.
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
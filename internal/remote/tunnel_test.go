@@ -0,0 +1,25 @@
+package remote
+
+import (
+	"testing"
+
+	"codes/internal/config"
+)
+
+func TestServeEndpointFromConfig(t *testing.T) {
+	if ep := ServeEndpointFromConfig(nil); ep.Port != 3456 || ep.Token != "" || ep.TLS {
+		t.Errorf("no config: %+v", ep)
+	}
+	ep := ServeEndpointFromConfig(&config.Config{
+		HTTPBind:        "127.0.0.1:8080",
+		HTTPTokens:      []string{"plain"},
+		HTTPAdminTokens: []string{"admin"},
+		HTTPTLS:         &config.HTTPTLS{SelfSigned: true},
+	})
+	if ep.Port != 8080 || ep.Token != "admin" || !ep.TLS {
+		t.Errorf("endpoint = %+v", ep)
+	}
+	if ep := ServeEndpointFromConfig(&config.Config{HTTPBind: ":9000", HTTPTokens: []string{"plain"}}); ep.Port != 9000 || ep.Token != "plain" {
+		t.Errorf("endpoint = %+v", ep)
+	}
+}
@@ -12,17 +12,23 @@ import (
 	"time"
 
 	"codes/internal/config"
+	"codes/internal/notify"
+	"codes/internal/trace"
 )
 
 // safeIDPattern matches only characters safe for file paths, shell scripts, and AppleScript.
-var safeIDPattern = regexp.MustCompile(`[^a-zA-Z0-9_\-.]`)
+var safeIDPattern = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`[^a-zA-Z0-9_\-.]`)
+})
 
 // validEnvVarName matches valid environment variable names.
-var validEnvVarName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+var validEnvVarName = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+})
 
 // sanitizeID replaces unsafe characters in a session/project name with underscores.
 func sanitizeID(name string) string {
-	return safeIDPattern.ReplaceAllString(name, "_")
+	return safeIDPattern().ReplaceAllString(name, "_")
 }
 
 // Status represents the state of a session.
@@ -224,7 +230,9 @@ func (m *Manager) StartSession(name, path string, args []string, env map[string]
 
 	id := m.nextSessionID(name)
 
+	end := trace.Start("exec.start", "cmd", "terminal", "project", name)
 	pid, err := openInTerminal(id, path, args, env, m.terminal)
+	end()
 	if err != nil {
 		return nil, fmt.Errorf("failed to open terminal: %w", err)
 	}
@@ -240,6 +248,7 @@ func (m *Manager) StartSession(name, path string, args []string, env map[string]
 	m.sessions[id] = s
 
 	saveSession(s)
+	go runStartedHook(s, "")
 
 	// Monitor process exit in background
 	go func() {
@@ -259,6 +268,22 @@ func (m *Manager) StartSession(name, path string, args []string, env map[string]
 	return s, nil
 }
 
+// runStartedHook runs the on_session_started hook for a terminal session
+// opened on the host remote ("" for this machine). Errors are dropped: the
+// TUI owns the terminal, so there is nowhere to print them.
+func runStartedHook(s *Session, remote string) {
+	_ = notify.RunEventHook(notify.HookEvent{
+		Event: "on_session_started",
+		Session: &notify.HookSession{
+			ID:      s.ID,
+			Kind:    "terminal",
+			Project: strings.TrimPrefix(s.ProjectName, "remote:"),
+			Path:    s.ProjectPath,
+			Remote:  remote,
+		},
+	})
+}
+
 // FocusSession brings the configured terminal to the foreground.
 func (m *Manager) FocusSession() {
 	focusTerminalWindow(m.terminal)
@@ -398,7 +423,9 @@ func (m *Manager) StartRemoteSession(name string, host *config.RemoteHost, proje
 
 	id := m.nextSessionID("remote-" + name)
 
+	end := trace.Start("exec.start", "cmd", "terminal", "project", name, "host", host.UserAtHost())
 	pid, err := openRemoteInTerminal(id, host, project, m.terminal)
+	end()
 	if err != nil {
 		return nil, fmt.Errorf("failed to open remote terminal: %w", err)
 	}
@@ -414,6 +441,7 @@ func (m *Manager) StartRemoteSession(name string, host *config.RemoteHost, proje
 	m.sessions[id] = s
 
 	saveSession(s)
+	go runStartedHook(s, host.Name)
 
 	// Monitor process exit in background
 	go func() {
@@ -64,9 +64,9 @@ func TestValidEnvVarName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := validEnvVarName.MatchString(tt.input)
+			result := validEnvVarName().MatchString(tt.input)
 			if result != tt.isValid {
-				t.Errorf("validEnvVarName.MatchString(%q) = %v, want %v", tt.input, result, tt.isValid)
+				t.Errorf("validEnvVarName().MatchString(%q) = %v, want %v", tt.input, result, tt.isValid)
 			}
 		})
 	}
@@ -49,7 +49,7 @@ func buildScript(name, dir string, args []string, env map[string]string) (script
 
 	// Set environment variables
 	for k, v := range env {
-		if !validEnvVarName.MatchString(k) {
+		if !validEnvVarName().MatchString(k) {
 			continue // skip invalid variable names to prevent shell injection
 		}
 		escaped := strings.ReplaceAll(v, "'", "'\\''")
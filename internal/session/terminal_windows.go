@@ -32,7 +32,7 @@ func buildWindowsScript(name, dir string, args []string, env map[string]string)
 
 	// Set environment variables
 	for k, v := range env {
-		if !validEnvVarName.MatchString(k) {
+		if !validEnvVarName().MatchString(k) {
 			continue
 		}
 		escaped := strings.ReplaceAll(v, "'", "''")
@@ -104,7 +104,7 @@ func buildWindowsBatchScript(name, dir string, args []string, env map[string]str
 
 	// Set environment variables
 	for k, v := range env {
-		if !validEnvVarName.MatchString(k) {
+		if !validEnvVarName().MatchString(k) {
 			continue
 		}
 		b.WriteString(fmt.Sprintf("SET \"%s=%s\"\n", k, escapeBatchValue(v)))
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"codes/internal/config"
+	"codes/internal/trace"
 )
 
 // claudeProjectsDir returns the path to ~/.claude/projects/.
@@ -52,9 +54,11 @@ func resolveProjectAlias(projectPath string, projects map[string]config.ProjectE
 type jsonlLine struct {
 	Type      string    `json:"type"`
 	Timestamp time.Time `json:"timestamp"`
+	Summary   string    `json:"summary"` // "summary" lines
 	Message   *struct {
-		Model string `json:"model"`
-		Usage *struct {
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"` // a string or content blocks in "user" lines
+		Usage   *struct {
 			InputTokens       int64 `json:"input_tokens"`
 			OutputTokens      int64 `json:"output_tokens"`
 			CacheCreateTokens int64 `json:"cache_creation_input_tokens"`
@@ -72,6 +76,7 @@ type ScanOptions struct {
 
 // ScanSessions scans all Claude session JSONL files and returns SessionRecords.
 func ScanSessions(opts ScanOptions) ([]SessionRecord, error) {
+	defer trace.Start("scan.sessions")()
 	projDir, err := claudeProjectsDir()
 	if err != nil {
 		return nil, err
@@ -161,10 +166,12 @@ func parseSessionFile(path, sessionID, project, projectPath, profile string) (*S
 	}
 
 	var (
-		turns     int
-		firstTime time.Time
-		lastTime  time.Time
-		mainModel string
+		turns       int
+		firstTime   time.Time
+		lastTime    time.Time
+		mainModel   string
+		summary     string
+		firstPrompt string
 	)
 
 	scanner := bufio.NewScanner(f)
@@ -186,7 +193,19 @@ func parseSessionFile(path, sessionID, project, projectPath, profile string) (*S
 			}
 		}
 
-		if line.Type != "assistant" {
+		switch line.Type {
+		case "summary":
+			if summary == "" {
+				summary = line.Summary
+			}
+			continue
+		case "user":
+			if firstPrompt == "" && line.Message != nil {
+				firstPrompt = promptText(line.Message.Content)
+			}
+			continue
+		case "assistant":
+		default:
 			continue
 		}
 		if line.Message == nil {
@@ -215,6 +234,10 @@ func parseSessionFile(path, sessionID, project, projectPath, profile string) (*S
 
 	record.Model = mainModel
 	record.Turns = turns
+	record.Summary = summary
+	if record.Summary == "" {
+		record.Summary = firstPrompt
+	}
 	record.StartTime = firstTime
 	record.EndTime = lastTime
 	if !firstTime.IsZero() && !lastTime.IsZero() {
@@ -231,3 +254,63 @@ func parseSessionFile(path, sessionID, project, projectPath, profile string) (*S
 
 	return record, nil
 }
+
+// maxSummaryLen bounds SessionRecord.Summary when it comes from a prompt.
+const maxSummaryLen = 200
+
+// promptText returns the text of a user message, on one line, or "" for
+// tool results and the command and caveat messages Claude Code records
+// (which start with "<").
+func promptText(content json.RawMessage) string {
+	var text string
+	if json.Unmarshal(content, &text) != nil {
+		var blocks []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(content, &blocks) != nil {
+			return ""
+		}
+		for _, b := range blocks {
+			if b.Type == "text" {
+				text = b.Text
+				break
+			}
+		}
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if strings.HasPrefix(text, "<") {
+		return ""
+	}
+	if r := []rune(text); len(r) > maxSummaryLen {
+		text = string(r[:maxSummaryLen-1]) + "…"
+	}
+	return text
+}
+
+// claudeDirName returns the name Claude Code gives the directory holding a
+// project's sessions: the path with every character but letters and
+// digits replaced by "-".
+func claudeDirName(path string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, path)
+}
+
+// ProjectSessions returns the records of the project at projectPath, most
+// recent first. Records are matched by Claude's directory name, since
+// projectPathFromDir cannot restore dashes and dots in the path.
+func ProjectSessions(records []SessionRecord, projectPath string) []SessionRecord {
+	want := claudeDirName(filepath.Clean(projectPath))
+	var matched []SessionRecord
+	for _, r := range records {
+		if claudeDirName(r.ProjectPath) == want {
+			matched = append(matched, r)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].EndTime.After(matched[j].EndTime) })
+	return matched
+}
@@ -2,6 +2,9 @@ package stats
 
 import (
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -99,6 +102,52 @@ func TestProjectPathFromDir(t *testing.T) {
 	}
 }
 
+func TestParseSessionFile_Summary(t *testing.T) {
+	write := func(lines ...string) string {
+		path := filepath.Join(t.TempDir(), "s.jsonl")
+		os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+		return path
+	}
+	assistant := `{"type":"assistant","timestamp":"2026-02-15T10:00:00Z","message":{"model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":5}}}`
+
+	rec, err := parseSessionFile(write(
+		`{"type":"user","message":{"role":"user","content":"<command-name>/clear</command-name>"}}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"fix the\nlogin bug"}]}}`,
+		assistant,
+	), "s", "p", "/p", "default")
+	if err != nil || rec == nil {
+		t.Fatalf("parseSessionFile: %v, %v", rec, err)
+	}
+	if rec.Summary != "fix the login bug" {
+		t.Errorf("summary from first prompt = %q", rec.Summary)
+	}
+
+	rec, _ = parseSessionFile(write(
+		`{"type":"summary","summary":"Login bug fix","leafUuid":"x"}`,
+		`{"type":"user","message":{"role":"user","content":"fix the login bug"}}`,
+		assistant,
+	), "s", "p", "/p", "default")
+	if rec.Summary != "Login bug fix" {
+		t.Errorf("summary = %q, want Claude's summary", rec.Summary)
+	}
+}
+
+func TestProjectSessions(t *testing.T) {
+	now := time.Now()
+	records := []SessionRecord{
+		{SessionID: "old", ProjectPath: projectPathFromDir("-home-user-my-app"), EndTime: now.Add(-time.Hour)},
+		{SessionID: "other", ProjectPath: "/home/user/other", EndTime: now},
+		{SessionID: "new", ProjectPath: projectPathFromDir("-home-user-my-app"), EndTime: now},
+	}
+	got := ProjectSessions(records, "/home/user/my-app/")
+	if len(got) != 2 || got[0].SessionID != "new" || got[1].SessionID != "old" {
+		t.Errorf("ProjectSessions = %+v, want new then old", got)
+	}
+	if got := ProjectSessions(records, "/home/user/my.app"); len(got) != 2 {
+		t.Errorf("dots are encoded like slashes: got %d sessions", len(got))
+	}
+}
+
 func TestAggregate_BasicGrouping(t *testing.T) {
 	records := []SessionRecord{
 		{
@@ -18,6 +18,7 @@ type SessionRecord struct {
 	CacheReadTokens   int64         `json:"cacheReadTokens"`
 	CostUSD           float64       `json:"costUsd"`
 	Turns             int           `json:"turns"`
+	Summary           string        `json:"summary,omitempty"` // Claude's summary of the session, or its first prompt
 }
 
 // DailyStat aggregates session records by calendar date.
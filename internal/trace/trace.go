@@ -0,0 +1,82 @@
+// Package trace prints timing spans for the slow parts of a command (config
+// load and save, SSH calls, subprocess launches, file scans) to stderr, for
+// `codes --verbose` / `--trace`. Each span is one logfmt line:
+//
+//	msg=trace span=ssh duration_ms=812.4 host=dev@build-box
+//
+// Tracing is off unless Enable is called or Env is set, and costs one atomic
+// load per span when off.
+package trace
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Env turns tracing on when set to a non-empty value. Enable sets it, so agent
+// daemons and other codes processes started by a traced command trace too.
+const Env = "CODES_TRACE"
+
+var (
+	enabled atomic.Bool
+	mu      sync.Mutex
+	logger  = newLogger(os.Stderr)
+)
+
+func init() {
+	if os.Getenv(Env) != "" {
+		enabled.Store(true)
+	}
+}
+
+func newLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// The span name and duration are what matter; drop time and level
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// Enable turns tracing on for this process and the codes processes it starts.
+func Enable() {
+	enabled.Store(true)
+	os.Setenv(Env, "1")
+}
+
+// Enabled reports whether spans are printed.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// SetOutput sends spans to w instead of stderr.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	logger = newLogger(w)
+	mu.Unlock()
+}
+
+// Start begins a span called name with key-value attrs (as for slog) and
+// returns the function that ends it and prints it:
+//
+//	defer trace.Start("config.load", "path", path)()
+func Start(name string, attrs ...any) func() {
+	if !enabled.Load() {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		args := append([]any{"span", name, "duration_ms", float64(elapsed.Microseconds()) / 1000}, attrs...)
+		mu.Lock()
+		logger.Info("trace", args...)
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,35 @@
+package trace
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() {
+		enabled.Store(false)
+		SetOutput(os.Stderr)
+	})
+
+	enabled.Store(false)
+	Start("config.load")()
+	if buf.Len() != 0 {
+		t.Errorf("disabled tracing printed %q", buf.String())
+	}
+
+	enabled.Store(true)
+	Start("ssh", "host", "dev@build-box")()
+	line := buf.String()
+	for _, want := range []string{"msg=trace", "span=ssh", "duration_ms=", "host=dev@build-box"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("span line %q lacks %q", line, want)
+		}
+	}
+	if strings.Contains(line, "level=") || strings.Contains(line, "time=") {
+		t.Errorf("span line %q has time or level", line)
+	}
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"codes/internal/agent"
+)
+
+// Activity timeline: 'a' in the Teams sub-tab swaps the conversation for
+// the selected team's activity (agent.TeamActivity, what team_activity
+// returns to MCP clients), newest first; on a member row only the events
+// by or to that member. 'f' cycles the type filter and J/K scroll. It is
+// reread on the same loop as the conversation.
+
+// activityFilter is a group of activity event types.
+type activityFilter int
+
+const (
+	activityAll activityFilter = iota
+	activityMessages
+	activityTasks
+	activityProgress
+	activityProblems
+)
+
+var activityFilterNames = []string{"all", "messages", "tasks", "progress", "problems"}
+
+func (f activityFilter) String() string { return activityFilterNames[f] }
+
+func (f activityFilter) next() activityFilter {
+	return (f + 1) % activityFilter(len(activityFilterNames))
+}
+
+// match reports whether an event of type typ belongs to the group.
+func (f activityFilter) match(typ string) bool {
+	switch f {
+	case activityMessages:
+		return typ == agent.ActivityMessage || typ == "help_request" || typ == "discovery" || typ == "plan"
+	case activityTasks:
+		return strings.HasPrefix(typ, "task_")
+	case activityProgress:
+		return typ == "progress"
+	case activityProblems:
+		return typ == agent.ActivityTaskFailed || typ == "help_request"
+	}
+	return true
+}
+
+// teamActivityPane is the timeline's state while it is shown.
+type teamActivityPane struct {
+	team    string
+	events  []agent.ActivityEvent
+	err     error
+	filter  activityFilter
+	scroll  int // lines
+	loading bool
+}
+
+// teamActivityMsg carries a team's timeline.
+type teamActivityMsg struct {
+	team   string
+	events []agent.ActivityEvent
+	err    error
+}
+
+func loadTeamActivityCmd(team string) tea.Cmd {
+	return func() tea.Msg {
+		events, err := agent.TeamActivity(team)
+		return teamActivityMsg{team: team, events: events, err: err}
+	}
+}
+
+// openTeamActivity shows the timeline of the selected row's team.
+func (m Model) openTeamActivity() (Model, tea.Cmd) {
+	target, ok := m.selectedChatTarget()
+	if !ok {
+		return m, nil
+	}
+	m.teamActivity = &teamActivityPane{team: target.team, loading: true}
+	return m, loadTeamActivityCmd(target.team)
+}
+
+// refreshTeamActivity rereads the timeline for the selected row, starting
+// over when it belongs to another team.
+func (m Model) refreshTeamActivity() tea.Cmd {
+	target, ok := m.selectedChatTarget()
+	if !ok {
+		return nil
+	}
+	if p := m.teamActivity; p.team != target.team {
+		p.team, p.events, p.err, p.scroll, p.loading = target.team, nil, nil, 0, true
+	}
+	return loadTeamActivityCmd(target.team)
+}
+
+// visibleActivity returns the events of target shown under filter.
+func visibleActivity(events []agent.ActivityEvent, target chatTarget, filter activityFilter) []agent.ActivityEvent {
+	var visible []agent.ActivityEvent
+	for _, e := range events {
+		if !filter.match(e.Type) {
+			continue
+		}
+		if !target.broadcast() && e.Agent != target.member.Name && e.To != target.member.Name {
+			continue
+		}
+		visible = append(visible, e)
+	}
+	return visible
+}
+
+// activityStyle colors an event type.
+func activityStyle(typ string) lipgloss.Style {
+	switch typ {
+	case agent.ActivityTaskCompleted:
+		return statusOkStyle
+	case agent.ActivityTaskFailed, "help_request":
+		return statusErrorStyle
+	case agent.ActivityTaskStarted, agent.ActivityTaskCreated, "plan":
+		return statsAccentStyle
+	}
+	return statsDimStyle
+}
+
+// renderTeamActivity renders the timeline from its scroll offset, at most
+// height lines.
+func renderTeamActivity(p *teamActivityPane, target chatTarget, width, height int) string {
+	if p.err != nil {
+		return statusErrorStyle.Render(p.err.Error())
+	}
+	if p.loading && p.events == nil {
+		return statsDimStyle.Render("Loading...")
+	}
+	visible := visibleActivity(p.events, target, p.filter)
+	if len(visible) == 0 {
+		return statsDimStyle.Render(fmt.Sprintf("No %s activity yet.", p.filter))
+	}
+	var lines []string
+	for _, e := range visible {
+		who := e.Agent
+		if who == "" {
+			who = "-"
+		}
+		if e.To != "" {
+			who += " → " + e.To
+		}
+		header := statsDimStyle.Render(e.Time.Local().Format("01-02 15:04")) + " " +
+			activityStyle(e.Type).Render(fmt.Sprintf("%-14s", e.Type)) + " " + statsAccentStyle.Render(who)
+		lines = append(lines, ansi.Truncate(header, width, "…"))
+		summary := strings.Join(strings.Fields(e.Summary), " ")
+		lines = append(lines, "  "+ansi.Truncate(summary, width-2, "…"))
+	}
+	lines = lines[min(p.scroll, max(0, len(lines)-height)):]
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
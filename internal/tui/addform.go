@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"codes/internal/config"
@@ -47,14 +48,17 @@ type gitCloneStartMsg struct {
 	clonePath string
 	name      string
 	remote    string
+	opts      config.CloneOptions
 }
 
 // gitCloneMsg carries the result of a git clone operation.
 type gitCloneMsg struct {
-	name   string
-	path   string
-	remote string
-	err    error
+	name    string
+	path    string
+	remote  string
+	fork    *config.ForkInfo // set when origin is a fork
+	warning string           // non-fatal problem after a successful clone
+	err     error
 }
 
 // addFormModel is the model for the add-project form.
@@ -76,11 +80,24 @@ type addFormModel struct {
 	// Debounce for SSH path completion
 	debounceSeq int
 
+	// Remote directory browser, open with ctrl+b
+	browser *remoteBrowser
+
 	// Git mode
-	isGitMode bool
-	gitURL    string
+	isGitMode    bool
+	gitURL       string
+	sparseInput  textinput.Model // comma-separated sparse-checkout paths
+	cloneDepth   int             // 0 = full history
+	singleBranch bool
 }
 
+// Field indices specific to git mode (after name, URL and clone path).
+const (
+	gitFieldSparse       = 3
+	gitFieldShallow      = 4
+	gitFieldSingleBranch = 5
+)
+
 // newAddForm creates a new add-project form.
 func newAddForm() addFormModel {
 	ni := textinput.New()
@@ -96,6 +113,10 @@ func newAddForm() addFormModel {
 	ci.Placeholder = "clone target path"
 	ci.CharLimit = 200
 
+	si := textinput.New()
+	si.Placeholder = "optional: dir1, dir2 (sparse checkout)"
+	si.CharLimit = 300
+
 	// Load available remotes
 	var remoteNames []string
 	if remotes, err := config.ListRemotes(); err == nil {
@@ -108,6 +129,7 @@ func newAddForm() addFormModel {
 		nameInput:      ni,
 		pathInput:      pi,
 		clonePathInput: ci,
+		sparseInput:    si,
 		remoteNames:    remoteNames,
 		remoteIdx:      -1, // default: local
 		focused:        0,
@@ -117,7 +139,7 @@ func newAddForm() addFormModel {
 func (m *addFormModel) fieldCount() int {
 	base := 2 // name, path
 	if m.isGitMode {
-		base = 3 // name, path/URL, clone path
+		base = 6 // name, path/URL, clone path, sparse paths, shallow, single branch
 	}
 	if len(m.remoteNames) > 0 {
 		base++ // remote selector
@@ -127,7 +149,7 @@ func (m *addFormModel) fieldCount() int {
 
 func (m *addFormModel) remoteFieldIdx() int {
 	if m.isGitMode {
-		return 3
+		return 6
 	}
 	return 2
 }
@@ -137,6 +159,7 @@ func (m *addFormModel) focusInput() {
 	m.nameInput.Blur()
 	m.pathInput.Blur()
 	m.clonePathInput.Blur()
+	m.sparseInput.Blur()
 
 	switch m.focused {
 	case 0:
@@ -148,6 +171,10 @@ func (m *addFormModel) focusInput() {
 			m.clonePathInput.Focus()
 		}
 		// else: remote field (no text input)
+	case gitFieldSparse:
+		if m.isGitMode {
+			m.sparseInput.Focus()
+		}
 	}
 }
 
@@ -158,16 +185,19 @@ func (m *addFormModel) selectedRemote() string {
 	return m.remoteNames[m.remoteIdx]
 }
 
-// Git URL detection patterns.
-var gitURLPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`^git@[^:]+:.+/.+`),
-	regexp.MustCompile(`^https?://[^/]+/.+/.+`),
-	regexp.MustCompile(`^ssh://git@.+/.+`),
-}
+// gitURLPatterns detects git URLs. Compiled on first use so commands that
+// never open the add form don't pay for it at startup.
+var gitURLPatterns = sync.OnceValue(func() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		regexp.MustCompile(`^git@[^:]+:.+/.+`),
+		regexp.MustCompile(`^https?://[^/]+/.+/.+`),
+		regexp.MustCompile(`^ssh://git@.+/.+`),
+	}
+})
 
 func isGitURL(input string) bool {
 	input = strings.TrimSpace(input)
-	for _, pat := range gitURLPatterns {
+	for _, pat := range gitURLPatterns() {
 		if pat.MatchString(input) {
 			return true
 		}
@@ -230,6 +260,12 @@ func (m *addFormModel) checkGitMode() {
 			projectsDir := config.GetProjectsDir()
 			m.clonePathInput.SetValue(filepath.Join(projectsDir, repoName))
 			m.clonePathInput.CursorEnd()
+
+			// Start from the configured clone defaults
+			defaults := config.GetCloneDefaults()
+			m.cloneDepth = defaults.Depth
+			m.singleBranch = defaults.SingleBranch
+			m.sparseInput.SetValue(strings.Join(defaults.SparsePaths, ", "))
 		}
 		// Clear path suggestions in git mode
 		m.suggestions = nil
@@ -239,6 +275,59 @@ func (m *addFormModel) checkGitMode() {
 	}
 }
 
+// cloneOptions returns the clone options currently selected in the form.
+func (m *addFormModel) cloneOptions() config.CloneOptions {
+	return config.CloneOptions{
+		Depth:        m.cloneDepth,
+		SingleBranch: m.singleBranch,
+		SparsePaths:  parseSparsePaths(m.sparseInput.Value()),
+	}
+}
+
+// toggleCloneOption flips the focused shallow/single-branch option.
+// Turning shallow on uses the configured depth, or 1 if none is set.
+func (m *addFormModel) toggleCloneOption() {
+	switch m.focused {
+	case gitFieldShallow:
+		if m.cloneDepth > 0 {
+			m.cloneDepth = 0
+		} else {
+			m.cloneDepth = max(config.GetCloneDefaults().Depth, 1)
+		}
+	case gitFieldSingleBranch:
+		m.singleBranch = !m.singleBranch
+	}
+}
+
+// parseSparsePaths splits a comma- or space-separated list of directories.
+func parseSparsePaths(input string) []string {
+	var paths []string
+	for _, p := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if p = strings.Trim(p, "/"); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// remoteCloneCommand builds the shell command that clones gitURL on a remote
+// host with opts, followed by sparse-checkout setup when paths are given.
+func remoteCloneCommand(gitURL, clonePath string, opts config.CloneOptions) string {
+	var b strings.Builder
+	b.WriteString("git clone")
+	for _, a := range opts.CloneArgs() {
+		b.WriteString(" " + a)
+	}
+	fmt.Fprintf(&b, " %q %q", gitURL, clonePath)
+	if len(opts.SparsePaths) > 0 {
+		fmt.Fprintf(&b, " && git -C %q sparse-checkout set", clonePath)
+		for _, p := range opts.SparsePaths {
+			fmt.Fprintf(&b, " %q", p)
+		}
+	}
+	return b.String()
+}
+
 // listPathSuggestions scans the local filesystem and returns matching path suggestions.
 func listPathSuggestions(input string) []pathSuggestion {
 	if input == "" {
@@ -391,6 +480,13 @@ func (m addFormModel) Update(msg tea.Msg) (addFormModel, tea.Cmd) {
 	case tea.KeyMsg:
 		key := msg.String()
 
+		if m.browser != nil {
+			return m.updateRemoteBrowser(msg)
+		}
+		if key == "ctrl+b" && m.focused == 1 && m.remoteIdx >= 0 && !m.isGitMode {
+			return m, m.openRemoteBrowser()
+		}
+
 		// Path field with suggestions: intercept navigation keys
 		if m.focused == 1 && len(m.suggestions) > 0 {
 			switch key {
@@ -406,6 +502,15 @@ func (m addFormModel) Update(msg tea.Msg) (addFormModel, tea.Cmd) {
 			}
 		}
 
+		// Clone option toggles (git mode)
+		if m.isGitMode && (m.focused == gitFieldShallow || m.focused == gitFieldSingleBranch) {
+			switch key {
+			case "left", "right", "enter", " ":
+				m.toggleCloneOption()
+				return m, nil
+			}
+		}
+
 		switch key {
 		case "tab", "down":
 			m.focused = (m.focused + 1) % fields
@@ -474,12 +579,14 @@ func (m addFormModel) Update(msg tea.Msg) (addFormModel, tea.Cmd) {
 				m.err = ""
 				remoteName := m.selectedRemote()
 				gitURL := m.gitURL
+				opts := m.cloneOptions()
 				return m, func() tea.Msg {
 					return gitCloneStartMsg{
 						gitURL:    gitURL,
 						clonePath: clonePath,
 						name:      name,
 						remote:    remoteName,
+						opts:      opts,
 					}
 				}
 			}
@@ -529,6 +636,10 @@ func (m addFormModel) Update(msg tea.Msg) (addFormModel, tea.Cmd) {
 		if m.isGitMode {
 			m.clonePathInput, cmd = m.clonePathInput.Update(msg)
 		}
+	case gitFieldSparse:
+		if m.isGitMode {
+			m.sparseInput, cmd = m.sparseInput.Update(msg)
+		}
 	}
 	return m, cmd
 }
@@ -555,7 +666,9 @@ func (m addFormModel) View() string {
 	b.WriteString(m.pathInput.View() + "\n")
 
 	// Path suggestions (only in non-git mode)
-	if !m.isGitMode && m.focused == 1 {
+	if m.browser != nil {
+		b.WriteString(renderRemoteBrowser(m.browser))
+	} else if !m.isGitMode && m.focused == 1 {
 		if m.loadingSugg {
 			b.WriteString(lipgloss.NewStyle().Foreground(mutedColor).Render("    loading...") + "\n")
 		} else if len(m.suggestions) > 0 {
@@ -592,6 +705,17 @@ func (m addFormModel) View() string {
 	if m.isGitMode {
 		b.WriteString(formLabelStyle.Render("Clone Path") + "\n")
 		b.WriteString(m.clonePathInput.View() + "\n\n")
+
+		b.WriteString(formLabelStyle.Render("Sparse Paths") + "\n")
+		b.WriteString(m.sparseInput.View() + "\n\n")
+
+		shallow := "Shallow clone"
+		if m.cloneDepth > 0 {
+			shallow = fmt.Sprintf("Shallow clone (--depth %d)", m.cloneDepth)
+		}
+		b.WriteString(formLabelStyle.Render("Clone Options") + "\n")
+		b.WriteString(renderCloneToggle(shallow, m.cloneDepth > 0, m.focused == gitFieldShallow) + "\n")
+		b.WriteString(renderCloneToggle("Single branch", m.singleBranch, m.focused == gitFieldSingleBranch) + "\n\n")
 	}
 
 	// Remote selector (only shown if remotes exist)
@@ -627,8 +751,12 @@ func (m addFormModel) View() string {
 	}
 
 	var hint string
-	if m.isGitMode {
-		hint = "Tab: switch fields · Enter: clone & add · Esc: cancel"
+	if m.browser != nil {
+		hint = "↑↓: navigate · Enter: open · Backspace: up · Space: pick · .: pick this folder · Esc: close browser"
+	} else if m.isGitMode {
+		hint = "Tab: switch fields · Space: toggle option · Enter: clone & add · Esc: cancel"
+	} else if m.remoteIdx >= 0 {
+		hint = "Tab: complete path / switch fields · ↑↓: navigate suggestions · Ctrl+B: browse remote · Enter: add · Esc: cancel"
 	} else {
 		hint = "Tab: complete path / switch fields · ↑↓: navigate suggestions · Enter: add · Esc: cancel"
 	}
@@ -636,3 +764,15 @@ func (m addFormModel) View() string {
 
 	return b.String()
 }
+
+// renderCloneToggle renders a checkbox line for a clone option.
+func renderCloneToggle(label string, on, focused bool) string {
+	box := "[ ]"
+	if on {
+		box = "[x]"
+	}
+	if focused {
+		return lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render("  ▸ " + box + " " + label)
+	}
+	return lipgloss.NewStyle().Foreground(mutedColor).Render("    " + box + " " + label)
+}
@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codes/internal/config"
+)
+
+// Confirmation for destructive actions: deletes and kills first ask on the
+// status line, naming what they act on, and only y goes ahead. Removing
+// projects only drops their registry entries, so that can also be undone
+// with u for a few seconds afterwards.
+
+// undoWindow is how long a project removal can be undone.
+const undoWindow = 10 * time.Second
+
+// confirmPrompt is a pending yes/no question; run performs the action.
+type confirmPrompt struct {
+	question string
+	run      func(m Model) (tea.Model, tea.Cmd)
+}
+
+// projectUndo remembers removed projects' entries so u can restore them.
+type projectUndo struct {
+	entries map[string]config.ProjectEntry
+	at      time.Time
+}
+
+// projectRemovedMsg is sent after projects were removed from the registry.
+type projectRemovedMsg struct {
+	entries map[string]config.ProjectEntry
+	err     error
+}
+
+// projectRestoredMsg is sent after an undo put projects back.
+type projectRestoredMsg struct {
+	names []string
+	err   error
+}
+
+// undoExpiredMsg ends the undo window of the removal made at at.
+type undoExpiredMsg struct{ at time.Time }
+
+// confirm asks question before running run.
+func (m Model) confirm(question string, run func(m Model) (tea.Model, tea.Cmd)) (tea.Model, tea.Cmd) {
+	m.confirmPrompt = &confirmPrompt{question: question, run: run}
+	m.err = ""
+	return m, nil
+}
+
+// updateConfirmPrompt handles y/n while a confirmation is open; all other
+// keys are swallowed.
+func (m Model) updateConfirmPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		p := m.confirmPrompt
+		m.confirmPrompt = nil
+		return p.run(m)
+	case "n", "N", "esc":
+		m.confirmPrompt = nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// renderConfirmPrompt shows the question on the status line.
+func renderConfirmPrompt(p *confirmPrompt) string {
+	return fmt.Sprintf("  %s (y = yes, n/esc = cancel)", p.question)
+}
+
+// removeProjectsCmd removes names from the registry in one update, keeping
+// their entries for undo.
+func removeProjectsCmd(names ...string) tea.Cmd {
+	return func() tea.Msg {
+		entries := make(map[string]config.ProjectEntry)
+		err := config.UpdateConfig(func(cfg *config.Config) error {
+			for _, name := range names {
+				entry, ok := cfg.Projects[name]
+				if !ok {
+					return fmt.Errorf("project '%s' not found", name)
+				}
+				entries[name] = entry
+			}
+			for name := range entries {
+				delete(cfg.Projects, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return projectRemovedMsg{err: err}
+		}
+		return projectRemovedMsg{entries: entries}
+	}
+}
+
+// restoreProjectsCmd puts removed projects back, except those whose name
+// was taken again since.
+func restoreProjectsCmd(u projectUndo) tea.Cmd {
+	return func() tea.Msg {
+		var restored, taken []string
+		err := config.UpdateConfig(func(cfg *config.Config) error {
+			if cfg.Projects == nil {
+				cfg.Projects = make(map[string]config.ProjectEntry)
+			}
+			for name, entry := range u.entries {
+				if _, exists := cfg.Projects[name]; exists {
+					taken = append(taken, name)
+					continue
+				}
+				cfg.Projects[name] = entry
+				restored = append(restored, name)
+			}
+			return nil
+		})
+		sort.Strings(restored)
+		if err == nil && len(taken) > 0 {
+			sort.Strings(taken)
+			err = fmt.Errorf("already exists: %s", strings.Join(taken, ", "))
+		}
+		return projectRestoredMsg{names: restored, err: err}
+	}
+}
+
+// projectNames lists the names of entries, sorted.
+func projectNames(entries map[string]config.ProjectEntry) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeProjects names one project, or counts several.
+func describeProjects(names []string) string {
+	if len(names) == 1 {
+		return "project " + names[0]
+	}
+	return fmt.Sprintf("%d projects", len(names))
+}
+
+func undoExpireCmd(at time.Time) tea.Cmd {
+	return tea.Tick(undoWindow, func(time.Time) tea.Msg { return undoExpiredMsg{at: at} })
+}
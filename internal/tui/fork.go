@@ -0,0 +1,245 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codes/internal/config"
+	"codes/internal/remote"
+)
+
+// githubRepo is the subset of `gh repo view --json` used to decide whether a
+// clone should go through a fork.
+type githubRepo struct {
+	NameWithOwner    string `json:"nameWithOwner"`
+	ViewerPermission string `json:"viewerPermission"`
+	Parent           *struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"parent"`
+}
+
+// canPush reports whether the current gh user has push access.
+func (r *githubRepo) canPush() bool {
+	switch r.ViewerPermission {
+	case "ADMIN", "MAINTAIN", "WRITE":
+		return true
+	}
+	return false
+}
+
+// parentName returns the "owner/repo" this repo was forked from, if any.
+func (r *githubRepo) parentName() string {
+	if r.Parent == nil || r.Parent.Name == "" {
+		return ""
+	}
+	return r.Parent.Owner.Login + "/" + r.Parent.Name
+}
+
+// forkCheckMsg carries the GitHub lookup done before a clone starts.
+// repo is nil when the URL is not on GitHub or gh is unavailable.
+type forkCheckMsg struct {
+	start gitCloneStartMsg
+	repo  *githubRepo
+}
+
+// forkPrompt asks whether to fork a repo the user cannot push to.
+type forkPrompt struct {
+	start gitCloneStartMsg
+	repo  *githubRepo
+}
+
+// githubRepoFromURL extracts "owner/repo" from a github.com clone URL.
+func githubRepoFromURL(gitURL string) (string, bool) {
+	u := strings.TrimSuffix(strings.TrimSpace(gitURL), ".git")
+	for _, prefix := range []string{"https://github.com/", "http://github.com/", "git@github.com:", "ssh://git@github.com/"} {
+		if rest, ok := strings.CutPrefix(u, prefix); ok {
+			parts := strings.Split(strings.Trim(rest, "/"), "/")
+			if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+				return parts[0] + "/" + parts[1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// githubCloneURL returns the clone URL for fullName using the same scheme
+// (HTTPS or SSH) as like.
+func githubCloneURL(like, fullName string) string {
+	if isHTTPSURL(like) {
+		return "https://github.com/" + fullName + ".git"
+	}
+	return "git@github.com:" + fullName + ".git"
+}
+
+// checkFork looks the repo up on GitHub before cloning. Failures are not
+// fatal: the clone simply proceeds without fork handling.
+func checkFork(start gitCloneStartMsg) tea.Msg {
+	name, ok := githubRepoFromURL(start.gitURL)
+	if !ok {
+		return forkCheckMsg{start: start}
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return forkCheckMsg{start: start}
+	}
+	out, err := exec.Command("gh", "repo", "view", name, "--json", "nameWithOwner,viewerPermission,parent").Output()
+	if err != nil {
+		return forkCheckMsg{start: start}
+	}
+	var repo githubRepo
+	if err := json.Unmarshal(out, &repo); err != nil || repo.NameWithOwner == "" {
+		return forkCheckMsg{start: start}
+	}
+	return forkCheckMsg{start: start, repo: &repo}
+}
+
+// forkOutputRe matches the fork's name in what `gh repo fork` prints: the
+// "Created fork owner/repo" or "owner/repo already exists" notice, or the
+// fork's URL.
+var forkOutputRe = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`(?:Created fork |github\.com/)([\w.-]+/[\w.-]+)|([\w.-]+/[\w.-]+) already exists`)
+})
+
+// Forks are created asynchronously, so a clone right after `gh repo fork`
+// can fail until GitHub has copied the repository.
+const (
+	forkCloneAttempts = 5
+	forkCloneDelay    = 3 * time.Second
+)
+
+// forkRepo forks name to the current gh user's account (reusing an existing
+// fork) and returns the fork's "owner/repo". The fork's name is not assumed
+// to match the parent's: GitHub picks another when the user already has a
+// repo of that name, and an existing fork may have been renamed.
+func forkRepo(name string) (string, error) {
+	out, err := exec.Command("gh", "repo", "fork", name, "--clone=false", "--remote=false").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("gh repo fork: %s", strings.TrimSpace(string(out)))
+	}
+	if m := forkOutputRe().FindStringSubmatch(string(out)); m != nil {
+		fork := strings.TrimSuffix(m[1]+m[2], ".git")
+		if !strings.EqualFold(fork, name) {
+			return fork, nil
+		}
+	}
+	return findFork(name)
+}
+
+// findFork returns the current gh user's fork of name.
+func findFork(name string) (string, error) {
+	out, err := exec.Command("gh", "repo", "list", "--fork", "--limit", "1000", "--json", "nameWithOwner,parent").Output()
+	if err != nil {
+		return "", fmt.Errorf("gh repo list: %w", err)
+	}
+	var repos []githubRepo
+	if err := json.Unmarshal(out, &repos); err != nil {
+		return "", fmt.Errorf("gh repo list: %w", err)
+	}
+	for _, r := range repos {
+		if strings.EqualFold(r.parentName(), name) {
+			return r.NameWithOwner, nil
+		}
+	}
+	return "", fmt.Errorf("fork of %s not found in your repositories", name)
+}
+
+// forkAndClone forks the repo, clones the fork and points "upstream" at the
+// original repository. The clone is retried for a few seconds while GitHub
+// finishes creating the fork.
+func forkAndClone(start gitCloneStartMsg, repo *githubRepo) tea.Msg {
+	forkName, err := forkRepo(repo.NameWithOwner)
+	if err != nil {
+		return gitCloneMsg{err: err}
+	}
+	fork := &config.ForkInfo{Upstream: repo.NameWithOwner, Origin: forkName}
+	var msg tea.Msg
+	for attempt := 1; attempt <= forkCloneAttempts; attempt++ {
+		msg = cloneWithUpstream(start, githubCloneURL(start.gitURL, forkName), fork)
+		if res, ok := msg.(gitCloneMsg); !ok || res.err == nil {
+			break
+		}
+		if attempt < forkCloneAttempts {
+			time.Sleep(forkCloneDelay)
+		}
+	}
+	return msg
+}
+
+// cloneWithUpstream clones cloneURL and, when fork is set, adds an "upstream"
+// remote for fork.Upstream and records the relationship on the result.
+func cloneWithUpstream(start gitCloneStartMsg, cloneURL string, fork *config.ForkInfo) tea.Msg {
+	var msg tea.Msg
+	if start.remote != "" {
+		msg = cloneRemote(start.remote, cloneURL, start.clonePath, start.name, start.opts)
+	} else {
+		msg = cloneLocal(cloneURL, start.clonePath, start.name, start.opts)
+	}
+	res, ok := msg.(gitCloneMsg)
+	if !ok || res.err != nil || fork == nil {
+		return msg
+	}
+
+	res.fork = fork
+	upstreamURL := githubCloneURL(start.gitURL, fork.Upstream)
+	if err := addUpstreamRemote(start.remote, start.clonePath, upstreamURL); err != nil {
+		res.warning = fmt.Sprintf("could not add upstream remote: %v", err)
+	}
+	return res
+}
+
+// addUpstreamRemote adds (or repoints) the "upstream" remote of a clone,
+// locally or on a remote host.
+func addUpstreamRemote(remoteName, clonePath, upstreamURL string) error {
+	script := fmt.Sprintf("git -C %q remote add upstream %q 2>/dev/null || git -C %q remote set-url upstream %q",
+		clonePath, upstreamURL, clonePath, upstreamURL)
+	if remoteName != "" {
+		host, ok := config.GetRemote(remoteName)
+		if !ok {
+			return fmt.Errorf("remote '%s' not found", remoteName)
+		}
+		_, err := remote.RunSSH(host, script)
+		return err
+	}
+	if err := exec.Command("git", "-C", clonePath, "remote", "add", "upstream", upstreamURL).Run(); err != nil {
+		if out, err := exec.Command("git", "-C", clonePath, "remote", "set-url", "upstream", upstreamURL).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// updateForkPrompt handles y/n while the fork prompt is open.
+func (m Model) updateForkPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.forkPrompt
+	switch msg.String() {
+	case "y", "Y":
+		m.forkPrompt = nil
+		m.statusMsg = fmt.Sprintf("Forking %s...", p.repo.NameWithOwner)
+		return m, func() tea.Msg { return forkAndClone(p.start, p.repo) }
+	case "n", "N":
+		m.forkPrompt = nil
+		m.statusMsg = "Cloning..."
+		return m, func() tea.Msg { return cloneWithUpstream(p.start, p.start.gitURL, nil) }
+	case "esc":
+		m.forkPrompt = nil
+		m.statusMsg = ""
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// renderForkPrompt shows the fork question on the status line.
+func renderForkPrompt(p *forkPrompt) string {
+	return fmt.Sprintf("  You can't push to %s — fork it and clone your fork? (y = fork, n = clone read-only, esc = cancel)", p.repo.NameWithOwner)
+}
@@ -0,0 +1,245 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"codes/internal/chatsession"
+	"codes/internal/config"
+	"codes/internal/stats"
+)
+
+// Session history: H on a project lists its past Claude sessions, from
+// the stats scan of ~/.claude/projects and from codes' own chat session
+// transcripts, most recent first. Enter resumes the selected session with
+// 'claude --resume' in a new terminal.
+
+// historyEntry is one past session.
+type historyEntry struct {
+	claudeID string // what --resume takes; empty if Claude never started
+	chatID   string // codes chat session, if it ran as one
+	start    time.Time
+	end      time.Time
+	model    string
+	turns    int
+	costUSD  float64
+	summary  string
+}
+
+// historyLoadedMsg carries a project's past sessions.
+type historyLoadedMsg struct {
+	project string
+	entries []historyEntry
+	err     error
+}
+
+// loadHistoryCmd lists the past sessions of info; refresh forces a rescan
+// of Claude's session files instead of using the stats cache while fresh.
+func loadHistoryCmd(info config.ProjectInfo, refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		cache, err := stats.LoadCache()
+		if err == nil {
+			if refresh {
+				cache, err = stats.ForceRefresh(cache)
+			} else {
+				cache, err = stats.RefreshIfNeeded(cache)
+			}
+		}
+		if err != nil && cache == nil {
+			return historyLoadedMsg{project: info.Name, err: err}
+		}
+		return historyLoadedMsg{project: info.Name, entries: projectHistory(info, cache.Sessions)}
+	}
+}
+
+// projectHistory merges the project's Claude session records with its codes
+// chat sessions; a chat session whose Claude session was scanned adds its
+// ID to that entry.
+func projectHistory(info config.ProjectInfo, records []stats.SessionRecord) []historyEntry {
+	var entries []historyEntry
+	byClaudeID := make(map[string]int)
+	for _, r := range stats.ProjectSessions(records, info.Path) {
+		byClaudeID[r.SessionID] = len(entries)
+		entries = append(entries, historyEntry{
+			claudeID: r.SessionID,
+			start:    r.StartTime,
+			end:      r.EndTime,
+			model:    r.Model,
+			turns:    r.Turns,
+			costUSD:  r.CostUSD,
+			summary:  r.Summary,
+		})
+	}
+
+	saved, _ := chatsession.ListSaved()
+	for _, s := range saved {
+		if s.ProjectName != info.Name && filepath.Clean(s.ProjectPath) != filepath.Clean(info.Path) {
+			continue
+		}
+		if i, ok := byClaudeID[s.ClaudeSessionID]; ok && s.ClaudeSessionID != "" {
+			entries[i].chatID = s.ID
+			continue
+		}
+		entries = append(entries, historyEntry{
+			claudeID: s.ClaudeSessionID,
+			chatID:   s.ID,
+			start:    s.CreatedAt,
+			end:      s.LastActiveAt,
+			model:    s.Model,
+			turns:    s.TurnCount,
+			costUSD:  s.CostUSD,
+			summary:  chatFirstPrompt(s.ID),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].end.After(entries[j].end) })
+	return entries
+}
+
+// chatFirstPrompt returns the first message sent in a chat session.
+func chatFirstPrompt(id string) string {
+	_, transcript, err := chatsession.LoadTranscript(id)
+	if err != nil {
+		return ""
+	}
+	for _, e := range transcript {
+		for _, line := range chatsession.EventLines(e.Event) {
+			if text, ok := strings.CutPrefix(line, "you: "); ok {
+				return strings.Join(strings.Fields(text), " ")
+			}
+		}
+	}
+	return ""
+}
+
+// openHistory shows the history of the selected project.
+func (m Model) openHistory() (tea.Model, tea.Cmd) {
+	item, ok := m.projectList.SelectedItem().(projectItem)
+	if !ok {
+		return m, nil
+	}
+	if item.info.Remote != "" {
+		m.err = "session history is only available for local projects"
+		return m, nil
+	}
+	m.state = viewSessionHistory
+	m.historyProject = item.info
+	m.historyEntries = nil
+	m.historyCursor = 0
+	m.historyLoading = true
+	return m, loadHistoryCmd(item.info, false)
+}
+
+// updateSessionHistory handles key events in the history view.
+func (m Model) updateSessionHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "esc", "H":
+		m.state = viewProjects
+	case "j", "down":
+		if m.historyCursor < len(m.historyEntries)-1 {
+			m.historyCursor++
+		}
+	case "k", "up":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+	case "r":
+		m.historyLoading = true
+		return m, loadHistoryCmd(m.historyProject, true)
+	case "enter":
+		if m.historyCursor >= len(m.historyEntries) {
+			return m, nil
+		}
+		e := m.historyEntries[m.historyCursor]
+		if e.claudeID == "" {
+			m.err = "this session never reached Claude, so there is nothing to resume"
+			return m, nil
+		}
+		if !config.ClaudeAvailable() {
+			m.err = config.ErrClaudeNotFound.Error()
+			return m, nil
+		}
+		name, path := m.historyProject.Name, m.historyProject.Path
+		args, env := config.ClaudeCmdSpec()
+		args = append(args, "--resume", e.claudeID)
+		m.statusMsg = fmt.Sprintf("resuming %s...", e.claudeID)
+		return m, func() tea.Msg {
+			_, err := m.sessionMgr.StartSession(name, path, args, env)
+			return sessionStartedMsg{name: name, err: err}
+		}
+	}
+	return m, nil
+}
+
+// renderSessionHistory renders the history list and the selected session.
+func renderSessionHistory(project string, entries []historyEntry, loading bool, cursor, width, height int) string {
+	var b strings.Builder
+	b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  Session history: %s (%d)", project, len(entries))))
+	b.WriteString("\n\n")
+	if loading && len(entries) == 0 {
+		b.WriteString(statsDimStyle.Render("  Scanning sessions..."))
+		return b.String()
+	}
+	if len(entries) == 0 {
+		b.WriteString(statsDimStyle.Render("  No past sessions for this project."))
+		return b.String()
+	}
+
+	rows := max(1, height-8)
+	start := 0
+	if cursor >= rows {
+		start = cursor - rows + 1
+	}
+	end := min(len(entries), start+rows)
+	for i := start; i < end; i++ {
+		e := entries[i]
+		prefix := "  "
+		style := lipgloss.NewStyle()
+		if i == cursor {
+			prefix = "▸ "
+			style = style.Foreground(primaryColor).Bold(true)
+		}
+		source := "  "
+		if e.chatID != "" {
+			source = statsAccentStyle.Render("◆ ")
+		}
+		summary := e.summary
+		if summary == "" {
+			summary = "(no prompt)"
+		}
+		line := fmt.Sprintf("%s%s  %s  %s%s",
+			prefix,
+			statsDimStyle.Render(e.end.Local().Format("2006-01-02 15:04")),
+			statsAccentStyle.Render(fmt.Sprintf("$%7.4f", e.costUSD)),
+			source,
+			style.Render(summary))
+		b.WriteString(ansi.Truncate(line, width, "…") + "\n")
+	}
+
+	e := entries[min(cursor, len(entries)-1)]
+	b.WriteString("\n")
+	var details []string
+	if e.claudeID != "" {
+		details = append(details, "claude "+e.claudeID)
+	}
+	if e.chatID != "" {
+		details = append(details, "codes chat "+e.chatID)
+	}
+	if e.model != "" {
+		details = append(details, e.model)
+	}
+	details = append(details, fmt.Sprintf("%d turns", e.turns))
+	if !e.start.IsZero() && e.end.After(e.start) {
+		details = append(details, e.end.Sub(e.start).Round(time.Minute).String())
+	}
+	b.WriteString(statsDimStyle.Render("  " + strings.Join(details, " · ")))
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codes/internal/config"
+	"codes/internal/remote"
+)
+
+// hostKeyPrompt is a pending trust-on-first-use confirmation for a remote
+// whose host key is not yet in known_hosts.
+type hostKeyPrompt struct {
+	host    config.RemoteHost
+	unknown *remote.HostKeyUnknownError
+}
+
+// hostKeyTrustedMsg is sent after confirmed host keys are written to known_hosts.
+type hostKeyTrustedMsg struct {
+	name string
+	host config.RemoteHost
+	err  error
+}
+
+// hostKeyPromptFor returns a prompt if err reports an unknown host key.
+func hostKeyPromptFor(err error) *hostKeyPrompt {
+	var unknown *remote.HostKeyUnknownError
+	if !errors.As(err, &unknown) {
+		return nil
+	}
+	host, ok := config.GetRemote(unknown.Name)
+	if !ok {
+		return nil
+	}
+	return &hostKeyPrompt{host: *host, unknown: unknown}
+}
+
+// updateHostKeyPrompt handles y/n while a host key prompt is open. All other
+// keys are swallowed so nothing connects until the user decides.
+func (m Model) updateHostKeyPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		p := m.hostKeyPrompt
+		m.hostKeyPrompt = nil
+		m.statusMsg = fmt.Sprintf("trusting %s...", p.unknown.Address)
+		return m, func() tea.Msg {
+			err := remote.TrustHostKeys(&p.host, p.unknown.Keys)
+			return hostKeyTrustedMsg{name: p.host.Name, host: p.host, err: err}
+		}
+	case "n", "N", "esc":
+		name := m.hostKeyPrompt.host.Name
+		m.hostKeyPrompt = nil
+		m.err = fmt.Sprintf("remote %s: host key not trusted", name)
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// renderHostKeyPrompt shows the offered key fingerprint and the y/n choice.
+// Like ssh, a single fingerprint is shown, preferring ED25519.
+func renderHostKeyPrompt(p *hostKeyPrompt) string {
+	fp := ""
+	for _, f := range p.unknown.Fingerprints {
+		if fp == "" || strings.Contains(f, "(ED25519)") {
+			fp = f
+		}
+	}
+	return fmt.Sprintf("  Unknown host key for %s: %s — trust and add to known_hosts? (y/n)", p.unknown.Address, fp)
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"codes/internal/agent"
+	"codes/internal/chatsession"
+)
+
+// Live log pane: in the Task Queue, 'l' switches the right panel from the
+// selected item's details to the tail of its log, reread every second. For
+// a task that is its owner's daemon log, with the task's own lines
+// highlighted; for a chat session, the events recorded in its transcript.
+// The pane follows new lines until scrolled up, and 'f' follows again.
+
+const (
+	logPaneLines    = 500 // lines kept from the end of a log
+	logPaneInterval = time.Second
+)
+
+// logSource is what the log pane shows: a task's owner agent, or a chat
+// session.
+type logSource struct {
+	team, agent string
+	taskID      int
+	sessionID   string
+}
+
+// logLoadedMsg carries the lines read for a source.
+type logLoadedMsg struct {
+	src   logSource
+	lines []string
+	err   error
+}
+
+// logTickMsg rereads the log. gen tells apart the tick loops of successive
+// log panes, so only the latest one keeps running.
+type logTickMsg struct{ gen int }
+
+func logTick(gen int) tea.Cmd {
+	return tea.Tick(logPaneInterval, func(time.Time) tea.Msg { return logTickMsg{gen: gen} })
+}
+
+func loadLogCmd(src logSource) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := readLogLines(src)
+		return logLoadedMsg{src: src, lines: lines, err: err}
+	}
+}
+
+// readLogLines returns the last logPaneLines lines of a source's log.
+func readLogLines(src logSource) ([]string, error) {
+	if src.sessionID == "" {
+		if src.agent == "" {
+			return nil, nil
+		}
+		return agent.ReadAgentLog(src.team, src.agent, logPaneLines, "")
+	}
+	_, entries, err := chatsession.LoadTranscript(src.sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, e := range entries {
+		stamp := e.Time.Local().Format("15:04:05") + " "
+		for _, line := range chatsession.EventLines(e.Event) {
+			for _, l := range strings.Split(strings.TrimRight(line, "\n"), "\n") {
+				lines = append(lines, stamp+l)
+				stamp = "         "
+			}
+		}
+	}
+	if len(lines) > logPaneLines {
+		lines = lines[len(lines)-logPaneLines:]
+	}
+	return lines, nil
+}
+
+// selectedLogSource returns the log source of the item under the cursor.
+func (m Model) selectedLogSource() logSource {
+	task, sess := m.queueSelection()
+	switch {
+	case task != nil:
+		return logSource{team: m.taskTeams[queueKey(*task)], agent: task.Owner, taskID: task.ID}
+	case sess != nil:
+		return logSource{sessionID: sess.ID}
+	}
+	return logSource{}
+}
+
+// toggleTaskLog switches the right panel between details and the log pane.
+func (m Model) toggleTaskLog() (tea.Model, tea.Cmd) {
+	m.taskLogMode = !m.taskLogMode
+	if !m.taskLogMode {
+		return m, nil
+	}
+	m.taskLogGen++
+	m.taskLogFollow = true
+	m.taskLogLines, m.taskLogErr = nil, nil
+	m.taskLogSrc = m.selectedLogSource()
+	return m, tea.Batch(loadLogCmd(m.taskLogSrc), logTick(m.taskLogGen))
+}
+
+// reloadTaskLog rereads the log pane after the selection moved.
+func (m Model) reloadTaskLog() (Model, tea.Cmd) {
+	if !m.taskLogMode {
+		return m, nil
+	}
+	if src := m.selectedLogSource(); src != m.taskLogSrc {
+		m.taskLogSrc = src
+		m.taskLogFollow = true
+		m.taskLogLines, m.taskLogErr = nil, nil
+	}
+	return m, loadLogCmd(m.taskLogSrc)
+}
+
+// taskLogPane returns the renderer of the log pane, or nil outside log mode.
+func (m Model) taskLogPane() func(width, height int) string {
+	if !m.taskLogMode {
+		return nil
+	}
+	return func(width, height int) string {
+		title := "Log"
+		switch src := m.taskLogSrc; {
+		case src.sessionID != "":
+			title = "Chat session " + src.sessionID
+		case src.agent != "":
+			title = fmt.Sprintf("%s log · task %d", src.agent, src.taskID)
+		case src.taskID != 0:
+			title = fmt.Sprintf("Task %d has no owner yet", src.taskID)
+		}
+		return renderLogPane(title, m.taskLogLines, m.taskLogErr, taskLogHighlight(m.taskLogSrc), m.taskLogScroll, m.taskLogFollow, width, height)
+	}
+}
+
+// taskLogPageHeight is how many log lines the pane shows, matching the
+// height the Agent tab gives renderTaskQueueView.
+func (m Model) taskLogPageHeight() int {
+	return max(1, m.height-8-2-1)
+}
+
+// renderLogPane renders the tail of a log in height lines: the last ones
+// when following, otherwise from scroll.
+func renderLogPane(title string, lines []string, err error, highlight *regexp.Regexp, scroll int, follow bool, width, height int) string {
+	var b strings.Builder
+	state := statusOkStyle.Render("● following")
+	if !follow {
+		state = statsDimStyle.Render("paused (f to follow)")
+	}
+	b.WriteString(detailLabelStyle.Render(title) + "  " + state + "\n")
+
+	body := height - 1
+	switch {
+	case err != nil:
+		b.WriteString(statusErrorStyle.Render(err.Error()))
+		return b.String()
+	case len(lines) == 0:
+		b.WriteString(statsDimStyle.Render("Nothing logged yet."))
+		return b.String()
+	}
+	start := max(0, len(lines)-body)
+	if !follow {
+		start = min(scroll, start)
+	}
+	end := min(len(lines), start+body)
+	for i, line := range lines[start:end] {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		line = ansi.Truncate(line, width, "…")
+		if highlight != nil && highlight.MatchString(line) {
+			line = statsAccentStyle.Render(line)
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// taskLogHighlight matches a task's lines in its owner's log, such as
+// "executing task 7: ..." and "task 7 completed".
+func taskLogHighlight(src logSource) *regexp.Regexp {
+	if src.taskID == 0 {
+		return nil
+	}
+	return regexp.MustCompile(fmt.Sprintf(`\btask %d\b`, src.taskID))
+}
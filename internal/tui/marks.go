@@ -0,0 +1,229 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codes/internal/agent"
+	"codes/internal/config"
+	"codes/internal/session"
+)
+
+// Multi-select: space marks projects in the Projects list, running sessions
+// in a project's detail panel and tasks in the Tasks sub-tab. While any are
+// marked, the list's actions apply to all of them: d removes the marked
+// projects, x kills their sessions and enter starts a session in each; x in
+// the detail panel kills the marked sessions; c cancels the marked tasks.
+// esc clears the marks. Only marked items still shown are acted on.
+
+// markSymbol is shown before marked items.
+const markSymbol = "✓ "
+
+// toggleMark marks key, or unmarks it when marked.
+func toggleMark[K comparable](marks map[K]bool, key K) {
+	if marks[key] {
+		delete(marks, key)
+	} else {
+		marks[key] = true
+	}
+}
+
+// markDelegate renders the project list, prefixing marked projects.
+type markDelegate struct {
+	list.DefaultDelegate
+	marks map[string]bool
+}
+
+func (d markDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if p, ok := item.(projectItem); ok && d.marks[p.info.Name] {
+		item = markedProjectItem{p}
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
+type markedProjectItem struct{ projectItem }
+
+func (i markedProjectItem) Title() string { return markSymbol + i.projectItem.Title() }
+
+// markedProjects returns the marked projects shown in the list.
+func (m Model) markedProjects() []config.ProjectInfo {
+	var marked []config.ProjectInfo
+	for _, item := range m.projectList.Items() {
+		if p, ok := item.(projectItem); ok && m.projectMarks[p.info.Name] {
+			marked = append(marked, p.info)
+		}
+	}
+	return marked
+}
+
+// toggleProjectMark marks the selected project and moves to the next one.
+func (m Model) toggleProjectMark() (tea.Model, tea.Cmd) {
+	if item, ok := m.projectList.SelectedItem().(projectItem); ok {
+		toggleMark(m.projectMarks, item.info.Name)
+		m.projectList.CursorDown()
+	}
+	return m, nil
+}
+
+// removeMarkedProjects asks, then removes the marked projects; u restores
+// them all.
+func (m Model) removeMarkedProjects(marked []config.ProjectInfo) (tea.Model, tea.Cmd) {
+	names := make([]string, len(marked))
+	for i, p := range marked {
+		names[i] = p.Name
+	}
+	return m.confirm(fmt.Sprintf("Remove %d marked projects from codes? Their files are kept.", len(names)), func(m Model) (tea.Model, tea.Cmd) {
+		return m, removeProjectsCmd(names...)
+	})
+}
+
+// killMarkedProjects asks, then kills the sessions of the marked projects.
+func (m Model) killMarkedProjects(marked []config.ProjectInfo) (tea.Model, tea.Cmd) {
+	running := 0
+	for _, p := range marked {
+		running += len(m.sessionMgr.GetRunningByProject(p.Name))
+	}
+	if running == 0 {
+		m.statusMsg = "no sessions running in the marked projects"
+		return m, nil
+	}
+	return m.confirm(fmt.Sprintf("Kill %d running session(s) of %d marked projects?", running, len(marked)), func(m Model) (tea.Model, tea.Cmd) {
+		for _, p := range marked {
+			m.sessionMgr.KillByProject(p.Name)
+		}
+		clear(m.projectMarks)
+		m.statusMsg = fmt.Sprintf("killed %d session(s)", running)
+		return m, nil
+	})
+}
+
+// startMarkedProjects starts a session in each marked project, skipping
+// those whose directory is missing.
+func (m Model) startMarkedProjects(marked []config.ProjectInfo) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for _, p := range marked {
+		if !p.Exists {
+			continue
+		}
+		cmd, err := m.startSessionCmd(p)
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		cmds = append(cmds, cmd)
+	}
+	clear(m.projectMarks)
+	m.statusMsg = fmt.Sprintf("starting %d session(s)", len(cmds))
+	return m, tea.Batch(cmds...)
+}
+
+// markedSessions returns the marked sessions among running.
+func markedSessions(running []*session.Session, marks map[string]bool) []*session.Session {
+	var marked []*session.Session
+	for _, s := range running {
+		if marks[s.ID] {
+			marked = append(marked, s)
+		}
+	}
+	return marked
+}
+
+// killMarkedSessions asks, then kills the marked sessions of project.
+func (m Model) killMarkedSessions(project string, marked []*session.Session) (tea.Model, tea.Cmd) {
+	return m.confirm(fmt.Sprintf("Kill %d marked session(s) of %s?", len(marked), project), func(m Model) (tea.Model, tea.Cmd) {
+		for _, s := range marked {
+			m.sessionMgr.KillSession(s.ID)
+		}
+		clear(m.sessionMarks)
+		m.sessionCursor = 0
+		if len(m.sessionMgr.GetRunningByProject(project)) == 0 {
+			m.focus = focusLeft
+		}
+		return m, nil
+	})
+}
+
+// tasksCancelledMsg is sent after a batch cancel.
+type tasksCancelledMsg struct {
+	cancelled int
+	err       error // the first failure
+}
+
+// cancelTarget is a task to cancel and its team.
+type cancelTarget struct {
+	team string
+	id   int
+}
+
+// toggleTaskMark marks the selected task and moves to the next item.
+func (m Model) toggleTaskMark() (tea.Model, tea.Cmd) {
+	task, _ := m.queueSelection()
+	if task == nil {
+		return m, nil
+	}
+	toggleMark(m.taskMarks, queueKey(*task))
+	if m.taskQueueCursor < m.queueItemCount()-1 {
+		m.taskQueueCursor++
+		m.taskDetailScroll = 0
+		return m.reloadTaskLog()
+	}
+	return m, nil
+}
+
+// cancelQueueTasks asks, then cancels the marked tasks, or the selected one
+// when none are marked. Finished tasks are left alone.
+func (m Model) cancelQueueTasks() (tea.Model, tea.Cmd) {
+	var tasks []agent.Task
+	for _, t := range taskQueueOrder(m.visibleQueueTasks()) {
+		if m.taskMarks[queueKey(t)] {
+			tasks = append(tasks, t)
+		}
+	}
+	if len(tasks) == 0 {
+		if task, _ := m.queueSelection(); task != nil {
+			tasks = append(tasks, *task)
+		}
+	}
+	var targets []cancelTarget
+	for _, t := range tasks {
+		switch t.Status {
+		case agent.TaskPending, agent.TaskAssigned, agent.TaskRunning:
+			targets = append(targets, cancelTarget{team: m.taskTeams[queueKey(t)], id: t.ID})
+		}
+	}
+	if len(targets) == 0 {
+		m.statusMsg = "nothing to cancel"
+		return m, nil
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].team != targets[j].team {
+			return targets[i].team < targets[j].team
+		}
+		return targets[i].id < targets[j].id
+	})
+
+	question := fmt.Sprintf("Cancel task #%d in %s?", targets[0].id, targets[0].team)
+	if len(targets) > 1 {
+		question = fmt.Sprintf("Cancel %d tasks?", len(targets))
+	}
+	return m.confirm(question, func(m Model) (tea.Model, tea.Cmd) {
+		clear(m.taskMarks)
+		return m, func() tea.Msg {
+			msg := tasksCancelledMsg{}
+			for _, t := range targets {
+				if _, err := agent.CancelTask(t.team, t.id); err != nil {
+					if msg.err == nil {
+						msg.err = fmt.Errorf("task #%d in %s: %w", t.id, t.team, err)
+					}
+					continue
+				}
+				msg.cancelled++
+			}
+			return msg
+		}
+	})
+}
@@ -14,7 +14,10 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"codes/internal/agent"
+	"codes/internal/assistant/scheduler"
+	"codes/internal/chatsession"
 	"codes/internal/config"
+	"codes/internal/maintenance"
 	"codes/internal/remote"
 	"codes/internal/session"
 	"codes/internal/stats"
@@ -35,6 +38,9 @@ const (
 	viewSessionSummary
 	viewPartialRollback
 	viewLinkForm
+	viewEditProfile
+	viewSessionHistory
+	viewAddTask
 )
 
 // Sub-tab types for Config and Agent views
@@ -50,6 +56,8 @@ const (
 const (
 	agentTasks agentSubTab = iota
 	agentWorkflows
+	agentTeams
+	agentSchedules
 )
 
 type panelFocus int
@@ -70,6 +78,8 @@ type Model struct {
 	remoteList    list.Model
 	addForm       addFormModel
 	profileForm   profileFormModel
+	taskForm      taskFormModel
+	profileEdit   profileEditModel
 	remoteForm    remoteFormModel
 	linkForm      linkFormModel
 	help          help.Model
@@ -78,10 +88,18 @@ type Model struct {
 	height        int
 	err           string
 	statusMsg     string // non-error status/loading message
+	projectFilter string // "", projectFilterFavorites or a tag; cycled with '#'
 	sessionMgr    *session.Manager
 	sessionCursor int // cursor index within right-panel session list
 	settings      settingsModel
 	remoteStatus  map[string]*remote.RemoteStatus
+	hostKeyPrompt *hostKeyPrompt // pending host key confirmation, if any
+	forkPrompt    *forkPrompt    // pending fork confirmation, if any
+	worktreePrompt *worktreePrompt // pending branch name for a new worktree, if any
+	confirmPrompt  *confirmPrompt  // pending confirmation of a delete or kill, if any
+	projectUndo    *projectUndo    // last removed projects while they can be restored
+	projectMarks   map[string]bool // marked projects; shared with the list's delegate
+	sessionMarks   map[string]bool // marked session IDs in the detail panel
 	version       string // 当前版本
 	latestVersion string // 缓存的最新版本（空 = 未知或已是最新）
 	// Stats tab
@@ -93,8 +111,23 @@ type Model struct {
 	// Task Queue
 	taskQueueTeams   []string
 	taskQueueTasks   []agent.Task
+	taskTeams        map[queueTaskKey]string // team of each task
+	taskMarks        map[queueTaskKey]bool   // marked tasks
+	chatSessions     []chatsession.SessionInfo
 	taskQueueCursor  int
+	taskDetailScroll int // lines scrolled in the task detail pane
+	taskQueueMsgs    []queueMessage // searched along with the tasks
+	taskSearchActive bool           // typing a search query
+	taskSearchQuery  string
 	taskQueueLoading bool
+	// Task Queue log pane
+	taskLogMode   bool
+	taskLogGen    int // current tick loop
+	taskLogSrc    logSource
+	taskLogLines  []string
+	taskLogErr    error
+	taskLogFollow bool
+	taskLogScroll int
 	// Checkpoint
 	checkpoint      *session.Checkpoint
 	diffSummary     *session.DiffSummary
@@ -104,13 +137,47 @@ type Model struct {
 	workflowList   []workflow.Workflow
 	workflowRun    *workflow.WorkflowRunResult
 	workflowCursor int
+	// Session history
+	historyProject config.ProjectInfo
+	historyEntries []historyEntry
+	historyCursor  int
+	historyLoading bool
+	// Schedules tab
+	schedules      []*scheduler.Schedule
+	scheduleCursor int
+	// Teams tab
+	teamChatTargets []chatTarget
+	teamChatCursor  int
+	teamThread      []*agent.Message
+	teamThreadErr   error
+	teamDraft       string
+	teamComposing   bool // typing a message
+	teamChatGen     int  // current reread loop
+	teamActivity    *teamActivityPane // timeline shown instead of the conversation, if any
+	// Toasts
+	toastWatch       toastWatch
+	toasts           []toast // history, newest last
+	toastHistoryOpen bool
+	toastScroll      int
+	// Palette
+	paletteOpen    bool
+	paletteLoading bool
+	paletteQuery   string
+	paletteCursor  int
+	paletteItems   []paletteItem
+	paletteQueue   taskQueueLoadedMsg // tasks and sessions the entries point into
+	paletteTeams   teamsLoadedMsg
 	// Projects tab search
 	searchActive bool
 	searchQuery  string
 }
 
-// projectDeletedMsg is sent after deleting a project.
-type projectDeletedMsg struct{ name string }
+// projectFavoriteMsg is sent after pinning or unpinning a project.
+type projectFavoriteMsg struct {
+	name     string
+	favorite bool
+	err      error
+}
 
 // projectScanMsg is sent after scanning for Claude projects.
 type projectScanMsg struct {
@@ -180,6 +247,25 @@ type remoteSetupMsg struct {
 	err    error
 }
 
+// remoteSetupProgressMsg reports an install stage while a remote setup runs.
+// ch carries the remaining progress messages and the final remoteSetupMsg.
+type remoteSetupProgressMsg struct {
+	name  string
+	stage string
+	ch    <-chan tea.Msg
+}
+
+// waitForRemoteSetup returns a command that delivers the next message from a running setup.
+func waitForRemoteSetup(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
 // remoteStatusTickMsg triggers periodic remote status refresh.
 type remoteStatusTickMsg struct{}
 
@@ -208,10 +294,11 @@ func remoteStatusTick() tea.Cmd {
 // NewModel creates the initial TUI model.
 func NewModel(version string) Model {
 	// Load projects
-	projectItems := loadProjects()
+	projectItems := loadProjects("")
+	projectMarks := make(map[string]bool)
 	projectDelegate := newStyledDelegate()
 	projectDelegate.ShowDescription = true
-	pl := list.New(projectItems, projectDelegate, 0, 0)
+	pl := list.New(projectItems, markDelegate{DefaultDelegate: projectDelegate, marks: projectMarks}, 0, 0)
 	pl.SetShowTitle(false)
 	pl.SetShowHelp(false)
 	pl.SetShowStatusBar(false)
@@ -239,9 +326,19 @@ func NewModel(version string) Model {
 
 	cfg, _ := config.LoadConfig()
 
+	// Mention what the last maintenance run did, once
+	var statusMsg string
+	if r := maintenance.TakeUnshownReport(); r != nil {
+		statusMsg = fmt.Sprintf("maintenance %s: %s", r.RanAt.Local().Format("Jan 2 15:04"), r.Summary())
+	}
+
 	return Model{
+		statusMsg:    statusMsg,
 		state:        viewProjects,
 		projectList:  pl,
+		projectMarks: projectMarks,
+		sessionMarks: make(map[string]bool),
+		taskMarks:    make(map[queueTaskKey]bool),
 		profileList:  cl,
 		remoteList:   rl,
 		help:         help.New(),
@@ -254,7 +351,7 @@ func NewModel(version string) Model {
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(sessionTick(), remoteStatusTick(), m.checkUpdate())
+	return tea.Batch(sessionTick(), remoteStatusTick(), scanToastsCmd(toastWatch{}), m.checkUpdate())
 }
 
 func (m Model) checkUpdate() tea.Cmd {
@@ -299,6 +396,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.hostKeyPrompt != nil {
+			return m.updateHostKeyPrompt(msg)
+		}
+		if m.forkPrompt != nil {
+			return m.updateForkPrompt(msg)
+		}
+		if m.worktreePrompt != nil {
+			return m.updateWorktreePrompt(msg)
+		}
+		if m.confirmPrompt != nil {
+			return m.updateConfirmPrompt(msg)
+		}
 		// Global keys (not when filtering or in form)
 		if m.state == viewAddForm {
 			return m.updateAddForm(msg)
@@ -306,12 +415,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.state == viewAddProfile {
 			return m.updateProfileForm(msg)
 		}
+		if m.state == viewEditProfile {
+			return m.updateProfileEdit(msg)
+		}
 		if m.state == viewAddRemote {
 			return m.updateRemoteForm(msg)
 		}
 		if m.state == viewLinkForm {
 			return m.updateLinkForm(msg)
 		}
+		if m.state == viewAddTask {
+			return m.updateTaskForm(msg)
+		}
+		if m.paletteOpen {
+			return m.updatePalette(msg)
+		}
+		if msg.String() == "ctrl+p" {
+			return m.openPalette()
+		}
+		if m.toastHistoryOpen {
+			return m.updateToastHistory(msg)
+		}
+		if msg.String() == "ctrl+n" {
+			m.toastHistoryOpen = true
+			m.toastScroll = 0
+			return m, nil
+		}
 		if m.state == viewConfig && m.configSubTab == configSettings {
 			if msg.String() != "tab" && msg.String() != "1" && msg.String() != "2" && msg.String() != "3" && msg.String() != "left" && msg.String() != "right" {
 				return m.updateSettings(msg)
@@ -322,12 +451,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m.updateStats(msg)
 			}
 		}
+		if m.state == viewAgent && m.agentSubTab == agentTasks && m.taskSearchActive {
+			return m.updateTaskSearch(msg)
+		}
+		if m.state == viewAgent && m.agentSubTab == agentTeams && m.teamComposing {
+			return m.updateTeamCompose(msg)
+		}
 		if m.state == viewAgent {
-			if msg.String() != "tab" && msg.String() != "1" && msg.String() != "2" && msg.String() != "left" && msg.String() != "right" {
+			if msg.String() != "tab" && msg.String() != "1" && msg.String() != "2" && msg.String() != "3" && msg.String() != "4" && msg.String() != "left" && msg.String() != "right" {
 				if m.agentSubTab == agentTasks {
 					return m.updateTaskQueue(msg)
 				} else if m.agentSubTab == agentWorkflows {
 					return m.updateWorkflows(msg)
+				} else if m.agentSubTab == agentTeams {
+					return m.updateTeamChat(msg)
+				} else if m.agentSubTab == agentSchedules {
+					return m.updateSchedules(msg)
 				}
 			}
 		}
@@ -337,6 +476,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.state == viewPartialRollback {
 			return m.updatePartialRollback(msg)
 		}
+		if m.state == viewSessionHistory {
+			return m.updateSessionHistory(msg)
+		}
 
 		// Handle custom search mode for Projects tab
 		if m.state == viewProjects && m.searchActive && msg.String() != "tab" {
@@ -345,7 +487,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.state == viewProjects && m.searchActive { // tab pressed during search
 			m.searchActive = false
 			m.searchQuery = ""
-			m.projectList.SetItems(loadProjects())
+			m.projectList.SetItems(loadProjects(m.projectFilter))
 		}
 
 		// Right panel focused — handle session selection
@@ -421,7 +563,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		// Sub-tab navigation for Agent view
-		case m.state == viewAgent && (msg.String() == "1" || msg.String() == "2" || msg.String() == "left" || msg.String() == "right"):
+		case m.state == viewAgent && (msg.String() == "1" || msg.String() == "2" || msg.String() == "3" || msg.String() == "4" || msg.String() == "left" || msg.String() == "right"):
 			if msg.String() == "1" {
 				m.agentSubTab = agentTasks
 			} else if msg.String() == "2" {
@@ -429,26 +571,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if len(m.workflowList) == 0 {
 					return m, loadWorkflowsCmd()
 				}
+			} else if msg.String() == "3" {
+				m.agentSubTab = agentTeams
+				return m.openTeamChat()
+			} else if msg.String() == "4" {
+				m.agentSubTab = agentSchedules
+				return m, loadSchedulesCmd()
 			} else if msg.String() == "left" {
 				if m.agentSubTab > 0 {
 					m.agentSubTab--
 				}
 			} else if msg.String() == "right" {
-				if m.agentSubTab < agentWorkflows {
+				if m.agentSubTab < agentSchedules {
 					m.agentSubTab++
 					if m.agentSubTab == agentWorkflows && len(m.workflowList) == 0 {
 						return m, loadWorkflowsCmd()
 					}
+					if m.agentSubTab == agentTeams {
+						return m.openTeamChat()
+					}
+					if m.agentSubTab == agentSchedules {
+						return m, loadSchedulesCmd()
+					}
 				}
 			}
 			return m, nil
 
 		case msg.String() == "right":
 			if m.state == viewProjects && m.focus == focusLeft {
-				// Only activate right panel if there are running sessions
+				// Only activate right panel if there are running sessions or worktrees
 				if item, ok := m.projectList.SelectedItem().(projectItem); ok {
 					running := m.sessionMgr.GetRunningByProject(item.info.Name)
-					if len(running) > 0 {
+					if len(running) > 0 || hasWorktrees(item.info) {
 						m.focus = focusRight
 						m.sessionCursor = 0
 						return m, nil
@@ -469,6 +623,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+		case msg.String() == "H" && m.state == viewProjects:
+			return m.openHistory()
+
 		case msg.String() == "a" && m.state == viewProjects:
 			m.state = viewAddForm
 			m.addForm = newAddForm()
@@ -479,6 +636,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.profileForm = newProfileForm()
 			return m, nil
 
+		case msg.String() == "e" && m.state == viewConfig && m.configSubTab == configProfiles:
+			if item, ok := m.profileList.SelectedItem().(profileItem); ok {
+				m.state = viewEditProfile
+				m.profileEdit = newProfileEdit(item.cfg)
+				return m, nil
+			}
+
 		case msg.String() == "a" && m.state == viewConfig && m.configSubTab == configRemotes:
 			m.state = viewAddRemote
 			m.remoteForm = newRemoteForm()
@@ -487,10 +651,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case msg.String() == "d" && m.state == viewConfig && m.configSubTab == configRemotes:
 			if item, ok := m.remoteList.SelectedItem().(remoteItem); ok {
 				name := item.host.Name
-				return m, func() tea.Msg {
-					config.RemoveRemote(name)
-					return remoteDeletedMsg{name: name}
-				}
+				return m.confirm(fmt.Sprintf("Delete remote %s?", name), func(m Model) (tea.Model, tea.Cmd) {
+					return m, func() tea.Msg {
+						config.RemoveRemote(name)
+						return remoteDeletedMsg{name: name}
+					}
+				})
 			}
 
 		case msg.String() == "t" && m.state == viewConfig && m.configSubTab == configRemotes:
@@ -527,34 +693,82 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				name := item.host.Name
 				host := item.host
 				m.statusMsg = fmt.Sprintf("setting up %s...", name)
-				return m, func() tea.Msg {
+				ch := make(chan tea.Msg, 8)
+				go func() {
+					defer close(ch)
+					progress := func(stage string) {
+						ch <- remoteSetupProgressMsg{name: name, stage: stage, ch: ch}
+					}
 					// Step 1: Install codes
-					if _, err := remote.InstallOnRemote(&host); err != nil {
-						return remoteSetupMsg{name: name, err: err}
+					if _, err := remote.InstallOnRemoteWithOptions(&host, remote.InstallOptions{Progress: progress}); err != nil {
+						ch <- remoteSetupMsg{name: name, err: err}
+						return
 					}
 					// Step 2: Install Claude CLI (non-fatal)
+					progress("installing Claude CLI")
 					remote.InstallClaudeOnRemote(&host)
 					// Step 3: Sync profiles
+					progress("syncing profiles")
 					if err := remote.SyncProfiles(&host); err != nil {
-						return remoteSetupMsg{name: name, err: err}
+						ch <- remoteSetupMsg{name: name, err: err}
+						return
 					}
 					// Auto-refresh status after setup
 					status, _ := remote.CheckRemoteStatus(&host)
-					return remoteSetupMsg{name: name, status: status}
-				}
+					ch <- remoteSetupMsg{name: name, status: status}
+				}()
+				return m, waitForRemoteSetup(ch)
 			}
 
+		case msg.String() == " " && m.state == viewProjects:
+			return m.toggleProjectMark()
+
+		case msg.String() == "esc" && m.state == viewProjects && len(m.projectMarks) > 0:
+			clear(m.projectMarks)
+			return m, nil
+
 		case msg.String() == "d" && m.state == viewProjects:
+			if marked := m.markedProjects(); len(marked) > 0 {
+				return m.removeMarkedProjects(marked)
+			}
 			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
+				name := item.info.Name
+				return m.confirm(fmt.Sprintf("Remove project %s from codes? Its files are kept.", name), func(m Model) (tea.Model, tea.Cmd) {
+					return m, removeProjectsCmd(name)
+				})
+			}
+
+		case msg.String() == "u" && m.state == viewProjects && m.projectUndo != nil:
+			u := *m.projectUndo
+			m.projectUndo = nil
+			return m, restoreProjectsCmd(u)
+
+		case msg.String() == "*" && m.state == viewProjects:
+			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
+				name, favorite := item.info.Name, !item.info.Favorite
 				return m, func() tea.Msg {
-					config.RemoveProject(item.info.Name)
-					return projectDeletedMsg{name: item.info.Name}
+					return projectFavoriteMsg{name: name, favorite: favorite, err: config.SetProjectFavorite(name, favorite)}
 				}
 			}
 
+		case msg.String() == "#" && m.state == viewProjects:
+			m.projectFilter = nextProjectFilter(m.projectFilter)
+			m.projectList.SetItems(loadProjects(m.projectFilter))
+			m.projectList.ResetSelected()
+			return m, nil
+
 		case msg.String() == "x" && m.state == viewProjects:
+			if marked := m.markedProjects(); len(marked) > 0 {
+				return m.killMarkedProjects(marked)
+			}
 			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
-				m.sessionMgr.KillByProject(item.info.Name)
+				name := item.info.Name
+				if n := len(m.sessionMgr.GetRunningByProject(name)); n > 0 {
+					return m.confirm(fmt.Sprintf("Kill %d running session(s) of %s?", n, name), func(m Model) (tea.Model, tea.Cmd) {
+						m.sessionMgr.KillByProject(name)
+						return m, nil
+					})
+				}
 			}
 			return m, nil
 
@@ -676,6 +890,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				// Local project → inline claude session
+				if !config.ClaudeAvailable() {
+					m.err = config.ErrClaudeNotFound.Error()
+					return m, nil
+				}
 				cmd := config.BuildClaudeCmd(path)
 				return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
 					return inlineSessionFinishedMsg{name: name, err: err}
@@ -684,43 +902,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case msg.String() == "enter":
 			if m.state == viewProjects {
+				if marked := m.markedProjects(); len(marked) > 0 {
+					return m.startMarkedProjects(marked)
+				}
 				if item, ok := m.projectList.SelectedItem().(projectItem); ok {
 					if !item.info.Exists {
 						return m, nil
 					}
-					name := item.info.Name
-					path := item.info.Path
-
-					// Remote project → SSH session in new terminal
-					if item.info.Remote != "" {
-						host, ok := config.GetRemote(item.info.Remote)
-						if !ok {
-							m.err = fmt.Sprintf("remote '%s' not found", item.info.Remote)
-							return m, nil
-						}
-						return m, func() tea.Msg {
-							_, err := m.sessionMgr.StartRemoteSession(name, host, path)
-							return sessionStartedMsg{name: name, err: err}
-						}
-					}
-
-					// Local project → session in new terminal
-					args, env := config.ClaudeCmdSpec()
-					args = append(args, config.LinkedContextArgs(name)...)
-					return m, func() tea.Msg {
-						_, err := m.sessionMgr.StartSession(name, path, args, env)
-						return sessionStartedMsg{name: name, err: err}
+					cmd, err := m.startSessionCmd(item.info)
+					if err != nil {
+						m.err = err.Error()
+						return m, nil
 					}
+					return m, cmd
 				}
 			} else if m.state == viewConfig && m.configSubTab == configProfiles {
 				if item, ok := m.profileList.SelectedItem().(profileItem); ok {
 					profileName := item.cfg.Name
 					return m, func() tea.Msg {
-						cfg, err := config.LoadConfig()
-						if err == nil {
-							cfg.Default = profileName
-							config.SaveConfig(cfg)
-						}
+						config.SetDefaultProfile(profileName)
 						return profileSwitchedMsg{name: profileName}
 					}
 				}
@@ -745,6 +945,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = viewProjects
 		return m, nil
 
+	case historyLoadedMsg:
+		if msg.project != m.historyProject.Name {
+			return m, nil
+		}
+		m.historyLoading = false
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		m.historyEntries = msg.entries
+		m.historyCursor = min(m.historyCursor, max(0, len(m.historyEntries)-1))
+		return m, nil
+
 	case editorOpenedMsg:
 		if msg.err != nil {
 			m.err = msg.err.Error()
@@ -768,10 +981,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case projectAddedMsg:
 		config.AddProjectEntry(msg.name, config.ProjectEntry{Path: msg.path, Remote: msg.remote})
 		m.state = viewProjects
-		m.projectList.SetItems(loadProjects())
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 		m.err = ""
 		return m, nil
 
+	case projectFavoriteMsg:
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		m.projectList.SetItems(loadProjects(m.projectFilter))
+		if msg.favorite {
+			m.statusMsg = fmt.Sprintf("★ %s pinned", msg.name)
+		} else {
+			m.statusMsg = fmt.Sprintf("%s unpinned", msg.name)
+		}
+		return m, nil
+
 	case projectLinkedMsg:
 		if msg.err != nil {
 			m.linkForm.err = msg.err.Error()
@@ -785,7 +1011,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.cfg = cfg
 		m.state = viewProjects
-		m.projectList.SetItems(loadProjects())
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 		m.statusMsg = fmt.Sprintf("Linked %s → %s", msg.projectName, msg.linkedName)
 		return m, nil
 
@@ -802,7 +1028,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.cfg = cfg
 		m.state = viewProjects
-		m.projectList.SetItems(loadProjects())
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 		m.statusMsg = fmt.Sprintf("Unlinked %s ⨯ %s", msg.projectName, msg.linkedName)
 		return m, nil
 
@@ -843,6 +1069,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return pathSuggestionsMsg{suggestions: suggestions, forPath: path}
 		}
 
+	case remoteDirMsg:
+		if m.state == viewAddForm {
+			m.addForm.setRemoteDir(msg)
+		}
+		return m, nil
+
 	case pathSuggestionsMsg:
 		if m.state == viewAddForm && msg.forPath == m.addForm.pathInput.Value() {
 			m.addForm.suggestions = msg.suggestions
@@ -852,19 +1084,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case gitCloneStartMsg:
-		m.statusMsg = "Cloning..."
+		m.statusMsg = "Checking repository..."
 		m.state = viewProjects
-		gitURL := msg.gitURL
-		clonePath := msg.clonePath
-		name := msg.name
-		remoteName := msg.remote
+		start := msg
+		return m, func() tea.Msg { return checkFork(start) }
 
-		return m, func() tea.Msg {
-			if remoteName != "" {
-				return cloneRemote(remoteName, gitURL, clonePath, name)
-			}
-			return cloneLocal(gitURL, clonePath, name)
+	case forkCheckMsg:
+		start := msg.start
+		repo := msg.repo
+		switch {
+		case repo != nil && !repo.canPush():
+			// Read-only repo: offer to fork before cloning
+			m.statusMsg = ""
+			m.forkPrompt = &forkPrompt{start: start, repo: repo}
+			return m, nil
+		case repo != nil && repo.parentName() != "":
+			// Cloning an existing fork: track its parent as upstream
+			m.statusMsg = "Cloning..."
+			fork := &config.ForkInfo{Upstream: repo.parentName(), Origin: repo.NameWithOwner}
+			return m, func() tea.Msg { return cloneWithUpstream(start, start.gitURL, fork) }
 		}
+		m.statusMsg = "Cloning..."
+		return m, func() tea.Msg { return cloneWithUpstream(start, start.gitURL, nil) }
 
 	case gitCloneMsg:
 		m.statusMsg = ""
@@ -873,14 +1114,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		// Auto-add the cloned repo as a project
-		config.AddProjectEntry(msg.name, config.ProjectEntry{Path: msg.path, Remote: msg.remote})
-		m.projectList.SetItems(loadProjects())
+		config.AddProjectEntry(msg.name, config.ProjectEntry{Path: msg.path, Remote: msg.remote, Fork: msg.fork})
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 		m.err = ""
 		m.statusMsg = fmt.Sprintf("✓ cloned and added %s", msg.name)
+		if msg.fork != nil {
+			m.statusMsg += fmt.Sprintf(" (fork of %s)", msg.fork.Upstream)
+		}
+		if msg.warning != "" {
+			m.statusMsg += " — " + msg.warning
+		}
 		return m, nil
 
-	case projectDeletedMsg:
-		m.projectList.SetItems(loadProjects())
+	case projectRemovedMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("remove: %v", msg.err)
+			return m, nil
+		}
+		for name := range msg.entries {
+			delete(m.projectMarks, name)
+		}
+		m.projectList.SetItems(loadProjects(m.projectFilter))
+		m.projectUndo = &projectUndo{entries: msg.entries, at: time.Now()}
+		m.statusMsg = fmt.Sprintf("removed %s (u to undo)", describeProjects(projectNames(msg.entries)))
+		return m, undoExpireCmd(m.projectUndo.at)
+
+	case projectRestoredMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("undo: %v", msg.err)
+		}
+		if len(msg.names) == 0 {
+			return m, nil
+		}
+		m.projectList.SetItems(loadProjects(m.projectFilter))
+		selectListItem(&m.projectList, func(item list.Item) bool {
+			p, ok := item.(projectItem)
+			return ok && p.info.Name == msg.names[0]
+		})
+		m.statusMsg = fmt.Sprintf("restored %s", describeProjects(msg.names))
+		return m, nil
+
+	case undoExpiredMsg:
+		if m.projectUndo != nil && m.projectUndo.at.Equal(msg.at) {
+			m.projectUndo = nil
+			if strings.HasSuffix(m.statusMsg, "(u to undo)") {
+				m.statusMsg = ""
+			}
+		}
 		return m, nil
 
 	case projectScanMsg:
@@ -888,7 +1168,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			m.err = fmt.Sprintf("scan failed: %v", msg.err)
 		} else if msg.added > 0 {
-			m.projectList.SetItems(loadProjects())
+			m.projectList.SetItems(loadProjects(m.projectFilter))
 			m.statusMsg = fmt.Sprintf("✓ imported %d project(s), skipped %d", msg.added, msg.skipped)
 		} else {
 			m.statusMsg = fmt.Sprintf("all %d project(s) already configured", msg.skipped)
@@ -912,26 +1192,113 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.taskQueueTeams = msg.teams
 			m.taskQueueTasks = msg.tasks
+			m.taskTeams = msg.taskTeams
+			for key := range m.taskMarks {
+				if _, ok := msg.taskTeams[key]; !ok {
+					delete(m.taskMarks, key)
+				}
+			}
+			m.taskQueueMsgs = msg.messages
+			m.chatSessions = msg.sessions
 			m.taskQueueCursor = 0
+			m.taskDetailScroll = 0
+		}
+		return m.reloadTaskLog()
+
+	case tasksCancelledMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("cancel: %v", msg.err)
+		}
+		if msg.cancelled > 0 {
+			m.statusMsg = fmt.Sprintf("cancelled %d task(s)", msg.cancelled)
+		}
+		m.taskQueueLoading = true
+		return m, loadTaskQueueCmd()
+
+	case taskCreatedMsg:
+		if msg.err != nil {
+			m.taskForm.creating = false
+			m.taskForm.err = msg.err.Error()
+			return m, nil
+		}
+		m.statusMsg = fmt.Sprintf("created task #%d in %s", msg.task.ID, msg.team)
+		m.taskQueueLoading = true
+		model, cmd := m.closeTaskForm()
+		return model, tea.Batch(cmd, loadTaskQueueCmd())
+
+	case logTickMsg:
+		if !m.taskLogMode || msg.gen != m.taskLogGen {
+			return m, nil
+		}
+		m, cmd := m.reloadTaskLog()
+		return m, tea.Batch(cmd, logTick(msg.gen))
+
+	case logLoadedMsg:
+		if m.taskLogMode && msg.src == m.taskLogSrc {
+			m.taskLogLines, m.taskLogErr = msg.lines, msg.err
 		}
 		return m, nil
 
 	case profileAddedMsg:
 		// Save the new profile
-		cfg, err := config.LoadConfig()
-		if err == nil {
+		config.UpdateConfig(func(cfg *config.Config) error {
 			cfg.Profiles = append(cfg.Profiles, msg.cfg)
 			if len(cfg.Profiles) == 1 {
 				cfg.Default = msg.cfg.Name
 			}
-			config.SaveConfig(cfg)
+			return nil
+		})
+		m.state = viewConfig
+		m.configSubTab = configProfiles
+		items, _ := loadProfiles()
+		m.profileList.SetItems(items)
+		m.cfg, _ = config.LoadConfig()
+		m.err = ""
+		return m, nil
+
+	case worktreeAddedMsg:
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			m.statusMsg = ""
+			return m, nil
+		}
+		m.projectList.SetItems(loadProjects(m.projectFilter))
+		m.err = ""
+		m.statusMsg = fmt.Sprintf("created worktree %s at %s", msg.branch, msg.path)
+		return m, nil
+
+	case worktreeRemovedMsg:
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			m.statusMsg = ""
+			return m, nil
+		}
+		m.projectList.SetItems(loadProjects(m.projectFilter))
+		if item, ok := m.projectList.SelectedItem().(projectItem); ok {
+			running := m.sessionMgr.GetRunningByProject(item.info.Name)
+			m.sessionCursor = min(m.sessionCursor, detailRowCount(item.info, len(running))-1)
+		}
+		m.err = ""
+		m.statusMsg = fmt.Sprintf("removed worktree %s", msg.wt.Path)
+		return m, nil
+
+	case profileSavedMsg:
+		if msg.err != nil {
+			m.profileEdit.saving = false
+			m.profileEdit.err = msg.err.Error()
+			return m, nil
 		}
 		m.state = viewConfig
 		m.configSubTab = configProfiles
 		items, _ := loadProfiles()
 		m.profileList.SetItems(items)
+		selectListItem(&m.profileList, func(it list.Item) bool {
+			p, ok := it.(profileItem)
+			return ok && p.cfg.Name == msg.name
+		})
 		m.cfg, _ = config.LoadConfig()
 		m.err = ""
+		m.statusMsg = fmt.Sprintf("saved profile %s", msg.name)
 		return m, nil
 
 	case profileSwitchedMsg:
@@ -956,6 +1323,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case remoteStatusMsg:
 		m.statusMsg = ""
+		if p := hostKeyPromptFor(msg.err); p != nil {
+			m.err = ""
+			m.hostKeyPrompt = p
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = fmt.Sprintf("remote %s: %v", msg.name, msg.err)
 		} else {
@@ -968,6 +1340,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case remoteSyncMsg:
 		m.statusMsg = ""
+		if p := hostKeyPromptFor(msg.err); p != nil {
+			m.err = ""
+			m.hostKeyPrompt = p
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = fmt.Sprintf("sync %s: %v", msg.name, msg.err)
 		} else {
@@ -980,8 +1357,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case remoteSetupProgressMsg:
+		m.statusMsg = fmt.Sprintf("setting up %s: %s...", msg.name, msg.stage)
+		return m, waitForRemoteSetup(msg.ch)
+
 	case remoteSetupMsg:
 		m.statusMsg = ""
+		if p := hostKeyPromptFor(msg.err); p != nil {
+			m.err = ""
+			m.hostKeyPrompt = p
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = fmt.Sprintf("setup %s: %v", msg.name, msg.err)
 		} else {
@@ -994,6 +1380,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case hostKeyTrustedMsg:
+		m.statusMsg = ""
+		if msg.err != nil {
+			m.err = fmt.Sprintf("trust %s: %v", msg.name, msg.err)
+			return m, nil
+		}
+		m.err = ""
+		m.statusMsg = fmt.Sprintf("testing %s...", msg.name)
+		name, host := msg.name, msg.host
+		return m, func() tea.Msg {
+			status, err := remote.CheckRemoteStatus(&host)
+			return remoteStatusMsg{name: name, status: status, err: err}
+		}
+
 	case remoteStatusTickMsg:
 		// Auto-refresh: check status for all configured remotes in background
 		remotes, _ := config.ListRemotes()
@@ -1061,6 +1461,67 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.rollbackItems = nil
 		return m, nil
 
+	case paletteLoadedMsg:
+		m.paletteLoading = false
+		m.paletteItems = msg.items
+		m.paletteQueue, m.paletteTeams = msg.queue, msg.teams
+		return m, nil
+
+	case toastTickMsg:
+		return m, scanToastsCmd(m.toastWatch)
+
+	case toastsMsg:
+		m.toastWatch = msg.watch
+		m.addToasts(msg.toasts)
+		return m, toastTick()
+
+	case teamsLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("teams: %v", msg.err)
+			return m, nil
+		}
+		m.teamChatTargets = msg.targets
+		m.teamChatCursor = min(m.teamChatCursor, max(0, len(msg.targets)-1))
+		if m.teamActivity != nil {
+			return m, m.refreshTeamActivity()
+		}
+		if target, ok := m.selectedChatTarget(); ok {
+			return m, loadTeamThreadCmd(target)
+		}
+		return m, nil
+
+	case teamActivityMsg:
+		if p := m.teamActivity; p != nil && p.team == msg.team {
+			p.events, p.err, p.loading = msg.events, msg.err, false
+		}
+		return m, nil
+
+	case teamThreadMsg:
+		if target, ok := m.selectedChatTarget(); ok && sameChatTarget(target, msg.target) {
+			m.teamThread, m.teamThreadErr = msg.messages, msg.err
+		}
+		return m, nil
+
+	case teamMessageSentMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("message: %v", msg.err)
+			return m, nil
+		}
+		m.statusMsg = teamChatStatus(msg.target)
+		return m, loadTeamThreadCmd(msg.target)
+
+	case teamChatTickMsg:
+		if m.state != viewAgent || m.agentSubTab != agentTeams || msg.gen != m.teamChatGen {
+			return m, nil
+		}
+		if m.teamActivity != nil {
+			return m, tea.Batch(m.refreshTeamActivity(), teamChatTick(msg.gen))
+		}
+		if target, ok := m.selectedChatTarget(); ok {
+			return m, tea.Batch(loadTeamThreadCmd(target), teamChatTick(msg.gen))
+		}
+		return m, teamChatTick(msg.gen)
+
 	case workflowsLoadedMsg:
 		if msg.err != nil {
 			m.err = fmt.Sprintf("workflows: %v", msg.err)
@@ -1069,6 +1530,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case schedulesLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("schedules: %v", msg.err)
+			return m, nil
+		}
+		m.schedules = msg.schedules
+		m.scheduleCursor = min(m.scheduleCursor, max(0, len(m.schedules)-1))
+		m.err = ""
+		if msg.status != "" {
+			m.statusMsg = msg.status
+		}
+		return m, nil
+
+	case scheduleRunMsg:
+		if msg.err != nil {
+			m.statusMsg = ""
+			m.err = fmt.Sprintf("schedule %s: %v", msg.id, msg.err)
+			return m, loadSchedulesCmd()
+		}
+		m.err = ""
+		m.statusMsg = fmt.Sprintf("ran %s: %s", msg.id, strings.Join(strings.Fields(msg.result), " "))
+		return m, loadSchedulesCmd()
+
 	case workflowRunMsg:
 		m.statusMsg = ""
 		if msg.err != nil {
@@ -1088,7 +1572,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) updateAddForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "esc" {
+		if msg.String() == "esc" && m.addForm.browser == nil {
 			m.state = viewProjects
 			return m, nil
 		}
@@ -1114,6 +1598,21 @@ func (m Model) updateProfileForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m Model) updateProfileEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			m.state = viewConfig
+			m.configSubTab = configProfiles
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.profileEdit, cmd = m.profileEdit.Update(msg)
+	return m, cmd
+}
+
 func (m Model) updateRemoteForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -1180,6 +1679,11 @@ func (m Model) applySetting(key, value string) tea.Cmd {
 			cfg.SkipPermissions = value == "on"
 		case "projects_dir":
 			cfg.ProjectsDir = value
+		case "markdownTheme":
+			if cfg.Markdown == nil {
+				cfg.Markdown = &config.MarkdownConfig{}
+			}
+			cfg.Markdown.Theme = value
 		}
 		config.SaveConfig(cfg)
 		return settingChangedMsg{}
@@ -1190,10 +1694,31 @@ func (m Model) updateRightPanel(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "left", "h", "esc":
+		case "esc":
+			if len(m.sessionMarks) > 0 {
+				clear(m.sessionMarks)
+				return m, nil
+			}
 			m.focus = focusLeft
 			return m, nil
 
+		case "left", "h":
+			clear(m.sessionMarks)
+			m.focus = focusLeft
+			return m, nil
+
+		case " ":
+			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
+				running := m.sessionMgr.GetRunningByProject(item.info.Name)
+				if m.sessionCursor < len(running) {
+					toggleMark(m.sessionMarks, running[m.sessionCursor].ID)
+					if m.sessionCursor < detailRowCount(item.info, len(running))-1 {
+						m.sessionCursor++
+					}
+				}
+			}
+			return m, nil
+
 		case "up":
 			if m.sessionCursor > 0 {
 				m.sessionCursor--
@@ -1203,27 +1728,45 @@ func (m Model) updateRightPanel(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "down":
 			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
 				running := m.sessionMgr.GetRunningByProject(item.info.Name)
-				// sessions + "New Session" option
-				maxIdx := len(running)
+				// sessions, "New Session", then the worktrees
+				maxIdx := detailRowCount(item.info, len(running)) - 1
 				if m.sessionCursor < maxIdx {
 					m.sessionCursor++
 				}
 			}
 			return m, nil
 
-		case "x":
-			// Kill the selected session
+		case "x", "X":
+			// Kill the selected session, or remove the selected worktree
 			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
 				running := m.sessionMgr.GetRunningByProject(item.info.Name)
-				if m.sessionCursor < len(running) {
-					m.sessionMgr.KillSession(running[m.sessionCursor].ID)
-					// Adjust cursor after removal
-					remaining := m.sessionMgr.GetRunningByProject(item.info.Name)
-					if len(remaining) == 0 {
-						m.focus = focusLeft
-					} else if m.sessionCursor >= len(remaining) {
-						m.sessionCursor = len(remaining) - 1
+				if marked := markedSessions(running, m.sessionMarks); len(marked) > 0 && msg.String() == "x" {
+					return m.killMarkedSessions(item.info.Name, marked)
+				}
+				if wt, isNew, ok := selectedWorktree(item.info, len(running), m.sessionCursor); ok && !isNew {
+					projectPath, force := item.info.Path, msg.String() == "X"
+					question := fmt.Sprintf("Remove worktree %s? The branch is kept.", wt.Path)
+					if force {
+						question = fmt.Sprintf("Remove worktree %s, discarding uncommitted changes? The branch is kept.", wt.Path)
 					}
+					return m.confirm(question, func(m Model) (tea.Model, tea.Cmd) {
+						m.statusMsg = fmt.Sprintf("removing worktree %s...", wt.Path)
+						return m, removeWorktreeCmd(projectPath, wt, force)
+					})
+				}
+				if m.sessionCursor < len(running) && msg.String() == "x" {
+					s, project := running[m.sessionCursor], item.info.Name
+					return m.confirm(fmt.Sprintf("Kill session %s of %s?", s.ID, project), func(m Model) (tea.Model, tea.Cmd) {
+						m.sessionMgr.KillSession(s.ID)
+						// Adjust cursor after removal
+						remaining := m.sessionMgr.GetRunningByProject(project)
+						if len(remaining) == 0 {
+							m.focus = focusLeft
+						} else if m.sessionCursor >= len(remaining) {
+							m.sessionCursor = len(remaining) - 1
+						}
+						return m, nil
+					})
 				}
 			}
 			return m, nil
@@ -1237,9 +1780,24 @@ func (m Model) updateRightPanel(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.focus = focusLeft
 					return m, nil
 				}
-				// "New Session" selected
-				name := item.info.Name
 				path := item.info.Path
+				if wt, isNew, ok := selectedWorktree(item.info, len(running), m.sessionCursor); ok {
+					if isNew {
+						m.worktreePrompt = &worktreePrompt{project: item.info.Name, path: item.info.Path}
+						return m, nil
+					}
+					if wt.Prunable {
+						m.err = fmt.Sprintf("worktree %s no longer exists; x prunes it", wt.Path)
+						return m, nil
+					}
+					path = wt.Path
+				}
+				// "New Session" or a worktree selected
+				if !config.ClaudeAvailable() {
+					m.err = config.ErrClaudeNotFound.Error()
+					return m, nil
+				}
+				name := item.info.Name
 				m.focus = focusLeft
 				args, env := config.ClaudeCmdSpec()
 				args = append(args, config.LinkedContextArgs(name)...)
@@ -1285,12 +1843,20 @@ func (m Model) View() string {
 	b.WriteString(header)
 	b.WriteString("\n")
 
-	if m.state == viewAddForm {
+	if m.paletteOpen {
+		b.WriteString(renderPalette(m.paletteItems, m.paletteLoading, m.paletteQuery, m.paletteCursor, innerWidth, m.height-7))
+	} else if m.toastHistoryOpen {
+		b.WriteString(renderToastHistory(m.toasts, m.toastScroll, innerWidth, m.height-7))
+	} else if m.state == viewAddForm {
 		b.WriteString(m.addForm.View())
 	} else if m.state == viewAddProfile {
 		b.WriteString(m.profileForm.View())
+	} else if m.state == viewEditProfile {
+		b.WriteString(m.profileEdit.View())
 	} else if m.state == viewAddRemote {
 		b.WriteString(m.remoteForm.View())
+	} else if m.state == viewAddTask {
+		b.WriteString(m.taskForm.View())
 	} else if m.state == viewLinkForm {
 		return m.viewLinkForm()
 	} else if m.state == viewConfig {
@@ -1337,9 +1903,13 @@ func (m Model) View() string {
 		b.WriteString("\n")
 
 		if m.agentSubTab == agentTasks {
-			b.WriteString(renderTaskQueueView(m.taskQueueTeams, m.taskQueueTasks, m.taskQueueLoading, m.taskQueueCursor, innerWidth, contentHeight))
+			b.WriteString(renderTaskQueueView(m.taskQueueTeams, m.taskQueueTasks, m.taskMarks, m.chatSessions, m.taskQueueMsgs, m.taskSearchQuery, m.taskQueueLoading, m.taskQueueCursor, m.taskDetailScroll, m.taskLogPane(), m.cfg, innerWidth, contentHeight))
 		} else if m.agentSubTab == agentWorkflows {
 			b.WriteString(renderWorkflowsView(m.workflowList, m.workflowRun, m.workflowCursor, innerWidth, contentHeight))
+		} else if m.agentSubTab == agentSchedules {
+			b.WriteString(renderSchedulesView(m.schedules, m.scheduleCursor, innerWidth, contentHeight))
+		} else if m.agentSubTab == agentTeams {
+			b.WriteString(renderTeamChatView(m.teamChatTargets, m.teamChatCursor, m.teamThread, m.teamThreadErr, m.teamActivity, m.teamDraft, m.teamComposing, m.cfg, innerWidth, contentHeight))
 		}
 	} else if m.state == viewStats {
 		// Stats uses full width, no left/right split
@@ -1351,6 +1921,9 @@ func (m Model) View() string {
 	} else if m.state == viewPartialRollback {
 		contentHeight := m.height - 7
 		b.WriteString(m.renderPartialRollback(innerWidth, contentHeight))
+	} else if m.state == viewSessionHistory {
+		contentHeight := m.height - 7
+		b.WriteString(renderSessionHistory(m.historyProject.Name, m.historyEntries, m.historyLoading, m.historyCursor, innerWidth, contentHeight))
 	} else {
 		// Main content: left list + right detail (Projects view)
 		leftWidth := innerWidth / 2
@@ -1362,7 +1935,7 @@ func (m Model) View() string {
 		if m.state == viewProjects {
 			leftPanel = m.projectList.View()
 			if item, ok := m.projectList.SelectedItem().(projectItem); ok {
-				rightPanel = renderProjectDetail(item.info, rightWidth, contentHeight, m.sessionMgr, m.focus == focusRight, m.sessionCursor)
+				rightPanel = renderProjectDetail(item.info, rightWidth, contentHeight, m.sessionMgr, m.focus == focusRight, m.sessionCursor, m.sessionMarks)
 			}
 		}
 
@@ -1380,9 +1953,29 @@ func (m Model) View() string {
 		searchStyle := lipgloss.NewStyle().Foreground(primaryColor).Bold(true)
 		cursor := lipgloss.NewStyle().Background(primaryColor).Foreground(lipgloss.Color("#FFFFFF")).Render(" ")
 		b.WriteString("  " + searchStyle.Render("/") + " " + m.searchQuery + cursor)
+	} else if m.state == viewAgent && m.agentSubTab == agentTasks && m.taskSearchActive {
+		b.WriteString("\n")
+		searchStyle := lipgloss.NewStyle().Foreground(primaryColor).Bold(true)
+		cursor := lipgloss.NewStyle().Background(primaryColor).Foreground(lipgloss.Color("#FFFFFF")).Render(" ")
+		b.WriteString("  " + searchStyle.Render("/") + " " + m.taskSearchQuery + cursor)
+	} else if m.hostKeyPrompt != nil {
+		b.WriteString("\n")
+		b.WriteString(statusErrorStyle.Render(renderHostKeyPrompt(m.hostKeyPrompt)))
+	} else if m.forkPrompt != nil {
+		b.WriteString("\n")
+		b.WriteString(statusOkStyle.Render(renderForkPrompt(m.forkPrompt)))
+	} else if m.worktreePrompt != nil {
+		b.WriteString("\n")
+		b.WriteString(statusOkStyle.Render(renderWorktreePrompt(m.worktreePrompt)))
+	} else if m.confirmPrompt != nil {
+		b.WriteString("\n")
+		b.WriteString(statusWarnStyle.Render(renderConfirmPrompt(m.confirmPrompt)))
 	} else if m.err != "" {
 		b.WriteString("\n")
 		b.WriteString(statusErrorStyle.Render("  Error: " + m.err))
+	} else if t, ok := m.activeToast(); ok {
+		b.WriteString("\n")
+		b.WriteString(t)
 	} else if m.statusMsg != "" {
 		b.WriteString("\n")
 		b.WriteString(statusOkStyle.Render("  " + m.statusMsg))
@@ -1406,11 +1999,11 @@ func (m Model) renderHeader() string {
 	statsTab := inactiveTabStyle.Render("Stats")
 
 	// Determine active tab based on state
-	if m.state == viewProjects || m.state == viewAddForm || m.state == viewLinkForm {
+	if m.state == viewProjects || m.state == viewAddForm || m.state == viewLinkForm || m.state == viewSessionHistory {
 		projectTab = activeTabStyle.Render("Projects")
-	} else if m.state == viewConfig || m.state == viewAddProfile || m.state == viewAddRemote {
+	} else if m.state == viewConfig || m.state == viewAddProfile || m.state == viewEditProfile || m.state == viewAddRemote {
 		configTab = activeTabStyle.Render("Config")
-	} else if m.state == viewAgent {
+	} else if m.state == viewAgent || m.state == viewAddTask {
 		agentTab = activeTabStyle.Render("Agent")
 	} else if m.state == viewStats {
 		statsTab = activeTabStyle.Render("Stats")
@@ -1481,21 +2074,33 @@ func (m Model) renderConfigSubHeader(width int) string {
 func (m Model) renderAgentSubHeader(width int) string {
 	tasksTab := inactiveTabStyle.Render("Tasks")
 	workflowsTab := inactiveTabStyle.Render("Workflows")
+	teamsTab := inactiveTabStyle.Render("Teams")
+	schedulesTab := inactiveTabStyle.Render("Schedules")
 
 	switch m.agentSubTab {
 	case agentTasks:
 		tasksTab = activeTabStyle.Render("Tasks")
 	case agentWorkflows:
 		workflowsTab = activeTabStyle.Render("Workflows")
+	case agentTeams:
+		teamsTab = activeTabStyle.Render("Teams")
+	case agentSchedules:
+		schedulesTab = activeTabStyle.Render("Schedules")
 	}
 
-	subTabs := fmt.Sprintf("  %s  %s", tasksTab, workflowsTab)
-	hint := lipgloss.NewStyle().Foreground(mutedColor).Render("  (1/2 or ←→ to switch)")
+	subTabs := fmt.Sprintf("  %s  %s  %s  %s", tasksTab, workflowsTab, teamsTab, schedulesTab)
+	hint := lipgloss.NewStyle().Foreground(mutedColor).Render("  (1-4 or ←→ to switch)")
 	gap := strings.Repeat(" ", max(0, width-lipgloss.Width(subTabs)-lipgloss.Width(hint)))
 	return fmt.Sprintf("%s%s%s", subTabs, gap, hint)
 }
 
 func (m Model) renderHelp() string {
+	if m.paletteOpen {
+		return formHintStyle.Render("type to search projects, profiles, remotes, teams, tasks and sessions  ↑↓ select  Enter: go  Esc: close")
+	}
+	if m.toastHistoryOpen {
+		return formHintStyle.Render("↑↓/jk scroll  esc/ctrl+n close")
+	}
 	if m.state == viewProjects && m.searchActive {
 		return formHintStyle.Render("type to filter  Backspace: delete  Enter: confirm  Esc: clear")
 	}
@@ -1504,9 +2109,15 @@ func (m Model) renderHelp() string {
 	if m.state == viewAddProfile {
 		return formHintStyle.Render("Tab: switch fields  Space: toggle  Enter: add  Esc: cancel")
 	}
+	if m.state == viewEditProfile {
+		return formHintStyle.Render("Tab: switch fields  Space: cycle  Ctrl+N: add var  Ctrl+X: remove var  Ctrl+R: reveal  Enter: save  Esc: cancel")
+	}
 	if m.state == viewAddRemote {
 		return formHintStyle.Render("Tab: switch fields  Enter: add  Esc: cancel")
 	}
+	if m.state == viewAddTask {
+		return formHintStyle.Render("Tab: switch fields  ←→/Space: pick  Enter: create  Esc: cancel")
+	}
 	if m.state == viewConfig {
 		if m.configSubTab == configSettings {
 			return formHintStyle.Render("↑↓ select  Enter/Space cycle  1/2/3 or ←→ sub-tab  tab switch  q quit")
@@ -1514,7 +2125,7 @@ func (m Model) renderHelp() string {
 		// Profiles or Remotes
 		baseHelp := "jk/↑↓ select  enter open  / filter  1/2/3 or ←→ sub-tab  tab switch  q quit"
 		if m.configSubTab == configProfiles {
-			return formHintStyle.Render("a add profile  " + baseHelp)
+			return formHintStyle.Render("a add  e edit  " + baseHelp)
 		}
 		if m.configSubTab == configRemotes {
 			return formHintStyle.Render("a add  d delete  t test  s sync  S setup  " + baseHelp)
@@ -1525,10 +2136,34 @@ func (m Model) renderHelp() string {
 	}
 	if m.state == viewAgent {
 		if m.agentSubTab == agentTasks {
-			return formHintStyle.Render("↑↓ select  r refresh  1/2 or ←→ sub-tab  tab switch  q quit")
+			if m.taskSearchActive {
+				return formHintStyle.Render("type to search tasks and messages  Backspace: delete  Enter: confirm  Esc: clear")
+			}
+			if m.taskSearchQuery != "" {
+				return formHintStyle.Render("↑↓ select  J/K scroll detail  l log  / edit search  esc clear search  r refresh  tab switch  q quit")
+			}
+			if m.taskLogMode {
+				return formHintStyle.Render("↑↓ select  J/K scroll log  f follow  l details  / search  r refresh  tab switch  q quit")
+			}
+			if n := len(m.taskMarks); n > 0 {
+				return formHintStyle.Render(fmt.Sprintf("%d marked  space mark  c cancel marked  esc clear marks  r refresh  tab switch  q quit", n))
+			}
+			return formHintStyle.Render("↑↓ select  J/K scroll detail  l log  space mark  c cancel  n new task  / search  r refresh  1-4 or ←→ sub-tab  tab switch  q quit")
 		}
 		if m.agentSubTab == agentWorkflows {
-			return formHintStyle.Render("↑↓/jk select  enter run  d delete  r refresh  1/2 or ←→ sub-tab  tab switch  q quit")
+			return formHintStyle.Render("↑↓/jk select  enter run  d delete  r refresh  1-4 or ←→ sub-tab  tab switch  q quit")
+		}
+		if m.agentSubTab == agentTeams {
+			if m.teamComposing {
+				return formHintStyle.Render("type a message  Enter: send  Ctrl+U: clear  Esc: stop writing")
+			}
+			if m.teamActivity != nil {
+				return formHintStyle.Render("↑↓/jk select  f filter: " + m.teamActivity.filter.String() + "  J/K scroll  a conversation  r refresh  1-4 or ←→ sub-tab  tab switch  q quit")
+			}
+			return formHintStyle.Render("↑↓/jk select  i write  a activity  n new task  r refresh  1-4 or ←→ sub-tab  tab switch  q quit")
+		}
+		if m.agentSubTab == agentSchedules {
+			return formHintStyle.Render("↑↓/jk select  space enable/disable  enter run now  d delete  r refresh  1-4 or ←→ sub-tab  tab switch  q quit")
 		}
 	}
 	if m.state == viewSessionSummary {
@@ -1537,8 +2172,14 @@ func (m Model) renderHelp() string {
 	if m.state == viewPartialRollback {
 		return formHintStyle.Render("↑↓/jk select  space toggle  enter apply  esc back  q quit")
 	}
+	if m.state == viewSessionHistory {
+		return formHintStyle.Render("↑↓/jk select  enter resume  r rescan  esc back  q quit")
+	}
 	if m.focus == focusRight && m.state == viewProjects {
-		return formHintStyle.Render("↑↓/jk select  Enter open  x kill  ← back  q quit")
+		if n := len(m.sessionMarks); n > 0 {
+			return formHintStyle.Render(fmt.Sprintf("%d marked  space mark  x kill marked  esc clear marks  ← back  q quit", n))
+		}
+		return formHintStyle.Render("↑↓/jk select  Enter open  space mark  x kill/remove  X force remove  ← back  q quit")
 	}
 
 	parts := []string{
@@ -1548,7 +2189,16 @@ func (m Model) renderHelp() string {
 	}
 
 	if m.state == viewProjects {
-		parts = append(parts, "o inline", "→/l sessions", "a add", "d delete", "x kill", "e editor", "g github", "t terminal", "S scan")
+		parts = append(parts, "o inline", "→/l sessions", "space mark", "a add", "d delete", "x kill", "H history", "e editor", "g github", "t terminal", "S scan", "* favorite", "# filter")
+		if m.projectFilter != "" {
+			parts = append([]string{"showing " + projectFilterLabel(m.projectFilter)}, parts...)
+		}
+		if m.projectUndo != nil {
+			parts = append([]string{"u undo remove"}, parts...)
+		}
+		if n := len(m.markedProjects()); n > 0 {
+			parts = append([]string{fmt.Sprintf("%d marked: enter start  d remove  x kill  esc clear", n)}, parts...)
+		}
 	}
 
 	parts = append(parts, "tab switch", "q quit")
@@ -1569,35 +2219,43 @@ func Run(version string) error {
 // 2. Try git clone with original URL
 // 3. If HTTPS URL failed, retry with SSH URL
 // 4. Return guidance on failure
-func cloneLocal(gitURL, clonePath, name string) tea.Msg {
+//
+// Shallow, single-branch and sparse options from opts apply to every attempt.
+func cloneLocal(gitURL, clonePath, name string, opts config.CloneOptions) tea.Msg {
 	hasGH := false
 	if _, err := exec.LookPath("gh"); err == nil {
 		hasGH = true
 	}
+	cloneArgs := opts.CloneArgs()
 
-	// Step 1: Try gh repo clone
+	// Step 1: Try gh repo clone (extra git flags go after --)
 	if hasGH {
-		cmd := exec.Command("gh", "repo", "clone", gitURL, clonePath)
+		args := []string{"repo", "clone", gitURL, clonePath}
+		if len(cloneArgs) > 0 {
+			args = append(append(args, "--"), cloneArgs...)
+		}
+		cmd := exec.Command("gh", args...)
 		if out, err := cmd.CombinedOutput(); err == nil {
-			return gitCloneMsg{name: name, path: clonePath}
+			return finishLocalClone(clonePath, name, opts)
 		} else {
 			_ = out // gh failed, continue to git clone
 		}
 	}
 
 	// Step 2: Try git clone with original URL
-	cmd := exec.Command("git", "clone", gitURL, clonePath)
+	gitArgs := append([]string{"clone"}, cloneArgs...)
+	cmd := exec.Command("git", append(gitArgs, gitURL, clonePath)...)
 	out, err := cmd.CombinedOutput()
 	if err == nil {
-		return gitCloneMsg{name: name, path: clonePath}
+		return finishLocalClone(clonePath, name, opts)
 	}
 
 	// Step 3: If HTTPS URL failed, try SSH URL
 	if isHTTPSURL(gitURL) {
 		sshURL := httpsToSSH(gitURL)
-		cmd2 := exec.Command("git", "clone", sshURL, clonePath)
+		cmd2 := exec.Command("git", append(gitArgs, sshURL, clonePath)...)
 		if out2, err2 := cmd2.CombinedOutput(); err2 == nil {
-			return gitCloneMsg{name: name, path: clonePath}
+			return finishLocalClone(clonePath, name, opts)
 		} else {
 			_ = out2
 		}
@@ -1623,18 +2281,30 @@ func cloneLocal(gitURL, clonePath, name string) tea.Msg {
 	return gitCloneMsg{err: fmt.Errorf("%s", guidance)}
 }
 
+// finishLocalClone applies sparse-checkout paths to a fresh clone and reports
+// the result.
+func finishLocalClone(clonePath, name string, opts config.CloneOptions) tea.Msg {
+	if len(opts.SparsePaths) > 0 {
+		args := append([]string{"-C", clonePath, "sparse-checkout", "set"}, opts.SparsePaths...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return gitCloneMsg{err: fmt.Errorf("cloned to %s but sparse-checkout failed: %s", clonePath, strings.TrimSpace(string(out)))}
+		}
+	}
+	return gitCloneMsg{name: name, path: clonePath}
+}
+
 // cloneRemote tries to clone a git repo on a remote host with smart fallback:
 // 1. Try git clone with SSH agent forwarding (-A)
 // 2. If HTTPS URL failed, retry with SSH URL (still with -A)
 // 3. Return guidance on failure
-func cloneRemote(remoteName, gitURL, clonePath, name string) tea.Msg {
+func cloneRemote(remoteName, gitURL, clonePath, name string, opts config.CloneOptions) tea.Msg {
 	host, ok := config.GetRemote(remoteName)
 	if !ok {
 		return gitCloneMsg{err: fmt.Errorf("remote '%s' not found", remoteName)}
 	}
 
 	// Step 1: Try git clone with agent forwarding
-	_, err := remote.RunSSHWithAgent(host, fmt.Sprintf("git clone %q %q", gitURL, clonePath))
+	_, err := remote.RunSSHWithAgent(host, remoteCloneCommand(gitURL, clonePath, opts))
 	if err == nil {
 		return gitCloneMsg{name: name, path: clonePath, remote: remoteName}
 	}
@@ -1643,7 +2313,7 @@ func cloneRemote(remoteName, gitURL, clonePath, name string) tea.Msg {
 	// Step 2: If HTTPS URL failed, try SSH URL
 	if isHTTPSURL(gitURL) {
 		sshURL := httpsToSSH(gitURL)
-		_, err2 := remote.RunSSHWithAgent(host, fmt.Sprintf("git clone %q %q", sshURL, clonePath))
+		_, err2 := remote.RunSSHWithAgent(host, remoteCloneCommand(sshURL, clonePath, opts))
 		if err2 == nil {
 			return gitCloneMsg{name: name, path: clonePath, remote: remoteName}
 		}
@@ -1721,7 +2391,7 @@ func (m Model) updateProjectSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc":
 		m.searchActive = false
 		m.searchQuery = ""
-		m.projectList.SetItems(loadProjects())
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 	case "enter":
 		// Confirm filter, exit search mode (filtered items remain)
 		m.searchActive = false
@@ -1734,7 +2404,7 @@ func (m Model) updateProjectSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "ctrl+u":
 		// Clear entire query
 		m.searchQuery = ""
-		m.projectList.SetItems(loadProjects())
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 	case "q", "ctrl+c":
 		return m, tea.Quit
 	default:
@@ -1749,11 +2419,11 @@ func (m Model) updateProjectSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // applyProjectSearch filters the project list by the current searchQuery.
 func (m Model) applyProjectSearch() Model {
 	if m.searchQuery == "" {
-		m.projectList.SetItems(loadProjects())
+		m.projectList.SetItems(loadProjects(m.projectFilter))
 		return m
 	}
 	query := strings.ToLower(m.searchQuery)
-	all := loadProjects()
+	all := loadProjects(m.projectFilter)
 	var filtered []list.Item
 	for _, item := range all {
 		if proj, ok := item.(projectItem); ok {
@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/config"
+)
+
+// Palette: ctrl+p opens a fuzzy search over projects, profiles, remotes,
+// teams, queued and recent tasks and chat sessions. Enter jumps to the
+// selection: the tab that shows it, with it selected.
+
+// paletteItem is one searchable entry.
+type paletteItem struct {
+	kind   string // "project", "profile", "remote", "team", "task" or "session"
+	label  string
+	detail string
+	key    string // name of a project, profile or remote
+	index  int    // position of a team in the loaded targets, or of a task or session in the queue
+}
+
+// paletteLoadedMsg carries the palette's entries and the data its jumps
+// need.
+type paletteLoadedMsg struct {
+	items []paletteItem
+	queue taskQueueLoadedMsg
+	teams teamsLoadedMsg
+}
+
+// loadPaletteCmd gathers the entries. Projects are listed from the config,
+// without the git details the project list shows.
+func loadPaletteCmd() tea.Cmd {
+	return func() tea.Msg {
+		var items []paletteItem
+		if cfg, err := config.LoadConfig(); err == nil {
+			names := make([]string, 0, len(cfg.Projects))
+			for name, entry := range cfg.Projects {
+				if !entry.IsArchived() {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				entry := cfg.Projects[name]
+				detail := entry.Path
+				if entry.Remote != "" {
+					detail += " @ " + entry.Remote
+				}
+				for _, tag := range entry.Tags {
+					detail += " #" + tag
+				}
+				items = append(items, paletteItem{kind: "project", label: name, detail: detail, key: name})
+			}
+			for _, p := range cfg.Profiles {
+				items = append(items, paletteItem{kind: "profile", label: p.Name, key: p.Name})
+			}
+			for _, r := range cfg.Remotes {
+				items = append(items, paletteItem{kind: "remote", label: r.Name, detail: r.Host, key: r.Name})
+			}
+		}
+
+		teams := loadTeamsCmd()().(teamsLoadedMsg)
+		for i, t := range teams.targets {
+			if t.broadcast() {
+				items = append(items, paletteItem{kind: "team", label: t.team, index: i})
+			}
+		}
+
+		queue := loadTaskQueueCmd()().(taskQueueLoadedMsg)
+		order := taskQueueOrder(queue.tasks)
+		for i, t := range order {
+			detail := string(t.Status)
+			if team := queue.taskTeams[queueKey(t)]; team != "" {
+				detail = team + " · " + detail
+			}
+			if t.Owner != "" {
+				detail += " · " + t.Owner
+			}
+			items = append(items, paletteItem{kind: "task", label: fmt.Sprintf("#%d %s", t.ID, t.Subject), detail: detail, index: i})
+		}
+		for i, s := range queue.sessions {
+			name := s.ProjectName
+			if name == "" {
+				name = s.ProjectPath
+			}
+			items = append(items, paletteItem{kind: "session", label: name, detail: s.ID + " · " + string(s.Status), index: len(order) + i})
+		}
+		return paletteLoadedMsg{items: items, queue: queue, teams: teams}
+	}
+}
+
+// paletteMatches returns the entries matching query, best first, or all of
+// them without one.
+func paletteMatches(items []paletteItem, query string) []paletteItem {
+	if query == "" {
+		return items
+	}
+	targets := make([]string, len(items))
+	for i, it := range items {
+		targets[i] = it.kind + " " + it.label + " " + it.detail
+	}
+	ranks := list.DefaultFilter(query, targets)
+	matched := make([]paletteItem, 0, len(ranks))
+	for _, r := range ranks {
+		matched = append(matched, items[r.Index])
+	}
+	return matched
+}
+
+// openPalette shows the palette and loads its entries.
+func (m Model) openPalette() (tea.Model, tea.Cmd) {
+	m.paletteOpen, m.paletteLoading = true, true
+	m.paletteQuery = ""
+	m.paletteCursor = 0
+	return m, loadPaletteCmd()
+}
+
+// updatePalette handles key events while the palette is open.
+func (m Model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlP:
+		m.paletteOpen = false
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyUp, tea.KeyCtrlK:
+		m.paletteCursor = max(0, m.paletteCursor-1)
+	case tea.KeyDown, tea.KeyCtrlJ:
+		m.paletteCursor = min(m.paletteCursor+1, max(0, len(paletteMatches(m.paletteItems, m.paletteQuery))-1))
+	case tea.KeyEnter:
+		matches := paletteMatches(m.paletteItems, m.paletteQuery)
+		if m.paletteCursor < len(matches) {
+			m.paletteOpen = false
+			return m.paletteJump(matches[m.paletteCursor])
+		}
+	case tea.KeyBackspace:
+		if r := []rune(m.paletteQuery); len(r) > 0 {
+			m.paletteQuery = string(r[:len(r)-1])
+			m.paletteCursor = 0
+		}
+	case tea.KeyCtrlU:
+		m.paletteQuery = ""
+		m.paletteCursor = 0
+	case tea.KeySpace:
+		m.paletteQuery += " "
+		m.paletteCursor = 0
+	case tea.KeyRunes:
+		m.paletteQuery += string(msg.Runes)
+		m.paletteCursor = 0
+	}
+	return m, nil
+}
+
+// paletteJump switches to the tab that shows item and selects it there.
+func (m Model) paletteJump(item paletteItem) (tea.Model, tea.Cmd) {
+	m.focus = focusLeft
+	m.err = ""
+	switch item.kind {
+	case "project":
+		m.state = viewProjects
+		m.searchActive, m.searchQuery = false, ""
+		m.projectFilter = ""
+		m.projectList.SetItems(loadProjects(""))
+		selectListItem(&m.projectList, func(it list.Item) bool {
+			p, ok := it.(projectItem)
+			return ok && p.info.Name == item.key
+		})
+	case "profile":
+		m.state = viewConfig
+		m.configSubTab = configProfiles
+		selectListItem(&m.profileList, func(it list.Item) bool {
+			p, ok := it.(profileItem)
+			return ok && p.cfg.Name == item.key
+		})
+	case "remote":
+		m.state = viewConfig
+		m.configSubTab = configRemotes
+		selectListItem(&m.remoteList, func(it list.Item) bool {
+			r, ok := it.(remoteItem)
+			return ok && r.host.Name == item.key
+		})
+	case "team":
+		m.state = viewAgent
+		m.agentSubTab = agentTeams
+		m.teamChatTargets = m.paletteTeams.targets
+		m.teamChatCursor = item.index
+		m.teamThread, m.teamThreadErr = nil, nil
+		return m.openTeamChat()
+	case "task", "session":
+		m.state = viewAgent
+		m.agentSubTab = agentTasks
+		q := m.paletteQueue
+		m.taskQueueTeams, m.taskQueueTasks, m.taskTeams = q.teams, q.tasks, q.taskTeams
+		m.taskQueueMsgs, m.chatSessions = q.messages, q.sessions
+		m.taskQueueLoading = false
+		m.taskSearchActive, m.taskSearchQuery = false, ""
+		m.taskQueueCursor = item.index
+		m.taskDetailScroll = 0
+		return m.reloadTaskLog()
+	}
+	return m, nil
+}
+
+// selectListItem selects the first item of l that match accepts.
+func selectListItem(l *list.Model, match func(list.Item) bool) {
+	for i, it := range l.Items() {
+		if match(it) {
+			l.Select(i)
+			return
+		}
+	}
+}
+
+// renderPalette renders the palette's query and matches.
+func renderPalette(items []paletteItem, loading bool, query string, cursor, width, height int) string {
+	var b strings.Builder
+	b.WriteString(statsAccentStyle.Render("  ⌕ ") + query + "█\n\n")
+	if loading {
+		b.WriteString(statsDimStyle.Render("  Loading..."))
+		return b.String()
+	}
+	matches := paletteMatches(items, query)
+	if len(matches) == 0 {
+		b.WriteString(statsDimStyle.Render("  No matches."))
+		return b.String()
+	}
+
+	rows := max(1, height-3)
+	start := 0
+	if cursor >= rows {
+		start = cursor - rows + 1
+	}
+	end := min(len(matches), start+rows)
+	for i := start; i < end; i++ {
+		it := matches[i]
+		prefix := "  "
+		style := lipgloss.NewStyle()
+		if i == cursor {
+			prefix = "▸ "
+			style = style.Foreground(primaryColor).Bold(true)
+		}
+		line := fmt.Sprintf("%s%s %s  %s", prefix, statsDimStyle.Render(fmt.Sprintf("%-8s", it.kind)), style.Render(it.label), statsDimStyle.Render(it.detail))
+		b.WriteString(lipgloss.NewStyle().MaxWidth(width).Render(line) + "\n")
+	}
+	if len(matches) > rows {
+		b.WriteString(statsDimStyle.Render(fmt.Sprintf("  %d of %d", cursor+1, len(matches))))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
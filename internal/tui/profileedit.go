@@ -0,0 +1,314 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/config"
+)
+
+// Profile editor: 'e' on Config/Profiles edits the selected profile's name,
+// default model (ANTHROPIC_MODEL), skip-permissions override and the rest
+// of its env vars as key/value rows. Values of keys that look secret are
+// masked unless revealed with ctrl+r.
+
+// profileSavedMsg is sent after an edited profile was saved.
+type profileSavedMsg struct {
+	name string
+	err  error
+}
+
+// Skip-permissions choices: the profile may leave it to the global setting
+// or override it either way.
+const (
+	skipInherit = iota
+	skipYes
+	skipNo
+)
+
+var skipChoiceLabels = []string{"inherit global setting", "yes", "no"}
+
+// Fixed fields before the env rows; row i's key is field
+// profileEditRowsStart+2i and its value the one after.
+const (
+	profileEditName = iota
+	profileEditDefaultModel
+	profileEditSkip
+	profileEditRowsStart
+)
+
+// envRow is one env var of the profile being edited.
+type envRow struct {
+	key      textinput.Model
+	value    textinput.Model
+	revealed bool
+}
+
+type profileEditModel struct {
+	original   config.APIConfig
+	nameInput  textinput.Model
+	modelInput textinput.Model
+	skip       int
+	rows       []envRow
+	focused    int
+	err        string
+	saving     bool
+}
+
+func newProfileEdit(p config.APIConfig) profileEditModel {
+	ni := textinput.New()
+	ni.CharLimit = 50
+	ni.SetValue(p.Name)
+	ni.Focus()
+
+	mi := textinput.New()
+	mi.Placeholder = "claude default"
+	mi.CharLimit = 100
+	mi.SetValue(p.Env["ANTHROPIC_MODEL"])
+
+	m := profileEditModel{original: p, nameInput: ni, modelInput: mi}
+	if p.SkipPermissions != nil {
+		m.skip = skipNo
+		if *p.SkipPermissions {
+			m.skip = skipYes
+		}
+	}
+
+	keys := make([]string, 0, len(p.Env))
+	for k := range p.Env {
+		if k != "ANTHROPIC_MODEL" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m.rows = append(m.rows, newEnvRow(k, p.Env[k]))
+	}
+	return m
+}
+
+func newEnvRow(key, value string) envRow {
+	ki := textinput.New()
+	ki.Placeholder = "NAME"
+	ki.CharLimit = 100
+	ki.Width = 28
+	ki.SetValue(key)
+
+	vi := textinput.New()
+	vi.Placeholder = "value"
+	vi.CharLimit = 500
+	vi.SetValue(value)
+
+	r := envRow{key: ki, value: vi}
+	r.updateMask()
+	return r
+}
+
+// updateMask hides the value while the key looks like it holds a secret.
+func (r *envRow) updateMask() {
+	if config.IsSensitiveEnv(r.key.Value()) && !r.revealed {
+		r.value.EchoMode = textinput.EchoPassword
+		r.value.EchoCharacter = '•'
+	} else {
+		r.value.EchoMode = textinput.EchoNormal
+	}
+}
+
+func (m profileEditModel) fieldCount() int {
+	return profileEditRowsStart + 2*len(m.rows)
+}
+
+// focusedRow returns the index of the env row holding the focus, or -1.
+func (m profileEditModel) focusedRow() int {
+	if m.focused < profileEditRowsStart {
+		return -1
+	}
+	return (m.focused - profileEditRowsStart) / 2
+}
+
+func (m *profileEditModel) focusProfileEditInput() {
+	m.nameInput.Blur()
+	m.modelInput.Blur()
+	for i := range m.rows {
+		m.rows[i].key.Blur()
+		m.rows[i].value.Blur()
+	}
+	switch m.focused {
+	case profileEditName:
+		m.nameInput.Focus()
+	case profileEditDefaultModel:
+		m.modelInput.Focus()
+	case profileEditSkip:
+	default:
+		row := &m.rows[m.focusedRow()]
+		if (m.focused-profileEditRowsStart)%2 == 0 {
+			row.key.Focus()
+		} else {
+			row.value.Focus()
+		}
+	}
+}
+
+// profile builds the edited profile, checking what config.ValidateProfile
+// cannot see once the rows are a map.
+func (m profileEditModel) profile() (config.APIConfig, error) {
+	p := config.APIConfig{
+		Name:   strings.TrimSpace(m.nameInput.Value()),
+		Env:    make(map[string]string),
+		Status: m.original.Status,
+	}
+	switch m.skip {
+	case skipYes, skipNo:
+		skip := m.skip == skipYes
+		p.SkipPermissions = &skip
+	}
+	for _, r := range m.rows {
+		key, value := strings.TrimSpace(r.key.Value()), strings.TrimSpace(r.value.Value())
+		switch {
+		case key == "" && value == "":
+			continue
+		case key == "":
+			return p, fmt.Errorf("env var with value %q needs a name", value)
+		case key == "ANTHROPIC_MODEL":
+			return p, fmt.Errorf("set ANTHROPIC_MODEL with the Default Model field")
+		}
+		if _, dup := p.Env[key]; dup {
+			return p, fmt.Errorf("env var %s is set twice", key)
+		}
+		p.Env[key] = value
+	}
+	if model := strings.TrimSpace(m.modelInput.Value()); model != "" {
+		p.Env["ANTHROPIC_MODEL"] = model
+	}
+	return p, config.ValidateProfile(p)
+}
+
+func (m profileEditModel) Update(msg tea.Msg) (profileEditModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "tab", "down":
+			m.focused = (m.focused + 1) % m.fieldCount()
+			m.focusProfileEditInput()
+			return m, nil
+		case "shift+tab", "up":
+			m.focused = (m.focused + m.fieldCount() - 1) % m.fieldCount()
+			m.focusProfileEditInput()
+			return m, nil
+		case " ":
+			if m.focused == profileEditSkip {
+				m.skip = (m.skip + 1) % len(skipChoiceLabels)
+				return m, nil
+			}
+		case "ctrl+n":
+			m.rows = append(m.rows, newEnvRow("", ""))
+			m.focused = m.fieldCount() - 2
+			m.focusProfileEditInput()
+			return m, nil
+		case "ctrl+x":
+			if i := m.focusedRow(); i >= 0 {
+				m.rows = append(m.rows[:i], m.rows[i+1:]...)
+				m.focused = min(m.focused, m.fieldCount()-1)
+				m.focusProfileEditInput()
+			}
+			return m, nil
+		case "ctrl+r":
+			if i := m.focusedRow(); i >= 0 {
+				m.rows[i].revealed = !m.rows[i].revealed
+				m.rows[i].updateMask()
+			}
+			return m, nil
+		case "enter":
+			if m.saving {
+				return m, nil
+			}
+			p, err := m.profile()
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			m.err = ""
+			m.saving = true
+			original := m.original.Name
+			return m, func() tea.Msg {
+				return profileSavedMsg{name: p.Name, err: config.ReplaceProfile(original, p)}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.focused {
+	case profileEditName:
+		m.nameInput, cmd = m.nameInput.Update(msg)
+	case profileEditDefaultModel:
+		m.modelInput, cmd = m.modelInput.Update(msg)
+	case profileEditSkip:
+	default:
+		row := &m.rows[m.focusedRow()]
+		if (m.focused-profileEditRowsStart)%2 == 0 {
+			row.key, cmd = row.key.Update(msg)
+			row.updateMask()
+		} else {
+			row.value, cmd = row.value.Update(msg)
+		}
+	}
+	return m, cmd
+}
+
+func (m profileEditModel) View() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(primaryColor).
+		MarginBottom(1).
+		Render("Edit Profile: " + m.original.Name)
+
+	b.WriteString(title + "\n\n")
+
+	label := func(field int, text string) string {
+		if m.focused == field {
+			return lipgloss.NewStyle().Bold(true).Foreground(secondaryColor).Render("▸ " + text)
+		}
+		return formLabelStyle.Render(text)
+	}
+
+	b.WriteString(label(profileEditName, "Name") + "\n")
+	b.WriteString(m.nameInput.View() + "\n\n")
+
+	b.WriteString(label(profileEditDefaultModel, "Default Model") + "\n")
+	b.WriteString(m.modelInput.View() + "\n\n")
+
+	b.WriteString(label(profileEditSkip, "Skip Permissions") + "  " + detailValueStyle.Render(skipChoiceLabels[m.skip]) + "\n\n")
+
+	envLabel := formLabelStyle.Render("Environment")
+	if m.focusedRow() >= 0 {
+		envLabel = lipgloss.NewStyle().Bold(true).Foreground(secondaryColor).Render("▸ Environment")
+	}
+	b.WriteString(envLabel + "\n")
+	if len(m.rows) == 0 {
+		b.WriteString(statsDimStyle.Render("  No env vars. ctrl+n adds one.") + "\n")
+	}
+	for i, r := range m.rows {
+		prefix := "  "
+		if i == m.focusedRow() {
+			prefix = statsAccentStyle.Render("▸ ")
+		}
+		b.WriteString(prefix + lipgloss.NewStyle().Width(32).Render(r.key.View()) + " = " + r.value.View() + "\n")
+	}
+	b.WriteString("\n")
+
+	if m.saving {
+		b.WriteString(statusWarnStyle.Render("Saving...") + "\n\n")
+	}
+
+	if m.err != "" {
+		b.WriteString(statusErrorStyle.Render("⚠ "+m.err) + "\n\n")
+	}
+
+	return b.String()
+}
@@ -9,6 +9,7 @@ import (
 	"codes/internal/session"
 
 	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -18,6 +19,9 @@ type projectItem struct {
 }
 
 func (i projectItem) Title() string {
+	if i.info.Favorite {
+		return "★ " + i.info.Name
+	}
 	return i.info.Name
 }
 
@@ -37,6 +41,9 @@ func (i projectItem) Description() string {
 	if !i.info.Exists {
 		parts = append(parts, "✗ missing")
 	}
+	for _, tag := range i.info.Tags {
+		parts = append(parts, "#"+tag)
+	}
 	return strings.Join(parts, "  ")
 }
 
@@ -48,21 +55,40 @@ func (i projectItem) FilterValue() string {
 	if i.info.GitBranch != "" {
 		s += " " + i.info.GitBranch
 	}
+	for _, tag := range i.info.Tags {
+		s += " #" + tag
+	}
 	return s
 }
 
-// loadProjects returns a sorted slice of list.Item from the configured projects.
-func loadProjects() []list.Item {
-	projects, err := config.ListProjects()
+// projectFilterFavorites is the project filter that shows only favorites;
+// any other non-empty filter is a tag.
+const projectFilterFavorites = "★"
+
+// loadProjects returns the configured projects as list items, favorites
+// first, then by name. filter is "" for all of them,
+// projectFilterFavorites, or a tag.
+func loadProjects(filter string) []list.Item {
+	projects, err := config.ListActiveProjects()
 	if err != nil {
 		return nil
 	}
 
 	names := make([]string, 0, len(projects))
-	for name := range projects {
-		names = append(names, name)
+	for name, entry := range projects {
+		switch {
+		case filter == projectFilterFavorites && !entry.Favorite:
+		case filter != "" && filter != projectFilterFavorites && !entry.HasTag(filter):
+		default:
+			names = append(names, name)
+		}
 	}
-	sort.Strings(names)
+	sort.Slice(names, func(i, j int) bool {
+		if fi, fj := projects[names[i]].Favorite, projects[names[j]].Favorite; fi != fj {
+			return fi
+		}
+		return names[i] < names[j]
+	})
 
 	items := make([]list.Item, 0, len(names))
 	for _, name := range names {
@@ -73,9 +99,58 @@ func loadProjects() []list.Item {
 	return items
 }
 
+// nextProjectFilter returns the filter after current in the cycle: all
+// projects, favorites, then each tag in use.
+func nextProjectFilter(current string) string {
+	projects, err := config.ListActiveProjects()
+	if err != nil {
+		return ""
+	}
+	cycle := append([]string{"", projectFilterFavorites}, config.ProjectTags(projects)...)
+	for i, f := range cycle {
+		if f == current {
+			return cycle[(i+1)%len(cycle)]
+		}
+	}
+	return ""
+}
+
+// projectFilterLabel describes a project filter for the help line.
+func projectFilterLabel(filter string) string {
+	if filter == projectFilterFavorites {
+		return "★ favorites"
+	}
+	return "#" + filter
+}
+
+// startSessionCmd starts a session for a project in a new terminal: over
+// SSH on its remote, or claude locally with its linked projects as context.
+func (m Model) startSessionCmd(info config.ProjectInfo) (tea.Cmd, error) {
+	name, path := info.Name, info.Path
+	if info.Remote != "" {
+		host, ok := config.GetRemote(info.Remote)
+		if !ok {
+			return nil, fmt.Errorf("remote '%s' not found", info.Remote)
+		}
+		return func() tea.Msg {
+			_, err := m.sessionMgr.StartRemoteSession(name, host, path)
+			return sessionStartedMsg{name: name, err: err}
+		}, nil
+	}
+	if !config.ClaudeAvailable() {
+		return nil, config.ErrClaudeNotFound
+	}
+	args, env := config.ClaudeCmdSpec()
+	args = append(args, config.LinkedContextArgs(name)...)
+	return func() tea.Msg {
+		_, err := m.sessionMgr.StartSession(name, path, args, env)
+		return sessionStartedMsg{name: name, err: err}
+	}, nil
+}
+
 // renderProjectDetail renders the right-side detail panel for a project.
 // When focused is true, sessions become selectable with a cursor at sessionCursor.
-func renderProjectDetail(info config.ProjectInfo, width, height int, mgr *session.Manager, focused bool, sessionCursor int) string {
+func renderProjectDetail(info config.ProjectInfo, width, height int, mgr *session.Manager, focused bool, sessionCursor int, marks map[string]bool) string {
 	var b strings.Builder
 
 	if !info.Exists {
@@ -91,8 +166,10 @@ func renderProjectDetail(info config.ProjectInfo, width, height int, mgr *sessio
 	}
 
 	// Sessions
+	running := 0
 	if mgr != nil {
 		runningSessions := mgr.GetRunningByProject(info.Name)
+		running = len(runningSessions)
 
 		if len(runningSessions) > 0 {
 			b.WriteString(fmt.Sprintf("  %s %s\n\n",
@@ -112,6 +189,9 @@ func renderProjectDetail(info config.ProjectInfo, width, height int, mgr *sessio
 						Background(secondaryColor).Padding(0, 1)
 				}
 				label := labelStyle.Render(s.ID)
+				if marks[s.ID] {
+					label = statusOkStyle.Render(markSymbol) + label
+				}
 				b.WriteString(fmt.Sprintf("%s%s\n", prefix, label))
 				b.WriteString(fmt.Sprintf("%s%s PID %d  %s %s\n\n",
 					prefix,
@@ -136,10 +216,23 @@ func renderProjectDetail(info config.ProjectInfo, width, height int, mgr *sessio
 			b.WriteString(fmt.Sprintf("  %s %s\n",
 				detailLabelStyle.Render("Sessions:"),
 				lipgloss.NewStyle().Foreground(mutedColor).Render("No active sessions")))
+			if focused {
+				prefix, newStyle := "    ", lipgloss.NewStyle().Foreground(mutedColor)
+				if sessionCursor == 0 {
+					prefix, newStyle = statusOkStyle.Render("  > "), lipgloss.NewStyle().Bold(true).Foreground(secondaryColor)
+				}
+				b.WriteString(prefix + newStyle.Render("+ New Session") + "\n")
+			}
 		}
 		b.WriteString("\n")
 	}
 
+	// Worktrees
+	if hasWorktrees(info) {
+		b.WriteString(renderWorktrees(info, focused, sessionCursor, running+1))
+		b.WriteString("\n")
+	}
+
 	// Remote
 	if info.Remote != "" {
 		remoteLabel := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).
@@ -214,9 +307,9 @@ func renderProjectDetail(info config.ProjectInfo, width, height int, mgr *sessio
 	// Keybinding hints
 	b.WriteString("\n")
 	if focused {
-		b.WriteString(formHintStyle.Render("  ↑↓: select  Enter: open  k: kill  ←: back"))
+		b.WriteString(formHintStyle.Render("  ↑↓: select  Enter: open  x: kill/remove  X: force remove  ←: back"))
 	} else {
-		b.WriteString(formHintStyle.Render("  →: select session  Enter: new  l: links  k: kill"))
+		b.WriteString(formHintStyle.Render("  →: sessions & worktrees  Enter: new  l: links  x: kill"))
 	}
 
 	// Use highlighted border when focused
@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/config"
+	"codes/internal/remote"
+)
+
+// Remote directory browser: with a remote selected in the add form, ctrl+b
+// on the path field lists the remote's directories (remote.ListRemoteDir)
+// starting from the typed path, or the SSH user's home. Enter descends into
+// the highlighted directory, backspace goes up, space picks the highlighted
+// directory and "." the one being shown.
+
+const remoteBrowserRows = 10
+
+// remoteBrowser is the browser's state while it is open.
+type remoteBrowser struct {
+	remote  string
+	dir     string   // absolute; "" until the home directory is known
+	entries []string // subdirectory names, ".." first unless dir is "/"
+	cursor  int
+	loading bool
+	err     string
+}
+
+// remoteDirMsg carries a listing for the browser.
+type remoteDirMsg struct {
+	remote  string
+	dir     string
+	entries []string
+	err     error
+}
+
+// listRemoteDirCmd lists the subdirectories of dir on remoteName, or of the
+// SSH user's home when dir is not absolute.
+func listRemoteDirCmd(remoteName, dir string) tea.Cmd {
+	return func() tea.Msg {
+		host, ok := config.GetRemote(remoteName)
+		if !ok {
+			return remoteDirMsg{remote: remoteName, dir: dir, err: fmt.Errorf("remote '%s' not found", remoteName)}
+		}
+		if !strings.HasPrefix(dir, "/") {
+			home, err := remote.RunSSH(host, "pwd")
+			if err != nil {
+				return remoteDirMsg{remote: remoteName, dir: dir, err: err}
+			}
+			dir = strings.TrimSpace(home)
+		}
+		entries, err := remote.ListRemoteDir(host, dir)
+		return remoteDirMsg{remote: remoteName, dir: dir, entries: remoteSubdirs(dir, entries), err: err}
+	}
+}
+
+// remoteSubdirs keeps the visible directories of an `ls -1paF` listing,
+// sorted, with ".." first unless dir is the root.
+func remoteSubdirs(dir string, entries []string) []string {
+	var dirs []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e, "/"); ok && !strings.HasPrefix(name, ".") {
+			dirs = append(dirs, name)
+		}
+	}
+	sort.Strings(dirs)
+	if dir != "/" {
+		dirs = append([]string{".."}, dirs...)
+	}
+	return dirs
+}
+
+// browseStartDir returns the directory the browser opens in for the typed
+// path: the path itself when it ends in "/", else its parent.
+func browseStartDir(typed string) string {
+	typed = strings.TrimSpace(typed)
+	if !strings.HasPrefix(typed, "/") {
+		return ""
+	}
+	if strings.HasSuffix(typed, "/") {
+		return path.Clean(typed)
+	}
+	return path.Dir(typed)
+}
+
+// openRemoteBrowser opens the browser on the selected remote.
+func (m *addFormModel) openRemoteBrowser() tea.Cmd {
+	remoteName := m.selectedRemote()
+	dir := browseStartDir(m.pathInput.Value())
+	m.browser = &remoteBrowser{remote: remoteName, dir: dir, loading: true}
+	m.suggestions = nil
+	return listRemoteDirCmd(remoteName, dir)
+}
+
+// setRemoteDir shows a listing if it is the one the browser waits for.
+func (m *addFormModel) setRemoteDir(msg remoteDirMsg) {
+	b := m.browser
+	if b == nil || msg.remote != b.remote || (b.dir != "" && msg.dir != b.dir) {
+		return
+	}
+	b.loading = false
+	b.dir = msg.dir
+	b.cursor = 0
+	b.entries = msg.entries
+	b.err = ""
+	if msg.err != nil {
+		b.err = msg.err.Error()
+	}
+}
+
+// browseTo lists dir in the browser.
+func (m *addFormModel) browseTo(dir string) tea.Cmd {
+	b := m.browser
+	b.dir, b.entries, b.cursor, b.loading, b.err = dir, nil, 0, true, ""
+	return listRemoteDirCmd(b.remote, dir)
+}
+
+// pickRemoteDir puts dir in the path field and closes the browser.
+func (m *addFormModel) pickRemoteDir(dir string) {
+	m.pathInput.SetValue(dir)
+	m.pathInput.CursorEnd()
+	m.lastPath = dir
+	m.browser = nil
+}
+
+// updateRemoteBrowser handles key events while the browser is open.
+func (m addFormModel) updateRemoteBrowser(msg tea.KeyMsg) (addFormModel, tea.Cmd) {
+	b := m.browser
+	switch msg.String() {
+	case "esc", "ctrl+b":
+		m.browser = nil
+		return m, nil
+	case "up", "k":
+		b.cursor = max(0, b.cursor-1)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, max(0, len(b.entries)-1))
+	case "backspace", "left", "h":
+		if !b.loading && b.dir != "/" && b.dir != "" {
+			return m, m.browseTo(path.Dir(b.dir))
+		}
+	case "enter", "right", "l":
+		if !b.loading && b.cursor < len(b.entries) {
+			return m, m.browseTo(path.Join(b.dir, b.entries[b.cursor]))
+		}
+	case " ":
+		if !b.loading && b.cursor < len(b.entries) {
+			m.pickRemoteDir(path.Join(b.dir, b.entries[b.cursor]))
+		}
+	case ".":
+		if !b.loading && b.dir != "" {
+			m.pickRemoteDir(b.dir)
+		}
+	}
+	return m, nil
+}
+
+// renderRemoteBrowser renders the browser below the path field.
+func renderRemoteBrowser(b *remoteBrowser) string {
+	var s strings.Builder
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	dir := b.dir
+	if dir == "" {
+		dir = "~"
+	}
+	s.WriteString("  " + statsAccentStyle.Render(b.remote+":") + detailValueStyle.Render(dir) + "\n")
+	switch {
+	case b.loading:
+		s.WriteString(muted.Render("    loading...") + "\n")
+		return s.String()
+	case b.err != "":
+		s.WriteString(statusErrorStyle.Render("    "+b.err) + "\n")
+		return s.String()
+	case len(b.entries) == 0:
+		s.WriteString(muted.Render("    no subdirectories") + "\n")
+		return s.String()
+	}
+
+	start := 0
+	if b.cursor >= remoteBrowserRows {
+		start = b.cursor - remoteBrowserRows + 1
+	}
+	end := min(len(b.entries), start+remoteBrowserRows)
+	selected := lipgloss.NewStyle().Foreground(primaryColor).Bold(true)
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#60A5FA")) // blue for dirs, as in the suggestions
+	for i := start; i < end; i++ {
+		name := b.entries[i] + "/"
+		if i == b.cursor {
+			s.WriteString(selected.Render("  ▸ ") + dirStyle.Bold(true).Render(name) + "\n")
+		} else {
+			s.WriteString(muted.Render("    "+name) + "\n")
+		}
+	}
+	if len(b.entries) > remoteBrowserRows {
+		s.WriteString(muted.Render(fmt.Sprintf("    %d of %d", b.cursor+1, len(b.entries))) + "\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"codes/internal/assistant"
+	"codes/internal/assistant/scheduler"
+)
+
+// Schedules sub-tab: the assistant scheduler's reminders and cron schedules,
+// however they were created (the assistant, MCP tools). Space enables or
+// disables the selected one, d deletes it (after a confirmation) and enter
+// runs it now, as if it had fired. Changes are written to the schedules
+// file, which the scheduler in codes serve reloads by itself.
+
+// scheduleRunTimeout bounds "run now", as the serve scheduler bounds a
+// firing.
+const scheduleRunTimeout = 2 * time.Minute
+
+// schedulesLoadedMsg carries the schedules, after a change when status is
+// set.
+type schedulesLoadedMsg struct {
+	schedules []*scheduler.Schedule
+	status    string
+	err       error
+}
+
+// scheduleRunMsg is sent after "run now" finished.
+type scheduleRunMsg struct {
+	id     string
+	result string
+	err    error
+}
+
+func loadSchedulesCmd() tea.Cmd {
+	return func() tea.Msg {
+		schedules, err := scheduler.ListSchedules()
+		return schedulesLoadedMsg{schedules: schedules, err: err}
+	}
+}
+
+// changeScheduleCmd runs change and reloads the schedules.
+func changeScheduleCmd(change func() error, status string) tea.Cmd {
+	return func() tea.Msg {
+		if err := change(); err != nil {
+			return schedulesLoadedMsg{err: err}
+		}
+		schedules, err := scheduler.ListSchedules()
+		return schedulesLoadedMsg{schedules: schedules, status: status, err: err}
+	}
+}
+
+func runScheduleCmd(sc *scheduler.Schedule) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scheduleRunTimeout)
+		defer cancel()
+		result, err := assistant.RunSchedule(ctx, sc)
+		return scheduleRunMsg{id: sc.ID, result: result, err: err}
+	}
+}
+
+// selectedSchedule returns the schedule under the cursor.
+func (m Model) selectedSchedule() (*scheduler.Schedule, bool) {
+	if m.scheduleCursor < len(m.schedules) {
+		return m.schedules[m.scheduleCursor], true
+	}
+	return nil, false
+}
+
+// updateSchedules handles key events in the Schedules view.
+func (m Model) updateSchedules(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "j", "down":
+		if m.scheduleCursor < len(m.schedules)-1 {
+			m.scheduleCursor++
+		}
+	case "k", "up":
+		if m.scheduleCursor > 0 {
+			m.scheduleCursor--
+		}
+	case "r":
+		return m, loadSchedulesCmd()
+	case " ":
+		if sc, ok := m.selectedSchedule(); ok {
+			id, enable := sc.ID, !sc.Enabled
+			status := "disabled " + id
+			if enable {
+				status = "enabled " + id
+			}
+			return m, changeScheduleCmd(func() error {
+				_, err := scheduler.SetEnabled(id, enable)
+				return err
+			}, status)
+		}
+	case "d":
+		if sc, ok := m.selectedSchedule(); ok {
+			id := sc.ID
+			return m.confirm(fmt.Sprintf("Delete schedule %s?", id), func(m Model) (tea.Model, tea.Cmd) {
+				return m, changeScheduleCmd(func() error { return scheduler.RemoveSchedule(id) }, "deleted "+id)
+			})
+		}
+	case "enter":
+		if sc, ok := m.selectedSchedule(); ok {
+			m.statusMsg = fmt.Sprintf("running %s...", sc.ID)
+			return m, runScheduleCmd(sc)
+		}
+	}
+	return m, nil
+}
+
+// scheduleWhen describes when a schedule fires.
+func scheduleWhen(sc *scheduler.Schedule) string {
+	if sc.Type == scheduler.TypeOnce && sc.At != nil {
+		return "at " + sc.At.Local().Format("2006-01-02 15:04")
+	}
+	return "cron " + sc.Cron
+}
+
+// scheduleTarget describes what a schedule does when it fires.
+func scheduleTarget(sc *scheduler.Schedule) string {
+	if sc.Team != "" {
+		target := "task in team " + sc.Team
+		if sc.Owner != "" {
+			target += " for " + sc.Owner
+		}
+		return target
+	}
+	session := sc.SessionID
+	if session == "" {
+		session = "default"
+	}
+	return "reminder to assistant session " + session
+}
+
+// renderSchedulesView renders the Schedules panel.
+func renderSchedulesView(schedules []*scheduler.Schedule, cursor, width, height int) string {
+	if len(schedules) == 0 {
+		return lipgloss.NewStyle().
+			Width(width).
+			Height(height).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render(statsDimStyle.Render("No schedules. Ask the assistant for a reminder, or use the schedule_create MCP tool."))
+	}
+
+	leftWidth := width / 2
+	rightWidth := width - leftWidth - 2
+
+	var left strings.Builder
+	left.WriteString(detailLabelStyle.Render("  Schedules") + "\n\n")
+	for i, sc := range schedules {
+		prefix := "  "
+		style := lipgloss.NewStyle()
+		if i == cursor {
+			prefix = "▸ "
+			style = style.Foreground(primaryColor).Bold(true)
+		}
+		state := statusOkStyle.Render("●")
+		if !sc.Enabled {
+			state = statsDimStyle.Render("○")
+		}
+		line := prefix + state + " " + style.Render(scheduleWhen(sc)) + "  " + statsDimStyle.Render(strings.Join(strings.Fields(sc.Message), " "))
+		left.WriteString(ansi.Truncate(line, leftWidth, "…") + "\n")
+	}
+
+	sc := schedules[min(cursor, len(schedules)-1)]
+	var right strings.Builder
+	right.WriteString(detailLabelStyle.Render("Schedule "+sc.ID) + "\n\n")
+	row := func(label, value string) {
+		right.WriteString(fmt.Sprintf("%s %s\n", detailLabelStyle.Render(fmt.Sprintf("%-10s", label)), value))
+	}
+	if sc.Enabled {
+		row("State:", statusOkStyle.Render("enabled"))
+	} else {
+		row("State:", statsDimStyle.Render("disabled"))
+	}
+	row("When:", scheduleWhen(sc))
+	if next, ok := sc.Next(time.Now()); ok {
+		row("Next:", next.Local().Format("2006-01-02 15:04"))
+	}
+	if sc.LastRunAt != nil {
+		row("Last run:", sc.LastRunAt.Local().Format("2006-01-02 15:04"))
+	}
+	row("Does:", scheduleTarget(sc))
+	if sc.Subject != "" {
+		row("Subject:", sc.Subject)
+	}
+	row("Created:", sc.CreatedAt.Local().Format("2006-01-02 15:04"))
+	right.WriteString("\n" + ansi.Wrap(sc.Message, rightWidth, "") + "\n\n")
+	right.WriteString(formHintStyle.Render("Schedules fire while codes serve is running."))
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		lipgloss.NewStyle().Width(leftWidth).Render(left.String()),
+		lipgloss.NewStyle().Width(rightWidth).MarginLeft(2).Render(right.String()),
+	)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"codes/internal/config"
+	"codes/internal/markdown"
 )
 
 type settingItem struct {
@@ -28,6 +29,7 @@ func newSettingsModel(cfg *config.Config) settingsModel {
 	terminal := "terminal"
 	behavior := "current"
 	skip := "off"
+	mdTheme := markdown.ThemeDark
 	projectsDir := config.GetProjectsDir()
 	configFile := config.ConfigPath
 
@@ -44,6 +46,9 @@ func newSettingsModel(cfg *config.Config) settingsModel {
 		if cfg.ProjectsDir != "" {
 			projectsDir = cfg.ProjectsDir
 		}
+		if cfg.Markdown != nil && cfg.Markdown.Theme != "" {
+			mdTheme = cfg.Markdown.Theme
+		}
 	}
 
 	return settingsModel{
@@ -72,6 +77,12 @@ func newSettingsModel(cfg *config.Config) settingsModel {
 				value:   skip,
 				options: []string{"off", "on"},
 			},
+			{
+				label:   "Markdown Theme",
+				key:     "markdownTheme",
+				value:   mdTheme,
+				options: markdown.Themes,
+			},
 			{
 				label:   "Config File",
 				key:     "configFile",
@@ -157,6 +168,14 @@ func settingDescription(key, value string) string {
 			return "Claude runs with --dangerously-skip-permissions"
 		}
 		return "Claude runs with normal permission checks"
+	case "markdownTheme":
+		switch value {
+		case markdown.ThemePlain:
+			return "Task reports wrapped and indented, without colors"
+		case markdown.ThemeRaw:
+			return "Task reports shown as written"
+		}
+		return "Task reports rendered for a " + value + " terminal background"
 	case "configFile":
 		return "Read-only"
 	}
@@ -0,0 +1,338 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/agent"
+	"codes/internal/config"
+)
+
+// Task form: 'n' in the Tasks or Teams sub-tab creates a task in a team,
+// as task_create does over MCP. The team, project, assignee and priority
+// are pickers cycled with ←→; from the Teams sub-tab the selected team and
+// member are picked already.
+
+// taskCreatedMsg is sent after the form's task was created.
+type taskCreatedMsg struct {
+	team string
+	task *agent.Task
+	err  error
+}
+
+// Task form fields, in tab order.
+const (
+	taskFieldTeam = iota
+	taskFieldSubject
+	taskFieldDescription
+	taskFieldProject
+	taskFieldOwner
+	taskFieldPriority
+	taskFieldLabels
+	taskFieldCount
+)
+
+var taskPriorities = []agent.TaskPriority{agent.PriorityNormal, agent.PriorityHigh, agent.PriorityLow}
+
+type taskFormModel struct {
+	teams       []string
+	members     map[string][]string // team → member names
+	projects    []string            // registered project names
+	teamIdx     int
+	projectIdx  int // -1 = none (the team's work dir)
+	ownerIdx    int // -1 = auto (the team's assignment strategy)
+	priorityIdx int
+	subject     textinput.Model
+	description textinput.Model
+	labels      textinput.Model
+	focused     int
+	err         string
+	creating    bool
+}
+
+// newTaskForm opens the form with team and owner picked when they exist.
+func newTaskForm(team, owner string) taskFormModel {
+	m := taskFormModel{members: make(map[string][]string), projectIdx: -1, ownerIdx: -1}
+	m.teams, _ = agent.ListTeams()
+	for _, name := range m.teams {
+		if cfg, err := agent.GetTeam(name); err == nil {
+			for _, member := range cfg.Members {
+				m.members[name] = append(m.members[name], member.Name)
+			}
+		}
+	}
+	if projects, err := config.ListProjects(); err == nil {
+		for name := range projects {
+			m.projects = append(m.projects, name)
+		}
+		sort.Strings(m.projects)
+	}
+	for i, name := range m.teams {
+		if name == team {
+			m.teamIdx = i
+		}
+	}
+	for i, name := range m.members[m.team()] {
+		if name == owner {
+			m.ownerIdx = i
+		}
+	}
+
+	m.subject = textinput.New()
+	m.subject.Placeholder = "Fix the flaky login test"
+	m.subject.CharLimit = 200
+	m.description = textinput.New()
+	m.description.Placeholder = "What to do, and how to tell it is done"
+	m.description.CharLimit = 4000
+	m.labels = textinput.New()
+	m.labels.Placeholder = "bug, frontend"
+	m.labels.CharLimit = 200
+
+	m.focused = taskFieldSubject
+	if len(m.teams) == 0 {
+		m.err = "No teams yet. Create one with 'codes agent team create'."
+	}
+	m.focusInput()
+	return m
+}
+
+func (m taskFormModel) team() string {
+	if m.teamIdx < len(m.teams) {
+		return m.teams[m.teamIdx]
+	}
+	return ""
+}
+
+func (m *taskFormModel) focusInput() {
+	m.subject.Blur()
+	m.description.Blur()
+	m.labels.Blur()
+	switch m.focused {
+	case taskFieldSubject:
+		m.subject.Focus()
+	case taskFieldDescription:
+		m.description.Focus()
+	case taskFieldLabels:
+		m.labels.Focus()
+	}
+}
+
+// cycle moves the focused picker by delta; the "none"/"auto" choices are
+// index -1.
+func (m *taskFormModel) cycle(delta int) bool {
+	wrap := func(i, n int, withNone bool) int {
+		if withNone {
+			return (i+1+delta+n+1)%(n+1) - 1
+		}
+		return (i + delta + n) % n
+	}
+	switch m.focused {
+	case taskFieldTeam:
+		if len(m.teams) > 0 {
+			m.teamIdx = wrap(m.teamIdx, len(m.teams), false)
+			m.ownerIdx = -1
+		}
+	case taskFieldProject:
+		m.projectIdx = wrap(m.projectIdx, len(m.projects), true)
+	case taskFieldOwner:
+		m.ownerIdx = wrap(m.ownerIdx, len(m.members[m.team()]), true)
+	case taskFieldPriority:
+		m.priorityIdx = wrap(m.priorityIdx, len(taskPriorities), false)
+	default:
+		return false
+	}
+	return true
+}
+
+// spec builds the task from the fields.
+func (m taskFormModel) spec() agent.TaskSpec {
+	spec := agent.TaskSpec{
+		Subject:     strings.TrimSpace(m.subject.Value()),
+		Description: strings.TrimSpace(m.description.Value()),
+		Priority:    taskPriorities[m.priorityIdx],
+		Labels:      parseLabels(m.labels.Value()),
+	}
+	if m.projectIdx >= 0 {
+		spec.Project = m.projects[m.projectIdx]
+	}
+	if m.ownerIdx >= 0 {
+		spec.Owner = m.members[m.team()][m.ownerIdx]
+	}
+	return spec
+}
+
+// parseLabels splits a comma-separated list, dropping blanks and repeats.
+func parseLabels(s string) []string {
+	var labels []string
+	for _, l := range strings.Split(s, ",") {
+		if l = strings.TrimSpace(l); l != "" && !slices.Contains(labels, l) {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+func (m taskFormModel) Update(msg tea.Msg) (taskFormModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "tab", "down":
+			m.focused = (m.focused + 1) % taskFieldCount
+			m.focusInput()
+			return m, nil
+		case "shift+tab", "up":
+			m.focused = (m.focused - 1 + taskFieldCount) % taskFieldCount
+			m.focusInput()
+			return m, nil
+		case "left":
+			if m.cycle(-1) {
+				return m, nil
+			}
+		case "right", " ":
+			if m.cycle(1) {
+				return m, nil
+			}
+		case "enter":
+			if m.creating {
+				return m, nil
+			}
+			team := m.team()
+			if team == "" {
+				return m, nil
+			}
+			spec := m.spec()
+			if spec.Subject == "" {
+				m.err = "Subject is required"
+				return m, nil
+			}
+			m.err = ""
+			m.creating = true
+			return m, func() tea.Msg {
+				tasks, err := agent.CreateTasks(team, []agent.TaskSpec{spec})
+				if err != nil {
+					return taskCreatedMsg{team: team, err: err}
+				}
+				return taskCreatedMsg{team: team, task: tasks[0]}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.focused {
+	case taskFieldSubject:
+		m.subject, cmd = m.subject.Update(msg)
+	case taskFieldDescription:
+		m.description, cmd = m.description.Update(msg)
+	case taskFieldLabels:
+		m.labels, cmd = m.labels.Update(msg)
+	}
+	return m, cmd
+}
+
+// renderFormPicker renders a picker field, with arrows when focused.
+func renderFormPicker(value string, focused bool) string {
+	if focused {
+		return lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(primaryColor).
+			Padding(0, 1).
+			Render(fmt.Sprintf("◀ %s ▶", value))
+	}
+	return lipgloss.NewStyle().Foreground(mutedColor).Render(fmt.Sprintf("  %s  ", value))
+}
+
+func (m taskFormModel) View() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(primaryColor).
+		MarginBottom(1).
+		Render("New Task")
+	b.WriteString(title + "\n\n")
+
+	label := func(field int, text string) string {
+		if m.focused == field {
+			return lipgloss.NewStyle().Bold(true).Foreground(secondaryColor).Render("▸ " + text)
+		}
+		return formLabelStyle.Render(text)
+	}
+
+	team := m.team()
+	if team == "" {
+		team = "(none)"
+	}
+	b.WriteString(label(taskFieldTeam, "Team") + "\n")
+	b.WriteString(renderFormPicker(team, m.focused == taskFieldTeam) + "\n\n")
+
+	b.WriteString(label(taskFieldSubject, "Subject") + "\n")
+	b.WriteString(m.subject.View() + "\n\n")
+
+	b.WriteString(label(taskFieldDescription, "Description") + "\n")
+	b.WriteString(m.description.View() + "\n\n")
+
+	project := "none (team work dir)"
+	if m.projectIdx >= 0 {
+		project = m.projects[m.projectIdx]
+	}
+	b.WriteString(label(taskFieldProject, "Project") + "\n")
+	b.WriteString(renderFormPicker(project, m.focused == taskFieldProject) + "\n\n")
+
+	owner := "auto"
+	if m.ownerIdx >= 0 {
+		owner = m.members[m.team()][m.ownerIdx]
+	}
+	b.WriteString(label(taskFieldOwner, "Assignee") + "\n")
+	b.WriteString(renderFormPicker(owner, m.focused == taskFieldOwner) + "\n\n")
+
+	b.WriteString(label(taskFieldPriority, "Priority") + "\n")
+	b.WriteString(renderFormPicker(string(taskPriorities[m.priorityIdx]), m.focused == taskFieldPriority) + "\n\n")
+
+	b.WriteString(label(taskFieldLabels, "Labels") + "\n")
+	b.WriteString(m.labels.View() + "\n\n")
+
+	if m.creating {
+		b.WriteString(statusWarnStyle.Render("⏳ Creating task...") + "\n\n")
+	}
+	if m.err != "" {
+		b.WriteString(statusErrorStyle.Render("⚠ "+m.err) + "\n\n")
+	}
+	return b.String()
+}
+
+// openTaskForm opens the form from the Agent tab.
+func (m Model) openTaskForm(team, owner string) (tea.Model, tea.Cmd) {
+	m.state = viewAddTask
+	m.taskForm = newTaskForm(team, owner)
+	return m, nil
+}
+
+// closeTaskForm returns to the sub-tab the form was opened from, restarting
+// the Teams reread loop, which stops while the form is shown.
+func (m Model) closeTaskForm() (tea.Model, tea.Cmd) {
+	m.state = viewAgent
+	if m.agentSubTab == agentTeams {
+		return m.openTeamChat()
+	}
+	return m, nil
+}
+
+func (m Model) updateTaskForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			return m.closeTaskForm()
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.taskForm, cmd = m.taskForm.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,219 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/agent"
+	"codes/internal/chatsession"
+)
+
+// queueMessage is a team message searched alongside the task queue.
+type queueMessage struct {
+	team string
+	msg  agent.Message
+}
+
+// maxMessageMatches caps the messages listed for a search.
+const maxMessageMatches = 20
+
+var matchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1F2328")).Background(warnColor)
+
+// updateTaskSearch handles key events while typing a task search query.
+// The queue is filtered as the query changes.
+func (m Model) updateTaskSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.taskSearchActive = false
+		m.taskSearchQuery = ""
+	case "enter":
+		// Keep the filter and go back to moving through the results
+		m.taskSearchActive = false
+	case "backspace", "ctrl+h":
+		if runes := []rune(m.taskSearchQuery); len(runes) > 0 {
+			m.taskSearchQuery = string(runes[:len(runes)-1])
+		}
+	case "ctrl+u":
+		m.taskSearchQuery = ""
+	case "ctrl+c":
+		return m, tea.Quit
+	default:
+		if len(msg.Runes) > 0 {
+			m.taskSearchQuery += string(msg.Runes)
+		}
+	}
+	m.taskQueueCursor = 0
+	m.taskDetailScroll = 0
+	return m, nil
+}
+
+// visibleQueueTasks returns the tasks matching the search query (all of
+// them without one).
+func (m Model) visibleQueueTasks() []agent.Task {
+	return filterTasks(m.taskQueueTasks, m.taskSearchQuery)
+}
+
+// visibleQueueSessions returns the chat sessions matching the search query.
+func (m Model) visibleQueueSessions() []chatsession.SessionInfo {
+	return filterChatSessions(m.chatSessions, m.taskSearchQuery)
+}
+
+// filterChatSessions returns the sessions whose project name, path or ID
+// contain query, ignoring case.
+func filterChatSessions(sessions []chatsession.SessionInfo, query string) []chatsession.SessionInfo {
+	if query == "" {
+		return sessions
+	}
+	var matched []chatsession.SessionInfo
+	for _, s := range sessions {
+		if containsFold(s.ProjectName, query) || containsFold(s.ProjectPath, query) || containsFold(s.ID, query) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// filterTasks returns the tasks whose subject, description, result, error,
+// summary or owner contain query, ignoring case.
+func filterTasks(tasks []agent.Task, query string) []agent.Task {
+	if query == "" {
+		return tasks
+	}
+	var matched []agent.Task
+	for _, t := range tasks {
+		if taskMatch(t, query) != "" {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+// taskMatch returns the first field of t containing query, or "".
+func taskMatch(t agent.Task, query string) string {
+	fields := append([]string{t.Subject, t.Description, t.Result, t.Error, t.Owner}, t.Labels...)
+	if t.Summary != nil {
+		fields = append(fields, t.Summary.String())
+	}
+	for _, f := range fields {
+		if containsFold(f, query) {
+			return f
+		}
+	}
+	return ""
+}
+
+// filterMessages returns the messages whose content, sender or recipient
+// contain query, newest first, at most maxMessageMatches.
+func filterMessages(messages []queueMessage, query string) []queueMessage {
+	if query == "" {
+		return nil
+	}
+	var matched []queueMessage
+	for _, qm := range messages {
+		m := qm.msg
+		if containsFold(m.Content, query) || containsFold(m.From, query) || containsFold(m.To, query) {
+			matched = append(matched, qm)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].msg.CreatedAt.After(matched[j].msg.CreatedAt)
+	})
+	if len(matched) > maxMessageMatches {
+		matched = matched[:maxMessageMatches]
+	}
+	return matched
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// highlightMatches renders s with base, marking every case-insensitive
+// occurrence of query.
+func highlightMatches(s, query string, base lipgloss.Style) string {
+	if query == "" {
+		return base.Render(s)
+	}
+	lower, q := strings.ToLower(s), strings.ToLower(query)
+	// Lowercasing can change byte lengths; fall back to no highlighting then
+	if len(lower) != len(s) {
+		return base.Render(s)
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, q)
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			b.WriteString(base.Render(s[:i]))
+		}
+		b.WriteString(matchStyle.Render(s[i : i+len(q)]))
+		s, lower = s[i+len(q):], lower[i+len(q):]
+	}
+	if s != "" {
+		b.WriteString(base.Render(s))
+	}
+	return b.String()
+}
+
+// matchSnippet returns the line of text around the first match of query
+// (or its first line), cut to about width characters, with the match
+// highlighted.
+func matchSnippet(text, query string, width int) string {
+	lines := strings.Split(text, "\n")
+	line := strings.TrimSpace(lines[0])
+	for _, l := range lines {
+		if containsFold(l, query) {
+			line = strings.TrimSpace(l)
+			break
+		}
+	}
+	runes := []rune(line)
+	width = max(width, 20)
+	if len(runes) > width {
+		// Start a third of the width before the match
+		start := 0
+		if lower := strings.ToLower(line); len(lower) == len(line) {
+			if i := strings.Index(lower, strings.ToLower(query)); i > 0 {
+				start = max(0, utf8.RuneCountInString(line[:i])-width/3)
+			}
+		}
+		end := min(len(runes), start+width)
+		prefix, suffix := "", ""
+		if start > 0 {
+			prefix = "…"
+		}
+		if end < len(runes) {
+			suffix = "…"
+		}
+		line = prefix + string(runes[start:end]) + suffix
+	}
+	return highlightMatches(line, query, statsDimStyle)
+}
+
+// renderMessageMatches lists messages matching query below the queue.
+func renderMessageMatches(messages []queueMessage, query string, width int) string {
+	matched := filterMessages(messages, query)
+	if len(matched) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  ✉ Messages (%d)", len(matched))))
+	b.WriteString("\n")
+	for _, qm := range matched {
+		to := qm.msg.To
+		if to == "" {
+			to = "all"
+		}
+		head := fmt.Sprintf("%s %s → %s: ", qm.team, qm.msg.From, to)
+		b.WriteString("    " + highlightMatches(head, query, statsDimStyle))
+		b.WriteString(matchSnippet(qm.msg.Content, query, width-len(head)-6) + "\n")
+	}
+	return b.String()
+}
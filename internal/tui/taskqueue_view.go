@@ -3,21 +3,42 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"codes/internal/agent"
+	"codes/internal/chatsession"
+	"codes/internal/config"
+	"codes/internal/markdown"
 )
 
 // taskQueueLoadedMsg is sent after loading task queue data.
 type taskQueueLoadedMsg struct {
-	teams []string
-	tasks []agent.Task
-	err   error
+	teams     []string
+	tasks     []agent.Task
+	taskTeams map[queueTaskKey]string
+	messages  []queueMessage
+	sessions  []chatsession.SessionInfo
+	err       error
 }
 
-// loadTaskQueueCmd loads tasks from all teams.
+// queueTaskKey identifies a task across teams, whose IDs overlap.
+type queueTaskKey struct {
+	id      int
+	created time.Time
+}
+
+func queueKey(t agent.Task) queueTaskKey {
+	return queueTaskKey{id: t.ID, created: t.CreatedAt}
+}
+
+// queueSessionsShown is how many recent chat sessions the queue lists.
+const queueSessionsShown = 10
+
+// loadTaskQueueCmd loads tasks and, for searching, messages from all teams,
+// and the most recent chat sessions.
 func loadTaskQueueCmd() tea.Cmd {
 	return func() tea.Msg {
 		teams, err := agent.ListTeams()
@@ -26,6 +47,8 @@ func loadTaskQueueCmd() tea.Cmd {
 		}
 
 		var allTasks []agent.Task
+		var allMessages []queueMessage
+		taskTeams := make(map[queueTaskKey]string)
 		for _, team := range teams {
 			tasks, err := agent.ListTasks(team, "", "")
 			if err != nil {
@@ -34,12 +57,70 @@ func loadTaskQueueCmd() tea.Cmd {
 			for _, t := range tasks {
 				if t != nil {
 					allTasks = append(allTasks, *t)
+					taskTeams[queueKey(*t)] = team
 				}
 			}
+			msgs, _ := agent.GetAllTeamMessages(team, 0)
+			for _, msg := range msgs {
+				allMessages = append(allMessages, queueMessage{team: team, msg: *msg})
+			}
 		}
 
-		return taskQueueLoadedMsg{teams: teams, tasks: allTasks}
+		sessions, _ := chatsession.ListSaved()
+		if len(sessions) > queueSessionsShown {
+			sessions = sessions[:queueSessionsShown]
+		}
+
+		return taskQueueLoadedMsg{teams: teams, tasks: allTasks, taskTeams: taskTeams, messages: allMessages, sessions: sessions}
+	}
+}
+
+// queueCompletedShown is how many finished tasks the queue lists.
+const queueCompletedShown = 10
+
+// groupTaskQueue splits tasks into the queue's sections. completed holds
+// every finished task; only the last queueCompletedShown are listed.
+func groupTaskQueue(tasks []agent.Task) (running, queued, completed []agent.Task) {
+	for _, t := range tasks {
+		switch t.Status {
+		case agent.TaskPending, agent.TaskAssigned:
+			queued = append(queued, t)
+		case agent.TaskRunning:
+			running = append(running, t)
+		default: // completed, failed, cancelled
+			completed = append(completed, t)
+		}
+	}
+	return running, queued, completed
+}
+
+// taskQueueOrder returns the listed tasks in display order, which the
+// cursor indexes.
+func taskQueueOrder(tasks []agent.Task) []agent.Task {
+	running, queued, completed := groupTaskQueue(tasks)
+	if len(completed) > queueCompletedShown {
+		completed = completed[len(completed)-queueCompletedShown:]
+	}
+	return append(append(running, queued...), completed...)
+}
+
+// queueSelection returns the task or chat session under the cursor, which
+// moves through the listed tasks and then the chat sessions.
+func (m Model) queueSelection() (*agent.Task, *chatsession.SessionInfo) {
+	order := taskQueueOrder(m.visibleQueueTasks())
+	if m.taskQueueCursor < len(order) {
+		return &order[m.taskQueueCursor], nil
+	}
+	sessions := m.visibleQueueSessions()
+	if i := m.taskQueueCursor - len(order); i < len(sessions) {
+		return nil, &sessions[i]
 	}
+	return nil, nil
+}
+
+// queueItemCount is how many items the cursor moves through.
+func (m Model) queueItemCount() int {
+	return len(taskQueueOrder(m.visibleQueueTasks())) + len(m.visibleQueueSessions())
 }
 
 // updateTaskQueue handles key events in the Task Queue view.
@@ -50,26 +131,81 @@ func (m Model) updateTaskQueue(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "r":
 		m.taskQueueLoading = true
 		return m, loadTaskQueueCmd()
-	case "j", "down":
-		maxIdx := len(m.taskQueueTasks) - 1
-		if maxIdx < 0 {
-			maxIdx = 0
+	case "/":
+		m.taskSearchActive = true
+		return m, nil
+	case "n":
+		team := ""
+		if task, _ := m.queueSelection(); task != nil {
+			team = m.taskTeams[queueKey(*task)]
 		}
-		if m.taskQueueCursor < maxIdx {
+		return m.openTaskForm(team, "")
+	case " ":
+		return m.toggleTaskMark()
+	case "c":
+		return m.cancelQueueTasks()
+	case "esc":
+		if len(m.taskMarks) > 0 {
+			clear(m.taskMarks)
+			return m, nil
+		}
+		m.taskSearchQuery = ""
+		m.taskQueueCursor = 0
+		m.taskDetailScroll = 0
+		return m.reloadTaskLog()
+	case "j", "down":
+		if m.taskQueueCursor < m.queueItemCount()-1 {
 			m.taskQueueCursor++
+			m.taskDetailScroll = 0
+			return m.reloadTaskLog()
 		}
 		return m, nil
 	case "k", "up":
 		if m.taskQueueCursor > 0 {
 			m.taskQueueCursor--
+			m.taskDetailScroll = 0
+			return m.reloadTaskLog()
 		}
 		return m, nil
+	case "l":
+		return m.toggleTaskLog()
+	case "f":
+		m.taskLogFollow = true
+		return m, nil
+	case "J", "pgdown", "ctrl+d":
+		if m.taskLogMode {
+			if !m.taskLogFollow {
+				m.taskLogScroll += 5
+				// Back at the end: follow again.
+				m.taskLogFollow = m.taskLogScroll >= len(m.taskLogLines)-m.taskLogPageHeight()
+			}
+			return m, nil
+		}
+		m.taskDetailScroll += 5
+		return m, nil
+	case "K", "pgup", "ctrl+u":
+		if m.taskLogMode {
+			if m.taskLogFollow {
+				// Pause where the pane is, the last page.
+				m.taskLogFollow = false
+				m.taskLogScroll = max(0, len(m.taskLogLines)-m.taskLogPageHeight())
+			}
+			m.taskLogScroll = max(0, m.taskLogScroll-5)
+			return m, nil
+		}
+		m.taskDetailScroll = max(0, m.taskDetailScroll-5)
+		return m, nil
 	}
 	return m, nil
 }
 
-// renderTaskQueueView renders the Task Queue panel.
-func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, cursor int, width, height int) string {
+// renderTaskQueueView renders the Task Queue panel: the queue and recent
+// chat sessions on the left and, when there is room, the selected item on
+// the right, a task with its description and result rendered as Markdown.
+// With a search query, only matching tasks and sessions are listed,
+// followed by matching messages. logPane, when set, renders the right
+// panel instead.
+func renderTaskQueueView(teams []string, tasks []agent.Task, marks map[queueTaskKey]bool, sessions []chatsession.SessionInfo, messages []queueMessage, query string, loading bool, cursor, scroll int, logPane func(width, height int) string, cfg *config.Config, width, height int) string {
 	if loading {
 		return lipgloss.NewStyle().
 			Width(width).
@@ -78,7 +214,7 @@ func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, curso
 			Render(statsDimStyle.Render("Loading tasks..."))
 	}
 
-	if len(teams) == 0 {
+	if len(teams) == 0 && len(sessions) == 0 {
 		return lipgloss.NewStyle().
 			Width(width).
 			Height(height).
@@ -89,25 +225,118 @@ func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, curso
 	var b strings.Builder
 
 	// Header
-	b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  Task Queue — %d team(s), %d task(s)", len(teams), len(tasks))))
+	if query != "" {
+		matched := filterTasks(tasks, query)
+		b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  Task Queue — %d of %d task(s) match %q", len(matched), len(tasks), query)))
+		tasks = matched
+	} else {
+		b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  Task Queue — %d team(s), %d task(s)", len(teams), len(tasks))))
+	}
 	b.WriteString("\n\n")
 
-	if len(tasks) == 0 {
+	sessions = filterChatSessions(sessions, query)
+	if len(tasks) == 0 && len(sessions) == 0 {
+		if query != "" {
+			b.WriteString(statsDimStyle.Render("  No matching tasks.") + "\n\n")
+			b.WriteString(renderMessageMatches(messages, query, width))
+			return b.String()
+		}
 		b.WriteString(statsDimStyle.Render("  No tasks. Press 'n' to create one."))
 		return b.String()
 	}
 
-	// Separate into groups
-	var queued, running, completed []agent.Task
-	for _, t := range tasks {
-		switch t.Status {
-		case agent.TaskPending, agent.TaskAssigned:
-			queued = append(queued, t)
-		case agent.TaskRunning:
-			running = append(running, t)
-		default: // completed, failed, cancelled
-			completed = append(completed, t)
+	leftWidth := width * 2 / 5
+	if width < 90 {
+		leftWidth = width
+	}
+	order := taskQueueOrder(tasks)
+	list := renderTaskQueueList(tasks, marks, cursor, query, leftWidth)
+	if len(sessions) > 0 {
+		list += "\n" + renderChatSessionList(sessions, cursor-len(order), query)
+	}
+	if msgs := renderMessageMatches(messages, query, leftWidth); msgs != "" {
+		list += "\n" + msgs
+	}
+	if width < 90 || cursor >= len(order)+len(sessions) {
+		b.WriteString(list)
+		return b.String()
+	}
+
+	rightWidth := width - leftWidth - 2
+	var detail string
+	switch {
+	case logPane != nil:
+		detail = logPane(rightWidth, height-2)
+	case cursor < len(order):
+		detail = scrollLines(renderTaskDetail(order[cursor], markdown.FromConfig(cfg, rightWidth)), scroll, height-2)
+	default:
+		detail = scrollLines(renderChatSessionDetail(sessions[cursor-len(order)]), scroll, height-2)
+	}
+	b.WriteString(lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		lipgloss.NewStyle().Width(leftWidth).Render(list),
+		lipgloss.NewStyle().Width(rightWidth).MarginLeft(2).Render(detail),
+	))
+	return b.String()
+}
+
+// renderChatSessionList renders the chat sessions section; cursor counts
+// from its first session.
+func renderChatSessionList(sessions []chatsession.SessionInfo, cursor int, query string) string {
+	var b strings.Builder
+	b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  ◇ Chat sessions (%d)", len(sessions))))
+	b.WriteString("\n")
+	for i, s := range sessions {
+		prefix := "  "
+		if i == cursor {
+			prefix = "▸ "
+		}
+		name := s.ProjectName
+		if name == "" {
+			name = s.ProjectPath
+		}
+		b.WriteString(fmt.Sprintf("  %s%s %s %s\n", prefix, highlightMatches(name, query, lipgloss.NewStyle()),
+			statsDimStyle.Render("["+string(s.Status)+"]"), statsDimStyle.Render(s.ID)))
+	}
+	return b.String()
+}
+
+// renderChatSessionDetail renders one chat session for the detail pane.
+func renderChatSessionDetail(s chatsession.SessionInfo) string {
+	var b strings.Builder
+	b.WriteString(detailLabelStyle.Render("Chat session "+s.ID) + "\n")
+	meta := string(s.Status)
+	if s.Model != "" {
+		meta += " · " + s.Model
+	}
+	b.WriteString(statsDimStyle.Render(meta) + "\n\n")
+	b.WriteString(fmt.Sprintf("Project:     %s\n", s.ProjectPath))
+	b.WriteString(fmt.Sprintf("Turns:       %d\n", s.TurnCount))
+	b.WriteString(fmt.Sprintf("Cost:        $%.4f\n", s.CostUSD))
+	b.WriteString(fmt.Sprintf("Created:     %s\n", s.CreatedAt.Local().Format("2006-01-02 15:04")))
+	b.WriteString(fmt.Sprintf("Last active: %s\n\n", s.LastActiveAt.Local().Format("2006-01-02 15:04")))
+	b.WriteString(statsDimStyle.Render("Press 'l' to follow its events."))
+	return b.String()
+}
+
+// renderTaskQueueList renders the queue's sections. With a query, matches
+// are highlighted, and tasks matching outside their subject show the
+// matching line beneath. Marked tasks are ticked.
+func renderTaskQueueList(tasks []agent.Task, marks map[queueTaskKey]bool, cursor int, query string, width int) string {
+	var b strings.Builder
+	running, queued, completed := groupTaskQueue(tasks)
+	plain := lipgloss.NewStyle()
+	snippet := func(t agent.Task) {
+		if query == "" || containsFold(t.Subject, query) {
+			return
+		}
+		b.WriteString("          " + matchSnippet(taskMatch(t, query), query, width-12) + "\n")
+	}
+	mark := func(t agent.Task) string {
+		if marks[queueKey(t)] {
+			return statusOkStyle.Render(markSymbol)
 		}
+		return "  "
 	}
 
 	lineIdx := 0
@@ -125,7 +354,8 @@ func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, curso
 			if t.Owner != "" {
 				owner = fmt.Sprintf(" → %s", t.Owner)
 			}
-			b.WriteString(fmt.Sprintf("  %s#%-4d %s%s\n", prefix, t.ID, t.Subject, statsDimStyle.Render(owner)))
+			b.WriteString(fmt.Sprintf("%s%s#%-4d %s%s\n", mark(t), prefix, t.ID, highlightMatches(t.Subject, query, plain), statsDimStyle.Render(owner)))
+			snippet(t)
 			lineIdx++
 		}
 		b.WriteString("\n")
@@ -145,7 +375,8 @@ func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, curso
 				owner = fmt.Sprintf(" → %s", t.Owner)
 			}
 			status := string(t.Status)
-			b.WriteString(fmt.Sprintf("  %s#%-4d [%s] %s%s\n", prefix, t.ID, status, t.Subject, statsDimStyle.Render(owner)))
+			b.WriteString(fmt.Sprintf("%s%s#%-4d [%s] %s%s\n", mark(t), prefix, t.ID, status, highlightMatches(t.Subject, query, plain), statsDimStyle.Render(owner)))
+			snippet(t)
 			lineIdx++
 		}
 		b.WriteString("\n")
@@ -154,8 +385,8 @@ func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, curso
 	// Completed (last 10)
 	if len(completed) > 0 {
 		shown := completed
-		if len(shown) > 10 {
-			shown = shown[len(shown)-10:]
+		if len(shown) > queueCompletedShown {
+			shown = shown[len(shown)-queueCompletedShown:]
 		}
 		b.WriteString(statsDimStyle.Render(fmt.Sprintf("  ✓ Completed (%d)", len(completed))))
 		b.WriteString("\n")
@@ -170,10 +401,64 @@ func renderTaskQueueView(teams []string, tasks []agent.Task, loading bool, curso
 			} else if t.Status == agent.TaskCancelled {
 				statusIcon = "○"
 			}
-			b.WriteString(fmt.Sprintf("  %s%s #%-4d %s\n", prefix, statusIcon, t.ID, statsDimStyle.Render(t.Subject)))
+			b.WriteString(fmt.Sprintf("%s%s%s #%-4d %s\n", mark(t), prefix, statusIcon, t.ID, highlightMatches(t.Subject, query, statsDimStyle)))
+			snippet(t)
 			lineIdx++
 		}
 	}
 
 	return b.String()
 }
+
+// renderTaskDetail renders one task for the detail pane.
+func renderTaskDetail(t agent.Task, opts markdown.Options) string {
+	var b strings.Builder
+	b.WriteString(detailLabelStyle.Render(fmt.Sprintf("#%d %s", t.ID, t.Subject)))
+	b.WriteString("\n")
+	meta := string(t.Status)
+	if t.Owner != "" {
+		meta += " · " + t.Owner
+	}
+	if t.Priority != "" {
+		meta += " · " + string(t.Priority)
+	}
+	if len(t.Labels) > 0 {
+		meta += " · " + strings.Join(t.Labels, ", ")
+	}
+	b.WriteString(statsDimStyle.Render(meta) + "\n\n")
+
+	section := func(title, body string) {
+		b.WriteString(detailLabelStyle.Render(title) + "\n")
+		b.WriteString(markdown.Render(body, opts) + "\n\n")
+	}
+	if t.Description != "" {
+		section("Description", t.Description)
+	}
+	if t.Error != "" {
+		b.WriteString(statusErrorStyle.Render("Error: "+t.Error) + "\n\n")
+	}
+	if t.Summary != nil {
+		section("Summary", t.Summary.String())
+	}
+	if t.Result != "" {
+		section("Result", t.Result)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// scrollLines returns height lines of s starting at offset, clamped so the
+// last page stays full, with a marker when more follows.
+func scrollLines(s string, offset, height int) string {
+	lines := strings.Split(s, "\n")
+	if height <= 0 || len(lines) <= height {
+		return s
+	}
+	offset = min(offset, len(lines)-height)
+	end := offset + height
+	if end < len(lines) {
+		end--
+		return strings.Join(lines[offset:end], "\n") + "\n" +
+			statsDimStyle.Render(fmt.Sprintf("↓ %d more lines (J/K scroll)", len(lines)-end))
+	}
+	return strings.Join(lines[offset:end], "\n")
+}
@@ -0,0 +1,372 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/agent"
+	"codes/internal/config"
+	"codes/internal/markdown"
+)
+
+// Teams sub-tab: the teams and their members on the left, the conversation
+// with the selected member (or the team's broadcasts) on the right. 'i'
+// opens the composer; the message is sent as tuiSender, so the agent's reply
+// comes back to this thread. The thread is reread every few seconds while
+// the sub-tab is shown. 'a' shows the team's activity instead
+// (activity_view.go).
+
+// tuiSender is the name messages sent from the TUI come from, as
+// "assistant" is for codes assistant.
+const tuiSender = "tui"
+
+const teamChatInterval = 2 * time.Second
+
+// chatTarget is a row of the Teams list: a member, or with member empty,
+// the whole team.
+type chatTarget struct {
+	team   string
+	member agent.TeamMember
+}
+
+func (t chatTarget) broadcast() bool { return t.member.Name == "" }
+
+func sameChatTarget(a, b chatTarget) bool {
+	return a.team == b.team && a.member.Name == b.member.Name
+}
+
+// teamsLoadedMsg carries the chat targets of every team.
+type teamsLoadedMsg struct {
+	targets []chatTarget
+	err     error
+}
+
+// teamThreadMsg carries the conversation with a target.
+type teamThreadMsg struct {
+	target   chatTarget
+	messages []*agent.Message
+	err      error
+}
+
+// teamMessageSentMsg is sent after the composer's message was sent.
+type teamMessageSentMsg struct {
+	target chatTarget
+	err    error
+}
+
+// teamChatTickMsg rereads the thread; gen works as for logTickMsg.
+type teamChatTickMsg struct{ gen int }
+
+func teamChatTick(gen int) tea.Cmd {
+	return tea.Tick(teamChatInterval, func(time.Time) tea.Msg { return teamChatTickMsg{gen: gen} })
+}
+
+// loadTeamsCmd lists every team followed by its members.
+func loadTeamsCmd() tea.Cmd {
+	return func() tea.Msg {
+		teams, err := agent.ListTeams()
+		if err != nil {
+			return teamsLoadedMsg{err: err}
+		}
+		var targets []chatTarget
+		for _, name := range teams {
+			cfg, err := agent.GetTeam(name)
+			if err != nil {
+				continue
+			}
+			targets = append(targets, chatTarget{team: name})
+			for _, member := range cfg.Members {
+				targets = append(targets, chatTarget{team: name, member: member})
+			}
+		}
+		return teamsLoadedMsg{targets: targets}
+	}
+}
+
+// loadTeamThreadCmd reads the conversation with target.
+func loadTeamThreadCmd(target chatTarget) tea.Cmd {
+	return func() tea.Msg {
+		msgs, err := teamThread(target)
+		return teamThreadMsg{target: target, messages: msgs, err: err}
+	}
+}
+
+// teamThread returns the messages between tuiSender and target's member,
+// or the team's broadcasts, oldest first. Replies to tuiSender are marked
+// read once shown, like an agent's inbox.
+func teamThread(target chatTarget) ([]*agent.Message, error) {
+	inbox, err := agent.GetMessages(target.team, tuiSender, false)
+	if err != nil {
+		return nil, err
+	}
+	var thread []*agent.Message
+	if target.broadcast() {
+		for _, msg := range inbox {
+			if msg.To == "" {
+				thread = append(thread, msg)
+			}
+		}
+		return thread, nil
+	}
+
+	for _, msg := range inbox {
+		if msg.From == target.member.Name && msg.To == tuiSender {
+			thread = append(thread, msg)
+			if !msg.Read {
+				agent.MarkRead(target.team, msg.ID)
+			}
+		}
+	}
+	sent, err := agent.GetMessages(target.team, target.member.Name, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range sent {
+		if msg.From == tuiSender && msg.To == target.member.Name {
+			thread = append(thread, msg)
+		}
+	}
+	sort.Slice(thread, func(i, j int) bool {
+		return thread[i].CreatedAt.Before(thread[j].CreatedAt)
+	})
+	return thread, nil
+}
+
+// sendTeamMessageCmd sends content to target's member, or broadcasts it.
+func sendTeamMessageCmd(target chatTarget, content string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if target.broadcast() {
+			_, err = agent.BroadcastMessage(target.team, tuiSender, content)
+		} else {
+			_, err = agent.SendMessage(target.team, tuiSender, target.member.Name, content)
+		}
+		return teamMessageSentMsg{target: target, err: err}
+	}
+}
+
+// openTeamChat starts the Teams sub-tab: it loads the teams and starts a
+// new reread loop.
+func (m Model) openTeamChat() (Model, tea.Cmd) {
+	m.teamChatGen++
+	cmds := []tea.Cmd{loadTeamsCmd(), teamChatTick(m.teamChatGen)}
+	if target, ok := m.selectedChatTarget(); ok {
+		cmds = append(cmds, loadTeamThreadCmd(target))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// selectedChatTarget returns the target under the cursor.
+func (m Model) selectedChatTarget() (chatTarget, bool) {
+	if m.teamChatCursor < len(m.teamChatTargets) {
+		return m.teamChatTargets[m.teamChatCursor], true
+	}
+	return chatTarget{}, false
+}
+
+// updateTeamChat handles key events in the Teams view.
+func (m Model) updateTeamChat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "r":
+		return m, loadTeamsCmd()
+	case "j", "down", "k", "up":
+		if msg.String() == "j" || msg.String() == "down" {
+			if m.teamChatCursor >= len(m.teamChatTargets)-1 {
+				return m, nil
+			}
+			m.teamChatCursor++
+		} else {
+			if m.teamChatCursor == 0 {
+				return m, nil
+			}
+			m.teamChatCursor--
+		}
+		m.teamThread, m.teamThreadErr = nil, nil
+		if m.teamActivity != nil {
+			return m, m.refreshTeamActivity()
+		}
+		if target, ok := m.selectedChatTarget(); ok {
+			return m, loadTeamThreadCmd(target)
+		}
+		return m, nil
+	case "i", "enter":
+		if target, ok := m.selectedChatTarget(); ok {
+			m.teamActivity = nil
+			m.teamComposing = true
+			return m, loadTeamThreadCmd(target)
+		}
+		return m, nil
+	case "n":
+		target, _ := m.selectedChatTarget()
+		return m.openTaskForm(target.team, target.member.Name)
+	case "a":
+		if m.teamActivity != nil {
+			m.teamActivity = nil
+			if target, ok := m.selectedChatTarget(); ok {
+				return m, loadTeamThreadCmd(target)
+			}
+			return m, nil
+		}
+		return m.openTeamActivity()
+	case "f":
+		if p := m.teamActivity; p != nil {
+			p.filter, p.scroll = p.filter.next(), 0
+		}
+	case "J":
+		if p := m.teamActivity; p != nil {
+			target, _ := m.selectedChatTarget()
+			lines := 2 * len(visibleActivity(p.events, target, p.filter)) // header and summary
+			p.scroll = min(p.scroll+1, max(0, lines-1))
+		}
+	case "K":
+		if p := m.teamActivity; p != nil {
+			p.scroll = max(0, p.scroll-1)
+		}
+	}
+	return m, nil
+}
+
+// updateTeamCompose handles key events while writing a message.
+func (m Model) updateTeamCompose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.teamComposing = false
+		return m, nil
+	case tea.KeyEnter:
+		content := strings.TrimSpace(m.teamDraft)
+		target, ok := m.selectedChatTarget()
+		if content == "" || !ok {
+			return m, nil
+		}
+		m.teamComposing = false
+		m.teamDraft = ""
+		return m, sendTeamMessageCmd(target, content)
+	case tea.KeyBackspace:
+		if r := []rune(m.teamDraft); len(r) > 0 {
+			m.teamDraft = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		m.teamDraft = ""
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeySpace:
+		m.teamDraft += " "
+	case tea.KeyRunes:
+		m.teamDraft += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// renderTeamChatView renders the Teams panel.
+func renderTeamChatView(targets []chatTarget, cursor int, thread []*agent.Message, threadErr error, activity *teamActivityPane, draft string, composing bool, cfg *config.Config, width, height int) string {
+	if len(targets) == 0 {
+		return lipgloss.NewStyle().
+			Width(width).
+			Height(height).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render(statsDimStyle.Render("No teams configured. Use 'codes agent team create' to get started."))
+	}
+
+	leftWidth := min(width/3, 36)
+	rightWidth := width - leftWidth - 2
+
+	var left strings.Builder
+	left.WriteString(detailLabelStyle.Render("  Teams") + "\n\n")
+	for i, t := range targets {
+		prefix := "  "
+		style := lipgloss.NewStyle()
+		if i == cursor {
+			prefix = "▸ "
+			style = style.Foreground(primaryColor).Bold(true)
+		}
+		if t.broadcast() {
+			if i > 0 {
+				left.WriteString("\n")
+			}
+			left.WriteString(style.Render(prefix+t.team) + statsDimStyle.Render(" (everyone)") + "\n")
+			continue
+		}
+		role := ""
+		if t.member.Role != "" {
+			role = statsDimStyle.Render(" " + t.member.Role)
+		}
+		left.WriteString(style.Render(prefix+"  "+t.member.Name) + role + "\n")
+	}
+
+	target := targets[min(cursor, len(targets)-1)]
+	title := "Message " + target.member.Name
+	if target.broadcast() {
+		title = "Broadcast to " + target.team
+	}
+
+	if activity != nil {
+		title := "Activity of " + target.team
+		if !target.broadcast() {
+			title += " involving " + target.member.Name
+		}
+		right := detailLabelStyle.Render(title) + statsDimStyle.Render("  ("+activity.filter.String()+")") + "\n" +
+			renderTeamActivity(activity, target, rightWidth, height-2)
+		return lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			lipgloss.NewStyle().Width(leftWidth).Render(left.String()),
+			lipgloss.NewStyle().Width(rightWidth).MarginLeft(2).Render(right),
+		)
+	}
+
+	composer := statsDimStyle.Render("i write a message")
+	if composing {
+		composer = statsAccentStyle.Render("> ") + draft + "█"
+	}
+	right := detailLabelStyle.Render(title) + "\n" +
+		renderTeamThread(thread, threadErr, target, markdown.FromConfig(cfg, rightWidth-2), height-4) + "\n\n" + composer
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		lipgloss.NewStyle().Width(leftWidth).Render(left.String()),
+		lipgloss.NewStyle().Width(rightWidth).MarginLeft(2).Render(right),
+	)
+}
+
+// renderTeamThread renders the last height lines of a conversation, the
+// messages rendered as Markdown with opts.
+func renderTeamThread(thread []*agent.Message, err error, target chatTarget, opts markdown.Options, height int) string {
+	if err != nil {
+		return statusErrorStyle.Render(err.Error())
+	}
+	if len(thread) == 0 {
+		if target.broadcast() {
+			return statsDimStyle.Render("No broadcasts yet.")
+		}
+		return statsDimStyle.Render("No messages yet. " + target.member.Name + " replies once its daemon picks yours up.")
+	}
+	var lines []string
+	for _, msg := range thread {
+		from := statsAccentStyle.Render(msg.From)
+		if msg.From == tuiSender {
+			from = statsHeaderStyle.Render("you")
+		}
+		lines = append(lines, statsDimStyle.Render(msg.CreatedAt.Local().Format("15:04"))+" "+from)
+		for _, l := range strings.Split(markdown.Render(msg.Content, opts), "\n") {
+			lines = append(lines, "  "+l)
+		}
+	}
+	if height > 0 && len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// teamChatStatus describes a sent message for the status line.
+func teamChatStatus(target chatTarget) string {
+	if target.broadcast() {
+		return fmt.Sprintf("broadcast to %s", target.team)
+	}
+	return fmt.Sprintf("sent to %s", target.member.Name)
+}
@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/agent"
+	"codes/internal/chatsession"
+)
+
+// Toasts: every toastInterval the TUI looks for new task notifications in
+// ~/.codes/notifications (written by agent daemons when a task completes,
+// fails or is cancelled) and for chat sessions that finished a turn or
+// closed. Each event shows on the status line for toastDuration, whatever
+// the tab, and is kept in a history that ctrl+n opens.
+
+const (
+	toastInterval   = 2 * time.Second
+	toastDuration   = 6 * time.Second
+	maxToastHistory = 100
+)
+
+// toast is one event, newest last in Model.toasts.
+type toast struct {
+	text   string
+	failed bool
+	at     time.Time
+}
+
+// toastWatch is what the last scan saw. The first scan only records it, so
+// events from before the TUI started are not shown.
+type toastWatch struct {
+	primed   bool
+	files    map[string]time.Time // notification file -> modification time
+	sessions map[string]chatsession.SessionInfo
+}
+
+// toastTickMsg starts the next scan.
+type toastTickMsg struct{}
+
+// toastsMsg carries the result of a scan.
+type toastsMsg struct {
+	watch  toastWatch
+	toasts []toast
+}
+
+func toastTick() tea.Cmd {
+	return tea.Tick(toastInterval, func(time.Time) tea.Msg { return toastTickMsg{} })
+}
+
+func scanToastsCmd(prev toastWatch) tea.Cmd {
+	return func() tea.Msg {
+		watch, toasts := scanToasts(prev)
+		return toastsMsg{watch: watch, toasts: toasts}
+	}
+}
+
+// toastNotification is the part of a daemon's notification file a toast
+// shows.
+type toastNotification struct {
+	Team    string `json:"team"`
+	TaskID  int    `json:"taskId"`
+	Subject string `json:"subject"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// scanToasts compares the notifications and chat sessions with prev and
+// returns toasts for what changed, oldest first.
+func scanToasts(prev toastWatch) (toastWatch, []toast) {
+	watch := toastWatch{
+		primed:   true,
+		files:    make(map[string]time.Time),
+		sessions: make(map[string]chatsession.SessionInfo),
+	}
+	var toasts []toast
+	now := time.Now()
+
+	if dir, err := agent.NotificationsDir(); err == nil {
+		// The directory does not exist until the first notification
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			if seen, ok := prev.files[e.Name()]; ok && seen.Equal(info.ModTime()) {
+				watch.files[e.Name()] = seen
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			var n toastNotification
+			if err != nil || json.Unmarshal(data, &n) != nil {
+				continue // the daemon may still be writing it; retried next scan
+			}
+			watch.files[e.Name()] = info.ModTime()
+			if prev.primed {
+				toasts = append(toasts, notificationToast(n, now))
+			}
+		}
+	}
+
+	if sessions, err := chatsession.ListSaved(); err == nil {
+		for _, s := range sessions {
+			watch.sessions[s.ID] = s
+			if old, ok := prev.sessions[s.ID]; ok && prev.primed {
+				if t, ok := sessionToast(old, s, now); ok {
+					toasts = append(toasts, t)
+				}
+			}
+		}
+	}
+	return watch, toasts
+}
+
+func notificationToast(n toastNotification, at time.Time) toast {
+	text := fmt.Sprintf("team %s: task #%d %s", n.Team, n.TaskID, n.Status)
+	if n.Subject != "" {
+		text += " — " + n.Subject
+	}
+	if n.Error != "" {
+		text += ": " + n.Error
+	}
+	return toast{text: text, failed: n.Status == string(agent.TaskFailed), at: at}
+}
+
+// sessionToast reports a chat session that closed or, as its saved cost
+// went up, finished a turn.
+func sessionToast(old, cur chatsession.SessionInfo, at time.Time) (toast, bool) {
+	name := cur.ProjectName
+	if name == "" {
+		name = cur.ID
+	}
+	switch {
+	case cur.Status == chatsession.StatusClosed && old.Status != chatsession.StatusClosed:
+		return toast{text: fmt.Sprintf("session %s: closed", name), at: at}, true
+	case cur.CostUSD > old.CostUSD:
+		return toast{text: fmt.Sprintf("session %s: turn %d finished ($%.4f so far)", name, cur.TurnCount, cur.CostUSD), at: at}, true
+	}
+	return toast{}, false
+}
+
+// addToasts appends to the history, keeping the last maxToastHistory.
+func (m *Model) addToasts(toasts []toast) {
+	m.toasts = append(m.toasts, toasts...)
+	if len(m.toasts) > maxToastHistory {
+		m.toasts = m.toasts[len(m.toasts)-maxToastHistory:]
+	}
+}
+
+// activeToast returns the status line for the toasts still on screen: the
+// newest, with how many others arrived with it.
+func (m Model) activeToast() (string, bool) {
+	active := 0
+	for i := len(m.toasts) - 1; i >= 0 && time.Since(m.toasts[i].at) < toastDuration; i-- {
+		active++
+	}
+	if active == 0 {
+		return "", false
+	}
+	t := m.toasts[len(m.toasts)-1]
+	text := "● " + t.text
+	if active > 1 {
+		text += fmt.Sprintf("  (+%d more, ctrl+n)", active-1)
+	}
+	style := statusOkStyle
+	if t.failed {
+		style = statusErrorStyle
+	}
+	return style.Render("  " + text), true
+}
+
+// updateToastHistory handles key events while the history is open.
+func (m Model) updateToastHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "ctrl+n":
+		m.toastHistoryOpen = false
+	case "j", "down", "J":
+		m.toastScroll = min(m.toastScroll+1, max(0, len(m.toasts)-1))
+	case "k", "up", "K":
+		m.toastScroll = max(0, m.toastScroll-1)
+	}
+	return m, nil
+}
+
+// renderToastHistory lists the toasts, newest first.
+func renderToastHistory(toasts []toast, scroll, width, height int) string {
+	var b strings.Builder
+	b.WriteString(statsHeaderStyle.Render(fmt.Sprintf("  Notifications (%d)", len(toasts))))
+	b.WriteString("\n\n")
+	if len(toasts) == 0 {
+		b.WriteString(statsDimStyle.Render("  Nothing yet. Task and chat session events since the TUI started show up here."))
+		return b.String()
+	}
+	var lines []string
+	for i := len(toasts) - 1; i >= 0; i-- {
+		t := toasts[i]
+		style := lipgloss.NewStyle()
+		if t.failed {
+			style = statusErrorStyle
+		}
+		lines = append(lines, "  "+statsDimStyle.Render(t.at.Format("15:04:05"))+"  "+style.Render(t.text))
+	}
+	b.WriteString(lipgloss.NewStyle().MaxWidth(width).Render(scrollLines(strings.Join(lines, "\n"), scroll, height-2)))
+	return b.String()
+}
@@ -71,11 +71,13 @@ func (m Model) updateWorkflows(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			name := wf.Name
-			return m, func() tea.Msg {
-				workflow.DeleteWorkflow(name)
-				wfs, err := workflow.ListWorkflows()
-				return workflowsLoadedMsg{workflows: wfs, err: err}
-			}
+			return m.confirm(fmt.Sprintf("Delete workflow %s?", name), func(m Model) (tea.Model, tea.Cmd) {
+				return m, func() tea.Msg {
+					workflow.DeleteWorkflow(name)
+					wfs, err := workflow.ListWorkflows()
+					return workflowsLoadedMsg{workflows: wfs, err: err}
+				}
+			})
 		}
 	case "r":
 		return m, loadWorkflowsCmd()
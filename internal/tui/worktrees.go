@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"codes/internal/config"
+)
+
+// Worktrees in the project detail panel: below the sessions come the
+// project's linked worktrees and "+ New Worktree". The right panel's cursor
+// runs through the sessions, "+ New Session", the worktrees, then
+// "+ New Worktree". Enter on a worktree starts a session there; x removes
+// it (X even with uncommitted changes), or prunes it when stale.
+
+// worktreeAddedMsg is sent after a worktree was created.
+type worktreeAddedMsg struct {
+	branch string
+	path   string
+	err    error
+}
+
+// worktreeRemovedMsg is sent after a worktree was removed or pruned.
+type worktreeRemovedMsg struct {
+	wt  config.Worktree
+	err error
+}
+
+// worktreePrompt asks for the branch of a new worktree.
+type worktreePrompt struct {
+	project string
+	path    string
+	branch  string
+}
+
+// hasWorktrees reports whether the detail panel lists worktrees for info:
+// local git repositories only.
+func hasWorktrees(info config.ProjectInfo) bool {
+	return info.Remote == "" && info.Exists && (info.GitBranch != "" || len(info.Worktrees) > 0)
+}
+
+// detailRowCount returns how many rows the right panel's cursor moves
+// through for a project with running sessions.
+func detailRowCount(info config.ProjectInfo, running int) int {
+	n := running + 1 // sessions and "+ New Session"
+	if hasWorktrees(info) {
+		n += len(info.Worktrees) + 1
+	}
+	return n
+}
+
+// selectedWorktree returns the worktree under the right panel's cursor, and
+// whether the cursor is on "+ New Worktree" instead.
+func selectedWorktree(info config.ProjectInfo, running, cursor int) (wt config.Worktree, isNew, ok bool) {
+	if !hasWorktrees(info) {
+		return config.Worktree{}, false, false
+	}
+	i := cursor - running - 1
+	switch {
+	case i >= 0 && i < len(info.Worktrees):
+		return info.Worktrees[i], false, true
+	case i == len(info.Worktrees):
+		return config.Worktree{}, true, true
+	}
+	return config.Worktree{}, false, false
+}
+
+func addWorktreeCmd(projectPath, branch string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := config.AddWorktree(projectPath, branch)
+		return worktreeAddedMsg{branch: branch, path: path, err: err}
+	}
+}
+
+func removeWorktreeCmd(projectPath string, wt config.Worktree, force bool) tea.Cmd {
+	return func() tea.Msg {
+		return worktreeRemovedMsg{wt: wt, err: config.RemoveWorktree(projectPath, wt, force)}
+	}
+}
+
+// updateWorktreePrompt handles key events while the branch prompt is open.
+func (m Model) updateWorktreePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.worktreePrompt
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.worktreePrompt = nil
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEnter:
+		branch := strings.TrimSpace(p.branch)
+		if branch == "" {
+			return m, nil
+		}
+		m.worktreePrompt = nil
+		m.err = ""
+		m.statusMsg = fmt.Sprintf("creating worktree %s...", branch)
+		return m, addWorktreeCmd(p.path, branch)
+	case tea.KeyBackspace:
+		if r := []rune(p.branch); len(r) > 0 {
+			p.branch = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		p.branch += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// renderWorktreePrompt shows the branch prompt on the status line.
+func renderWorktreePrompt(p *worktreePrompt) string {
+	return fmt.Sprintf("  New worktree of %s on branch: %s█  (enter create, esc cancel)", p.project, p.branch)
+}
+
+// renderWorktrees renders the Worktrees section of the detail panel. first
+// is the cursor index of its first row.
+func renderWorktrees(info config.ProjectInfo, focused bool, cursor, first int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  %s\n", detailLabelStyle.Render("Worktrees:")))
+	rowPrefix := func(idx int) string {
+		if focused && cursor == idx {
+			return statusOkStyle.Render("  > ")
+		}
+		return "    "
+	}
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	for i, wt := range info.Worktrees {
+		branch := wt.Branch
+		if branch == "" {
+			branch = "detached " + shortHead(wt.Head)
+		}
+		style := detailValueStyle
+		if focused && cursor == first+i {
+			style = lipgloss.NewStyle().Bold(true).Foreground(secondaryColor)
+		}
+		line := rowPrefix(first+i) + style.Render("⎇ "+branch) + "  " + muted.Render(relativeTo(info.Path, wt.Path))
+		if wt.Prunable {
+			line += "  " + statusWarnStyle.Render("stale")
+		}
+		b.WriteString(line + "\n")
+	}
+	newIdx := first + len(info.Worktrees)
+	newStyle := muted
+	if focused && cursor == newIdx {
+		newStyle = lipgloss.NewStyle().Bold(true).Foreground(secondaryColor)
+	}
+	b.WriteString(rowPrefix(newIdx) + newStyle.Render("+ New Worktree") + "\n")
+	return b.String()
+}
+
+// relativeTo shows path relative to the project when that is shorter.
+func relativeTo(projectPath, path string) string {
+	if rel, err := filepath.Rel(projectPath, path); err == nil && len(rel) < len(path) {
+		return rel
+	}
+	return path
+}
+
+func shortHead(head string) string {
+	if len(head) > 7 {
+		return head[:7]
+	}
+	return head
+}
@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -200,8 +201,13 @@ func ReplaceSelf(newBinaryPath string) error {
 	return nil
 }
 
-// ApplyStaged checks for a staged binary in ~/.codes/update/ and applies it.
-func ApplyStaged() error {
+// stagedVersionFile names the file in the staging directory that records
+// the tag of the staged binary.
+const stagedVersionFile = "version"
+
+// ApplyStaged checks for a staged binary in ~/.codes/update/ and applies it,
+// recording the update from currentVer in the history.
+func ApplyStaged(currentVer string) error {
 	stagingDir, err := stagingDirPath()
 	if err != nil {
 		return err
@@ -210,31 +216,24 @@ func ApplyStaged() error {
 	if _, err := os.Stat(staged); os.IsNotExist(err) {
 		return nil // nothing staged
 	}
+	tag, _ := os.ReadFile(filepath.Join(stagingDir, stagedVersionFile))
 
 	err = ReplaceSelf(staged)
 	if err != nil {
 		return err
 	}
+	if len(tag) > 0 {
+		recordUpdate(currentVer, strings.TrimSpace(string(tag)), "staged")
+	}
 
 	// Clean up staging directory
 	_ = os.RemoveAll(stagingDir)
 	return nil
 }
 
-// RunSelfUpdate performs a manual self-update: check → download → replace.
-func RunSelfUpdate(currentVer string) error {
-	release, err := CheckLatestVersion()
-	if err != nil {
-		return fmt.Errorf("failed to check for updates: %w", err)
-	}
-
-	if currentVer != "dev" && !CompareVersions(currentVer, release.TagName) {
-		fmt.Printf("Already up to date (%s)\n", currentVer)
-		return nil
-	}
-
-	fmt.Printf("Updating %s → %s ...\n", currentVer, release.TagName)
-
+// Install downloads release and replaces the running binary with it,
+// recording the update from currentVer in the history.
+func Install(release *ReleaseInfo, currentVer string) error {
 	tmpDir, err := os.MkdirTemp("", "codes-update-*")
 	if err != nil {
 		return fmt.Errorf("create temp dir: %w", err)
@@ -249,8 +248,7 @@ func RunSelfUpdate(currentVer string) error {
 	if err := ReplaceSelf(path); err != nil {
 		return err
 	}
-
-	fmt.Printf("Successfully updated to %s\n", release.TagName)
+	recordUpdate(currentVer, release.TagName, "update")
 
 	// Clear any stale state
 	state := UpdateState{
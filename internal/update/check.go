@@ -123,7 +123,10 @@ func parseVersion(v string) []int {
 //
 //	"silent" downloads the new binary to the staging directory.
 //	"off"    does nothing.
-func AutoCheck(currentVer, mode string) {
+//
+// onNew, if not nil, is called the first time a check finds a given release
+// newer than currentVer.
+func AutoCheck(currentVer, mode string, onNew func(*ReleaseInfo)) {
 	if mode == "off" || currentVer == "dev" {
 		return
 	}
@@ -146,6 +149,7 @@ func AutoCheck(currentVer, mode string) {
 	}
 
 	// Update state
+	known := state.LatestVersion
 	state.LastCheck = time.Now().Unix()
 	state.LatestVersion = release.TagName
 	saveState(state)
@@ -153,6 +157,9 @@ func AutoCheck(currentVer, mode string) {
 	if !CompareVersions(currentVer, release.TagName) {
 		return
 	}
+	if onNew != nil && release.TagName != known {
+		onNew(release)
+	}
 
 	switch mode {
 	case "notify":
@@ -163,7 +170,9 @@ func AutoCheck(currentVer, mode string) {
 			return
 		}
 		_ = os.MkdirAll(stagingDir, 0755)
-		_, _ = DownloadRelease(release, stagingDir)
+		if _, err := DownloadRelease(release, stagingDir); err == nil {
+			_ = os.WriteFile(filepath.Join(stagingDir, stagedVersionFile), []byte(release.TagName), 0644)
+		}
 	}
 }
 
@@ -0,0 +1,68 @@
+package update
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxHistory caps the entries kept in the update history.
+const maxHistory = 100
+
+// HistoryEntry records one applied update of the codes binary.
+type HistoryEntry struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+	Via  string    `json:"via"` // "update" (codes update) or "staged" (auto-update)
+}
+
+// historyFilePath returns ~/.codes/update-history.json.
+func historyFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codes", "update-history.json"), nil
+}
+
+// LoadHistory returns the applied updates, oldest first.
+func LoadHistory() ([]HistoryEntry, error) {
+	path, err := historyFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []HistoryEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// recordUpdate appends an entry to the update history. Failures are ignored:
+// the history is informational and must not fail an update that succeeded.
+func recordUpdate(from, to, via string) {
+	path, err := historyFilePath()
+	if err != nil {
+		return
+	}
+	history, _ := LoadHistory()
+	history = append(history, HistoryEntry{From: from, To: to, At: time.Now(), Via: via})
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	_ = os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,102 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const releasesURL = "https://api.github.com/repos/" + repoOwner + "/" + repoName + "/releases?per_page=100"
+
+// FetchReleaseNotes returns the published releases after current up to and
+// including target, oldest first. For a dev build only target is returned,
+// since there is no starting point to compare against.
+func FetchReleaseNotes(current, target string) ([]ReleaseInfo, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(releasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var releases []ReleaseInfo
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, err
+	}
+	return releasesBetween(releases, current, target), nil
+}
+
+// releasesBetween filters releases to those newer than current and not newer
+// than target, skipping drafts and pre-releases other than target itself.
+func releasesBetween(releases []ReleaseInfo, current, target string) []ReleaseInfo {
+	var out []ReleaseInfo
+	for _, r := range releases {
+		if r.Draft || parseVersion(r.TagName) == nil {
+			continue
+		}
+		if r.TagName == target {
+			out = append(out, r)
+			continue
+		}
+		if r.Prerelease || current == "dev" || CompareVersions(target, r.TagName) {
+			continue
+		}
+		if CompareVersions(current, r.TagName) {
+			out = append(out, r)
+		}
+	}
+	slices.SortFunc(out, func(a, b ReleaseInfo) int {
+		switch {
+		case CompareVersions(a.TagName, b.TagName):
+			return -1
+		case CompareVersions(b.TagName, a.TagName):
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// IsMajorUpgrade reports whether going from current to target crosses a
+// major version, which may break configs, scripts or running agents.
+func IsMajorUpgrade(current, target string) bool {
+	cur, tgt := parseVersion(current), parseVersion(target)
+	return cur != nil && tgt != nil && tgt[0] > cur[0]
+}
+
+// breakingRe matches release note lines announcing a breaking change: a
+// "BREAKING" marker or a conventional commit with "!", e.g. "feat(api)!: ...".
+var breakingRe = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\bbreaking\b|^[\s*-]*(\[[^\]]*\]\s*)?\w+(\([^)]*\))?!:`)
+})
+
+// IsBreakingLine reports whether a line of release notes announces a
+// breaking change.
+func IsBreakingLine(line string) bool {
+	return breakingRe().MatchString(line)
+}
+
+// HasBreakingChanges reports whether any of the releases is a major version
+// bump over current or mentions a breaking change in its notes.
+func HasBreakingChanges(current string, releases []ReleaseInfo) bool {
+	for _, r := range releases {
+		if IsMajorUpgrade(current, r.TagName) {
+			return true
+		}
+		for _, line := range strings.Split(r.Body, "\n") {
+			if IsBreakingLine(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package update
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionHeader carries the sender's codes version on HTTP requests and
+// responses so both ends of a connection can detect version skew.
+const VersionHeader = "X-Codes-Version"
+
+// Skew describes how far apart two codes versions are.
+type Skew int
+
+const (
+	SkewNone  Skew = iota // same version, or at least one side is a dev build
+	SkewMinor             // different versions with the same major: warn
+	SkewMajor             // different major versions: refuse to interoperate
+)
+
+// VersionSkew compares the local version with a peer's (a server or daemon
+// built from a possibly different binary). Dev builds and missing versions
+// are never reported, since there is nothing meaningful to compare.
+func VersionSkew(local, peer string) Skew {
+	if isDevVersion(local) || isDevVersion(peer) || strings.TrimPrefix(local, "v") == strings.TrimPrefix(peer, "v") {
+		return SkewNone
+	}
+	l, p := parseVersion(local), parseVersion(peer)
+	if l == nil || p == nil {
+		// Unparseable but different, e.g. a git describe string
+		return SkewMinor
+	}
+	if l[0] != p[0] {
+		return SkewMajor
+	}
+	// Minor, patch or only the pre-release suffix differs
+	return SkewMinor
+}
+
+// SkewError reports a peer running a different codes version.
+type SkewError struct {
+	Peer          string // what we talked to, e.g. "codes serve" or "agent team/worker"
+	LocalVersion  string
+	RemoteVersion string
+	Skew          Skew
+}
+
+func (e *SkewError) Error() string {
+	if e.Skew == SkewMajor {
+		return fmt.Sprintf("%s runs codes %s, which is incompatible with this codes %s; restart it with the current binary",
+			e.Peer, e.RemoteVersion, e.LocalVersion)
+	}
+	return fmt.Sprintf("%s runs codes %s but this is codes %s; restart it to pick up the upgrade",
+		e.Peer, e.RemoteVersion, e.LocalVersion)
+}
+
+// CheckSkew returns a *SkewError when peerVersion differs from local, or nil
+// when the versions match or cannot be compared.
+func CheckSkew(peer, local, peerVersion string) *SkewError {
+	skew := VersionSkew(local, peerVersion)
+	if skew == SkewNone {
+		return nil
+	}
+	return &SkewError{Peer: peer, LocalVersion: local, RemoteVersion: peerVersion, Skew: skew}
+}
+
+func isDevVersion(v string) bool {
+	return v == "" || v == "dev"
+}
@@ -8,8 +8,12 @@ const CheckInterval = 24 * time.Hour
 // ReleaseInfo holds metadata about a GitHub release.
 type ReleaseInfo struct {
 	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Body        string `json:"body"` // release notes, Markdown
 	HTMLURL     string `json:"html_url"`
 	PublishedAt string `json:"published_at"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
 }
 
 // UpdateState persists the last check timestamp and latest known version.
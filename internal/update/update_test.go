@@ -1,7 +1,9 @@
 package update
 
 import (
+	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -106,3 +108,119 @@ func TestPlatformBinaryName(t *testing.T) {
 		}
 	}
 }
+
+func TestVersionSkew(t *testing.T) {
+	tests := []struct {
+		local string
+		peer  string
+		want  Skew
+	}{
+		{"v1.2.3", "v1.2.3", SkewNone},
+		{"v1.2.3", "1.2.3", SkewNone},
+		{"dev", "v1.2.3", SkewNone},
+		{"v1.2.3", "", SkewNone},
+		{"v1.2.3", "v1.2.4", SkewMinor},
+		{"v1.2.3", "v1.3.0", SkewMinor},
+		{"v1.2.3", "v1.2.3-rc1", SkewMinor},
+		{"v1.2.3", "v1.2.3-5-gabcdef", SkewMinor},
+		{"v1.2.3", "nightly", SkewMinor},
+		{"v1.9.0", "v2.0.0", SkewMajor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.local+"_vs_"+tt.peer, func(t *testing.T) {
+			if got := VersionSkew(tt.local, tt.peer); got != tt.want {
+				t.Errorf("VersionSkew(%q, %q) = %v, want %v", tt.local, tt.peer, got, tt.want)
+			}
+		})
+	}
+
+	if err := CheckSkew("codes serve", "v1.0.0", "v1.0.0"); err != nil {
+		t.Errorf("expected no skew error, got %v", err)
+	}
+	if err := CheckSkew("codes serve", "v2.0.0", "v1.0.0"); err == nil || err.Skew != SkewMajor {
+		t.Errorf("expected major skew error, got %v", err)
+	}
+}
+
+func TestReleasesBetween(t *testing.T) {
+	releases := []ReleaseInfo{
+		{TagName: "v2.0.0"},
+		{TagName: "v1.3.0-rc1", Prerelease: true},
+		{TagName: "v1.2.0"},
+		{TagName: "v1.1.0"},
+		{TagName: "v1.0.0"},
+		{TagName: "v2.1.0", Draft: true},
+		{TagName: "nightly"},
+	}
+
+	tags := func(rs []ReleaseInfo) string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.TagName)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		current, target, want string
+	}{
+		{"v1.0.0", "v2.0.0", "v1.1.0,v1.2.0,v2.0.0"},
+		{"v1.1.0", "v1.2.0", "v1.2.0"},
+		{"v1.2.0", "v1.3.0-rc1", "v1.3.0-rc1"},
+		{"dev", "v2.0.0", "v2.0.0"},
+	}
+	for _, tt := range tests {
+		if got := tags(releasesBetween(releases, tt.current, tt.target)); got != tt.want {
+			t.Errorf("releasesBetween(%s, %s) = %s, want %s", tt.current, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestBreakingChanges(t *testing.T) {
+	for line, want := range map[string]bool{
+		"- BREAKING: config key renamed":      true,
+		"### Breaking changes":                true,
+		"- feat(api)!: drop /v0 routes":       true,
+		"* refactor!: new task layout":        true,
+		"- [#12] fix!: stricter validation":   true,
+		"- feat(api): add /v2 routes":         false,
+		"- fix: handle unbreakable strings":   false,
+		"Thanks to everyone who tested this!": false,
+	} {
+		if got := IsBreakingLine(line); got != want {
+			t.Errorf("IsBreakingLine(%q) = %v, want %v", line, got, want)
+		}
+	}
+
+	if !IsMajorUpgrade("v1.9.0", "v2.0.0") || IsMajorUpgrade("v1.0.0", "v1.9.0") || IsMajorUpgrade("dev", "v2.0.0") {
+		t.Error("IsMajorUpgrade misclassified an upgrade")
+	}
+	if HasBreakingChanges("v1.0.0", []ReleaseInfo{{TagName: "v1.1.0", Body: "- feat: more"}}) {
+		t.Error("expected no breaking changes")
+	}
+	if !HasBreakingChanges("v1.0.0", []ReleaseInfo{{TagName: "v1.1.0", Body: "- feat!: less"}}) {
+		t.Error("expected breaking changes")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if h, err := LoadHistory(); err != nil || len(h) != 0 {
+		t.Fatalf("LoadHistory() on a fresh home = %v, %v", h, err)
+	}
+	for i := 0; i < maxHistory+2; i++ {
+		recordUpdate(fmt.Sprintf("v1.0.%d", i), fmt.Sprintf("v1.0.%d", i+1), "update")
+	}
+	h, err := LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory() error: %v", err)
+	}
+	if len(h) != maxHistory {
+		t.Fatalf("len(history) = %d, want %d", len(h), maxHistory)
+	}
+	if last := h[len(h)-1]; last.From != "v1.0.101" || last.To != "v1.0.102" || last.Via != "update" {
+		t.Errorf("last entry = %+v", last)
+	}
+}
@@ -0,0 +1,703 @@
+// Package client is a Go client for the codes HTTP API served by
+// `codes serve`. It covers chat sessions (including their live event stream),
+// teams, tasks, messages, projects and workflows:
+//
+//	c := client.New("http://localhost:3456", token)
+//	task, err := c.CreateTask(ctx, "my-team", client.CreateTaskRequest{Subject: "Fix the flaky test", Owner: "worker"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionHeader carries the codes version of each side of a request. A
+// server rejects clients whose major version differs from its own with
+// 426 Upgrade Required.
+const VersionHeader = "X-Codes-Version"
+
+// Client talks to one codes server. The zero value is not usable; create one
+// with New. A Client is safe for concurrent use.
+type Client struct {
+	baseURL string
+	token   string
+
+	// HTTPClient sends the requests. Defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+
+	// Version, if set, is sent in VersionHeader so the server can refuse an
+	// incompatible client instead of misreading its requests.
+	Version string
+}
+
+// New creates a client for the server at baseURL (e.g.
+// "http://localhost:3456") that authenticates with token.
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error is returned for any non-2xx response.
+type Error struct {
+	StatusCode int
+	Message    string // the server's "error" field, or the status text
+	Code       string // the server's "code" field, e.g. "read_only"; often empty
+	RequestID  string // the server's ID for the request, to find it in its log
+}
+
+func (e *Error) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("codes API: %d %s (request %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("codes API: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the server.
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey returns a context whose POST requests carry key in the
+// Idempotency-Key header. The server runs CreateTeam, CreateTask, CreateTasks
+// and CreateSession once per key and answers retries with the first
+// response, so a call that timed out can be retried with the same context
+// without creating anything twice.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response
+// into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key, ok := ctx.Value(idempotencyKeyCtx{}).(string); ok && key != "" && method == http.MethodPost {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	c.setHeaders(req.Header)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// responseError builds an *Error from a failed response.
+func responseError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode), RequestID: resp.Header.Get("X-Request-ID")}
+	var e ErrorResponse
+	if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+		apiErr.Message = e.Error
+		apiErr.Code = e.Code
+	}
+	return apiErr
+}
+
+func (c *Client) setHeaders(h http.Header) {
+	if c.token != "" {
+		h.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.Version != "" {
+		h.Set(VersionHeader, c.Version)
+	}
+}
+
+// seg escapes a path segment (team, session or agent name).
+func seg(s string) string {
+	return url.PathEscape(s)
+}
+
+// Health checks that the server is up and returns its version. It needs no
+// token.
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var out HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/health", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Sessions ---
+
+// CreateSession starts a chat session.
+func (c *Client) CreateSession(ctx context.Context, req CreateSessionRequest) (*SessionResponse, error) {
+	var out SessionResponse
+	if err := c.do(ctx, http.MethodPost, "/sessions", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSessions lists the server's chat sessions.
+func (c *Client) ListSessions(ctx context.Context) ([]SessionResponse, error) {
+	var out SessionListResponse
+	if err := c.do(ctx, http.MethodGet, "/sessions", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Sessions, nil
+}
+
+// GetSession returns one chat session.
+func (c *Client) GetSession(ctx context.Context, id string) (*SessionResponse, error) {
+	var out SessionResponse
+	if err := c.do(ctx, http.MethodGet, "/sessions/"+seg(id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSession stops and removes a chat session.
+func (c *Client) DeleteSession(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/sessions/"+seg(id), nil, nil)
+}
+
+// SendSessionMessage sends a user message, optionally with attachments, to a
+// chat session. Replies arrive on the session's event stream (see
+// SubscribeSession).
+func (c *Client) SendSessionMessage(ctx context.Context, id, content string, attachments ...SessionAttachment) (*SessionResponse, error) {
+	var out SessionResponse
+	req := SessionSendMessageRequest{Content: content, Attachments: attachments}
+	if err := c.do(ctx, http.MethodPost, "/sessions/"+seg(id)+"/message", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InterruptSession stops the turn a chat session is running.
+func (c *Client) InterruptSession(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/sessions/"+seg(id)+"/interrupt", nil, nil)
+}
+
+// ResumeSession resumes an earlier Claude conversation in a chat session.
+func (c *Client) ResumeSession(ctx context.Context, id, claudeSessionID string) (*SessionResponse, error) {
+	var out SessionResponse
+	if err := c.do(ctx, http.MethodPost, "/sessions/"+seg(id)+"/resume", ResumeSessionRequest{ClaudeSessionID: claudeSessionID}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SessionMessages returns a page of a chat session's recorded events, oldest
+// first (limit <= 0 uses the server default of 100). It works for sessions
+// that have closed too.
+func (c *Client) SessionMessages(ctx context.Context, id string, offset, limit int) (*SessionMessageListResponse, error) {
+	q := url.Values{}
+	if offset > 0 {
+		q.Set("offset", strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var out SessionMessageListResponse
+	if err := c.do(ctx, http.MethodGet, "/sessions/"+seg(id)+"/messages"+query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SessionTranscript returns a chat session's conversation as Markdown.
+func (c *Client) SessionTranscript(ctx context.Context, id string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/sessions/"+seg(id)+"/transcript", nil)
+	if err != nil {
+		return "", err
+	}
+	c.setHeaders(req.Header)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+// ShareSession creates a read-only link to a chat session that expires
+// after expiresIn (0 for the server default of 24 hours). A client created
+// with New(baseURL, share.Token) can then read that session and stream it.
+func (c *Client) ShareSession(ctx context.Context, id string, expiresIn time.Duration) (*SessionShareResponse, error) {
+	var req SessionShareRequest
+	if expiresIn > 0 {
+		req.ExpiresIn = expiresIn.String()
+	}
+	var out SessionShareResponse
+	if err := c.do(ctx, http.MethodPost, "/sessions/"+seg(id)+"/share", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartHostSession opens a Claude terminal session for a project on the
+// server's machine. It needs a token with the admin scope.
+func (c *Client) StartHostSession(ctx context.Context, projectName string) (*HostSessionResponse, error) {
+	var out HostSessionResponse
+	if err := c.do(ctx, http.MethodPost, "/host/sessions", StartHostSessionRequest{ProjectName: projectName}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Remotes ---
+
+// ListRemotes lists the server's remote SSH hosts with their last known
+// status. It needs a token with the admin scope, as do the other remote
+// methods.
+func (c *Client) ListRemotes(ctx context.Context) ([]RemoteHostInfo, error) {
+	var out RemoteListResponse
+	if err := c.do(ctx, http.MethodGet, "/remotes", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Remotes, nil
+}
+
+// RemoteStatus checks a remote host over SSH.
+func (c *Client) RemoteStatus(ctx context.Context, name string) (*RemoteHostInfo, error) {
+	var out RemoteHostInfo
+	if err := c.do(ctx, http.MethodGet, "/remotes/"+seg(name)+"/status", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SyncRemote syncs the server's profiles to a remote host.
+func (c *Client) SyncRemote(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/remotes/"+seg(name)+"/sync", nil, nil)
+}
+
+// SetupRemote installs codes and the Claude CLI on a remote host and syncs
+// the profiles. It can take longer than the default HTTPClient timeout.
+func (c *Client) SetupRemote(ctx context.Context, name string, req RemoteSetupRequest) (*RemoteSetupResponse, error) {
+	var out RemoteSetupResponse
+	if err := c.do(ctx, http.MethodPost, "/remotes/"+seg(name)+"/setup", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Remote returns a client for the codes serve API on the server's remote host
+// name, reached through the server over SSH. It sends c's token, which needs
+// the admin scope; the server authenticates to the remote with its own.
+func (c *Client) Remote(name string) *Client {
+	rc := *c
+	rc.baseURL = c.baseURL + "/remotes/" + seg(name) + "/proxy"
+	return &rc
+}
+
+// --- Webhooks ---
+
+// ListWebhooks lists the webhook delivery targets. It needs a token with the
+// admin scope, as do the other webhook methods.
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var out WebhookListResponse
+	if err := c.do(ctx, http.MethodGet, "/webhooks", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Webhooks, nil
+}
+
+// AddWebhook adds a webhook delivery target.
+func (c *Client) AddWebhook(ctx context.Context, req CreateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, http.MethodPost, "/webhooks", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook removes the webhook with the given ID (Webhook.ID).
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+seg(id), nil, nil)
+}
+
+// TestWebhook sends a sample event to a webhook. A target that rejects it
+// or cannot be reached is reported as a *Error with status 502.
+func (c *Client) TestWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/webhooks/"+seg(id)+"/test", nil, nil)
+}
+
+// --- Teams ---
+
+// ListTeams lists all teams.
+func (c *Client) ListTeams(ctx context.Context) ([]TeamSummary, error) {
+	var out TeamListResponse
+	if err := c.do(ctx, http.MethodGet, "/teams", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Teams, nil
+}
+
+// GetTeam returns a team with its members' current status.
+func (c *Client) GetTeam(ctx context.Context, name string) (*TeamDetailResponse, error) {
+	var out TeamDetailResponse
+	if err := c.do(ctx, http.MethodGet, "/teams/"+seg(name), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTeam creates an empty team.
+func (c *Client) CreateTeam(ctx context.Context, req CreateTeamRequest) (*TeamDetailResponse, error) {
+	var out TeamDetailResponse
+	if err := c.do(ctx, http.MethodPost, "/teams", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTeam deletes a team and all of its tasks and messages.
+func (c *Client) DeleteTeam(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/teams/"+seg(name), nil, nil)
+}
+
+// StartTeam starts a daemon for every member of a team.
+func (c *Client) StartTeam(ctx context.Context, name string) ([]AgentStartResponse, error) {
+	var out StartTeamResponse
+	if err := c.do(ctx, http.MethodPost, "/teams/"+seg(name)+"/start", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// StopTeam stops all of a team's agent daemons.
+func (c *Client) StopTeam(ctx context.Context, name string) ([]AgentStopResponse, error) {
+	var out StopTeamResponse
+	if err := c.do(ctx, http.MethodPost, "/teams/"+seg(name)+"/stop", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// TeamActivity returns what each agent is doing, recent messages and task
+// counts.
+func (c *Client) TeamActivity(ctx context.Context, name string) (*TeamActivityResponse, error) {
+	var out TeamActivityResponse
+	if err := c.do(ctx, http.MethodGet, "/teams/"+seg(name)+"/activity", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AgentLogs returns the last lines of an agent daemon's log, optionally
+// filtered by a regular expression. lines <= 0 uses the server default.
+func (c *Client) AgentLogs(ctx context.Context, team, agent string, lines int, grep string) (*AgentLogsResponse, error) {
+	q := url.Values{}
+	if lines > 0 {
+		q.Set("lines", strconv.Itoa(lines))
+	}
+	if grep != "" {
+		q.Set("grep", grep)
+	}
+	var out AgentLogsResponse
+	if err := c.do(ctx, http.MethodGet, "/teams/"+seg(team)+"/agents/"+seg(agent)+"/logs"+query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Tasks ---
+
+// CreateTask adds a task to a team. Tasks with an owner are picked up by
+// that agent's daemon.
+func (c *Client) CreateTask(ctx context.Context, team string, req CreateTaskRequest) (*TaskResponse, error) {
+	var out TaskResponse
+	if err := c.do(ctx, http.MethodPost, "/teams/"+seg(team)+"/tasks", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTasks creates several tasks in a team at once, all or none. A task's
+// DependsOn lists earlier tasks in the same batch by 1-based position.
+func (c *Client) CreateTasks(ctx context.Context, team string, tasks []BatchTaskRequest) (*CreateTaskBatchResponse, error) {
+	var out CreateTaskBatchResponse
+	if err := c.do(ctx, http.MethodPost, "/teams/"+seg(team)+"/tasks/batch", CreateTaskBatchRequest{Tasks: tasks}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTasks lists a team's tasks, optionally filtered by status and owner.
+func (c *Client) ListTasks(ctx context.Context, team, status, owner string) ([]TaskResponse, error) {
+	q := url.Values{}
+	if status != "" {
+		q.Set("status", status)
+	}
+	if owner != "" {
+		q.Set("owner", owner)
+	}
+	var out TaskListResponse
+	if err := c.do(ctx, http.MethodGet, "/teams/"+seg(team)+"/tasks"+query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Tasks, nil
+}
+
+// GetTask returns a single task.
+func (c *Client) GetTask(ctx context.Context, team string, id int) (*TaskResponse, error) {
+	var out TaskResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/tasks/%s/%d", seg(team), id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTask applies an action (cancel, assign, redirect, complete, fail,
+// answer) to a task.
+func (c *Client) UpdateTask(ctx context.Context, team string, id int, req UpdateTaskRequest) (*TaskResponse, error) {
+	var out TaskResponse
+	if err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/teams/%s/tasks/%d", seg(team), id), req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Questions lists a team's human tasks that are waiting for an answer; answer
+// them with UpdateTask and action "answer".
+func (c *Client) Questions(ctx context.Context, team string) ([]TaskResponse, error) {
+	var out TaskListResponse
+	if err := c.do(ctx, http.MethodGet, "/teams/"+seg(team)+"/questions", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Tasks, nil
+}
+
+// WaitTask polls a task until it reaches a final status (completed, failed
+// or cancelled) or ctx is done. interval <= 0 polls every 2s.
+func (c *Client) WaitTask(ctx context.Context, team string, id int, interval time.Duration) (*TaskResponse, error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		task, err := c.GetTask(ctx, team, id)
+		if err != nil {
+			return nil, err
+		}
+		switch task.Status {
+		case "completed", "failed", "cancelled":
+			return task, nil
+		}
+		select {
+		case <-ctx.Done():
+			return task, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// NotificationQuery selects the notifications PollNotifications waits for.
+// Empty filters match everything.
+type NotificationQuery struct {
+	Since   int64         // NextSequence of the previous call; 0 for every buffered notification
+	Timeout time.Duration // how long the server waits; 0 means 20s, which fits HTTPClient's default timeout, and < 0 answers at once
+	Team    string
+	Agent   string
+	Status  string // "completed", "failed" or "cancelled"
+}
+
+// PollNotifications waits until an agent finishes a task matching q, or
+// q.Timeout passes, and returns the notifications after q.Since. Pass
+// NextSequence back as Since to continue where the last call left off.
+func (c *Client) PollNotifications(ctx context.Context, q NotificationQuery) (*NotificationsResponse, error) {
+	timeout := q.Timeout
+	if timeout == 0 {
+		timeout = 20 * time.Second
+	}
+	v := url.Values{}
+	v.Set("timeout", strconv.Itoa(int(max(timeout, 0)/time.Second)))
+	if q.Since > 0 {
+		v.Set("since", strconv.FormatInt(q.Since, 10))
+	}
+	if q.Team != "" {
+		v.Set("team", q.Team)
+	}
+	if q.Agent != "" {
+		v.Set("agent", q.Agent)
+	}
+	if q.Status != "" {
+		v.Set("status", q.Status)
+	}
+	var out NotificationsResponse
+	if err := c.do(ctx, http.MethodGet, "/notifications"+query(v), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TaskArtifacts lists the files a completed task produced.
+func (c *Client) TaskArtifacts(ctx context.Context, team string, id int) ([]string, error) {
+	var out ArtifactListResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/teams/%s/tasks/%d/artifacts", seg(team), id), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Artifacts, nil
+}
+
+// --- Messages ---
+
+// SendMessage posts a message to one agent, or to the whole team when req.To
+// is empty.
+func (c *Client) SendMessage(ctx context.Context, team string, req SendMessageRequest) (*MessageResponse, error) {
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/teams/"+seg(team)+"/messages", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMessages returns a team's most recent messages (limit <= 0 uses the
+// server default).
+func (c *Client) ListMessages(ctx context.Context, team string, limit int) ([]MessageResponse, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var out MessageListResponse
+	if err := c.do(ctx, http.MethodGet, "/teams/"+seg(team)+"/messages"+query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Messages, nil
+}
+
+// --- Projects & Workflows ---
+
+// ListProjects lists the registered projects.
+func (c *Client) ListProjects(ctx context.Context) ([]ProjectInfoResponse, error) {
+	var out ProjectListResponse
+	if err := c.do(ctx, http.MethodGet, "/projects", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Projects, nil
+}
+
+// GetProject returns a registered project with its git status.
+func (c *Client) GetProject(ctx context.Context, name string) (*ProjectInfoResponse, error) {
+	var out ProjectInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/projects/"+seg(name), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddProject registers a project, cloning it first if req has a GitURL.
+func (c *Client) AddProject(ctx context.Context, req CreateProjectRequest) (*ProjectInfoResponse, error) {
+	var out ProjectInfoResponse
+	if err := c.do(ctx, http.MethodPost, "/projects", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteProject unregisters a project. Its directory is left in place.
+func (c *Client) DeleteProject(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+seg(name), nil, nil)
+}
+
+// ListProfiles lists the API profiles, with secret env values redacted.
+func (c *Client) ListProfiles(ctx context.Context) ([]ProfileInfo, error) {
+	var out ProfileListResponse
+	if err := c.do(ctx, http.MethodGet, "/profiles", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Profiles, nil
+}
+
+// GetProfile returns an API profile, with secret env values redacted.
+func (c *Client) GetProfile(ctx context.Context, name string) (*ProfileInfo, error) {
+	var out ProfileInfo
+	if err := c.do(ctx, http.MethodGet, "/profiles/"+seg(name), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateProfile adds an API profile. It needs the profiles:write scope.
+func (c *Client) CreateProfile(ctx context.Context, req CreateProfileRequest) (*ProfileInfo, error) {
+	var out ProfileInfo
+	if err := c.do(ctx, http.MethodPost, "/profiles", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProfile changes an API profile. It needs the profiles:write scope.
+func (c *Client) UpdateProfile(ctx context.Context, name string, req UpdateProfileRequest) (*ProfileInfo, error) {
+	var out ProfileInfo
+	if err := c.do(ctx, http.MethodPatch, "/profiles/"+seg(name), req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteProfile removes an API profile. It needs the profiles:write scope.
+func (c *Client) DeleteProfile(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/profiles/"+seg(name), nil, nil)
+}
+
+// ListWorkflows lists the available workflows.
+func (c *Client) ListWorkflows(ctx context.Context) ([]WorkflowSummary, error) {
+	var out WorkflowListResponse
+	if err := c.do(ctx, http.MethodGet, "/workflows", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Workflows, nil
+}
+
+// RunWorkflow creates a team from a workflow and starts its agents.
+func (c *Client) RunWorkflow(ctx context.Context, name string, req RunWorkflowRequest) (*RunWorkflowResponse, error) {
+	var out RunWorkflowResponse
+	if err := c.do(ctx, http.MethodPost, "/workflows/"+seg(name)+"/run", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func query(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
@@ -0,0 +1,186 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codes/internal/chatsession"
+	"codes/internal/httpserver"
+	"codes/pkg/client"
+)
+
+// newTestServer serves the real HTTP API from a temporary HOME.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	chatsession.DefaultManager = chatsession.NewSessionManager()
+	ts := httptest.NewServer(httpserver.NewHTTPServer([]string{"test-token"}, "v1.2.0").Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClientTeamsAndTasks(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	c := client.New(ts.URL+"/", "test-token")
+
+	health, err := c.Health(ctx)
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if health.Version != "v1.2.0" {
+		t.Errorf("Health version = %q, want v1.2.0", health.Version)
+	}
+
+	team := fmt.Sprintf("sdk-test-%d", time.Now().UnixNano())
+	if _, err := c.CreateTeam(ctx, client.CreateTeamRequest{Name: team, WorkDir: t.TempDir()}); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+
+	task, err := c.CreateTask(ctx, team, client.CreateTaskRequest{Subject: "write docs", Priority: "high"})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if task.ID == 0 || task.Status != "pending" {
+		t.Errorf("CreateTask = %+v, want a pending task", task)
+	}
+
+	got, err := c.GetTask(ctx, team, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Subject != "write docs" {
+		t.Errorf("GetTask subject = %q", got.Subject)
+	}
+
+	cancelled, err := c.UpdateTask(ctx, team, task.ID, client.UpdateTaskRequest{Action: "cancel"})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if cancelled.Status != "cancelled" {
+		t.Errorf("UpdateTask status = %q, want cancelled", cancelled.Status)
+	}
+	if n := len(cancelled.History); n == 0 || cancelled.History[n-1].To != "cancelled" {
+		t.Errorf("UpdateTask history = %+v, want it to end in cancelled", cancelled.History)
+	}
+
+	final, err := c.WaitTask(ctx, team, task.ID, 10*time.Millisecond)
+	if err != nil || final.Status != "cancelled" {
+		t.Errorf("WaitTask = %+v, %v", final, err)
+	}
+
+	retryCtx := client.WithIdempotencyKey(ctx, "create-once")
+	once, err := c.CreateTask(retryCtx, team, client.CreateTaskRequest{Subject: "only once"})
+	if err != nil {
+		t.Fatalf("CreateTask with idempotency key: %v", err)
+	}
+	if again, err := c.CreateTask(retryCtx, team, client.CreateTaskRequest{Subject: "only once"}); err != nil || again.ID != once.ID {
+		t.Errorf("retried CreateTask = %+v, %v; want task %d again", again, err, once.ID)
+	}
+
+	batch, err := c.CreateTasks(ctx, team, []client.BatchTaskRequest{{Subject: "schema"}, {Subject: "migrate", DependsOn: []int{1}}})
+	if err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	if len(batch.IDs) != 2 || batch.IDs[1] != batch.IDs[0]+1 || batch.Tasks[1].Subject != "migrate" {
+		t.Errorf("CreateTasks = %+v", batch)
+	}
+
+	tasks, err := c.ListTasks(ctx, team, "cancelled", "")
+	if err != nil || len(tasks) != 1 {
+		t.Errorf("ListTasks(cancelled) = %d tasks, %v; want 1", len(tasks), err)
+	}
+
+	if _, err := c.SendMessage(ctx, team, client.SendMessageRequest{From: "sdk", Content: "hello"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	msgs, err := c.ListMessages(ctx, team, 10)
+	if err != nil || len(msgs) != 1 || msgs[0].Content != "hello" {
+		t.Errorf("ListMessages = %+v, %v", msgs, err)
+	}
+
+	notifDir := filepath.Join(os.Getenv("HOME"), ".codes", "notifications")
+	os.MkdirAll(notifDir, 0755)
+	notif := fmt.Sprintf(`{"team":%q,"taskId":%d,"subject":"write docs","status":"completed","agent":"worker","timestamp":"2026-01-02T15:04:05Z"}`, team, task.ID)
+	os.WriteFile(filepath.Join(notifDir, fmt.Sprintf("%s__%d.json", team, task.ID)), []byte(notif), 0644)
+	polled, err := c.PollNotifications(ctx, client.NotificationQuery{Team: team, Timeout: -1})
+	if err != nil || len(polled.Notifications) != 1 || polled.Notifications[0].TaskID != task.ID || polled.NextSequence != 1 {
+		t.Errorf("PollNotifications = %+v, %v", polled, err)
+	}
+
+	if err := c.DeleteTeam(ctx, team); err != nil {
+		t.Fatalf("DeleteTeam: %v", err)
+	}
+	if _, err := c.GetTeam(ctx, team); !client.IsNotFound(err) {
+		t.Errorf("GetTeam after delete: err = %v, want not found", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	_, err := client.New(ts.URL, "wrong-token").ListTeams(ctx)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "invalid token" {
+		t.Errorf("bad token: err = %v, want 401 invalid token", err)
+	}
+
+	c := client.New(ts.URL, "test-token")
+	c.Version = "v2.0.0"
+	_, err = c.ListTeams(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("major version skew: err = %v, want 426", err)
+	}
+
+	srv := httpserver.NewHTTPServer([]string{"test-token"}, "v1.2.0")
+	srv.SetReadOnly(true)
+	ro := httptest.NewServer(srv.Handler())
+	defer ro.Close()
+	_, err = client.New(ro.URL, "test-token").CreateTeam(ctx, client.CreateTeamRequest{Name: "x"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Code != "read_only" {
+		t.Errorf("read-only: err = %v, want 403 read_only", err)
+	}
+}
+
+func TestClientSubscribeSession(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	c := client.New(ts.URL, "test-token")
+
+	// Created directly so no claude process is started
+	sess, err := chatsession.DefaultManager.Create("test", t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("Create session: %v", err)
+	}
+
+	got, err := c.GetSession(ctx, sess.ID)
+	if err != nil || got.ID != sess.ID {
+		t.Fatalf("GetSession = %+v, %v", got, err)
+	}
+
+	stream, err := c.SubscribeSession(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("SubscribeSession: %v", err)
+	}
+	defer stream.Close()
+
+	ev, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if ev.Type != "session_status" || ev.Status != string(chatsession.StatusCreating) {
+		t.Errorf("first event = %+v, want session_status creating", ev)
+	}
+
+	if _, err := c.SubscribeSession(ctx, "nonexistent"); !client.IsNotFound(err) {
+		t.Errorf("SubscribeSession(nonexistent): err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// SessionStream is a live connection to a chat session's WebSocket. The
+// server sends the session's status first, then every Claude stream-json
+// event as it happens.
+type SessionStream struct {
+	conn *websocket.Conn
+}
+
+// SubscribeSession opens the event stream of a chat session. Close the
+// stream when done; cancelling ctx only bounds the handshake.
+func (c *Client) SubscribeSession(ctx context.Context, id string) (*SessionStream, error) {
+	wsURL := c.baseURL + "/sessions/" + seg(id) + "/ws"
+	switch {
+	case strings.HasPrefix(wsURL, "https://"):
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	case strings.HasPrefix(wsURL, "http://"):
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+
+	header := http.Header{}
+	c.setHeaders(header)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, responseError(resp)
+		}
+		return nil, err
+	}
+	return &SessionStream{conn: conn}, nil
+}
+
+// Next blocks until the next event arrives. It returns an error once the
+// stream is closed by either side.
+func (s *SessionStream) Next() (SessionEvent, error) {
+	var ev SessionEvent
+	_, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return ev, err
+	}
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return ev, fmt.Errorf("decode session event: %w", err)
+	}
+	return ev, nil
+}
+
+// Send sends a user message, optionally with attachments, over the stream.
+func (s *SessionStream) Send(content string, attachments ...SessionAttachment) error {
+	return s.conn.WriteJSON(map[string]any{"type": "user_message", "content": content, "attachments": attachments})
+}
+
+// Interrupt stops the session's current turn.
+func (s *SessionStream) Interrupt() error {
+	return s.conn.WriteJSON(map[string]string{"type": "interrupt"})
+}
+
+// RespondPermission answers a tool permission request the session emitted.
+func (s *SessionStream) RespondPermission(requestID string, allow bool) error {
+	return s.conn.WriteJSON(map[string]any{"type": "permission_response", "request_id": requestID, "allow": allow})
+}
+
+// Close ends the subscription; the session itself keeps running.
+func (s *SessionStream) Close() error {
+	s.conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return s.conn.Close()
+}
@@ -0,0 +1,612 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The request and response bodies of the codes HTTP API. The server in
+// internal/httpserver uses these same types, so they always match the wire
+// format.
+
+// --- General ---
+
+// ErrorResponse is the body of every non-2xx response.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`      // machine-readable reason where one is defined, e.g. "read_only", "limit_exceeded"
+	RequestID string `json:"requestId,omitempty"` // X-Request-ID of the failed request, for the server log
+}
+
+// HealthResponse is returned by GET /health.
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Version  string `json:"version,omitempty"`
+	ReadOnly bool   `json:"read_only,omitempty"` // started with codes serve --read-only
+}
+
+// StatusResponse is the body of endpoints that only acknowledge an action,
+// such as DELETE /sessions/{id}.
+type StatusResponse struct {
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// AssistantRequest is the request body for POST /assistant
+type AssistantRequest struct {
+	Text      string `json:"text"`                 // User message
+	SessionID string `json:"session_id,omitempty"` // Conversation session (default: "default")
+	Model     string `json:"model,omitempty"`      // Override model
+}
+
+// AssistantResponse is the response body for POST /assistant
+type AssistantResponse struct {
+	Reply     string `json:"reply"`
+	SessionID string `json:"session_id"`
+}
+
+// --- Sessions ---
+
+// CreateSessionRequest is the body for POST /sessions.
+type CreateSessionRequest struct {
+	ProjectName string `json:"project_name,omitempty"` // Registered project alias
+	ProjectPath string `json:"project_path,omitempty"` // Explicit path (overrides project_name)
+	Model       string `json:"model,omitempty"`        // Claude model (default: sonnet)
+	Message     string `json:"message,omitempty"`      // First user message (optional)
+	// Limits: once reached, further messages get 403 with code
+	// "limit_exceeded". Cost is known at the end of a turn, so the turn
+	// that crosses MaxCostUSD still completes.
+	MaxCostUSD float64 `json:"max_cost_usd,omitempty"` // Spend limit in USD (0 = none)
+	MaxTurns   int     `json:"max_turns,omitempty"`    // Limit on user messages (0 = none)
+}
+
+// ResumeSessionRequest is the body for POST /sessions/{id}/resume.
+type ResumeSessionRequest struct {
+	ClaudeSessionID string `json:"claude_session_id"` // Claude session ID to resume
+}
+
+// SessionSendMessageRequest is the body for POST /sessions/{id}/message.
+type SessionSendMessageRequest struct {
+	Content     string              `json:"content"`               // User message text
+	Attachments []SessionAttachment `json:"attachments,omitempty"` // Files for Claude to look at, e.g. screenshots
+}
+
+// SessionAttachment is a file sent with a chat session message. The server
+// saves it for Claude to read; PNG, JPEG, GIF and WebP images are also shown
+// to Claude directly. At most 10 per message, 10 MiB each.
+type SessionAttachment struct {
+	Name      string `json:"name"`                 // File name, e.g. "screenshot.png"
+	MediaType string `json:"media_type,omitempty"` // e.g. "image/png"; guessed from the name or content when empty
+	Data      string `json:"data"`                 // Base64-encoded content
+}
+
+// SessionShareRequest is the optional body for POST /sessions/{id}/share.
+type SessionShareRequest struct {
+	ExpiresIn string `json:"expires_in,omitempty"` // Link lifetime, e.g. "2h" (default 24h, max 720h)
+}
+
+// SessionShareResponse is a read-only share link for one session. The token
+// works as a Bearer token, or as ?share=<token>, for GET /sessions/{id} and
+// its ws, messages and transcript, and for nothing else.
+type SessionShareResponse struct {
+	Token          string    `json:"token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	WebSocketPath  string    `json:"websocket_path"`  // watch the session live
+	TranscriptPath string    `json:"transcript_path"` // Markdown transcript, e.g. to open in a browser
+}
+
+// SessionResponse is the JSON shape for a single session.
+type SessionResponse struct {
+	ID              string    `json:"id"`
+	ProjectName     string    `json:"project_name,omitempty"`
+	ProjectPath     string    `json:"project_path"`
+	Model           string    `json:"model,omitempty"`
+	ClaudeSessionID string    `json:"claude_session_id,omitempty"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastActiveAt    time.Time `json:"last_active_at"`
+	CostUSD         float64   `json:"cost_usd"`
+	TurnCount       int       `json:"turn_count"`
+	MaxCostUSD      float64   `json:"max_cost_usd,omitempty"`
+	MaxTurns        int       `json:"max_turns,omitempty"`
+	ClientCount     int       `json:"client_count"`
+}
+
+// SessionListResponse wraps a list of sessions.
+type SessionListResponse struct {
+	Sessions   []SessionResponse `json:"sessions"`
+	Total      int               `json:"total"`                 // matching items before limit/offset
+	NextOffset int               `json:"next_offset,omitempty"` // offset of the next page, when there is one
+}
+
+// SessionEvent is a message pushed to subscribers of /sessions/{id}/ws.
+type SessionEvent struct {
+	Type    string          `json:"type"`              // claude_event, session_status, session_expired, error
+	Event   json.RawMessage `json:"event,omitempty"`   // Raw Claude stream-json event
+	Status  string          `json:"status,omitempty"`  // For session_status
+	Message string          `json:"message,omitempty"` // For error and session_expired
+	Code    string          `json:"code,omitempty"`    // For error: machine-readable reason, e.g. "limit_exceeded"
+}
+
+// SessionMessage is a recorded event of a chat session, as returned by
+// GET /sessions/{id}/messages.
+type SessionMessage struct {
+	Seq   int             `json:"seq"` // 1 for the session's first event
+	Time  time.Time       `json:"time"`
+	Event json.RawMessage `json:"event"` // {"type":"user","content":...} for a user message, otherwise a raw Claude stream-json event
+}
+
+// SessionMessageListResponse is a page of a session's transcript.
+type SessionMessageListResponse struct {
+	Session    SessionResponse  `json:"session"` // status "closed" once the session is gone
+	Messages   []SessionMessage `json:"messages"`
+	Total      int              `json:"total"`                 // matching items before limit/offset
+	NextOffset int              `json:"next_offset,omitempty"` // offset of the next page, when there is one
+}
+
+// StartHostSessionRequest is the body for POST /host/sessions.
+type StartHostSessionRequest struct {
+	ProjectName string `json:"project_name"` // Registered project alias
+}
+
+// HostSessionResponse describes a terminal session opened on the server's
+// machine by POST /host/sessions.
+type HostSessionResponse struct {
+	ID          string    `json:"id"`
+	ProjectName string    `json:"project_name"`
+	ProjectPath string    `json:"project_path"`     // user@host for remote projects
+	Remote      string    `json:"remote,omitempty"` // Remote host of the project
+	PID         int       `json:"pid"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// --- Teams ---
+
+// CreateTeamRequest is the request body for POST /teams.
+type CreateTeamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	WorkDir     string `json:"work_dir,omitempty"`
+}
+
+// TeamListResponse represents the teams list response
+type TeamListResponse struct {
+	Teams      []TeamSummary `json:"teams"`
+	Total      int           `json:"total"`                 // matching items before limit/offset
+	NextOffset int           `json:"next_offset,omitempty"` // offset of the next page, when there is one
+}
+
+// TeamSummary represents a summary of a team
+type TeamSummary struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TeamDetailResponse represents detailed team information
+type TeamDetailResponse struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	WorkDir     string       `json:"work_dir,omitempty"`
+	Members     []TeamMember `json:"members"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// TeamMember represents a team member with status
+type TeamMember struct {
+	Name   string `json:"name"`
+	Role   string `json:"role,omitempty"`
+	Model  string `json:"model,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Status string `json:"status,omitempty"` // Agent status: "idle", "running", "stopped"
+	PID    int    `json:"pid,omitempty"`
+}
+
+// TeamActivityResponse represents the team activity dashboard.
+type TeamActivityResponse struct {
+	Members        []MemberActivity  `json:"members"`
+	RecentMessages []MessageResponse `json:"recent_messages"`
+	TaskStats      TaskStats         `json:"task_stats"`
+}
+
+// MemberActivity represents an agent's current activity in the team dashboard.
+type MemberActivity struct {
+	Name        string `json:"name"`
+	Role        string `json:"role,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Status      string `json:"status"`
+	CurrentTask int    `json:"current_task,omitempty"`
+	Activity    string `json:"activity,omitempty"`
+	PID         int    `json:"pid,omitempty"`
+}
+
+// TaskStats summarizes task counts by status.
+type TaskStats struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// StartTeamResponse is returned by POST /teams/{name}/start.
+type StartTeamResponse struct {
+	Results []AgentStartResponse `json:"results"`
+}
+
+// AgentStartResponse represents the result of starting a single agent.
+type AgentStartResponse struct {
+	Name    string `json:"name"`
+	Started bool   `json:"started"`
+	PID     int    `json:"pid,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// StopTeamResponse is returned by POST /teams/{name}/stop.
+type StopTeamResponse struct {
+	Results []AgentStopResponse `json:"results"`
+}
+
+// AgentStopResponse represents the result of stopping a single agent.
+type AgentStopResponse struct {
+	Name    string `json:"name"`
+	Stopped bool   `json:"stopped"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AgentLogsResponse is returned by GET /teams/{name}/agents/{agent}/logs.
+type AgentLogsResponse struct {
+	Lines []string `json:"lines"`
+	Alive bool     `json:"alive"`
+}
+
+// --- Tasks ---
+
+// CreateTaskRequest is the request body for POST /teams/{name}/tasks.
+type CreateTaskRequest struct {
+	Subject      string   `json:"subject"`
+	Description  string   `json:"description,omitempty"`
+	Owner        string   `json:"owner,omitempty"`
+	Priority     string   `json:"priority,omitempty"`
+	BlockedBy    []int    `json:"blocked_by,omitempty"`
+	Project      string   `json:"project,omitempty"`
+	WorkDir      string   `json:"work_dir,omitempty"`
+	Artifacts    []string `json:"artifacts,omitempty"`     // output paths/globs collected on completion
+	ContextFiles []string `json:"context_files,omitempty"` // files inlined into the prompt when the task starts
+	Type         string   `json:"type,omitempty"`          // "human" for a question to the human instead of agent work
+	Choices      []string `json:"choices,omitempty"`       // allowed answers to a human task
+}
+
+// CreateTaskBatchRequest is the request body for POST
+// /teams/{name}/tasks/batch.
+type CreateTaskBatchRequest struct {
+	Tasks []BatchTaskRequest `json:"tasks"`
+}
+
+// BatchTaskRequest is one task in a CreateTaskBatchRequest.
+type BatchTaskRequest struct {
+	Subject      string   `json:"subject"`
+	Description  string   `json:"description,omitempty"`
+	Owner        string   `json:"owner,omitempty"`
+	Priority     string   `json:"priority,omitempty"`
+	BlockedBy    []int    `json:"blocked_by,omitempty"` // IDs of existing tasks
+	DependsOn    []int    `json:"depends_on,omitempty"` // 1-based positions of earlier tasks in the batch
+	Skills       []string `json:"skills,omitempty"`     // skills the owner must have
+	Project      string   `json:"project,omitempty"`
+	WorkDir      string   `json:"work_dir,omitempty"`
+	Artifacts    []string `json:"artifacts,omitempty"`
+	ContextFiles []string `json:"context_files,omitempty"`
+	Type         string   `json:"type,omitempty"`
+	Choices      []string `json:"choices,omitempty"`
+}
+
+// CreateTaskBatchResponse is the response for POST
+// /teams/{name}/tasks/batch: the created tasks and their IDs, both in
+// request order.
+type CreateTaskBatchResponse struct {
+	Tasks []TaskResponse `json:"tasks"`
+	IDs   []int          `json:"ids"`
+}
+
+// UpdateTaskRequest is the request body for PATCH /teams/{name}/tasks/{id}.
+type UpdateTaskRequest struct {
+	Action       string `json:"action"` // "cancel", "assign", "redirect", "followup", "complete", "fail", "answer"
+	Owner        string `json:"owner,omitempty"`
+	Subject      string `json:"subject,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+	Result       string `json:"result,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Answer       string `json:"answer,omitempty"` // answer to a human task
+}
+
+// TaskResponse represents the task status response
+type TaskResponse struct {
+	ID           int              `json:"id"`
+	Subject      string           `json:"subject"`
+	Description  string           `json:"description,omitempty"`
+	Status       string           `json:"status"`
+	Priority     string           `json:"priority,omitempty"`
+	Owner        string           `json:"owner,omitempty"`
+	Type         string           `json:"type,omitempty"`    // "human" for a question to the human
+	Choices      []string         `json:"choices,omitempty"` // allowed answers to a human task
+	Project      string           `json:"project,omitempty"`
+	WorkDir      string           `json:"work_dir,omitempty"`
+	FollowUpOf   int              `json:"follow_up_of,omitempty"` // completed task whose session this one resumes
+	RequestID    string           `json:"request_id,omitempty"`   // X-Request-ID of the API request that created or last redirected it
+	Result       string           `json:"result,omitempty"`
+	AnsweredBy   string           `json:"answered_by,omitempty"` // who answered a human task
+	Summary      *TaskSummary     `json:"summary,omitempty"`     // digest of a long result
+	Error        string           `json:"error,omitempty"`
+	Artifacts    []string         `json:"artifacts,omitempty"`
+	ContextFiles []string         `json:"context_files,omitempty"`
+	History      []TaskTransition `json:"history,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	AskedAt      *time.Time       `json:"asked_at,omitempty"` // when a human task's question was posted
+	CompletedAt  *time.Time       `json:"completed_at,omitempty"`
+}
+
+// TaskSummary is a structured digest of a long task result.
+type TaskSummary struct {
+	Changes   string   `json:"changes"`
+	Files     []string `json:"files,omitempty"`
+	FollowUps []string `json:"follow_ups,omitempty"`
+}
+
+// TaskTransition records one status change in a task's history.
+type TaskTransition struct {
+	From string    `json:"from,omitempty"` // empty for the initial status at creation
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// TaskListResponse wraps a list of tasks.
+type TaskListResponse struct {
+	Tasks      []TaskResponse `json:"tasks"`
+	Total      int            `json:"total"`                 // matching items before limit/offset
+	NextOffset int            `json:"next_offset,omitempty"` // offset of the next page, when there is one
+}
+
+// ArtifactListResponse is returned by GET /teams/{name}/tasks/{id}/artifacts.
+type ArtifactListResponse struct {
+	Artifacts []string `json:"artifacts"`
+}
+
+// Notification reports that an agent finished a task, from GET
+// /notifications.
+type Notification struct {
+	Sequence  int64        `json:"sequence"` // position in the server's feed; pass the last one back as since
+	Team      string       `json:"team"`
+	TaskID    int          `json:"task_id"`
+	Subject   string       `json:"subject"`
+	Status    string       `json:"status"` // "completed", "failed" or "cancelled"
+	Agent     string       `json:"agent"`
+	Result    string       `json:"result,omitempty"` // truncated; see the task for the full result
+	Summary   *TaskSummary `json:"summary,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// NotificationsResponse is returned by GET /notifications.
+type NotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	NextSequence  int64          `json:"next_sequence"` // since for the next call
+	TimedOut      bool           `json:"timed_out"`     // nothing new arrived before the timeout
+}
+
+// --- Messages ---
+
+// SendMessageRequest is the request body for POST /teams/{name}/messages.
+type SendMessageRequest struct {
+	From    string `json:"from"`
+	To      string `json:"to,omitempty"` // empty = broadcast
+	Content string `json:"content"`
+}
+
+// MessageListResponse wraps a list of messages.
+type MessageListResponse struct {
+	Messages   []MessageResponse `json:"messages"`
+	Total      int               `json:"total"`                 // matching items before limit/offset
+	NextOffset int               `json:"next_offset,omitempty"` // offset of the next page, when there is one
+}
+
+// MessageResponse represents a message in the HTTP response.
+type MessageResponse struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	From      string    `json:"from"`
+	To        string    `json:"to,omitempty"`
+	Content   string    `json:"content"`
+	TaskID    int       `json:"task_id,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// --- Projects & Profiles ---
+
+// ProjectListResponse represents the list of projects.
+type ProjectListResponse struct {
+	Projects []ProjectInfoResponse `json:"projects"`
+}
+
+// ProjectInfoResponse represents a project entry in API responses.
+// The git fields are only filled in by GET /projects/{name}, and only for
+// local projects.
+type ProjectInfoResponse struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Host      string `json:"host,omitempty"`
+	Missing   bool   `json:"missing,omitempty"` // the directory does not exist
+	GitRemote string `json:"git_remote,omitempty"`
+	GitBranch string `json:"git_branch,omitempty"`
+	GitDirty  bool   `json:"git_dirty,omitempty"`
+}
+
+// CreateProjectRequest registers a project. Give either Path, an existing
+// directory, or GitURL, a repository to clone (into Path if set, otherwise
+// into the projects directory). Name defaults to the directory or repository
+// name.
+type CreateProjectRequest struct {
+	Name   string `json:"name,omitempty"`
+	Path   string `json:"path,omitempty"`
+	GitURL string `json:"git_url,omitempty"`
+	Host   string `json:"host,omitempty"` // remote host the path is on; not supported with git_url
+}
+
+// ProfileListResponse represents the list of API profiles.
+type ProfileListResponse struct {
+	Profiles []ProfileInfo `json:"profiles"`
+}
+
+// ProfileInfo represents a safe (no secrets) view of an API profile: env
+// values that look like secrets (tokens, keys, passwords) are "<REDACTED>".
+type ProfileInfo struct {
+	Name            string            `json:"name"`
+	IsDefault       bool              `json:"is_default"`
+	Env             map[string]string `json:"env,omitempty"`
+	SkipPermissions *bool             `json:"skip_permissions,omitempty"` // nil = global setting
+	Status          string            `json:"status,omitempty"`           // "active", "inactive" or empty if never tested
+}
+
+// CreateProfileRequest represents a request to add an API profile.
+type CreateProfileRequest struct {
+	Name            string            `json:"name"`
+	Env             map[string]string `json:"env"` // e.g. ANTHROPIC_BASE_URL, ANTHROPIC_AUTH_TOKEN
+	SkipPermissions *bool             `json:"skip_permissions,omitempty"`
+	Default         bool              `json:"default,omitempty"` // also make it the default profile
+}
+
+// UpdateProfileRequest represents a change to an API profile. Env is merged
+// into the profile's env: an empty value removes the variable and
+// "<REDACTED>" keeps the current one, so a redacted profile can be sent back
+// with just the changes.
+type UpdateProfileRequest struct {
+	Env             map[string]string `json:"env,omitempty"`
+	SkipPermissions *bool             `json:"skip_permissions,omitempty"`
+}
+
+// SwitchProfileRequest represents a request to switch the active profile.
+type SwitchProfileRequest struct {
+	Name string `json:"name"`
+}
+
+// SwitchProfileResponse represents the result of switching profiles.
+type SwitchProfileResponse struct {
+	Message string `json:"message"`
+	Active  string `json:"active"`
+}
+
+// --- Workflows ---
+
+// WorkflowListResponse represents the list of workflows.
+type WorkflowListResponse struct {
+	Workflows []WorkflowSummary `json:"workflows"`
+}
+
+// WorkflowSummary represents a workflow in list responses.
+type WorkflowSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	AgentCount  int    `json:"agent_count"`
+	TaskCount   int    `json:"task_count"`
+	BuiltIn     bool   `json:"built_in"`
+}
+
+// RunWorkflowRequest represents a request to run a workflow.
+type RunWorkflowRequest struct {
+	WorkDir string `json:"work_dir,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Project string `json:"project,omitempty"`
+}
+
+// RunWorkflowResponse represents the result of running a workflow.
+type RunWorkflowResponse struct {
+	TeamName      string `json:"team_name"`
+	AgentsStarted int    `json:"agents_started"`
+	TasksCreated  int    `json:"tasks_created"`
+}
+
+// --- Audit ---
+
+// AuditEntry is one state-changing API or MCP operation, from GET /audit.
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source"`               // "http" or "mcp"
+	Actor     string    `json:"actor"`                // token name or hash, MCP client name, or "anonymous"
+	Action    string    `json:"action"`               // "POST /teams/x/tasks" or the MCP tool name
+	Params    string    `json:"params,omitempty"`     // SHA-256 digest of the request body or tool arguments
+	Result    string    `json:"result"`               // "ok" or "error"
+	Status    int       `json:"status,omitempty"`     // HTTP status
+	Error     string    `json:"error,omitempty"`      // MCP tool error
+	RequestID string    `json:"request_id,omitempty"` // X-Request-ID of the HTTP request
+}
+
+// AuditResponse is returned by GET /audit, newest entries first.
+type AuditResponse struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// --- Remotes ---
+
+// RemoteHostStatus is what was found on a remote host when it was checked.
+type RemoteHostStatus struct {
+	CodesInstalled  bool   `json:"codes_installed"`
+	CodesVersion    string `json:"codes_version,omitempty"`
+	ClaudeInstalled bool   `json:"claude_installed"`
+	OS              string `json:"os,omitempty"`
+	Arch            string `json:"arch,omitempty"`
+}
+
+// RemoteHostInfo is a remote SSH host from the server's config.
+type RemoteHostInfo struct {
+	Name   string            `json:"name"`
+	Host   string            `json:"host"`
+	User   string            `json:"user,omitempty"`
+	Port   int               `json:"port,omitempty"`
+	Status *RemoteHostStatus `json:"status,omitempty"` // last known; nil if never checked
+}
+
+// RemoteListResponse is returned by GET /remotes.
+type RemoteListResponse struct {
+	Remotes []RemoteHostInfo `json:"remotes"`
+}
+
+// RemoteSetupRequest is the body for POST /remotes/{name}/setup.
+type RemoteSetupRequest struct {
+	RateLimit  string `json:"rate_limit,omitempty"` // cap download bandwidth, e.g. 500k or 2M
+	SkipClaude bool   `json:"skip_claude,omitempty"`
+}
+
+// RemoteSetupResponse is returned by POST /remotes/{name}/setup.
+type RemoteSetupResponse struct {
+	Stages      []string       `json:"stages"`
+	ClaudeError string         `json:"claude_error,omitempty"` // Claude CLI install failure (non-fatal)
+	Remote      RemoteHostInfo `json:"remote"`
+}
+
+// --- Webhooks ---
+
+// Webhook is a notification delivery target.
+type Webhook struct {
+	ID     string            `json:"id"` // name, or URL if unnamed; used in /webhooks/{id}
+	Name   string            `json:"name,omitempty"`
+	URL    string            `json:"url"`
+	Format string            `json:"format"`           // slack, feishu, dingtalk, telegram or custom
+	Events []string          `json:"events,omitempty"` // task_completed, task_failed, task_cancelled; empty means all
+	Extra  map[string]string `json:"extra,omitempty"`  // format parameters, e.g. telegram chat_id or custom template
+}
+
+// WebhookListResponse is returned by GET /webhooks.
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// CreateWebhookRequest is the body for POST /webhooks.
+type CreateWebhookRequest struct {
+	Name   string            `json:"name,omitempty"`
+	URL    string            `json:"url"`
+	Format string            `json:"format,omitempty"` // default slack
+	Events []string          `json:"events,omitempty"`
+	Extra  map[string]string `json:"extra,omitempty"`
+}
@@ -0,0 +1,139 @@
+// Package schemas publishes the JSON Schemas for the payloads codes sends to
+// integrations: task notifications and callbacks, hook stdin, webhook bodies
+// and session stream events. The HTTP server serves them at /schemas/.
+//
+// Each schema is versioned in its file name. A version only ever gains
+// optional fields; anything else gets a new version next to the old one.
+package schemas
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Published schema files.
+const (
+	TaskNotification = "task-notification.v1.json"
+	Hook             = "hook.v1.json"
+	HookEvent        = "hook-event.v1.json"
+	Webhook          = "webhook.v1.json"
+	SessionEvent     = "session-event.v1.json"
+)
+
+// ErrNotFound is returned for a schema file that doesn't exist.
+var ErrNotFound = errors.New("schema not found")
+
+// Schema describes one published schema.
+type Schema struct {
+	Name        string `json:"name"`    // e.g. "task-notification"
+	Version     int    `json:"version"` // e.g. 1
+	File        string `json:"file"`    // e.g. "task-notification.v1.json"
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// List returns all published schemas, sorted by file name.
+func List() ([]Schema, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Schema, 0, len(entries))
+	for _, e := range entries {
+		s, err := describe(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].File < list[j].File })
+	return list, nil
+}
+
+// Get returns the raw schema document stored in file.
+func Get(file string) ([]byte, error) {
+	if strings.Contains(file, "/") || !strings.HasSuffix(file, ".json") {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, file)
+	}
+	data, err := files.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, file)
+	}
+	return data, nil
+}
+
+// Validate checks a JSON document against the schema stored in file.
+func Validate(file string, data []byte) error {
+	rs, err := resolve(file)
+	if err != nil {
+		return err
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return fmt.Errorf("parse instance: %w", err)
+	}
+	if err := rs.Validate(instance); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// resolve loads and prepares the schema stored in file for validation.
+func resolve(file string) (*jsonschema.Resolved, error) {
+	data, err := Get(file)
+	if err != nil {
+		return nil, err
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+	// $id is relative to whichever server publishes the schema; any
+	// absolute base will do for resolving it here.
+	rs, err := s.Resolve(&jsonschema.ResolveOptions{BaseURI: "http://localhost/"})
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", file, err)
+	}
+	return rs, nil
+}
+
+// describe builds the Schema entry for file from its name and contents.
+func describe(file string) (Schema, error) {
+	base := strings.TrimSuffix(file, ".json")
+	dot := strings.LastIndex(base, ".v")
+	if dot < 0 {
+		return Schema{}, fmt.Errorf("schema %s: file name has no version", file)
+	}
+	version, err := strconv.Atoi(base[dot+2:])
+	if err != nil {
+		return Schema{}, fmt.Errorf("schema %s: bad version: %w", file, err)
+	}
+	data, err := Get(file)
+	if err != nil {
+		return Schema{}, err
+	}
+	var doc struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Schema{}, fmt.Errorf("parse %s: %w", file, err)
+	}
+	return Schema{
+		Name:        base[:dot],
+		Version:     version,
+		File:        file,
+		Title:       doc.Title,
+		Description: doc.Description,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestListAndResolve(t *testing.T) {
+	list, err := List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := map[string]bool{TaskNotification: true, Hook: true, HookEvent: true, Webhook: true, SessionEvent: true}
+	if len(list) != len(want) {
+		t.Errorf("List returned %d schemas, want %d", len(list), len(want))
+	}
+	for _, s := range list {
+		if !want[s.File] {
+			t.Errorf("unexpected schema %s", s.File)
+		}
+		if s.Name == "" || s.Version != 1 || s.Title == "" || s.Description == "" {
+			t.Errorf("incomplete entry %+v", s)
+		}
+		if _, err := resolve(s.File); err != nil {
+			t.Errorf("resolve: %v", err)
+		}
+		data, _ := Get(s.File)
+		var doc struct {
+			ID string `json:"$id"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil || doc.ID != "/schemas/"+s.File {
+			t.Errorf("%s: $id = %q, want /schemas/%s", s.File, doc.ID, s.File)
+		}
+	}
+
+	for _, file := range []string{"missing.v1.json", "../schemas.go", "schemas.go"} {
+		if _, err := Get(file); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want ErrNotFound", file, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		file  string
+		doc   string
+		valid bool
+	}{
+		{TaskNotification, `{"team":"t","taskId":1,"subject":"s","status":"completed","agent":"a","result":"ok","timestamp":"2026-01-01T00:00:00Z"}`, true},
+		{TaskNotification, `{"team":"t","taskId":1,"subject":"s","status":"done","agent":"a","timestamp":"2026-01-01T00:00:00Z"}`, false},
+		{TaskNotification, `{"team":"t","subject":"s","status":"failed","agent":"a","timestamp":"2026-01-01T00:00:00Z"}`, false},
+		{HookEvent, `{"event":"on_agent_stopped","timestamp":"2026-01-01T00:00:00Z","agent":{"team":"t","name":"a","pid":42,"reason":"signal"}}`, true},
+		{HookEvent, `{"event":"on_team_created","timestamp":"2026-01-01T00:00:00Z","team":{"name":"t"}}`, true},
+		{HookEvent, `{"event":"on_task_started","timestamp":"2026-01-01T00:00:00Z","team":{"name":"t"}}`, false},
+		{HookEvent, `{"event":"on_task_completed","timestamp":"2026-01-01T00:00:00Z"}`, false},
+		{Webhook, `{"text":"hi"}`, true},
+		{Webhook, `{"msgtype":"text","text":{"content":"hi"}}`, true},
+		{Webhook, `{"text":"hi","extra":1}`, false},
+		{SessionEvent, `{"type":"session_status","status":"ready"}`, true},
+		{SessionEvent, `{"type":"claude_event","event":{"type":"assistant"}}`, true},
+		{SessionEvent, `{"type":"error"}`, false},
+		{SessionEvent, `{"type":"session_expired","message":"session closed after 24h0m0s without activity"}`, true},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.file, []byte(tt.doc))
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%s, %s) = %v, want valid=%v", tt.file, tt.doc, err, tt.valid)
+		}
+	}
+}